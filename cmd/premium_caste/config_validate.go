@@ -0,0 +1,24 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"premium_caste/internal/config"
+)
+
+// runConfigValidate загружает конфиг и проверяет значения, не подключаясь к базе.
+// Все ошибки выводятся построчно, код завершения 1, если они есть
+func runConfigValidate() int {
+	cfg, err := config.LoadFromFlags()
+	if err == nil {
+		err = cfg.Validate()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "config is invalid:\n%v\n", err)
+		return exitError
+	}
+
+	fmt.Fprintln(os.Stdout, "config is valid")
+	return exitOK
+}
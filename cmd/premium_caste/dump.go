@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"time"
+
+	"premium_caste/internal/config"
+	"premium_caste/internal/domain/models"
+	"premium_caste/internal/repository"
+)
+
+// dumpFormat отличает дамп этой команды от произвольного JSON
+const dumpFormat = "premium_caste-export"
+
+// dumpFile содержимое файла export. Файлы медиа в дамп не входят,
+// каталог file_storage.base_dir переносится отдельно
+type dumpFile struct {
+	Format        string             `json:"format"`
+	SchemaVersion int64              `json:"schema_version"` // Версия миграций базы, из которой сделан дамп
+	ExportedAt    time.Time          `json:"exported_at"`
+	Tables        []models.TableDump `json:"tables"`
+}
+
+// runExport выгружает данные приложения в JSON
+func runExport() int {
+	output := flag.String("output", "-", "file to write the dump to, - for stdout")
+
+	cfg := config.MustLoad()
+	log := setupLogger(cfg, os.Stderr)
+
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	err := func() error {
+		version, err := schemaVersion(ctx, log, cfg.DSN)
+		if err != nil {
+			return err
+		}
+
+		repo, closeRepo, err := openRepository(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		defer closeRepo()
+
+		tables, err := repo.Maintenance.ExportTables(ctx)
+		if err != nil {
+			return err
+		}
+
+		dump := dumpFile{
+			Format:        dumpFormat,
+			SchemaVersion: version,
+			ExportedAt:    time.Now().UTC(),
+			Tables:        tables,
+		}
+
+		return writeDump(*output, dump)
+	}()
+
+	return exitCode(log, err)
+}
+
+// runImport загружает дамп export в базу той же версии схемы. Строки, которые
+// уже есть в базе, пропускаются, поэтому повторный импорт безопасен
+func runImport() int {
+	input := flag.String("input", "-", "dump file to read, - for stdin")
+
+	cfg := config.MustLoad()
+	log := setupLogger(cfg, os.Stderr)
+
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	err := func() error {
+		dump, err := readDump(*input)
+		if err != nil {
+			return err
+		}
+
+		version, err := schemaVersion(ctx, log, cfg.DSN)
+		if err != nil {
+			return err
+		}
+		if version != dump.SchemaVersion {
+			return fmt.Errorf("dump schema version %d does not match database version %d, run migrate to %d first",
+				dump.SchemaVersion, version, dump.SchemaVersion)
+		}
+
+		repo, closeRepo, err := openRepository(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		defer closeRepo()
+
+		inserted, err := repo.Maintenance.ImportTables(ctx, dump.Tables)
+		if err != nil {
+			return err
+		}
+
+		for _, table := range repository.DumpTables {
+			if n, ok := inserted[table]; ok {
+				fmt.Fprintf(os.Stdout, "%s\t%d\n", table, n)
+			}
+		}
+
+		return nil
+	}()
+
+	return exitCode(log, err)
+}
+
+// schemaVersion возвращает версию последней примененной миграции
+func schemaVersion(ctx context.Context, log *slog.Logger, dsn string) (int64, error) {
+	m, closeDB, err := openMigrator(ctx, log, dsn)
+	if err != nil {
+		return 0, err
+	}
+	defer closeDB()
+
+	return m.Version(ctx)
+}
+
+func writeDump(path string, dump dumpFile) error {
+	if path == "-" {
+		return encodeDump(os.Stdout, dump)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create dump file: %w", err)
+	}
+
+	if err := encodeDump(file, dump); err != nil {
+		file.Close()
+		os.Remove(path)
+		return err
+	}
+
+	return file.Close()
+}
+
+func encodeDump(w io.Writer, dump dumpFile) error {
+	if err := json.NewEncoder(w).Encode(dump); err != nil {
+		return fmt.Errorf("failed to write dump: %w", err)
+	}
+
+	return nil
+}
+
+func readDump(path string) (*dumpFile, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open dump file: %w", err)
+		}
+		defer file.Close()
+		r = file
+	}
+
+	var dump dumpFile
+	if err := json.NewDecoder(r).Decode(&dump); err != nil {
+		return nil, fmt.Errorf("failed to read dump: %w", err)
+	}
+	if dump.Format != dumpFormat {
+		return nil, fmt.Errorf("%w: not a %s dump", errUsage, dumpFormat)
+	}
+
+	return &dump, nil
+}
@@ -2,76 +2,163 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
 	"os/signal"
+	"slices"
+	"strings"
 	"syscall"
 
-	"premium_caste/internal/app"
 	"premium_caste/internal/config"
+	"premium_caste/internal/lib/actor"
+	"premium_caste/internal/repository"
 	redisapp "premium_caste/internal/storage/redis"
 )
 
+// Коды завершения процесса
 const (
-	envLocal = "local"
-	envDev   = "dev"
-	envProd  = "prod"
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
 )
 
+// cliUserAgent отмечает в журнале аудита действия, выполненные подкомандами
+const cliUserAgent = "premium_caste/cli"
+
+// errUsage неверные аргументы подкоманды
+var errUsage = errors.New("invalid arguments")
+
+// command подкоманда бинарника. Флаги подкоманда регистрирует в flag.CommandLine
+// до вызова config.MustLoad, который их и разбирает вместе с --config
+type command struct {
+	name    string
+	args    string // Позиционные аргументы для справки
+	summary string
+	details string // Дополнительная справка после summary
+	run     func() int
+}
+
+var commands = []command{
+	{name: "serve", summary: "start the HTTP server (default)", run: runServe},
+	{name: "migrate", args: "<command>", summary: "apply or roll back database migrations", details: migrateDetails, run: runMigrate},
+	{name: "create-admin", summary: "create an administrator account", run: runCreateAdmin},
+	{name: "reset-password", summary: "set a new password for a user and revoke their sessions", run: runResetPassword},
+	{name: "reindex-search", summary: "rebuild search indexes and drop cached feeds", run: runReindexSearch},
+	{name: "gc-media", summary: "delete media files nothing refers to", run: runGCMedia},
+	{name: "audit-prune", summary: "delete audit events older than the retention period", run: runAuditPrune},
+	{name: "export", summary: "dump application data to JSON", run: runExport},
+	{name: "import", summary: "load application data from an export dump", run: runImport},
+	{name: "config validate", summary: "check the config file and exit", run: runConfigValidate},
+}
+
 func main() {
+	os.Exit(run(os.Args[1:]))
+}
 
-	cfg := config.MustLoad()
+// run выбирает подкоманду по первым аргументам. Без подкоманды или с флагом
+// на первом месте запускается serve, как раньше
+func run(args []string) int {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return runCommand(commands[0], args)
+	}
 
-	log := setupLogger(cfg.Env)
+	if args[0] == "help" {
+		printUsage(os.Stdout)
+		return exitOK
+	}
 
-	redisClient := redisapp.NewClient(cfg.Redis.RedisAddr, cfg.Redis.RedisPassword, cfg.Redis.RedisDB)
-	if err := redisClient.HealthCheck(context.Background()); err != nil {
-		panic("Failed to connect to Redis")
+	for _, cmd := range commands {
+		words := strings.Fields(cmd.name)
+		if len(args) >= len(words) && slices.Equal(args[:len(words)], words) {
+			return runCommand(cmd, args[len(words):])
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", strings.Join(args, " "))
+	printUsage(os.Stderr)
+	return exitUsage
+}
+
+// runCommand готовит flag.CommandLine и os.Args для подкоманды: config.MustLoad
+// разбирает глобальный набор флагов, поэтому имя подкоманды из аргументов убирается
+func runCommand(cmd command, args []string) int {
+	flag.CommandLine = flag.NewFlagSet(cmd.name, flag.ExitOnError)
+	flag.CommandLine.Usage = func() {
+		out := flag.CommandLine.Output()
+		fmt.Fprintf(out, "usage: %s\n\n%s\n\n", strings.TrimSpace("premium_caste "+cmd.name+" [flags] "+cmd.args), cmd.summary)
+		if cmd.details != "" {
+			fmt.Fprintf(out, "%s\n", cmd.details)
+		}
+		fmt.Fprintf(out, "flags:\n")
+		flag.PrintDefaults()
 	}
+	os.Args = append([]string{os.Args[0]}, args...)
 
-	application := app.New(log, redisClient, cfg.DSN, cfg.HTTP.Host, cfg.HTTP.Port, cfg.TokenTTL, cfg.FileStorage.BaseDir, cfg.FileStorage.BaseURL)
+	return cmd.run()
+}
 
-	go func() {
-		application.HTTPServer.BuildRouters()
-		application.HTTPServer.MustRun()
-	}()
+func printUsage(w io.Writer) {
+	fmt.Fprintf(w, "usage: premium_caste <command> [flags]\n\ncommands:\n")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-16s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(w, "\nRun 'premium_caste <command> -h' for command flags.\n")
+}
 
-	// Graceful shutdown
-	stop := make(chan os.Signal, 1)
-	signal.Notify(stop, syscall.SIGTERM, syscall.SIGINT)
+// exitCode печатает ошибку подкоманды и возвращает код завершения
+func exitCode(log *slog.Logger, err error) int {
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.Is(err, errUsage):
+		fmt.Fprintf(os.Stderr, "%v\n\n", err)
+		flag.CommandLine.Usage()
+		return exitUsage
+	default:
+		log.Error("command failed", slog.String("command", flag.CommandLine.Name()), slog.String("error", err.Error()))
+		return exitError
+	}
+}
 
-	<-stop
-	application.HTTPServer.Stop()
-	redisClient.Close()
-	application.Repo.Close()
+// signalContext отменяется по SIGINT и SIGTERM, чтобы команды прерывались корректно.
+// Действия команд попадают в журнал аудита с User-Agent cliUserAgent
+func signalContext() (context.Context, context.CancelFunc) {
+	ctx := actor.WithClient(context.Background(), "", cliUserAgent)
+	return signal.NotifyContext(ctx, syscall.SIGTERM, syscall.SIGINT)
+}
 
-	log.Info("Gracefully stopped")
-	log.Info("application stop")
+// openRepository подключается к Postgres и Redis теми же конструкторами, что и app.New
+func openRepository(ctx context.Context, cfg *config.Config) (*repository.Repository, func(), error) {
+	redisClient := redisapp.NewClient(cfg.Redis.RedisAddr, cfg.Redis.RedisPassword, cfg.Redis.RedisDB)
+
+	repo, err := repository.NewRepository(ctx, cfg.DSN, redisClient)
+	if err != nil {
+		redisClient.Close()
+		return nil, nil, err
+	}
+
+	return repo, func() {
+		repo.Close()
+		redisClient.Close()
+	}, nil
 }
 
-func setupLogger(env string) *slog.Logger {
-	var log *slog.Logger
-
-	switch env {
-	case envLocal:
-		log = slog.New(
-			slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-				Level: slog.LevelDebug,
-			}),
-		)
-	case envDev:
-		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-				Level: slog.LevelDebug,
-			}),
-		)
-	case envProd:
-		log = slog.New(
-			slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-				Level: slog.LevelInfo,
-			}),
-		)
+// logLevel уровень логирования. Общий для всех обработчиков, чтобы serve мог
+// поменять его по SIGHUP без пересоздания логгера
+var logLevel slog.LevelVar
+
+// setupLogger создает логгер окружения cfg.Env с уровнем из log_level. Служебные
+// команды пишут лог в stderr, чтобы он не смешивался с их выводом
+func setupLogger(cfg *config.Config, w io.Writer) *slog.Logger {
+	logLevel.Set(cfg.SlogLevel())
+
+	if cfg.Env == config.EnvLocal {
+		return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: &logLevel}))
 	}
 
-	return log
+	return slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: &logLevel}))
 }
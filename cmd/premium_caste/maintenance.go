@@ -0,0 +1,132 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"premium_caste/internal/config"
+	audit "premium_caste/internal/services/audit_service"
+	media "premium_caste/internal/services/media_service"
+	storage "premium_caste/internal/storage/filestorage"
+)
+
+// runReindexSearch перестраивает индексы поиска и списков и сбрасывает кеш лент и sitemap,
+// которые собираются из тех же таблиц
+func runReindexSearch() int {
+	cfg := config.MustLoad()
+	log := setupLogger(cfg, os.Stderr)
+
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	err := func() error {
+		repo, closeRepo, err := openRepository(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		defer closeRepo()
+
+		tables, err := repo.Maintenance.ReindexSearch(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stdout, "reindexed: %s\n", strings.Join(tables, ", "))
+
+		if err := repo.FeedCache.Invalidate(ctx); err != nil {
+			return fmt.Errorf("indexes rebuilt, but feed cache was not invalidated: %w", err)
+		}
+		fmt.Fprintln(os.Stdout, "feed cache invalidated")
+
+		return nil
+	}()
+
+	return exitCode(log, err)
+}
+
+// runGCMedia удаляет медиа без ссылок вместе с файлами
+func runGCMedia() int {
+	minAge := flag.Duration("min-age", 24*time.Hour, "keep media uploaded more recently than this, so uploads in progress are not collected")
+	dryRun := flag.Bool("dry-run", false, "only list media that would be deleted")
+
+	cfg := config.MustLoad()
+	log := setupLogger(cfg, os.Stderr)
+
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	err := func() error {
+		if *minAge < 0 {
+			return fmt.Errorf("%w: --min-age must not be negative", errUsage)
+		}
+
+		repo, closeRepo, err := openRepository(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		defer closeRepo()
+
+		fileStorage, err := storage.NewLocalFileStorage(cfg.FileStorage.BaseDir, cfg.FileStorage.BaseURL)
+		if err != nil {
+			return fmt.Errorf("failed to init file storage: %w", err)
+		}
+
+		mediaService := media.NewMediaService(log, repo.Media, repo.Tx, fileStorage, audit.NewAuditService(log, repo.Audit, cfg.Audit.Retention))
+		result, err := mediaService.CollectGarbage(ctx, *minAge, *dryRun)
+		if result != nil {
+			for _, m := range result.Media {
+				fmt.Fprintf(os.Stdout, "%s\t%s\t%d\n", m.ID, m.StoragePath, m.FileSize)
+			}
+
+			verb := "deleted"
+			if result.DryRun {
+				verb = "would delete"
+			}
+			fmt.Fprintf(os.Stdout, "%s %d media, %d bytes\n", verb, len(result.Media), result.FreedBytes)
+		}
+		if err != nil {
+			return err
+		}
+		if result.FileErrors > 0 {
+			return fmt.Errorf("%d files could not be deleted, see the log", result.FileErrors)
+		}
+
+		return nil
+	}()
+
+	return exitCode(log, err)
+}
+
+// runAuditPrune удаляет записи журнала аудита старше срока хранения из конфигурации
+func runAuditPrune() int {
+	cfg := config.MustLoad()
+	log := setupLogger(cfg, os.Stderr)
+
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	err := func() error {
+		if cfg.Audit.Retention == 0 {
+			fmt.Fprintln(os.Stdout, "audit retention is not set, nothing to prune")
+			return nil
+		}
+
+		repo, closeRepo, err := openRepository(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		defer closeRepo()
+
+		deleted, err := audit.NewAuditService(log, repo.Audit, cfg.Audit.Retention).Prune(ctx)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stdout, "deleted %d audit events older than %s\n", deleted, cfg.Audit.Retention)
+		return nil
+	}()
+
+	return exitCode(log, err)
+}
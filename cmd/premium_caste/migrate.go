@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"premium_caste/internal/config"
+	"premium_caste/internal/storage/migrator"
+	"premium_caste/migrations"
+
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const migrateDetails = `commands:
+  up            apply all pending migrations
+  down          roll back the latest migration
+  redo          roll back and re-apply the latest migration
+  status        show applied and pending migrations
+  to VERSION    migrate up or down to VERSION (0 rolls back everything)
+`
+
+// runMigrate выполняет команду мигратора из аргументов после флагов
+func runMigrate() int {
+	cfg := config.MustLoad()
+	log := setupLogger(cfg, os.Stderr)
+
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	return exitCode(log, migrate(ctx, log, cfg.DSN, flag.Args(), os.Stdout))
+}
+
+// migrate выполняет команду мигратора args над базой dsn, статус пишет в out
+func migrate(ctx context.Context, log *slog.Logger, dsn string, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("%w: command is required", errUsage)
+	}
+
+	command, version := args[0], int64(0)
+	switch {
+	case command == "to" && len(args) == 2:
+		v, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || v < 0 {
+			return fmt.Errorf("%w: invalid version %q", errUsage, args[1])
+		}
+		version = v
+	case command == "to" || len(args) > 1:
+		return fmt.Errorf("%w: unexpected arguments for %s", errUsage, command)
+	}
+
+	m, closeDB, err := openMigrator(ctx, log, dsn)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	switch command {
+	case "up":
+		return m.Up(ctx)
+	case "down":
+		return m.Down(ctx)
+	case "redo":
+		return m.Redo(ctx)
+	case "to":
+		return m.To(ctx, version)
+	case "status":
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			return err
+		}
+		return printMigrationStatus(out, statuses)
+	default:
+		return fmt.Errorf("%w: unknown command %q", errUsage, command)
+	}
+}
+
+// autoMigrate применяет миграции при старте сервера. Параллельно стартующие
+// экземпляры ждут друг друга на блокировке мигратора
+func autoMigrate(ctx context.Context, log *slog.Logger, dsn string) error {
+	m, closeDB, err := openMigrator(ctx, log, dsn)
+	if err != nil {
+		return err
+	}
+	defer closeDB()
+
+	return m.Up(ctx)
+}
+
+func openMigrator(ctx context.Context, log *slog.Logger, dsn string) (*migrator.Migrator, func(), error) {
+	db, err := pgxpool.Connect(ctx, dsn)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	m, err := migrator.New(log, db, migrations.FS)
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+
+	return m, db.Close, nil
+}
+
+func printMigrationStatus(out io.Writer, statuses []migrator.Status) error {
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, status := range statuses {
+		appliedAt := "pending"
+		if status.AppliedAt != nil {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%03d\t%s\t%s\n", status.Version, status.Name, appliedAt)
+	}
+
+	return w.Flush()
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"premium_caste/internal/app"
+	"premium_caste/internal/config"
+	redisapp "premium_caste/internal/storage/redis"
+)
+
+// runServe запускает HTTP-сервер и ждет SIGINT или SIGTERM. SIGHUP перечитывает конфиг
+// и применяет log_level, rate_limits, http.cors.allow_origins и TLS-сертификат.
+// Об изменениях остальных настроек пишет в лог: они вступят в силу после перезапуска
+func runServe() int {
+	cfg := config.MustLoad()
+
+	log := setupLogger(cfg, os.Stdout)
+
+	if cfg.AutoMigrate {
+		if err := autoMigrate(context.Background(), log, cfg.DSN); err != nil {
+			panic("failed to apply migrations: " + err.Error())
+		}
+	}
+
+	redisClient := redisapp.NewClient(cfg.Redis.RedisAddr, cfg.Redis.RedisPassword, cfg.Redis.RedisDB)
+	if err := redisClient.HealthCheck(context.Background()); err != nil {
+		panic("Failed to connect to Redis")
+	}
+
+	application := app.New(log, redisClient, cfg.DSN, cfg.HTTP, cfg.TokenTTL, cfg.FileStorage.BaseDir, cfg.FileStorage.BaseURL, cfg.FileStorage.MaxArchiveBuilds, cfg.Site, cfg.Audit, cfg.OIDC, cfg.Auth, cfg.RateLimits)
+
+	go func() {
+		application.HTTPServer.BuildRouters()
+		application.HTTPServer.MustRun()
+	}()
+
+	pruneCtx, stopPruner := context.WithCancel(context.Background())
+	go application.Audit.RunPruner(pruneCtx, cfg.Audit.PruneInterval)
+
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			next, err := cfg.Reload()
+			if err != nil {
+				log.Error("failed to reload config, keeping current settings", slog.String("error", err.Error()))
+				continue
+			}
+
+			if sections := cfg.RestartRequired(next); len(sections) > 0 {
+				log.Warn("config changes require restart", slog.Any("sections", sections))
+			}
+
+			logLevel.Set(next.SlogLevel())
+			if err := application.Reload(next); err != nil {
+				log.Error("failed to apply reloaded config", slog.String("error", err.Error()))
+				continue
+			}
+
+			log.Info("config reloaded", slog.String("log_level", next.SlogLevel().String()))
+		}
+	}()
+
+	// Graceful shutdown
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGTERM, syscall.SIGINT)
+
+	<-stop
+	signal.Stop(reload)
+	stopPruner()
+	application.HTTPServer.Stop()
+	redisClient.Close()
+	application.Repo.Close()
+
+	log.Info("Gracefully stopped")
+	log.Info("application stop")
+
+	return exitOK
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"premium_caste/internal/config"
+	"premium_caste/internal/lib/validation"
+	audit "premium_caste/internal/services/audit_service"
+	tokenapp "premium_caste/internal/services/token_service"
+	user "premium_caste/internal/services/user_service"
+	"premium_caste/internal/transport/http/dto"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// runCreateAdmin создает пользователя с правами администратора. Публичная
+// регистрация не должна выдавать эти права, поэтому для первого администратора
+// нужна отдельная команда
+func runCreateAdmin() int {
+	name := flag.String("name", "", "administrator name")
+	email := flag.String("email", "", "administrator email")
+	phone := flag.String("phone", "", "administrator phone in E.164 format, e.g. +71234567890")
+
+	cfg := config.MustLoad()
+	log := setupLogger(cfg, os.Stderr)
+
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	err := func() error {
+		password, err := readPassword(os.Stdin, os.Stderr)
+		if err != nil {
+			return err
+		}
+
+		input := dto.UserRegisterInput{
+			Name:     *name,
+			Email:    *email,
+			Phone:    *phone,
+			Password: password,
+			IsAdmin:  true,
+		}
+		if err := validateInput(input); err != nil {
+			return err
+		}
+
+		repo, closeRepo, err := openRepository(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		defer closeRepo()
+
+		users := user.NewUserService(log, repo.User, repo.Tx, tokenapp.NewTokenService(repo.Token, cfg.Auth.JWTSecret), user.NewLogEmailSender(log), audit.NewAuditService(log, repo.Audit, cfg.Audit.Retention), repo.Settings, repo.Challenges, cfg.Site.Title)
+		id, err := users.RegisterNewUser(ctx, input)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(os.Stdout, "administrator %s created with id %s\n", input.Email, id)
+		return nil
+	}()
+
+	return exitCode(log, err)
+}
+
+// runResetPassword задает пользователю новый пароль и отзывает его refresh-токены
+func runResetPassword() int {
+	identifier := flag.String("user", "", "email or phone of the user")
+	keepSessions := flag.Bool("keep-sessions", false, "do not revoke the user's refresh tokens")
+
+	cfg := config.MustLoad()
+	log := setupLogger(cfg, os.Stderr)
+
+	ctx, cancel := signalContext()
+	defer cancel()
+
+	err := func() error {
+		password, err := readPassword(os.Stdin, os.Stderr)
+		if err != nil {
+			return err
+		}
+
+		input := dto.ResetPasswordInput{Identifier: *identifier, Password: password}
+		if err := validateInput(input); err != nil {
+			return err
+		}
+
+		repo, closeRepo, err := openRepository(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		defer closeRepo()
+
+		tokens := tokenapp.NewTokenService(repo.Token, cfg.Auth.JWTSecret)
+		users := user.NewUserService(log, repo.User, repo.Tx, tokens, user.NewLogEmailSender(log), audit.NewAuditService(log, repo.Audit, cfg.Audit.Retention), repo.Settings, repo.Challenges, cfg.Site.Title)
+		id, err := users.ResetPassword(ctx, input.Identifier, input.Password)
+		if err != nil {
+			return err
+		}
+
+		if !*keepSessions {
+			if err := tokens.RevokeUserTokens(ctx, id); err != nil {
+				return fmt.Errorf("password changed, but sessions were not revoked: %w", err)
+			}
+		}
+
+		fmt.Fprintf(os.Stdout, "password for %s changed\n", input.Identifier)
+		return nil
+	}()
+
+	return exitCode(log, err)
+}
+
+// readPassword читает пароль из первой строки in. Если in - терминал, в out
+// выводится приглашение. Ввод не скрывается, поэтому в скриптах пароль лучше
+// передавать через канал: echo "$PASSWORD" | premium_caste create-admin ...
+func readPassword(in *os.File, out io.Writer) (string, error) {
+	if info, err := in.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		fmt.Fprint(out, "Password: ")
+	}
+
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// validateInput проверяет ввод теми же правилами, что и HTTP-обработчики,
+// и возвращает ошибку использования со списком полей
+func validateInput(input any) error {
+	v := validation.MustNew()
+
+	err := v.Validate(input)
+	var errs validator.ValidationErrors
+	if !errors.As(err, &errs) {
+		return err
+	}
+
+	messages := make([]string, 0, len(errs))
+	for _, field := range v.Fields(errs, validation.LangEnglish) {
+		messages = append(messages, field.Message)
+	}
+
+	return fmt.Errorf("%w: %s", errUsage, strings.Join(messages, "; "))
+}
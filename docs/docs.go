@@ -15,241 +15,246 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/v1/login": {
-            "post": {
-                "description": "Вход в систему по email и паролю. Возвращает JWT-токен.",
-                "consumes": [
-                    "application/json"
+        "/api/v1/admin/api-keys": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Все ключи API или ключи одного пользователя",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "Администрирование пользователей"
                 ],
-                "summary": "Аутентификация пользователя",
+                "summary": "Ключи API пользователей",
                 "parameters": [
                     {
-                        "description": "Данные для входа",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/request.LoginRequest"
-                        }
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID владельца",
+                        "name": "user_id",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Успешный вход (токен)",
+                        "description": "OK",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/response.Response"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "type": "object",
-                                            "additionalProperties": {
-                                                "type": "string"
-                                            }
-                                        }
-                                    }
-                                }
-                            ]
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.APIKeyResponse"
+                            }
                         }
                     },
                     "400": {
-                        "description": "Неверный формат запроса",
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
-                    "401": {
-                        "description": "Ошибка аутентификации",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
             }
         },
-        "/api/v1/media/groups": {
-            "post": {
+        "/api/v1/admin/api-keys/{id}": {
+            "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Создает новую группу для организации медиафайлов",
-                "consumes": [
-                    "multipart/form-data"
-                ],
-                "produces": [
-                    "application/json"
-                ],
                 "tags": [
-                    "Медиа-группы"
+                    "Администрирование пользователей"
                 ],
-                "summary": "Создать медиагруппу",
+                "summary": "Отозвать ключ API пользователя",
                 "parameters": [
                     {
                         "type": "string",
                         "format": "uuid",
-                        "description": "UUID владельца группы",
-                        "name": "owner_id",
-                        "in": "formData",
+                        "description": "UUID ключа",
+                        "name": "id",
+                        "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Описание группы",
-                        "name": "description",
-                        "in": "formData"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Группа создана (no content)"
+                    "204": {
+                        "description": "No Content"
                     },
                     "400": {
-                        "description": "Невалидный UUID владельца",
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
-                    "500": {
-                        "description": "Ошибка создания группы",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Ключ не найден или уже отозван",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
             }
         },
-        "/api/v1/media/groups/{group_id}": {
+        "/api/v1/admin/audit": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Возвращает список всех медиафайлов в группе",
+                "description": "Действия администраторов и события безопасности, новые первыми",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Медиа-группы"
+                    "Журнал аудита"
                 ],
-                "summary": "Получить медиа группы",
+                "summary": "Журнал аудита",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Действие, например post.publish или user.login_failed",
+                        "name": "action",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
                         "format": "uuid",
-                        "description": "UUID группы",
-                        "name": "group_id",
-                        "in": "path",
-                        "required": true
+                        "description": "UUID инициатора",
+                        "name": "actor_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID ключа API, с которым выполнено действие",
+                        "name": "api_key_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Тип объекта (post, gallery, media, user)",
+                        "name": "target_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Идентификатор объекта",
+                        "name": "target_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало периода (RFC3339 или YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода (RFC3339 или YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Количество элементов на странице",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Значение next_cursor предыдущей страницы. Если задан, page не учитывается",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Список медиафайлов",
+                        "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Media"
+                            "$ref": "#/definitions/dto.AuditEventListResponse"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "Ссылки first, prev, next и last (RFC 8288)"
                             }
                         }
                     },
                     "400": {
-                        "description": "Невалидный UUID группы",
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
-                    "500": {
-                        "description": "Ошибка получения списка",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
             }
         },
-        "/api/v1/media/groups/{group_id}/attach": {
-            "post": {
+        "/api/v1/admin/security": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Связывает один или несколько медиафайлов с существующей медиагруппой",
-                "consumes": [
-                    "application/json"
-                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Медиа-группы"
-                ],
-                "summary": "Прикрепить медиа к группе",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "format": "uuid",
-                        "description": "UUID группы",
-                        "name": "group_id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Данные для прикрепления",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.AttachMediaRequest"
-                        }
-                    }
+                    "Администрирование пользователей"
                 ],
+                "summary": "Настройки безопасности",
                 "responses": {
                     "200": {
-                        "description": "Успешное прикрепление (no content)"
-                    },
-                    "400": {
-                        "description": "Невалидные данные: пустой массив, неверный формат UUID",
-                        "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
-                        }
-                    },
-                    "404": {
-                        "description": "Группа не найдена",
-                        "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
-                        }
-                    },
-                    "413": {
-                        "description": "Превышен лимит количества медиафайлов",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/models.SecurityPolicy"
                         }
                     },
-                    "500": {
-                        "description": "Ошибка привязки медиа",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
-            }
-        },
-        "/api/v1/media/images": {
-            "get": {
-                "description": "Возвращает список всех загруженных изображений с метаданными",
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Требование 2FA для администраторов и редакторов действует со следующего входа",
                 "consumes": [
                     "application/json"
                 ],
@@ -257,346 +262,290 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Медиа"
+                    "Администрирование пользователей"
+                ],
+                "summary": "Изменить настройки безопасности",
+                "parameters": [
+                    {
+                        "description": "Настройки",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateSecurityPolicyRequest"
+                        }
+                    }
                 ],
-                "summary": "Получить все изображения",
                 "responses": {
                     "200": {
-                        "description": "Успешный ответ",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/models.SecurityPolicy"
                         }
                     },
-                    "500": {
-                        "description": "Внутренняя ошибка сервера",
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
             }
         },
-        "/api/v1/media/multiple": {
-            "post": {
-                "description": "Загружает несколько медиафайлов на сервер. Поддерживает передачу файлов и общих параметров загрузки.",
-                "consumes": [
-                    "multipart/form-data"
+        "/api/v1/admin/users": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Поиск пользователей по имени, email или телефону с фильтрами по роли и статусу",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Медиа"
+                    "Администрирование пользователей"
                 ],
-                "summary": "Загрузка множества медиафайлов",
+                "summary": "Список пользователей",
                 "parameters": [
-                    {
-                        "type": "file",
-                        "description": "Файлы для загрузки (поддерживается множественная загрузка)",
-                        "name": "files",
-                        "in": "formData",
-                        "required": true
-                    },
                     {
                         "type": "string",
-                        "description": "ID пользователя, загружающего файлы",
-                        "name": "uploader_id",
-                        "in": "formData",
-                        "required": true
+                        "description": "Подстрока имени, email или телефона",
+                        "name": "q",
+                        "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Тип медиа (например, image, video)",
-                        "name": "media_type",
-                        "in": "formData",
-                        "required": true
+                        "description": "Роль (user, editor, admin)",
+                        "name": "role",
+                        "in": "query"
                     },
                     {
-                        "type": "boolean",
-                        "description": "Флаг публичности файла",
-                        "name": "is_public",
-                        "in": "formData",
-                        "required": true
+                        "type": "string",
+                        "description": "Статус (active, suspended, anonymized)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Количество элементов на странице",
+                        "name": "per_page",
+                        "in": "query"
                     },
                     {
                         "type": "string",
-                        "description": "Дополнительные метаданные (опционально)",
-                        "name": "metadata",
-                        "in": "formData"
+                        "description": "Значение next_cursor предыдущей страницы. Если задан, page не учитывается",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Успешная загрузка, возвращает массив созданных медиа-объектов",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/models.Media"
+                            "$ref": "#/definitions/dto.UserListResponse"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "Ссылки first, prev, next и last (RFC 8288)"
                             }
                         }
                     },
                     "400": {
-                        "description": "Ошибка валидации входных данных",
+                        "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
-                    "500": {
-                        "description": "Ошибка сервера при загрузке файлов",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
             }
         },
-        "/api/v1/media/upload": {
-            "post": {
+        "/api/v1/admin/users/{id}": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Загружает файл на сервер с возможностью указания метаданных",
-                "consumes": [
-                    "multipart/form-data"
-                ],
+                "description": "Возвращает профиль пользователя со статусом блокировки. Хеш пароля не возвращается",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Медиа"
+                    "Администрирование пользователей"
                 ],
-                "summary": "Загрузка медиафайла",
+                "summary": "Пользователь",
                 "parameters": [
-                    {
-                        "type": "file",
-                        "description": "Файл для загрузки (макс. 10MB)",
-                        "name": "file",
-                        "in": "formData",
-                        "required": true
-                    },
                     {
                         "type": "string",
                         "format": "uuid",
-                        "description": "UUID пользователя-загрузчика",
-                        "name": "uploader_id",
-                        "in": "formData",
-                        "required": true
-                    },
-                    {
-                        "enum": [
-                            "photo",
-                            "video",
-                            "audio",
-                            "document"
-                        ],
-                        "type": "string",
-                        "description": "Тип контента",
-                        "name": "media_type",
-                        "in": "formData",
+                        "description": "UUID пользователя",
+                        "name": "id",
+                        "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "boolean",
-                        "description": "Публичный доступ (по умолчанию false)",
-                        "name": "is_public",
-                        "in": "formData"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Дополнительные метаданные в JSON-формате",
-                        "name": "metadata",
-                        "in": "formData"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Ширина в пикселях (для изображений/видео)",
-                        "name": "width",
-                        "in": "formData"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Высота в пикселях (для изображений/видео)",
-                        "name": "height",
-                        "in": "formData"
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Длительность в секундах (для видео/аудио)",
-                        "name": "duration",
-                        "in": "formData"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Успешно загруженный медиаобъект",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.Media"
+                            "$ref": "#/definitions/dto.UserResponse"
                         }
                     },
                     "400": {
-                        "description": "Некорректные входные данные",
-                        "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
-                        }
-                    },
-                    "413": {
-                        "description": "Превышен максимальный размер файла",
-                        "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
-                        }
-                    },
-                    "415": {
-                        "description": "Неподдерживаемый тип файла",
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
-                    "500": {
-                        "description": "Внутренняя ошибка сервера",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
-            }
-        },
-        "/api/v1/posts": {
-            "get": {
+            },
+            "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Возвращает список постов с пагинацией и фильтрацией по статусу. http://localhost:8080/api/v1/posts?status=archived\u0026page=1\u0026per_page=1",
-                "produces": [
-                    "application/json"
-                ],
+                "description": "Удаляет пользователя вместе с его комментариями",
                 "tags": [
-                    "Посты"
+                    "Администрирование пользователей"
                 ],
-                "summary": "Список постов",
+                "summary": "Удалить пользователя",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Фильтр по статусу (draft, published, archived)",
-                        "name": "status",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 1,
-                        "description": "Номер страницы",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "integer",
-                        "default": 10,
-                        "description": "Количество элементов на странице",
-                        "name": "per_page",
-                        "in": "query"
+                        "format": "uuid",
+                        "description": "UUID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/dto.BlogPostListResponse"
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
-            },
-            "post": {
+            }
+        },
+        "/api/v1/admin/users/{id}/2fa": {
+            "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Создает новый пост блога. Добавлять только authod_id -\u003e существующий пользователь. Добавлять FeaturedImageID -\u003e только существующую медиа",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
+                "description": "Выключает двухфакторную аутентификацию пользователя, потерявшего устройство и коды восстановления",
                 "tags": [
-                    "Посты"
+                    "Администрирование пользователей"
                 ],
-                "summary": "Создать новый пост",
+                "summary": "Сбросить 2FA пользователя",
                 "parameters": [
                     {
-                        "description": "Данные поста",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.CreateBlogPostRequest"
-                        }
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID пользователя",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
-                        "schema": {
-                            "$ref": "#/definitions/dto.BlogPostResponse"
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
             }
         },
-        "/api/v1/posts/{id}": {
-            "get": {
+        "/api/v1/admin/users/{id}/anonymize": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Возвращает пост по его ID",
+                "description": "Заменяет имя, email и телефон заглушками и удаляет пароль. Комментарии пользователя сохраняются",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Посты"
+                    "Администрирование пользователей"
                 ],
-                "summary": "Получить пост",
+                "summary": "Анонимизировать пользователя",
                 "parameters": [
                     {
                         "type": "string",
                         "format": "uuid",
-                        "description": "UUID поста",
+                        "description": "UUID пользователя",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -606,30 +555,44 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.BlogPostResponse"
+                            "$ref": "#/definitions/dto.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/api/v1/admin/users/{id}/role": {
             "put": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Обновляет данные поста",
+                "description": "Назначает роль user, editor или admin. Свою роль менять нельзя, последнего администратора понизить нельзя",
                 "consumes": [
                     "application/json"
                 ],
@@ -637,25 +600,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Посты"
+                    "Администрирование пользователей"
                 ],
-                "summary": "Обновить пост",
+                "summary": "Изменить роль пользователя",
                 "parameters": [
                     {
                         "type": "string",
                         "format": "uuid",
-                        "description": "UUID поста",
+                        "description": "UUID пользователя",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Данные для обновления",
+                        "description": "Новая роль",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.UpdateBlogPostRequest"
+                            "$ref": "#/definitions/dto.UpdateUserRoleRequest"
                         }
                     }
                 ],
@@ -663,92 +626,123 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.BlogPostResponse"
+                            "$ref": "#/definitions/dto.UserResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/api/v1/admin/users/{id}/suspend": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Удаляет пост (физическое удаление)",
-                "tags": [
-                    "Посты"
+                "description": "Запрещает вход и отзывает refresh-токены пользователя",
+                "consumes": [
+                    "application/json"
                 ],
-                "summary": "Удалить пост",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Администрирование пользователей"
+                ],
+                "summary": "Заблокировать пользователя",
                 "parameters": [
                     {
                         "type": "string",
                         "format": "uuid",
-                        "description": "UUID поста",
+                        "description": "UUID пользователя",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Причина блокировки",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/dto.SuspendUserRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.UserResponse"
+                        }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
-            }
-        },
-        "/api/v1/posts/{id}/archive": {
-            "patch": {
+            },
+            "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Архивирует пост (soft delete)",
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "Посты"
+                    "Администрирование пользователей"
                 ],
-                "summary": "Архивировать пост",
+                "summary": "Разблокировать пользователя",
                 "parameters": [
                     {
                         "type": "string",
                         "format": "uuid",
-                        "description": "UUID поста",
+                        "description": "UUID пользователя",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -758,95 +752,68 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.BlogPostResponse"
+                            "$ref": "#/definitions/dto.UserResponse"
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
             }
         },
-        "/api/v1/posts/{id}/media-groups": {
-            "get": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "Возвращает список медиа-групп, привязанных к посту",
+        "/api/v1/auth/oidc/{provider}": {
+            "post": {
+                "description": "Возвращает адрес страницы входа провайдера. После входа провайдер вернет пользователя\nна redirect_url сайта с параметрами code и state, которые нужно передать в callback",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Посты"
+                    "users"
                 ],
-                "summary": "Получить медиа-группы поста",
+                "summary": "Начать вход через провайдера",
                 "parameters": [
                     {
                         "type": "string",
-                        "format": "uuid",
-                        "description": "UUID поста",
-                        "name": "id",
+                        "description": "Имя провайдера",
+                        "name": "provider",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Тип связи (content, gallery, attachment)",
-                        "name": "relation_type",
-                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.PostMediaGroupsResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/dto.OIDCStartResponse"
                         }
                     },
                     "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
+                        "description": "Провайдер не настроен",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/api/v1/auth/oidc/{provider}/callback": {
             "post": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "Привязывает медиа-группу к посту с указанием типа связи",
+                "description": "Обменивает код провайдера на токены. Ответ такой же, как у POST /api/v1/login:\nесли нужен второй фактор, вместо токенов возвращается challenge_id.\nДля новой учетной записи провайдера создается пользователь. Если email уже занят,\nвозвращается 409: провайдера нужно привязать из профиля после входа",
                 "consumes": [
                     "application/json"
                 ],
@@ -854,109 +821,125 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Посты"
+                    "users"
                 ],
-                "summary": "Добавить медиа-группу к посту",
+                "summary": "Завершить вход через провайдера",
                 "parameters": [
                     {
                         "type": "string",
-                        "format": "uuid",
-                        "description": "UUID поста",
-                        "name": "id",
+                        "description": "Имя провайдера",
+                        "name": "provider",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Данные медиа-группы",
+                        "description": "Параметры возврата от провайдера",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.AddMediaGroupRequest"
+                            "$ref": "#/definitions/dto.OIDCCallbackRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "OK",
+                        "description": "Успешный вход (токен) или запрос второго фактора",
                         "schema": {
-                            "$ref": "#/definitions/dto.PostMediaGroupsResponse"
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "object",
+                                            "additionalProperties": {
+                                                "type": "string"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "401": {
+                        "description": "Вход истек или провайдер его не подтвердил",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "403": {
+                        "description": "Email не подтвержден провайдером",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "409": {
+                        "description": "Пользователь с таким email уже есть",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
             }
         },
-        "/api/v1/posts/{id}/publish": {
-            "patch": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
+        "/api/v1/auth/providers": {
+            "get": {
+                "description": "Внешние провайдеры OpenID Connect, через которых можно войти",
+                "produces": [
+                    "application/json"
                 ],
-                "description": "Устанавливает статус поста \"published\"",
                 "tags": [
-                    "Посты"
-                ],
-                "summary": "Опубликовать пост",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "format": "uuid",
-                        "description": "UUID поста",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "users"
                 ],
+                "summary": "Провайдеры входа",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.BlogPostResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/dto.IdentityProvidersResponse"
                         }
-                    },
-                    "404": {
-                        "description": "Not Found",
+                    }
+                }
+            }
+        },
+        "/api/v1/categories": {
+            "get": {
+                "description": "Возвращает все категории блога. Иерархия задается полем parent_id",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Категории"
+                ],
+                "summary": "Список категорий",
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.CategoryResponse"
+                            }
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
-            }
-        },
-        "/api/v1/register": {
+            },
             "post": {
-                "description": "Создание аккаунта. Возвращает ID пользователя.",
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Создает категорию блога. Если slug не указан, он формируется из названия",
                 "consumes": [
                     "application/json"
                 ],
@@ -964,132 +947,84 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "Категории"
                 ],
-                "summary": "Регистрация нового пользователя",
+                "summary": "Создать категорию",
                 "parameters": [
                     {
-                        "description": "Данные для регистрации",
+                        "description": "Данные категории",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.UserRegisterInput"
+                            "$ref": "#/definitions/dto.CreateCategoryRequest"
                         }
                     }
                 ],
                 "responses": {
                     "201": {
-                        "description": "Успешная регистрация",
+                        "description": "Created",
                         "schema": {
-                            "allOf": [
-                                {
-                                    "$ref": "#/definitions/response.Response"
-                                },
-                                {
-                                    "type": "object",
-                                    "properties": {
-                                        "data": {
-                                            "type": "object",
-                                            "properties": {
-                                                "user_id": {
-                                                    "type": "string"
-                                                }
-                                            }
-                                        }
-                                    }
-                                }
-                            ]
+                            "$ref": "#/definitions/dto.CategoryResponse"
                         }
                     },
                     "400": {
-                        "description": "Неверный формат запроса",
-                        "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
-                        }
-                    },
-                    "409": {
-                        "description": "Пользователь уже существует",
-                        "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
-                        }
-                    },
-                    "500": {
-                        "description": "Внутренняя ошибка сервера",
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
             }
         },
-        "/api/v1/users/users_id": {
-            "post": {
-                "security": [
-                    {
-                        "ApiKeyAuth": []
-                    }
-                ],
-                "description": "Возвращает полную информацию о пользователе по его UUID",
-                "consumes": [
-                    "application/json"
-                ],
+        "/api/v1/categories/{id}": {
+            "get": {
+                "description": "Возвращает категорию по её ID",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Пользователи"
+                    "Категории"
                 ],
-                "summary": "Получение информации о пользователе",
+                "summary": "Получить категорию",
                 "parameters": [
                     {
+                        "type": "string",
                         "format": "uuid",
-                        "example": "\"a8a8a8a8-a8a8-a8a8-a8a8-a8a8a8a8a8a8\"",
-                        "description": "UUID пользователя",
-                        "name": "user_id",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "type": "string"
-                        }
+                        "description": "UUID категории",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Успешно полученные данные пользователя",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/models.User"
+                            "$ref": "#/definitions/dto.CategoryResponse"
                         }
                     },
                     "400": {
-                        "description": "Некорректный UUID пользователя",
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
                     "404": {
-                        "description": "Пользователь не найден",
-                        "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
-                        }
-                    },
-                    "500": {
-                        "description": "Внутренняя ошибка сервера",
+                        "description": "Not Found",
                         "schema": {
-                            "$ref": "#/definitions/response.ErrorResponse"
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
-            }
-        },
-        "/api/v1/users/{user_id}/is-admin": {
-            "get": {
+            },
+            "put": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Проверяет, является ли указанный пользователь администратором",
+                "description": "Обновляет категорию. Нулевой UUID в parent_id делает категорию корневой",
                 "consumes": [
                     "application/json"
                 ],
@@ -1097,162 +1032,204 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Категории"
                 ],
-                "summary": "Проверка административного статуса пользователя",
+                "summary": "Обновить категорию",
                 "parameters": [
                     {
                         "type": "string",
                         "format": "uuid",
-                        "description": "UUID пользователя",
-                        "name": "user_id",
+                        "description": "UUID категории",
+                        "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Данные для обновления",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateCategoryRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Результат проверки\" example({\"is_admin\": true})",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "boolean"
-                            }
+                            "$ref": "#/definitions/dto.CategoryResponse"
                         }
                     },
                     "400": {
-                        "description": "Невалидный UUID\" example({\"error\": \"invalid user ID format\"})",
+                        "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
-                    "500": {
-                        "description": "Ошибка сервера\" example({\"error\": \"failed to check admin status\"})",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Удаляет категорию. Подкатегории переносятся к родителю, посты остаются без категории",
+                "tags": [
+                    "Категории"
+                ],
+                "summary": "Удалить категорию",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID категории",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
             }
         },
-        "/galleries": {
+        "/api/v1/comments": {
             "get": {
-                "description": "Возвращает список галерей с возможностью фильтрации по статусу и пагинацией.",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Возвращает комментарии всех постов, новые первыми. По умолчанию показываются ожидающие модерации",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Галереи"
+                    "Комментарии"
                 ],
-                "summary": "Получение списка галерей",
+                "summary": "Очередь модерации",
                 "parameters": [
                     {
                         "type": "string",
-                        "example": "\"published\"",
-                        "description": "Фильтр по статусу галереи",
+                        "default": "pending",
+                        "description": "Статус (pending, approved, spam, rejected, all)",
                         "name": "status",
                         "in": "query"
                     },
                     {
                         "type": "integer",
-                        "example": 1,
-                        "description": "Номер страницы (по умолчанию: 1)",
+                        "default": 1,
+                        "description": "Номер страницы",
                         "name": "page",
                         "in": "query"
                     },
                     {
                         "type": "integer",
-                        "example": 10,
-                        "description": "Количество элементов на странице (по умолчанию: 10, максимум: 100)",
+                        "default": 10,
+                        "description": "Количество элементов на странице",
                         "name": "per_page",
                         "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Значение next_cursor предыдущей страницы. Если задан, page не учитывается",
+                        "name": "cursor",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Успешный ответ с данными галерей и общим количеством",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/dto.CommentListResponse"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "Ссылки first, prev, next и last (RFC 8288)"
+                            }
                         }
                     },
-                    "500": {
-                        "description": "Внутренняя ошибка сервера",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
-            },
-            "post": {
-                "description": "Создает новую галерею на основе переданных данных.",
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
+            }
+        },
+        "/api/v1/comments/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Удаляет комментарий вместе со всеми ответами на него",
                 "tags": [
-                    "Галереи"
+                    "Комментарии"
                 ],
-                "summary": "Создание новой галереи",
+                "summary": "Удалить комментарий",
                 "parameters": [
                     {
-                        "description": "Данные для создания галереи",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.CreateGalleryRequest"
-                        }
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID комментария",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Галерея успешно создана, возвращает ID созданной галереи",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
                     "400": {
-                        "description": "Некорректные данные запроса",
+                        "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
-                    "500": {
-                        "description": "Ошибка при создании галереи",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
             }
         },
-        "/galleries/by-tags": {
-            "get": {
-                "description": "Возвращает список галерей, отфильтрованных по указанным тегам, с возможностью выбора логики фильтрации (AND/OR).",
+        "/api/v1/comments/{id}/status": {
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Меняет статус комментария (pending, approved, spam, rejected)",
                 "consumes": [
                     "application/json"
                 ],
@@ -1260,125 +1237,79 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Галереи"
+                    "Комментарии"
                 ],
-                "summary": "Получение списка галерей по тегам",
+                "summary": "Модерация комментария",
                 "parameters": [
                     {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "csv",
-                        "example": "[\"nature\", \"art\"]",
-                        "description": "Список тегов для фильтрации",
-                        "name": "tags",
-                        "in": "query",
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID комментария",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     },
                     {
-                        "type": "boolean",
-                        "example": false,
-                        "description": "Режим фильтрации: true — AND, false — OR (по умолчанию: false)",
-                        "name": "match_all",
-                        "in": "query"
+                        "description": "Новый статус",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateCommentStatusRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Успешный ответ с данными галерей",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/dto.CommentResponse"
                         }
                     },
                     "400": {
-                        "description": "Некорректный запрос",
+                        "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
-                    "500": {
-                        "description": "Внутренняя ошибка сервера",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
             }
         },
-        "/galleries/{gallery_id}/has-tags": {
+        "/api/v1/csrf-token": {
             "get": {
-                "description": "Проверяет, содержит ли галерея все указанные теги",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Токен текущего сеанса для заголовка X-CSRF-Token. Он обязателен в запросах POST, PUT,\nPATCH и DELETE, авторизованных cookie, и в POST /api/v1/refresh с refresh-токеном из cookie.\nС заголовком Authorization: Bearer токен не нужен. Тот же токен возвращается в поле csrf_token\nпри входе, подтверждении 2FA, входе через провайдера, обновлении токенов и смене пароля",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Галереи"
-                ],
-                "summary": "Проверка наличия тегов у галереи",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "example": "\"a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11\"",
-                        "description": "Идентификатор галереи",
-                        "name": "gallery_id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "csv",
-                        "example": "[\"art\", \"design\"]",
-                        "description": "Список тегов для проверки",
-                        "name": "tags",
-                        "in": "query",
-                        "required": true
-                    }
+                    "users"
                 ],
+                "summary": "Токен CSRF",
                 "responses": {
                     "200": {
-                        "description": "Результат проверки",
-                        "schema": {
-                            "type": "boolean"
-                        }
-                    },
-                    "400": {
-                        "description": "Некорректный запрос",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/dto.CSRFTokenResponse"
                         }
                     },
-                    "500": {
-                        "description": "Внутренняя ошибка сервера",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
             }
         },
-        "/galleries/{gallery_id}/tags": {
-            "get": {
-                "description": "Возвращает список тегов для указанной галереи",
+        "/api/v1/login": {
+            "post": {
+                "description": "Вход в систему по email и паролю. Возвращает JWT-токен.\nЕсли у пользователя включена двухфакторная аутентификация или политика безопасности требует ее,\nтокены не выдаются: в ответе two_factor_required и challenge_id для POST /api/v1/login/2fa",
                 "consumes": [
                     "application/json"
                 ],
@@ -1386,51 +1317,60 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Галереи"
+                    "users"
                 ],
-                "summary": "Получение тегов галереи",
+                "summary": "Аутентификация пользователя",
                 "parameters": [
                     {
-                        "type": "string",
-                        "example": "\"a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11\"",
-                        "description": "Идентификатор галереи",
-                        "name": "gallery_id",
-                        "in": "path",
-                        "required": true
+                        "description": "Данные для входа",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/request.LoginRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Список тегов галереи",
+                        "description": "Успешный вход (токен) или запрос второго фактора",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "string"
-                            }
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "object",
+                                            "additionalProperties": {
+                                                "type": "string"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
                         }
                     },
                     "400": {
-                        "description": "Некорректный запрос",
+                        "description": "Неверный формат запроса",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
-                    "500": {
-                        "description": "Внутренняя ошибка сервера",
+                    "401": {
+                        "description": "Ошибка аутентификации",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Полностью заменяет теги галереи на указанные",
+            }
+        },
+        "/api/v1/login/2fa": {
+            "post": {
+                "description": "Завершает вход кодом из приложения-аутентификатора или кодом восстановления.\nЕсли вход требовал настройки 2FA, код подтверждает ее и в ответе возвращаются коды восстановления",
                 "consumes": [
                     "application/json"
                 ],
@@ -1438,58 +1378,60 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Галереи"
+                    "users"
                 ],
-                "summary": "Замена тегов галереи",
+                "summary": "Второй шаг входа",
                 "parameters": [
                     {
-                        "type": "string",
-                        "example": "\"a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11\"",
-                        "description": "Идентификатор галереи",
-                        "name": "gallery_id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "example": "[\"new\", \"tags\"]",
-                        "description": "Новый список тегов",
-                        "name": "tags",
+                        "description": "Идентификатор входа и код",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/dto.LoginTwoFactorRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "Теги успешно заменены"
+                    "200": {
+                        "description": "Успешный вход (токен)",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "object",
+                                            "additionalProperties": {
+                                                "type": "string"
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
                     },
                     "400": {
-                        "description": "Некорректный запрос",
+                        "description": "Неверный код",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
-                    "500": {
-                        "description": "Внутренняя ошибка сервера",
+                    "401": {
+                        "description": "Вход истек или превышено число попыток",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
-            },
+            }
+        },
+        "/api/v1/login/2fa/enroll": {
             "post": {
-                "description": "Добавляет указанные теги к галерее",
+                "description": "Создает секрет TOTP для пользователя, от которого политика безопасности требует 2FA.\nНастройка подтверждается кодом в POST /api/v1/login/2fa",
                 "consumes": [
                     "application/json"
                 ],
@@ -1497,58 +1439,79 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Галереи"
+                    "users"
                 ],
-                "summary": "Добавление тегов к галерее",
+                "summary": "Настройка 2FA при входе",
                 "parameters": [
                     {
-                        "type": "string",
-                        "example": "\"a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11\"",
-                        "description": "Идентификатор галереи",
-                        "name": "gallery_id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "example": "[\"art\", \"design\"]",
-                        "description": "Список тегов для добавления",
-                        "name": "tags",
+                        "description": "Идентификатор входа",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/dto.LoginChallengeRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "Теги успешно добавлены"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.TwoFactorEnrollmentResponse"
+                        }
                     },
-                    "400": {
-                        "description": "Некорректный запрос",
+                    "401": {
+                        "description": "Вход истек",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
-                    "500": {
-                        "description": "Внутренняя ошибка сервера",
+                    "409": {
+                        "description": "2FA уже настроена",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/me": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает профиль текущего пользователя",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Профиль"
+                ],
+                "summary": "Свой профиль",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.UserResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
             },
-            "delete": {
-                "description": "Удаляет указанные теги из галереи",
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Меняет имя и телефон. Новый email сохраняется как pending_email и применяется после подтверждения кодом, отправленным на этот адрес",
                 "consumes": [
                     "application/json"
                 ],
@@ -1556,121 +1519,136 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Галереи"
+                    "Профиль"
                 ],
-                "summary": "Удаление тегов из галереи",
+                "summary": "Изменить свой профиль",
                 "parameters": [
                     {
-                        "type": "string",
-                        "example": "\"a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11\"",
-                        "description": "Идентификатор галереи",
-                        "name": "gallery_id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "example": "[\"old\", \"tag\"]",
-                        "description": "Список тегов для удаления",
-                        "name": "tags",
+                        "description": "Изменяемые поля",
+                        "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/dto.UpdateProfileRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "Теги успешно удалены"
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.UserResponse"
+                        }
                     },
                     "400": {
-                        "description": "Некорректный запрос",
+                        "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
-                    "500": {
-                        "description": "Внутренняя ошибка сервера",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Email или телефон уже заняты",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
             }
         },
-        "/galleries/{id}": {
+        "/api/v1/me/2fa": {
             "get": {
-                "description": "Возвращает полную информацию о галерее по её уникальному идентификатору.",
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
                 ],
+                "description": "Включена ли двухфакторная аутентификация, требует ли ее политика и сколько осталось кодов восстановления",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Галереи"
-                ],
-                "summary": "Получение галереи по ID",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "example": "\"1221067c-cc35-4dae-b5f5-feee4bbb3e22\"",
-                        "description": "UUID галереи",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    }
+                    "Профиль"
                 ],
+                "summary": "Состояние 2FA",
                 "responses": {
                     "200": {
-                        "description": "Успешный ответ с данными галереи",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/dto.TwoFactorStatusResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Выключает двухфакторную аутентификацию после проверки пароля. Недоступно, если политика требует 2FA для роли",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Профиль"
+                ],
+                "summary": "Выключить 2FA",
+                "parameters": [
+                    {
+                        "description": "Текущий пароль",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.DisableTwoFactorRequest"
                         }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
                     },
                     "400": {
-                        "description": "Некорректный формат UUID",
+                        "description": "Пароль неверный",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
-                    "404": {
-                        "description": "Галерея не найдена",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
-                    "500": {
-                        "description": "Внутренняя ошибка сервера",
+                    "403": {
+                        "description": "2FA обязательна",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Обновляет данные существующей галереи на основе переданных данных.",
+            }
+        },
+        "/api/v1/me/2fa/confirm": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Подтверждает настройку кодом из приложения и возвращает коды восстановления. Они показываются один раз",
                 "consumes": [
                     "application/json"
                 ],
@@ -1678,52 +1656,93 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Галереи"
+                    "Профиль"
                 ],
-                "summary": "Обновление галереи",
+                "summary": "Включить 2FA",
                 "parameters": [
                     {
-                        "description": "Данные для обновления галереи",
+                        "description": "Код из приложения",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.UpdateGalleryRequest"
+                            "$ref": "#/definitions/dto.TwoFactorCodeRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Галерея успешно обновлена",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/dto.RecoveryCodesResponse"
                         }
                     },
                     "400": {
-                        "description": "Некорректные данные запроса",
+                        "description": "Неверный код",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
-                    "500": {
-                        "description": "Ошибка при обновлении галереи",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Удаляет существующую галерею по её ID.",
+            }
+        },
+        "/api/v1/me/2fa/enroll": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Создает секрет TOTP. 2FA включается после подтверждения кодом из приложения",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Профиль"
+                ],
+                "summary": "Начать настройку 2FA",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.TwoFactorEnrollmentResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "2FA уже включена",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/me/2fa/recovery-codes": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Заменяет коды восстановления новыми, старые перестают действовать",
                 "consumes": [
                     "application/json"
                 ],
@@ -1731,52 +1750,88 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Галереи"
+                    "Профиль"
                 ],
-                "summary": "Удаление галереи",
+                "summary": "Новые коды восстановления",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "ID галереи",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Код из приложения",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.TwoFactorCodeRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Галерея успешно удалена",
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/dto.RecoveryCodesResponse"
                         }
                     },
                     "400": {
-                        "description": "Некорректный ID галереи",
+                        "description": "Неверный код",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
-                    "500": {
-                        "description": "Ошибка при удалении галереи",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "2FA не включена",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
             }
         },
-        "/galleries/{id}/status": {
-            "put": {
-                "description": "Обновляет статус существующей галереи на основе переданных данных.",
+        "/api/v1/me/api-keys": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Ключи API пользователя, включая отозванные. Секретная часть не возвращается",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Профиль"
+                ],
+                "summary": "Мои ключи API",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.APIKeyResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Выпускает ключ для интеграций. Ключ передается в заголовке X-API-Key и показывается только в этом ответе.\nОбласти доступа: posts:read, posts:write, media:read, media:write, galleries:read, galleries:write",
                 "consumes": [
                     "application/json"
                 ],
@@ -1784,323 +1839,4542 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Галереи"
+                    "Профиль"
                 ],
-                "summary": "Обновление статуса галереи",
+                "summary": "Создать ключ API",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "ID галереи",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Данные для обновления статуса галереи",
+                        "description": "Название, области доступа и лимит запросов",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.UpdateGalleryStatusRequest"
+                            "$ref": "#/definitions/dto.CreateAPIKeyRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Статус галереи успешно обновлен",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/dto.APIKeyCreatedResponse"
                         }
                     },
                     "400": {
-                        "description": "Некорректные данные запроса",
+                        "description": "Bad Request",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.Problem"
                         }
                     },
-                    "500": {
-                        "description": "Ошибка при обновлении статуса галереи",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/response.Problem"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "dto.AddMediaGroupRequest": {
-            "type": "object",
-            "required": [
-                "group_id",
-                "relation_type"
-            ],
-            "properties": {
-                "group_id": {
-                    "type": "string",
-                    "format": "uuid"
-                },
-                "relation_type": {
-                    "type": "string",
-                    "enum": [
-                        "content",
-                        "gallery",
-                        "attachment"
-                    ]
-                }
-            }
         },
-        "dto.AttachMediaRequest": {
-            "type": "object",
-            "required": [
-                "group_id",
-                "media_id"
-            ],
-            "properties": {
+        "/api/v1/me/api-keys/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "tags": [
+                    "Профиль"
+                ],
+                "summary": "Отозвать ключ API",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID ключа",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Ключ не найден или уже отозван",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/me/email/confirm": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Применяет email из pending_email по коду из письма. Код действует 24 часа",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Профиль"
+                ],
+                "summary": "Подтвердить новый email",
+                "parameters": [
+                    {
+                        "description": "Код подтверждения",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ConfirmEmailRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Код неверный или истек",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Email уже занят",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/me/identities": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Профиль"
+                ],
+                "summary": "Привязанные провайдеры",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.IdentityResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/me/identities/{provider}": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает адрес страницы входа провайдера. Привязка завершается в POST /api/v1/me/identities/{provider}/callback",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Профиль"
+                ],
+                "summary": "Начать привязку провайдера",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Имя провайдера",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.OIDCStartResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Провайдер не настроен",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Последнего провайдера нельзя отвязать, если у пользователя нет пароля",
+                "tags": [
+                    "Профиль"
+                ],
+                "summary": "Отвязать провайдера",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Имя провайдера",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Провайдер не привязан",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Это единственный способ входа",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/me/identities/{provider}/callback": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Профиль"
+                ],
+                "summary": "Привязать провайдера",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Имя провайдера",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Параметры возврата от провайдера",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.OIDCCallbackRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.IdentityResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Привязка истекла или провайдер ее не подтвердил",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Учетная запись провайдера уже привязана",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/me/password": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Меняет пароль после проверки текущего. Все сеансы пользователя завершаются, для текущего выдаются новые токены",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Профиль"
+                ],
+                "summary": "Сменить пароль",
+                "parameters": [
+                    {
+                        "description": "Текущий и новый пароль",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ChangePasswordRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/models.TokenPair"
+                        }
+                    },
+                    "400": {
+                        "description": "Текущий пароль неверный",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/media/groups": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Создает новую группу для организации медиафайлов",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Медиа-группы"
+                ],
+                "summary": "Создать медиагруппу",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Описание группы",
+                        "name": "description",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Группа создана (no content)"
+                    },
+                    "400": {
+                        "description": "Невалидный UUID владельца",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка создания группы",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/media/groups/upload": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Загружает несколько медиафайлов и объединяет их в новую группу. Медиа и группа создаются в одной транзакции.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Медиа-группы"
+                ],
+                "summary": "Загрузка файлов в новую медиа-группу",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Файлы для загрузки (поддерживается множественная загрузка)",
+                        "name": "files",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Тип медиа (например, image, video)",
+                        "name": "media_type",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Флаг публичности файла",
+                        "name": "is_public",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дополнительные метаданные (опционально)",
+                        "name": "metadata",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Описание группы",
+                        "name": "description",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Группа и созданные медиа",
+                        "schema": {
+                            "$ref": "#/definitions/dto.MediaGroupUploadResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации входных данных",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера при загрузке файлов",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/media/groups/{group_id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает список всех медиафайлов в группе",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Медиа-группы"
+                ],
+                "summary": "Получить медиа группы",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID группы",
+                        "name": "group_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Список медиафайлов",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Media"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидный UUID группы",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка получения списка",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/media/groups/{group_id}/attach": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Связывает один или несколько медиафайлов с существующей медиагруппой",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Медиа-группы"
+                ],
+                "summary": "Прикрепить медиа к группе",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID группы",
+                        "name": "group_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Данные для прикрепления",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.AttachMediaRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешное прикрепление (no content)"
+                    },
+                    "400": {
+                        "description": "Невалидные данные: пустой массив, неверный формат UUID",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Группа не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Превышен лимит количества медиафайлов",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка привязки медиа",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/media/images": {
+            "get": {
+                "description": "Возвращает список всех загруженных изображений с метаданными",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Медиа"
+                ],
+                "summary": "Получить все изображения",
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/media/multiple": {
+            "post": {
+                "description": "Загружает несколько медиафайлов на сервер. Поддерживает передачу файлов и общих параметров загрузки.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Медиа"
+                ],
+                "summary": "Загрузка множества медиафайлов",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Файлы для загрузки (поддерживается множественная загрузка)",
+                        "name": "files",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Тип медиа (например, image, video)",
+                        "name": "media_type",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Флаг публичности файла",
+                        "name": "is_public",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дополнительные метаданные (опционально)",
+                        "name": "metadata",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Успешная загрузка, возвращает массив созданных медиа-объектов",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Media"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Ошибка валидации входных данных",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера при загрузке файлов",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/media/upload": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Загружает файл на сервер с возможностью указания метаданных",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Медиа"
+                ],
+                "summary": "Загрузка медиафайла",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Файл для загрузки (макс. 10MB)",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "photo",
+                            "video",
+                            "audio",
+                            "document"
+                        ],
+                        "type": "string",
+                        "description": "Тип контента",
+                        "name": "media_type",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Публичный доступ (по умолчанию false)",
+                        "name": "is_public",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Дополнительные метаданные в JSON-формате",
+                        "name": "metadata",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ширина в пикселях (для изображений/видео)",
+                        "name": "width",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Высота в пикселях (для изображений/видео)",
+                        "name": "height",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Длительность в секундах (для видео/аудио)",
+                        "name": "duration",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Успешно загруженный медиаобъект",
+                        "schema": {
+                            "$ref": "#/definitions/models.Media"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные входные данные",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "413": {
+                        "description": "Превышен максимальный размер файла",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "415": {
+                        "description": "Неподдерживаемый тип файла",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/posts": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает список постов с пагинацией и фильтрацией по статусу, категории, тегам, автору и дате. http://localhost:8080/api/v1/posts?status=published\u0026category=travel\u0026tags=go\u0026tags=web\u0026from=2024-01-01\u0026page=1\u0026per_page=10",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Посты"
+                ],
+                "summary": "Список постов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Фильтр по статусу (draft, published, archived, all). Кроме published, только для администратора",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "UUID или slug категории (включая подкатегории)",
+                        "name": "category",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Slug'и тегов, пост должен содержать любой из них",
+                        "name": "tags",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID автора",
+                        "name": "author_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало периода (RFC3339 или YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода (RFC3339 или YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Количество элементов на странице",
+                        "name": "per_page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Значение next_cursor предыдущей страницы. Если задан, page не учитывается",
+                        "name": "cursor",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.BlogPostListResponse"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "Ссылки first, prev, next и last (RFC 8288)"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Создает новый пост блога от имени текущего пользователя. Добавлять FeaturedImageID -\u003e только существующую медиа",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Посты"
+                ],
+                "summary": "Создать новый пост",
+                "parameters": [
+                    {
+                        "description": "Данные поста",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateBlogPostRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.BlogPostResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/posts/by-slug/{slug}": {
+            "get": {
+                "description": "Возвращает пост по его slug. Для прежнего slug переименованного поста возвращает 301 на актуальный адрес.\nНеопубликованные посты видит только администратор, остальным возвращается 404",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Посты"
+                ],
+                "summary": "Получить пост по slug",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Slug поста",
+                        "name": "slug",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.BlogPostResponse"
+                        }
+                    },
+                    "301": {
+                        "description": "Пост переименован, актуальный адрес в заголовке Location"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/posts/slug-availability": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Проверяет, не занят ли slug другим постом, в том числе в истории переименований",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Посты"
+                ],
+                "summary": "Проверить доступность slug поста",
+                "parameters": [
+                    {
+                        "description": "Проверяемый slug",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.SlugAvailabilityRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.SlugAvailabilityResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/posts/tags": {
+            "get": {
+                "description": "Возвращает теги постов с количеством использований, отсортированные по популярности",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Посты"
+                ],
+                "summary": "Облако тегов постов",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "published",
+                        "description": "Статус учитываемых постов (draft, published, archived, all). Кроме published, только для администратора",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Максимальное количество тегов",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.TagCountResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/posts/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает пост по его ID. Неопубликованные посты видит только администратор, остальным возвращается 404",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Посты"
+                ],
+                "summary": "Получить пост",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID поста",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.BlogPostResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Обновляет данные поста",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Посты"
+                ],
+                "summary": "Обновить пост",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID поста",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Данные для обновления",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateBlogPostRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.BlogPostResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Удаляет пост (физическое удаление)",
+                "tags": [
+                    "Посты"
+                ],
+                "summary": "Удалить пост",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID поста",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/posts/{id}/archive": {
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Архивирует пост (soft delete)",
+                "tags": [
+                    "Посты"
+                ],
+                "summary": "Архивировать пост",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID поста",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.BlogPostResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/posts/{id}/comments": {
+            "get": {
+                "description": "Возвращает одобренные комментарии опубликованного поста в виде дерева",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Комментарии"
+                ],
+                "summary": "Комментарии поста",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID поста",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.CommentResponse"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Добавляет комментарий к опубликованному посту. Комментарий появится после модерации",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Комментарии"
+                ],
+                "summary": "Оставить комментарий",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID поста",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Текст комментария",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateCommentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/dto.CommentResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Комментарии к посту закрыты",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много комментариев",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/posts/{id}/media-groups": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает список медиа-групп, привязанных к посту",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Посты"
+                ],
+                "summary": "Получить медиа-группы поста",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID поста",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Тип связи (content, gallery, attachment)",
+                        "name": "relation_type",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.PostMediaGroupsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Привязывает медиа-группу к посту с указанием типа связи",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Посты"
+                ],
+                "summary": "Добавить медиа-группу к посту",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID поста",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Данные медиа-группы",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.AddMediaGroupRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.PostMediaGroupsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/posts/{id}/publish": {
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Устанавливает статус поста \"published\"",
+                "tags": [
+                    "Посты"
+                ],
+                "summary": "Опубликовать пост",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID поста",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.BlogPostResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/register": {
+            "post": {
+                "description": "Создание аккаунта. Возвращает ID пользователя.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Регистрация нового пользователя",
+                "parameters": [
+                    {
+                        "description": "Данные для регистрации",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UserRegisterInput"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Успешная регистрация",
+                        "schema": {
+                            "allOf": [
+                                {
+                                    "$ref": "#/definitions/response.Response"
+                                },
+                                {
+                                    "type": "object",
+                                    "properties": {
+                                        "data": {
+                                            "type": "object",
+                                            "properties": {
+                                                "user_id": {
+                                                    "type": "string"
+                                                }
+                                            }
+                                        }
+                                    }
+                                }
+                            ]
+                        }
+                    },
+                    "400": {
+                        "description": "Неверный формат запроса",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "409": {
+                        "description": "Пользователь уже существует",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/user_id": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Возвращает профиль пользователя по его UUID. Чужие профили доступны только администраторам, свой профиль удобнее получать через /api/v1/me",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Пользователи"
+                ],
+                "summary": "Получение информации о пользователе",
+                "parameters": [
+                    {
+                        "format": "uuid",
+                        "example": "\"a8a8a8a8-a8a8-a8a8-a8a8-a8a8a8a8a8a8\"",
+                        "description": "UUID пользователя",
+                        "name": "user_id",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешно полученные данные пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/dto.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный UUID пользователя",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "403": {
+                        "description": "Нет доступа к чужому профилю",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Пользователь не найден",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/users/{user_id}/is-admin": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Проверяет, является ли указанный пользователь администратором",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Проверка административного статуса пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID пользователя",
+                        "name": "user_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Результат проверки\" example({\"is_admin\": true})",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "boolean"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Невалидный UUID",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/atom.xml": {
+            "get": {
+                "description": "Последние опубликованные посты в формате Atom",
+                "produces": [
+                    "text/xml"
+                ],
+                "tags": [
+                    "Ленты"
+                ],
+                "summary": "Atom лента",
+                "responses": {
+                    "200": {
+                        "description": "Atom",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/feed.xml": {
+            "get": {
+                "description": "Последние опубликованные посты в формате RSS 2.0",
+                "produces": [
+                    "text/xml"
+                ],
+                "tags": [
+                    "Ленты"
+                ],
+                "summary": "RSS лента",
+                "responses": {
+                    "200": {
+                        "description": "RSS 2.0",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/galleries": {
+            "get": {
+                "description": "Возвращает список галерей с фильтрацией по статусу, тегам, автору, дате и названию. Страница задается номером (page) или курсором (cursor) из next_cursor предыдущего ответа. http://localhost:8080/api/v1/gallery/galleries?tags=nature\u0026tags=art\u0026match_all=true\u0026sort=title\u0026per_page=20",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Галереи"
+                ],
+                "summary": "Получение списка галерей",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "published",
+                        "description": "Фильтр по статусу галереи (draft, published, archived, all). Кроме published, только для администратора",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Теги галереи",
+                        "name": "tags",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "default": false,
+                        "description": "true — галерея содержит все теги, false — любой из них",
+                        "name": "match_all",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "UUID автора",
+                        "name": "author_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало периода (RFC3339 или YYYY-MM-DD)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода (RFC3339 или YYYY-MM-DD)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Подстрока в названии",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "created",
+                        "description": "Сортировка (created, published, title)",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Курсор следующей страницы",
+                        "name": "cursor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 1,
+                        "description": "Номер страницы (по умолчанию: 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "example": 10,
+                        "description": "Количество элементов на странице (по умолчанию: 10, максимум: 100)",
+                        "name": "per_page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Галереи и общее количество с учетом фильтров",
+                        "schema": {
+                            "$ref": "#/definitions/dto.GalleryListResponse"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "Ссылки first, prev, next и last (RFC 8288)"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные параметры запроса",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Создает новую галерею на основе переданных данных.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Галереи"
+                ],
+                "summary": "Создание новой галереи",
+                "parameters": [
+                    {
+                        "description": "Данные для создания галереи",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateGalleryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Галерея успешно создана, возвращает ID созданной галереи",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные данные запроса",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при создании галереи",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/galleries/by-slug/{slug}": {
+            "get": {
+                "description": "Возвращает галерею по slug. Для прежнего slug переименованной галереи возвращает 301 на актуальный адрес.\nНеопубликованные галереи видит только администратор, остальным возвращается 404.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Галереи"
+                ],
+                "summary": "Получение галереи по slug",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "\"summer-in-paris\"",
+                        "description": "Slug галереи",
+                        "name": "slug",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ с данными галереи",
+                        "schema": {
+                            "$ref": "#/definitions/dto.GalleryResponse"
+                        }
+                    },
+                    "301": {
+                        "description": "Галерея переименована, актуальный адрес в заголовке Location"
+                    },
+                    "404": {
+                        "description": "Галерея не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/galleries/by-tags": {
+            "get": {
+                "description": "Возвращает список галерей, отфильтрованных по указанным тегам, с возможностью выбора логики фильтрации (AND/OR). По умолчанию только опубликованные.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Галереи"
+                ],
+                "summary": "Получение списка галерей по тегам",
+                "parameters": [
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "example": "[\"nature\", \"art\"]",
+                        "description": "Список тегов для фильтрации",
+                        "name": "tags",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "example": false,
+                        "description": "Режим фильтрации: true — AND, false — OR (по умолчанию: false)",
+                        "name": "match_all",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "published",
+                        "description": "Фильтр по статусу галереи. Кроме published, только для администратора",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 1,
+                        "description": "Номер страницы",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 10,
+                        "description": "Количество элементов на странице",
+                        "name": "per_page",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ с данными галерей",
+                        "schema": {
+                            "$ref": "#/definitions/dto.GalleryListResponse"
+                        },
+                        "headers": {
+                            "Link": {
+                                "type": "string",
+                                "description": "Ссылки first, prev, next и last (RFC 8288)"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/galleries/slug-availability": {
+            "post": {
+                "description": "Проверяет, не занят ли slug другой галереей, в том числе в истории переименований.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Галереи"
+                ],
+                "summary": "Проверка доступности slug галереи",
+                "parameters": [
+                    {
+                        "description": "Проверяемый slug",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.SlugAvailabilityRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.SlugAvailabilityResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный slug",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/galleries/{gallery_id}/has-tags": {
+            "get": {
+                "description": "Проверяет, содержит ли галерея все указанные теги",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Галереи"
+                ],
+                "summary": "Проверка наличия тегов у галереи",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "\"a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11\"",
+                        "description": "Идентификатор галереи",
+                        "name": "gallery_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "csv",
+                        "example": "[\"art\", \"design\"]",
+                        "description": "Список тегов для проверки",
+                        "name": "tags",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Результат проверки",
+                        "schema": {
+                            "type": "boolean"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/galleries/{gallery_id}/tags": {
+            "get": {
+                "description": "Возвращает список тегов для указанной галереи",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Галереи"
+                ],
+                "summary": "Получение тегов галереи",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "\"a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11\"",
+                        "description": "Идентификатор галереи",
+                        "name": "gallery_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Список тегов галереи",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Полностью заменяет теги галереи на указанные",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Галереи"
+                ],
+                "summary": "Замена тегов галереи",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "\"a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11\"",
+                        "description": "Идентификатор галереи",
+                        "name": "gallery_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "example": "[\"new\", \"tags\"]",
+                        "description": "Новый список тегов",
+                        "name": "tags",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Теги успешно заменены"
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Добавляет указанные теги к галерее",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Галереи"
+                ],
+                "summary": "Добавление тегов к галерее",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "\"a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11\"",
+                        "description": "Идентификатор галереи",
+                        "name": "gallery_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "example": "[\"art\", \"design\"]",
+                        "description": "Список тегов для добавления",
+                        "name": "tags",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Теги успешно добавлены"
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет указанные теги из галереи",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Галереи"
+                ],
+                "summary": "Удаление тегов из галереи",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "\"a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11\"",
+                        "description": "Идентификатор галереи",
+                        "name": "gallery_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "example": "[\"old\", \"tag\"]",
+                        "description": "Список тегов для удаления",
+                        "name": "tags",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Теги успешно удалены"
+                    },
+                    "400": {
+                        "description": "Некорректный запрос",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/galleries/{id}": {
+            "get": {
+                "description": "Возвращает полную информацию о галерее по её уникальному идентификатору.\nНеопубликованные галереи видит только администратор, остальным возвращается 404.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Галереи"
+                ],
+                "summary": "Получение галереи по ID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "\"1221067c-cc35-4dae-b5f5-feee4bbb3e22\"",
+                        "description": "UUID галереи",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Успешный ответ с данными галереи",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный формат UUID",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Галерея не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Обновляет данные существующей галереи на основе переданных данных.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Галереи"
+                ],
+                "summary": "Обновление галереи",
+                "parameters": [
+                    {
+                        "description": "Данные для обновления галереи",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateGalleryRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Галерея успешно обновлена",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные данные запроса",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при обновлении галереи",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет существующую галерею по её ID.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Галереи"
+                ],
+                "summary": "Удаление галереи",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID галереи",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Галерея успешно удалена",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID галереи",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при удалении галереи",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/galleries/{id}/archive": {
+            "get": {
+                "description": "Потоково отдает ZIP-архив опубликованной галереи с файлом manifest.json (названия, подписи, alt-тексты). Размер изображений выбирается параметром size; если уменьшенного варианта нет, в архив попадает оригинал. Число одновременных скачиваний на пользователя ограничено.",
+                "produces": [
+                    "application/zip"
+                ],
+                "tags": [
+                    "Галереи"
+                ],
+                "summary": "Скачивание галереи архивом",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "\"1221067c-cc35-4dae-b5f5-feee4bbb3e22\"",
+                        "description": "UUID галереи",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "original",
+                            "large",
+                            "medium",
+                            "small"
+                        ],
+                        "type": "string",
+                        "default": "original",
+                        "description": "Размер изображений",
+                        "name": "size",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "ZIP-архив галереи",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID или размер",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Требуется авторизация",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Галерея не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "Слишком много одновременных скачиваний",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/galleries/{id}/cover": {
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Галереи"
+                ],
+                "summary": "Выбор обложки галереи",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID галереи",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Медиафайл обложки",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.SetGalleryCoverRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Обновленная галерея",
+                        "schema": {
+                            "$ref": "#/definitions/dto.GalleryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные данные запроса",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Изображение не найдено в галерее",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/galleries/{id}/items": {
+            "post": {
+                "description": "Добавляет медиафайлы в конец галереи с подписями и alt-текстом.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Галереи"
+                ],
+                "summary": "Добавление изображений в галерею",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID галереи",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Добавляемые изображения",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.AddGalleryItemsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Обновленная галерея",
+                        "schema": {
+                            "$ref": "#/definitions/dto.GalleryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные данные запроса или медиафайл не найден",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Галерея не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/galleries/{id}/items/order": {
+            "put": {
+                "description": "Принимает полный список ID медиафайлов галереи в новом порядке.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Галереи"
+                ],
+                "summary": "Изменение порядка изображений галереи",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID галереи",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новый порядок изображений",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ReorderGalleryItemsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Обновленная галерея",
+                        "schema": {
+                            "$ref": "#/definitions/dto.GalleryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Список не совпадает с изображениями галереи",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Галерея не найдена",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/galleries/{id}/items/{media_id}": {
+            "delete": {
+                "description": "Убирает изображение из галереи, сам медиафайл не удаляется.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Галереи"
+                ],
+                "summary": "Удаление изображения из галереи",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID галереи",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID медиафайла",
+                        "name": "media_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Обновленная галерея",
+                        "schema": {
+                            "$ref": "#/definitions/dto.GalleryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректный ID",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Изображение не найдено в галерее",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Галереи"
+                ],
+                "summary": "Обновление изображения галереи",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID галереи",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID медиафайла",
+                        "name": "media_id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Подпись и alt-текст",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateGalleryItemRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Обновленная галерея",
+                        "schema": {
+                            "$ref": "#/definitions/dto.GalleryResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные данные запроса",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Изображение не найдено в галерее",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/galleries/{id}/status": {
+            "put": {
+                "description": "Обновляет статус существующей галереи на основе переданных данных.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Галереи"
+                ],
+                "summary": "Обновление статуса галереи",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID галереи",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Данные для обновления статуса галереи",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.UpdateGalleryStatusRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Статус галереи успешно обновлен",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные данные запроса",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Ошибка при обновлении статуса галереи",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/gallery/tags": {
+            "get": {
+                "description": "Возвращает теги галерей с количеством использований, отсортированные по популярности. Параметр prefix используется для автодополнения.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Галереи"
+                ],
+                "summary": "Справочник тегов галерей",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Начало тега",
+                        "name": "prefix",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "published",
+                        "description": "Статус учитываемых галерей (draft, published, archived, all)",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "default": 100,
+                        "description": "Максимальное количество тегов",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.GalleryTagResponse"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/gallery/tags/aliases": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Галереи"
+                ],
+                "summary": "Алиасы тегов галерей",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.GalleryTagAliasResponse"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/gallery/tags/aliases/{alias}": {
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Галереи"
+                ],
+                "summary": "Удаление алиаса тега",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Алиас",
+                        "name": "alias",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Алиас удален"
+                    },
+                    "400": {
+                        "description": "Некорректный алиас",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Алиас не найден",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/gallery/tags/merge": {
+            "post": {
+                "description": "Заменяет исходные теги целевым во всех галереях одной транзакцией. С keep_aliases исходные теги продолжают находить галереи.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Галереи"
+                ],
+                "summary": "Слияние тегов галерей",
+                "parameters": [
+                    {
+                        "description": "Исходные теги и целевой тег",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.MergeGalleryTagsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.GalleryTagOperationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные данные запроса",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/gallery/tags/rename": {
+            "post": {
+                "description": "Переименовывает тег во всех галереях одной транзакцией. С keep_alias старое имя продолжает находить галереи.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Галереи"
+                ],
+                "summary": "Переименование тега галерей",
+                "parameters": [
+                    {
+                        "description": "Старое и новое имя тега",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.RenameGalleryTagRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.GalleryTagOperationResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Некорректные данные запроса",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Внутренняя ошибка сервера",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/sitemap.xml": {
+            "get": {
+                "description": "Индекс sitemap со ссылками на страницы опубликованных постов и галерей",
+                "produces": [
+                    "text/xml"
+                ],
+                "tags": [
+                    "Ленты"
+                ],
+                "summary": "Индекс sitemap",
+                "responses": {
+                    "200": {
+                        "description": "Sitemap index",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/sitemaps/{name}": {
+            "get": {
+                "description": "Страница sitemap раздела постов или галерей, например posts-1.xml",
+                "produces": [
+                    "text/xml"
+                ],
+                "tags": [
+                    "Ленты"
+                ],
+                "summary": "Страница sitemap",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Имя файла из индекса sitemap",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Sitemap",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/response.Problem"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "apperr.FieldError": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "description": "Стабильный код, например required или max",
+                    "type": "string"
+                },
+                "field": {
+                    "description": "Имя поля в запросе",
+                    "type": "string"
+                },
+                "message": {
+                    "description": "Описание для человека",
+                    "type": "string"
+                },
+                "param": {
+                    "description": "Параметр правила, например 255 для max=255",
+                    "type": "string"
+                }
+            }
+        },
+        "dto.APIKeyCreatedResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "rate_limit": {
+                    "type": "integer"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.APIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "rate_limit": {
+                    "type": "integer"
+                },
+                "revoked_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.AddGalleryItemsRequest": {
+            "type": "object",
+            "required": [
+                "items"
+            ],
+            "properties": {
+                "items": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/dto.GalleryItemInput"
+                    }
+                }
+            }
+        },
+        "dto.AddMediaGroupRequest": {
+            "type": "object",
+            "required": [
+                "group_id",
+                "relation_type"
+            ],
+            "properties": {
+                "group_id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "relation_type": {
+                    "type": "string",
+                    "enum": [
+                        "content",
+                        "gallery",
+                        "attachment"
+                    ]
+                }
+            }
+        },
+        "dto.AttachMediaRequest": {
+            "type": "object",
+            "required": [
+                "group_id",
+                "media_id"
+            ],
+            "properties": {
                 "group_id": {
                     "type": "string"
                 },
                 "media_id": {
                     "type": "array",
-                    "minItems": 1,
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "dto.AuditEventListResponse": {
+            "type": "object",
+            "properties": {
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.AuditEventResponse"
+                    }
+                },
+                "next_cursor": {
+                    "description": "Курсор следующей страницы, пусто на последней",
+                    "type": "string"
+                },
+                "page": {
+                    "description": "Номер страницы, при переходе по курсору не используется",
+                    "type": "integer"
+                },
+                "per_page": {
+                    "description": "Размер страницы",
+                    "type": "integer"
+                },
+                "total": {
+                    "description": "Количество записей, удовлетворяющих фильтру",
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "description": "Количество страниц",
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.AuditEventResponse": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "example": "post.publish"
+                },
+                "actor_id": {
+                    "description": "Пусто для служебных команд и анонимных запросов",
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "after": {
+                    "description": "Состояние объекта после изменения",
+                    "type": "object"
+                },
+                "api_key_id": {
+                    "description": "Ключ API, с которым выполнено действие. Пусто для входа по токену",
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "before": {
+                    "description": "Состояние объекта до изменения",
+                    "type": "object"
+                },
+                "id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "occurred_at": {
+                    "type": "string"
+                },
+                "target_id": {
+                    "type": "string"
+                },
+                "target_type": {
+                    "type": "string",
+                    "example": "post"
+                },
+                "user_agent": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.BlogPostListResponse": {
+            "type": "object",
+            "properties": {
+                "next_cursor": {
+                    "description": "Курсор следующей страницы, пусто на последней",
+                    "type": "string"
+                },
+                "page": {
+                    "description": "Номер страницы, при переходе по курсору не используется",
+                    "type": "integer"
+                },
+                "per_page": {
+                    "description": "Размер страницы",
+                    "type": "integer"
+                },
+                "posts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.BlogPostResponse"
+                    }
+                },
+                "total": {
+                    "description": "Количество записей, удовлетворяющих фильтру",
+                    "type": "integer"
+                },
+                "total_count": {
+                    "description": "Устарело: то же, что total",
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "description": "Количество страниц",
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.BlogPostResponse": {
+            "type": "object",
+            "properties": {
+                "author_id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "category_id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "comment_count": {
+                    "description": "Количество одобренных комментариев",
+                    "type": "integer"
+                },
+                "comments_closed": {
+                    "type": "boolean"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "content_format": {
+                    "type": "string"
+                },
+                "content_html": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "excerpt": {
+                    "type": "string"
+                },
+                "featured_image_id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "featured_image_path": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "media_groups": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "array",
+                        "items": {
+                            "$ref": "#/definitions/dto.MediaItemResponse"
+                        }
+                    }
+                },
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "published_at": {
+                    "type": "string"
+                },
+                "reading_time": {
+                    "description": "Время чтения в минутах",
+                    "type": "integer"
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.BlogTagResponse"
+                    }
+                },
+                "title": {
+                    "type": "string"
+                },
+                "toc": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.TOCEntry"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.BlogTagResponse": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "slug": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CSRFTokenResponse": {
+            "type": "object",
+            "properties": {
+                "csrf_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CategoryResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parent_id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.ChangePasswordRequest": {
+            "type": "object",
+            "required": [
+                "current_password",
+                "new_password"
+            ],
+            "properties": {
+                "current_password": {
+                    "type": "string"
+                },
+                "new_password": {
+                    "type": "string",
+                    "maxLength": 64,
+                    "minLength": 8
+                }
+            }
+        },
+        "dto.CommentListResponse": {
+            "type": "object",
+            "properties": {
+                "comments": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.CommentResponse"
+                    }
+                },
+                "next_cursor": {
+                    "description": "Курсор следующей страницы, пусто на последней",
+                    "type": "string"
+                },
+                "page": {
+                    "description": "Номер страницы, при переходе по курсору не используется",
+                    "type": "integer"
+                },
+                "per_page": {
+                    "description": "Размер страницы",
+                    "type": "integer"
+                },
+                "total": {
+                    "description": "Количество записей, удовлетворяющих фильтру",
+                    "type": "integer"
+                },
+                "total_count": {
+                    "description": "Устарело: то же, что total",
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "description": "Количество страниц",
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.CommentResponse": {
+            "type": "object",
+            "properties": {
+                "author_name": {
+                    "type": "string"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "moderated_at": {
+                    "type": "string"
+                },
+                "moderated_by": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "parent_id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "post_id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "replies": {
+                    "description": "Ответы, только в древовидном списке поста",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.CommentResponse"
+                    }
+                },
+                "status": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "string",
+                    "format": "uuid"
+                }
+            }
+        },
+        "dto.ConfirmEmailRequest": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CreateAPIKeyRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "expires_at": {
+                    "description": "Без срока ключ действует до отзыва",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "rate_limit": {
+                    "description": "Запросов в минуту, по умолчанию rate_limits.api_key_default из конфига",
+                    "type": "integer",
+                    "maximum": 6000,
+                    "minimum": 1
+                },
+                "scopes": {
+                    "description": "posts:read, posts:write, media:read, media:write, galleries:read, galleries:write",
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "dto.CreateBlogPostRequest": {
+            "type": "object",
+            "required": [
+                "content",
+                "title"
+            ],
+            "properties": {
+                "category_id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "comments_closed": {
+                    "type": "boolean"
+                },
+                "content": {
+                    "type": "string"
+                },
+                "content_format": {
+                    "type": "string",
+                    "enum": [
+                        "markdown",
+                        "html",
+                        "plain"
+                    ]
+                },
+                "excerpt": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "featured_image_id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "media_groups": {
+                    "description": "Привязываются вместе с постом в одной транзакции",
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "$ref": "#/definitions/dto.AddMediaGroupRequest"
+                    }
+                },
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": {}
+                },
+                "published_at": {
+                    "type": "string"
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "draft",
+                        "published",
+                        "archived"
+                    ]
+                },
+                "tags": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 3
+                }
+            }
+        },
+        "dto.CreateCategoryRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "maxLength": 500
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 2
+                },
+                "parent_id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "slug": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "dto.CreateCommentRequest": {
+            "type": "object",
+            "required": [
+                "content"
+            ],
+            "properties": {
+                "content": {
+                    "type": "string",
+                    "maxLength": 5000,
+                    "minLength": 1
+                },
+                "parent_id": {
+                    "type": "string",
+                    "format": "uuid"
+                }
+            }
+        },
+        "dto.CreateGalleryRequest": {
+            "type": "object",
+            "required": [
+                "title"
+            ],
+            "properties": {
+                "cover_image_index": {
+                    "description": "Устарело: индекс обложки в images",
+                    "type": "integer"
+                },
+                "cover_media_id": {
+                    "description": "По умолчанию первое изображение",
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "images": {
+                    "description": "Устарело: пути изображений, сопоставляются с media по storage_path",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "items": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.GalleryItemInput"
+                    }
+                },
+                "metadata": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "slug": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "draft",
+                        "published",
+                        "archived"
+                    ]
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "title": {
+                    "type": "string",
+                    "maxLength": 255
+                }
+            }
+        },
+        "dto.DisableTwoFactorRequest": {
+            "type": "object",
+            "required": [
+                "current_password"
+            ],
+            "properties": {
+                "current_password": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.GalleryItemInput": {
+            "type": "object",
+            "required": [
+                "media_id"
+            ],
+            "properties": {
+                "alt_text": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "caption": {
+                    "type": "string",
+                    "maxLength": 500
+                },
+                "media_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.GalleryItemResponse": {
+            "type": "object",
+            "properties": {
+                "alt_text": {
+                    "type": "string"
+                },
+                "caption": {
+                    "type": "string"
+                },
+                "media_id": {
+                    "type": "string"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "storage_path": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.GalleryListResponse": {
+            "type": "object",
+            "properties": {
+                "galleries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.GalleryResponse"
+                    }
+                },
+                "next_cursor": {
+                    "description": "Курсор следующей страницы, пусто на последней",
+                    "type": "string"
+                },
+                "page": {
+                    "description": "Номер страницы, при переходе по курсору не используется",
+                    "type": "integer"
+                },
+                "per_page": {
+                    "description": "Размер страницы",
+                    "type": "integer"
+                },
+                "total": {
+                    "description": "Количество записей, удовлетворяющих фильтру",
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "description": "Количество страниц",
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.GalleryResponse": {
+            "type": "object",
+            "properties": {
+                "author_id": {
+                    "description": "Идентификатор автора галереи",
+                    "type": "string"
+                },
+                "cover_image_index": {
+                    "description": "Индекс изображения, используемого как обложка",
+                    "type": "integer"
+                },
+                "cover_media_id": {
+                    "description": "Медиа обложки",
+                    "type": "string"
+                },
+                "created_at": {
+                    "description": "Дата и время создания галереи",
+                    "type": "string"
+                },
+                "description": {
+                    "description": "Описание галереи",
+                    "type": "string"
+                },
+                "id": {
+                    "description": "Уникальный идентификатор галереи",
+                    "type": "string"
+                },
+                "images": {
+                    "description": "Пути изображений в порядке items, оставлены для совместимости",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "items": {
+                    "description": "Изображения галереи с подписями",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.GalleryItemResponse"
+                    }
+                },
+                "metadata": {
+                    "description": "Дополнительные метаданные (может быть произвольной структурой)"
+                },
+                "published_at": {
+                    "description": "Дата и время публикации (если галерея опубликована)",
+                    "type": "string"
+                },
+                "slug": {
+                    "description": "Уникальный URL-идентификатор галереи",
+                    "type": "string"
+                },
+                "status": {
+                    "description": "Статус галереи (например, \"draft\", \"published\", \"archived\")",
+                    "type": "string"
+                },
+                "tags": {
+                    "description": "Список тегов, связанных с галереей",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "title": {
+                    "description": "Название галереи",
+                    "type": "string"
+                },
+                "updated_at": {
+                    "description": "Дата и время последнего обновления галереи",
+                    "type": "string"
+                }
+            }
+        },
+        "dto.GalleryTagAliasResponse": {
+            "type": "object",
+            "properties": {
+                "alias": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "tag": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.GalleryTagOperationResponse": {
+            "type": "object",
+            "properties": {
+                "galleries": {
+                    "description": "Количество измененных галерей",
+                    "type": "integer"
+                },
+                "tag": {
+                    "description": "Итоговый тег",
+                    "type": "string"
+                }
+            }
+        },
+        "dto.GalleryTagResponse": {
+            "type": "object",
+            "properties": {
+                "count": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.IdentityProvidersResponse": {
+            "type": "object",
+            "properties": {
+                "providers": {
+                    "type": "array",
                     "items": {
                         "type": "string"
                     }
                 }
             }
         },
-        "dto.BlogPostListResponse": {
+        "dto.IdentityResponse": {
             "type": "object",
             "properties": {
-                "page": {
-                    "type": "integer"
+                "created_at": {
+                    "type": "string"
                 },
-                "per_page": {
-                    "type": "integer"
+                "email": {
+                    "type": "string"
                 },
-                "posts": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/dto.BlogPostResponse"
-                    }
+                "last_login_at": {
+                    "type": "string"
                 },
-                "total_count": {
-                    "type": "integer"
+                "provider": {
+                    "type": "string"
                 }
             }
         },
-        "dto.BlogPostResponse": {
+        "dto.LoginChallengeRequest": {
             "type": "object",
+            "required": [
+                "challenge_id"
+            ],
             "properties": {
-                "author_id": {
-                    "type": "string",
-                    "format": "uuid"
-                },
-                "content": {
+                "challenge_id": {
                     "type": "string"
-                },
-                "created_at": {
+                }
+            }
+        },
+        "dto.LoginTwoFactorRequest": {
+            "type": "object",
+            "required": [
+                "challenge_id",
+                "code"
+            ],
+            "properties": {
+                "challenge_id": {
                     "type": "string"
                 },
-                "excerpt": {
+                "code": {
+                    "description": "Код из приложения-аутентификатора или код восстановления",
+                    "type": "string"
+                }
+            }
+        },
+        "dto.MediaGroupResponse": {
+            "type": "object",
+            "properties": {
+                "added_at": {
                     "type": "string"
                 },
-                "featured_image_id": {
+                "group_id": {
                     "type": "string",
                     "format": "uuid"
                 },
-                "featured_image_path": {
+                "relation_type": {
                     "type": "string"
-                },
-                "id": {
+                }
+            }
+        },
+        "dto.MediaGroupUploadResponse": {
+            "type": "object",
+            "properties": {
+                "group_id": {
                     "type": "string",
                     "format": "uuid"
                 },
-                "media_groups": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "type": "array",
-                        "items": {
-                            "$ref": "#/definitions/dto.MediaItemResponse"
-                        }
+                "media": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Media"
                     }
-                },
-                "metadata": {
-                    "type": "object",
-                    "additionalProperties": {}
-                },
-                "published_at": {
-                    "type": "string"
-                },
-                "slug": {
+                }
+            }
+        },
+        "dto.MediaItemResponse": {
+            "type": "object",
+            "properties": {
+                "group_id": {
                     "type": "string"
                 },
-                "status": {
+                "id": {
                     "type": "string"
                 },
-                "title": {
-                    "type": "string"
+                "position": {
+                    "type": "integer"
                 },
-                "updated_at": {
+                "storage_path": {
                     "type": "string"
                 }
             }
         },
-        "dto.CreateBlogPostRequest": {
+        "dto.MergeGalleryTagsRequest": {
             "type": "object",
             "required": [
-                "author_id",
-                "content",
-                "title"
+                "sources",
+                "target"
             ],
             "properties": {
-                "author_id": {
-                    "type": "string",
-                    "format": "uuid"
-                },
-                "content": {
-                    "type": "string"
+                "keep_aliases": {
+                    "description": "Сохранить исходные теги как алиасы target",
+                    "type": "boolean"
                 },
-                "excerpt": {
-                    "type": "string",
-                    "maxLength": 255
+                "sources": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "featured_image_id": {
+                "target": {
                     "type": "string",
-                    "format": "uuid"
-                },
-                "metadata": {
-                    "type": "object",
-                    "additionalProperties": {}
-                },
-                "published_at": {
+                    "maxLength": 50
+                }
+            }
+        },
+        "dto.OIDCCallbackRequest": {
+            "type": "object",
+            "required": [
+                "code",
+                "state"
+            ],
+            "properties": {
+                "code": {
                     "type": "string"
                 },
-                "slug": {
+                "state": {
                     "type": "string"
+                }
+            }
+        },
+        "dto.OIDCStartResponse": {
+            "type": "object",
+            "properties": {
+                "authorization_url": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.PostMediaGroupsResponse": {
+            "type": "object",
+            "properties": {
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.MediaGroupResponse"
+                    }
                 },
-                "status": {
-                    "type": "string",
-                    "enum": [
-                        "draft",
-                        "published",
-                        "archived"
-                    ]
-                },
-                "title": {
+                "post_id": {
                     "type": "string",
-                    "maxLength": 100,
-                    "minLength": 3
+                    "format": "uuid"
                 }
             }
         },
-        "dto.CreateGalleryRequest": {
+        "dto.RecoveryCodesResponse": {
+            "type": "object",
+            "properties": {
+                "recovery_codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "dto.RenameGalleryTagRequest": {
             "type": "object",
             "required": [
-                "author_id",
-                "title"
+                "from",
+                "to"
             ],
             "properties": {
-                "author_id": {
-                    "type": "string"
-                },
-                "cover_image_index": {
-                    "description": "Индекс изображения, используемого как обложка",
-                    "type": "integer"
+                "from": {
+                    "type": "string",
+                    "maxLength": 50
                 },
-                "description": {
-                    "type": "string"
+                "keep_alias": {
+                    "description": "Сохранить старое имя как алиас, чтобы старые ссылки продолжали работать",
+                    "type": "boolean"
                 },
-                "images": {
-                    "description": "Список изображений в галерее",
+                "to": {
+                    "type": "string",
+                    "maxLength": 50
+                }
+            }
+        },
+        "dto.ReorderGalleryItemsRequest": {
+            "type": "object",
+            "required": [
+                "media_ids"
+            ],
+            "properties": {
+                "media_ids": {
                     "type": "array",
+                    "minItems": 1,
                     "items": {
                         "type": "string"
                     }
-                },
-                "metadata": {
-                    "type": "object",
-                    "additionalProperties": true
-                },
+                }
+            }
+        },
+        "dto.SetGalleryCoverRequest": {
+            "type": "object",
+            "required": [
+                "media_id"
+            ],
+            "properties": {
+                "media_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.SlugAvailabilityRequest": {
+            "type": "object",
+            "required": [
+                "slug"
+            ],
+            "properties": {
                 "slug": {
                     "type": "string"
-                },
-                "status": {
+                }
+            }
+        },
+        "dto.SlugAvailabilityResponse": {
+            "type": "object",
+            "properties": {
+                "available": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "dto.SuspendUserRequest": {
+            "type": "object",
+            "properties": {
+                "reason": {
+                    "type": "string",
+                    "maxLength": 500
+                }
+            }
+        },
+        "dto.TOCEntry": {
+            "type": "object",
+            "properties": {
+                "id": {
                     "type": "string"
                 },
-                "tags": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "level": {
+                    "type": "integer"
                 },
-                "title": {
+                "text": {
                     "type": "string"
                 }
             }
         },
-        "dto.MediaGroupResponse": {
+        "dto.TagCountResponse": {
             "type": "object",
             "properties": {
-                "added_at": {
-                    "type": "string"
+                "count": {
+                    "type": "integer"
                 },
-                "group_id": {
-                    "type": "string",
-                    "format": "uuid"
+                "name": {
+                    "type": "string"
                 },
-                "relation_type": {
+                "slug": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.TwoFactorCodeRequest": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
                     "type": "string"
                 }
             }
         },
-        "dto.MediaItemResponse": {
+        "dto.TwoFactorEnrollmentResponse": {
             "type": "object",
             "properties": {
-                "group_id": {
+                "provisioning_uri": {
+                    "description": "Ссылка otpauth:// для QR-кода",
                     "type": "string"
                 },
-                "id": {
-                    "type": "string"
-                },
-                "position": {
-                    "type": "integer"
-                },
-                "storage_path": {
+                "secret": {
+                    "description": "Секрет в base32 для ручного ввода",
                     "type": "string"
                 }
             }
         },
-        "dto.PostMediaGroupsResponse": {
+        "dto.TwoFactorStatusResponse": {
             "type": "object",
             "properties": {
-                "groups": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/dto.MediaGroupResponse"
-                    }
+                "enabled": {
+                    "type": "boolean"
                 },
-                "post_id": {
-                    "type": "string",
-                    "format": "uuid"
+                "recovery_codes_left": {
+                    "type": "integer"
+                },
+                "required": {
+                    "description": "Политика безопасности требует 2FA для роли пользователя",
+                    "type": "boolean"
                 }
             }
         },
         "dto.UpdateBlogPostRequest": {
             "type": "object",
             "properties": {
+                "category_id": {
+                    "description": "Нулевой UUID убирает пост из категории",
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "comments_closed": {
+                    "type": "boolean"
+                },
                 "content": {
                     "type": "string"
                 },
+                "content_format": {
+                    "type": "string",
+                    "enum": [
+                        "markdown",
+                        "html",
+                        "plain"
+                    ]
+                },
                 "excerpt": {
                     "type": "string",
                     "maxLength": 255
@@ -2127,6 +6401,14 @@ const docTemplate = `{
                         "archived"
                     ]
                 },
+                "tags": {
+                    "description": "nil - теги не меняются, пустой список - удалить все теги",
+                    "type": "array",
+                    "maxItems": 20,
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "title": {
                     "type": "string",
                     "maxLength": 100,
@@ -2134,6 +6416,62 @@ const docTemplate = `{
                 }
             }
         },
+        "dto.UpdateCategoryRequest": {
+            "type": "object",
+            "properties": {
+                "description": {
+                    "type": "string",
+                    "maxLength": 500
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 2
+                },
+                "parent_id": {
+                    "description": "Нулевой UUID делает категорию корневой",
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "position": {
+                    "type": "integer"
+                },
+                "slug": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "dto.UpdateCommentStatusRequest": {
+            "type": "object",
+            "required": [
+                "status"
+            ],
+            "properties": {
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "pending",
+                        "approved",
+                        "spam",
+                        "rejected"
+                    ]
+                }
+            }
+        },
+        "dto.UpdateGalleryItemRequest": {
+            "type": "object",
+            "properties": {
+                "alt_text": {
+                    "type": "string",
+                    "maxLength": 255
+                },
+                "caption": {
+                    "type": "string",
+                    "maxLength": 500
+                }
+            }
+        },
         "dto.UpdateGalleryRequest": {
             "type": "object",
             "required": [
@@ -2142,9 +6480,13 @@ const docTemplate = `{
             ],
             "properties": {
                 "cover_image_index": {
-                    "description": "Индекс изображения, используемого как обложка",
+                    "description": "Устарело: индекс обложки в images",
                     "type": "integer"
                 },
+                "cover_media_id": {
+                    "description": "nil - обложка не меняется",
+                    "type": "string"
+                },
                 "description": {
                     "type": "string"
                 },
@@ -2152,21 +6494,34 @@ const docTemplate = `{
                     "type": "string"
                 },
                 "images": {
-                    "description": "Список изображений в галерее",
+                    "description": "Устарело: пути изображений, сопоставляются с media по storage_path",
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
                 },
+                "items": {
+                    "description": "nil - изображения не меняются",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.GalleryItemInput"
+                    }
+                },
                 "metadata": {
                     "type": "object",
                     "additionalProperties": true
                 },
                 "slug": {
-                    "type": "string"
+                    "type": "string",
+                    "maxLength": 255
                 },
                 "status": {
-                    "type": "string"
+                    "type": "string",
+                    "enum": [
+                        "draft",
+                        "published",
+                        "archived"
+                    ]
                 },
                 "tags": {
                     "type": "array",
@@ -2175,7 +6530,8 @@ const docTemplate = `{
                     }
                 },
                 "title": {
-                    "type": "string"
+                    "type": "string",
+                    "maxLength": 255
                 }
             }
         },
@@ -2186,7 +6542,88 @@ const docTemplate = `{
             ],
             "properties": {
                 "status": {
+                    "type": "string",
+                    "enum": [
+                        "draft",
+                        "published",
+                        "archived"
+                    ]
+                }
+            }
+        },
+        "dto.UpdateProfileRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "description": "Применяется после подтверждения кодом из письма",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 2
+                },
+                "phone": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.UpdateSecurityPolicyRequest": {
+            "type": "object",
+            "required": [
+                "require_two_factor"
+            ],
+            "properties": {
+                "require_two_factor": {
+                    "description": "Требовать 2FA от администраторов и редакторов",
+                    "type": "boolean"
+                }
+            }
+        },
+        "dto.UpdateUserRoleRequest": {
+            "type": "object",
+            "required": [
+                "role"
+            ],
+            "properties": {
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "user",
+                        "editor",
+                        "admin"
+                    ]
+                }
+            }
+        },
+        "dto.UserListResponse": {
+            "type": "object",
+            "properties": {
+                "next_cursor": {
+                    "description": "Курсор следующей страницы, пусто на последней",
                     "type": "string"
+                },
+                "page": {
+                    "description": "Номер страницы, при переходе по курсору не используется",
+                    "type": "integer"
+                },
+                "per_page": {
+                    "description": "Размер страницы",
+                    "type": "integer"
+                },
+                "total": {
+                    "description": "Количество записей, удовлетворяющих фильтру",
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "description": "Количество страниц",
+                    "type": "integer"
+                },
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.UserResponse"
+                    }
                 }
             }
         },
@@ -2202,9 +6639,6 @@ const docTemplate = `{
                 "email": {
                     "type": "string"
                 },
-                "is_admin": {
-                    "type": "boolean"
-                },
                 "name": {
                     "type": "string",
                     "maxLength": 100,
@@ -2221,6 +6655,76 @@ const docTemplate = `{
                 }
             }
         },
+        "dto.UserResponse": {
+            "type": "object",
+            "properties": {
+                "anonymized_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "is_admin": {
+                    "type": "boolean"
+                },
+                "last_login": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "pending_email": {
+                    "description": "Новый email, ожидающий подтверждения",
+                    "type": "string"
+                },
+                "phone": {
+                    "type": "string"
+                },
+                "registration_date": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "user",
+                        "editor",
+                        "admin"
+                    ]
+                },
+                "status": {
+                    "type": "string",
+                    "enum": [
+                        "active",
+                        "suspended",
+                        "anonymized"
+                    ]
+                },
+                "suspended_at": {
+                    "type": "string"
+                },
+                "suspended_by": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "suspension_reason": {
+                    "type": "string"
+                },
+                "two_factor_enabled": {
+                    "type": "boolean"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "updated_by": {
+                    "type": "string",
+                    "format": "uuid"
+                }
+            }
+        },
         "models.Media": {
             "type": "object",
             "properties": {
@@ -2284,37 +6788,29 @@ const docTemplate = `{
             "type": "object",
             "additionalProperties": true
         },
-        "models.User": {
+        "models.SecurityPolicy": {
             "type": "object",
             "properties": {
-                "basket_id": {
-                    "type": "string"
-                },
-                "email": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "string"
-                },
-                "is_admin": {
+                "require_two_factor": {
+                    "description": "2FA обязательна для администраторов и редакторов",
                     "type": "boolean"
-                },
-                "last_login": {
+                }
+            }
+        },
+        "models.TokenPair": {
+            "type": "object",
+            "properties": {
+                "access_token": {
                     "type": "string"
                 },
-                "name": {
+                "csrf_token": {
+                    "description": "Токен сеанса для заголовка X-CSRF-Token в запросах с cookie",
                     "type": "string"
                 },
-                "password": {
-                    "type": "array",
-                    "items": {
-                        "type": "integer"
-                    }
-                },
-                "phone": {
+                "refresh_token": {
                     "type": "string"
                 },
-                "registration_date": {
+                "user_id": {
                     "type": "string"
                 }
             }
@@ -2336,17 +6832,39 @@ const docTemplate = `{
                 }
             }
         },
-        "response.ErrorResponse": {
+        "response.Problem": {
             "type": "object",
             "properties": {
-                "details": {
-                    "type": "string"
+                "code": {
+                    "type": "string",
+                    "example": "post_not_found"
                 },
-                "error": {
-                    "type": "string"
+                "detail": {
+                    "type": "string",
+                    "example": "post not found"
+                },
+                "errors": {
+                    "description": "Ошибки по полям для code=validation_failed",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/apperr.FieldError"
+                    }
+                },
+                "instance": {
+                    "type": "string",
+                    "example": "/api/v1/posts/1221067c-cc35-4dae-b5f5-feee4bbb3e22"
                 },
                 "status": {
-                    "type": "string"
+                    "type": "integer",
+                    "example": 404
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Not Found"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "about:blank"
                 }
             }
         },
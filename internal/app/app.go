@@ -2,14 +2,26 @@ package app
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"net/http"
 	"time"
 
 	httpapp "premium_caste/internal/app/http"
+	"premium_caste/internal/config"
+	"premium_caste/internal/lib/oidc"
 	"premium_caste/internal/repository"
+	apikey "premium_caste/internal/services/apikey_service"
+	archive "premium_caste/internal/services/archive_service"
+	audit "premium_caste/internal/services/audit_service"
 	blog "premium_caste/internal/services/blog_service"
+	category "premium_caste/internal/services/category_service"
+	comment "premium_caste/internal/services/comment_service"
+	feed "premium_caste/internal/services/feed_service"
 	gallery "premium_caste/internal/services/gallery_service"
 	media "premium_caste/internal/services/media_service"
+	oidcapp "premium_caste/internal/services/oidc_service"
+	tag "premium_caste/internal/services/tag_service"
 	tokenapp "premium_caste/internal/services/token_service"
 	user "premium_caste/internal/services/user_service"
 	storage "premium_caste/internal/storage/filestorage"
@@ -21,11 +33,14 @@ import (
 type App struct {
 	HTTPServer httpapp.Server
 	Repo       repository.Repository
+	Audit      *audit.AuditService
+
+	comments *comment.CommentService
+	apiKeys  *apikey.APIKeyService
 }
 
-func New(log *slog.Logger, redisClient *redisapp.Client, storagePath string, httpHost, httpPort string, tokenTTL time.Duration, baseDir, baseURL string) *App {
+func New(log *slog.Logger, redisClient *redisapp.Client, storagePath string, httpCfg config.HTTPConfig, tokenTTL time.Duration, baseDir, baseURL string, maxArchiveBuilds int, site config.SiteConfig, auditCfg config.AuditConfig, oidcCfg config.OIDCConfig, authCfg config.AuthConfig, limits config.RateLimitConfig) *App {
 	ctx := context.Background()
-	token := "test"
 
 	repo, err := repository.NewRepository(ctx, storagePath, redisClient)
 	if err != nil {
@@ -37,17 +52,79 @@ func New(log *slog.Logger, redisClient *redisapp.Client, storagePath string, htt
 		panic("not init file storage")
 	}
 
-	tokenService := tokenapp.NewTokenService(repo.Token)
-	blogService := blog.NewBlogService(log, repo.Blog)
-	userSerivce := user.NewUserService(log, repo.User, tokenService)
-	mediaService := media.NewMediaService(log, repo.Media, fileStorage)
-	galleryService := gallery.NewGalleryService(log, repo.Gallery)
+	tokenService := tokenapp.NewTokenService(repo.Token, authCfg.JWTSecret)
+	auditService := audit.NewAuditService(log, repo.Audit, auditCfg.Retention)
+	blogService := blog.NewBlogService(log, repo.Blog, repo.Tx, auditService)
+	userSerivce := user.NewUserService(log, repo.User, repo.Tx, tokenService, user.NewLogEmailSender(log), auditService, repo.Settings, repo.Challenges, site.Title)
+	oidcService := oidcapp.NewOIDCService(log, identityProviders(oidcCfg), repo.User, repo.Identities, repo.OIDCStates, repo.Tx, userSerivce, auditService)
+	mediaService := media.NewMediaService(log, repo.Media, repo.Tx, fileStorage, auditService)
+	categoryService := category.NewCategoryService(log, repo.Category)
+	commentService := comment.NewCommentService(log, repo.Comment, commentRateLimit(limits))
+	galleryService := gallery.NewGalleryService(log, repo.Gallery, auditService)
+	tagService := tag.NewTagService(log, repo.GalleryTag)
+	apiKeyService := apikey.NewAPIKeyService(log, repo.APIKeys, repo.APIKeyRates, repo.User, auditService, limits.APIKeyDefault)
+	archiveService := archive.NewArchiveService(log, galleryService, fileStorage, maxArchiveBuilds)
+	feedService := feed.NewFeedService(log, blogService, galleryService, repo.FeedCache, feed.SiteInfo{
+		BaseURL:     site.BaseURL,
+		Title:       site.Title,
+		Description: site.Description,
+	}, site.FeedCacheTTL)
 
-	httpRouters := httprouters.NewRouter(log, userSerivce, mediaService, tokenService, blogService, galleryService)
-	httpApp := httpapp.New(log, token, httpHost, httpPort, httpRouters)
+	httpRouters := httprouters.NewRouter(log, userSerivce, mediaService, tokenService, blogService, categoryService, commentService, galleryService, feedService, tagService, archiveService, auditService, oidcService, apiKeyService, httprouters.CookieConfig{
+		Secure:   httpCfg.Cookie.Secure,
+		SameSite: httpCfg.Cookie.SameSiteMode(),
+		Domain:   httpCfg.Cookie.Domain,
+	})
+	httpApp := httpapp.New(log, httpCfg, httpRouters)
 
 	return &App{
 		HTTPServer: *httpApp,
 		Repo:       *repo,
+		Audit:      auditService,
+		comments:   commentService,
+		apiKeys:    apiKeyService,
+	}
+}
+
+// Reload применяет настройки, которые меняются без перезапуска: лимиты запросов,
+// источники CORS и сертификат TLS. Уровень логирования меняет вызывающий,
+// потому что логгер создается до приложения
+func (a *App) Reload(cfg *config.Config) error {
+	const op = "app.Reload"
+
+	a.comments.SetRateLimit(commentRateLimit(cfg.RateLimits))
+	a.apiKeys.SetDefaultRateLimit(cfg.RateLimits.APIKeyDefault)
+	a.HTTPServer.SetCORSOrigins(cfg.HTTP.CORS.AllowOrigins)
+
+	if err := a.HTTPServer.ReloadTLS(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
 	}
+
+	return nil
+}
+
+// commentRateLimit лимит комментариев из раздела rate_limits конфига
+func commentRateLimit(limits config.RateLimitConfig) comment.RateLimit {
+	return comment.RateLimit{Limit: limits.Comments, Window: limits.CommentsWindow}
+}
+
+// providerTimeout ограничивает запросы к провайдерам входа, чтобы зависший провайдер не держал запрос пользователя
+const providerTimeout = 10 * time.Second
+
+// identityProviders создает клиентов провайдеров OpenID Connect из конфига
+func identityProviders(cfg config.OIDCConfig) map[string]oidcapp.IdentityProvider {
+	client := &http.Client{Timeout: providerTimeout}
+
+	providers := make(map[string]oidcapp.IdentityProvider, len(cfg.Providers))
+	for name, p := range cfg.Providers {
+		providers[name] = oidc.NewProvider(oidc.Config{
+			Issuer:       p.Issuer,
+			ClientID:     p.ClientID,
+			ClientSecret: p.ClientSecret,
+			RedirectURL:  p.RedirectURL,
+			Scopes:       p.Scopes,
+		}, client)
+	}
+
+	return providers
 }
@@ -2,24 +2,28 @@ package httpapp
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
 	"os"
 	"path"
+	"slices"
 	"strings"
-	"time"
-
+	"sync/atomic"
+
+	"premium_caste/internal/config"
+	"premium_caste/internal/domain/apperr"
+	"premium_caste/internal/domain/models"
+	"premium_caste/internal/lib/actor"
+	"premium_caste/internal/lib/principal"
+	"premium_caste/internal/lib/validation"
 	"premium_caste/internal/metrics"
 	prommiddleware "premium_caste/internal/middleware"
+	"premium_caste/internal/storage"
 	httprouters "premium_caste/internal/transport/http"
-	"premium_caste/internal/transport/http/dto/response"
 
-	"github.com/go-playground/validator/v10"
-	"github.com/golang-jwt/jwt/v4"
-	"github.com/google/uuid"
-	"github.com/gorilla/sessions"
-	"github.com/labstack/echo-contrib/session"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/prometheus/client_golang/prometheus"
@@ -27,53 +31,64 @@ import (
 	echoSwagger "github.com/swaggo/echo-swagger"
 )
 
-type CustomValidator struct {
-	validator *validator.Validate
-}
+// Ошибки проверки доступа
+var (
+	errAuthRequired  = apperr.Unauthorized("authentication_required", "authentication required")
+	errAdminRequired = apperr.Forbidden("admin_required", "admin access required")
+	errCSRFToken     = apperr.Forbidden("csrf_token_invalid", "missing or invalid CSRF token")
 
-func (cv *CustomValidator) Validate(i interface{}) error {
-	return cv.validator.Struct(i)
-}
+	errAPIKeyNotAllowed = apperr.Forbidden("api_key_not_allowed", "api keys are not accepted for this endpoint")
+	errAPIKeyScope      = apperr.Forbidden("api_key_scope_required", "api key has no scope for this endpoint")
+)
+
+const (
+	// csrfHeader заголовок с токеном CSRF сеанса
+	csrfHeader = httprouters.CSRFHeader
+	// apiKeyHeader заголовок с ключом API интеграций
+	apiKeyHeader = "X-API-Key"
+)
+
+// uploadRoutes группа маршрутов загрузки файлов, для которой действует upload_body_limit
+const uploadRoutes = "/api/v1/media/"
 
 type Server struct {
 	log          *slog.Logger
 	e            *echo.Echo
 	routers      *httprouters.Routers
+	cfg          config.HTTPConfig
+	httpServer   *http.Server
+	certs        *certReloader // nil без TLS
+	corsOrigins  *atomic.Pointer[[]string]
 	metricsReg   *prometheus.Registry
 	metricServer *http.Server
-	host         string
-	port         string
-	token        string
 }
 
-func New(log *slog.Logger, token string, host, port string, routers *httprouters.Routers) *Server {
+func New(log *slog.Logger, cfg config.HTTPConfig, routers *httprouters.Routers) *Server {
 	e := echo.New()
 	e.HideBanner = true
+	e.HTTPErrorHandler = httprouters.ErrorHandler(log)
 
-	validate := validator.New()
-	e.Validator = &CustomValidator{validator: validate}
+	e.Validator = validation.MustNew()
 
+	corsOrigins := &atomic.Pointer[[]string]{}
+	corsOrigins.Store(&cfg.CORS.AllowOrigins)
+
+	// Список источников читается на каждый запрос, чтобы SetCORSOrigins менял его без перезапуска
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins:     []string{"http://localhost:5173", "http://localhost:4173"},
+		AllowOriginFunc: func(origin string) (bool, error) {
+			return slices.Contains(*corsOrigins.Load(), origin), nil
+		},
 		AllowMethods:     []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete, http.MethodPatch},
-		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept},
+		AllowHeaders:     []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, echo.HeaderAuthorization, csrfHeader},
+		ExposeHeaders:    []string{"Link", echo.HeaderContentDisposition},
 		AllowCredentials: true,
-		MaxAge:           86400,
+		MaxAge:           int(cfg.CORS.MaxAge.Seconds()),
 	}))
-	// e.Use(session.Middleware(sessions.NewCookieStore([]byte("test"))))
-
-	store := sessions.NewCookieStore([]byte("test"))
-	store.Options = &sessions.Options{
-		MaxAge:   86400 * 7,
-		HttpOnly: true,
-		Path:     "/",
-		Secure:   true,
-	}
-
-	e.Use(session.Middleware(store))
-
 	e.Use(middleware.Recover())
 
+	e.Use(securityHeadersMiddleware(cfg.SecurityHeaders))
+	e.Use(clientMiddleware)
+
 	e.Use(middleware.RequestLoggerWithConfig(middleware.RequestLoggerConfig{
 		LogURI:      true,
 		LogStatus:   true,
@@ -89,24 +104,43 @@ func New(log *slog.Logger, token string, host, port string, routers *httprouters
 		},
 	}))
 
-	metricsReg := prometheus.NewRegistry()
-	metrics.RegisterMetrics(metricsReg)
+	s := &Server{
+		log:         log,
+		e:           e,
+		routers:     routers,
+		cfg:         cfg,
+		corsOrigins: corsOrigins,
+		httpServer: &http.Server{
+			Addr:              net.JoinHostPort(cfg.Host, cfg.Port),
+			Handler:           e,
+			ReadHeaderTimeout: cfg.ReadHeaderTimeout,
+			ReadTimeout:       cfg.ReadTimeout,
+			WriteTimeout:      cfg.WriteTimeout,
+			IdleTimeout:       cfg.IdleTimeout,
+			ErrorLog:          slog.NewLogLogger(log.Handler(), slog.LevelWarn),
+		},
+	}
+
+	e.Use(s.bodyLimitMiddleware)
 
-	metricsServer := &http.Server{
-		Addr:    ":9090",
-		Handler: promhttp.HandlerFor(metricsReg, promhttp.HandlerOpts{}),
+	if cfg.TLS.Enabled() {
+		s.certs = newCertReloader(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		s.httpServer.TLSConfig = &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: s.certs.GetCertificate,
+		}
 	}
 
-	return &Server{
-		log:          log,
-		e:            e,
-		routers:      routers,
-		metricsReg:   metricsReg,
-		metricServer: metricsServer,
-		host:         host,
-		port:         port,
-		token:        token,
+	s.metricsReg = prometheus.NewRegistry()
+	metrics.RegisterMetrics(s.metricsReg)
+
+	s.metricServer = &http.Server{
+		Addr:              cfg.MetricsAddr,
+		Handler:           promhttp.HandlerFor(s.metricsReg, promhttp.HandlerOpts{}),
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout,
 	}
+
+	return s
 }
 
 func (s *Server) MustRun() {
@@ -122,8 +156,21 @@ func (s *Server) MustRun() {
 func (s *Server) Start() error {
 	const op = "http.Server.Start"
 
+	if s.certs != nil {
+		if err := s.certs.Reload(); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
 	go func() {
-		if err := s.e.Start(fmt.Sprintf(":%s", s.port)); err != nil && err != http.ErrServerClosed {
+		var err error
+		if s.certs != nil {
+			// Сертификат отдает TLSConfig.GetCertificate
+			err = s.httpServer.ListenAndServeTLS("", "")
+		} else {
+			err = s.httpServer.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			s.log.Error("HTTP server stopped", op, slog.Any("error", err))
 		}
 	}()
@@ -137,15 +184,40 @@ func (s *Server) Start() error {
 	return nil
 }
 
+// ReloadTLS перечитывает сертификат из файлов. Новые соединения получают новый
+// сертификат, при ошибке остается прежний. Без TLS ничего не делает
+func (s *Server) ReloadTLS() error {
+	const op = "http.Server.ReloadTLS"
+
+	if s.certs == nil {
+		return nil
+	}
+
+	if err := s.certs.Reload(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.log.Info("TLS certificate reloaded", slog.String("cert_file", s.cfg.TLS.CertFile))
+
+	return nil
+}
+
+// SetCORSOrigins заменяет список источников, которым разрешены запросы из браузера.
+// Пустой список запрещает все кросс-доменные запросы
+func (s *Server) SetCORSOrigins(origins []string) {
+	s.corsOrigins.Store(&origins)
+	s.log.Info("CORS origins updated", slog.Any("origins", origins))
+}
+
 func (s *Server) Stop() error {
 	const op = "http.Server.Stop"
 
-	optCtx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	optCtx, cancel := context.WithTimeout(context.Background(), s.cfg.ShutdownTimeout)
 	defer cancel()
 
 	s.log.Info("stopping", op, "http server")
 
-	if err := s.e.Shutdown(optCtx); err != nil {
+	if err := s.httpServer.Shutdown(optCtx); err != nil {
 		return fmt.Errorf("%s could not shutdown server gracefuly: %w", op, err)
 	}
 
@@ -156,94 +228,204 @@ func (s *Server) Stop() error {
 	return nil
 }
 
-func (s *Server) adminOnlyMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+// bodyLimitMiddleware ограничивает размер тела запроса: body_limit для всех маршрутов,
+// upload_body_limit для загрузки файлов. Тело больше лимита отклоняется с 413
+// по Content-Length или при чтении
+func (s *Server) bodyLimitMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 	return func(c echo.Context) error {
-		sess, err := session.Get("session", c)
-		if err != nil {
-			return c.JSON(http.StatusUnauthorized, response.ErrorResponse{Error: "session required"})
+		limit := s.cfg.BodyLimit
+		if strings.HasPrefix(c.Path(), uploadRoutes) {
+			limit = s.cfg.UploadBodyLimit
 		}
 
-		userID, ok := sess.Values["user_id"].(string)
-		if !ok || userID == "" {
-			return c.JSON(http.StatusUnauthorized, response.ErrorResponse{Error: "authentication required"})
+		req := c.Request()
+		if req.ContentLength > limit {
+			return &http.MaxBytesError{Limit: limit}
 		}
+		req.Body = http.MaxBytesReader(c.Response(), req.Body, limit)
 
-		parsedUUID, err := uuid.Parse(userID)
-		if err != nil {
-			return c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid user ID format"})
-		}
+		return next(c)
+	}
+}
 
-		isAdmin, err := s.routers.UserService.IsAdmin(c.Request().Context(), parsedUUID)
-		if err != nil || !isAdmin {
-			return c.JSON(http.StatusForbidden, response.ErrorResponse{Error: "admin access required"})
+// securityHeadersMiddleware добавляет заголовки безопасности. HSTS отправляется только
+// на HTTPS-запросы, в том числе пришедшие через прокси с X-Forwarded-Proto: https.
+// Swagger UI исключен: его страница не работает с ограничительной CSP
+func securityHeadersMiddleware(cfg config.SecurityHeadersConfig) echo.MiddlewareFunc {
+	return middleware.SecureWithConfig(middleware.SecureConfig{
+		Skipper: func(c echo.Context) bool {
+			return strings.HasPrefix(c.Path(), "/swagger/")
+		},
+		ContentTypeNosniff:    "nosniff",
+		XFrameOptions:         "DENY",
+		HSTSMaxAge:            int(cfg.HSTSMaxAge.Seconds()),
+		ContentSecurityPolicy: cfg.ContentSecurityPolicy,
+	})
+}
+
+// authMiddleware единственная проверка личности пользователя. Access-токен принимается
+// из заголовка Authorization: Bearer или из cookie access_token, владелец токена
+// сохраняется как Principal в echo.Context и в контексте запроса.
+// Cookie браузер подставляет сам, поэтому изменяющие запросы с ней требуют токен CSRF.
+// Клиенты API с заголовком Bearer от этой проверки освобождены.
+// Ключи API здесь не принимаются, для них маршрут закрывается scopeMiddleware
+func (s *Server) authMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return s.authenticate("", next)
+}
+
+// scopeMiddleware работает как authMiddleware и дополнительно принимает ключ API
+// из заголовка X-API-Key, если ключу выдана область scope
+func (s *Server) scopeMiddleware(scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return s.authenticate(scope, next)
+	}
+}
+
+// optionalAuthMiddleware для публичных маршрутов, которые администратору показывают
+// больше, чем гостю. Пользователь определяется так же, как в scopeMiddleware, но без
+// учетных данных или с недействительными запрос проходит анонимно
+func (s *Server) optionalAuthMiddleware(scope string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if p, err := s.requestPrincipal(c, scope); err == nil {
+				setPrincipal(c, p)
+			}
+
+			return next(c)
 		}
+	}
+}
 
-		sess.Options.MaxAge = 86400 * 7 // Обновляем срок
-		if err := sess.Save(c.Request(), c.Response()); err != nil {
-			return c.JSON(http.StatusInternalServerError, "failed to save session")
+func (s *Server) authenticate(scope string, next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		p, err := s.requestPrincipal(c, scope)
+		if err != nil {
+			return err
 		}
 
+		setPrincipal(c, p)
+
 		return next(c)
 	}
 }
 
-func (s *Server) jwtFromCookieMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
-	return func(c echo.Context) error {
-		cookie, err := c.Cookie("access_token")
+// setPrincipal сохраняет пользователя в echo.Context и в контексте запроса
+func setPrincipal(c echo.Context, p principal.Principal) {
+	c.Set(principal.EchoKey, p)
+
+	ctx := principal.WithPrincipal(c.Request().Context(), p)
+	c.SetRequest(c.Request().WithContext(actor.WithUser(ctx, p.UserID)))
+}
+
+// requestPrincipal определяет пользователя по ключу API или access-токену.
+// scope пустой, если маршрут не принимает ключи API
+func (s *Server) requestPrincipal(c echo.Context, scope string) (principal.Principal, error) {
+	req := c.Request()
+
+	if rawKey := req.Header.Get(apiKeyHeader); rawKey != "" {
+		if scope == "" {
+			return principal.Principal{}, errAPIKeyNotAllowed
+		}
+
+		p, err := s.routers.APIKeyService.Authenticate(req.Context(), rawKey)
 		if err != nil {
-			return c.JSON(http.StatusUnauthorized, response.ErrorResponse{
-				Error: "access token required in cookies",
-			})
+			return principal.Principal{}, err
+		}
+		if !p.Allows(scope) {
+			return principal.Principal{}, errAPIKeyScope.WithDetail("scope %s required", scope)
 		}
 
-		token, err := jwt.Parse(cookie.Value, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(s.token), nil
-		})
+		return p, nil
+	}
 
-		if err != nil || !token.Valid {
-			return c.JSON(http.StatusUnauthorized, response.ErrorResponse{
-				Error: "invalid or expired token",
-			})
+	rawToken := bearerToken(req)
+	fromCookie := rawToken == ""
+	if fromCookie {
+		cookie, err := c.Cookie("access_token")
+		if err != nil || cookie.Value == "" {
+			return principal.Principal{}, errAuthRequired
 		}
+		rawToken = cookie.Value
+	}
+
+	p, err := s.routers.AuthService.ParseAccessToken(rawToken)
+	if err != nil {
+		return principal.Principal{}, err
+	}
+
+	if fromCookie && !safeMethod(req.Method) &&
+		!s.routers.AuthService.ValidCSRFToken(p.SessionID, req.Header.Get(csrfHeader)) {
+		return principal.Principal{}, errCSRFToken
+	}
+
+	return p, nil
+}
 
-		if _, ok := token.Claims.(jwt.MapClaims); ok {
-			c.SetCookie(&http.Cookie{
-				Name:     "access_token",
-				Value:    cookie.Value,
-				Expires:  time.Now().Add(1 * time.Hour),
-				Path:     "/",
-				HttpOnly: true,
-				Secure:   false,
-				SameSite: http.SameSiteLaxMode,
-			})
+// adminOnlyMiddleware пропускает только администраторов. Ставится после authMiddleware.
+// Роль из токена сверяется с базой, чтобы понижение и блокировка действовали сразу,
+// а не после истечения access-токена
+func (s *Server) adminOnlyMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		p, ok := c.Get(principal.EchoKey).(principal.Principal)
+		if !ok {
+			return errAuthRequired
+		}
+		if !p.HasRole(models.RoleAdmin) {
+			return errAdminRequired
 		}
 
-		sess, _ := session.Get("session", c)
-		sess.Options.MaxAge = 86400 * 7 // Обновляем срок
-		sess.Save(c.Request(), c.Response())
+		isAdmin, err := s.routers.UserService.IsAdmin(c.Request().Context(), p.UserID)
+		if err != nil || !isAdmin {
+			return errAdminRequired
+		}
 
-		c.Set("user", token.Claims)
+		return next(c)
+	}
+}
+
+// safeMethod запрос только читает данные и не требует токена CSRF
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+
+	return false
+}
+
+// bearerToken возвращает токен из заголовка Authorization со схемой Bearer
+func bearerToken(r *http.Request) string {
+	scheme, token, ok := strings.Cut(r.Header.Get(echo.HeaderAuthorization), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+
+	return strings.TrimSpace(token)
+}
+
+// clientMiddleware сохраняет в контексте запроса адрес и User-Agent клиента для журнала аудита
+func clientMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		req := c.Request()
+		c.SetRequest(req.WithContext(actor.WithClient(req.Context(), c.RealIP(), req.UserAgent())))
 
 		return next(c)
 	}
 }
 
 func (s *Server) BuildRouters() {
-	s.e.GET("/metrics", echo.WrapHandler(promhttp.HandlerFor(s.metricsReg, promhttp.HandlerOpts{})))
+	// Метрики отдает только metricServer на metrics_addr, публичный роутер их не обслуживает
 	s.e.GET("/swagger/*", echoSwagger.WrapHandler)
 
 	s.e.GET("/uploads/*", func(c echo.Context) error {
 		filePath := path.Join("uploads", c.Param("*"))
 
 		if _, err := os.Stat(filePath); os.IsNotExist(err) {
-			return echo.NewHTTPError(http.StatusNotFound, "File not found")
+			return storage.ErrFileNotFound
 		}
 
 		if strings.Contains(filePath, "../") {
-			return echo.NewHTTPError(http.StatusForbidden, "Invalid path")
+			return apperr.Forbidden("invalid_path", "invalid path")
 		}
 
 		return c.File(filePath)
@@ -257,62 +439,157 @@ func (s *Server) BuildRouters() {
 	// 	})(c)
 	// }, s.jwtFromCookieMiddleware)
 
+	s.e.GET("/feed.xml", s.routers.GetRSSFeed)
+	s.e.GET("/atom.xml", s.routers.GetAtomFeed)
+	s.e.GET("/sitemap.xml", s.routers.GetSitemapIndex)
+	s.e.GET("/sitemaps/:name", s.routers.GetSitemap)
+
 	api := s.e.Group("/api/v1")
 	api.Use(prommiddleware.PrometheusMetrics)
 	{
 		api.POST("/register", s.routers.Register)
 		api.POST("/login", s.routers.Login)
+		api.POST("/login/2fa", s.routers.LoginTwoFactor)
+		api.POST("/login/2fa/enroll", s.routers.LoginTwoFactorEnroll)
+		api.GET("/auth/providers", s.routers.ListIdentityProviders)
+		api.POST("/auth/oidc/:provider", s.routers.StartOIDCLogin)
+		api.POST("/auth/oidc/:provider/callback", s.routers.FinishOIDCLogin)
 		api.POST("/refresh", s.routers.Refresh)
+		api.GET("/csrf-token", s.routers.GetCSRFToken, s.authMiddleware)
+
+		// Ключи API принимаются только маршрутами со scopeMiddleware нужной области
+		postsRead := s.scopeMiddleware(models.ScopePostsRead)
+		postsWrite := s.scopeMiddleware(models.ScopePostsWrite)
+		mediaRead := s.scopeMiddleware(models.ScopeMediaRead)
+		mediaWrite := s.scopeMiddleware(models.ScopeMediaWrite)
+		galleriesRead := s.scopeMiddleware(models.ScopeGalleriesRead)
+		galleriesWrite := s.scopeMiddleware(models.ScopeGalleriesWrite)
+
+		// Публичные маршруты показывают черновики только администратору
+		postsViewer := s.optionalAuthMiddleware(models.ScopePostsRead)
+		galleriesViewer := s.optionalAuthMiddleware(models.ScopeGalleriesRead)
 
 		userGroup := api.Group("/users")
-		userGroup.Use(s.jwtFromCookieMiddleware)
+		userGroup.Use(s.authMiddleware)
 		{
 			userGroup.GET("/:user_id/is-admin", s.routers.IsAdminPermission)
 			userGroup.POST("/user_id", s.routers.GetUserById)
 		}
 
-		mediaGroup := api.Group("/media", s.adminOnlyMiddleware)
-		mediaGroup.Use(s.jwtFromCookieMiddleware)
+		meGroup := api.Group("/me", s.authMiddleware)
 		{
-			mediaGroup.POST("/upload", s.routers.UploadMedia)
-			mediaGroup.POST("/uploads", s.routers.UploadMultipleMedia)
-			mediaGroup.POST("/groups/attach", s.routers.AttachMediaToGroup)
-			mediaGroup.POST("/groups", s.routers.CreateMediaGroup)
-			mediaGroup.GET("/groups/group_id", s.routers.ListGroupMedia)
-			mediaGroup.GET("/images", s.routers.GetAllImages)
-			mediaGroup.GET("/image", s.routers.GetImages)
+			meGroup.GET("", s.routers.GetMe)
+			meGroup.PATCH("", s.routers.UpdateMe)
+			meGroup.POST("/email/confirm", s.routers.ConfirmMyEmail)
+			meGroup.POST("/password", s.routers.ChangeMyPassword)
+			meGroup.GET("/2fa", s.routers.GetMyTwoFactor)
+			meGroup.POST("/2fa/enroll", s.routers.EnrollMyTwoFactor)
+			meGroup.POST("/2fa/confirm", s.routers.ConfirmMyTwoFactor)
+			meGroup.DELETE("/2fa", s.routers.DisableMyTwoFactor)
+			meGroup.POST("/2fa/recovery-codes", s.routers.RegenerateMyRecoveryCodes)
+			meGroup.GET("/identities", s.routers.ListMyIdentities)
+			meGroup.POST("/identities/:provider", s.routers.StartLinkIdentity)
+			meGroup.POST("/identities/:provider/callback", s.routers.FinishLinkIdentity)
+			meGroup.DELETE("/identities/:provider", s.routers.UnlinkMyIdentity)
+			meGroup.GET("/api-keys", s.routers.ListMyAPIKeys)
+			meGroup.POST("/api-keys", s.routers.CreateMyAPIKey)
+			meGroup.DELETE("/api-keys/:id", s.routers.RevokeMyAPIKey)
+		}
+
+		mediaGroup := api.Group("/media")
+		{
+			mediaGroup.POST("/upload", s.routers.UploadMedia, mediaWrite, s.adminOnlyMiddleware)
+			mediaGroup.POST("/uploads", s.routers.UploadMultipleMedia, mediaWrite, s.adminOnlyMiddleware)
+			mediaGroup.POST("/groups/attach", s.routers.AttachMediaToGroup, mediaWrite, s.adminOnlyMiddleware)
+			mediaGroup.POST("/groups", s.routers.CreateMediaGroup, mediaWrite, s.adminOnlyMiddleware)
+			mediaGroup.POST("/groups/upload", s.routers.UploadMediaGroup, mediaWrite, s.adminOnlyMiddleware)
+			mediaGroup.GET("/groups/group_id", s.routers.ListGroupMedia, mediaRead, s.adminOnlyMiddleware)
+			mediaGroup.GET("/images", s.routers.GetAllImages, mediaRead, s.adminOnlyMiddleware)
+			mediaGroup.GET("/image", s.routers.GetImages, mediaRead, s.adminOnlyMiddleware)
 		}
 
 		blogGroup := api.Group("/posts")
-		blogGroup.GET("", s.routers.ListPosts)
-		blogGroup.GET("/:id", s.routers.GetPost)
+		blogGroup.GET("", s.routers.ListPosts, postsViewer)
+		blogGroup.GET("/tags", s.routers.GetPostTags, postsViewer)
+		blogGroup.GET("/by-slug/:slug", s.routers.GetPostBySlug, postsViewer)
+		blogGroup.GET("/:id", s.routers.GetPost, postsViewer)
 		blogGroup.GET("/:id/media-groups", s.routers.GetPostMediaGroups)
-		blogGroup.Use(s.jwtFromCookieMiddleware)
+		blogGroup.GET("/:id/comments", s.routers.ListPostComments)
 		{
-			blogGroup.POST("", s.routers.CreatePost, s.adminOnlyMiddleware)
-			blogGroup.PUT("/:id", s.routers.UpdatePost, s.adminOnlyMiddleware)
-			blogGroup.DELETE("/:id", s.routers.DeletePost, s.adminOnlyMiddleware)
-			blogGroup.PATCH("/:id/publish", s.routers.PublishPost, s.adminOnlyMiddleware)
-			blogGroup.PATCH("/:id/archive", s.routers.ArchivePost, s.adminOnlyMiddleware)
-			blogGroup.POST("/:id/media-groups", s.routers.AddMediaGroup, s.adminOnlyMiddleware)
+			blogGroup.POST("", s.routers.CreatePost, postsWrite, s.adminOnlyMiddleware)
+			blogGroup.POST("/slug-availability", s.routers.CheckPostSlugAvailability, postsRead, s.adminOnlyMiddleware)
+			blogGroup.PUT("/:id", s.routers.UpdatePost, postsWrite, s.adminOnlyMiddleware)
+			blogGroup.DELETE("/:id", s.routers.DeletePost, postsWrite, s.adminOnlyMiddleware)
+			blogGroup.PATCH("/:id/publish", s.routers.PublishPost, postsWrite, s.adminOnlyMiddleware)
+			blogGroup.PATCH("/:id/archive", s.routers.ArchivePost, postsWrite, s.adminOnlyMiddleware)
+			blogGroup.POST("/:id/media-groups", s.routers.AddMediaGroup, postsWrite, s.adminOnlyMiddleware)
+			blogGroup.POST("/:id/comments", s.routers.CreateComment, s.authMiddleware)
 		}
 
+		categoryGroup := api.Group("/categories")
+		categoryGroup.GET("", s.routers.ListCategories)
+		categoryGroup.GET("/:id", s.routers.GetCategory)
+		categoryGroup.Use(s.authMiddleware)
+		{
+			categoryGroup.POST("", s.routers.CreateCategory, s.adminOnlyMiddleware)
+			categoryGroup.PUT("/:id", s.routers.UpdateCategory, s.adminOnlyMiddleware)
+			categoryGroup.DELETE("/:id", s.routers.DeleteCategory, s.adminOnlyMiddleware)
+		}
+
+		commentGroup := api.Group("/comments", s.authMiddleware, s.adminOnlyMiddleware)
+		{
+			commentGroup.GET("", s.routers.ListComments)
+			commentGroup.PATCH("/:id/status", s.routers.ModerateComment)
+			commentGroup.DELETE("/:id", s.routers.DeleteComment)
+		}
+
+		adminUserGroup := api.Group("/admin/users", s.authMiddleware, s.adminOnlyMiddleware)
+		{
+			adminUserGroup.GET("", s.routers.ListUsers)
+			adminUserGroup.GET("/:id", s.routers.GetUser)
+			adminUserGroup.PUT("/:id/role", s.routers.ChangeUserRole)
+			adminUserGroup.POST("/:id/suspend", s.routers.SuspendUser)
+			adminUserGroup.DELETE("/:id/suspend", s.routers.UnsuspendUser)
+			adminUserGroup.POST("/:id/anonymize", s.routers.AnonymizeUser)
+			adminUserGroup.DELETE("/:id/2fa", s.routers.ResetUserTwoFactor)
+			adminUserGroup.DELETE("/:id", s.routers.DeleteUser)
+		}
+
+		api.GET("/admin/audit", s.routers.ListAuditEvents, s.authMiddleware, s.adminOnlyMiddleware)
+		api.GET("/admin/security", s.routers.GetSecurityPolicy, s.authMiddleware, s.adminOnlyMiddleware)
+		api.PUT("/admin/security", s.routers.UpdateSecurityPolicy, s.authMiddleware, s.adminOnlyMiddleware)
+		api.GET("/admin/api-keys", s.routers.ListAPIKeys, s.authMiddleware, s.adminOnlyMiddleware)
+		api.DELETE("/admin/api-keys/:id", s.routers.RevokeAPIKey, s.authMiddleware, s.adminOnlyMiddleware)
+
 		galleryGroup := api.Group("/gallery")
-		galleryGroup.GET("/galleries", s.routers.GetGalleriesHandler)
-		galleryGroup.GET("/galleries/:id", s.routers.GetGalleryByIDHandler)
-		galleryGroup.GET("/galleries/by-tags", s.routers.GetGalleriesByTagsHandler)
-		galleryGroup.Use(s.jwtFromCookieMiddleware)
+		galleryGroup.GET("/galleries", s.routers.GetGalleriesHandler, galleriesViewer)
+		galleryGroup.GET("/galleries/:id", s.routers.GetGalleryByIDHandler, galleriesViewer)
+		galleryGroup.GET("/galleries/by-tags", s.routers.GetGalleriesByTagsHandler, galleriesViewer)
+		galleryGroup.GET("/galleries/by-slug/:slug", s.routers.GetGalleryBySlugHandler, galleriesViewer)
+		galleryGroup.GET("/tags", s.routers.ListGalleryTagsHandler)
 		{
-			galleryGroup.POST("/galleries", s.routers.CreateGalleryHandler, s.adminOnlyMiddleware)
-			galleryGroup.PUT("/galleries", s.routers.UpdateGalleryHandler, s.adminOnlyMiddleware)
-			galleryGroup.PATCH("/galleries/:id/status", s.routers.UpdateGalleryStatusHandler, s.adminOnlyMiddleware)
-			galleryGroup.DELETE("/galleries/:id", s.routers.DeleteGalleryHandler, s.adminOnlyMiddleware)
-
-			galleryGroup.POST("/galleries/:gallery_id/tags", s.routers.AddTagsHandler, s.adminOnlyMiddleware)
-			galleryGroup.DELETE("/galleries/:gallery_id/tags", s.routers.RemoveTagsHandler, s.adminOnlyMiddleware)
-			galleryGroup.PUT("/galleries/:gallery_id/tags", s.routers.ReplaceTagsHandler, s.adminOnlyMiddleware)
-			galleryGroup.GET("/galleries/:gallery_id/tags", s.routers.GetTagsHandler, s.adminOnlyMiddleware)
-			galleryGroup.GET("/galleries/:gallery_id/has-tags", s.routers.HasTagsHandler, s.adminOnlyMiddleware)
+			galleryGroup.GET("/galleries/:id/archive", s.routers.DownloadGalleryArchiveHandler, galleriesRead)
+			galleryGroup.POST("/galleries", s.routers.CreateGalleryHandler, galleriesWrite, s.adminOnlyMiddleware)
+			galleryGroup.POST("/galleries/slug-availability", s.routers.CheckGallerySlugAvailabilityHandler, galleriesRead, s.adminOnlyMiddleware)
+			galleryGroup.PUT("/galleries", s.routers.UpdateGalleryHandler, galleriesWrite, s.adminOnlyMiddleware)
+			galleryGroup.PATCH("/galleries/:id/status", s.routers.UpdateGalleryStatusHandler, galleriesWrite, s.adminOnlyMiddleware)
+			galleryGroup.DELETE("/galleries/:id", s.routers.DeleteGalleryHandler, galleriesWrite, s.adminOnlyMiddleware)
+			galleryGroup.POST("/galleries/:id/items", s.routers.AddGalleryItemsHandler, galleriesWrite, s.adminOnlyMiddleware)
+			galleryGroup.PUT("/galleries/:id/items/order", s.routers.ReorderGalleryItemsHandler, galleriesWrite, s.adminOnlyMiddleware)
+			galleryGroup.PATCH("/galleries/:id/items/:media_id", s.routers.UpdateGalleryItemHandler, galleriesWrite, s.adminOnlyMiddleware)
+			galleryGroup.DELETE("/galleries/:id/items/:media_id", s.routers.RemoveGalleryItemHandler, galleriesWrite, s.adminOnlyMiddleware)
+			galleryGroup.PUT("/galleries/:id/cover", s.routers.SetGalleryCoverHandler, galleriesWrite, s.adminOnlyMiddleware)
+
+			galleryGroup.POST("/galleries/:gallery_id/tags", s.routers.AddTagsHandler, galleriesWrite, s.adminOnlyMiddleware)
+			galleryGroup.DELETE("/galleries/:gallery_id/tags", s.routers.RemoveTagsHandler, galleriesWrite, s.adminOnlyMiddleware)
+			galleryGroup.PUT("/galleries/:gallery_id/tags", s.routers.ReplaceTagsHandler, galleriesWrite, s.adminOnlyMiddleware)
+			galleryGroup.GET("/galleries/:gallery_id/tags", s.routers.GetTagsHandler, galleriesRead, s.adminOnlyMiddleware)
+			galleryGroup.GET("/galleries/:gallery_id/has-tags", s.routers.HasTagsHandler, galleriesRead, s.adminOnlyMiddleware)
+
+			galleryGroup.POST("/tags/rename", s.routers.RenameGalleryTagHandler, galleriesWrite, s.adminOnlyMiddleware)
+			galleryGroup.POST("/tags/merge", s.routers.MergeGalleryTagsHandler, galleriesWrite, s.adminOnlyMiddleware)
+			galleryGroup.GET("/tags/aliases", s.routers.ListGalleryTagAliasesHandler, galleriesRead, s.adminOnlyMiddleware)
+			galleryGroup.DELETE("/tags/aliases/:alias", s.routers.DeleteGalleryTagAliasHandler, galleriesWrite, s.adminOnlyMiddleware)
 		}
 	}
 }
@@ -0,0 +1,809 @@
+package httpapp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"premium_caste/internal/config"
+	"premium_caste/internal/domain/models"
+	"premium_caste/internal/lib/actor"
+	"premium_caste/internal/lib/principal"
+	"premium_caste/internal/repository"
+	apikeyapp "premium_caste/internal/services/apikey_service"
+	auditapp "premium_caste/internal/services/audit_service"
+	tokenapp "premium_caste/internal/services/token_service"
+	httprouters "premium_caste/internal/transport/http"
+	"premium_caste/internal/transport/http/dto"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testConfig настройки сервера для тестов middleware
+func testConfig() config.HTTPConfig {
+	return config.HTTPConfig{
+		BodyLimit:       16,
+		UploadBodyLimit: 64,
+		SecurityHeaders: config.SecurityHeadersConfig{
+			HSTSMaxAge:            time.Hour,
+			ContentSecurityPolicy: "default-src 'none'",
+		},
+	}
+}
+
+// adminChecker отвечает на IsAdmin по списку администраторов в базе. Остальные методы не нужны
+type adminChecker struct {
+	httprouters.UserService
+	admins map[uuid.UUID]bool
+}
+
+func (a adminChecker) IsAdmin(_ context.Context, userID uuid.UUID) (bool, error) {
+	return a.admins[userID], nil
+}
+
+// issuedKeys принимает заранее выданные ключи API
+type issuedKeys struct {
+	httprouters.APIKeyService
+	keys map[string]principal.Principal
+}
+
+func (k issuedKeys) Authenticate(_ context.Context, rawKey string) (principal.Principal, error) {
+	p, ok := k.keys[rawKey]
+	if !ok {
+		return principal.Principal{}, apikeyapp.ErrInvalidAPIKey
+	}
+	return p, nil
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	admin := models.User{ID: uuid.New(), Email: "admin@example.com", Role: models.RoleAdmin, IsAdmin: true}
+	demoted := models.User{ID: uuid.New(), Email: "demoted@example.com", Role: models.RoleAdmin, IsAdmin: true}
+	member := models.User{ID: uuid.New(), Email: "user@example.com", Role: models.RoleUser}
+
+	tokens := tokenapp.NewTokenService(nil, "0123456789abcdef0123456789abcdef")
+	csrf := tokens.CSRFToken("session-1")
+
+	token := func(user models.User, tokenType string) string {
+		raw, err := tokens.NewToken(user, "session-1", tokenType, time.Minute)
+		require.NoError(t, err)
+		return raw
+	}
+
+	keys := issuedKeys{keys: map[string]principal.Principal{
+		"pck_admin_media": {UserID: admin.ID, Roles: admin.Roles(), APIKeyID: uuid.New(), Scopes: []string{models.ScopeMediaWrite}},
+		"pck_admin_posts": {UserID: admin.ID, Roles: admin.Roles(), APIKeyID: uuid.New(), Scopes: []string{models.ScopePostsRead}},
+		"pck_user_media":  {UserID: member.ID, Roles: member.Roles(), APIKeyID: uuid.New(), Scopes: []string{models.ScopeMediaWrite}},
+	}}
+
+	routers := &httprouters.Routers{
+		AuthService:   tokens,
+		UserService:   adminChecker{admins: map[uuid.UUID]bool{admin.ID: true}},
+		APIKeyService: keys,
+	}
+	server := New(log, testConfig(), routers)
+
+	var got principal.Principal
+	handler := func(c echo.Context) error {
+		got = c.Get(principal.EchoKey).(principal.Principal)
+
+		fromCtx, ok := principal.FromContext(c.Request().Context())
+		require.True(t, ok)
+		assert.Equal(t, got, fromCtx)
+		assert.Equal(t, got.UserID, actor.FromContext(c.Request().Context()).UserID)
+
+		return c.NoContent(http.StatusNoContent)
+	}
+	server.e.GET("/me", handler, server.authMiddleware)
+	server.e.POST("/me", handler, server.authMiddleware)
+	server.e.GET("/admin", handler, server.authMiddleware, server.adminOnlyMiddleware)
+	server.e.POST("/media", handler, server.scopeMiddleware(models.ScopeMediaWrite), server.adminOnlyMiddleware)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		bearer     string
+		cookie     string
+		csrf       string
+		apiKey     string
+		wantStatus int
+		wantUser   uuid.UUID
+	}{
+		{name: "bearer token", path: "/me", bearer: token(member, tokenapp.TokenTypeAccess), wantStatus: http.StatusNoContent, wantUser: member.ID},
+		{name: "cookie token", path: "/me", cookie: token(member, tokenapp.TokenTypeAccess), wantStatus: http.StatusNoContent, wantUser: member.ID},
+		{name: "bearer wins over cookie", path: "/me", bearer: token(member, tokenapp.TokenTypeAccess), cookie: token(admin, tokenapp.TokenTypeAccess), wantStatus: http.StatusNoContent, wantUser: member.ID},
+		{name: "no token", path: "/me", wantStatus: http.StatusUnauthorized},
+		{name: "refresh token instead of access", path: "/me", bearer: token(member, tokenapp.TokenTypeRefresh), wantStatus: http.StatusUnauthorized},
+		{name: "garbage token", path: "/me", cookie: "not-a-token", wantStatus: http.StatusUnauthorized},
+		{name: "admin", path: "/admin", bearer: token(admin, tokenapp.TokenTypeAccess), wantStatus: http.StatusNoContent, wantUser: admin.ID},
+		{name: "not admin", path: "/admin", bearer: token(member, tokenapp.TokenTypeAccess), wantStatus: http.StatusForbidden},
+		{name: "admin role revoked in database", path: "/admin", bearer: token(demoted, tokenapp.TokenTypeAccess), wantStatus: http.StatusForbidden},
+		{name: "cookie mutation without csrf token", method: http.MethodPost, path: "/me", cookie: token(member, tokenapp.TokenTypeAccess), wantStatus: http.StatusForbidden},
+		{name: "cookie mutation with csrf token", method: http.MethodPost, path: "/me", cookie: token(member, tokenapp.TokenTypeAccess), csrf: csrf, wantStatus: http.StatusNoContent, wantUser: member.ID},
+		{name: "csrf token of another session", method: http.MethodPost, path: "/me", cookie: token(member, tokenapp.TokenTypeAccess), csrf: tokens.CSRFToken("session-2"), wantStatus: http.StatusForbidden},
+		{name: "bearer mutation needs no csrf token", method: http.MethodPost, path: "/me", bearer: token(member, tokenapp.TokenTypeAccess), wantStatus: http.StatusNoContent, wantUser: member.ID},
+		{name: "api key with scope", method: http.MethodPost, path: "/media", apiKey: "pck_admin_media", wantStatus: http.StatusNoContent, wantUser: admin.ID},
+		{name: "api key without scope", method: http.MethodPost, path: "/media", apiKey: "pck_admin_posts", wantStatus: http.StatusForbidden},
+		{name: "api key of non-admin on admin route", method: http.MethodPost, path: "/media", apiKey: "pck_user_media", wantStatus: http.StatusForbidden},
+		{name: "unknown api key", method: http.MethodPost, path: "/media", apiKey: "pck_unknown", wantStatus: http.StatusUnauthorized},
+		{name: "api key on route without scopes", path: "/me", apiKey: "pck_admin_media", wantStatus: http.StatusForbidden},
+		{name: "token on route with scopes", method: http.MethodPost, path: "/media", bearer: token(admin, tokenapp.TokenTypeAccess), wantStatus: http.StatusNoContent, wantUser: admin.ID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got = principal.Principal{}
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+
+			req := httptest.NewRequest(method, tt.path, nil)
+			if tt.apiKey != "" {
+				req.Header.Set(apiKeyHeader, tt.apiKey)
+			}
+			if tt.csrf != "" {
+				req.Header.Set(csrfHeader, tt.csrf)
+			}
+			if tt.bearer != "" {
+				req.Header.Set(echo.HeaderAuthorization, "Bearer "+tt.bearer)
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: "access_token", Value: tt.cookie})
+			}
+			rec := httptest.NewRecorder()
+
+			server.e.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantStatus, rec.Code, rec.Body.String())
+			if tt.wantUser != uuid.Nil {
+				assert.Equal(t, tt.wantUser, got.UserID)
+				assert.Equal(t, tt.apiKey != "", got.ViaAPIKey())
+				if tt.apiKey == "" {
+					assert.Equal(t, "session-1", got.SessionID)
+				}
+			}
+		})
+	}
+}
+
+func TestBodyLimitMiddleware(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	server := New(log, testConfig(), &httprouters.Routers{})
+
+	handler := func(c echo.Context) error {
+		if _, err := io.ReadAll(c.Request().Body); err != nil {
+			return err
+		}
+		return c.NoContent(http.StatusNoContent)
+	}
+	server.e.POST("/api/v1/posts", handler)
+	server.e.POST("/api/v1/media/upload", handler)
+
+	tests := []struct {
+		name       string
+		path       string
+		size       int
+		chunked    bool
+		wantStatus int
+	}{
+		{name: "within limit", path: "/api/v1/posts", size: 16, wantStatus: http.StatusNoContent},
+		{name: "over limit", path: "/api/v1/posts", size: 17, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "over limit without content length", path: "/api/v1/posts", size: 17, chunked: true, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "upload within upload limit", path: "/api/v1/media/upload", size: 64, wantStatus: http.StatusNoContent},
+		{name: "upload over upload limit", path: "/api/v1/media/upload", size: 65, wantStatus: http.StatusRequestEntityTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(strings.Repeat("x", tt.size)))
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			rec := httptest.NewRecorder()
+
+			server.e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code, rec.Body.String())
+		})
+	}
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	server := New(log, testConfig(), &httprouters.Routers{})
+	server.e.GET("/api/v1/posts", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	t.Run("plain http", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		server.e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/posts", nil))
+
+		assert.Equal(t, "nosniff", rec.Header().Get(echo.HeaderXContentTypeOptions))
+		assert.Equal(t, "DENY", rec.Header().Get(echo.HeaderXFrameOptions))
+		assert.Equal(t, "default-src 'none'", rec.Header().Get(echo.HeaderContentSecurityPolicy))
+		assert.Empty(t, rec.Header().Get(echo.HeaderStrictTransportSecurity))
+	})
+
+	t.Run("https behind proxy", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/posts", nil)
+		req.Header.Set(echo.HeaderXForwardedProto, "https")
+		rec := httptest.NewRecorder()
+		server.e.ServeHTTP(rec, req)
+
+		assert.Equal(t, "max-age=3600; includeSubdomains", rec.Header().Get(echo.HeaderStrictTransportSecurity))
+	})
+}
+
+func TestMetricsOnlyOnMetricsServer(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	server := New(log, testConfig(), &httprouters.Routers{})
+	server.BuildRouters()
+
+	rec := httptest.NewRecorder()
+	server.e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	server.metricServer.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestSetCORSOrigins(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	server := New(log, testConfig(), &httprouters.Routers{})
+	server.e.GET("/api/v1/posts", func(c echo.Context) error {
+		return c.NoContent(http.StatusNoContent)
+	})
+
+	allowedOrigin := func(origin string) string {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/posts", nil)
+		req.Header.Set(echo.HeaderOrigin, origin)
+		rec := httptest.NewRecorder()
+		server.e.ServeHTTP(rec, req)
+
+		return rec.Header().Get(echo.HeaderAccessControlAllowOrigin)
+	}
+
+	assert.Empty(t, allowedOrigin("https://admin.example.com"), "no origins are allowed by default")
+
+	server.SetCORSOrigins([]string{"https://admin.example.com"})
+	assert.Equal(t, "https://admin.example.com", allowedOrigin("https://admin.example.com"))
+	assert.Empty(t, allowedOrigin("https://evil.example.com"))
+
+	server.SetCORSOrigins(nil)
+	assert.Empty(t, allowedOrigin("https://admin.example.com"))
+}
+
+// recordingGalleries запоминает фильтр, с которым обработчик запросил список галерей
+type recordingGalleries struct {
+	httprouters.GalleryService
+	filter dto.GalleryFilter
+}
+
+func (g *recordingGalleries) ListGalleries(_ context.Context, filter dto.GalleryFilter, page, perPage int) (*dto.GalleryListResponse, error) {
+	g.filter = filter
+	return &dto.GalleryListResponse{Galleries: []dto.GalleryResponse{}}, nil
+}
+
+// savedGalleries считает вызовы сервиса, изменяющие галереи
+type savedGalleries struct {
+	httprouters.GalleryService
+	calls int
+}
+
+func (g *savedGalleries) CreateGallery(context.Context, dto.CreateGalleryRequest) (uuid.UUID, error) {
+	g.calls++
+	return uuid.New(), nil
+}
+
+func (g *savedGalleries) UpdateGallery(context.Context, dto.UpdateGalleryRequest) error {
+	g.calls++
+	return nil
+}
+
+func (g *savedGalleries) UpdateGalleryStatus(context.Context, uuid.UUID, string) error {
+	g.calls++
+	return nil
+}
+
+// noopFeeds сбрасывает кэш лент без действий
+type noopFeeds struct {
+	httprouters.FeedService
+}
+
+func (noopFeeds) Invalidate(context.Context) error { return nil }
+
+func TestGalleryWriteValidation(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	admin := models.User{ID: uuid.New(), Email: "admin@example.com", Role: models.RoleAdmin, IsAdmin: true}
+	tokens := tokenapp.NewTokenService(nil, "0123456789abcdef0123456789abcdef")
+	adminToken, err := tokens.NewToken(admin, "session-1", tokenapp.TokenTypeAccess, time.Minute)
+	require.NoError(t, err)
+
+	galleries := &savedGalleries{}
+	users := adminChecker{admins: map[uuid.UUID]bool{admin.ID: true}}
+	routers := httprouters.NewRouter(log, users, nil, tokens, nil, nil, nil, galleries, noopFeeds{}, nil, nil, nil, nil, nil, httprouters.CookieConfig{})
+	cfg := testConfig()
+	cfg.BodyLimit = 4096
+	server := New(log, cfg, routers)
+	server.BuildRouters()
+
+	galleryID := uuid.New().String()
+	longTitle := strings.Repeat("x", 256)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{name: "create", method: http.MethodPost, path: "/api/v1/gallery/galleries", body: `{"title":"Summer","status":"draft"}`, wantStatus: http.StatusCreated},
+		{name: "create with unknown status", method: http.MethodPost, path: "/api/v1/gallery/galleries", body: `{"title":"Summer","status":"secret"}`, wantStatus: http.StatusBadRequest},
+		{name: "update", method: http.MethodPut, path: "/api/v1/gallery/galleries", body: `{"id":"` + galleryID + `","title":"Summer","status":"published"}`, wantStatus: http.StatusOK},
+		{name: "update with unknown status", method: http.MethodPut, path: "/api/v1/gallery/galleries", body: `{"id":"` + galleryID + `","title":"Summer","status":"secret"}`, wantStatus: http.StatusBadRequest},
+		{name: "update with long title", method: http.MethodPut, path: "/api/v1/gallery/galleries", body: `{"id":"` + galleryID + `","title":"` + longTitle + `"}`, wantStatus: http.StatusBadRequest},
+		{name: "update without id", method: http.MethodPut, path: "/api/v1/gallery/galleries", body: `{"title":"Summer"}`, wantStatus: http.StatusBadRequest},
+		{name: "status with unknown value", method: http.MethodPatch, path: "/api/v1/gallery/galleries/" + galleryID + "/status", body: `{"status":"secret"}`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			galleries.calls = 0
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			req.Header.Set(echo.HeaderAuthorization, "Bearer "+adminToken)
+			rec := httptest.NewRecorder()
+
+			server.e.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantStatus, rec.Code, rec.Body.String())
+			if tt.wantStatus == http.StatusBadRequest {
+				assert.Zero(t, galleries.calls, "invalid request must not reach the service")
+			} else {
+				assert.Equal(t, 1, galleries.calls)
+			}
+		})
+	}
+}
+
+func TestPublicGalleryListStatus(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	admin := models.User{ID: uuid.New(), Email: "admin@example.com", Role: models.RoleAdmin, IsAdmin: true}
+	member := models.User{ID: uuid.New(), Email: "user@example.com", Role: models.RoleUser}
+
+	tokens := tokenapp.NewTokenService(nil, "0123456789abcdef0123456789abcdef")
+	token := func(user models.User) string {
+		raw, err := tokens.NewToken(user, "session-1", tokenapp.TokenTypeAccess, time.Minute)
+		require.NoError(t, err)
+		return raw
+	}
+
+	galleries := &recordingGalleries{}
+	server := New(log, testConfig(), &httprouters.Routers{
+		AuthService:    tokens,
+		UserService:    adminChecker{admins: map[uuid.UUID]bool{admin.ID: true}},
+		GalleryService: galleries,
+	})
+	server.BuildRouters()
+
+	tests := []struct {
+		name       string
+		path       string
+		bearer     string
+		wantStatus string
+	}{
+		{name: "anonymous draft", path: "/api/v1/gallery/galleries?status=draft", wantStatus: "published"},
+		{name: "anonymous all by tags", path: "/api/v1/gallery/galleries/by-tags?tags=nature&status=all", wantStatus: "published"},
+		{name: "user draft", path: "/api/v1/gallery/galleries?status=draft", bearer: token(member), wantStatus: "published"},
+		{name: "invalid token is anonymous", path: "/api/v1/gallery/galleries/by-tags?tags=nature&status=draft", bearer: "not-a-token", wantStatus: "published"},
+		{name: "admin draft", path: "/api/v1/gallery/galleries?status=draft", bearer: token(admin), wantStatus: "draft"},
+		{name: "admin all by tags", path: "/api/v1/gallery/galleries/by-tags?tags=nature&status=all", bearer: token(admin), wantStatus: "all"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			galleries.filter = dto.GalleryFilter{}
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.bearer != "" {
+				req.Header.Set(echo.HeaderAuthorization, "Bearer "+tt.bearer)
+			}
+			rec := httptest.NewRecorder()
+
+			server.e.ServeHTTP(rec, req)
+
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			assert.Equal(t, tt.wantStatus, galleries.filter.Status)
+		})
+	}
+}
+
+// recordingPosts запоминает статус, с которым обработчики запросили посты и теги
+type recordingPosts struct {
+	httprouters.BlogService
+	status string
+}
+
+func (p *recordingPosts) ListPosts(_ context.Context, filter dto.BlogPostFilter, page, perPage int) (*dto.BlogPostListResponse, error) {
+	p.status = filter.Status
+	return &dto.BlogPostListResponse{Posts: []dto.BlogPostResponse{}}, nil
+}
+
+func (p *recordingPosts) GetTagCounts(_ context.Context, statusFilter string, limit int) ([]dto.TagCountResponse, error) {
+	p.status = statusFilter
+	return []dto.TagCountResponse{}, nil
+}
+
+// postWithStatus возвращает по ID пост со статусом status
+type postWithStatus struct {
+	httprouters.BlogService
+	status string
+}
+
+func (p postWithStatus) GetPostByID(_ context.Context, id uuid.UUID) (*dto.BlogPostResponse, error) {
+	return &dto.BlogPostResponse{ID: id, Status: p.status}, nil
+}
+
+// galleryWithStatus возвращает по ID галерею со статусом status
+type galleryWithStatus struct {
+	httprouters.GalleryService
+	status string
+}
+
+func (g galleryWithStatus) GetGalleryByID(_ context.Context, id uuid.UUID) (*dto.GalleryResponse, error) {
+	return &dto.GalleryResponse{ID: id, Status: g.status}, nil
+}
+
+func TestPublicByIDStatus(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	admin := models.User{ID: uuid.New(), Email: "admin@example.com", Role: models.RoleAdmin, IsAdmin: true}
+	member := models.User{ID: uuid.New(), Email: "user@example.com", Role: models.RoleUser}
+
+	tokens := tokenapp.NewTokenService(nil, "0123456789abcdef0123456789abcdef")
+	token := func(user models.User) string {
+		raw, err := tokens.NewToken(user, "session-1", tokenapp.TokenTypeAccess, time.Minute)
+		require.NoError(t, err)
+		return raw
+	}
+
+	users := adminChecker{admins: map[uuid.UUID]bool{admin.ID: true}}
+	id := uuid.New().String()
+
+	tests := []struct {
+		name       string
+		status     string
+		bearer     string
+		wantStatus int
+	}{
+		{name: "anonymous published", status: "published", wantStatus: http.StatusOK},
+		{name: "anonymous draft", status: "draft", wantStatus: http.StatusNotFound},
+		{name: "user archived", status: "archived", bearer: token(member), wantStatus: http.StatusNotFound},
+		{name: "admin draft", status: "draft", bearer: token(admin), wantStatus: http.StatusOK},
+	}
+
+	for _, path := range []string{"/api/v1/posts/" + id, "/api/v1/gallery/galleries/" + id} {
+		for _, tt := range tests {
+			t.Run(path+" "+tt.name, func(t *testing.T) {
+				posts := postWithStatus{status: tt.status}
+				galleries := galleryWithStatus{status: tt.status}
+				routers := httprouters.NewRouter(log, users, nil, tokens, posts, nil, nil, galleries, nil, nil, nil, nil, nil, nil, httprouters.CookieConfig{})
+				server := New(log, testConfig(), routers)
+				server.BuildRouters()
+
+				req := httptest.NewRequest(http.MethodGet, path, nil)
+				if tt.bearer != "" {
+					req.Header.Set(echo.HeaderAuthorization, "Bearer "+tt.bearer)
+				}
+				rec := httptest.NewRecorder()
+
+				server.e.ServeHTTP(rec, req)
+
+				assert.Equal(t, tt.wantStatus, rec.Code, rec.Body.String())
+			})
+		}
+	}
+}
+
+func TestPublicPostStatus(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	admin := models.User{ID: uuid.New(), Email: "admin@example.com", Role: models.RoleAdmin, IsAdmin: true}
+
+	tokens := tokenapp.NewTokenService(nil, "0123456789abcdef0123456789abcdef")
+	adminToken, err := tokens.NewToken(admin, "session-1", tokenapp.TokenTypeAccess, time.Minute)
+	require.NoError(t, err)
+
+	posts := &recordingPosts{}
+	users := adminChecker{admins: map[uuid.UUID]bool{admin.ID: true}}
+	routers := httprouters.NewRouter(log, users, nil, tokens, posts, nil, nil, nil, nil, nil, nil, nil, nil, nil, httprouters.CookieConfig{})
+	server := New(log, testConfig(), routers)
+	server.BuildRouters()
+
+	tests := []struct {
+		name       string
+		path       string
+		bearer     string
+		wantStatus string
+	}{
+		{name: "anonymous list without status", path: "/api/v1/posts", wantStatus: "published"},
+		{name: "anonymous list of drafts", path: "/api/v1/posts?status=draft", wantStatus: "published"},
+		{name: "anonymous tags of all posts", path: "/api/v1/posts/tags?status=all", wantStatus: "published"},
+		{name: "admin list without status", path: "/api/v1/posts", bearer: adminToken, wantStatus: ""},
+		{name: "admin tags of drafts", path: "/api/v1/posts/tags?status=draft", bearer: adminToken, wantStatus: "draft"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			posts.status = "unset"
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.bearer != "" {
+				req.Header.Set(echo.HeaderAuthorization, "Bearer "+tt.bearer)
+			}
+			rec := httptest.NewRecorder()
+
+			server.e.ServeHTTP(rec, req)
+
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			assert.Equal(t, tt.wantStatus, posts.status)
+		})
+	}
+}
+
+// memoryTokens хранилище refresh-токенов в памяти
+type memoryTokens map[string]bool
+
+func (m memoryTokens) SaveRefreshToken(_ context.Context, userID, token string, _ time.Duration) error {
+	m[userID+":"+token] = true
+	return nil
+}
+
+func (m memoryTokens) GetRefreshToken(_ context.Context, userID, token string) (bool, error) {
+	return m[userID+":"+token], nil
+}
+
+func (m memoryTokens) DeleteRefreshToken(_ context.Context, userID, token string) error {
+	delete(m, userID+":"+token)
+	return nil
+}
+
+func (m memoryTokens) DeleteAllUserTokens(_ context.Context, userID string) error {
+	for key := range m {
+		if strings.HasPrefix(key, userID+":") {
+			delete(m, key)
+		}
+	}
+	return nil
+}
+
+func TestRefreshCSRF(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	member := models.User{ID: uuid.New(), Email: "user@example.com", Role: models.RoleUser}
+
+	tokens := tokenapp.NewTokenService(memoryTokens{}, "0123456789abcdef0123456789abcdef")
+	routers := httprouters.NewRouter(log, nil, nil, tokens, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, httprouters.CookieConfig{})
+	cfg := testConfig()
+	cfg.BodyLimit = 4096
+	server := New(log, cfg, routers)
+	server.BuildRouters()
+
+	sessionCSRF := func(t *testing.T, refreshToken string) string {
+		p, err := tokens.ParseRefreshToken(refreshToken)
+		require.NoError(t, err)
+		return tokens.CSRFToken(p.SessionID)
+	}
+
+	tests := []struct {
+		name       string
+		fromCookie bool
+		csrf       func(t *testing.T, refreshToken string) string
+		wantStatus int
+	}{
+		{name: "cookie without csrf token", fromCookie: true, wantStatus: http.StatusForbidden},
+		{name: "cookie with csrf token of the session", fromCookie: true, csrf: sessionCSRF, wantStatus: http.StatusOK},
+		{name: "cookie with csrf token of another session", fromCookie: true, csrf: func(*testing.T, string) string { return tokens.CSRFToken("session-2") }, wantStatus: http.StatusForbidden},
+		{name: "token in body", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pair, err := tokens.GenerateTokens(member)
+			require.NoError(t, err)
+
+			body := `{}`
+			if !tt.fromCookie {
+				body = `{"refresh_token":"` + pair.RefreshToken + `"}`
+			}
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/refresh", strings.NewReader(body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			if tt.fromCookie {
+				req.AddCookie(&http.Cookie{Name: "refresh_token", Value: pair.RefreshToken})
+			}
+			if tt.csrf != nil {
+				req.Header.Set(httprouters.CSRFHeader, tt.csrf(t, pair.RefreshToken))
+			}
+			rec := httptest.NewRecorder()
+
+			server.e.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.wantStatus, rec.Code, rec.Body.String())
+		})
+	}
+
+	t.Run("no refresh token", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/refresh", strings.NewReader(`{}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+
+		server.e.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+}
+
+// passwordLogin выдает токены при входе, как UserService после проверки пароля
+type passwordLogin struct {
+	httprouters.UserService
+	tokens *tokenapp.TokenService
+	user   models.User
+}
+
+func (p passwordLogin) Login(_ context.Context, _, _ string) (*models.LoginResult, error) {
+	pair, err := p.tokens.GenerateTokens(p.user)
+	if err != nil {
+		return nil, err
+	}
+	return &models.LoginResult{Tokens: pair}, nil
+}
+
+func TestCookieLoginRefresh(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	member := models.User{ID: uuid.New(), Email: "user@example.com", Role: models.RoleUser}
+
+	tokens := tokenapp.NewTokenService(memoryTokens{}, "0123456789abcdef0123456789abcdef")
+	users := passwordLogin{tokens: tokens, user: member}
+	routers := httprouters.NewRouter(log, users, nil, tokens, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, httprouters.CookieConfig{})
+	cfg := testConfig()
+	cfg.BodyLimit = 4096
+	server := New(log, cfg, routers)
+	server.BuildRouters()
+
+	login := func(t *testing.T) (*http.Cookie, string) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/login", strings.NewReader(`{"identifier":"user@example.com","password":"password123"}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+
+		server.e.ServeHTTP(rec, req)
+
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+		var body struct {
+			Data struct {
+				CSRFToken string `json:"csrf_token"`
+			} `json:"data"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		require.NotEmpty(t, body.Data.CSRFToken)
+
+		for _, cookie := range rec.Result().Cookies() {
+			if cookie.Name == "refresh_token" {
+				return cookie, body.Data.CSRFToken
+			}
+		}
+		t.Fatal("refresh_token cookie is not set")
+		return nil, ""
+	}
+
+	// Access-токен истек: браузер отправляет только refresh-cookie
+	refresh := func(refreshCookie *http.Cookie, csrf string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/refresh", strings.NewReader(`{}`))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.AddCookie(&http.Cookie{Name: refreshCookie.Name, Value: refreshCookie.Value})
+		if csrf != "" {
+			req.Header.Set(httprouters.CSRFHeader, csrf)
+		}
+		rec := httptest.NewRecorder()
+		server.e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("csrf token from login response", func(t *testing.T) {
+		refreshCookie, csrf := login(t)
+
+		rec := refresh(refreshCookie, csrf)
+
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var body struct {
+			CSRFToken string `json:"csrf_token"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, csrf, body.CSRFToken)
+
+		var rotated *http.Cookie
+		for _, cookie := range rec.Result().Cookies() {
+			if cookie.Name == "refresh_token" {
+				rotated = cookie
+			}
+		}
+		require.NotNil(t, rotated)
+
+		assert.Equal(t, http.StatusOK, refresh(rotated, body.CSRFToken).Code)
+	})
+
+	t.Run("without csrf token", func(t *testing.T) {
+		refreshCookie, _ := login(t)
+
+		assert.Equal(t, http.StatusForbidden, refresh(refreshCookie, "").Code)
+	})
+}
+
+// savedEvents запоминает записи журнала аудита
+type savedEvents struct {
+	repository.AuditRepository
+	events []models.AuditEvent
+}
+
+func (s *savedEvents) SaveEvent(_ context.Context, event models.AuditEvent) error {
+	s.events = append(s.events, event)
+	return nil
+}
+
+func TestAuditRecordsAPIKey(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	member := models.User{ID: uuid.New(), Email: "user@example.com", Role: models.RoleUser}
+	apiKeyID := uuid.New()
+
+	tokens := tokenapp.NewTokenService(nil, "0123456789abcdef0123456789abcdef")
+	sessionToken, err := tokens.NewToken(member, "session-1", tokenapp.TokenTypeAccess, time.Minute)
+	require.NoError(t, err)
+
+	keys := issuedKeys{keys: map[string]principal.Principal{
+		"pck_user_posts": {UserID: member.ID, Roles: member.Roles(), APIKeyID: apiKeyID, Scopes: []string{models.ScopePostsWrite}},
+	}}
+	server := New(log, testConfig(), &httprouters.Routers{AuthService: tokens, APIKeyService: keys})
+
+	repo := &savedEvents{}
+	audit := auditapp.NewAuditService(log, repo, 0)
+	server.e.POST("/posts", func(c echo.Context) error {
+		audit.Record(c.Request().Context(), models.AuditEntry{Action: models.AuditPostCreate, TargetType: models.AuditTargetPost})
+		return c.NoContent(http.StatusCreated)
+	}, server.scopeMiddleware(models.ScopePostsWrite))
+
+	send := func(header, value string) {
+		req := httptest.NewRequest(http.MethodPost, "/posts", nil)
+		req.Header.Set(header, value)
+		rec := httptest.NewRecorder()
+		server.e.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	}
+
+	send(apiKeyHeader, "pck_user_posts")
+	send(echo.HeaderAuthorization, "Bearer "+sessionToken)
+
+	require.Len(t, repo.events, 2)
+	require.NotNil(t, repo.events[0].APIKeyID, "write with api key")
+	assert.Equal(t, apiKeyID, *repo.events[0].APIKeyID)
+	assert.Equal(t, member.ID, *repo.events[0].ActorID)
+	assert.Nil(t, repo.events[1].APIKeyID, "write in user session")
+	assert.Equal(t, member.ID, *repo.events[1].ActorID)
+}
@@ -0,0 +1,42 @@
+package httpapp
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+// certReloader отдает TLS-сертификат, загруженный из файлов. Reload перечитывает файлы,
+// чтобы обновленный сертификат применялся без перезапуска сервера
+type certReloader struct {
+	certFile string
+	keyFile  string
+	cert     atomic.Pointer[tls.Certificate]
+}
+
+func newCertReloader(certFile, keyFile string) *certReloader {
+	return &certReloader{certFile: certFile, keyFile: keyFile}
+}
+
+// Reload загружает сертификат из файлов. При ошибке остается прежний сертификат
+func (r *certReloader) Reload() error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	r.cert.Store(&cert)
+
+	return nil
+}
+
+// GetCertificate для tls.Config.GetCertificate
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cert := r.cert.Load()
+	if cert == nil {
+		return nil, errors.New("TLS certificate is not loaded")
+	}
+
+	return cert, nil
+}
@@ -1,61 +1,413 @@
 package config
 
 import (
+	"errors"
 	"flag"
+	"fmt"
+	"log/slog"
+	"maps"
+	"net"
+	"net/http"
+	"net/url"
 	"os"
+	"regexp"
+	"slices"
+	"strconv"
 	"time"
 
 	"github.com/ilyakaznacheev/cleanenv"
+	"github.com/jackc/pgx/v4"
 )
 
+// Окружения, для которых настроен логгер
+const (
+	EnvLocal = "local"
+	EnvDev   = "dev"
+	EnvProd  = "prod"
+)
+
+// Config настройки приложения. Значения читаются из YAML-файла, переменные окружения
+// из тегов env их переопределяют. Секреты можно передать файлом, см. secretVars
 type Config struct {
-	Env         string            `yaml:"env" env-default:"local"`
-	DSN         string            `yaml:"dsn" env-required:"true"`
-	TokenTTL    time.Duration     `yaml:"token_ttl" env-default:"1h"`
+	Env         string            `yaml:"env" env:"APP_ENV" env-default:"local"`
+	DSN         string            `yaml:"dsn" env:"DSN"`
+	AutoMigrate bool              `yaml:"auto_migrate" env:"AUTO_MIGRATE" env-default:"false"` // Применять миграции при старте сервера
+	TokenTTL    time.Duration     `yaml:"token_ttl" env:"TOKEN_TTL" env-default:"1h"`
+	LogLevel    string            `yaml:"log_level" env:"LOG_LEVEL"` // debug, info, warn, error. По умолчанию debug, в prod info
+	Auth        AuthConfig        `yaml:"auth"`
 	HTTP        HTTPConfig        `yaml:"http"`
 	FileStorage FileStorageConfig `yaml:"file_storage"`
 	Redis       RedisConf         `yaml:"redis"`
+	Site        SiteConfig        `yaml:"site"`
+	Audit       AuditConfig       `yaml:"audit"`
+	RateLimits  RateLimitConfig   `yaml:"rate_limits"`
+	OIDC        OIDCConfig        `yaml:"oidc"`
+
+	path string // Файл, из которого загружен конфиг, для Reload
+}
+
+// AuthConfig ключи подписи токенов
+type AuthConfig struct {
+	JWTSecret string `yaml:"jwt_secret" env:"AUTH_JWT_SECRET"` // Подписывает access- и refresh-токены и токены CSRF, не короче 32 байт
 }
 
 type HTTPConfig struct {
-	Host string `yaml:"host"`
-	Port string `yaml:"port" env-default:"8080"`
+	Host        string `yaml:"host" env:"HTTP_HOST"`
+	Port        string `yaml:"port" env:"HTTP_PORT" env-default:"8080"`
+	MetricsAddr string `yaml:"metrics_addr" env:"HTTP_METRICS_ADDR" env-default:":9090"` // Отдельный сервер метрик Prometheus
+
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"HTTP_READ_HEADER_TIMEOUT" env-default:"10s"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"HTTP_READ_TIMEOUT" env-default:"5m"`   // Включает чтение тела, поэтому с запасом на загрузку файлов
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"HTTP_WRITE_TIMEOUT" env-default:"5m"` // Скачивание архива галереи не ограничено
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT" env-default:"2m"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" env:"HTTP_SHUTDOWN_TIMEOUT" env-default:"10s"`
+
+	BodyLimit       int64 `yaml:"body_limit" env:"HTTP_BODY_LIMIT" env-default:"1048576"`                 // Максимальный размер тела запроса в байтах, 1MB
+	UploadBodyLimit int64 `yaml:"upload_body_limit" env:"HTTP_UPLOAD_BODY_LIMIT" env-default:"104857600"` // Для загрузки медиафайлов, 100MB
+
+	CORS            CORSConfig            `yaml:"cors"`
+	Cookie          CookieConfig          `yaml:"cookie"`
+	TLS             TLSConfig             `yaml:"tls"`
+	SecurityHeaders SecurityHeadersConfig `yaml:"security_headers"`
+}
+
+// CORSConfig сайты, которым браузер разрешает запросы к API с cookie.
+// Без allow_origins запросы с других сайтов запрещены
+type CORSConfig struct {
+	AllowOrigins []string      `yaml:"allow_origins" env:"HTTP_CORS_ALLOW_ORIGINS"`
+	MaxAge       time.Duration `yaml:"max_age" env:"HTTP_CORS_MAX_AGE" env-default:"24h"` // Сколько браузер кеширует ответ на preflight-запрос
+}
+
+// CookieConfig параметры cookie с токенами
+type CookieConfig struct {
+	Secure   bool   `yaml:"secure" env:"HTTP_COOKIE_SECURE"`                            // Только HTTPS. Обязательно в prod
+	SameSite string `yaml:"same_site" env:"HTTP_COOKIE_SAME_SITE" env-default:"strict"` // strict, lax или none
+	Domain   string `yaml:"domain" env:"HTTP_COOKIE_DOMAIN"`                            // По умолчанию домен API
+}
+
+// SameSiteMode значение SameSite для http.Cookie
+func (c CookieConfig) SameSiteMode() http.SameSite {
+	switch c.SameSite {
+	case "lax":
+		return http.SameSiteLaxMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteStrictMode
+	}
+}
+
+// TLSConfig сертификат для HTTPS. Без файлов сервер работает по HTTP, например за
+// обратным прокси. Сертификат перечитывается из файлов по SIGHUP
+type TLSConfig struct {
+	CertFile string `yaml:"cert_file" env:"HTTP_TLS_CERT_FILE"`
+	KeyFile  string `yaml:"key_file" env:"HTTP_TLS_KEY_FILE"`
+}
+
+// Enabled сервер принимает HTTPS
+func (c TLSConfig) Enabled() bool {
+	return c.CertFile != "" || c.KeyFile != ""
+}
+
+// SecurityHeadersConfig заголовки безопасности ответов. X-Content-Type-Options и
+// X-Frame-Options отправляются всегда
+type SecurityHeadersConfig struct {
+	HSTSMaxAge            time.Duration `yaml:"hsts_max_age" env:"HTTP_HSTS_MAX_AGE" env-default:"8760h"` // Только для HTTPS-запросов
+	ContentSecurityPolicy string        `yaml:"content_security_policy" env:"HTTP_CONTENT_SECURITY_POLICY" env-default:"default-src 'none'; frame-ancestors 'none'"`
 }
 
 type FileStorageConfig struct {
-	BaseDir string `yaml:"base_dir"`
-	BaseURL string `yaml:"base_url"`
-	MaxSize int64  `yaml:"max_size"`
+	BaseDir string `yaml:"base_dir" env:"FILE_STORAGE_BASE_DIR"`
+	BaseURL string `yaml:"base_url" env:"FILE_STORAGE_BASE_URL"`
+	MaxSize int64  `yaml:"max_size" env:"FILE_STORAGE_MAX_SIZE"`
+	// MaxArchiveBuilds сколько архивов галерей один пользователь может скачивать одновременно
+	MaxArchiveBuilds int `yaml:"max_archive_builds" env:"FILE_STORAGE_MAX_ARCHIVE_BUILDS" env-default:"2"`
+}
+
+// SiteConfig публичные данные сайта для RSS/Atom лент и sitemap
+type SiteConfig struct {
+	BaseURL      string        `yaml:"base_url" env:"SITE_BASE_URL" env-default:"http://localhost:3000"`
+	Title        string        `yaml:"title" env:"SITE_TITLE" env-default:"Premium Caste"`
+	Description  string        `yaml:"description" env:"SITE_DESCRIPTION"`
+	FeedCacheTTL time.Duration `yaml:"feed_cache_ttl" env:"SITE_FEED_CACHE_TTL" env-default:"1h"`
+}
+
+// AuditConfig срок хранения журнала аудита
+type AuditConfig struct {
+	Retention     time.Duration `yaml:"retention" env:"AUDIT_RETENTION" env-default:"2160h"`         // Записи старше удаляются, 0 - хранить бессрочно
+	PruneInterval time.Duration `yaml:"prune_interval" env:"AUDIT_PRUNE_INTERVAL" env-default:"24h"` // Как часто сервер удаляет устаревшие записи
+}
+
+// RateLimitConfig лимиты запросов. Применяются без перезапуска по SIGHUP
+type RateLimitConfig struct {
+	Comments       int           `yaml:"comments" env:"RATE_LIMIT_COMMENTS" env-default:"5"` // Комментариев пользователя за comments_window
+	CommentsWindow time.Duration `yaml:"comments_window" env:"RATE_LIMIT_COMMENTS_WINDOW" env-default:"10m"`
+	APIKeyDefault  int           `yaml:"api_key_default" env:"RATE_LIMIT_API_KEY_DEFAULT" env-default:"60"` // Запросов в минуту для новых ключей API без своего лимита
+}
+
+// OIDCConfig провайдеры OpenID Connect для входа. Ключ - имя провайдера в адресах API.
+// Провайдер объявляется в файле, а его поля переопределяются переменными OIDC_<ИМЯ>_<ПОЛЕ>:
+// для google это OIDC_GOOGLE_ISSUER, OIDC_GOOGLE_CLIENT_ID, OIDC_GOOGLE_REDIRECT_URL,
+// OIDC_GOOGLE_SCOPES через запятую и OIDC_GOOGLE_CLIENT_SECRET или OIDC_GOOGLE_CLIENT_SECRET_FILE
+type OIDCConfig struct {
+	Providers map[string]OIDCProviderConfig `yaml:"providers"`
+}
+
+type OIDCProviderConfig struct {
+	Issuer       string   `yaml:"issuer"`
+	ClientID     string   `yaml:"client_id"`
+	ClientSecret string   `yaml:"client_secret"`
+	RedirectURL  string   `yaml:"redirect_url"` // Страница сайта, которая передает code и state в API
+	Scopes       []string `yaml:"scopes"`       // По умолчанию openid, email, profile
 }
 
 type RedisConf struct {
-	RedisAddr     string `yaml:"redis_addr"`
-	RedisPassword string `yaml:"redis_password"`
-	RedisDB       int    `yaml:"redis_db"`
+	RedisAddr     string `yaml:"redis_addr" env:"REDIS_ADDR"`
+	RedisPassword string `yaml:"redis_password" env:"REDIS_PASSWORD"`
+	RedisDB       int    `yaml:"redis_db" env:"REDIS_DB"`
 }
 
+// MustLoad загружает и проверяет конфиг по пути из флага --config или переменной CONFIG_PATH.
+// Если конфиг не читается или неверен, выводит все найденные ошибки и завершает процесс
 func MustLoad() *Config {
+	cfg, err := LoadFromFlags()
+	if err == nil {
+		err = cfg.Validate()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "config is invalid:\n%v\n", err)
+		os.Exit(1)
+	}
+
+	return cfg
+}
+
+// LoadFromFlags регистрирует флаг --config в flag.CommandLine, разбирает флаги
+// и загружает конфиг. Без флага путь берется из CONFIG_PATH
+func LoadFromFlags() (*Config, error) {
 	path := fetchConfigPath()
 	if path == "" {
-		panic("config path is empty")
+		return nil, errors.New("config path is empty")
 	}
 
-	return MustLoadPath(path)
+	return Load(path)
 }
 
 func MustLoadPath(configPath string) *Config {
+	cfg, err := Load(configPath)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	return cfg
+}
+
+// Load читает конфиг из файла и переменных окружения без проверки значений.
+// Значения проверяет Validate
+func Load(configPath string) (*Config, error) {
 	// check if file exists
 	if _, err := os.Stat(configPath); os.IsNotExist(err) {
-		panic("config file does not exist: " + configPath)
+		return nil, fmt.Errorf("config file does not exist: %s", configPath)
 	}
 
-	var cfg Config
+	cfg := Config{path: configPath}
 
 	if err := cleanenv.ReadConfig(configPath, &cfg); err != nil {
-		panic("cannot read config: " + err.Error())
+		return nil, fmt.Errorf("cannot read config: %w", err)
+	}
+	if err := cfg.readSecrets(); err != nil {
+		return nil, fmt.Errorf("cannot read secrets: %w", err)
+	}
+
+	return &cfg, nil
+}
+
+// SlogLevel уровень логирования из log_level или уровень по умолчанию для окружения
+func (c *Config) SlogLevel() slog.Level {
+	switch c.LogLevel {
+	case "debug":
+		return slog.LevelDebug
+	case "info":
+		return slog.LevelInfo
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	}
+
+	if c.Env == EnvProd {
+		return slog.LevelInfo
+	}
+	return slog.LevelDebug
+}
+
+// Validate проверяет значения конфига, с которыми сервер не сможет работать,
+// и возвращает все найденные ошибки сразу
+func (c *Config) Validate() error {
+	var errs []error
+	add := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	switch c.Env {
+	case EnvLocal, EnvDev, EnvProd:
+	default:
+		add("env: must be one of %s, %s, %s, got %q", EnvLocal, EnvDev, EnvProd, c.Env)
+	}
+
+	if c.DSN == "" {
+		add("dsn: is required")
+	} else if _, err := pgx.ParseConfig(c.DSN); err != nil {
+		add("dsn: %w", err)
+	}
+	if c.TokenTTL <= 0 {
+		add("token_ttl: must be positive")
+	}
+	switch c.LogLevel {
+	case "", "debug", "info", "warn", "error":
+	default:
+		add("log_level: must be one of debug, info, warn, error, got %q", c.LogLevel)
+	}
+
+	if len(c.Auth.JWTSecret) < minJWTSecretLen {
+		add("auth.jwt_secret: must be at least %d bytes", minJWTSecretLen)
+	}
+
+	if port, err := strconv.Atoi(c.HTTP.Port); err != nil || port < 1 || port > 65535 {
+		add("http.port: must be a number between 1 and 65535, got %q", c.HTTP.Port)
+	}
+	if _, _, err := net.SplitHostPort(c.HTTP.MetricsAddr); err != nil {
+		add("http.metrics_addr: %w", err)
+	}
+	for _, timeout := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"read_header_timeout", c.HTTP.ReadHeaderTimeout},
+		{"read_timeout", c.HTTP.ReadTimeout},
+		{"write_timeout", c.HTTP.WriteTimeout},
+		{"idle_timeout", c.HTTP.IdleTimeout},
+	} {
+		if timeout.value < 0 {
+			add("http.%s: must not be negative", timeout.name)
+		}
+	}
+	if c.HTTP.ShutdownTimeout <= 0 {
+		add("http.shutdown_timeout: must be positive")
+	}
+	if c.HTTP.BodyLimit <= 0 {
+		add("http.body_limit: must be positive")
+	}
+	if c.HTTP.UploadBodyLimit < c.HTTP.BodyLimit {
+		add("http.upload_body_limit: must not be less than http.body_limit")
+	}
+	for i, origin := range c.HTTP.CORS.AllowOrigins {
+		if err := validateURL(origin); err != nil {
+			add("http.cors.allow_origins[%d]: %w", i, err)
+		}
+	}
+	if c.HTTP.CORS.MaxAge < 0 {
+		add("http.cors.max_age: must not be negative")
+	}
+	switch c.HTTP.Cookie.SameSite {
+	case "strict", "lax":
+	case "none":
+		if !c.HTTP.Cookie.Secure {
+			add("http.cookie.same_site: none requires http.cookie.secure")
+		}
+	default:
+		add("http.cookie.same_site: must be one of strict, lax, none, got %q", c.HTTP.Cookie.SameSite)
+	}
+	if c.Env == EnvProd && !c.HTTP.Cookie.Secure {
+		add("http.cookie.secure: must be true in %s", EnvProd)
+	}
+	if c.HTTP.TLS.Enabled() && (c.HTTP.TLS.CertFile == "" || c.HTTP.TLS.KeyFile == "") {
+		add("http.tls: cert_file and key_file must be set together")
+	}
+	if c.HTTP.SecurityHeaders.HSTSMaxAge < 0 {
+		add("http.security_headers.hsts_max_age: must not be negative")
+	}
+
+	if c.FileStorage.BaseDir == "" {
+		add("file_storage.base_dir: is required")
+	}
+	if err := validateURL(c.FileStorage.BaseURL); err != nil {
+		add("file_storage.base_url: %w", err)
+	}
+	if c.FileStorage.MaxSize < 0 {
+		add("file_storage.max_size: must not be negative")
+	}
+	if c.FileStorage.MaxArchiveBuilds < 1 {
+		add("file_storage.max_archive_builds: must be at least 1")
+	}
+
+	if _, _, err := net.SplitHostPort(c.Redis.RedisAddr); err != nil {
+		add("redis.redis_addr: %w", err)
+	}
+	if c.Redis.RedisDB < 0 {
+		add("redis.redis_db: must not be negative")
+	}
+
+	if err := validateURL(c.Site.BaseURL); err != nil {
+		add("site.base_url: %w", err)
+	}
+	if c.Site.FeedCacheTTL < 0 {
+		add("site.feed_cache_ttl: must not be negative")
+	}
+
+	if c.Audit.Retention < 0 {
+		add("audit.retention: must not be negative")
+	}
+	if c.Audit.PruneInterval <= 0 {
+		add("audit.prune_interval: must be positive")
+	}
+
+	if c.RateLimits.Comments < 1 {
+		add("rate_limits.comments: must be at least 1")
+	}
+	if c.RateLimits.CommentsWindow <= 0 {
+		add("rate_limits.comments_window: must be positive")
+	}
+	if c.RateLimits.APIKeyDefault < 1 {
+		add("rate_limits.api_key_default: must be at least 1")
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(c.OIDC.Providers)) {
+		provider := c.OIDC.Providers[name]
+		if !providerName.MatchString(name) {
+			add("oidc.providers.%s: name must contain only lowercase letters, digits, '-' and '_'", name)
+		}
+		if err := validateURL(provider.Issuer); err != nil {
+			add("oidc.providers.%s.issuer: %w", name, err)
+		}
+		if provider.ClientID == "" {
+			add("oidc.providers.%s.client_id: is required", name)
+		}
+		if err := validateURL(provider.RedirectURL); err != nil {
+			add("oidc.providers.%s.redirect_url: %w", name, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// minJWTSecretLen минимальная длина ключа подписи токенов: 256 бит для HS256
+const minJWTSecretLen = 32
+
+// providerName имя провайдера входит в адреса API
+var providerName = regexp.MustCompile(`^[a-z0-9_-]+$`)
+
+// validateURL проверяет, что rawURL - абсолютный http(s) адрес
+func validateURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("must be an absolute http(s) URL, got %q", rawURL)
 	}
 
-	return &cfg
+	return nil
 }
 
 func fetchConfigPath() string {
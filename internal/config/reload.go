@@ -0,0 +1,51 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Reload заново читает файл, из которого загружен конфиг, и переменные окружения
+// и проверяет значения. При ошибке текущий конфиг остается в силе
+func (c *Config) Reload() (*Config, error) {
+	next, err := Load(c.path)
+	if err != nil {
+		return nil, err
+	}
+	if err := next.Validate(); err != nil {
+		return nil, err
+	}
+
+	return next, nil
+}
+
+// RestartRequired возвращает разделы конфига, изменения которых в next применятся
+// только после перезапуска. Без перезапуска меняются log_level, rate_limits
+// и http.cors.allow_origins
+func (c *Config) RestartRequired(next *Config) []string {
+	current, updated := reflect.ValueOf(c.withoutReloadable()), reflect.ValueOf(next.withoutReloadable())
+
+	var sections []string
+	for i := range current.NumField() {
+		field := current.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		if !reflect.DeepEqual(current.Field(i).Interface(), updated.Field(i).Interface()) {
+			name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+			sections = append(sections, name)
+		}
+	}
+
+	return sections
+}
+
+// withoutReloadable копия конфига без настроек, которые применяются по SIGHUP
+func (c Config) withoutReloadable() Config {
+	c.LogLevel = ""
+	c.RateLimits = RateLimitConfig{}
+	c.HTTP.CORS.AllowOrigins = nil
+
+	return c
+}
@@ -0,0 +1,101 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// fileSuffix суффикс переменной окружения с путем к файлу секрета, как у Docker и Kubernetes secrets
+const fileSuffix = "_FILE"
+
+// secretVars поля с секретами и их переменные окружения. Кроме самой переменной,
+// секрет можно передать файлом в переменной с суффиксом _FILE, например DSN_FILE
+func (c *Config) secretVars() map[string]*string {
+	return map[string]*string{
+		"DSN":             &c.DSN,
+		"REDIS_PASSWORD":  &c.Redis.RedisPassword,
+		"AUTH_JWT_SECRET": &c.Auth.JWTSecret,
+	}
+}
+
+// readSecrets читает секреты из файлов _FILE и настройки провайдеров OpenID Connect,
+// для которых у cleanenv нет тегов. Задать и переменную, и файл нельзя
+func (c *Config) readSecrets() error {
+	var errs []error
+
+	vars := c.secretVars()
+	for _, name := range slices.Sorted(maps.Keys(vars)) {
+		if err := readSecretFile(name, vars[name]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	for _, provider := range slices.Sorted(maps.Keys(c.OIDC.Providers)) {
+		cfg := c.OIDC.Providers[provider]
+
+		fields := map[string]*string{
+			"ISSUER":        &cfg.Issuer,
+			"CLIENT_ID":     &cfg.ClientID,
+			"CLIENT_SECRET": &cfg.ClientSecret,
+			"REDIRECT_URL":  &cfg.RedirectURL,
+		}
+		for field, value := range fields {
+			if env, ok := os.LookupEnv(oidcVar(provider, field)); ok {
+				*value = env
+			}
+		}
+		if env, ok := os.LookupEnv(oidcVar(provider, "SCOPES")); ok {
+			cfg.Scopes = splitList(env)
+		}
+
+		if err := readSecretFile(oidcVar(provider, "CLIENT_SECRET"), &cfg.ClientSecret); err != nil {
+			errs = append(errs, err)
+		}
+
+		c.OIDC.Providers[provider] = cfg
+	}
+
+	return errors.Join(errs...)
+}
+
+// readSecretFile записывает в value содержимое файла из переменной name_FILE.
+// Перевод строки в конце файла отбрасывается
+func readSecretFile(name string, value *string) error {
+	path, ok := os.LookupEnv(name + fileSuffix)
+	if !ok {
+		return nil
+	}
+	if _, ok := os.LookupEnv(name); ok {
+		return fmt.Errorf("%s and %s%s are both set", name, name, fileSuffix)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("%s%s: %w", name, fileSuffix, err)
+	}
+
+	*value = strings.TrimRight(string(data), "\r\n")
+
+	return nil
+}
+
+// oidcVar переменная окружения с полем field провайдера: OIDC_GOOGLE_CLIENT_ID для google
+func oidcVar(provider, field string) string {
+	return "OIDC_" + strings.ToUpper(strings.ReplaceAll(provider, "-", "_")) + "_" + field
+}
+
+// splitList разбирает список через запятую, как cleanenv для срезов
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
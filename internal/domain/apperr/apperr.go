@@ -0,0 +1,166 @@
+// Package apperr описывает ошибки предметной области, которые репозитории и сервисы
+// возвращают наверх. Каждая ошибка имеет вид (не найдено, конфликт, ошибка валидации и т.д.),
+// по которому транспорт выбирает HTTP-статус, и стабильный код для клиентов API.
+//
+// Ошибки неизменяемы: WithFields, WithDetail и Wrap возвращают копию, поэтому
+// общие переменные-ошибки можно безопасно использовать из разных запросов.
+package apperr
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Kind вид ошибки
+type Kind string
+
+const (
+	KindInvalid      Kind = "invalid"      // Некорректные входные данные
+	KindUnauthorized Kind = "unauthorized" // Требуется аутентификация
+	KindForbidden    Kind = "forbidden"    // Действие запрещено
+	KindNotFound     Kind = "not_found"    // Объект не найден
+	KindConflict     Kind = "conflict"     // Конфликт с текущим состоянием, например занятый slug
+	KindTooLarge     Kind = "too_large"    // Превышен допустимый размер
+	KindUnsupported  Kind = "unsupported"  // Неподдерживаемый формат
+	KindRateLimited  Kind = "rate_limited" // Превышен лимит запросов
+	KindInternal     Kind = "internal"     // Внутренняя ошибка
+)
+
+// CodeValidation общий код ошибки валидации с описанием полей
+const CodeValidation = "validation_failed"
+
+// FieldError ошибка в конкретном поле запроса
+type FieldError struct {
+	Field   string `json:"field"`           // Имя поля в запросе
+	Code    string `json:"code"`            // Стабильный код, например required или max
+	Param   string `json:"param,omitempty"` // Параметр правила, например 255 для max=255
+	Message string `json:"message"`         // Описание для человека
+}
+
+// Error ошибка предметной области
+type Error struct {
+	Kind    Kind
+	Code    string       // Стабильный код, например post_not_found
+	Message string       // Краткое описание, одинаковое для всех ошибок с этим кодом
+	Detail  string       // Подробности конкретного случая
+	Fields  []FieldError // Ошибки по полям для KindInvalid
+	cause   error
+}
+
+// New создает ошибку вида kind с кодом code
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func NotFound(code, message string) *Error     { return New(KindNotFound, code, message) }
+func Conflict(code, message string) *Error     { return New(KindConflict, code, message) }
+func Invalid(code, message string) *Error      { return New(KindInvalid, code, message) }
+func Forbidden(code, message string) *Error    { return New(KindForbidden, code, message) }
+func Unauthorized(code, message string) *Error { return New(KindUnauthorized, code, message) }
+func RateLimited(code, message string) *Error  { return New(KindRateLimited, code, message) }
+func TooLarge(code, message string) *Error     { return New(KindTooLarge, code, message) }
+func Unsupported(code, message string) *Error  { return New(KindUnsupported, code, message) }
+
+// Validation создает ошибку валидации с описанием полей
+func Validation(message string, fields ...FieldError) *Error {
+	return Invalid(CodeValidation, message).WithFields(fields...)
+}
+
+// InvalidField создает ошибку валидации одного поля
+func InvalidField(field, code, message string) *Error {
+	return Validation(message, Field(field, code, message))
+}
+
+// Field описывает ошибку в поле field
+func Field(field, code, message string) FieldError {
+	return FieldError{Field: field, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	msg := e.Message
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	if e.cause != nil {
+		msg += ": " + e.cause.Error()
+	}
+
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+// Is считает ошибки равными, если совпадают вид и код. Благодаря этому
+// копии, полученные через WithDetail или Wrap, совпадают с исходной переменной.
+// Если у target есть ошибки полей, каждая из них должна быть и у e: иначе
+// все ошибки InvalidField с общим кодом validation_failed совпадали бы между собой
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok {
+		return false
+	}
+	if e.Kind != t.Kind || e.Code != t.Code {
+		return false
+	}
+
+	for _, want := range t.Fields {
+		if !e.hasField(want.Field, want.Code) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// hasField у ошибки есть ошибка поля field с кодом code
+func (e *Error) hasField(field, code string) bool {
+	for _, f := range e.Fields {
+		if f.Field == field && f.Code == code {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WithDetail возвращает копию ошибки с подробностями конкретного случая
+func (e *Error) WithDetail(format string, args ...any) *Error {
+	c := *e
+	c.Detail = fmt.Sprintf(format, args...)
+	return &c
+}
+
+// WithFields возвращает копию ошибки с добавленными ошибками полей
+func (e *Error) WithFields(fields ...FieldError) *Error {
+	c := *e
+	c.Fields = append(append([]FieldError(nil), e.Fields...), fields...)
+	return &c
+}
+
+// Wrap возвращает копию ошибки с исходной причиной, например ошибкой драйвера БД
+func (e *Error) Wrap(cause error) *Error {
+	c := *e
+	c.cause = cause
+	return &c
+}
+
+// As находит ошибку предметной области в цепочке err
+func As(err error) (*Error, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+
+	return nil, false
+}
+
+// KindOf возвращает вид ошибки, для прочих ошибок KindInternal
+func KindOf(err error) Kind {
+	if e, ok := As(err); ok {
+		return e.Kind
+	}
+
+	return KindInternal
+}
@@ -0,0 +1,102 @@
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestError(t *testing.T) {
+	errPostNotFound := NotFound("post_not_found", "post not found")
+
+	tests := []struct {
+		name     string
+		err      error
+		wantMsg  string
+		wantKind Kind
+		wantIs   bool
+	}{
+		{
+			name:     "sentinel",
+			err:      errPostNotFound,
+			wantMsg:  "post not found",
+			wantKind: KindNotFound,
+			wantIs:   true,
+		},
+		{
+			name:     "wrapped by fmt",
+			err:      fmt.Errorf("blog_service.GetPost: %w", errPostNotFound),
+			wantMsg:  "blog_service.GetPost: post not found",
+			wantKind: KindNotFound,
+			wantIs:   true,
+		},
+		{
+			name:     "copy with detail and cause",
+			err:      errPostNotFound.WithDetail("slug %q", "hello").Wrap(errors.New("no rows")),
+			wantMsg:  `post not found: slug "hello": no rows`,
+			wantKind: KindNotFound,
+			wantIs:   true,
+		},
+		{
+			name:     "same code of another kind",
+			err:      Conflict("post_not_found", "post not found"),
+			wantMsg:  "post not found",
+			wantKind: KindConflict,
+		},
+		{
+			name:     "plain error",
+			err:      errors.New("boom"),
+			wantMsg:  "boom",
+			wantKind: KindInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantMsg, tt.err.Error())
+			assert.Equal(t, tt.wantKind, KindOf(tt.err))
+			assert.Equal(t, tt.wantIs, errors.Is(tt.err, errPostNotFound))
+		})
+	}
+}
+
+func TestError_CopiesDoNotShareState(t *testing.T) {
+	base := Validation("invalid request")
+
+	first := base.WithFields(Field("title", "required", "title is required"))
+	second := base.WithFields(Field("slug", "invalid", "invalid slug"))
+
+	assert.Empty(t, base.Fields)
+	require.Len(t, first.Fields, 1)
+	require.Len(t, second.Fields, 1)
+	assert.Equal(t, "title", first.Fields[0].Field)
+	assert.Equal(t, "slug", second.Fields[0].Field)
+	assert.Equal(t, CodeValidation, second.Code)
+}
+
+func TestError_IsComparesFields(t *testing.T) {
+	errInvalidCode := InvalidField("code", "invalid_code", "two-factor code is invalid")
+
+	tests := []struct {
+		name   string
+		err    error
+		target error
+		want   bool
+	}{
+		{name: "same sentinel", err: fmt.Errorf("op: %w", errInvalidCode), target: errInvalidCode, want: true},
+		{name: "copy with detail", err: errInvalidCode.WithDetail("expired"), target: errInvalidCode, want: true},
+		{name: "other field", err: InvalidField("email", "required", "email is required"), target: errInvalidCode},
+		{name: "same field other code", err: InvalidField("code", "required", "code is required"), target: errInvalidCode},
+		{name: "any validation error", err: InvalidField("email", "required", "email is required"), target: Validation("invalid request"), want: true},
+		{name: "field among several", err: Validation("invalid request", Field("email", "required", "email is required"), Field("code", "invalid_code", "bad code")), target: errInvalidCode, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, errors.Is(tt.err, tt.target))
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Области доступа API-ключей. Публичное чтение постов и галерей доступно без ключа,
+// области ограничивают только маршруты, которые требуют входа
+const (
+	ScopePostsRead      = "posts:read"
+	ScopePostsWrite     = "posts:write"
+	ScopeMediaRead      = "media:read"
+	ScopeMediaWrite     = "media:write"
+	ScopeGalleriesRead  = "galleries:read"
+	ScopeGalleriesWrite = "galleries:write"
+)
+
+// APIKeyScopes все области доступа, которые можно выдать ключу
+var APIKeyScopes = []string{
+	ScopePostsRead,
+	ScopePostsWrite,
+	ScopeMediaRead,
+	ScopeMediaWrite,
+	ScopeGalleriesRead,
+	ScopeGalleriesWrite,
+}
+
+// ValidAPIKeyScope scope есть в APIKeyScopes
+func ValidAPIKeyScope(scope string) bool {
+	return slices.Contains(APIKeyScopes, scope)
+}
+
+// APIKey ключ API пользователя. Запросы с ключом выполняются от имени владельца,
+// но только на маршрутах, область которых выдана ключу
+type APIKey struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"` // Начало ключа, чтобы узнать его в списке
+	Scopes     []string   `json:"scopes"`
+	RateLimit  int        `json:"rate_limit"` // Запросов в минуту
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Active ключ не отозван и не истек к моменту now
+func (k APIKey) Active(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
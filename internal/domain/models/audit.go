@@ -0,0 +1,104 @@
+package models
+
+import (
+	"encoding/json"
+	"premium_caste/internal/lib/pagination"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Действия, которые попадают в журнал аудита
+const (
+	AuditPostCreate  = "post.create"
+	AuditPostUpdate  = "post.update"
+	AuditPostPublish = "post.publish"
+	AuditPostArchive = "post.archive"
+	AuditPostDelete  = "post.delete"
+
+	AuditGalleryCreate = "gallery.create"
+	AuditGalleryUpdate = "gallery.update"
+	AuditGalleryStatus = "gallery.status"
+	AuditGalleryDelete = "gallery.delete"
+
+	AuditMediaUpload = "media.upload"
+	AuditMediaDelete = "media.delete"
+
+	AuditUserRegister       = "user.register"
+	AuditUserLogin          = "user.login"
+	AuditUserLoginFailed    = "user.login_failed"
+	AuditUserProfileUpdate  = "user.profile_update"
+	AuditUserEmailChange    = "user.email_change"
+	AuditUserPasswordChange = "user.password_change"
+	AuditUserPasswordReset  = "user.password_reset"
+	AuditUserRoleChange     = "user.role_change"
+	AuditUserSuspend        = "user.suspend"
+	AuditUserUnsuspend      = "user.unsuspend"
+	AuditUserAnonymize      = "user.anonymize"
+	AuditUserDelete         = "user.delete"
+
+	AuditUserTwoFactorEnable  = "user.2fa_enable"
+	AuditUserTwoFactorDisable = "user.2fa_disable"
+	AuditUserTwoFactorReset   = "user.2fa_reset"
+	AuditUserTwoFactorFailed  = "user.2fa_failed"
+	AuditUserRecoveryCodes    = "user.recovery_codes_regenerate"
+	AuditUserRecoveryCodeUsed = "user.recovery_code_used"
+
+	AuditUserIdentityLink   = "user.identity_link"
+	AuditUserIdentityUnlink = "user.identity_unlink"
+
+	AuditSettingsUpdate = "settings.update"
+
+	AuditAPIKeyCreate = "api_key.create"
+	AuditAPIKeyRevoke = "api_key.revoke"
+)
+
+// Типы объектов, над которыми выполняются действия
+const (
+	AuditTargetPost    = "post"
+	AuditTargetGallery = "gallery"
+	AuditTargetMedia   = "media"
+	AuditTargetUser    = "user"
+	AuditTargetSetting = "setting"
+	AuditTargetAPIKey  = "api_key"
+)
+
+// AuditEntry событие, которое сервис передает в журнал. Инициатор, его адрес и
+// User-Agent берутся из контекста. Before и After сериализуются в JSON
+type AuditEntry struct {
+	Action     string
+	TargetType string
+	TargetID   string
+	Before     any
+	After      any
+}
+
+// AuditEvent запись журнала аудита
+type AuditEvent struct {
+	ID         uuid.UUID       `json:"id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Action     string          `json:"action"`
+	ActorID    *uuid.UUID      `json:"actor_id,omitempty"`
+	APIKeyID   *uuid.UUID      `json:"api_key_id,omitempty"`
+	IP         string          `json:"ip,omitempty"`
+	UserAgent  string          `json:"user_agent,omitempty"`
+	TargetType string          `json:"target_type,omitempty"`
+	TargetID   string          `json:"target_id,omitempty"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+}
+
+// AuditFilter фильтр журнала аудита. Пустые поля не ограничивают выборку
+type AuditFilter struct {
+	Action     string
+	ActorID    *uuid.UUID
+	APIKeyID   *uuid.UUID
+	TargetType string
+	TargetID   string
+	From       *time.Time
+	To         *time.Time
+	After      *pagination.Cursor // Позиция, после которой начинается страница. Если задана, номер страницы игнорируется
+}
+
+// AuditSortOccurred сортировка журнала: сначала новые записи
+const AuditSortOccurred = "occurred"
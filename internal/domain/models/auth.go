@@ -1,11 +1,16 @@
 package models
 
-import "github.com/google/uuid"
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type TokenPair struct {
 	UserID       uuid.UUID `json:"user_id"`
 	AccessToken  string    `json:"access_token"`
 	RefreshToken string    `json:"refresh_token"`
+	CSRFToken    string    `json:"csrf_token"` // Токен сеанса для заголовка X-CSRF-Token в запросах с cookie
 }
 
 type TokenMeta struct {
@@ -14,3 +19,31 @@ type TokenMeta struct {
 	IssuedAt  int64  `json:"issued_at"`
 	ExpiresAt int64  `json:"expires_at"`
 }
+
+// LoginChallenge незавершенный вход: пароль проверен, ожидается второй фактор
+type LoginChallenge struct {
+	ID         string    `json:"challenge_id"`
+	UserID     uuid.UUID `json:"-"`
+	Enrollment bool      `json:"enrollment_required"` // Политика требует 2FA, а пользователь ее еще не настроил
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// LoginResult результат шага входа: пара токенов или запрос второго фактора
+type LoginResult struct {
+	Tokens        *TokenPair
+	Challenge     *LoginChallenge
+	RecoveryCodes []string // Выдаются один раз, если 2FA настроена при входе
+}
+
+// SettingSecurity ключ настроек безопасности в app_settings
+const SettingSecurity = "security"
+
+// SecurityPolicy настройки безопасности, которые меняют администраторы
+type SecurityPolicy struct {
+	RequireTwoFactor bool `json:"require_two_factor"` // 2FA обязательна для администраторов и редакторов
+}
+
+// TwoFactorRequired политика требует второй фактор для роли
+func (p SecurityPolicy) TwoFactorRequired(role string) bool {
+	return p.RequireTwoFactor && (role == RoleAdmin || role == RoleEditor)
+}
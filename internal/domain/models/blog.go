@@ -1,6 +1,7 @@
 package models
 
 import (
+	"premium_caste/internal/lib/pagination"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,15 +13,21 @@ type BlogPost struct {
 	Slug              string                 `db:"slug" json:"slug"`
 	Excerpt           string                 `db:"excerpt" json:"excerpt,omitempty"`
 	Content           string                 `db:"content" json:"content"`
+	ContentFormat     string                 `db:"content_format" json:"content_format"` // markdown, html, plain
+	ContentHTML       string                 `db:"content_html" json:"content_html"`     // Отрендеренный и очищенный HTML
 	FeaturedImageID   uuid.UUID              `db:"featured_image_id" json:"featured_image_id,omitempty"`
 	FeaturedImagePath *string                `json:"featured_image_path"`
 	AuthorID          uuid.UUID              `db:"author_id" json:"author_id"`
+	CategoryID        *uuid.UUID             `db:"category_id" json:"category_id,omitempty"`
 	Status            string                 `db:"status" json:"status"`
 	PublishedAt       *time.Time             `db:"published_at" json:"published_at,omitempty"`
 	CreatedAt         time.Time              `db:"created_at" json:"created_at"`
 	UpdatedAt         time.Time              `db:"updated_at" json:"updated_at"`
 	Metadata          map[string]any         `db:"metadata" json:"metadata,omitempty"`
+	CommentsClosed    bool                   `db:"comments_closed" json:"comments_closed"`
+	CommentCount      int                    `json:"comment_count"` // Количество одобренных комментариев
 	MediaGroups       map[string][]MediaItem `json:"media_groups"`
+	Tags              []BlogTag              `json:"tags"`
 }
 
 type PostMediaGroup struct {
@@ -36,3 +43,44 @@ type MediaItem struct {
 	Position    int       `json:"position"`
 	GroupID     uuid.UUID `json:"group_id"`
 }
+
+// BlogCategory категория постов блога, может быть вложенной в другую категорию
+type BlogCategory struct {
+	ID          uuid.UUID  `db:"id" json:"id"`
+	ParentID    *uuid.UUID `db:"parent_id" json:"parent_id,omitempty"`
+	Name        string     `db:"name" json:"name"`
+	Slug        string     `db:"slug" json:"slug"`
+	Description string     `db:"description" json:"description,omitempty"`
+	Position    int        `db:"position" json:"position"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+// BlogTag тег из общего справочника тегов постов
+type BlogTag struct {
+	ID   uuid.UUID `db:"id" json:"id"`
+	Name string    `db:"name" json:"name"`
+	Slug string    `db:"slug" json:"slug"`
+}
+
+// TagCount тег с количеством постов, в которых он используется
+type TagCount struct {
+	Name  string `json:"name"`
+	Slug  string `json:"slug"`
+	Count int    `json:"count"`
+}
+
+// BlogPostFilter параметры фильтрации списка постов
+type BlogPostFilter struct {
+	Status       string     // "all", "draft", "published", "archived"
+	CategoryID   *uuid.UUID // Категория (вместе со всеми подкатегориями)
+	CategorySlug string     // Альтернатива CategoryID
+	Tags         []string   // Slug'и тегов, пост должен содержать любой из них
+	AuthorID     *uuid.UUID
+	DateFrom     *time.Time         // Нижняя граница даты публикации (или создания для черновиков)
+	DateTo       *time.Time         // Верхняя граница даты публикации (или создания для черновиков)
+	After        *pagination.Cursor // Позиция, после которой начинается страница. Если задана, номер страницы игнорируется
+}
+
+// BlogPostSortCreated единственная сортировка списка постов: сначала новые по дате создания
+const BlogPostSortCreated = "created"
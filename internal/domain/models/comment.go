@@ -0,0 +1,45 @@
+package models
+
+import (
+	"premium_caste/internal/lib/pagination"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Статусы модерации комментариев
+const (
+	CommentStatusPending  = "pending"
+	CommentStatusApproved = "approved"
+	CommentStatusSpam     = "spam"
+	CommentStatusRejected = "rejected"
+)
+
+type Comment struct {
+	ID          uuid.UUID  `db:"id" json:"id"`
+	PostID      uuid.UUID  `db:"post_id" json:"post_id"`
+	UserID      uuid.UUID  `db:"user_id" json:"user_id"`
+	ParentID    *uuid.UUID `db:"parent_id" json:"parent_id,omitempty"`
+	Content     string     `db:"content" json:"content"`
+	Status      string     `db:"status" json:"status"`
+	ModeratedBy *uuid.UUID `db:"moderated_by" json:"moderated_by,omitempty"`
+	ModeratedAt *time.Time `db:"moderated_at" json:"moderated_at,omitempty"`
+	CreatedAt   time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time  `db:"updated_at" json:"updated_at"`
+	AuthorName  string     `json:"author_name"` // Имя автора из users
+}
+
+// CommentFilter фильтр очереди модерации
+type CommentFilter struct {
+	Status string             // Пусто для всех статусов
+	After  *pagination.Cursor // Позиция, после которой начинается страница. Если задана, номер страницы игнорируется
+}
+
+// CommentSortCreated сортировка очереди модерации: сначала новые
+const CommentSortCreated = "created"
+
+// PostCommentSettings состояние поста, влияющее на возможность комментирования
+type PostCommentSettings struct {
+	Status         string
+	CommentsClosed bool
+}
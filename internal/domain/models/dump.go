@@ -0,0 +1,10 @@
+package models
+
+import "encoding/json"
+
+// TableDump строки одной таблицы для переноса между базами. Rows - JSON-массив
+// объектов, ключи которых совпадают с именами столбцов
+type TableDump struct {
+	Name string          `json:"name"`
+	Rows json.RawMessage `json:"rows"`
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"premium_caste/internal/lib/pagination"
 	"time"
 
 	"github.com/google/uuid"
@@ -8,17 +9,63 @@ import (
 
 // Gallery представляет собой модель галереи
 type Gallery struct {
-	ID              uuid.UUID   `json:"id"`                // Уникальный идентификатор галереи
-	Title           string      `json:"title"`             // Заголовок галереи
-	Slug            string      `json:"slug"`              // Уникальный URL-идентификатор
-	Description     string      `json:"description"`       // Описание галереи
-	Images          []string    `json:"images"`            // Массив путей/URL изображений
-	CoverImageIndex int         `json:"cover_image_index"` // Индекс обложки в массиве images
-	AuthorID        uuid.UUID   `json:"author_id"`         // ID автора галереи
-	Status          string      `json:"status"`            // Статус галереи (например, "draft", "published")
-	PublishedAt     *time.Time  `json:"published_at"`      // Дата публикации (может быть nil)
-	CreatedAt       time.Time   `json:"created_at"`        // Дата создания
-	UpdatedAt       time.Time   `json:"updated_at"`        // Дата последнего обновления
-	Metadata        interface{} `json:"metadata"`          // Дополнительные метаданные (JSONB)
-	Tags            []string    `json:"tags"`              // Массив тегов
+	ID              uuid.UUID     `json:"id"`                // Уникальный идентификатор галереи
+	Title           string        `json:"title"`             // Заголовок галереи
+	Slug            string        `json:"slug"`              // Уникальный URL-идентификатор
+	Description     string        `json:"description"`       // Описание галереи
+	Images          []string      `json:"images"`            // Пути изображений в порядке items, заполняется из gallery_items
+	CoverImageIndex int           `json:"cover_image_index"` // Индекс обложки в массиве images
+	CoverMediaID    *uuid.UUID    `json:"cover_media_id"`    // Обложка. При обновлении nil - не менять, нулевой UUID - сбросить
+	Items           []GalleryItem `json:"items"`             // Изображения галереи. При обновлении nil - не менять
+	AuthorID        uuid.UUID     `json:"author_id"`         // ID автора галереи
+	Status          string        `json:"status"`            // Статус галереи (например, "draft", "published")
+	PublishedAt     *time.Time    `json:"published_at"`      // Дата публикации (может быть nil)
+	CreatedAt       time.Time     `json:"created_at"`        // Дата создания
+	UpdatedAt       time.Time     `json:"updated_at"`        // Дата последнего обновления
+	Metadata        interface{}   `json:"metadata"`          // Дополнительные метаданные (JSONB)
+	Tags            []string      `json:"tags"`              // Массив тегов
+}
+
+// GalleryItem изображение галереи, ссылающееся на запись media
+type GalleryItem struct {
+	GalleryID   uuid.UUID `json:"gallery_id"`
+	MediaID     uuid.UUID `json:"media_id"`
+	Position    int       `json:"position"`
+	Caption     string    `json:"caption"`
+	AltText     string    `json:"alt_text"`
+	StoragePath string    `json:"storage_path"` // Путь к файлу из media
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Порядок сортировки списка галерей
+const (
+	GallerySortCreated   = "created"   // Сначала новые по дате создания
+	GallerySortPublished = "published" // Сначала новые по дате публикации
+	GallerySortTitle     = "title"     // По названию
+)
+
+// GalleryFilter параметры выборки списка галерей
+type GalleryFilter struct {
+	Status       string             // "all", "draft", "published", "archived"
+	Tags         []string           // Теги галереи
+	MatchAllTags bool               // true - галерея должна содержать все теги, false - любой из них
+	AuthorID     *uuid.UUID         // Автор галереи
+	DateFrom     *time.Time         // Нижняя граница даты публикации (или создания для черновиков)
+	DateTo       *time.Time         // Верхняя граница даты публикации (или создания для черновиков)
+	Query        string             // Подстрока в названии без учета регистра
+	Sort         string             // GallerySortCreated (по умолчанию), GallerySortPublished или GallerySortTitle
+	After        *pagination.Cursor // Позиция, после которой начинается страница. Если задана, номер страницы игнорируется
+}
+
+// GalleryTag тег галерей с количеством галерей, в которых он используется
+type GalleryTag struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// GalleryTagAlias прежнее имя тега, которое указывает на актуальный тег
+type GalleryTagAlias struct {
+	Alias     string    `json:"alias"`
+	Tag       string    `json:"tag"`
+	CreatedAt time.Time `json:"created_at"`
 }
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserIdentity учетная запись внешнего провайдера OpenID Connect, привязанная к пользователю
+type UserIdentity struct {
+	Provider    string     `json:"provider"`
+	Subject     string     `json:"-"`
+	UserID      uuid.UUID  `json:"user_id"`
+	Email       string     `json:"email"` // Email у провайдера на момент привязки
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+}
+
+// OIDCState незавершенный вход через провайдера: ждет возврата пользователя со страницы провайдера
+type OIDCState struct {
+	Provider string
+	Verifier string     // code_verifier PKCE
+	Nonce    string     // Должен вернуться в ID token
+	LinkTo   *uuid.UUID // Привязать учетную запись к этому пользователю вместо входа
+}
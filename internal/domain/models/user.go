@@ -1,19 +1,75 @@
 package models
 
 import (
+	"premium_caste/internal/lib/pagination"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// Роли пользователей. Администратор управляет учетными записями, редактор - контентом
+const (
+	RoleUser   = "user"
+	RoleEditor = "editor"
+	RoleAdmin  = "admin"
+)
+
+// Состояния учетной записи для фильтра списка пользователей
+const (
+	UserStatusActive     = "active"
+	UserStatusSuspended  = "suspended"
+	UserStatusAnonymized = "anonymized"
+)
+
 type User struct {
-	ID               uuid.UUID `db:"id" json:"id"`
-	Name             string    `db:"name" json:"name"`
-	Email            string    `db:"email" json:"email"`
-	Phone            string    `db:"phone" json:"phone"`
-	Password         []byte    `db:"password" json:"password,omitempty"`
-	IsAdmin          bool      `db:"is_admin" json:"is_admin"`
-	BasketID         uuid.UUID `db:"basket_id" json:"basket_id"`
-	RegistrationDate time.Time `db:"registration_date,omitempty" json:"registration_date,omitempty"`
-	LastLogin        time.Time `db:"last_login,omitempty" json:"last_login,omitempty"`
+	ID               uuid.UUID  `db:"id" json:"id"`
+	Name             string     `db:"name" json:"name"`
+	Email            string     `db:"email" json:"email"`
+	Phone            string     `db:"phone" json:"phone"`
+	Password         []byte     `db:"password" json:"-"` // Хеш пароля никогда не сериализуется в ответы
+	PendingEmail     string     `db:"pending_email" json:"pending_email,omitempty"`
+	IsAdmin          bool       `db:"is_admin" json:"is_admin"`
+	Role             string     `db:"role" json:"role"`
+	BasketID         uuid.UUID  `db:"basket_id" json:"basket_id"`
+	RegistrationDate time.Time  `db:"registration_date,omitempty" json:"registration_date,omitempty"`
+	LastLogin        time.Time  `db:"last_login,omitempty" json:"last_login,omitempty"`
+	SuspendedAt      *time.Time `db:"suspended_at" json:"suspended_at,omitempty"`
+	SuspendedBy      *uuid.UUID `db:"suspended_by" json:"suspended_by,omitempty"`
+	SuspensionReason string     `db:"suspension_reason" json:"suspension_reason,omitempty"`
+	AnonymizedAt     *time.Time `db:"anonymized_at" json:"anonymized_at,omitempty"`
+	UpdatedAt        *time.Time `db:"updated_at" json:"updated_at,omitempty"`
+	UpdatedBy        *uuid.UUID `db:"updated_by" json:"updated_by,omitempty"`
+	TwoFactorEnabled bool       `db:"-" json:"two_factor_enabled"` // Вход требует код TOTP или код восстановления
 }
+
+// IsSuspended учетная запись заблокирована администратором
+func (u User) IsSuspended() bool {
+	return u.SuspendedAt != nil
+}
+
+// Roles роли, которые записываются в access-токен. Флаг is_admin дает роль администратора
+// и учетным записям, созданным командой create-admin до появления ролей
+func (u User) Roles() []string {
+	role := u.Role
+	if role == "" {
+		role = RoleUser
+	}
+
+	roles := []string{role}
+	if u.IsAdmin && role != RoleAdmin {
+		roles = append(roles, RoleAdmin)
+	}
+
+	return roles
+}
+
+// UserFilter параметры списка пользователей в админке
+type UserFilter struct {
+	Query  string // Подстрока имени, email или телефона
+	Role   string
+	Status string             // active, suspended, anonymized или пусто для всех
+	After  *pagination.Cursor // Позиция, после которой начинается страница. Если задана, номер страницы игнорируется
+}
+
+// UserSortRegistered сортировка списка пользователей: сначала новые по дате регистрации
+const UserSortRegistered = "registered"
@@ -0,0 +1,41 @@
+// Package actor передает через контекст сведения о том, кто выполняет запрос.
+// HTTP-слой заполняет их в middleware, сервисы читают при записи журнала аудита
+package actor
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Actor инициатор действия. UserID пустой для анонимных запросов и служебных команд
+type Actor struct {
+	UserID    uuid.UUID
+	IP        string
+	UserAgent string
+}
+
+type ctxKey struct{}
+
+// FromContext возвращает инициатора, сохраненного в ctx
+func FromContext(ctx context.Context) Actor {
+	a, _ := ctx.Value(ctxKey{}).(Actor)
+	return a
+}
+
+// WithUser сохраняет в ctx пользователя, сохраняя адрес клиента
+func WithUser(ctx context.Context, userID uuid.UUID) context.Context {
+	a := FromContext(ctx)
+	a.UserID = userID
+
+	return context.WithValue(ctx, ctxKey{}, a)
+}
+
+// WithClient сохраняет в ctx адрес и User-Agent клиента
+func WithClient(ctx context.Context, ip, userAgent string) context.Context {
+	a := FromContext(ctx)
+	a.IP = ip
+	a.UserAgent = userAgent
+
+	return context.WithValue(ctx, ctxKey{}, a)
+}
@@ -0,0 +1,182 @@
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"premium_caste/internal/lib/slug"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+)
+
+// Форматы исходного текста поста
+const (
+	FormatMarkdown = "markdown"
+	FormatHTML     = "html"
+	FormatPlain    = "plain"
+)
+
+// wordsPerMinute средняя скорость чтения для оценки времени чтения
+const wordsPerMinute = 200
+
+var (
+	md = goldmark.New(
+		goldmark.WithExtensions(extension.GFM),
+	)
+
+	// policy строгая политика очистки: только разметка текста, ссылки и изображения
+	policy = newPolicy()
+
+	headingRe = regexp.MustCompile(`(?is)<h([1-6])([^>]*)>(.*?)</h[1-6]>`)
+	idAttrRe  = regexp.MustCompile(`(?i)\sid="([^"]*)"`)
+	tagRe     = regexp.MustCompile(`(?s)<[^>]*>`)
+)
+
+// Heading заголовок в оглавлении
+type Heading struct {
+	Level int    `json:"level"`
+	ID    string `json:"id"`
+	Text  string `json:"text"`
+}
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.UGCPolicy()
+	p.AllowAttrs("id").Matching(regexp.MustCompile(`^[a-z0-9-]+$`)).OnElements("h1", "h2", "h3", "h4", "h5", "h6")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[a-zA-Z0-9+#-]+$`)).OnElements("code")
+	p.RequireNoFollowOnLinks(true)
+	p.AddTargetBlankToFullyQualifiedLinks(true)
+	return p
+}
+
+// ValidFormat проверяет, что формат поддерживается
+func ValidFormat(format string) bool {
+	switch format {
+	case FormatMarkdown, FormatHTML, FormatPlain:
+		return true
+	}
+	return false
+}
+
+// Render преобразует исходный текст в безопасный HTML. У заголовков проставляются
+// id, чтобы на них можно было ссылаться из оглавления.
+func Render(format, source string) (string, error) {
+	var raw string
+
+	switch format {
+	case FormatMarkdown:
+		var buf bytes.Buffer
+		if err := md.Convert([]byte(source), &buf); err != nil {
+			return "", fmt.Errorf("failed to render markdown: %w", err)
+		}
+		raw = buf.String()
+	case FormatHTML:
+		raw = source
+	case FormatPlain:
+		raw = plainToHTML(source)
+	default:
+		return "", fmt.Errorf("unsupported content format: %s", format)
+	}
+
+	return addHeadingIDs(policy.Sanitize(raw)), nil
+}
+
+// PlainText возвращает текст HTML без разметки с нормализованными пробелами
+func PlainText(htmlContent string) string {
+	text := tagRe.ReplaceAllString(htmlContent, " ")
+	return strings.Join(strings.Fields(html.UnescapeString(text)), " ")
+}
+
+// Excerpt формирует краткое описание длиной не более maxLen символов, обрезая по границе слова
+func Excerpt(htmlContent string, maxLen int) string {
+	text := PlainText(htmlContent)
+	if utf8.RuneCountInString(text) <= maxLen {
+		return text
+	}
+
+	runes := []rune(text)
+	cut := string(runes[:maxLen-1])
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		cut = cut[:i]
+	}
+
+	return strings.TrimRight(cut, " ,.;:-") + "…"
+}
+
+// ReadingTime оценивает время чтения в минутах (не меньше одной минуты для непустого текста)
+func ReadingTime(htmlContent string) int {
+	words := len(strings.Fields(PlainText(htmlContent)))
+	if words == 0 {
+		return 0
+	}
+	return (words + wordsPerMinute - 1) / wordsPerMinute
+}
+
+// TableOfContents возвращает заголовки отрендеренного HTML в порядке следования
+func TableOfContents(htmlContent string) []Heading {
+	matches := headingRe.FindAllStringSubmatch(htmlContent, -1)
+	headings := make([]Heading, 0, len(matches))
+
+	for _, m := range matches {
+		id := idAttrRe.FindStringSubmatch(m[2])
+		if id == nil {
+			continue
+		}
+
+		level, _ := strconv.Atoi(m[1])
+		headings = append(headings, Heading{
+			Level: level,
+			ID:    id[1],
+			Text:  PlainText(m[3]),
+		})
+	}
+
+	return headings
+}
+
+// addHeadingIDs проставляет заголовкам уникальные id на основе их текста
+func addHeadingIDs(htmlContent string) string {
+	used := make(map[string]int)
+
+	return headingRe.ReplaceAllStringFunc(htmlContent, func(h string) string {
+		m := headingRe.FindStringSubmatch(h)
+		attrs := idAttrRe.ReplaceAllString(m[2], "")
+
+		id := slug.Make(PlainText(m[3]))
+		if id == "" {
+			id = "section"
+		}
+		if n := used[id]; n > 0 {
+			used[id] = n + 1
+			id = fmt.Sprintf("%s-%d", id, n)
+		} else {
+			used[id] = 1
+		}
+
+		return fmt.Sprintf(`<h%s id="%s"%s>%s</h%s>`, m[1], id, attrs, m[3], m[1])
+	})
+}
+
+// plainToHTML оборачивает абзацы простого текста в <p>, сохраняя переносы строк
+func plainToHTML(source string) string {
+	source = strings.ReplaceAll(source, "\r\n", "\n")
+
+	var b strings.Builder
+	for _, paragraph := range strings.Split(source, "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		b.WriteString("<p>")
+		b.WriteString(strings.ReplaceAll(html.EscapeString(paragraph), "\n", "<br>"))
+		b.WriteString("</p>\n")
+	}
+
+	return b.String()
+}
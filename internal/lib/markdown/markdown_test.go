@@ -0,0 +1,92 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name        string
+		format      string
+		source      string
+		contains    []string
+		notContains []string
+		wantErr     bool
+	}{
+		{
+			name:     "markdown with headings",
+			format:   FormatMarkdown,
+			source:   "# Введение\n\nТекст **жирный**\n\n## Детали",
+			contains: []string{`<h1 id="vvedenie">Введение</h1>`, "<strong>жирный</strong>", `<h2 id="detali">`},
+		},
+		{
+			name:        "markdown raw html is stripped",
+			format:      FormatMarkdown,
+			source:      "Привет <script>alert(1)</script>",
+			notContains: []string{"<script>"},
+		},
+		{
+			name:        "html is sanitized",
+			format:      FormatHTML,
+			source:      `<p onclick="x()">ok</p><img src="javascript:alert(1)"><a href="https://example.com">link</a>`,
+			contains:    []string{"<p>ok</p>", `rel="nofollow noopener"`},
+			notContains: []string{"onclick", "javascript:"},
+		},
+		{
+			name:     "plain text escaped",
+			format:   FormatPlain,
+			source:   "a < b\nline\n\nnext",
+			contains: []string{"<p>a &lt; b<br>line</p>", "<p>next</p>"},
+		},
+		{
+			name:    "unknown format",
+			format:  "rtf",
+			source:  "x",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Render(tt.format, tt.source)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			for _, s := range tt.contains {
+				assert.Contains(t, got, s)
+			}
+			for _, s := range tt.notContains {
+				assert.NotContains(t, got, s)
+			}
+		})
+	}
+}
+
+func TestTableOfContents(t *testing.T) {
+	html, err := Render(FormatMarkdown, "## Шаг\n\ntext\n\n### Шаг\n\n## Итог")
+	require.NoError(t, err)
+
+	toc := TableOfContents(html)
+	require.Len(t, toc, 3)
+	assert.Equal(t, Heading{Level: 2, ID: "shag", Text: "Шаг"}, toc[0])
+	assert.Equal(t, Heading{Level: 3, ID: "shag-1", Text: "Шаг"}, toc[1])
+	assert.Equal(t, "itog", toc[2].ID)
+}
+
+func TestExcerptAndReadingTime(t *testing.T) {
+	html := "<p>" + strings.Repeat("слово ", 450) + "</p>"
+
+	excerpt := Excerpt(html, 50)
+	assert.LessOrEqual(t, len([]rune(excerpt)), 50)
+	assert.True(t, strings.HasSuffix(excerpt, "…"))
+
+	assert.Equal(t, "короткий текст", Excerpt("<p>короткий <b>текст</b></p>", 50))
+	assert.Equal(t, 3, ReadingTime(html))
+	assert.Equal(t, 0, ReadingTime(""))
+}
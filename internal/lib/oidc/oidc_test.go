@@ -0,0 +1,90 @@
+package oidc_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"premium_caste/internal/lib/oidc"
+	"premium_caste/internal/lib/oidc/oidctest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	clientID    = "premium-caste"
+	redirectURL = "http://localhost:3000/auth/callback"
+)
+
+func TestProvider_AuthCodeURL(t *testing.T) {
+	issuer := oidctest.NewIssuer(t)
+	provider := oidc.NewProvider(issuer.Config(clientID, redirectURL), nil)
+
+	authURL, err := provider.AuthCodeURL(context.Background(), "state-1", "nonce-1", "verifier")
+	require.NoError(t, err)
+
+	u, err := url.Parse(authURL)
+	require.NoError(t, err)
+	assert.Equal(t, issuer.URL+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+
+	query := u.Query()
+	assert.Equal(t, "code", query.Get("response_type"))
+	assert.Equal(t, clientID, query.Get("client_id"))
+	assert.Equal(t, redirectURL, query.Get("redirect_uri"))
+	assert.Equal(t, "openid email profile", query.Get("scope"))
+	assert.Equal(t, "state-1", query.Get("state"))
+	assert.Equal(t, "nonce-1", query.Get("nonce"))
+	assert.Equal(t, oidc.Challenge("verifier"), query.Get("code_challenge"))
+	assert.Equal(t, "S256", query.Get("code_challenge_method"))
+}
+
+func TestProvider_Exchange(t *testing.T) {
+	ctx := context.Background()
+	identity := oidc.Identity{Subject: "user-1", Email: "user@example.com", EmailVerified: true, Name: "User"}
+
+	tests := []struct {
+		name     string
+		claims   map[string]any
+		verifier string
+		nonce    string
+		wantErr  bool
+	}{
+		{name: "valid token", verifier: "verifier", nonce: "nonce"},
+		{name: "wrong pkce verifier", verifier: "other", nonce: "nonce", wantErr: true},
+		{name: "nonce mismatch", verifier: "verifier", nonce: "other", wantErr: true},
+		{name: "wrong audience", claims: map[string]any{"aud": "someone-else"}, verifier: "verifier", nonce: "nonce", wantErr: true},
+		{name: "wrong issuer", claims: map[string]any{"iss": "https://evil.example.com"}, verifier: "verifier", nonce: "nonce", wantErr: true},
+		{name: "expired", claims: map[string]any{"exp": 1}, verifier: "verifier", nonce: "nonce", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			issuer := oidctest.NewIssuer(t)
+			issuer.Claims = tt.claims
+			provider := oidc.NewProvider(issuer.Config(clientID, redirectURL), nil)
+
+			authURL, err := provider.AuthCodeURL(ctx, "state", "nonce", "verifier")
+			require.NoError(t, err)
+			code, state := issuer.Authorize(t, authURL, identity)
+			assert.Equal(t, "state", state)
+
+			got, err := provider.Exchange(ctx, code, tt.verifier, tt.nonce)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, identity, got)
+		})
+	}
+}
+
+func TestProvider_IssuerMismatch(t *testing.T) {
+	issuer := oidctest.NewIssuer(t)
+	cfg := issuer.Config(clientID, redirectURL)
+	cfg.Issuer += "/"
+
+	_, err := oidc.NewProvider(cfg, nil).AuthCodeURL(context.Background(), "state", "nonce", "verifier")
+	assert.ErrorContains(t, err, "issuer mismatch")
+}
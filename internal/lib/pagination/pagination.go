@@ -0,0 +1,193 @@
+// Package pagination содержит общую для всех списков логику постраничного вывода:
+// нормализацию параметров, подсчет записей с учетом фильтров, непрозрачные курсоры
+// для keyset-пагинации и заголовок Link.
+package pagination
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"premium_caste/internal/storage"
+	"strconv"
+	"strings"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+const (
+	DefaultPerPage = 10
+	MaxPerPage     = 100
+)
+
+// Normalize приводит номер страницы и ее размер к допустимым значениям
+func Normalize(page, perPage int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if perPage < 1 || perPage > MaxPerPage {
+		perPage = DefaultPerPage
+	}
+
+	return page, perPage
+}
+
+// Meta описание страницы списка, которое встраивается в ответы API
+type Meta struct {
+	Total      int    `json:"total"`                 // Количество записей, удовлетворяющих фильтру
+	Page       int    `json:"page"`                  // Номер страницы, при переходе по курсору не используется
+	PerPage    int    `json:"per_page"`              // Размер страницы
+	TotalPages int    `json:"total_pages"`           // Количество страниц
+	NextCursor string `json:"next_cursor,omitempty"` // Курсор следующей страницы, пусто на последней
+}
+
+// NewMeta заполняет описание страницы. Курсор следующей страницы задается отдельно
+func NewMeta(total, page, perPage int) Meta {
+	meta := Meta{Total: total, Page: page, PerPage: perPage}
+	if perPage > 0 {
+		meta.TotalPages = (total + perPage - 1) / perPage
+	}
+
+	return meta
+}
+
+// SetNextCursor заполняет NextCursor, если после страницы из count записей есть еще записи.
+// byCursor - страница получена по курсору, тогда ее номер неизвестен и конец списка
+// определяется только по неполной странице. last возвращает позицию последней записи
+func (m *Meta) SetNextCursor(count int, byCursor bool, last func() Cursor) {
+	if count == 0 || count < m.PerPage {
+		return
+	}
+	if !byCursor && m.Page*m.PerPage >= m.Total {
+		return
+	}
+
+	m.NextCursor = last().Encode()
+}
+
+// Cursor позиция в списке для постраничного вывода без OFFSET: значение ключа
+// сортировки последней записи страницы и ее ID, который разрешает равенство ключей
+type Cursor struct {
+	Sort string    `json:"s"`
+	ID   uuid.UUID `json:"id"`
+	Time time.Time `json:"t,omitempty"` // Для сортировок по дате
+	Text string    `json:"n,omitempty"` // Для сортировок по строке
+}
+
+// Encode возвращает курсор в виде непрозрачной строки для next_cursor
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor разбирает строку курсора. Курсор действителен только для той же сортировки,
+// иначе возвращается storage.ErrInvalidCursor
+func DecodeCursor(value, sort string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, storage.ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.ID == uuid.Nil || cursor.Sort != sort {
+		return nil, storage.ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+// Keyset порядок выборки списка
+type Keyset struct {
+	Key  string // SQL-выражение ключа сортировки
+	ID   string // Уникальный столбец, по которому упорядочиваются записи с равным ключом
+	Desc bool   // Сортировка по убыванию
+	Text bool   // Ключ строковый: позиция берется из Cursor.Text, иначе из Cursor.Time
+}
+
+// Apply добавляет в запрос сортировку и ограничение страницы. Если задан курсор,
+// страница начинается сразу после него, иначе пропускаются предыдущие page-1 страниц
+func (k Keyset) Apply(builder sq.SelectBuilder, after *Cursor, page, perPage int) sq.SelectBuilder {
+	order, cmp := "ASC", ">"
+	if k.Desc {
+		order, cmp = "DESC", "<"
+	}
+
+	builder = builder.
+		OrderBy(k.Key+" "+order, k.ID+" "+order).
+		Limit(uint64(perPage))
+
+	if after == nil {
+		return builder.Offset(uint64((page - 1) * perPage))
+	}
+
+	var position any = after.Time
+	if k.Text {
+		position = after.Text
+	}
+
+	return builder.Where("("+k.Key+", "+k.ID+") "+cmp+" (?, ?)", position, after.ID)
+}
+
+// Querier выполняет запрос, возвращающий одну строку. Подходят pgxpool.Pool и pgx.Tx
+type Querier interface {
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// Count выполняет запрос COUNT(*) с теми же условиями, что и у выборки страницы
+func Count(ctx context.Context, db Querier, builder sq.SelectBuilder) (int, error) {
+	query, args, err := builder.ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("failed to build count query: %w", err)
+	}
+
+	var count int
+	if err := db.QueryRow(ctx, query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count rows: %w", err)
+	}
+
+	return count, nil
+}
+
+// Links формирует значение заголовка Link (RFC 8288) для страницы списка.
+// Ссылки строятся от адреса запроса u с сохранением остальных параметров.
+// При переходе по курсору номера страниц не имеют смысла, поэтому
+// отдаются только first и next
+func Links(u *url.URL, meta Meta) string {
+	links := make([]string, 0, 4)
+	add := func(rel string, set func(url.Values)) {
+		query := u.Query()
+		query.Del("page")
+		query.Del("cursor")
+		set(query)
+
+		link := url.URL{Path: u.Path, RawQuery: query.Encode()}
+		links = append(links, fmt.Sprintf(`<%s>; rel="%s"`, link.String(), rel))
+	}
+	page := func(n int) func(url.Values) {
+		return func(q url.Values) { q.Set("page", strconv.Itoa(n)) }
+	}
+
+	cursorMode := u.Query().Get("cursor") != ""
+
+	add("first", page(1))
+	if !cursorMode && meta.Page > 1 {
+		add("prev", page(min(meta.Page-1, max(meta.TotalPages, 1))))
+	}
+
+	switch {
+	case meta.NextCursor != "":
+		add("next", func(q url.Values) { q.Set("cursor", meta.NextCursor) })
+	case !cursorMode && meta.Page < meta.TotalPages:
+		add("next", page(meta.Page+1))
+	}
+
+	if !cursorMode {
+		add("last", page(max(meta.TotalPages, 1)))
+	}
+
+	return strings.Join(links, ", ")
+}
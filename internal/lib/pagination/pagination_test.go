@@ -0,0 +1,122 @@
+package pagination
+
+import (
+	"net/url"
+	"premium_caste/internal/storage"
+	"testing"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name                  string
+		page, perPage         int
+		wantPage, wantPerPage int
+	}{
+		{name: "valid", page: 3, perPage: 20, wantPage: 3, wantPerPage: 20},
+		{name: "zero page", page: 0, perPage: 20, wantPage: 1, wantPerPage: 20},
+		{name: "too large page size", page: 1, perPage: MaxPerPage + 1, wantPage: 1, wantPerPage: DefaultPerPage},
+		{name: "negative page size", page: 1, perPage: -1, wantPage: 1, wantPerPage: DefaultPerPage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, perPage := Normalize(tt.page, tt.perPage)
+			assert.Equal(t, tt.wantPage, page)
+			assert.Equal(t, tt.wantPerPage, perPage)
+		})
+	}
+}
+
+func TestCursor(t *testing.T) {
+	cursor := Cursor{Sort: "created", ID: uuid.New(), Time: time.Date(2024, 5, 1, 12, 0, 0, 123000, time.UTC)}
+
+	decoded, err := DecodeCursor(cursor.Encode(), "created")
+	require.NoError(t, err)
+	assert.Equal(t, cursor.ID, decoded.ID)
+	assert.True(t, cursor.Time.Equal(decoded.Time))
+
+	_, err = DecodeCursor(cursor.Encode(), "title")
+	assert.ErrorIs(t, err, storage.ErrInvalidCursor)
+
+	_, err = DecodeCursor("not-a-cursor", "created")
+	assert.ErrorIs(t, err, storage.ErrInvalidCursor)
+}
+
+func TestMeta_SetNextCursor(t *testing.T) {
+	last := func() Cursor { return Cursor{Sort: "created", ID: uuid.New()} }
+
+	tests := []struct {
+		name     string
+		meta     Meta
+		count    int
+		byCursor bool
+		wantNext bool
+	}{
+		{name: "more pages", meta: NewMeta(25, 1, 10), count: 10, wantNext: true},
+		{name: "last full page", meta: NewMeta(20, 2, 10), count: 10},
+		{name: "partial page", meta: NewMeta(25, 3, 10), count: 5},
+		{name: "full page by cursor", meta: NewMeta(25, 1, 10), count: 10, byCursor: true, wantNext: true},
+		{name: "empty page", meta: NewMeta(0, 1, 10)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.meta.SetNextCursor(tt.count, tt.byCursor, last)
+			assert.Equal(t, tt.wantNext, tt.meta.NextCursor != "")
+		})
+	}
+}
+
+func TestKeyset_Apply(t *testing.T) {
+	builder := sq.StatementBuilder.PlaceholderFormat(sq.Dollar).Select("id").From("items i")
+	keyset := Keyset{Key: "i.created_at", ID: "i.id", Desc: true}
+
+	query, args, err := keyset.Apply(builder, nil, 3, 10).ToSql()
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT id FROM items i ORDER BY i.created_at DESC, i.id DESC LIMIT 10 OFFSET 20", query)
+	assert.Empty(t, args)
+
+	after := &Cursor{ID: uuid.New(), Text: "Autumn"}
+	query, args, err = Keyset{Key: "i.title", ID: "i.id", Text: true}.Apply(builder, after, 3, 10).ToSql()
+	require.NoError(t, err)
+	assert.Equal(t, "SELECT id FROM items i WHERE (i.title, i.id) > ($1, $2) ORDER BY i.title ASC, i.id ASC LIMIT 10", query)
+	assert.Equal(t, []interface{}{"Autumn", after.ID}, args)
+}
+
+func TestLinks(t *testing.T) {
+	t.Run("page numbers", func(t *testing.T) {
+		u, _ := url.Parse("/api/v1/posts?status=published&page=2&per_page=10")
+
+		links := Links(u, NewMeta(35, 2, 10))
+
+		assert.Equal(t, `</api/v1/posts?page=1&per_page=10&status=published>; rel="first", `+
+			`</api/v1/posts?page=1&per_page=10&status=published>; rel="prev", `+
+			`</api/v1/posts?page=3&per_page=10&status=published>; rel="next", `+
+			`</api/v1/posts?page=4&per_page=10&status=published>; rel="last"`, links)
+	})
+
+	t.Run("next page by cursor", func(t *testing.T) {
+		u, _ := url.Parse("/api/v1/posts?cursor=abc&per_page=10")
+		meta := NewMeta(35, 1, 10)
+		meta.NextCursor = "def"
+
+		links := Links(u, meta)
+
+		assert.Equal(t, `</api/v1/posts?page=1&per_page=10>; rel="first", `+
+			`</api/v1/posts?cursor=def&per_page=10>; rel="next"`, links)
+	})
+
+	t.Run("single page", func(t *testing.T) {
+		u, _ := url.Parse("/api/v1/posts")
+
+		links := Links(u, NewMeta(3, 1, 10))
+
+		assert.Equal(t, `</api/v1/posts?page=1>; rel="first", </api/v1/posts?page=1>; rel="last"`, links)
+	})
+}
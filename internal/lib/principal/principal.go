@@ -0,0 +1,53 @@
+// Package principal описывает аутентифицированного пользователя запроса.
+// Middleware аутентификации сохраняет его в контексте, обработчики и сервисы берут
+// личность пользователя отсюда, а не из тела запроса
+package principal
+
+import (
+	"context"
+	"slices"
+
+	"github.com/google/uuid"
+)
+
+// EchoKey ключ, под которым Principal хранится в echo.Context
+const EchoKey = "principal"
+
+// Principal пользователь, подтвердивший личность access-токеном или ключом API
+type Principal struct {
+	UserID    uuid.UUID
+	Roles     []string
+	SessionID string // Общий для всех токенов одного входа, сохраняется при обновлении. Пустой для ключа API
+
+	APIKeyID uuid.UUID // Ключ API, с которым пришел запрос. uuid.Nil для входа по токену
+	Scopes   []string  // Области доступа ключа API
+}
+
+// HasRole у пользователя есть роль role
+func (p Principal) HasRole(role string) bool {
+	return slices.Contains(p.Roles, role)
+}
+
+// ViaAPIKey запрос пришел с ключом API
+func (p Principal) ViaAPIKey() bool {
+	return p.APIKeyID != uuid.Nil
+}
+
+// Allows запрос может выполнять действия области scope. Вход по токену не ограничен
+// областями, ключ API - только выданными ему
+func (p Principal) Allows(scope string) bool {
+	return !p.ViaAPIKey() || slices.Contains(p.Scopes, scope)
+}
+
+type ctxKey struct{}
+
+// WithPrincipal сохраняет пользователя в ctx
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, ctxKey{}, p)
+}
+
+// FromContext возвращает пользователя, сохраненного в ctx. ok false для анонимных запросов
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(ctxKey{}).(Principal)
+	return p, ok && p.UserID != uuid.Nil
+}
@@ -0,0 +1,69 @@
+package slug
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// MaxLength максимальная длина slug (совпадает с размером колонок slug в БД)
+const MaxLength = 255
+
+var validSlug = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
+// Транслитерация кириллицы по упрощенной схеме, принятой в URL (ГОСТ 7.79-2000, система Б без диакритики)
+var translit = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo", 'ж': "zh",
+	'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh", 'ц': "ts",
+	'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+	'я': "ya",
+	// Украинские и белорусские буквы
+	'є': "ye", 'і': "i", 'ї': "yi", 'ґ': "g", 'ў': "u",
+}
+
+// Make формирует slug из произвольной строки: транслитерирует кириллицу,
+// приводит к нижнему регистру и заменяет все прочие символы на дефисы.
+func Make(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+
+	// pendingDash откладывает вставку дефиса, чтобы не было дефисов по краям и повторов
+	pendingDash := false
+	write := func(part string) {
+		if part == "" {
+			return
+		}
+		if pendingDash && b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		pendingDash = false
+		b.WriteString(part)
+	}
+
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			write(string(r))
+		case translit[r] != "":
+			write(translit[r])
+		case r == 'ъ' || r == 'ь' || r == '\'' || r == '"' || r == '’':
+			// Знаки, которые выбрасываются без разделителя
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r):
+			pendingDash = true
+		}
+	}
+
+	result := b.String()
+	if len(result) > MaxLength {
+		result = strings.TrimRight(result[:MaxLength], "-")
+	}
+
+	return result
+}
+
+// Valid проверяет, что строка является корректным slug:
+// латиница в нижнем регистре, цифры и одиночные дефисы между ними
+func Valid(s string) bool {
+	return len(s) <= MaxLength && validSlug.MatchString(s)
+}
@@ -0,0 +1,54 @@
+package slug
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMake(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "latin", input: "Hello World", want: "hello-world"},
+		{name: "cyrillic", input: "Привет, мир!", want: "privet-mir"},
+		{name: "complex cyrillic letters", input: "Щука и ёжик съели юлу", want: "shchuka-i-yozhik-seli-yulu"},
+		{name: "quotes removed without dash", input: `Don't "stop"`, want: "dont-stop"},
+		{name: "collapse separators", input: "  Go -- и   Postgres  ", want: "go-i-postgres"},
+		{name: "digits", input: "Топ 10 мест 2024", want: "top-10-mest-2024"},
+		{name: "only symbols", input: "!!!", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Make(tt.input)
+			assert.Equal(t, tt.want, got)
+			if got != "" {
+				assert.True(t, Valid(got))
+			}
+		})
+	}
+}
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{input: "hello-world", want: true},
+		{input: "post1", want: true},
+		{input: "Hello", want: false},
+		{input: "hello--world", want: false},
+		{input: "-hello", want: false},
+		{input: "привет", want: false},
+		{input: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			assert.Equal(t, tt.want, Valid(tt.input))
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package totp
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Секрет и ожидаемые коды из приложения B RFC 6238, усеченные до 6 цифр
+var rfcSecret = []byte("12345678901234567890")
+
+func TestCode(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{unix: 59, want: "287082"},
+		{unix: 1111111109, want: "081804"},
+		{unix: 1111111111, want: "050471"},
+		{unix: 1234567890, want: "005924"},
+		{unix: 2000000000, want: "279037"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, Code(rfcSecret, Step(time.Unix(tt.unix, 0))))
+		})
+	}
+}
+
+func TestMatch(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	step := Step(now)
+
+	tests := []struct {
+		name     string
+		code     string
+		wantStep int64
+		wantOK   bool
+	}{
+		{name: "current step", code: Code(rfcSecret, step), wantStep: step, wantOK: true},
+		{name: "previous step within skew", code: Code(rfcSecret, step-1), wantStep: step - 1, wantOK: true},
+		{name: "next step within skew", code: Code(rfcSecret, step+1), wantStep: step + 1, wantOK: true},
+		{name: "outside skew", code: Code(rfcSecret, step-2)},
+		{name: "wrong length", code: "12345"},
+		{name: "wrong code", code: "000000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotStep, ok := Match(rfcSecret, tt.code, now, 1)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantStep, gotStep)
+		})
+	}
+}
+
+func TestProvisioningURI(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	require.Len(t, secret, SecretSize)
+
+	u, err := url.Parse(ProvisioningURI("Premium Caste", "admin@example.com", secret))
+	require.NoError(t, err)
+
+	assert.Equal(t, "otpauth", u.Scheme)
+	assert.Equal(t, "totp", u.Host)
+	assert.Equal(t, "/Premium Caste:admin@example.com", u.Path)
+	assert.Equal(t, EncodeSecret(secret), u.Query().Get("secret"))
+	assert.Equal(t, "Premium Caste", u.Query().Get("issuer"))
+	assert.Equal(t, "6", u.Query().Get("digits"))
+}
@@ -0,0 +1,186 @@
+// Package validation настраивает валидатор запросов API и переводит его ошибки
+// в описание полей на языке клиента (русский или английский)
+package validation
+
+import (
+	"fmt"
+	"premium_caste/internal/domain/apperr"
+	"premium_caste/internal/lib/slug"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/ru"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	ru_translations "github.com/go-playground/validator/v10/translations/ru"
+)
+
+// Поддерживаемые языки сообщений
+const (
+	LangEnglish = "en"
+	LangRussian = "ru"
+)
+
+// DefaultLang язык сообщений, если клиент не указал поддерживаемый
+const DefaultLang = LangEnglish
+
+// customTranslations сообщения для собственных правил и правил, перевод которых
+// в библиотеке отсутствует или неполон. Ключ - правило, затем язык
+var customTranslations = map[string]map[string]string{
+	"slug": {
+		LangEnglish: "{0} must contain only lowercase latin letters, digits and hyphens",
+		LangRussian: "{0} может содержать только строчные латинские буквы, цифры и дефисы",
+	},
+	"e164": {
+		LangEnglish: "{0} must be a phone number in E.164 format",
+		LangRussian: "{0} должен быть номером телефона в формате E.164",
+	},
+}
+
+// Validator валидатор запросов для echo.Echo.Validator
+type Validator struct {
+	validate    *validator.Validate
+	translators map[string]ut.Translator
+}
+
+// New создает валидатор с правилом slug и сообщениями на русском и английском
+func New() (*Validator, error) {
+	validate := validator.New()
+
+	// В ошибках поля называются так же, как в JSON запроса
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		if name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	if err := validate.RegisterValidation("slug", func(fl validator.FieldLevel) bool {
+		return slug.Valid(fl.Field().String())
+	}); err != nil {
+		return nil, fmt.Errorf("failed to register slug rule: %w", err)
+	}
+
+	enLocale := en.New()
+	uni := ut.New(enLocale, enLocale, ru.New())
+
+	v := &Validator{
+		validate:    validate,
+		translators: make(map[string]ut.Translator, 2),
+	}
+
+	register := map[string]func(*validator.Validate, ut.Translator) error{
+		LangEnglish: en_translations.RegisterDefaultTranslations,
+		LangRussian: ru_translations.RegisterDefaultTranslations,
+	}
+	for lang, registerDefaults := range register {
+		trans, _ := uni.GetTranslator(lang)
+		if err := registerDefaults(validate, trans); err != nil {
+			return nil, fmt.Errorf("failed to register %s translations: %w", lang, err)
+		}
+
+		for tag, messages := range customTranslations {
+			if err := registerTranslation(validate, trans, tag, messages[lang]); err != nil {
+				return nil, fmt.Errorf("failed to register %s translation for %s: %w", lang, tag, err)
+			}
+		}
+
+		v.translators[lang] = trans
+	}
+
+	return v, nil
+}
+
+// MustNew создает валидатор и паникует, если правила или переводы не зарегистрировались
+func MustNew() *Validator {
+	v, err := New()
+	if err != nil {
+		panic("cannot init validator: " + err.Error())
+	}
+
+	return v
+}
+
+// Validate проверяет структуру по тегам validate
+func (v *Validator) Validate(i any) error {
+	return v.validate.Struct(i)
+}
+
+// Fields описывает ошибки валидатора на языке lang: имя поля из JSON, правило,
+// его параметр и сообщение. Для неизвестного языка используется DefaultLang
+func (v *Validator) Fields(errs validator.ValidationErrors, lang string) []apperr.FieldError {
+	trans, ok := v.translators[lang]
+	if !ok {
+		trans = v.translators[DefaultLang]
+	}
+
+	fields := make([]apperr.FieldError, 0, len(errs))
+	for _, fe := range errs {
+		fields = append(fields, apperr.FieldError{
+			Field:   FieldPath(fe),
+			Code:    fe.Tag(),
+			Param:   fe.Param(),
+			Message: fe.Translate(trans),
+		})
+	}
+
+	return fields
+}
+
+// FieldPath возвращает путь к полю без имени корневой структуры: items[0].media_id
+func FieldPath(fe validator.FieldError) string {
+	path := fe.Namespace()
+	if i := strings.IndexByte(path, '.'); i >= 0 {
+		path = path[i+1:]
+	}
+
+	return path
+}
+
+// Lang выбирает язык сообщений по заголовку Accept-Language с учетом весов q.
+// Если ни один из языков клиента не поддерживается, возвращается DefaultLang
+func Lang(acceptLanguage string) string {
+	best, bestQ := DefaultLang, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+
+		base, _, _ := strings.Cut(strings.ToLower(tag), "-")
+		if (base == LangEnglish || base == LangRussian) && q > bestQ {
+			best, bestQ = base, q
+		}
+	}
+
+	return best
+}
+
+// registerTranslation заменяет сообщение правила tag, подставляя в {0} имя поля
+func registerTranslation(validate *validator.Validate, trans ut.Translator, tag, message string) error {
+	return validate.RegisterTranslation(tag, trans,
+		func(ut ut.Translator) error {
+			return ut.Add(tag, message, true)
+		},
+		func(ut ut.Translator, fe validator.FieldError) string {
+			text, err := ut.T(tag, fe.Field())
+			if err != nil {
+				return fe.Error()
+			}
+			return text
+		},
+	)
+}
@@ -0,0 +1,106 @@
+package validation
+
+import (
+	"premium_caste/internal/domain/apperr"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testItem struct {
+	MediaID string `json:"media_id" validate:"required,uuid"`
+}
+
+type testRequest struct {
+	Title string     `json:"title" validate:"required,max=5"`
+	Slug  string     `json:"slug" validate:"omitempty,slug"`
+	Items []testItem `json:"items" validate:"dive"`
+}
+
+func TestValidator_Fields(t *testing.T) {
+	v, err := New()
+	require.NoError(t, err)
+
+	req := testRequest{
+		Title: "too long title",
+		Slug:  "Not A Slug",
+		Items: []testItem{{MediaID: "1"}},
+	}
+
+	verr := v.Validate(req)
+	require.Error(t, verr)
+
+	var errs validator.ValidationErrors
+	require.ErrorAs(t, verr, &errs)
+
+	tests := []struct {
+		name string
+		lang string
+		want []apperr.FieldError
+	}{
+		{
+			name: "english",
+			lang: LangEnglish,
+			want: []apperr.FieldError{
+				{Field: "title", Code: "max", Param: "5", Message: "title must be a maximum of 5 characters in length"},
+				{Field: "slug", Code: "slug", Message: "slug must contain only lowercase latin letters, digits and hyphens"},
+				{Field: "items[0].media_id", Code: "uuid", Message: "media_id must be a valid UUID"},
+			},
+		},
+		{
+			name: "russian",
+			lang: LangRussian,
+			want: []apperr.FieldError{
+				{Field: "title", Code: "max", Param: "5", Message: "title должен содержать максимум 5 символов"},
+				{Field: "slug", Code: "slug", Message: "slug может содержать только строчные латинские буквы, цифры и дефисы"},
+				{Field: "items[0].media_id", Code: "uuid", Message: "media_id должен быть UUID"},
+			},
+		},
+		{
+			name: "unknown language falls back to english",
+			lang: "de",
+			want: []apperr.FieldError{
+				{Field: "title", Code: "max", Param: "5", Message: "title must be a maximum of 5 characters in length"},
+				{Field: "slug", Code: "slug", Message: "slug must contain only lowercase latin letters, digits and hyphens"},
+				{Field: "items[0].media_id", Code: "uuid", Message: "media_id must be a valid UUID"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, v.Fields(errs, tt.lang))
+		})
+	}
+}
+
+func TestValidator_Validate(t *testing.T) {
+	v := MustNew()
+
+	assert.NoError(t, v.Validate(testRequest{Title: "ok", Slug: "valid-slug-1"}))
+	assert.Error(t, v.Validate(testRequest{}))
+}
+
+func TestLang(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{header: "", want: LangEnglish},
+		{header: "ru", want: LangRussian},
+		{header: "ru-RU,ru;q=0.9,en-US;q=0.8,en;q=0.7", want: LangRussian},
+		{header: "en-US,en;q=0.9,ru;q=0.8", want: LangEnglish},
+		{header: "de-DE,ru;q=0.5", want: LangRussian},
+		{header: "en;q=0.3, RU;q=0.7", want: LangRussian},
+		{header: "fr, de", want: LangEnglish},
+		{header: "ru;q=abc", want: LangEnglish},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			assert.Equal(t, tt.want, Lang(tt.header))
+		})
+	}
+}
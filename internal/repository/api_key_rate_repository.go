@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	redisapp "premium_caste/internal/storage/redis"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// apiKeyRatePrefix префикс счетчиков запросов по ключам API
+const apiKeyRatePrefix = "api_key_rate:"
+
+type RedisAPIKeyRateRepo struct {
+	Client *redisapp.Client
+}
+
+func NewRedisAPIKeyRateRepo(client *redisapp.Client) *RedisAPIKeyRateRepo {
+	return &RedisAPIKeyRateRepo{Client: client}
+}
+
+// CountAPIKeyRequest учитывает запрос с ключом и возвращает число запросов в текущем окне.
+// Окна фиксированные: счетчик каждого окна живет window и удаляется сам
+func (r *RedisAPIKeyRateRepo) CountAPIKeyRequest(ctx context.Context, keyID uuid.UUID, window time.Duration) (int64, error) {
+	const op = "repository.api_key_rate.CountAPIKeyRequest"
+
+	slot := time.Now().UnixNano() / int64(window)
+	key := apiKeyRatePrefix + keyID.String() + ":" + strconv.FormatInt(slot, 10)
+
+	var count *redis.IntCmd
+	_, err := r.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		count = pipe.Incr(ctx, key)
+		pipe.Expire(ctx, key, window)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count.Val(), nil
+}
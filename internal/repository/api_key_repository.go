@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"premium_caste/internal/domain/models"
+	"premium_caste/internal/storage"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// apiKeyTouchInterval время использования ключа записывается не чаще этого интервала,
+// чтобы частые запросы интеграций не обновляли строку на каждый вызов
+const apiKeyTouchInterval = time.Minute
+
+var apiKeyColumns = []string{"id", "user_id", "name", "prefix", "scopes", "rate_limit", "created_at", "expires_at", "last_used_at", "revoked_at"}
+
+type APIKeyRepo struct {
+	db *txDB
+	sb sq.StatementBuilderType
+}
+
+func NewAPIKeyRepo(db *pgxpool.Pool) *APIKeyRepo {
+	return &APIKeyRepo{
+		db: newTxDB(db),
+		sb: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// CreateAPIKey сохраняет ключ и возвращает его ID
+func (r *APIKeyRepo) CreateAPIKey(ctx context.Context, key models.APIKey, keyHash string) (uuid.UUID, error) {
+	const op = "repository.api_key_repository.CreateAPIKey"
+
+	sql, args, err := r.sb.Insert("api_keys").
+		Columns("user_id", "name", "prefix", "key_hash", "scopes", "rate_limit", "created_at", "expires_at").
+		Values(key.UserID, key.Name, key.Prefix, keyHash, key.Scopes, key.RateLimit, key.CreatedAt, key.ExpiresAt).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%s: can't build sql: %w", op, err)
+	}
+
+	var id uuid.UUID
+	if err := r.db.QueryRow(ctx, sql, args...).Scan(&id); err != nil {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// APIKeyByHash возвращает ключ по SHA-256, в том числе отозванный или истекший
+func (r *APIKeyRepo) APIKeyByHash(ctx context.Context, keyHash string) (models.APIKey, error) {
+	const op = "repository.api_key_repository.APIKeyByHash"
+
+	sql, args, err := r.sb.Select(apiKeyColumns...).
+		From("api_keys").
+		Where(sq.Eq{"key_hash": keyHash}).
+		ToSql()
+	if err != nil {
+		return models.APIKey{}, fmt.Errorf("%s: can't build sql: %w", op, err)
+	}
+
+	key, err := scanAPIKey(r.db.QueryRow(ctx, sql, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.APIKey{}, fmt.Errorf("%s: %w", op, storage.ErrAPIKeyNotFound)
+		}
+		return models.APIKey{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return key, nil
+}
+
+// ListAPIKeys возвращает ключи пользователя userID или всех пользователей, если он nil.
+// Новые ключи идут первыми
+func (r *APIKeyRepo) ListAPIKeys(ctx context.Context, userID *uuid.UUID) ([]models.APIKey, error) {
+	const op = "repository.api_key_repository.ListAPIKeys"
+
+	query := r.sb.Select(apiKeyColumns...).
+		From("api_keys").
+		OrderBy("created_at DESC", "id")
+	if userID != nil {
+		query = query.Where(sq.Eq{"user_id": *userID})
+	}
+
+	sql, args, err := query.ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: can't build sql: %w", op, err)
+	}
+
+	rows, err := r.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey отзывает действующий ключ. Если userID задан, ключ должен принадлежать ему.
+// Чужой, неизвестный или уже отозванный ключ - storage.ErrAPIKeyNotFound
+func (r *APIKeyRepo) RevokeAPIKey(ctx context.Context, keyID uuid.UUID, userID *uuid.UUID) error {
+	const op = "repository.api_key_repository.RevokeAPIKey"
+
+	where := sq.And{sq.Eq{"id": keyID}, sq.Eq{"revoked_at": nil}}
+	if userID != nil {
+		where = append(where, sq.Eq{"user_id": *userID})
+	}
+
+	sql, args, err := r.sb.Update("api_keys").
+		Set("revoked_at", time.Now().UTC()).
+		Where(where).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: can't build sql: %w", op, err)
+	}
+
+	tag, err := r.db.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrAPIKeyNotFound)
+	}
+
+	return nil
+}
+
+// TouchAPIKey запоминает время использования ключа, если с прошлой записи прошло
+// больше apiKeyTouchInterval
+func (r *APIKeyRepo) TouchAPIKey(ctx context.Context, keyID uuid.UUID) error {
+	const op = "repository.api_key_repository.TouchAPIKey"
+
+	now := time.Now().UTC()
+	sql, args, err := r.sb.Update("api_keys").
+		Set("last_used_at", now).
+		Where(sq.Eq{"id": keyID}).
+		Where(sq.Or{sq.Eq{"last_used_at": nil}, sq.Lt{"last_used_at": now.Add(-apiKeyTouchInterval)}}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: can't build sql: %w", op, err)
+	}
+
+	if _, err := r.db.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func scanAPIKey(row pgx.Row) (models.APIKey, error) {
+	var key models.APIKey
+	err := row.Scan(
+		&key.ID,
+		&key.UserID,
+		&key.Name,
+		&key.Prefix,
+		&key.Scopes,
+		&key.RateLimit,
+		&key.CreatedAt,
+		&key.ExpiresAt,
+		&key.LastUsedAt,
+		&key.RevokedAt,
+	)
+
+	return key, err
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"premium_caste/internal/domain/models"
+	"premium_caste/internal/lib/pagination"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type AuditRepo struct {
+	db *txDB
+	sb sq.StatementBuilderType
+}
+
+func NewAuditRepo(db *pgxpool.Pool) *AuditRepo {
+	return &AuditRepo{
+		db: newTxDB(db),
+		sb: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// SaveEvent добавляет запись в журнал аудита
+func (r *AuditRepo) SaveEvent(ctx context.Context, event models.AuditEvent) error {
+	const op = "repository.audit_repository.SaveEvent"
+
+	sql, args, err := r.sb.Insert("audit_events").
+		Columns("action", "actor_id", "api_key_id", "ip", "user_agent", "target_type", "target_id", "before", "after").
+		Values(
+			event.Action,
+			event.ActorID,
+			event.APIKeyID,
+			nullString(event.IP),
+			nullString(event.UserAgent),
+			nullString(event.TargetType),
+			nullString(event.TargetID),
+			nullJSON(event.Before),
+			nullJSON(event.After),
+		).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: can't build sql: %w", op, err)
+	}
+
+	if _, err := r.db.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// auditKeyset порядок журнала: сначала новые записи
+var auditKeyset = pagination.Keyset{Key: "occurred_at", ID: "id", Desc: true}
+
+// ListEvents возвращает страницу журнала, новые записи первыми, и общее количество записей
+func (r *AuditRepo) ListEvents(ctx context.Context, filter models.AuditFilter, page, perPage int) ([]models.AuditEvent, int, error) {
+	const op = "repository.audit_repository.ListEvents"
+
+	where := sq.And{}
+	if filter.Action != "" {
+		where = append(where, sq.Eq{"action": filter.Action})
+	}
+	if filter.ActorID != nil {
+		where = append(where, sq.Eq{"actor_id": *filter.ActorID})
+	}
+	if filter.APIKeyID != nil {
+		where = append(where, sq.Eq{"api_key_id": *filter.APIKeyID})
+	}
+	if filter.TargetType != "" {
+		where = append(where, sq.Eq{"target_type": filter.TargetType})
+	}
+	if filter.TargetID != "" {
+		where = append(where, sq.Eq{"target_id": filter.TargetID})
+	}
+	if filter.From != nil {
+		where = append(where, sq.GtOrEq{"occurred_at": *filter.From})
+	}
+	if filter.To != nil {
+		where = append(where, sq.LtOrEq{"occurred_at": *filter.To})
+	}
+
+	total, err := pagination.Count(ctx, r.db, r.sb.Select("COUNT(*)").From("audit_events").Where(where))
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	queryBuilder := r.sb.
+		Select(
+			"id",
+			"occurred_at",
+			"action",
+			"actor_id",
+			"api_key_id",
+			"COALESCE(ip, '')",
+			"COALESCE(user_agent, '')",
+			"COALESCE(target_type, '')",
+			"COALESCE(target_id, '')",
+			"before",
+			"after",
+		).
+		From("audit_events").
+		Where(where)
+
+	sql, args, err := auditKeyset.Apply(queryBuilder, filter.After, page, perPage).ToSql()
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: can't build sql: %w", op, err)
+	}
+
+	rows, err := r.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	events := make([]models.AuditEvent, 0, perPage)
+	for rows.Next() {
+		var event models.AuditEvent
+		var before, after []byte
+		err := rows.Scan(
+			&event.ID,
+			&event.OccurredAt,
+			&event.Action,
+			&event.ActorID,
+			&event.APIKeyID,
+			&event.IP,
+			&event.UserAgent,
+			&event.TargetType,
+			&event.TargetID,
+			&before,
+			&after,
+		)
+		if err != nil {
+			return nil, 0, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		}
+		event.Before = before
+		event.After = after
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	return events, total, nil
+}
+
+// DeleteEventsBefore удаляет записи старше before и возвращает их количество
+func (r *AuditRepo) DeleteEventsBefore(ctx context.Context, before time.Time) (int64, error) {
+	const op = "repository.audit_repository.DeleteEventsBefore"
+
+	sql, args, err := r.sb.Delete("audit_events").Where(sq.Lt{"occurred_at": before}).ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("%s: can't build sql: %w", op, err)
+	}
+
+	tag, err := r.db.Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+func nullString(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// nullJSON передает пустой JSON как NULL, а не как пустую строку, которую jsonb не примет
+func nullJSON(data []byte) any {
+	if len(data) == 0 {
+		return nil
+	}
+	return string(data)
+}
@@ -6,22 +6,25 @@ import (
 	"errors"
 	"fmt"
 	"premium_caste/internal/domain/models"
+	"premium_caste/internal/lib/pagination"
+	"premium_caste/internal/storage"
 	"time"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/lib/pq"
 )
 
 type BlogRepo struct {
-	db *pgxpool.Pool
+	db *txDB
 	sb sq.StatementBuilderType
 }
 
 func NewBlogRepository(db *pgxpool.Pool) *BlogRepo {
 	return &BlogRepo{
-		db: db,
+		db: newTxDB(db),
 		sb: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
 	}
 }
@@ -35,20 +38,28 @@ func (b *BlogRepo) SaveBlogPost(ctx context.Context, blogPost models.BlogPost) (
 			"slug",
 			"excerpt",
 			"content",
+			"content_format",
+			"content_html",
 			"featured_image_id",
 			"author_id",
+			"category_id",
 			"status",
 			"metadata",
+			"comments_closed",
 		).
 		Values(
 			blogPost.Title,
 			blogPost.Slug,
 			blogPost.Excerpt,
 			blogPost.Content,
+			blogPost.ContentFormat,
+			blogPost.ContentHTML,
 			blogPost.FeaturedImageID,
 			blogPost.AuthorID,
+			blogPost.CategoryID,
 			blogPost.Status,
 			blogPost.Metadata,
+			blogPost.CommentsClosed,
 		).
 		Suffix("RETURNING id").
 		ToSql()
@@ -59,7 +70,7 @@ func (b *BlogRepo) SaveBlogPost(ctx context.Context, blogPost models.BlogPost) (
 	var id uuid.UUID
 	err = b.db.QueryRow(ctx, query, args...).Scan(&id)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+		return uuid.Nil, fmt.Errorf("%s: %w", op, asConflict(err, storage.ErrSlugTaken))
 	}
 
 	return id, nil
@@ -73,10 +84,14 @@ func (b *BlogRepo) UpdateBlogPostFields(ctx context.Context, postID uuid.UUID, u
 		"slug":              true,
 		"excerpt":           true,
 		"content":           true,
+		"content_format":    true,
+		"content_html":      true,
 		"featured_image_id": true,
+		"category_id":       true,
 		"status":            true,
 		"published_at":      true,
 		"metadata":          true,
+		"comments_closed":   true,
 	}
 
 	if len(updates) == 0 {
@@ -101,9 +116,35 @@ func (b *BlogRepo) UpdateBlogPostFields(ctx context.Context, postID uuid.UUID, u
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	_, err = b.db.Exec(ctx, query, args...)
+	tx, err := b.db.Begin(ctx)
 	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
+		return fmt.Errorf("%s failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Запоминаем текущий slug, чтобы при переименовании сохранить его в истории
+	newSlug, slugChanged := updates["slug"].(string)
+	var oldSlug string
+	if slugChanged {
+		oldSlug, err = lockCurrentSlug(ctx, tx, "blog_posts", postID, storage.ErrPostNotFound)
+		if err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	_, err = tx.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, asConflict(err, storage.ErrSlugTaken))
+	}
+
+	if slugChanged {
+		if err := recordSlugChange(ctx, tx, "post_slug_history", "post_id", postID, oldSlug, newSlug); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s failed to commit transaction: %w", op, err)
 	}
 
 	return nil
@@ -228,12 +269,13 @@ func (b *BlogRepo) GetBlogPostByID(ctx context.Context, postID uuid.UUID) (*mode
 
 	// Основной запрос для получения информации о посте
 	postQuery := b.sb.Select(
-		"bp.id", "bp.title", "bp.slug", "bp.excerpt", "bp.content",
+		"bp.id", "bp.title", "bp.slug", "bp.excerpt", "bp.content", "bp.content_format", "COALESCE(bp.content_html, '')",
 		"bp.featured_image_id",
 		"(SELECT storage_path FROM media WHERE id = bp.featured_image_id) AS featured_image_path",
-		"bp.author_id", "bp.status",
+		"bp.author_id", "bp.category_id", "bp.status",
 		"bp.published_at", "bp.created_at", "bp.updated_at",
-		"bp.metadata",
+		"bp.metadata", "bp.comments_closed",
+		"(SELECT COUNT(*) FROM comments c WHERE c.post_id = bp.id AND c.status = 'approved') AS comment_count",
 	).
 		From("blog_posts bp").
 		Where(sq.Eq{"bp.id": postID})
@@ -274,14 +316,19 @@ func (b *BlogRepo) GetBlogPostByID(ctx context.Context, postID uuid.UUID) (*mode
 		&post.Slug,
 		&post.Excerpt,
 		&post.Content,
+		&post.ContentFormat,
+		&post.ContentHTML,
 		&post.FeaturedImageID,
 		&post.FeaturedImagePath,
 		&post.AuthorID,
+		&post.CategoryID,
 		&post.Status,
 		&publishedAt,
 		&post.CreatedAt,
 		&post.UpdatedAt,
 		&post.Metadata,
+		&post.CommentsClosed,
+		&post.CommentCount,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -336,23 +383,25 @@ func (b *BlogRepo) GetBlogPostByID(ctx context.Context, postID uuid.UUID) (*mode
 		return nil, fmt.Errorf("%s failed to commit transaction: %w", op, err)
 	}
 
+	// Получаем теги поста
+	tags, err := b.getPostsTags(ctx, []uuid.UUID{post.ID})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	post.Tags = tags[post.ID]
+
 	return &post, nil
 }
 
 func (b *BlogRepo) GetBlogPosts(
 	ctx context.Context,
-	statusFilter string, // "all", "draft", "published", "archived"
+	filter models.BlogPostFilter,
 	page int,
 	perPage int,
 ) ([]models.BlogPost, int, error) {
 	const op = "repository.blog_repository.GetBlogPosts"
 
-	if page < 1 {
-		page = 1
-	}
-	if perPage < 1 || perPage > 100 {
-		perPage = 10
-	}
+	page, perPage = pagination.Normalize(page, perPage)
 
 	// Строим базовый запрос
 	// queryBuilder := b.sb.Select(
@@ -360,35 +409,35 @@ func (b *BlogRepo) GetBlogPosts(
 	// ).From("blog_posts")
 
 	queryBuilder := b.sb.Select(
-		"bp.id", "bp.title", "bp.slug", "bp.excerpt", "bp.content",
+		"bp.id", "bp.title", "bp.slug", "bp.excerpt", "bp.content", "bp.content_format", "COALESCE(bp.content_html, '')",
 		"bp.featured_image_id",
 		"(SELECT storage_path FROM media WHERE id = bp.featured_image_id) AS featured_image_path",
-		"bp.author_id", "bp.status",
+		"bp.author_id", "bp.category_id", "bp.status",
 		"bp.published_at", "bp.created_at", "bp.updated_at",
+		"bp.comments_closed",
+		"(SELECT COUNT(*) FROM comments c WHERE c.post_id = bp.id AND c.status = 'approved') AS comment_count",
 	).
 		From("blog_posts bp")
 
-	// Применяем фильтр по статусу
-	switch statusFilter {
-	case "draft", "published", "archived":
-		queryBuilder = queryBuilder.Where(sq.Eq{"status": statusFilter})
-	case "all":
+	// Применяем фильтры
+	queryBuilder, err := applyBlogPostFilter(queryBuilder, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
 
-	default:
-		return nil, 0, fmt.Errorf("%s: invalid status filter '%s'", op, statusFilter)
+	// Получаем общее количество постов с учетом фильтров (для пагинации)
+	countBuilder, err := applyBlogPostFilter(b.sb.Select("COUNT(*)").From("blog_posts bp"), filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
 	}
 
-	// Получаем общее количество постов (для пагинации)
-	totalCount, err := b.getTotalCount(ctx)
+	totalCount, err := pagination.Count(ctx, b.db, countBuilder)
 	if err != nil {
 		return nil, 0, fmt.Errorf("%s: %w", op, err)
 	}
 
 	// Применяем пагинацию
-	queryBuilder = queryBuilder.
-		OrderBy("created_at DESC").
-		Limit(uint64(perPage)).
-		Offset(uint64((page - 1) * perPage))
+	queryBuilder = blogPostKeyset.Apply(queryBuilder, filter.After, page, perPage)
 
 	// Формируем SQL-запрос
 	query, args, err := queryBuilder.ToSql()
@@ -413,13 +462,18 @@ func (b *BlogRepo) GetBlogPosts(
 			&post.Slug,
 			&post.Excerpt,
 			&post.Content,
+			&post.ContentFormat,
+			&post.ContentHTML,
 			&post.FeaturedImageID,
 			&post.FeaturedImagePath,
 			&post.AuthorID,
+			&post.CategoryID,
 			&post.Status,
 			&post.PublishedAt,
 			&post.CreatedAt,
 			&post.UpdatedAt,
+			&post.CommentsClosed,
+			&post.CommentCount,
 		)
 		if err != nil {
 			return nil, 0, fmt.Errorf("%s: %w", op, err)
@@ -427,34 +481,217 @@ func (b *BlogRepo) GetBlogPosts(
 		posts = append(posts, post)
 	}
 
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Подгружаем теги одним запросом для всех постов страницы
+	postIDs := make([]uuid.UUID, 0, len(posts))
+	for _, post := range posts {
+		postIDs = append(postIDs, post.ID)
+	}
+
+	tags, err := b.getPostsTags(ctx, postIDs)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for i := range posts {
+		posts[i].Tags = tags[posts[i].ID]
+	}
+
 	return posts, totalCount, nil
 
 	// Получить первые 10 опубликованных постов
-	// posts, total, err := repo.GetBlogPosts(ctx, "published", 1, 10)
+	// posts, total, err := repo.GetBlogPosts(ctx, models.BlogPostFilter{Status: "published"}, 1, 10)
+
+	// // Получить черновики автора с тегом "travel"
+	// drafts, total, err := repo.GetBlogPosts(ctx, models.BlogPostFilter{Status: "draft", AuthorID: &authorID, Tags: []string{"travel"}}, 1, 10)
+}
+
+// blogPostKeyset порядок списка постов: сначала новые
+var blogPostKeyset = pagination.Keyset{Key: "bp.created_at", ID: "bp.id", Desc: true}
+
+// applyBlogPostFilter добавляет в запрос условия фильтрации постов.
+// Запрос должен выбирать из blog_posts с алиасом bp.
+func applyBlogPostFilter(builder sq.SelectBuilder, filter models.BlogPostFilter) (sq.SelectBuilder, error) {
+	// Фильтр по статусу
+	switch filter.Status {
+	case "draft", "published", "archived":
+		builder = builder.Where(sq.Eq{"bp.status": filter.Status})
+	case "all", "":
+
+	default:
+		return builder, fmt.Errorf("invalid status filter '%s'", filter.Status)
+	}
+
+	// Фильтр по категории вместе со всеми вложенными подкатегориями
+	if filter.CategoryID != nil || filter.CategorySlug != "" {
+		rootCondition, rootArg := "id = ?", any(filter.CategoryID)
+		if filter.CategoryID == nil {
+			rootCondition, rootArg = "slug = ?", filter.CategorySlug
+		}
+
+		builder = builder.Where(`bp.category_id IN (
+			WITH RECURSIVE category_tree AS (
+				SELECT id FROM blog_categories WHERE `+rootCondition+`
+				UNION ALL
+				SELECT c.id FROM blog_categories c JOIN category_tree ct ON c.parent_id = ct.id
+			)
+			SELECT id FROM category_tree
+		)`, rootArg)
+	}
+
+	// Фильтр по тегам: пост должен содержать хотя бы один из указанных тегов
+	if len(filter.Tags) > 0 {
+		builder = builder.Where(`EXISTS (
+			SELECT 1 FROM post_tags pt
+			JOIN blog_tags t ON t.id = pt.tag_id
+			WHERE pt.post_id = bp.id AND t.slug = ANY(?)
+		)`, pq.Array(filter.Tags))
+	}
+
+	if filter.AuthorID != nil {
+		builder = builder.Where(sq.Eq{"bp.author_id": *filter.AuthorID})
+	}
+
+	// Диапазон дат: для опубликованных постов берем дату публикации, для остальных - дату создания
+	if filter.DateFrom != nil {
+		builder = builder.Where(sq.GtOrEq{"COALESCE(bp.published_at, bp.created_at)": *filter.DateFrom})
+	}
+	if filter.DateTo != nil {
+		builder = builder.Where(sq.LtOrEq{"COALESCE(bp.published_at, bp.created_at)": *filter.DateTo})
+	}
+
+	return builder, nil
+}
+
+// SetPostTags полностью заменяет теги поста. Отсутствующие в справочнике теги создаются.
+func (b *BlogRepo) SetPostTags(ctx context.Context, postID uuid.UUID, tags []models.BlogTag) error {
+	const op = "repository.blog_repository.SetPostTags"
+
+	tx, err := b.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `DELETE FROM post_tags WHERE post_id = $1`, postID); err != nil {
+		return fmt.Errorf("%s failed to clear post tags: %w", op, err)
+	}
+
+	for _, tag := range tags {
+		var tagID uuid.UUID
+		err := tx.QueryRow(ctx, `
+			INSERT INTO blog_tags (name, slug)
+			VALUES ($1, $2)
+			ON CONFLICT (slug) DO UPDATE SET slug = EXCLUDED.slug
+			RETURNING id
+		`, tag.Name, tag.Slug).Scan(&tagID)
+		if err != nil {
+			return fmt.Errorf("%s failed to upsert tag %s: %w", op, tag.Slug, err)
+		}
+
+		_, err = tx.Exec(ctx, `
+			INSERT INTO post_tags (post_id, tag_id)
+			VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+		`, postID, tagID)
+		if err != nil {
+			return fmt.Errorf("%s failed to attach tag %s: %w", op, tag.Slug, err)
+		}
+	}
 
-	// // Получить черновики (страница 2, по 5 записей)
-	// drafts, total, err := repo.GetBlogPosts(ctx, "draft", 2, 5)
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s failed to commit transaction: %w", op, err)
+	}
+
+	return nil
 }
 
-func (b *BlogRepo) getTotalCount(ctx context.Context) (int, error) {
-	queryBuilder := sq.Select("COUNT(*)").
-		From("blog_posts")
+// GetTagCounts возвращает теги с количеством постов для облака тегов
+func (b *BlogRepo) GetTagCounts(ctx context.Context, statusFilter string, limit int) ([]models.TagCount, error) {
+	const op = "repository.blog_repository.GetTagCounts"
+
+	queryBuilder := b.sb.Select("t.name", "t.slug", "COUNT(*) AS posts_count").
+		From("blog_tags t").
+		Join("post_tags pt ON pt.tag_id = t.id").
+		Join("blog_posts bp ON bp.id = pt.post_id").
+		GroupBy("t.id", "t.name", "t.slug").
+		OrderBy("posts_count DESC", "t.name")
+
+	queryBuilder, err := applyBlogPostFilter(queryBuilder, models.BlogPostFilter{Status: statusFilter})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if limit > 0 {
+		queryBuilder = queryBuilder.Limit(uint64(limit))
+	}
 
 	query, args, err := queryBuilder.ToSql()
 	if err != nil {
-		return 0, fmt.Errorf("error build query: %w", err)
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	var count int
-	err = b.db.QueryRow(ctx, query, args...).Scan(&count)
+	rows, err := b.db.Query(ctx, query, args...)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return 0, nil
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var counts []models.TagCount
+	for rows.Next() {
+		var tc models.TagCount
+		if err := rows.Scan(&tc.Name, &tc.Slug, &tc.Count); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
 		}
-		return 0, fmt.Errorf("error execute query: %w (SQL: %s)", err, query)
+		counts = append(counts, tc)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return count, nil
+	return counts, nil
+}
+
+// getPostsTags возвращает теги для набора постов, сгруппированные по ID поста
+func (b *BlogRepo) getPostsTags(ctx context.Context, postIDs []uuid.UUID) (map[uuid.UUID][]models.BlogTag, error) {
+	result := make(map[uuid.UUID][]models.BlogTag, len(postIDs))
+	if len(postIDs) == 0 {
+		return result, nil
+	}
+
+	ids := make([]string, 0, len(postIDs))
+	for _, id := range postIDs {
+		ids = append(ids, id.String())
+	}
+
+	rows, err := b.db.Query(ctx, `
+		SELECT pt.post_id, t.id, t.name, t.slug
+		FROM post_tags pt
+		JOIN blog_tags t ON t.id = pt.tag_id
+		WHERE pt.post_id = ANY($1::uuid[])
+		ORDER BY t.name
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query post tags: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			postID uuid.UUID
+			tag    models.BlogTag
+		)
+		if err := rows.Scan(&postID, &tag.ID, &tag.Name, &tag.Slug); err != nil {
+			return nil, fmt.Errorf("failed to scan post tag: %w", err)
+		}
+		result[postID] = append(result[postID], tag)
+	}
+
+	return result, rows.Err()
 }
 
 // Добавление связи между постом и медиа-группой
@@ -512,3 +749,61 @@ func (b *BlogRepo) GetPostMediaGroups(ctx context.Context, postID uuid.UUID, rel
 
 	return groupIDs, nil
 }
+
+// GetBlogPostBySlug возвращает пост по его текущему slug
+func (b *BlogRepo) GetBlogPostBySlug(ctx context.Context, slug string) (*models.BlogPost, error) {
+	const op = "repository.blog_repository.GetBlogPostBySlug"
+
+	var id uuid.UUID
+	err := b.db.QueryRow(ctx, `SELECT id FROM blog_posts WHERE slug = $1`, slug).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrPostNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	post, err := b.GetBlogPostByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return post, nil
+}
+
+// GetPostSlugRedirect возвращает актуальный slug поста, которому раньше принадлежал oldSlug
+func (b *BlogRepo) GetPostSlugRedirect(ctx context.Context, oldSlug string) (string, error) {
+	const op = "repository.blog_repository.GetPostSlugRedirect"
+
+	var slug string
+	err := b.db.QueryRow(ctx, `
+		SELECT bp.slug
+		FROM post_slug_history h
+		JOIN blog_posts bp ON bp.id = h.post_id
+		WHERE h.slug = $1
+	`, oldSlug).Scan(&slug)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", fmt.Errorf("%s: %w", op, storage.ErrPostNotFound)
+		}
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return slug, nil
+}
+
+// IsPostSlugTaken проверяет, занят ли slug постом или историей переименований
+func (b *BlogRepo) IsPostSlugTaken(ctx context.Context, slug string) (bool, error) {
+	const op = "repository.blog_repository.IsPostSlugTaken"
+
+	var taken bool
+	err := b.db.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM blog_posts WHERE slug = $1)
+			OR EXISTS (SELECT 1 FROM post_slug_history WHERE slug = $1)
+	`, slug).Scan(&taken)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return taken, nil
+}
@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"premium_caste/internal/domain/models"
+	"premium_caste/internal/storage"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type CategoryRepo struct {
+	db *txDB
+	sb sq.StatementBuilderType
+}
+
+func NewCategoryRepository(db *pgxpool.Pool) *CategoryRepo {
+	return &CategoryRepo{
+		db: newTxDB(db),
+		sb: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// CreateCategory создает новую категорию и возвращает её ID
+func (r *CategoryRepo) CreateCategory(ctx context.Context, category models.BlogCategory) (uuid.UUID, error) {
+	const op = "repository.category_repository.CreateCategory"
+
+	query, args, err := r.sb.Insert("blog_categories").
+		Columns(
+			"parent_id",
+			"name",
+			"slug",
+			"description",
+			"position",
+		).
+		Values(
+			category.ParentID,
+			category.Name,
+			category.Slug,
+			category.Description,
+			category.Position,
+		).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var id uuid.UUID
+	if err := r.db.QueryRow(ctx, query, args...).Scan(&id); err != nil {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, asConflict(err, storage.ErrSlugTaken))
+	}
+
+	return id, nil
+}
+
+// UpdateCategory обновляет данные категории
+func (r *CategoryRepo) UpdateCategory(ctx context.Context, category models.BlogCategory) error {
+	const op = "repository.category_repository.UpdateCategory"
+
+	query, args, err := r.sb.Update("blog_categories").
+		Set("parent_id", category.ParentID).
+		Set("name", category.Name).
+		Set("slug", category.Slug).
+		Set("description", category.Description).
+		Set("position", category.Position).
+		Set("updated_at", time.Now()).
+		Where(sq.Eq{"id": category.ID}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, asConflict(err, storage.ErrSlugTaken))
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrCategoryNotFound)
+	}
+
+	return nil
+}
+
+// DeleteCategory удаляет категорию. Подкатегории поднимаются на уровень выше,
+// у постов категория сбрасывается.
+func (r *CategoryRepo) DeleteCategory(ctx context.Context, id uuid.UUID) error {
+	const op = "repository.category_repository.DeleteCategory"
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Переносим дочерние категории к родителю удаляемой
+	_, err = tx.Exec(ctx, `
+		UPDATE blog_categories
+		SET parent_id = (SELECT parent_id FROM blog_categories WHERE id = $1), updated_at = NOW()
+		WHERE parent_id = $1
+	`, id)
+	if err != nil {
+		return fmt.Errorf("%s failed to reparent children: %w", op, err)
+	}
+
+	result, err := tx.Exec(ctx, `DELETE FROM blog_categories WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrCategoryNotFound)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetCategoryByID возвращает категорию по ID
+func (r *CategoryRepo) GetCategoryByID(ctx context.Context, id uuid.UUID) (models.BlogCategory, error) {
+	const op = "repository.category_repository.GetCategoryByID"
+
+	return r.getCategory(ctx, op, sq.Eq{"id": id})
+}
+
+// GetCategoryBySlug возвращает категорию по slug
+func (r *CategoryRepo) GetCategoryBySlug(ctx context.Context, slug string) (models.BlogCategory, error) {
+	const op = "repository.category_repository.GetCategoryBySlug"
+
+	return r.getCategory(ctx, op, sq.Eq{"slug": slug})
+}
+
+// ListCategories возвращает все категории, упорядоченные по позиции и названию
+func (r *CategoryRepo) ListCategories(ctx context.Context) ([]models.BlogCategory, error) {
+	const op = "repository.category_repository.ListCategories"
+
+	query, args, err := r.categorySelect().
+		OrderBy("position", "name").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var categories []models.BlogCategory
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		categories = append(categories, category)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return categories, nil
+}
+
+func (r *CategoryRepo) getCategory(ctx context.Context, op string, where sq.Eq) (models.BlogCategory, error) {
+	query, args, err := r.categorySelect().Where(where).ToSql()
+	if err != nil {
+		return models.BlogCategory{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	category, err := scanCategory(r.db.QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.BlogCategory{}, fmt.Errorf("%s: %w", op, storage.ErrCategoryNotFound)
+		}
+		return models.BlogCategory{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return category, nil
+}
+
+func (r *CategoryRepo) categorySelect() sq.SelectBuilder {
+	return r.sb.Select(
+		"id",
+		"parent_id",
+		"name",
+		"slug",
+		"COALESCE(description, '')",
+		"position",
+		"created_at",
+		"updated_at",
+	).From("blog_categories")
+}
+
+func scanCategory(row pgx.Row) (models.BlogCategory, error) {
+	var category models.BlogCategory
+	err := row.Scan(
+		&category.ID,
+		&category.ParentID,
+		&category.Name,
+		&category.Slug,
+		&category.Description,
+		&category.Position,
+		&category.CreatedAt,
+		&category.UpdatedAt,
+	)
+	return category, err
+}
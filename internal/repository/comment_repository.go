@@ -0,0 +1,291 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"premium_caste/internal/domain/models"
+	"premium_caste/internal/lib/pagination"
+	"premium_caste/internal/storage"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type CommentRepo struct {
+	db *txDB
+	sb sq.StatementBuilderType
+}
+
+func NewCommentRepository(db *pgxpool.Pool) *CommentRepo {
+	return &CommentRepo{
+		db: newTxDB(db),
+		sb: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// CreateComment сохраняет новый комментарий и возвращает его ID
+func (r *CommentRepo) CreateComment(ctx context.Context, comment models.Comment) (uuid.UUID, error) {
+	const op = "repository.comment_repository.CreateComment"
+
+	query, args, err := r.sb.Insert("comments").
+		Columns(
+			"post_id",
+			"user_id",
+			"parent_id",
+			"content",
+			"status",
+		).
+		Values(
+			comment.PostID,
+			comment.UserID,
+			comment.ParentID,
+			comment.Content,
+			comment.Status,
+		).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var id uuid.UUID
+	if err := r.db.QueryRow(ctx, query, args...).Scan(&id); err != nil {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// GetCommentByID возвращает комментарий по ID независимо от статуса
+func (r *CommentRepo) GetCommentByID(ctx context.Context, id uuid.UUID) (models.Comment, error) {
+	const op = "repository.comment_repository.GetCommentByID"
+
+	query, args, err := r.commentSelect().Where(sq.Eq{"c.id": id}).ToSql()
+	if err != nil {
+		return models.Comment{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	comment, err := scanComment(r.db.QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Comment{}, fmt.Errorf("%s: %w", op, storage.ErrCommentNotFound)
+		}
+		return models.Comment{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return comment, nil
+}
+
+// ListPostComments возвращает одобренные комментарии поста в хронологическом порядке
+func (r *CommentRepo) ListPostComments(ctx context.Context, postID uuid.UUID) ([]models.Comment, error) {
+	const op = "repository.comment_repository.ListPostComments"
+
+	query, args, err := r.commentSelect().
+		Where(sq.Eq{"c.post_id": postID, "c.status": models.CommentStatusApproved}).
+		OrderBy("c.created_at").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	comments, err := r.queryComments(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return comments, nil
+}
+
+// commentKeyset порядок очереди модерации: сначала новые
+var commentKeyset = pagination.Keyset{Key: "c.created_at", ID: "c.id", Desc: true}
+
+// ListComments возвращает комментарии для модерации с фильтром по статусу и пагинацией
+func (r *CommentRepo) ListComments(ctx context.Context, filter models.CommentFilter, page, perPage int) ([]models.Comment, int, error) {
+	const op = "repository.comment_repository.ListComments"
+
+	page, perPage = pagination.Normalize(page, perPage)
+
+	queryBuilder := r.commentSelect()
+	countBuilder := r.sb.Select("COUNT(*)").From("comments c")
+	if filter.Status != "" {
+		queryBuilder = queryBuilder.Where(sq.Eq{"c.status": filter.Status})
+		countBuilder = countBuilder.Where(sq.Eq{"c.status": filter.Status})
+	}
+
+	total, err := pagination.Count(ctx, r.db, countBuilder)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	query, args, err := commentKeyset.Apply(queryBuilder, filter.After, page, perPage).ToSql()
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	comments, err := r.queryComments(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return comments, total, nil
+}
+
+// UpdateCommentStatus меняет статус комментария и запоминает модератора
+func (r *CommentRepo) UpdateCommentStatus(ctx context.Context, id uuid.UUID, status string, moderatorID uuid.UUID) error {
+	const op = "repository.comment_repository.UpdateCommentStatus"
+
+	now := time.Now()
+	query, args, err := r.sb.Update("comments").
+		Set("status", status).
+		Set("moderated_by", moderatorID).
+		Set("moderated_at", now).
+		Set("updated_at", now).
+		Where(sq.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	result, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrCommentNotFound)
+	}
+
+	return nil
+}
+
+// DeleteComment удаляет комментарий вместе с ответами на него
+func (r *CommentRepo) DeleteComment(ctx context.Context, id uuid.UUID) error {
+	const op = "repository.comment_repository.DeleteComment"
+
+	result, err := r.db.Exec(ctx, `DELETE FROM comments WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrCommentNotFound)
+	}
+
+	return nil
+}
+
+// CreateCommentWithinLimit сохраняет комментарий, если с since пользователь оставил
+// меньше limit комментариев, иначе возвращает storage.ErrCommentRateLimited.
+// Проверка и вставка идут под блокировкой пользователя до конца транзакции,
+// поэтому параллельные запросы не превышают лимит
+func (r *CommentRepo) CreateCommentWithinLimit(ctx context.Context, comment models.Comment, since time.Time, limit int) (uuid.UUID, error) {
+	const op = "repository.comment_repository.CreateCommentWithinLimit"
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%s failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('comments:' || $1::text))`, comment.UserID); err != nil {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var id uuid.UUID
+	err = tx.QueryRow(ctx, `
+		INSERT INTO comments (post_id, user_id, parent_id, content, status)
+		SELECT $1::uuid, $2::uuid, $3::uuid, $4::text, $5::text
+		WHERE (SELECT COUNT(*) FROM comments WHERE user_id = $2 AND created_at >= $6) < $7
+		RETURNING id
+	`, comment.PostID, comment.UserID, comment.ParentID, comment.Content, comment.Status, since, limit).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, fmt.Errorf("%s: %w", op, storage.ErrCommentRateLimited)
+		}
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return uuid.Nil, fmt.Errorf("%s failed to commit transaction: %w", op, err)
+	}
+
+	return id, nil
+}
+
+// GetPostCommentSettings возвращает статус поста и признак закрытых комментариев
+func (r *CommentRepo) GetPostCommentSettings(ctx context.Context, postID uuid.UUID) (models.PostCommentSettings, error) {
+	const op = "repository.comment_repository.GetPostCommentSettings"
+
+	var settings models.PostCommentSettings
+	err := r.db.QueryRow(ctx, `
+		SELECT status, comments_closed FROM blog_posts WHERE id = $1
+	`, postID).Scan(&settings.Status, &settings.CommentsClosed)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.PostCommentSettings{}, fmt.Errorf("%s: %w", op, storage.ErrPostNotFound)
+		}
+		return models.PostCommentSettings{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return settings, nil
+}
+
+func (r *CommentRepo) queryComments(ctx context.Context, query string, args ...interface{}) ([]models.Comment, error) {
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []models.Comment
+	for rows.Next() {
+		comment, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+
+	return comments, rows.Err()
+}
+
+func (r *CommentRepo) commentSelect() sq.SelectBuilder {
+	return r.sb.Select(
+		"c.id",
+		"c.post_id",
+		"c.user_id",
+		"c.parent_id",
+		"c.content",
+		"c.status",
+		"c.moderated_by",
+		"c.moderated_at",
+		"c.created_at",
+		"c.updated_at",
+		"COALESCE(u.name, '')",
+	).
+		From("comments c").
+		LeftJoin("users u ON u.id = c.user_id")
+}
+
+func scanComment(row pgx.Row) (models.Comment, error) {
+	var comment models.Comment
+	err := row.Scan(
+		&comment.ID,
+		&comment.PostID,
+		&comment.UserID,
+		&comment.ParentID,
+		&comment.Content,
+		&comment.Status,
+		&comment.ModeratedBy,
+		&comment.ModeratedAt,
+		&comment.CreatedAt,
+		&comment.UpdatedAt,
+		&comment.AuthorName,
+	)
+	return comment, err
+}
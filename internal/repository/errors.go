@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"errors"
+	"premium_caste/internal/domain/apperr"
+
+	"github.com/jackc/pgconn"
+)
+
+// pgUniqueViolation код ошибки Postgres при нарушении уникального ограничения
+const pgUniqueViolation = "23505"
+
+// isUniqueViolation проверяет по коду ошибки Postgres, что запрос нарушил уникальное ограничение
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation
+}
+
+// asConflict заменяет нарушение уникального ограничения на ошибку конфликта conflict,
+// сохраняя исходную ошибку в цепочке. Прочие ошибки возвращаются без изменений
+func asConflict(err error, conflict *apperr.Error) error {
+	if isUniqueViolation(err) {
+		return conflict.Wrap(err)
+	}
+
+	return err
+}
+
+// Коды ошибок Postgres, после которых транзакцию можно безопасно повторить целиком
+const (
+	pgSerializationFailure = "40001"
+	pgDeadlockDetected     = "40P01"
+)
+
+// isRetryable проверяет, что транзакция откатилась из-за конфликта с параллельной
+// транзакцией и ее можно повторить
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+
+	return pgErr.Code == pgSerializationFailure || pgErr.Code == pgDeadlockDetected
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	redisapp "premium_caste/internal/storage/redis"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// feedCachePrefix общий префикс ключей кэша лент и sitemap
+const feedCachePrefix = "feed:"
+
+type RedisFeedCache struct {
+	Client *redisapp.Client
+}
+
+func NewRedisFeedCache(client *redisapp.Client) *RedisFeedCache {
+	return &RedisFeedCache{Client: client}
+}
+
+// Get возвращает закэшированный документ. Второе значение false, если ключа нет
+func (r *RedisFeedCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	const op = "repository.feed_cache.Get"
+
+	data, err := r.Client.Get(ctx, feedCachePrefix+key).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, false, nil
+		}
+		return nil, false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return data, true, nil
+}
+
+func (r *RedisFeedCache) Set(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	const op = "repository.feed_cache.Set"
+
+	if err := r.Client.Set(ctx, feedCachePrefix+key, data, ttl).Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// Invalidate удаляет все закэшированные ленты и sitemap
+func (r *RedisFeedCache) Invalidate(ctx context.Context) error {
+	const op = "repository.feed_cache.Invalidate"
+
+	var keys []string
+	iter := r.Client.Scan(ctx, 0, feedCachePrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(keys) == 0 {
+		return nil
+	}
+
+	if err := r.Client.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
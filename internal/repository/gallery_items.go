@@ -0,0 +1,419 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"premium_caste/internal/domain/models"
+	"premium_caste/internal/storage"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/lib/pq"
+)
+
+// AddGalleryItems добавляет изображения в конец галереи. Уже добавленные медиа пропускаются
+func (r *GalleryRepo) AddGalleryItems(ctx context.Context, galleryID uuid.UUID, items []models.GalleryItem) error {
+	const op = "repository.GalleryRepo.AddGalleryItems"
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockGallery(ctx, tx, galleryID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := insertGalleryItems(ctx, tx, galleryID, items); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := setGalleryCover(ctx, tx, galleryID, nil); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+// UpdateGalleryItem обновляет подпись и alt-текст изображения галереи
+func (r *GalleryRepo) UpdateGalleryItem(ctx context.Context, item models.GalleryItem) error {
+	const op = "repository.GalleryRepo.UpdateGalleryItem"
+
+	result, err := r.db.Exec(ctx, `
+		UPDATE gallery_items SET caption = $3, alt_text = $4
+		WHERE gallery_id = $1 AND media_id = $2
+	`, item.GalleryID, item.MediaID, item.Caption, item.AltText)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrGalleryItemNotFound)
+	}
+
+	return nil
+}
+
+// RemoveGalleryItem убирает изображение из галереи и уплотняет позиции оставшихся
+func (r *GalleryRepo) RemoveGalleryItem(ctx context.Context, galleryID, mediaID uuid.UUID) error {
+	const op = "repository.GalleryRepo.RemoveGalleryItem"
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockGallery(ctx, tx, galleryID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	result, err := tx.Exec(ctx, `DELETE FROM gallery_items WHERE gallery_id = $1 AND media_id = $2`, galleryID, mediaID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrGalleryItemNotFound)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE gallery_items gi
+		SET position = ordered.rn - 1
+		FROM (
+			SELECT media_id, ROW_NUMBER() OVER (ORDER BY position) AS rn
+			FROM gallery_items
+			WHERE gallery_id = $1
+		) ordered
+		WHERE gi.gallery_id = $1 AND gi.media_id = ordered.media_id
+	`, galleryID)
+	if err != nil {
+		return fmt.Errorf("%s failed to renumber items: %w", op, err)
+	}
+
+	if err := setGalleryCover(ctx, tx, galleryID, nil); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+// ReorderGalleryItems задает новый порядок изображений. mediaIDs должен содержать
+// каждое изображение галереи ровно один раз
+func (r *GalleryRepo) ReorderGalleryItems(ctx context.Context, galleryID uuid.UUID, mediaIDs []uuid.UUID) error {
+	const op = "repository.GalleryRepo.ReorderGalleryItems"
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockGallery(ctx, tx, galleryID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := tx.Query(ctx, `SELECT media_id FROM gallery_items WHERE gallery_id = $1`, galleryID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	current := make(map[uuid.UUID]bool)
+	for rows.Next() {
+		var mediaID uuid.UUID
+		if err := rows.Scan(&mediaID); err != nil {
+			rows.Close()
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		current[mediaID] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(mediaIDs) != len(current) {
+		return fmt.Errorf("%s: %w", op, storage.ErrInvalidGalleryOrder)
+	}
+	seen := make(map[uuid.UUID]bool, len(mediaIDs))
+	for _, mediaID := range mediaIDs {
+		if !current[mediaID] || seen[mediaID] {
+			return fmt.Errorf("%s: %w", op, storage.ErrInvalidGalleryOrder)
+		}
+		seen[mediaID] = true
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE gallery_items gi
+		SET position = o.ord - 1
+		FROM unnest($2::uuid[]) WITH ORDINALITY AS o(media_id, ord)
+		WHERE gi.gallery_id = $1 AND gi.media_id = o.media_id
+	`, galleryID, pq.Array(uuidStrings(mediaIDs)))
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+// SetGalleryCover делает обложкой изображение галереи
+func (r *GalleryRepo) SetGalleryCover(ctx context.Context, galleryID, mediaID uuid.UUID) error {
+	const op = "repository.GalleryRepo.SetGalleryCover"
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockGallery(ctx, tx, galleryID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := setGalleryCover(ctx, tx, galleryID, &mediaID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetMediaIDsByPaths сопоставляет пути изображений с записями media
+func (r *GalleryRepo) GetMediaIDsByPaths(ctx context.Context, paths []string) (map[string]uuid.UUID, error) {
+	const op = "repository.GalleryRepo.GetMediaIDsByPaths"
+
+	result := make(map[string]uuid.UUID, len(paths))
+	if len(paths) == 0 {
+		return result, nil
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT DISTINCT ON (p.path) p.path, m.id
+		FROM unnest($1::text[]) AS p(path)
+		JOIN media m ON ltrim(m.storage_path, '/') = ltrim(p.path, '/')
+		ORDER BY p.path, m.created_at
+	`, pq.Array(paths))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			path    string
+			mediaID uuid.UUID
+		)
+		if err := rows.Scan(&path, &mediaID); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		result[path] = mediaID
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return result, nil
+}
+
+// attachGalleryItems подгружает изображения галерей одним запросом и заполняет
+// совместимые поля Images и CoverImageIndex
+func (r *GalleryRepo) attachGalleryItems(ctx context.Context, galleries []models.Gallery) error {
+	if len(galleries) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(galleries))
+	for _, gallery := range galleries {
+		ids = append(ids, gallery.ID)
+	}
+
+	rows, err := r.db.Query(ctx, `
+		SELECT gi.gallery_id, gi.media_id, gi.position,
+			COALESCE(gi.caption, ''), COALESCE(gi.alt_text, ''),
+			m.storage_path, gi.created_at
+		FROM gallery_items gi
+		JOIN media m ON m.id = gi.media_id
+		WHERE gi.gallery_id = ANY($1::uuid[])
+		ORDER BY gi.gallery_id, gi.position
+	`, pq.Array(uuidStrings(ids)))
+	if err != nil {
+		return fmt.Errorf("failed to query gallery items: %w", err)
+	}
+	defer rows.Close()
+
+	items := make(map[uuid.UUID][]models.GalleryItem)
+	for rows.Next() {
+		var item models.GalleryItem
+		err := rows.Scan(
+			&item.GalleryID,
+			&item.MediaID,
+			&item.Position,
+			&item.Caption,
+			&item.AltText,
+			&item.StoragePath,
+			&item.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to scan gallery item: %w", err)
+		}
+		items[item.GalleryID] = append(items[item.GalleryID], item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read gallery items: %w", err)
+	}
+
+	for i := range galleries {
+		galleries[i].Items = items[galleries[i].ID]
+		galleries[i].Images = make([]string, 0, len(galleries[i].Items))
+		galleries[i].CoverImageIndex = 0
+		for idx, item := range galleries[i].Items {
+			galleries[i].Images = append(galleries[i].Images, item.StoragePath)
+			if galleries[i].CoverMediaID != nil && *galleries[i].CoverMediaID == item.MediaID {
+				galleries[i].CoverImageIndex = idx
+			}
+		}
+	}
+
+	return nil
+}
+
+// insertGalleryItems добавляет изображения после уже существующих
+func insertGalleryItems(ctx context.Context, tx pgx.Tx, galleryID uuid.UUID, items []models.GalleryItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	mediaIDs := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		mediaIDs = append(mediaIDs, item.MediaID)
+	}
+
+	var found int
+	err := tx.QueryRow(ctx, `
+		SELECT COUNT(DISTINCT id) FROM media WHERE id = ANY($1::uuid[])
+	`, pq.Array(uuidStrings(mediaIDs))).Scan(&found)
+	if err != nil {
+		return fmt.Errorf("failed to check media: %w", err)
+	}
+	if found != len(uniqueUUIDs(mediaIDs)) {
+		return storage.ErrMediaNotFound
+	}
+
+	var next int
+	err = tx.QueryRow(ctx, `
+		SELECT COALESCE(MAX(position) + 1, 0) FROM gallery_items WHERE gallery_id = $1
+	`, galleryID).Scan(&next)
+	if err != nil {
+		return fmt.Errorf("failed to get next position: %w", err)
+	}
+
+	for _, item := range items {
+		result, err := tx.Exec(ctx, `
+			INSERT INTO gallery_items (gallery_id, media_id, position, caption, alt_text)
+			VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''))
+			ON CONFLICT (gallery_id, media_id) DO NOTHING
+		`, galleryID, item.MediaID, next, item.Caption, item.AltText)
+		if err != nil {
+			return fmt.Errorf("failed to insert gallery item: %w", err)
+		}
+		if result.RowsAffected() > 0 {
+			next++
+		}
+	}
+
+	return nil
+}
+
+// setGalleryCover назначает обложку. Если coverID nil или нулевой, а текущая обложка
+// отсутствует в галерее, обложкой становится первое изображение
+func setGalleryCover(ctx context.Context, tx pgx.Tx, galleryID uuid.UUID, coverID *uuid.UUID) error {
+	if coverID != nil && *coverID != uuid.Nil {
+		result, err := tx.Exec(ctx, `
+			UPDATE galleries SET cover_media_id = $2
+			WHERE id = $1 AND EXISTS (
+				SELECT 1 FROM gallery_items WHERE gallery_id = $1 AND media_id = $2
+			)
+		`, galleryID, *coverID)
+		if err != nil {
+			return fmt.Errorf("failed to set gallery cover: %w", err)
+		}
+		if result.RowsAffected() == 0 {
+			return storage.ErrGalleryItemNotFound
+		}
+		return nil
+	}
+
+	if coverID != nil {
+		if _, err := tx.Exec(ctx, `UPDATE galleries SET cover_media_id = NULL WHERE id = $1`, galleryID); err != nil {
+			return fmt.Errorf("failed to reset gallery cover: %w", err)
+		}
+	}
+
+	_, err := tx.Exec(ctx, `
+		UPDATE galleries g
+		SET cover_media_id = (
+			SELECT media_id FROM gallery_items WHERE gallery_id = $1 ORDER BY position LIMIT 1
+		)
+		WHERE g.id = $1 AND (
+			g.cover_media_id IS NULL OR NOT EXISTS (
+				SELECT 1 FROM gallery_items WHERE gallery_id = $1 AND media_id = g.cover_media_id
+			)
+		)
+	`, galleryID)
+	if err != nil {
+		return fmt.Errorf("failed to update gallery cover: %w", err)
+	}
+
+	return nil
+}
+
+// lockGallery блокирует строку галереи до конца транзакции и обновляет updated_at
+func lockGallery(ctx context.Context, tx pgx.Tx, galleryID uuid.UUID) error {
+	var id uuid.UUID
+	err := tx.QueryRow(ctx, `
+		UPDATE galleries SET updated_at = NOW() WHERE id = $1 RETURNING id
+	`, galleryID).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return storage.ErrGalleryNotFound
+		}
+		return fmt.Errorf("failed to lock gallery: %w", err)
+	}
+
+	return nil
+}
+
+func uuidStrings(ids []uuid.UUID) []string {
+	result := make([]string, 0, len(ids))
+	for _, id := range ids {
+		result = append(result, id.String())
+	}
+	return result
+}
+
+func uniqueUUIDs(ids []uuid.UUID) map[uuid.UUID]struct{} {
+	result := make(map[uuid.UUID]struct{}, len(ids))
+	for _, id := range ids {
+		result[id] = struct{}{}
+	}
+	return result
+}
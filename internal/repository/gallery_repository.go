@@ -5,27 +5,30 @@ import (
 	"errors"
 	"fmt"
 	"premium_caste/internal/domain/models"
+	"premium_caste/internal/lib/pagination"
+	"premium_caste/internal/storage"
+	"strings"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/lib/pq"
 )
 
 type GalleryRepo struct {
-	db *pgxpool.Pool
+	db *txDB
 	sb squirrel.StatementBuilderType
 }
 
 func NewGalleryRepo(db *pgxpool.Pool) *GalleryRepo {
 	return &GalleryRepo{
-		db: db,
+		db: newTxDB(db),
 		sb: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
 	}
 }
 
-// CreateGallery создает новую галерею и возвращает её ID
+// CreateGallery создает новую галерею вместе с её изображениями и возвращает ID
 func (r *GalleryRepo) CreateGallery(ctx context.Context, gallery models.Gallery) (uuid.UUID, error) {
 	const op = "repository.GalleryRepo.CreateGallery"
 
@@ -34,8 +37,6 @@ func (r *GalleryRepo) CreateGallery(ctx context.Context, gallery models.Gallery)
 			"title",
 			"slug",
 			"description",
-			"images",
-			"cover_image_index",
 			"author_id",
 			"status",
 			"metadata",
@@ -45,12 +46,10 @@ func (r *GalleryRepo) CreateGallery(ctx context.Context, gallery models.Gallery)
 			gallery.Title,
 			gallery.Slug,
 			gallery.Description,
-			gallery.Images,
-			gallery.CoverImageIndex,
 			gallery.AuthorID,
 			gallery.Status,
 			gallery.Metadata,
-			gallery.Tags,
+			squirrel.Expr(canonicalTags("?"), pq.Array(gallery.Tags)),
 		).
 		Suffix("RETURNING id").
 		ToSql()
@@ -58,12 +57,30 @@ func (r *GalleryRepo) CreateGallery(ctx context.Context, gallery models.Gallery)
 		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
 	}
 
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%s failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
 	var id uuid.UUID
-	err = r.db.QueryRow(ctx, query, args...).Scan(&id)
+	err = tx.QueryRow(ctx, query, args...).Scan(&id)
 	if err != nil {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, asConflict(err, storage.ErrSlugTaken))
+	}
+
+	if err := insertGalleryItems(ctx, tx, id, gallery.Items); err != nil {
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := setGalleryCover(ctx, tx, id, gallery.CoverMediaID); err != nil {
 		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
 	}
 
+	if err := tx.Commit(ctx); err != nil {
+		return uuid.Nil, fmt.Errorf("%s failed to commit transaction: %w", op, err)
+	}
+
 	return id, nil
 }
 
@@ -75,11 +92,9 @@ func (r *GalleryRepo) UpdateGallery(ctx context.Context, gallery models.Gallery)
 		Set("title", gallery.Title).
 		Set("slug", gallery.Slug).
 		Set("description", gallery.Description).
-		Set("images", gallery.Images).
-		Set("cover_image_index", gallery.CoverImageIndex).
 		Set("status", gallery.Status).
 		Set("metadata", gallery.Metadata).
-		Set("tags", gallery.Tags).
+		Set("tags", squirrel.Expr(canonicalTags("?"), pq.Array(gallery.Tags))).
 		Set("updated_at", squirrel.Expr("NOW()")).
 		Where(squirrel.Eq{"id": gallery.ID}).
 		ToSql()
@@ -87,11 +102,45 @@ func (r *GalleryRepo) UpdateGallery(ctx context.Context, gallery models.Gallery)
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
-	_, err = r.db.Exec(ctx, query, args...)
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Запоминаем текущий slug, чтобы при переименовании сохранить его в истории
+	oldSlug, err := lockCurrentSlug(ctx, tx, "galleries", gallery.ID, storage.ErrGalleryNotFound)
 	if err != nil {
 		return fmt.Errorf("%s: %w", op, err)
 	}
 
+	_, err = tx.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, asConflict(err, storage.ErrSlugTaken))
+	}
+
+	if err := recordSlugChange(ctx, tx, "gallery_slug_history", "gallery_id", gallery.ID, oldSlug, gallery.Slug); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Список изображений заменяется только если он передан
+	if gallery.Items != nil {
+		if _, err := tx.Exec(ctx, `DELETE FROM gallery_items WHERE gallery_id = $1`, gallery.ID); err != nil {
+			return fmt.Errorf("%s failed to clear gallery items: %w", op, err)
+		}
+		if err := insertGalleryItems(ctx, tx, gallery.ID, gallery.Items); err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := setGalleryCover(ctx, tx, gallery.ID, gallery.CoverMediaID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s failed to commit transaction: %w", op, err)
+	}
+
 	return nil
 }
 
@@ -144,8 +193,7 @@ func (r *GalleryRepo) GetGalleryByID(ctx context.Context, id uuid.UUID) (models.
 		"title",
 		"slug",
 		"description",
-		"images",
-		"cover_image_index",
+		"cover_media_id",
 		"author_id",
 		"status",
 		"published_at",
@@ -167,8 +215,7 @@ func (r *GalleryRepo) GetGalleryByID(ctx context.Context, id uuid.UUID) (models.
 		&gallery.Title,
 		&gallery.Slug,
 		&gallery.Description,
-		&gallery.Images,
-		&gallery.CoverImageIndex,
+		&gallery.CoverMediaID,
 		&gallery.AuthorID,
 		&gallery.Status,
 		&gallery.PublishedAt,
@@ -179,58 +226,54 @@ func (r *GalleryRepo) GetGalleryByID(ctx context.Context, id uuid.UUID) (models.
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
-			return models.Gallery{}, fmt.Errorf("%s: %w", op, err)
+			return models.Gallery{}, fmt.Errorf("%s: %w", op, storage.ErrGalleryNotFound)
 		}
 		return models.Gallery{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return gallery, nil
+	galleries := []models.Gallery{gallery}
+	if err := r.attachGalleryItems(ctx, galleries); err != nil {
+		return models.Gallery{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return galleries[0], nil
 }
 
-func (r *GalleryRepo) GetGalleries(
+// ListGalleries возвращает страницу галерей, удовлетворяющих фильтру, и их общее количество.
+// Если в фильтре задан курсор After, страница начинается сразу после него и page не учитывается
+func (r *GalleryRepo) ListGalleries(
 	ctx context.Context,
-	statusFilter string, // "all", "draft", "published", "archived"
+	filter models.GalleryFilter,
 	page int,
 	perPage int,
 ) ([]models.Gallery, int, error) {
-	const op = "repository.GalleryRepo.GetGalleries"
+	const op = "repository.GalleryRepo.ListGalleries"
 
-	// Проверка и корректировка параметров пагинации
-	if page < 1 {
-		page = 1
-	}
-	if perPage < 1 || perPage > 100 {
-		perPage = 10
-	}
+	page, perPage = pagination.Normalize(page, perPage)
 
-	// Строим базовый запрос
 	queryBuilder := r.sb.Select(
-		"id", "title", "slug", "description", "images",
-		"cover_image_index", "author_id", "status",
-		"metadata", "tags", "created_at", "updated_at",
-	).From("galleries")
+		"g.id", "g.title", "g.slug", "g.description",
+		"g.cover_media_id", "g.author_id", "g.status", "g.published_at",
+		"g.metadata", "g.tags", "g.created_at", "g.updated_at",
+	).From("galleries g")
 
-	// Применяем фильтр по статусу
-	switch statusFilter {
-	case "draft", "published", "archived":
-		queryBuilder = queryBuilder.Where(squirrel.Eq{"status": statusFilter})
-	case "all":
-		// Без фильтрации
-	default:
-		return nil, 0, fmt.Errorf("%s: invalid status filter '%s'", op, statusFilter)
+	queryBuilder, err := applyGalleryFilter(queryBuilder, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
 	}
 
-	// Получаем общее количество галерей (для пагинации)
-	totalCount, err := r.getTotalCount(ctx)
+	// Общее количество считается с теми же фильтрами, но без курсора
+	countBuilder, err := applyGalleryFilter(r.sb.Select("COUNT(*)").From("galleries g"), filter)
 	if err != nil {
 		return nil, 0, fmt.Errorf("%s: %w", op, err)
 	}
 
-	// Применяем пагинацию
-	queryBuilder = queryBuilder.
-		OrderBy("created_at DESC").
-		Limit(uint64(perPage)).
-		Offset(uint64((page - 1) * perPage))
+	totalCount, err := pagination.Count(ctx, r.db, countBuilder)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	queryBuilder = galleryKeyset(filter.Sort).Apply(queryBuilder, filter.After, page, perPage)
 
 	// Формируем SQL-запрос
 	query, args, err := queryBuilder.ToSql()
@@ -254,10 +297,10 @@ func (r *GalleryRepo) GetGalleries(
 			&gallery.Title,
 			&gallery.Slug,
 			&gallery.Description,
-			&gallery.Images,
-			&gallery.CoverImageIndex,
+			&gallery.CoverMediaID,
 			&gallery.AuthorID,
 			&gallery.Status,
+			&gallery.PublishedAt,
 			&gallery.Metadata,
 			&gallery.Tags,
 			&gallery.CreatedAt,
@@ -269,95 +312,75 @@ func (r *GalleryRepo) GetGalleries(
 		galleries = append(galleries, gallery)
 	}
 
-	return galleries, totalCount, nil
-}
-
-// Вспомогательная функция для получения общего количества записей
-func (b *GalleryRepo) getTotalCount(ctx context.Context) (int, error) {
-	queryBuilder := squirrel.Select("COUNT(*)").
-		From("galleries")
-
-	query, args, err := queryBuilder.ToSql()
-	if err != nil {
-		return 0, fmt.Errorf("error build query: %w", err)
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
 	}
 
-	var count int
-	err = b.db.QueryRow(ctx, query, args...).Scan(&count)
-	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return 0, nil
-		}
-		return 0, fmt.Errorf("error execute query: %w (SQL: %s)", err, query)
+	if err := r.attachGalleryItems(ctx, galleries); err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return count, nil
+	return galleries, totalCount, nil
 }
 
-// GetGalleriesByTags возвращает галереи, отфильтрованные по тегам
-func (b *GalleryRepo) GetGalleriesByTags(
-	ctx context.Context,
-	tags []string, // Теги для фильтрации
-	matchAll bool, // true: AND-фильтр (все теги), false: OR-фильтр (любой из тегов)
-) ([]models.Gallery, error) {
-	const op = "repository.TagRepository.GetGalleriesByTags"
-
-	// Базовый запрос
-	queryBuilder := b.sb.Select(
-		"id", "title", "slug", "description", "images",
-		"cover_image_index", "author_id", "status",
-		"metadata", "tags", "created_at", "updated_at",
-	).From("galleries")
-
-	// Применяем фильтр по тегам
-	if len(tags) > 0 {
-		if matchAll {
+// applyGalleryFilter добавляет в запрос условия фильтрации галерей.
+// Запрос должен выбирать из galleries с алиасом g.
+func applyGalleryFilter(builder squirrel.SelectBuilder, filter models.GalleryFilter) (squirrel.SelectBuilder, error) {
+	// Фильтр по статусу
+	switch filter.Status {
+	case "draft", "published", "archived":
+		builder = builder.Where(squirrel.Eq{"g.status": filter.Status})
+	case "all", "":
+
+	default:
+		return builder, fmt.Errorf("invalid status filter '%s'", filter.Status)
+	}
+
+	// Алиасы заменяются на актуальные теги, поэтому старые ссылки на теги продолжают работать
+	if len(filter.Tags) > 0 {
+		if filter.MatchAllTags {
 			// AND-условие: галерея должна содержать ВСЕ указанные теги
-			queryBuilder = queryBuilder.Where("tags @> ?", pq.Array(tags))
+			builder = builder.Where("g.tags @> "+canonicalTags("?"), pq.Array(filter.Tags))
 		} else {
 			// OR-условие: галерея должна содержать ЛЮБОЙ из указанных тегов
-			queryBuilder = queryBuilder.Where("tags && ?", pq.Array(tags))
+			builder = builder.Where("g.tags && "+canonicalTags("?"), pq.Array(filter.Tags))
 		}
 	}
 
-	// Формируем SQL-запрос
-	query, args, err := queryBuilder.ToSql()
-	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
+	if filter.AuthorID != nil {
+		builder = builder.Where(squirrel.Eq{"g.author_id": *filter.AuthorID})
 	}
 
-	// Выполняем запрос
-	rows, err := b.db.Query(ctx, query, args...)
-	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
+	// Диапазон дат: для опубликованных галерей берем дату публикации, для остальных - дату создания
+	if filter.DateFrom != nil {
+		builder = builder.Where(squirrel.GtOrEq{"COALESCE(g.published_at, g.created_at)": *filter.DateFrom})
+	}
+	if filter.DateTo != nil {
+		builder = builder.Where(squirrel.LtOrEq{"COALESCE(g.published_at, g.created_at)": *filter.DateTo})
 	}
-	defer rows.Close()
 
-	// Сканируем результаты
-	var galleries []models.Gallery
-	for rows.Next() {
-		var gallery models.Gallery
-		err := rows.Scan(
-			&gallery.ID,
-			&gallery.Title,
-			&gallery.Slug,
-			&gallery.Description,
-			&gallery.Images,
-			&gallery.CoverImageIndex,
-			&gallery.AuthorID,
-			&gallery.Status,
-			&gallery.Metadata,
-			&gallery.Tags,
-			&gallery.CreatedAt,
-			&gallery.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("%s: %w", op, err)
-		}
-		galleries = append(galleries, gallery)
+	if filter.Query != "" {
+		builder = builder.Where("g.title ILIKE ? ESCAPE '\\'", "%"+escapeLike(filter.Query)+"%")
+	}
+
+	return builder, nil
+}
+
+// galleryKeyset возвращает порядок списка галерей для сортировки
+func galleryKeyset(sort string) pagination.Keyset {
+	switch sort {
+	case models.GallerySortPublished:
+		return pagination.Keyset{Key: "COALESCE(g.published_at, g.created_at)", ID: "g.id", Desc: true}
+	case models.GallerySortTitle:
+		return pagination.Keyset{Key: "g.title", ID: "g.id", Text: true}
+	default:
+		return pagination.Keyset{Key: "g.created_at", ID: "g.id", Desc: true}
 	}
+}
 
-	return galleries, nil
+// escapeLike экранирует спецсимволы шаблона LIKE
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
 }
 
 // AddTags добавляет теги к галерее
@@ -366,7 +389,7 @@ func (b *GalleryRepo) AddTags(ctx context.Context, galleryID string, tags []stri
 
 	query := `
 		UPDATE galleries
-		SET tags = array_cat(tags, $1)
+		SET tags = ` + canonicalTags("array_cat(tags, $1::varchar[])") + `
 		WHERE id = $2
 	`
 
@@ -387,7 +410,7 @@ func (b *GalleryRepo) RemoveTags(ctx context.Context, galleryID string, tagsToRe
 	for _, tag := range tagsToRemove {
 		_, err := b.db.Exec(ctx, `
 			UPDATE galleries 
-			SET tags = array_remove(tags, $1::varchar) 
+			SET tags = array_remove(tags, COALESCE((SELECT tag FROM gallery_tag_aliases WHERE alias = $1), $1::varchar))
 			WHERE id = $2
 		`, tag, galleryID)
 		if err != nil {
@@ -404,7 +427,7 @@ func (b *GalleryRepo) UpdateTags(ctx context.Context, galleryID string, tags []s
 
 	query := `
 		UPDATE galleries
-		SET tags = $1
+		SET tags = ` + canonicalTags("$1") + `
 		WHERE id = $2
 	`
 
@@ -423,7 +446,7 @@ func (b *GalleryRepo) HasTags(ctx context.Context, galleryID string, tags []stri
 	query := `
 		SELECT COUNT(*) > 0
 		FROM galleries
-		WHERE id = $1 AND tags @> $2
+		WHERE id = $1 AND tags @> ` + canonicalTags("$2") + `
 	`
 
 	var hasTags bool
@@ -453,3 +476,61 @@ func (b *GalleryRepo) GetTags(ctx context.Context, galleryID string) ([]string,
 
 	return tags, nil
 }
+
+// GetGalleryBySlug возвращает галерею по её текущему slug
+func (r *GalleryRepo) GetGalleryBySlug(ctx context.Context, slug string) (models.Gallery, error) {
+	const op = "repository.GalleryRepo.GetGalleryBySlug"
+
+	var id uuid.UUID
+	err := r.db.QueryRow(ctx, `SELECT id FROM galleries WHERE slug = $1`, slug).Scan(&id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.Gallery{}, fmt.Errorf("%s: %w", op, storage.ErrGalleryNotFound)
+		}
+		return models.Gallery{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	gallery, err := r.GetGalleryByID(ctx, id)
+	if err != nil {
+		return models.Gallery{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return gallery, nil
+}
+
+// GetGallerySlugRedirect возвращает актуальный slug галереи, которой раньше принадлежал oldSlug
+func (r *GalleryRepo) GetGallerySlugRedirect(ctx context.Context, oldSlug string) (string, error) {
+	const op = "repository.GalleryRepo.GetGallerySlugRedirect"
+
+	var slug string
+	err := r.db.QueryRow(ctx, `
+		SELECT g.slug
+		FROM gallery_slug_history h
+		JOIN galleries g ON g.id = h.gallery_id
+		WHERE h.slug = $1
+	`, oldSlug).Scan(&slug)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", fmt.Errorf("%s: %w", op, storage.ErrGalleryNotFound)
+		}
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return slug, nil
+}
+
+// IsGallerySlugTaken проверяет, занят ли slug галереей или историей переименований
+func (r *GalleryRepo) IsGallerySlugTaken(ctx context.Context, slug string) (bool, error) {
+	const op = "repository.GalleryRepo.IsGallerySlugTaken"
+
+	var taken bool
+	err := r.db.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM galleries WHERE slug = $1)
+			OR EXISTS (SELECT 1 FROM gallery_slug_history WHERE slug = $1)
+	`, slug).Scan(&taken)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return taken, nil
+}
@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"premium_caste/internal/domain/models"
+	"premium_caste/internal/storage"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+	"github.com/lib/pq"
+)
+
+type GalleryTagRepo struct {
+	db *txDB
+	sb squirrel.StatementBuilderType
+}
+
+func NewGalleryTagRepo(db *pgxpool.Pool) *GalleryTagRepo {
+	return &GalleryTagRepo{
+		db: newTxDB(db),
+		sb: squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+	}
+}
+
+// canonicalTags возвращает SQL-выражение, которое заменяет алиасы в массиве тегов
+// на актуальные теги и убирает повторы, сохраняя порядок. arg - плейсхолдер массива
+func canonicalTags(arg string) string {
+	return `ARRAY(
+		SELECT resolved.tag FROM (
+			SELECT COALESCE(a.tag, t.tag) AS tag, MIN(t.ord) AS ord
+			FROM unnest(` + arg + `::varchar[]) WITH ORDINALITY AS t(tag, ord)
+			LEFT JOIN gallery_tag_aliases a ON a.alias = t.tag
+			GROUP BY 1
+		) resolved
+		ORDER BY resolved.ord
+	)::varchar[]`
+}
+
+// ListTags возвращает теги галерей с количеством использований, начиная с самых популярных
+func (r *GalleryTagRepo) ListTags(ctx context.Context, statusFilter, prefix string, limit int) ([]models.GalleryTag, error) {
+	const op = "repository.GalleryTagRepo.ListTags"
+
+	queryBuilder := r.sb.Select("t.tag", "COUNT(*) AS cnt").
+		From("galleries g").
+		JoinClause("CROSS JOIN LATERAL unnest(g.tags) AS t(tag)")
+
+	switch statusFilter {
+	case "draft", "published", "archived":
+		queryBuilder = queryBuilder.Where(squirrel.Eq{"g.status": statusFilter})
+	case "all", "":
+
+	default:
+		return nil, fmt.Errorf("%s: invalid status filter '%s'", op, statusFilter)
+	}
+
+	if prefix != "" {
+		queryBuilder = queryBuilder.Where("t.tag LIKE ? ESCAPE '\\'", escapeLike(prefix)+"%")
+	}
+
+	query, args, err := queryBuilder.
+		GroupBy("t.tag").
+		OrderBy("cnt DESC", "t.tag").
+		Limit(uint64(limit)).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	tags := make([]models.GalleryTag, 0)
+	for rows.Next() {
+		var tag models.GalleryTag
+		if err := rows.Scan(&tag.Name, &tag.Count); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		tags = append(tags, tag)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tags, nil
+}
+
+// MergeTags заменяет теги sources на target во всех галереях одной транзакцией
+// и возвращает количество измененных галерей. Переименование - слияние одного тега.
+// При keepAliases прежние имена сохраняются как алиасы target
+func (r *GalleryTagRepo) MergeTags(ctx context.Context, sources []string, target string, keepAliases bool) (int, error) {
+	const op = "repository.GalleryTagRepo.MergeTags"
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("%s failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	result, err := tx.Exec(ctx, `
+		UPDATE galleries g
+		SET tags = ARRAY(
+				SELECT merged.tag FROM (
+					SELECT CASE WHEN t.tag = ANY($1::varchar[]) THEN $2::varchar ELSE t.tag END AS tag, MIN(t.ord) AS ord
+					FROM unnest(g.tags) WITH ORDINALITY AS t(tag, ord)
+					GROUP BY 1
+				) merged
+				ORDER BY merged.ord
+			)::varchar[],
+			updated_at = NOW()
+		WHERE g.tags && $1::varchar[]
+	`, pq.Array(sources), target)
+	if err != nil {
+		return 0, fmt.Errorf("%s failed to update galleries: %w", op, err)
+	}
+
+	if err := mergeTagAliases(ctx, tx, sources, target, keepAliases); err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("%s failed to commit transaction: %w", op, err)
+	}
+
+	return int(result.RowsAffected()), nil
+}
+
+// ListAliases возвращает все алиасы тегов
+func (r *GalleryTagRepo) ListAliases(ctx context.Context) ([]models.GalleryTagAlias, error) {
+	const op = "repository.GalleryTagRepo.ListAliases"
+
+	rows, err := r.db.Query(ctx, `
+		SELECT alias, tag, created_at
+		FROM gallery_tag_aliases
+		ORDER BY tag, alias
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	aliases := make([]models.GalleryTagAlias, 0)
+	for rows.Next() {
+		var alias models.GalleryTagAlias
+		if err := rows.Scan(&alias.Alias, &alias.Tag, &alias.CreatedAt); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		aliases = append(aliases, alias)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return aliases, nil
+}
+
+// DeleteAlias удаляет алиас тега
+func (r *GalleryTagRepo) DeleteAlias(ctx context.Context, alias string) error {
+	const op = "repository.GalleryTagRepo.DeleteAlias"
+
+	result, err := r.db.Exec(ctx, `DELETE FROM gallery_tag_aliases WHERE alias = $1`, alias)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrTagAliasNotFound)
+	}
+
+	return nil
+}
+
+// mergeTagAliases переносит алиасы исходных тегов на target
+func mergeTagAliases(ctx context.Context, tx pgx.Tx, sources []string, target string, keepAliases bool) error {
+	// target снова стал настоящим тегом и больше не может быть алиасом
+	if _, err := tx.Exec(ctx, `DELETE FROM gallery_tag_aliases WHERE alias = $1`, target); err != nil {
+		return fmt.Errorf("failed to clean aliases: %w", err)
+	}
+
+	// Старые ссылки на исходные теги продолжают вести на актуальный тег
+	_, err := tx.Exec(ctx, `
+		UPDATE gallery_tag_aliases SET tag = $2 WHERE tag = ANY($1::varchar[])
+	`, pq.Array(sources), target)
+	if err != nil {
+		return fmt.Errorf("failed to update aliases: %w", err)
+	}
+
+	if !keepAliases {
+		return nil
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO gallery_tag_aliases (alias, tag)
+		SELECT source, $2 FROM unnest($1::varchar[]) AS source
+		WHERE source <> $2
+		ON CONFLICT (alias) DO UPDATE SET tag = EXCLUDED.tag, created_at = NOW()
+	`, pq.Array(sources), target)
+	if err != nil {
+		return fmt.Errorf("failed to save aliases: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"premium_caste/internal/domain/models"
+	"premium_caste/internal/storage"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type IdentityRepo struct {
+	db *txDB
+	sb sq.StatementBuilderType
+}
+
+func NewIdentityRepo(db *pgxpool.Pool) *IdentityRepo {
+	return &IdentityRepo{
+		db: newTxDB(db),
+		sb: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// IdentityUserID возвращает пользователя, к которому привязана учетная запись провайдера
+func (r *IdentityRepo) IdentityUserID(ctx context.Context, provider, subject string) (uuid.UUID, error) {
+	const op = "repository.identity_repository.IdentityUserID"
+
+	sql, args, err := r.sb.Select("user_id").
+		From("user_identities").
+		Where(sq.Eq{"provider": provider, "subject": subject}).
+		ToSql()
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("%s: can't build sql: %w", op, err)
+	}
+
+	var userID uuid.UUID
+	if err := r.db.QueryRow(ctx, sql, args...).Scan(&userID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return uuid.Nil, fmt.Errorf("%s: %w", op, storage.ErrIdentityNotFound)
+		}
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userID, nil
+}
+
+// LinkIdentity привязывает учетную запись провайдера. Если она уже привязана или у
+// пользователя есть другая запись того же провайдера, возвращается storage.ErrIdentityLinked
+func (r *IdentityRepo) LinkIdentity(ctx context.Context, identity models.UserIdentity) error {
+	const op = "repository.identity_repository.LinkIdentity"
+
+	sql, args, err := r.sb.Insert("user_identities").
+		Columns("provider", "subject", "user_id", "email", "created_at").
+		Values(identity.Provider, identity.Subject, identity.UserID, identity.Email, time.Now().UTC()).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: can't build sql: %w", op, err)
+	}
+
+	if _, err := r.db.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("%s: %w", op, asConflict(err, storage.ErrIdentityLinked))
+	}
+
+	return nil
+}
+
+// TouchIdentity запоминает время входа через учетную запись провайдера
+func (r *IdentityRepo) TouchIdentity(ctx context.Context, provider, subject string) error {
+	const op = "repository.identity_repository.TouchIdentity"
+
+	sql, args, err := r.sb.Update("user_identities").
+		Set("last_login_at", time.Now().UTC()).
+		Where(sq.Eq{"provider": provider, "subject": subject}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: can't build sql: %w", op, err)
+	}
+
+	if _, err := r.db.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ListIdentities возвращает учетные записи провайдеров пользователя в порядке привязки
+func (r *IdentityRepo) ListIdentities(ctx context.Context, userID uuid.UUID) ([]models.UserIdentity, error) {
+	const op = "repository.identity_repository.ListIdentities"
+
+	sql, args, err := r.sb.Select("provider", "subject", "user_id", "email", "created_at", "last_login_at").
+		From("user_identities").
+		Where(sq.Eq{"user_id": userID}).
+		OrderBy("created_at", "provider").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: can't build sql: %w", op, err)
+	}
+
+	rows, err := r.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	identities := []models.UserIdentity{}
+	for rows.Next() {
+		var identity models.UserIdentity
+		if err := rows.Scan(
+			&identity.Provider,
+			&identity.Subject,
+			&identity.UserID,
+			&identity.Email,
+			&identity.CreatedAt,
+			&identity.LastLoginAt,
+		); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		identities = append(identities, identity)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return identities, nil
+}
+
+// UnlinkIdentity отвязывает учетную запись провайдера от пользователя
+func (r *IdentityRepo) UnlinkIdentity(ctx context.Context, userID uuid.UUID, provider string) error {
+	const op = "repository.identity_repository.UnlinkIdentity"
+
+	sql, args, err := r.sb.Delete("user_identities").
+		Where(sq.Eq{"user_id": userID, "provider": provider}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: can't build sql: %w", op, err)
+	}
+
+	tag, err := r.db.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrIdentityNotFound)
+	}
+
+	return nil
+}
@@ -9,12 +9,96 @@ import (
 	"github.com/google/uuid"
 )
 
+// Transactor выполняет вызовы нескольких репозиториев в одной транзакции.
+// Репозитории должны вызываться с контекстом, который получает fn
+type Transactor interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
 type UserRepository interface {
 	SaveUser(ctx context.Context, user models.User) (uuid.UUID, error)
 	IsAdmin(ctx context.Context, userID uuid.UUID) (bool, error)
 	// User(ctx context.Context, email string) (models.User, error)
 	UserByIdentifier(ctx context.Context, identifier string) (models.User, error)
 	GetUserById(ctx context.Context, userID uuid.UUID) (models.User, error)
+	UpdatePassword(ctx context.Context, userID uuid.UUID, passwordHash []byte) error
+	ListUsers(ctx context.Context, filter models.UserFilter, page, perPage int) ([]models.User, int, error)
+	ActiveAdminIDs(ctx context.Context) ([]uuid.UUID, error)
+	UpdateRole(ctx context.Context, userID uuid.UUID, role string, actorID uuid.UUID) error
+	SetSuspended(ctx context.Context, userID uuid.UUID, suspended bool, reason string, actorID uuid.UUID) error
+	Anonymize(ctx context.Context, userID uuid.UUID, actorID uuid.UUID) error
+	DeleteUser(ctx context.Context, userID uuid.UUID) error
+	PasswordHash(ctx context.Context, userID uuid.UUID) ([]byte, error)
+	UpdateProfile(ctx context.Context, userID uuid.UUID, name, phone *string) error
+	SetPendingEmail(ctx context.Context, userID uuid.UUID, email string, tokenHash []byte, expiresAt time.Time) error
+	ConfirmEmail(ctx context.Context, userID uuid.UUID, tokenHash []byte) error
+	TouchLastLogin(ctx context.Context, userID uuid.UUID) error
+	TOTPSecret(ctx context.Context, userID uuid.UUID) ([]byte, error)
+	SetTOTPSecret(ctx context.Context, userID uuid.UUID, secret []byte) error
+	EnableTOTP(ctx context.Context, userID uuid.UUID, step int64) error
+	DisableTOTP(ctx context.Context, userID uuid.UUID) error
+	UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error)
+	ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes [][]byte) error
+	UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash []byte) (bool, error)
+	CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int, error)
+}
+
+// LoginChallengeRepository незавершенные входы, ожидающие второй фактор
+type LoginChallengeRepository interface {
+	SaveLoginChallenge(ctx context.Context, key string, challenge models.LoginChallenge, ttl time.Duration) error
+	LoginChallenge(ctx context.Context, key string) (models.LoginChallenge, error)
+	CountLoginChallengeAttempt(ctx context.Context, key string) (int64, error)
+	DeleteLoginChallenge(ctx context.Context, key string) error
+}
+
+// IdentityRepository учетные записи внешних провайдеров, привязанные к пользователям
+type IdentityRepository interface {
+	IdentityUserID(ctx context.Context, provider, subject string) (uuid.UUID, error)
+	LinkIdentity(ctx context.Context, identity models.UserIdentity) error
+	TouchIdentity(ctx context.Context, provider, subject string) error
+	ListIdentities(ctx context.Context, userID uuid.UUID) ([]models.UserIdentity, error)
+	UnlinkIdentity(ctx context.Context, userID uuid.UUID, provider string) error
+}
+
+// OIDCStateRepository незавершенные входы через внешних провайдеров
+type OIDCStateRepository interface {
+	SaveOIDCState(ctx context.Context, key string, state models.OIDCState, ttl time.Duration) error
+	TakeOIDCState(ctx context.Context, key string) (models.OIDCState, error)
+}
+
+// APIKeyRepository ключи API. Ключи ищутся по SHA-256, сами ключи не хранятся
+type APIKeyRepository interface {
+	CreateAPIKey(ctx context.Context, key models.APIKey, keyHash string) (uuid.UUID, error)
+	APIKeyByHash(ctx context.Context, keyHash string) (models.APIKey, error)
+	ListAPIKeys(ctx context.Context, userID *uuid.UUID) ([]models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, keyID uuid.UUID, userID *uuid.UUID) error
+	TouchAPIKey(ctx context.Context, keyID uuid.UUID) error
+}
+
+// APIKeyRateRepository счетчики запросов по ключам API
+type APIKeyRateRepository interface {
+	CountAPIKeyRequest(ctx context.Context, keyID uuid.UUID, window time.Duration) (int64, error)
+}
+
+// SettingsRepository настройки приложения, которые меняются без перезапуска.
+// Значения хранятся в JSON
+type SettingsRepository interface {
+	GetSetting(ctx context.Context, key string, dest any) error
+	SaveSetting(ctx context.Context, key string, value any, actorID uuid.UUID) error
+}
+
+// MaintenanceRepository служебные операции команд обслуживания
+type MaintenanceRepository interface {
+	ReindexSearch(ctx context.Context) ([]string, error)
+	ExportTables(ctx context.Context) ([]models.TableDump, error)
+	ImportTables(ctx context.Context, dumps []models.TableDump) (map[string]int64, error)
+}
+
+// AuditRepository журнал аудита
+type AuditRepository interface {
+	SaveEvent(ctx context.Context, event models.AuditEvent) error
+	ListEvents(ctx context.Context, filter models.AuditFilter, page, perPage int) ([]models.AuditEvent, int, error)
+	DeleteEventsBefore(ctx context.Context, before time.Time) (int64, error)
 }
 
 type TokenRepository interface {
@@ -34,6 +118,8 @@ type MediaRepository interface {
 	GetMediaByGroupID(ctx context.Context, groupID uuid.UUID) ([]models.Media, error)
 	GetAllImages(ctx context.Context, limit int) ([]models.Media, int, error)
 	GetImages(ctx context.Context) ([]models.Media, error)
+	ListOrphanMedia(ctx context.Context, createdBefore time.Time, afterID uuid.UUID, limit int) ([]models.Media, error)
+	DeleteOrphanMedia(ctx context.Context, id uuid.UUID) (bool, error)
 }
 
 type BlogRepository interface {
@@ -43,8 +129,46 @@ type BlogRepository interface {
 	SoftDeleteBlogPost(ctx context.Context, postID uuid.UUID) error
 	AddMediaGroupToPost(ctx context.Context, postID, groupID uuid.UUID, relationType string) error
 	GetPostMediaGroups(ctx context.Context, postID uuid.UUID, relationType string) ([]uuid.UUID, error)
-	GetBlogPosts(ctx context.Context, statusFilter string, page int, perPage int) ([]models.BlogPost, int, error)
+	GetBlogPosts(ctx context.Context, filter models.BlogPostFilter, page int, perPage int) ([]models.BlogPost, int, error)
 	GetBlogPostByID(ctx context.Context, postID uuid.UUID) (*models.BlogPost, error)
+	SetPostTags(ctx context.Context, postID uuid.UUID, tags []models.BlogTag) error
+	GetTagCounts(ctx context.Context, statusFilter string, limit int) ([]models.TagCount, error)
+	GetBlogPostBySlug(ctx context.Context, slug string) (*models.BlogPost, error)
+	GetPostSlugRedirect(ctx context.Context, oldSlug string) (string, error)
+	IsPostSlugTaken(ctx context.Context, slug string) (bool, error)
+}
+
+type CategoryRepository interface {
+	CreateCategory(ctx context.Context, category models.BlogCategory) (uuid.UUID, error)
+	UpdateCategory(ctx context.Context, category models.BlogCategory) error
+	DeleteCategory(ctx context.Context, id uuid.UUID) error
+	GetCategoryByID(ctx context.Context, id uuid.UUID) (models.BlogCategory, error)
+	GetCategoryBySlug(ctx context.Context, slug string) (models.BlogCategory, error)
+	ListCategories(ctx context.Context) ([]models.BlogCategory, error)
+}
+
+type CommentRepository interface {
+	CreateComment(ctx context.Context, comment models.Comment) (uuid.UUID, error)
+	GetCommentByID(ctx context.Context, id uuid.UUID) (models.Comment, error)
+	ListPostComments(ctx context.Context, postID uuid.UUID) ([]models.Comment, error)
+	ListComments(ctx context.Context, filter models.CommentFilter, page, perPage int) ([]models.Comment, int, error)
+	UpdateCommentStatus(ctx context.Context, id uuid.UUID, status string, moderatorID uuid.UUID) error
+	DeleteComment(ctx context.Context, id uuid.UUID) error
+	CreateCommentWithinLimit(ctx context.Context, comment models.Comment, since time.Time, limit int) (uuid.UUID, error)
+	GetPostCommentSettings(ctx context.Context, postID uuid.UUID) (models.PostCommentSettings, error)
+}
+
+type GalleryTagRepository interface {
+	ListTags(ctx context.Context, statusFilter, prefix string, limit int) ([]models.GalleryTag, error)
+	MergeTags(ctx context.Context, sources []string, target string, keepAliases bool) (int, error)
+	ListAliases(ctx context.Context) ([]models.GalleryTagAlias, error)
+	DeleteAlias(ctx context.Context, alias string) error
+}
+
+type FeedCacheRepository interface {
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, data []byte, ttl time.Duration) error
+	Invalidate(ctx context.Context) error
 }
 
 type GalleryRepository interface {
@@ -53,11 +177,19 @@ type GalleryRepository interface {
 	UpdateGalleryStatus(ctx context.Context, id uuid.UUID, status string) error
 	DeleteGallery(ctx context.Context, id uuid.UUID) error
 	GetGalleryByID(ctx context.Context, id uuid.UUID) (models.Gallery, error)
-	GetGalleries(ctx context.Context, statusFilter string, page int, perPage int) ([]models.Gallery, int, error)
-	GetGalleriesByTags(ctx context.Context, tags []string, matchAll bool) ([]models.Gallery, error)
+	GetGalleryBySlug(ctx context.Context, slug string) (models.Gallery, error)
+	GetGallerySlugRedirect(ctx context.Context, oldSlug string) (string, error)
+	IsGallerySlugTaken(ctx context.Context, slug string) (bool, error)
+	ListGalleries(ctx context.Context, filter models.GalleryFilter, page int, perPage int) ([]models.Gallery, int, error)
 	AddTags(ctx context.Context, galleryID string, tags []string) error
 	RemoveTags(ctx context.Context, galleryID string, tagsToRemove []string) error
 	UpdateTags(ctx context.Context, galleryID string, tags []string) error
 	HasTags(ctx context.Context, galleryID string, tags []string) (bool, error)
 	GetTags(ctx context.Context, galleryID string) ([]string, error)
+	AddGalleryItems(ctx context.Context, galleryID uuid.UUID, items []models.GalleryItem) error
+	UpdateGalleryItem(ctx context.Context, item models.GalleryItem) error
+	RemoveGalleryItem(ctx context.Context, galleryID, mediaID uuid.UUID) error
+	ReorderGalleryItems(ctx context.Context, galleryID uuid.UUID, mediaIDs []uuid.UUID) error
+	SetGalleryCover(ctx context.Context, galleryID, mediaID uuid.UUID) error
+	GetMediaIDsByPaths(ctx context.Context, paths []string) (map[string]uuid.UUID, error)
 }
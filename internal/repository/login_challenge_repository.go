@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"premium_caste/internal/domain/models"
+	"premium_caste/internal/storage"
+	redisapp "premium_caste/internal/storage/redis"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// loginChallengePrefix префикс ключей незавершенных входов
+const loginChallengePrefix = "login_challenge:"
+
+type RedisLoginChallengeRepo struct {
+	Client *redisapp.Client
+}
+
+func NewRedisLoginChallengeRepo(client *redisapp.Client) *RedisLoginChallengeRepo {
+	return &RedisLoginChallengeRepo{Client: client}
+}
+
+// SaveLoginChallenge сохраняет незавершенный вход на ttl. key не должен совпадать
+// с идентификатором, который получает клиент, чтобы по содержимому Redis нельзя было войти
+func (r *RedisLoginChallengeRepo) SaveLoginChallenge(ctx context.Context, key string, challenge models.LoginChallenge, ttl time.Duration) error {
+	const op = "repository.login_challenge.SaveLoginChallenge"
+
+	_, err := r.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, loginChallengePrefix+key,
+			"user_id", challenge.UserID.String(),
+			"enrollment", strconv.FormatBool(challenge.Enrollment),
+			"expires_at", challenge.ExpiresAt.Unix(),
+			"attempts", 0,
+		)
+		pipe.Expire(ctx, loginChallengePrefix+key, ttl)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// LoginChallenge возвращает незавершенный вход. Если его нет или срок истек,
+// возвращается storage.ErrInvalidLoginChallenge
+func (r *RedisLoginChallengeRepo) LoginChallenge(ctx context.Context, key string) (models.LoginChallenge, error) {
+	const op = "repository.login_challenge.LoginChallenge"
+
+	fields, err := r.Client.HGetAll(ctx, loginChallengePrefix+key).Result()
+	if err != nil {
+		return models.LoginChallenge{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(fields) == 0 {
+		return models.LoginChallenge{}, fmt.Errorf("%s: %w", op, storage.ErrInvalidLoginChallenge)
+	}
+
+	userID, err := uuid.Parse(fields["user_id"])
+	if err != nil {
+		return models.LoginChallenge{}, fmt.Errorf("%s: %w", op, storage.ErrInvalidLoginChallenge)
+	}
+	expiresAt, _ := strconv.ParseInt(fields["expires_at"], 10, 64)
+	enrollment, _ := strconv.ParseBool(fields["enrollment"])
+
+	return models.LoginChallenge{
+		UserID:     userID,
+		Enrollment: enrollment,
+		ExpiresAt:  time.Unix(expiresAt, 0),
+	}, nil
+}
+
+// CountLoginChallengeAttempt увеличивает счетчик попыток ввести код и возвращает его.
+// Если вход успел истечь, HINCRBY создал бы ключ без срока жизни, поэтому такой
+// ключ удаляется и возвращается storage.ErrInvalidLoginChallenge
+func (r *RedisLoginChallengeRepo) CountLoginChallengeAttempt(ctx context.Context, key string) (int64, error) {
+	const op = "repository.login_challenge.CountLoginChallengeAttempt"
+
+	var (
+		attempts *redis.IntCmd
+		ttl      *redis.DurationCmd
+	)
+	_, err := r.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		attempts = pipe.HIncrBy(ctx, loginChallengePrefix+key, "attempts", 1)
+		ttl = pipe.TTL(ctx, loginChallengePrefix+key)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if ttl.Val() < 0 {
+		if err := r.DeleteLoginChallenge(ctx, key); err != nil {
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		return 0, fmt.Errorf("%s: %w", op, storage.ErrInvalidLoginChallenge)
+	}
+
+	return attempts.Val(), nil
+}
+
+func (r *RedisLoginChallengeRepo) DeleteLoginChallenge(ctx context.Context, key string) error {
+	const op = "repository.login_challenge.DeleteLoginChallenge"
+
+	if err := r.Client.Del(ctx, loginChallengePrefix+key).Err(); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
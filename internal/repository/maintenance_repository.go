@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"premium_caste/internal/domain/models"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// DumpTables таблицы с данными приложения в порядке внешних ключей: каждая
+// таблица ссылается только на предыдущие или на себя. Таблица версий миграций
+// не переносится, схему создает migrate
+var DumpTables = []string{
+	"users",
+	"user_recovery_codes",
+	"user_identities",
+	"api_keys",
+	"app_settings",
+	"media",
+	"media_groups",
+	"media_group_items",
+	"blog_categories",
+	"blog_tags",
+	"blog_posts",
+	"post_tags",
+	"post_media_groups",
+	"post_slug_history",
+	"galleries",
+	"gallery_items",
+	"gallery_slug_history",
+	"gallery_tag_aliases",
+	"comments",
+}
+
+// searchTables таблицы, индексы которых используются поиском, фильтрами и сортировками списков
+var searchTables = []string{
+	"blog_posts",
+	"blog_tags",
+	"post_tags",
+	"blog_categories",
+	"galleries",
+	"gallery_items",
+	"gallery_tag_aliases",
+	"media",
+}
+
+// MaintenanceRepo служебные операции над базой для команд обслуживания
+type MaintenanceRepo struct {
+	db *txDB
+}
+
+func NewMaintenanceRepo(db *pgxpool.Pool) *MaintenanceRepo {
+	return &MaintenanceRepo{db: newTxDB(db)}
+}
+
+// ReindexSearch перестраивает индексы таблиц поиска и обновляет их статистику.
+// REINDEX блокирует запись в таблицу, пока идет перестроение
+func (r *MaintenanceRepo) ReindexSearch(ctx context.Context) ([]string, error) {
+	const op = "repository.maintenance_repository.ReindexSearch"
+
+	for _, table := range searchTables {
+		name := pgx.Identifier{table}.Sanitize()
+		if _, err := r.db.Exec(ctx, "REINDEX TABLE "+name); err != nil {
+			return nil, fmt.Errorf("%s: failed to reindex %s: %w", op, table, err)
+		}
+		if _, err := r.db.Exec(ctx, "ANALYZE "+name); err != nil {
+			return nil, fmt.Errorf("%s: failed to analyze %s: %w", op, table, err)
+		}
+	}
+
+	return slices.Clone(searchTables), nil
+}
+
+// ExportTables выгружает строки таблиц DumpTables одним согласованным снимком
+func (r *MaintenanceRepo) ExportTables(ctx context.Context) ([]models.TableDump, error) {
+	const op = "repository.maintenance_repository.ExportTables"
+
+	tx, err := r.db.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	dumps := make([]models.TableDump, 0, len(DumpTables))
+	for _, table := range DumpTables {
+		var rows []byte
+		query := fmt.Sprintf(`SELECT COALESCE(json_agg(t), '[]'::json) FROM %s t`, pgx.Identifier{table}.Sanitize())
+		if err := tx.QueryRow(ctx, query).Scan(&rows); err != nil {
+			return nil, fmt.Errorf("%s: failed to export %s: %w", op, table, err)
+		}
+
+		dumps = append(dumps, models.TableDump{Name: table, Rows: json.RawMessage(rows)})
+	}
+
+	return dumps, nil
+}
+
+// ImportTables вставляет строки в одной транзакции в порядке DumpTables.
+// Строки, конфликтующие с уже существующими, пропускаются. Возвращает число
+// вставленных строк по таблицам
+func (r *MaintenanceRepo) ImportTables(ctx context.Context, dumps []models.TableDump) (map[string]int64, error) {
+	const op = "repository.maintenance_repository.ImportTables"
+
+	byName := make(map[string]models.TableDump, len(dumps))
+	for _, dump := range dumps {
+		if !slices.Contains(DumpTables, dump.Name) {
+			return nil, fmt.Errorf("%s: unknown table %q", op, dump.Name)
+		}
+		byName[dump.Name] = dump
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	inserted := make(map[string]int64, len(dumps))
+	for _, table := range DumpTables {
+		dump, ok := byName[table]
+		if !ok {
+			continue
+		}
+
+		name := pgx.Identifier{table}.Sanitize()
+		query := fmt.Sprintf(`INSERT INTO %s SELECT * FROM json_populate_recordset(NULL::%s, $1::json) ON CONFLICT DO NOTHING`, name, name)
+		tag, err := tx.Exec(ctx, query, string(dump.Rows))
+		if err != nil {
+			return nil, fmt.Errorf("%s: failed to import %s: %w", op, table, err)
+		}
+		inserted[table] = tag.RowsAffected()
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return inserted, nil
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"premium_caste/internal/domain/models"
+	"premium_caste/internal/lib/pagination"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
@@ -13,13 +14,13 @@ import (
 )
 
 type MediaRepo struct {
-	db *pgxpool.Pool
+	db *txDB
 	sb sq.StatementBuilderType
 }
 
 func NewMediaRepository(db *pgxpool.Pool) *MediaRepo {
 	return &MediaRepo{
-		db: db,
+		db: newTxDB(db),
 		sb: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
 	}
 }
@@ -406,19 +407,12 @@ func (r *MediaRepo) GetMediaByGroupID(ctx context.Context, groupID uuid.UUID) ([
 func (r *MediaRepo) GetAllImages(ctx context.Context, limit int) ([]models.Media, int, error) {
 	const op = "repository.media_repository.GetAllImages"
 
-	// Запрос для получения общего количества записей
-	countQuery, _, err := r.sb.
-		Select("COUNT(*)").
-		From("media").
-		ToSql()
-	if err != nil {
-		return nil, 0, fmt.Errorf("%s: failed to build count query: %w", op, err)
-	}
+	isPhoto := sq.Eq{"media_type": models.MediaTypePhoto}
 
-	var totalCount int
-	err = r.db.QueryRow(ctx, countQuery).Scan(&totalCount)
+	// Общее количество картинок без учета limit
+	totalCount, err := pagination.Count(ctx, r.db, r.sb.Select("COUNT(*)").From("media").Where(isPhoto))
 	if err != nil {
-		return nil, 0, fmt.Errorf("%s: failed to execute count query: %w", op, err)
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
 	}
 
 	// Запрос для получения данных
@@ -436,6 +430,7 @@ func (r *MediaRepo) GetAllImages(ctx context.Context, limit int) ([]models.Media
 			"metadata",
 		).
 		From("media").
+		Where(isPhoto).
 		OrderBy("created_at DESC")
 
 	if limit > 0 {
@@ -547,3 +542,87 @@ func (r *MediaRepo) GetImages(ctx context.Context) ([]models.Media, error) {
 
 	return images, nil
 }
+
+// orphanMedia условие для медиа, на которые ничего не ссылается: ни группы,
+// ни галереи (элементы, обложки и старый массив путей), ни обложки постов
+var orphanMedia = sq.Expr(`NOT EXISTS (SELECT 1 FROM media_group_items mgi WHERE mgi.media_id = media.id)
+	AND NOT EXISTS (SELECT 1 FROM gallery_items gi WHERE gi.media_id = media.id)
+	AND NOT EXISTS (SELECT 1 FROM galleries g WHERE g.cover_media_id = media.id OR media.storage_path = ANY(g.images))
+	AND NOT EXISTS (SELECT 1 FROM blog_posts bp WHERE bp.featured_image_id = media.id)`)
+
+// ListOrphanMedia возвращает до limit медиа без ссылок, загруженных раньше createdBefore,
+// по возрастанию id начиная после afterID. Для первой страницы afterID - uuid.Nil
+func (r *MediaRepo) ListOrphanMedia(ctx context.Context, createdBefore time.Time, afterID uuid.UUID, limit int) ([]models.Media, error) {
+	const op = "repository.media_repository.ListOrphanMedia"
+
+	query, args, err := r.sb.
+		Select(
+			"id",
+			"uploader_id",
+			"created_at",
+			"media_type",
+			"original_filename",
+			"storage_path",
+			"file_size",
+		).
+		From("media").
+		Where(sq.Lt{"created_at": createdBefore}).
+		Where(sq.Gt{"id": afterID}).
+		Where(orphanMedia).
+		OrderBy("id").
+		Limit(uint64(limit)).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to build query: %w", op, err)
+	}
+
+	rows, err := r.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: failed to execute query: %w", op, err)
+	}
+	defer rows.Close()
+
+	var media []models.Media
+	for rows.Next() {
+		var m models.Media
+		if err := rows.Scan(
+			&m.ID,
+			&m.UploaderID,
+			&m.CreatedAt,
+			&m.MediaType,
+			&m.OriginalFilename,
+			&m.StoragePath,
+			&m.FileSize,
+		); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		}
+		media = append(media, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	return media, nil
+}
+
+// DeleteOrphanMedia удаляет медиа, если на него по-прежнему ничего не ссылается.
+// Возвращает false, если медиа уже удалено или успело где-то использоваться
+func (r *MediaRepo) DeleteOrphanMedia(ctx context.Context, id uuid.UUID) (bool, error) {
+	const op = "repository.media_repository.DeleteOrphanMedia"
+
+	query, args, err := r.sb.Delete("media").
+		Where(sq.Eq{"id": id}).
+		Where(orphanMedia).
+		ToSql()
+	if err != nil {
+		return false, fmt.Errorf("%s: failed to build query: %w", op, err)
+	}
+
+	tag, err := r.db.Exec(ctx, query, args...)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
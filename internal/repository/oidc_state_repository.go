@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"premium_caste/internal/domain/models"
+	"premium_caste/internal/storage"
+	redisapp "premium_caste/internal/storage/redis"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// oidcStatePrefix префикс ключей незавершенных входов через внешних провайдеров
+const oidcStatePrefix = "oidc_state:"
+
+type RedisOIDCStateRepo struct {
+	Client *redisapp.Client
+}
+
+func NewRedisOIDCStateRepo(client *redisapp.Client) *RedisOIDCStateRepo {
+	return &RedisOIDCStateRepo{Client: client}
+}
+
+// SaveOIDCState сохраняет вход на ttl. Как и для LoginChallenge, key - хеш значения
+// state, которое получает клиент
+func (r *RedisOIDCStateRepo) SaveOIDCState(ctx context.Context, key string, state models.OIDCState, ttl time.Duration) error {
+	const op = "repository.oidc_state.SaveOIDCState"
+
+	fields := []any{
+		"provider", state.Provider,
+		"verifier", state.Verifier,
+		"nonce", state.Nonce,
+	}
+	if state.LinkTo != nil {
+		fields = append(fields, "link_to", state.LinkTo.String())
+	}
+
+	_, err := r.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, oidcStatePrefix+key, fields...)
+		pipe.Expire(ctx, oidcStatePrefix+key, ttl)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// TakeOIDCState возвращает и удаляет вход, поэтому ответ провайдера можно
+// предъявить только один раз. Если входа нет, возвращается storage.ErrInvalidOIDCState
+func (r *RedisOIDCStateRepo) TakeOIDCState(ctx context.Context, key string) (models.OIDCState, error) {
+	const op = "repository.oidc_state.TakeOIDCState"
+
+	var (
+		get *redis.MapStringStringCmd
+		del *redis.IntCmd
+	)
+	_, err := r.Client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		get = pipe.HGetAll(ctx, oidcStatePrefix+key)
+		del = pipe.Del(ctx, oidcStatePrefix+key)
+		return nil
+	})
+	if err != nil {
+		return models.OIDCState{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	fields := get.Val()
+	if del.Val() == 0 || fields["provider"] == "" {
+		return models.OIDCState{}, fmt.Errorf("%s: %w", op, storage.ErrInvalidOIDCState)
+	}
+
+	state := models.OIDCState{
+		Provider: fields["provider"],
+		Verifier: fields["verifier"],
+		Nonce:    fields["nonce"],
+	}
+	if raw, ok := fields["link_to"]; ok {
+		userID, err := uuid.Parse(raw)
+		if err != nil {
+			return models.OIDCState{}, fmt.Errorf("%s: %w", op, storage.ErrInvalidOIDCState)
+		}
+		state.LinkTo = &userID
+	}
+
+	return state, nil
+}
@@ -9,12 +9,25 @@ import (
 )
 
 type Repository struct {
-	db      *pgxpool.Pool
-	User    UserRepository
-	Media   MediaRepository
-	Token   TokenRepository
-	Blog    BlogRepository
-	Gallery GalleryRepository
+	db          *pgxpool.Pool
+	User        UserRepository
+	Media       MediaRepository
+	Token       TokenRepository
+	Blog        BlogRepository
+	Category    CategoryRepository
+	Comment     CommentRepository
+	FeedCache   FeedCacheRepository
+	Gallery     GalleryRepository
+	GalleryTag  GalleryTagRepository
+	Maintenance MaintenanceRepository
+	Audit       AuditRepository
+	Settings    SettingsRepository
+	Challenges  LoginChallengeRepository
+	Identities  IdentityRepository
+	OIDCStates  OIDCStateRepository
+	APIKeys     APIKeyRepository
+	APIKeyRates APIKeyRateRepository
+	Tx          Transactor
 }
 
 func NewRepository(ctx context.Context, dsn string, redis *redisapp.Client) (*Repository, error) {
@@ -24,11 +37,25 @@ func NewRepository(ctx context.Context, dsn string, redis *redisapp.Client) (*Re
 	}
 
 	return &Repository{
-		User:    NewUserRepository(db),
-		Media:   NewMediaRepository(db),
-		Token:   NewRedisTokenRepo(redis),
-		Blog:    NewBlogRepository(db),
-		Gallery: NewGalleryRepo(db),
+		db:          db,
+		User:        NewUserRepository(db),
+		Media:       NewMediaRepository(db),
+		Token:       NewRedisTokenRepo(redis),
+		Blog:        NewBlogRepository(db),
+		Category:    NewCategoryRepository(db),
+		Comment:     NewCommentRepository(db),
+		FeedCache:   NewRedisFeedCache(redis),
+		Gallery:     NewGalleryRepo(db),
+		GalleryTag:  NewGalleryTagRepo(db),
+		Maintenance: NewMaintenanceRepo(db),
+		Audit:       NewAuditRepo(db),
+		Settings:    NewSettingsRepo(db),
+		Challenges:  NewRedisLoginChallengeRepo(redis),
+		Identities:  NewIdentityRepo(db),
+		OIDCStates:  NewRedisOIDCStateRepo(redis),
+		APIKeys:     NewAPIKeyRepo(db),
+		APIKeyRates: NewRedisAPIKeyRateRepo(redis),
+		Tx:          NewTxManager(db),
 	}, nil
 }
 
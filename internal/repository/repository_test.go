@@ -2,15 +2,20 @@ package repository_test
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"premium_caste/internal/domain/models"
+	"premium_caste/internal/lib/pagination"
 	"premium_caste/internal/repository"
+	"premium_caste/internal/storage"
 	redisapp "premium_caste/internal/storage/redis"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/go-redis/redismock/v9"
 	"github.com/google/uuid"
+	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/assert"
@@ -120,13 +125,41 @@ func applyMigrations(pool *pgxpool.Pool) error {
 			slug VARCHAR(255) UNIQUE NOT NULL,           
 			excerpt TEXT,                               
 			content TEXT NOT NULL,                       
+			content_format VARCHAR(20) NOT NULL DEFAULT 'html',
+			content_html TEXT,
 			featured_image_id UUID,  
 			author_id UUID NOT NULL,                     
+			category_id UUID,
 			status VARCHAR(20) NOT NULL DEFAULT 'draft',  
 			published_at TIMESTAMPTZ,                    
 			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
 			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-			metadata JSONB                               
+			metadata JSONB,
+			comments_closed BOOLEAN NOT NULL DEFAULT FALSE
+		);
+
+		CREATE TABLE IF NOT EXISTS blog_categories (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			parent_id UUID REFERENCES blog_categories(id) ON DELETE SET NULL,
+			name VARCHAR(100) NOT NULL,
+			slug VARCHAR(100) UNIQUE NOT NULL,
+			description TEXT,
+			position INT NOT NULL DEFAULT 0,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+
+		CREATE TABLE IF NOT EXISTS blog_tags (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			name VARCHAR(50) NOT NULL,
+			slug VARCHAR(50) UNIQUE NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+
+		CREATE TABLE IF NOT EXISTS post_tags (
+			post_id UUID NOT NULL REFERENCES blog_posts(id) ON DELETE CASCADE,
+			tag_id UUID NOT NULL REFERENCES blog_tags(id) ON DELETE CASCADE,
+			PRIMARY KEY (post_id, tag_id)
 		);
 
 		CREATE TABLE IF NOT EXISTS post_media_groups (
@@ -143,6 +176,7 @@ func applyMigrations(pool *pgxpool.Pool) error {
 			description TEXT,
 			images TEXT[] NOT NULL DEFAULT '{}',
 			cover_image_index INT DEFAULT 0,      
+			cover_media_id UUID REFERENCES media(id) ON DELETE SET NULL,
 			author_id UUID NOT NULL,
 			status VARCHAR(20) NOT NULL DEFAULT 'draft',
 			published_at TIMESTAMPTZ,
@@ -151,6 +185,48 @@ func applyMigrations(pool *pgxpool.Pool) error {
 			metadata JSONB,
 			tags VARCHAR(255)[] DEFAULT '{}'      
 		);
+
+		CREATE TABLE IF NOT EXISTS post_slug_history (
+			slug VARCHAR(255) PRIMARY KEY,
+			post_id UUID NOT NULL REFERENCES blog_posts(id) ON DELETE CASCADE,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+
+		CREATE TABLE IF NOT EXISTS gallery_items (
+			gallery_id UUID NOT NULL REFERENCES galleries(id) ON DELETE CASCADE,
+			media_id UUID NOT NULL REFERENCES media(id) ON DELETE CASCADE,
+			position INT NOT NULL DEFAULT 0,
+			caption TEXT,
+			alt_text TEXT,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			PRIMARY KEY (gallery_id, media_id)
+		);
+
+		CREATE TABLE IF NOT EXISTS gallery_tag_aliases (
+			alias VARCHAR(255) PRIMARY KEY,
+			tag VARCHAR(255) NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			CHECK (alias <> tag)
+		);
+
+		CREATE TABLE IF NOT EXISTS gallery_slug_history (
+			slug VARCHAR(255) PRIMARY KEY,
+			gallery_id UUID NOT NULL REFERENCES galleries(id) ON DELETE CASCADE,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
+
+		CREATE TABLE IF NOT EXISTS comments (
+			id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+			post_id UUID NOT NULL REFERENCES blog_posts(id) ON DELETE CASCADE,
+			user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			parent_id UUID REFERENCES comments(id) ON DELETE CASCADE,
+			content TEXT NOT NULL,
+			status VARCHAR(20) NOT NULL DEFAULT 'pending',
+			moderated_by UUID REFERENCES users(id) ON DELETE SET NULL,
+			moderated_at TIMESTAMPTZ,
+			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		);
 	`)
 
 	return err
@@ -399,6 +475,110 @@ func TestMediaRepo_TransactionHandling(t *testing.T) {
 	})
 }
 
+func TestTxManager_WithinTx(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewMediaRepository(db)
+	txManager := repository.NewTxManager(db)
+
+	countMedia := func(t *testing.T, id uuid.UUID) int {
+		var count int
+		err := db.QueryRow(testCtx, `SELECT COUNT(*) FROM media WHERE id = $1`, id).Scan(&count)
+		require.NoError(t, err)
+		return count
+	}
+
+	t.Run("commit spans repository calls", func(t *testing.T) {
+		var media *models.Media
+		var groupID uuid.UUID
+		err := txManager.WithinTx(testCtx, func(ctx context.Context) error {
+			media = &models.Media{ID: uuid.New(), MediaType: "image", OriginalFilename: "tx.jpg", StoragePath: "uploads/tx.jpg", CreatedAt: time.Now().UTC()}
+			if _, err := repo.CreateMedia(ctx, media); err != nil {
+				return err
+			}
+
+			var err error
+			groupID, err = repo.AddMediaGroup(ctx, uuid.New(), "tx group")
+			if err != nil {
+				return err
+			}
+
+			return repo.AddMediaGroupItems(ctx, groupID, []uuid.UUID{media.ID})
+		})
+		require.NoError(t, err)
+
+		items, err := repo.GetMediaByGroupID(testCtx, groupID)
+		require.NoError(t, err)
+		require.Len(t, items, 1)
+		assert.Equal(t, media.ID, items[0].ID)
+	})
+
+	t.Run("error rolls back every call", func(t *testing.T) {
+		mediaID := uuid.New()
+		err := txManager.WithinTx(testCtx, func(ctx context.Context) error {
+			media := &models.Media{ID: mediaID, MediaType: "image", OriginalFilename: "rollback.jpg", StoragePath: "uploads/rollback.jpg", CreatedAt: time.Now().UTC()}
+			if _, err := repo.CreateMedia(ctx, media); err != nil {
+				return err
+			}
+
+			// Несуществующий медиа-файл нарушает внешний ключ
+			groupID, err := repo.AddMediaGroup(ctx, uuid.New(), "rollback group")
+			if err != nil {
+				return err
+			}
+			return repo.AddMediaGroupItems(ctx, groupID, []uuid.UUID{uuid.New()})
+		})
+		require.Error(t, err)
+		assert.Equal(t, 0, countMedia(t, mediaID))
+	})
+
+	t.Run("nested call rolls back to savepoint", func(t *testing.T) {
+		outerID, innerID := uuid.New(), uuid.New()
+		err := txManager.WithinTx(testCtx, func(ctx context.Context) error {
+			outer := &models.Media{ID: outerID, MediaType: "image", OriginalFilename: "outer.jpg", StoragePath: "uploads/outer.jpg", CreatedAt: time.Now().UTC()}
+			if _, err := repo.CreateMedia(ctx, outer); err != nil {
+				return err
+			}
+
+			innerErr := txManager.WithinTx(ctx, func(ctx context.Context) error {
+				inner := &models.Media{ID: innerID, MediaType: "image", OriginalFilename: "inner.jpg", StoragePath: "uploads/inner.jpg", CreatedAt: time.Now().UTC()}
+				if _, err := repo.CreateMedia(ctx, inner); err != nil {
+					return err
+				}
+				return errors.New("inner failure")
+			})
+			require.Error(t, innerErr)
+
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 1, countMedia(t, outerID))
+		assert.Equal(t, 0, countMedia(t, innerID))
+	})
+
+	t.Run("serialization failure is retried", func(t *testing.T) {
+		attempts := 0
+		err := txManager.WithinTx(testCtx, func(ctx context.Context) error {
+			attempts++
+			if attempts == 1 {
+				return fmt.Errorf("service: %w", &pgconn.PgError{Code: "40001"})
+			}
+			return nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 2, attempts)
+	})
+
+	t.Run("other errors are not retried", func(t *testing.T) {
+		attempts := 0
+		err := txManager.WithinTx(testCtx, func(ctx context.Context) error {
+			attempts++
+			return errors.New("boom")
+		})
+		require.Error(t, err)
+		assert.Equal(t, 1, attempts)
+	})
+}
+
 func TestUserRepository_SaveUser(t *testing.T) {
 	pool := setupTestDB(t)
 
@@ -437,6 +617,7 @@ func TestUserRepository_SaveUser(t *testing.T) {
 		_, err = repo.SaveUser(testCtx, user)
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "duplicate key value violates unique constraint")
+		assert.ErrorIs(t, err, storage.ErrUserExists)
 	})
 }
 
@@ -831,14 +1012,14 @@ func TestBlogRepo_GetBlogPosts(t *testing.T) {
 	}
 
 	t.Run("successful get all posts", func(t *testing.T) {
-		posts, total, err := repo.GetBlogPosts(ctx, "all", 1, 10)
+		posts, total, err := repo.GetBlogPosts(ctx, models.BlogPostFilter{Status: "all"}, 1, 10)
 		require.NoError(t, err)
 		assert.Equal(t, len(testPosts), total)
 		assert.Len(t, posts, len(testPosts))
 	})
 
 	t.Run("successful get published posts", func(t *testing.T) {
-		posts, _, err := repo.GetBlogPosts(ctx, "published", 1, 10)
+		posts, _, err := repo.GetBlogPosts(ctx, models.BlogPostFilter{Status: "published"}, 1, 10)
 		require.NoError(t, err)
 		assert.Len(t, posts, 2)
 		for _, post := range posts {
@@ -848,52 +1029,274 @@ func TestBlogRepo_GetBlogPosts(t *testing.T) {
 
 	t.Run("successful get draft posts with pagination", func(t *testing.T) {
 		// Первая страница - 1 запись
-		posts, _, err := repo.GetBlogPosts(ctx, "draft", 1, 1)
+		posts, _, err := repo.GetBlogPosts(ctx, models.BlogPostFilter{Status: "draft"}, 1, 1)
 		require.NoError(t, err)
 		assert.Len(t, posts, 1)
 		assert.Equal(t, "Draft Post 2", posts[0].Title)
 
 		// Вторая страница - 1 запись
-		posts, _, err = repo.GetBlogPosts(ctx, "draft", 2, 1)
+		posts, _, err = repo.GetBlogPosts(ctx, models.BlogPostFilter{Status: "draft"}, 2, 1)
 		require.NoError(t, err)
 		assert.Len(t, posts, 1)
 		assert.Equal(t, "Draft Post 1", posts[0].Title)
 	})
 
+	t.Run("total respects filter and cursor continues the page", func(t *testing.T) {
+		filter := models.BlogPostFilter{Status: "draft"}
+
+		first, total, err := repo.GetBlogPosts(ctx, filter, 1, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 2, total)
+		require.Len(t, first, 1)
+
+		filter.After = &pagination.Cursor{Sort: models.BlogPostSortCreated, ID: first[0].ID, Time: first[0].CreatedAt}
+		second, total, err := repo.GetBlogPosts(ctx, filter, 1, 1)
+		require.NoError(t, err)
+		assert.Equal(t, 2, total)
+		require.Len(t, second, 1)
+		assert.Equal(t, "Draft Post 1", second[0].Title)
+	})
+
 	t.Run("successful get archived posts", func(t *testing.T) {
-		posts, _, err := repo.GetBlogPosts(ctx, "archived", 1, 10)
+		posts, _, err := repo.GetBlogPosts(ctx, models.BlogPostFilter{Status: "archived"}, 1, 10)
 		require.NoError(t, err)
 		assert.Len(t, posts, 1)
 		assert.Equal(t, "Archived Post 1", posts[0].Title)
 	})
 
 	t.Run("invalid status filter", func(t *testing.T) {
-		_, _, err := repo.GetBlogPosts(ctx, "invalid_status", 1, 10)
+		_, _, err := repo.GetBlogPosts(ctx, models.BlogPostFilter{Status: "invalid_status"}, 1, 10)
 		require.Error(t, err)
 		assert.Contains(t, err.Error(), "invalid status filter")
 	})
 
 	t.Run("automatic page correction", func(t *testing.T) {
 		// Страница 0 должна корректироваться на 1
-		posts, total, err := repo.GetBlogPosts(ctx, "all", 0, 10)
+		posts, total, err := repo.GetBlogPosts(ctx, models.BlogPostFilter{Status: "all"}, 0, 10)
 		require.NoError(t, err)
 		assert.Equal(t, len(testPosts), total)
 		assert.Len(t, posts, len(testPosts))
 
 		// perPage > 100 должно корректироваться на 10
-		posts, _, err = repo.GetBlogPosts(ctx, "all", 1, 101)
+		posts, _, err = repo.GetBlogPosts(ctx, models.BlogPostFilter{Status: "all"}, 1, 101)
 		require.NoError(t, err)
 		assert.Len(t, posts, 5)
 	})
 
 	t.Run("empty result", func(t *testing.T) {
 		// Пытаемся получить несуществующий статус
-		posts, _, err := repo.GetBlogPosts(ctx, "archived", 2, 10)
+		posts, _, err := repo.GetBlogPosts(ctx, models.BlogPostFilter{Status: "archived"}, 2, 10)
 		require.NoError(t, err)
 		assert.Empty(t, posts)
 	})
 }
 
+func TestBlogRepo_SlugHistory(t *testing.T) {
+	ctx := context.Background()
+	pool := setupTestDB(t)
+
+	repo := repository.NewBlogRepository(pool)
+
+	postID, err := repo.SaveBlogPost(ctx, models.BlogPost{Title: "Old", Slug: "old-slug", Status: "published"})
+	require.NoError(t, err)
+
+	require.NoError(t, repo.UpdateBlogPostFields(ctx, postID, map[string]interface{}{"slug": "new-slug"}))
+
+	post, err := repo.GetBlogPostBySlug(ctx, "new-slug")
+	require.NoError(t, err)
+	assert.Equal(t, postID, post.ID)
+
+	_, err = repo.GetBlogPostBySlug(ctx, "old-slug")
+	assert.ErrorIs(t, err, storage.ErrPostNotFound)
+
+	current, err := repo.GetPostSlugRedirect(ctx, "old-slug")
+	require.NoError(t, err)
+	assert.Equal(t, "new-slug", current)
+
+	taken, err := repo.IsPostSlugTaken(ctx, "old-slug")
+	require.NoError(t, err)
+	assert.True(t, taken)
+
+	// Возврат к старому slug убирает его из истории
+	require.NoError(t, repo.UpdateBlogPostFields(ctx, postID, map[string]interface{}{"slug": "old-slug"}))
+	current, err = repo.GetPostSlugRedirect(ctx, "new-slug")
+	require.NoError(t, err)
+	assert.Equal(t, "old-slug", current)
+
+	_, err = repo.GetPostSlugRedirect(ctx, "old-slug")
+	assert.ErrorIs(t, err, storage.ErrPostNotFound)
+}
+
+func TestBlogRepo_Taxonomy(t *testing.T) {
+	ctx := context.Background()
+	pool := setupTestDB(t)
+
+	repo := repository.NewBlogRepository(pool)
+	categoryRepo := repository.NewCategoryRepository(pool)
+
+	parentID, err := categoryRepo.CreateCategory(ctx, models.BlogCategory{Name: "Travel", Slug: "travel"})
+	require.NoError(t, err)
+	childID, err := categoryRepo.CreateCategory(ctx, models.BlogCategory{ParentID: &parentID, Name: "Europe", Slug: "europe"})
+	require.NoError(t, err)
+
+	inChild, err := repo.SaveBlogPost(ctx, models.BlogPost{Title: "Paris", Slug: "paris", Status: "published", CategoryID: &childID})
+	require.NoError(t, err)
+	_, err = repo.SaveBlogPost(ctx, models.BlogPost{Title: "Other", Slug: "other", Status: "published"})
+	require.NoError(t, err)
+
+	require.NoError(t, repo.SetPostTags(ctx, inChild, []models.BlogTag{
+		{Name: "Food", Slug: "food"},
+		{Name: "City", Slug: "city"},
+	}))
+
+	t.Run("filter by parent category includes subcategories", func(t *testing.T) {
+		posts, total, err := repo.GetBlogPosts(ctx, models.BlogPostFilter{CategorySlug: "travel"}, 1, 10)
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		require.Len(t, posts, 1)
+		assert.Equal(t, inChild, posts[0].ID)
+		assert.Len(t, posts[0].Tags, 2)
+	})
+
+	t.Run("filter by tag", func(t *testing.T) {
+		posts, total, err := repo.GetBlogPosts(ctx, models.BlogPostFilter{Tags: []string{"food"}}, 1, 10)
+		require.NoError(t, err)
+		assert.Equal(t, 1, total)
+		assert.Len(t, posts, 1)
+	})
+
+	t.Run("tag counts", func(t *testing.T) {
+		counts, err := repo.GetTagCounts(ctx, "published", 10)
+		require.NoError(t, err)
+		assert.Len(t, counts, 2)
+	})
+
+	t.Run("delete category reparents children", func(t *testing.T) {
+		require.NoError(t, categoryRepo.DeleteCategory(ctx, parentID))
+
+		child, err := categoryRepo.GetCategoryByID(ctx, childID)
+		require.NoError(t, err)
+		assert.Nil(t, child.ParentID)
+	})
+}
+
+func TestCommentRepo_Moderation(t *testing.T) {
+	ctx := context.Background()
+	pool := setupTestDB(t)
+
+	blogRepo := repository.NewBlogRepository(pool)
+	repo := repository.NewCommentRepository(pool)
+
+	var userID uuid.UUID
+	err := pool.QueryRow(ctx,
+		"INSERT INTO users (name, email, password) VALUES ('Reader', 'reader@example.com', 'x') RETURNING id",
+	).Scan(&userID)
+	require.NoError(t, err)
+
+	postID, err := blogRepo.SaveBlogPost(ctx, models.BlogPost{Title: "Post", Slug: "post-comments", Status: "published", AuthorID: userID})
+	require.NoError(t, err)
+
+	rootID, err := repo.CreateComment(ctx, models.Comment{PostID: postID, UserID: userID, Content: "root", Status: models.CommentStatusPending})
+	require.NoError(t, err)
+	_, err = repo.CreateComment(ctx, models.Comment{PostID: postID, UserID: userID, ParentID: &rootID, Content: "reply", Status: models.CommentStatusPending})
+	require.NoError(t, err)
+
+	pending, total, err := repo.ListComments(ctx, models.CommentFilter{Status: models.CommentStatusPending}, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Len(t, pending, 2)
+
+	// Вторая страница по курсору первой
+	first, _, err := repo.ListComments(ctx, models.CommentFilter{Status: models.CommentStatusPending}, 1, 1)
+	require.NoError(t, err)
+	require.Len(t, first, 1)
+	after := &pagination.Cursor{Sort: models.CommentSortCreated, ID: first[0].ID, Time: first[0].CreatedAt}
+	second, _, err := repo.ListComments(ctx, models.CommentFilter{Status: models.CommentStatusPending, After: after}, 1, 1)
+	require.NoError(t, err)
+	require.Len(t, second, 1)
+	assert.NotEqual(t, first[0].ID, second[0].ID)
+
+	visible, err := repo.ListPostComments(ctx, postID)
+	require.NoError(t, err)
+	assert.Empty(t, visible)
+
+	require.NoError(t, repo.UpdateCommentStatus(ctx, rootID, models.CommentStatusApproved, userID))
+
+	comment, err := repo.GetCommentByID(ctx, rootID)
+	require.NoError(t, err)
+	assert.Equal(t, models.CommentStatusApproved, comment.Status)
+	assert.Equal(t, "Reader", comment.AuthorName)
+	require.NotNil(t, comment.ModeratedBy)
+
+	post, err := blogRepo.GetBlogPostByID(ctx, postID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, post.CommentCount)
+
+	// У пользователя уже два комментария за последнюю минуту
+	_, err = repo.CreateCommentWithinLimit(ctx, models.Comment{PostID: postID, UserID: userID, Content: "third", Status: models.CommentStatusPending}, time.Now().Add(-time.Minute), 2)
+	assert.ErrorIs(t, err, storage.ErrCommentRateLimited)
+
+	// Удаление комментария удаляет и ответы
+	require.NoError(t, repo.DeleteComment(ctx, rootID))
+	_, total, err = repo.ListComments(ctx, models.CommentFilter{}, 1, 10)
+	require.NoError(t, err)
+	assert.Zero(t, total)
+
+	assert.ErrorIs(t, repo.DeleteComment(ctx, rootID), storage.ErrCommentNotFound)
+}
+
+func TestCommentRepo_CreateCommentWithinLimit(t *testing.T) {
+	ctx := context.Background()
+	pool := setupTestDB(t)
+
+	blogRepo := repository.NewBlogRepository(pool)
+	repo := repository.NewCommentRepository(pool)
+
+	var userID uuid.UUID
+	err := pool.QueryRow(ctx,
+		"INSERT INTO users (name, email, password) VALUES ('Reader', 'reader@example.com', 'x') RETURNING id",
+	).Scan(&userID)
+	require.NoError(t, err)
+
+	postID, err := blogRepo.SaveBlogPost(ctx, models.BlogPost{Title: "Post", Slug: "post-limit", Status: "published", AuthorID: userID})
+	require.NoError(t, err)
+
+	const limit = 3
+	since := time.Now().Add(-time.Minute)
+
+	// Параллельные запросы не пропускают больше limit комментариев
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := repo.CreateCommentWithinLimit(ctx, models.Comment{PostID: postID, UserID: userID, Content: "spam", Status: models.CommentStatusPending}, since, limit)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	created := 0
+	for err := range errs {
+		if err == nil {
+			created++
+			continue
+		}
+		assert.ErrorIs(t, err, storage.ErrCommentRateLimited)
+	}
+	assert.Equal(t, limit, created)
+
+	_, total, err := repo.ListComments(ctx, models.CommentFilter{}, 1, 10)
+	require.NoError(t, err)
+	assert.Equal(t, limit, total)
+
+	// Старые комментарии в лимит не входят
+	_, err = repo.CreateCommentWithinLimit(ctx, models.Comment{PostID: postID, UserID: userID, Content: "later", Status: models.CommentStatusPending}, time.Now().Add(time.Minute), limit)
+	require.NoError(t, err)
+}
+
 func TestMediaGroupOperations(t *testing.T) {
 	ctx := context.Background()
 	pool := setupTestDB(t)
@@ -932,15 +1335,13 @@ func TestGalleryRepo_CreateGallery(t *testing.T) {
 		{
 			name: "successful creation",
 			gallery: models.Gallery{
-				Title:           "Test Gallery",
-				Slug:            "test-gallery",
-				Description:     "Test description",
-				Images:          []string{"image1.jpg", "image2.jpg"},
-				CoverImageIndex: 0,
-				AuthorID:        uuid.New(),
-				Status:          "draft",
-				Metadata:        map[string]interface{}{"key": "value"},
-				Tags:            []string{"tag1", "tag2"},
+				Title:       "Test Gallery",
+				Slug:        "test-gallery",
+				Description: "Test description",
+				AuthorID:    uuid.New(),
+				Status:      "draft",
+				Metadata:    map[string]interface{}{"key": "value"},
+				Tags:        []string{"tag1", "tag2"},
 			},
 		},
 	}
@@ -972,7 +1373,7 @@ func TestGalleryRepo_CreateGallery(t *testing.T) {
 			// Проверяем, что данные записались в БД
 			var dbGallery models.Gallery
 			err = db.QueryRow(testCtx,
-				`SELECT id, title, slug, description, images, cover_image_index, 
+				`SELECT id, title, slug, description,
 				 author_id, status, metadata, tags, created_at, updated_at
 				 FROM galleries WHERE id = $1`, id).
 				Scan(
@@ -980,8 +1381,6 @@ func TestGalleryRepo_CreateGallery(t *testing.T) {
 					&dbGallery.Title,
 					&dbGallery.Slug,
 					&dbGallery.Description,
-					&dbGallery.Images,
-					&dbGallery.CoverImageIndex,
 					&dbGallery.AuthorID,
 					&dbGallery.Status,
 					&dbGallery.Metadata,
@@ -994,8 +1393,6 @@ func TestGalleryRepo_CreateGallery(t *testing.T) {
 			require.Equal(t, tt.gallery.Title, dbGallery.Title)
 			require.Equal(t, tt.gallery.Slug, dbGallery.Slug)
 			require.Equal(t, tt.gallery.Description, dbGallery.Description)
-			require.Equal(t, tt.gallery.Images, dbGallery.Images)
-			require.Equal(t, tt.gallery.CoverImageIndex, dbGallery.CoverImageIndex)
 			require.Equal(t, tt.gallery.AuthorID, dbGallery.AuthorID)
 			require.Equal(t, tt.gallery.Status, dbGallery.Status)
 			require.Equal(t, tt.gallery.Metadata, dbGallery.Metadata)
@@ -1016,7 +1413,6 @@ func TestGalleryRepo_UpdateGallery(t *testing.T) {
 		Title:    "Original Title",
 		Slug:     "original-slug",
 		AuthorID: uuid.New(),
-		Images:   []string{"new1.jpg", "new2.jpg"},
 	}
 	id, err := repo.CreateGallery(testCtx, gallery)
 	require.NoError(t, err)
@@ -1029,15 +1425,13 @@ func TestGalleryRepo_UpdateGallery(t *testing.T) {
 		{
 			name: "successful update",
 			updates: models.Gallery{
-				ID:              id,
-				Title:           "Updated Title",
-				Slug:            "updated-slug",
-				Description:     "Updated description",
-				Images:          []string{"new1.jpg", "new2.jpg"},
-				CoverImageIndex: 1,
-				Status:          "published",
-				Metadata:        map[string]interface{}{"new": "data"},
-				Tags:            []string{"new", "tags"},
+				ID:          id,
+				Title:       "Updated Title",
+				Slug:        "updated-slug",
+				Description: "Updated description",
+				Status:      "published",
+				Metadata:    map[string]interface{}{"new": "data"},
+				Tags:        []string{"new", "tags"},
 			},
 		},
 	}
@@ -1056,15 +1450,13 @@ func TestGalleryRepo_UpdateGallery(t *testing.T) {
 			// Проверяем обновленные данные
 			var dbGallery models.Gallery
 			err = db.QueryRow(testCtx,
-				`SELECT title, slug, description, images, cover_image_index, 
+				`SELECT title, slug, description,
 				 status, metadata, tags, updated_at
 				 FROM galleries WHERE id = $1`, id).
 				Scan(
 					&dbGallery.Title,
 					&dbGallery.Slug,
 					&dbGallery.Description,
-					&dbGallery.Images,
-					&dbGallery.CoverImageIndex,
 					&dbGallery.Status,
 					&dbGallery.Metadata,
 					&dbGallery.Tags,
@@ -1075,8 +1467,6 @@ func TestGalleryRepo_UpdateGallery(t *testing.T) {
 			require.Equal(t, tt.updates.Title, dbGallery.Title)
 			require.Equal(t, tt.updates.Slug, dbGallery.Slug)
 			require.Equal(t, tt.updates.Description, dbGallery.Description)
-			require.Equal(t, tt.updates.Images, dbGallery.Images)
-			require.Equal(t, tt.updates.CoverImageIndex, dbGallery.CoverImageIndex)
 			require.Equal(t, tt.updates.Status, dbGallery.Status)
 			require.Equal(t, tt.updates.Metadata, dbGallery.Metadata)
 			require.Equal(t, tt.updates.Tags, dbGallery.Tags)
@@ -1095,7 +1485,6 @@ func TestGalleryRepo_DeleteGallery(t *testing.T) {
 		Title:    "To be deleted",
 		Slug:     "delete-me",
 		AuthorID: uuid.New(),
-		Images:   []string{"new1.jpg", "new2.jpg"},
 	}
 	id, err := repo.CreateGallery(testCtx, gallery)
 	require.NoError(t, err)
@@ -1120,16 +1509,16 @@ func TestGalleryRepo_GetGalleryByID(t *testing.T) {
 
 	// Создаем тестовую галерею
 	expected := models.Gallery{
-		Title:           "Test Get Gallery",
-		Slug:            "test-get-gallery",
-		Description:     "Test description for get",
-		Images:          []string{"get1.jpg", "get2.jpg"},
-		CoverImageIndex: 1,
-		AuthorID:        uuid.New(),
-		Status:          "published",
-		Metadata:        map[string]interface{}{"get": "test"},
-		Tags:            []string{"get", "test"},
+		Title:       "Test Get Gallery",
+		Slug:        "test-get-gallery",
+		Description: "Test description for get",
+		Items:       createGalleryMedia(t, db, "get1.jpg", "get2.jpg"),
+		AuthorID:    uuid.New(),
+		Status:      "published",
+		Metadata:    map[string]interface{}{"get": "test"},
+		Tags:        []string{"get", "test"},
 	}
+	expected.CoverMediaID = &expected.Items[1].MediaID
 	id, err := repo.CreateGallery(testCtx, expected)
 	require.NoError(t, err)
 	expected.ID = id
@@ -1142,8 +1531,10 @@ func TestGalleryRepo_GetGalleryByID(t *testing.T) {
 		require.Equal(t, expected.Title, result.Title)
 		require.Equal(t, expected.Slug, result.Slug)
 		require.Equal(t, expected.Description, result.Description)
-		require.Equal(t, expected.Images, result.Images)
-		require.Equal(t, expected.CoverImageIndex, result.CoverImageIndex)
+		require.Equal(t, []string{"get1.jpg", "get2.jpg"}, result.Images)
+		require.Equal(t, 1, result.CoverImageIndex)
+		require.Equal(t, expected.CoverMediaID, result.CoverMediaID)
+		require.Len(t, result.Items, 2)
 		require.Equal(t, expected.AuthorID, result.AuthorID)
 		require.Equal(t, expected.Status, result.Status)
 		require.Equal(t, expected.Metadata, result.Metadata)
@@ -1159,6 +1550,75 @@ func TestGalleryRepo_GetGalleryByID(t *testing.T) {
 	})
 }
 
+func TestGalleryRepo_GalleryItems(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewGalleryRepo(db)
+	ctx := context.Background()
+
+	items := createGalleryMedia(t, db, "a.jpg", "b.jpg", "c.jpg")
+	id, err := repo.CreateGallery(ctx, models.Gallery{
+		Title:    "Items",
+		Slug:     "items",
+		AuthorID: uuid.New(),
+		Items:    items[:2],
+	})
+	require.NoError(t, err)
+
+	t.Run("legacy paths resolved", func(t *testing.T) {
+		ids, err := repo.GetMediaIDsByPaths(ctx, []string{"/a.jpg", "missing.jpg"})
+		require.NoError(t, err)
+		require.Equal(t, map[string]uuid.UUID{"/a.jpg": items[0].MediaID}, ids)
+	})
+
+	t.Run("add, reorder and remove", func(t *testing.T) {
+		require.NoError(t, repo.AddGalleryItems(ctx, id, items[2:]))
+
+		order := []uuid.UUID{items[2].MediaID, items[0].MediaID, items[1].MediaID}
+		require.NoError(t, repo.ReorderGalleryItems(ctx, id, order))
+
+		err := repo.ReorderGalleryItems(ctx, id, order[:2])
+		require.ErrorIs(t, err, storage.ErrInvalidGalleryOrder)
+
+		require.NoError(t, repo.RemoveGalleryItem(ctx, id, items[0].MediaID))
+
+		gallery, err := repo.GetGalleryByID(ctx, id)
+		require.NoError(t, err)
+		require.Equal(t, []string{"c.jpg", "b.jpg"}, gallery.Images)
+		require.Equal(t, 1, gallery.Items[1].Position)
+	})
+
+	t.Run("cover must belong to gallery", func(t *testing.T) {
+		err := repo.SetGalleryCover(ctx, id, items[0].MediaID)
+		require.ErrorIs(t, err, storage.ErrGalleryItemNotFound)
+
+		require.NoError(t, repo.SetGalleryCover(ctx, id, items[1].MediaID))
+	})
+
+	t.Run("unknown media", func(t *testing.T) {
+		err := repo.AddGalleryItems(ctx, id, []models.GalleryItem{{MediaID: uuid.New()}})
+		require.ErrorIs(t, err, storage.ErrMediaNotFound)
+	})
+}
+
+// createGalleryMedia создает записи media с указанными путями и возвращает их как изображения галереи
+func createGalleryMedia(t *testing.T, db *pgxpool.Pool, paths ...string) []models.GalleryItem {
+	t.Helper()
+
+	items := make([]models.GalleryItem, 0, len(paths))
+	for _, path := range paths {
+		id := uuid.New()
+		_, err := db.Exec(context.Background(), `
+			INSERT INTO media (id, uploader_id, created_at, media_type, original_filename, storage_path, file_size)
+			VALUES ($1, $2, NOW(), 'image', $3, $3, 1)`,
+			id, uuid.New(), path)
+		require.NoError(t, err)
+
+		items = append(items, models.GalleryItem{MediaID: id})
+	}
+
+	return items
+}
+
 func TestGalleryRepo_GetGalleries(t *testing.T) {
 	db := setupTestDB(t)
 	repo := repository.NewGalleryRepo(db)
@@ -1189,14 +1649,14 @@ func TestGalleryRepo_GetGalleries(t *testing.T) {
 	require.NoError(t, err)
 
 	t.Run("get all galleries", func(t *testing.T) {
-		galleries, total, err := repo.GetGalleries(testCtx, "all", 1, 10)
+		galleries, total, err := repo.ListGalleries(testCtx, models.GalleryFilter{Status: "all"}, 1, 10)
 		require.NoError(t, err)
 		require.GreaterOrEqual(t, total, 2)
 		require.GreaterOrEqual(t, len(galleries), 2)
 	})
 
 	t.Run("filter by published status", func(t *testing.T) {
-		galleries, total, err := repo.GetGalleries(testCtx, "published", 1, 10)
+		galleries, total, err := repo.ListGalleries(testCtx, models.GalleryFilter{Status: "published"}, 1, 10)
 		require.NoError(t, err)
 
 		require.GreaterOrEqual(t, total, 1)
@@ -1205,7 +1665,7 @@ func TestGalleryRepo_GetGalleries(t *testing.T) {
 	})
 
 	t.Run("filter by draft status", func(t *testing.T) {
-		galleries, total, err := repo.GetGalleries(testCtx, "draft", 1, 10)
+		galleries, total, err := repo.ListGalleries(testCtx, models.GalleryFilter{Status: "draft"}, 1, 10)
 		require.NoError(t, err)
 
 		require.GreaterOrEqual(t, total, 1)
@@ -1215,30 +1675,113 @@ func TestGalleryRepo_GetGalleries(t *testing.T) {
 
 	t.Run("pagination works", func(t *testing.T) {
 		// Первая страница - 1 запись
-		galleries, total, err := repo.GetGalleries(testCtx, "all", 1, 1)
+		galleries, total, err := repo.ListGalleries(testCtx, models.GalleryFilter{Status: "all"}, 1, 1)
 		require.NoError(t, err)
 
 		require.GreaterOrEqual(t, total, 2)
 		require.Equal(t, 1, len(galleries))
 
 		// Вторая страница - следующая запись
-		galleries, _, err = repo.GetGalleries(testCtx, "all", 2, 1)
+		galleries, _, err = repo.ListGalleries(testCtx, models.GalleryFilter{Status: "all"}, 2, 1)
 		require.NoError(t, err)
 		require.Equal(t, 1, len(galleries))
 	})
 
 	t.Run("invalid status filter", func(t *testing.T) {
-		_, _, err := repo.GetGalleries(testCtx, "invalid_status", 1, 10)
+		_, _, err := repo.ListGalleries(testCtx, models.GalleryFilter{Status: "invalid_status"}, 1, 10)
 		require.Error(t, err)
 		require.Contains(t, err.Error(), "invalid status filter")
 	})
 
 	t.Run("empty result", func(t *testing.T) {
-		galleries, total, err := repo.GetGalleries(testCtx, "archived", 1, 10)
+		galleries, total, err := repo.ListGalleries(testCtx, models.GalleryFilter{Status: "archived"}, 1, 10)
 		require.NoError(t, err)
-		require.Equal(t, 2, total)
+		require.Equal(t, 0, total)
 		require.Empty(t, galleries)
 	})
+
+	t.Run("title search and sort", func(t *testing.T) {
+		galleries, total, err := repo.ListGalleries(testCtx, models.GalleryFilter{Query: "draft", Sort: models.GallerySortTitle}, 1, 10)
+		require.NoError(t, err)
+		require.Equal(t, 1, total)
+		require.Equal(t, "draft-gallery", galleries[0].Slug)
+
+		_, total, err = repo.ListGalleries(testCtx, models.GalleryFilter{Query: "100%"}, 1, 10)
+		require.NoError(t, err)
+		require.Equal(t, 0, total)
+	})
+
+	t.Run("cursor pagination", func(t *testing.T) {
+		filter := models.GalleryFilter{Sort: models.GallerySortTitle}
+
+		first, total, err := repo.ListGalleries(testCtx, filter, 1, 1)
+		require.NoError(t, err)
+		require.Equal(t, 2, total)
+		require.Equal(t, "Draft Gallery", first[0].Title)
+
+		filter.After = &pagination.Cursor{Sort: models.GallerySortTitle, ID: first[0].ID, Text: first[0].Title}
+		second, total, err := repo.ListGalleries(testCtx, filter, 1, 1)
+		require.NoError(t, err)
+		require.Equal(t, 2, total)
+		require.Equal(t, "Published Gallery", second[0].Title)
+	})
+}
+
+func TestGalleryTagRepo_MergeTags(t *testing.T) {
+	db := setupTestDB(t)
+	repo := repository.NewGalleryRepo(db)
+	tagRepo := repository.NewGalleryTagRepo(db)
+	ctx := context.Background()
+
+	_, err := repo.CreateGallery(ctx, models.Gallery{
+		Title: "Sea", Slug: "sea", Status: "published", AuthorID: uuid.New(),
+		Tags: []string{"natrue", "sea"},
+	})
+	require.NoError(t, err)
+	_, err = repo.CreateGallery(ctx, models.Gallery{
+		Title: "Forest", Slug: "forest", Status: "published", AuthorID: uuid.New(),
+		Tags: []string{"nature", "forest"},
+	})
+	require.NoError(t, err)
+
+	t.Run("directory with counts and prefix", func(t *testing.T) {
+		tags, err := tagRepo.ListTags(ctx, "published", "nat", 10)
+		require.NoError(t, err)
+		require.Equal(t, []models.GalleryTag{{Name: "natrue", Count: 1}, {Name: "nature", Count: 1}}, tags)
+	})
+
+	t.Run("rename keeps alias", func(t *testing.T) {
+		affected, err := tagRepo.MergeTags(ctx, []string{"natrue"}, "nature", true)
+		require.NoError(t, err)
+		require.Equal(t, 1, affected)
+
+		tags, err := tagRepo.ListTags(ctx, "all", "nat", 10)
+		require.NoError(t, err)
+		require.Equal(t, []models.GalleryTag{{Name: "nature", Count: 2}}, tags)
+
+		// Старое имя продолжает находить галереи
+		galleries, total, err := repo.ListGalleries(ctx, models.GalleryFilter{Tags: []string{"natrue"}}, 1, 10)
+		require.NoError(t, err)
+		require.Equal(t, 2, total)
+		require.Len(t, galleries, 2)
+	})
+
+	t.Run("alias resolved on write", func(t *testing.T) {
+		id, err := repo.CreateGallery(ctx, models.Gallery{
+			Title: "Lake", Slug: "lake", AuthorID: uuid.New(),
+			Tags: []string{"natrue", "nature", "lake"},
+		})
+		require.NoError(t, err)
+
+		tags, err := repo.GetTags(ctx, id.String())
+		require.NoError(t, err)
+		require.Equal(t, []string{"nature", "lake"}, tags)
+	})
+
+	t.Run("delete alias", func(t *testing.T) {
+		require.NoError(t, tagRepo.DeleteAlias(ctx, "natrue"))
+		require.ErrorIs(t, tagRepo.DeleteAlias(ctx, "natrue"), storage.ErrTagAliasNotFound)
+	})
 }
 
 func TestTagRepository_GetGalleriesByTags(t *testing.T) {
@@ -1273,38 +1816,38 @@ func TestTagRepository_GetGalleriesByTags(t *testing.T) {
 	require.NoError(t, err)
 
 	t.Run("filter with AND logic", func(t *testing.T) {
-		galleries, err := repo.GetGalleriesByTags(testCtx, []string{"nature", "landscape"}, true)
+		galleries, _, err := repo.ListGalleries(testCtx, models.GalleryFilter{Tags: []string{"nature", "landscape"}, MatchAllTags: true}, 1, 10)
 		require.NoError(t, err)
 		require.Equal(t, 1, len(galleries))
 		require.Equal(t, "gallery-with-tags", galleries[0].Slug)
 	})
 
 	t.Run("filter with OR logic", func(t *testing.T) {
-		galleries, err := repo.GetGalleriesByTags(testCtx, []string{"nature", "art"}, false)
+		galleries, _, err := repo.ListGalleries(testCtx, models.GalleryFilter{Tags: []string{"nature", "art"}, MatchAllTags: false}, 1, 10)
 		require.NoError(t, err)
 		require.Equal(t, 2, len(galleries))
 	})
 
 	t.Run("no matching tags", func(t *testing.T) {
-		galleries, err := repo.GetGalleriesByTags(testCtx, []string{"unknown"}, true)
+		galleries, _, err := repo.ListGalleries(testCtx, models.GalleryFilter{Tags: []string{"unknown"}, MatchAllTags: true}, 1, 10)
 		require.NoError(t, err)
 		require.Empty(t, galleries)
 	})
 
 	t.Run("empty tags list", func(t *testing.T) {
-		galleries, err := repo.GetGalleriesByTags(testCtx, []string{}, true)
+		galleries, _, err := repo.ListGalleries(testCtx, models.GalleryFilter{Tags: []string{}, MatchAllTags: true}, 1, 10)
 		require.NoError(t, err)
 		require.Equal(t, 2, len(galleries))
 	})
 
 	t.Run("partial match with AND logic", func(t *testing.T) {
-		galleries, err := repo.GetGalleriesByTags(testCtx, []string{"nature", "art"}, true)
+		galleries, _, err := repo.ListGalleries(testCtx, models.GalleryFilter{Tags: []string{"nature", "art"}, MatchAllTags: true}, 1, 10)
 		require.NoError(t, err)
 		require.Empty(t, galleries)
 	})
 
 	t.Run("partial match with OR logic", func(t *testing.T) {
-		galleries, err := repo.GetGalleriesByTags(testCtx, []string{"nature", "art"}, false)
+		galleries, _, err := repo.ListGalleries(testCtx, models.GalleryFilter{Tags: []string{"nature", "art"}, MatchAllTags: false}, 1, 10)
 		require.NoError(t, err)
 		require.Equal(t, 2, len(galleries))
 	})
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type SettingsRepo struct {
+	db *txDB
+	sb sq.StatementBuilderType
+}
+
+func NewSettingsRepo(db *pgxpool.Pool) *SettingsRepo {
+	return &SettingsRepo{
+		db: newTxDB(db),
+		sb: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+	}
+}
+
+// GetSetting читает настройку key в dest. Если настройка не сохранялась,
+// dest остается без изменений, поэтому значения по умолчанию задает вызывающий
+func (r *SettingsRepo) GetSetting(ctx context.Context, key string, dest any) error {
+	const op = "repository.settings_repository.GetSetting"
+
+	sql, args, err := r.sb.Select("value").
+		From("app_settings").
+		Where(sq.Eq{"key": key}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: can't build sql: %w", op, err)
+	}
+
+	var value []byte
+	if err := r.db.QueryRow(ctx, sql, args...).Scan(&value); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := json.Unmarshal(value, dest); err != nil {
+		return fmt.Errorf("%s: failed to decode %s: %w", op, key, err)
+	}
+
+	return nil
+}
+
+// SaveSetting сохраняет настройку key и запоминает, кто ее изменил
+func (r *SettingsRepo) SaveSetting(ctx context.Context, key string, value any, actorID uuid.UUID) error {
+	const op = "repository.settings_repository.SaveSetting"
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("%s: failed to encode %s: %w", op, key, err)
+	}
+
+	var updatedBy *uuid.UUID
+	if actorID != uuid.Nil {
+		updatedBy = &actorID
+	}
+
+	sql, args, err := r.sb.Insert("app_settings").
+		Columns("key", "value", "updated_at", "updated_by").
+		Values(key, data, time.Now().UTC(), updatedBy).
+		Suffix("ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, updated_at = EXCLUDED.updated_at, updated_by = EXCLUDED.updated_by").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: can't build sql: %w", op, err)
+	}
+
+	if _, err := r.db.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
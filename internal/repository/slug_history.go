@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+)
+
+// lockCurrentSlug возвращает текущий slug записи и блокирует строку до конца транзакции
+func lockCurrentSlug(ctx context.Context, tx pgx.Tx, table string, id uuid.UUID, notFound error) (string, error) {
+	var slug string
+	err := tx.QueryRow(ctx, `SELECT slug FROM `+table+` WHERE id = $1 FOR UPDATE`, id).Scan(&slug)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return "", notFound
+		}
+		return "", fmt.Errorf("failed to get current slug: %w", err)
+	}
+
+	return slug, nil
+}
+
+// recordSlugChange сохраняет прежний slug в таблице истории. Если сущность
+// вернула себе один из старых slug'ов, он удаляется из истории.
+func recordSlugChange(ctx context.Context, tx pgx.Tx, historyTable, idColumn string, id uuid.UUID, oldSlug, newSlug string) error {
+	if oldSlug == newSlug {
+		return nil
+	}
+
+	_, err := tx.Exec(ctx, `DELETE FROM `+historyTable+` WHERE slug = $1`, newSlug)
+	if err != nil {
+		return fmt.Errorf("failed to clean slug history: %w", err)
+	}
+
+	// Старый slug мог принадлежать другой сущности до её переименования - ссылка переходит к текущей
+	_, err = tx.Exec(ctx, `
+		INSERT INTO `+historyTable+` (slug, `+idColumn+`)
+		VALUES ($1, $2)
+		ON CONFLICT (slug) DO UPDATE SET `+idColumn+` = EXCLUDED.`+idColumn+`, created_at = NOW()
+	`, oldSlug, id)
+	if err != nil {
+		return fmt.Errorf("failed to record slug history: %w", err)
+	}
+
+	return nil
+}
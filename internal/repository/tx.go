@@ -0,0 +1,155 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const (
+	// defaultTxAttempts сколько раз выполняется транзакция при конфликтах сериализации
+	defaultTxAttempts = 3
+	// defaultTxBackoff пауза перед повтором, растет линейно с номером попытки
+	defaultTxBackoff = 20 * time.Millisecond
+)
+
+// txKey ключ контекста, под которым хранится текущая транзакция
+type txKey struct{}
+
+// querier методы pgxpool.Pool и pgx.Tx, которыми пользуются репозитории
+type querier interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+}
+
+// txDB подключение репозитория к базе. Если в контексте есть транзакция TxManager,
+// запросы выполняются в ней, иначе - на пуле соединений. Begin внутри транзакции
+// создает точку сохранения, поэтому собственные транзакции репозиториев
+// вкладываются в общую без изменений
+type txDB struct {
+	pool *pgxpool.Pool
+}
+
+func newTxDB(pool *pgxpool.Pool) *txDB {
+	return &txDB{pool: pool}
+}
+
+func (d *txDB) conn(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+
+	return d.pool
+}
+
+func (d *txDB) Begin(ctx context.Context) (pgx.Tx, error) {
+	return d.conn(ctx).Begin(ctx)
+}
+
+func (d *txDB) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	return d.conn(ctx).Exec(ctx, sql, args...)
+}
+
+func (d *txDB) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return d.conn(ctx).Query(ctx, sql, args...)
+}
+
+func (d *txDB) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return d.conn(ctx).QueryRow(ctx, sql, args...)
+}
+
+// TxManager выполняет вызовы нескольких репозиториев в одной транзакции.
+// Транзакция передается через контекст: все репозитории, созданные на том же пуле,
+// работают в ней, если получили контекст из функции WithinTx
+type TxManager struct {
+	pool     *pgxpool.Pool
+	attempts int
+	backoff  time.Duration
+}
+
+func NewTxManager(pool *pgxpool.Pool) *TxManager {
+	return &TxManager{
+		pool:     pool,
+		attempts: defaultTxAttempts,
+		backoff:  defaultTxBackoff,
+	}
+}
+
+// WithinTx выполняет fn в транзакции с уровнем изоляции по умолчанию.
+// Подробности - в WithinTxOptions
+func (m *TxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return m.WithinTxOptions(ctx, pgx.TxOptions{}, fn)
+}
+
+// WithinTxOptions выполняет fn в транзакции с параметрами opts. Если fn вернула ошибку
+// или запаниковала, транзакция откатывается, иначе фиксируется.
+//
+// Вызов внутри другой транзакции создает точку сохранения: ошибка fn откатывает
+// только ее изменения, а opts не применяются. Внешняя транзакция при ошибке
+// сериализации или взаимной блокировке повторяется целиком, поэтому fn не должна
+// иметь побочных эффектов вне базы. Транзакция не рассчитана на параллельные
+// запросы из нескольких горутин
+func (m *TxManager) WithinTxOptions(ctx context.Context, opts pgx.TxOptions, fn func(ctx context.Context) error) error {
+	const op = "repository.TxManager.WithinTx"
+
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return runTx(ctx, tx.Begin, fn)
+	}
+
+	begin := func(ctx context.Context) (pgx.Tx, error) {
+		return m.pool.BeginTx(ctx, opts)
+	}
+
+	for attempt := 1; ; attempt++ {
+		err := runTx(ctx, begin, fn)
+		if err == nil || !isRetryable(err) || attempt >= m.attempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s: %w", op, errors.Join(err, ctx.Err()))
+		case <-time.After(time.Duration(attempt) * m.backoff):
+		}
+	}
+}
+
+// runTx открывает транзакцию через begin, выполняет fn и фиксирует или откатывает ее.
+// Ошибки fn возвращаются без оборачивания, чтобы сервисы видели свои ошибки как есть
+func runTx(ctx context.Context, begin func(ctx context.Context) (pgx.Tx, error), fn func(ctx context.Context) error) (err error) {
+	const op = "repository.runTx"
+
+	tx, err := begin(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback(ctx)
+			panic(p)
+		}
+		if err != nil {
+			if rbErr := tx.Rollback(ctx); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+				err = errors.Join(err, fmt.Errorf("%s: failed to rollback transaction: %w", op, rbErr))
+			}
+		}
+	}()
+
+	if err = fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("%s: failed to commit transaction: %w", op, err)
+	}
+
+	return nil
+}
@@ -8,22 +8,23 @@ import (
 	"time"
 
 	"premium_caste/internal/domain/models"
+	"premium_caste/internal/lib/pagination"
 	"premium_caste/internal/storage"
 
 	sq "github.com/Masterminds/squirrel"
 	"github.com/google/uuid"
-	"github.com/jackc/pgx"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
 type UserRepo struct {
-	db *pgxpool.Pool
+	db *txDB
 	sb sq.StatementBuilderType
 }
 
 func NewUserRepository(db *pgxpool.Pool) *UserRepo {
 	return &UserRepo{
-		db: db,
+		db: newTxDB(db),
 		sb: sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
 	}
 }
@@ -31,6 +32,10 @@ func NewUserRepository(db *pgxpool.Pool) *UserRepo {
 func (r *UserRepo) SaveUser(ctx context.Context, user models.User) (uuid.UUID, error) {
 	const op = "repository.user_repository.SaveUser"
 
+	if user.Role == "" {
+		user.Role = models.RoleUser
+	}
+
 	query, args, err := r.sb.Insert("users").
 		Columns(
 			"name",
@@ -38,15 +43,17 @@ func (r *UserRepo) SaveUser(ctx context.Context, user models.User) (uuid.UUID, e
 			"phone",
 			"password",
 			"is_admin",
+			"role",
 			"basket_id",
 			"last_login",
 		).
 		Values(
 			user.Name,
 			user.Email,
-			user.Phone,
+			nullIfEmpty(user.Phone), // Пользователи внешних провайдеров приходят без телефона
 			user.Password,
-			user.IsAdmin,
+			user.Role == models.RoleAdmin,
+			user.Role,
 			user.BasketID,
 			time.Now().UTC(),
 		).
@@ -59,12 +66,21 @@ func (r *UserRepo) SaveUser(ctx context.Context, user models.User) (uuid.UUID, e
 	var id uuid.UUID
 	err = r.db.QueryRow(ctx, query, args...).Scan(&id)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+		return uuid.Nil, fmt.Errorf("%s: %w", op, asConflict(err, storage.ErrUserExists))
 	}
 
 	return id, nil
 }
 
+// nullIfEmpty сохраняет пустую строку как NULL, чтобы не нарушать ограничение уникальности
+func nullIfEmpty(s string) any {
+	if s == "" {
+		return nil
+	}
+
+	return s
+}
+
 // func (r *UserRepo) User(ctx context.Context, email string) (models.User, error) {
 // 	const op = "repository.user_repository.User"
 
@@ -93,6 +109,56 @@ func (r *UserRepo) SaveUser(ctx context.Context, user models.User) (uuid.UUID, e
 // 	return user, nil
 // }
 
+// userColumns столбцы users без хеша пароля в порядке scanUser
+var userColumns = []string{
+	"id",
+	"name",
+	"email",
+	"COALESCE(phone, '')",
+	"is_admin",
+	"role",
+	"basket_id",
+	"registration_date",
+	"COALESCE(last_login, registration_date)",
+	"suspended_at",
+	"suspended_by",
+	"COALESCE(suspension_reason, '')",
+	"anonymized_at",
+	"updated_at",
+	"updated_by",
+	"COALESCE(pending_email, '')",
+	"totp_enabled_at IS NOT NULL",
+}
+
+func scanUser(row pgx.Row, user *models.User, extra ...any) error {
+	var lastLogin time.Time
+	dest := []any{
+		&user.ID,
+		&user.Name,
+		&user.Email,
+		&user.Phone,
+		&user.IsAdmin,
+		&user.Role,
+		&user.BasketID,
+		&user.RegistrationDate,
+		&lastLogin,
+		&user.SuspendedAt,
+		&user.SuspendedBy,
+		&user.SuspensionReason,
+		&user.AnonymizedAt,
+		&user.UpdatedAt,
+		&user.UpdatedBy,
+		&user.PendingEmail,
+		&user.TwoFactorEnabled,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return err
+	}
+	user.LastLogin = lastLogin
+
+	return nil
+}
+
 func (r *UserRepo) UserByIdentifier(ctx context.Context, identifier string) (models.User, error) {
 	const op = "repository.user_repository.UserByIdentifier"
 
@@ -103,7 +169,8 @@ func (r *UserRepo) UserByIdentifier(ctx context.Context, identifier string) (mod
 		condition = sq.Eq{"phone": identifier}
 	}
 
-	sql, args, err := r.sb.Select("id", "name", "email", "phone", "password", "is_admin", "basket_id").
+	sql, args, err := r.sb.Select(userColumns...).
+		Column("password").
 		From("users").
 		Where(condition).
 		ToSql()
@@ -111,29 +178,22 @@ func (r *UserRepo) UserByIdentifier(ctx context.Context, identifier string) (mod
 		return models.User{}, fmt.Errorf("%s: can't build sql:%w", op, err)
 	}
 
-	rows, err := r.db.Query(ctx, sql, args...)
-	if err != nil {
-		return models.User{}, fmt.Errorf("%s: %w", op, err)
-	}
-	defer rows.Close()
-
 	var user models.User
-	if rows.Next() {
-		err = rows.Scan(&user.ID, &user.Name, &user.Email, &user.Phone, &user.Password, &user.IsAdmin, &user.BasketID)
-		if err != nil {
-			return models.User{}, fmt.Errorf("%s: %w", op, err)
+	if err := scanUser(r.db.QueryRow(ctx, sql, args...), &user, &user.Password); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return models.User{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
 		}
-
-		return user, nil
+		return models.User{}, fmt.Errorf("%s: %w", op, err)
 	}
 
-	return models.User{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	return user, nil
 }
 
+// IsAdmin проверяет роль администратора. Заблокированный администратор прав не имеет
 func (r *UserRepo) IsAdmin(ctx context.Context, userID uuid.UUID) (bool, error) {
 	const op = "repository.user_repository.IsAdmin"
 
-	sql, args, err := r.sb.Select("is_admin").From("users").Where(sq.Eq{"id": userID}).ToSql()
+	sql, args, err := r.sb.Select("is_admin AND suspended_at IS NULL").From("users").Where(sq.Eq{"id": userID}).ToSql()
 	if err != nil {
 		return false, fmt.Errorf("%s: can't build sql: %w", op, err)
 	}
@@ -154,15 +214,7 @@ func (r *UserRepo) GetUserById(ctx context.Context, userID uuid.UUID) (models.Us
 	const op = "repository.user_repository.GetUserById"
 
 	sql, args, err := r.sb.
-		Select(
-			"name",
-			"email",
-			"phone",
-			"is_admin",
-			"basket_id",
-			"registration_date",
-			"last_login",
-		).
+		Select(userColumns...).
 		From("users").
 		Where(sq.Eq{"id": userID}).
 		ToSql()
@@ -171,18 +223,7 @@ func (r *UserRepo) GetUserById(ctx context.Context, userID uuid.UUID) (models.Us
 	}
 
 	var user models.User
-
-	// Выполняем запрос и сканируем результат
-	err = r.db.QueryRow(ctx, sql, args...).Scan(
-		&user.Name,
-		&user.Email,
-		&user.Phone,
-		&user.IsAdmin,
-		&user.BasketID,
-		&user.RegistrationDate,
-		&user.LastLogin,
-	)
-	if err != nil {
+	if err := scanUser(r.db.QueryRow(ctx, sql, args...), &user); err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return models.User{}, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
 		}
@@ -191,3 +232,492 @@ func (r *UserRepo) GetUserById(ctx context.Context, userID uuid.UUID) (models.Us
 
 	return user, nil
 }
+
+// userKeyset порядок списка пользователей: сначала новые
+var userKeyset = pagination.Keyset{Key: "registration_date", ID: "id", Desc: true}
+
+// ListUsers возвращает страницу пользователей, новые первыми, и их общее количество с учетом фильтра
+func (r *UserRepo) ListUsers(ctx context.Context, filter models.UserFilter, page, perPage int) ([]models.User, int, error) {
+	const op = "repository.user_repository.ListUsers"
+
+	var where sq.And
+	if filter.Query != "" {
+		pattern := "%" + escapeLike(filter.Query) + "%"
+		where = append(where, sq.Expr(`(name ILIKE ? ESCAPE '\' OR email ILIKE ? ESCAPE '\' OR phone LIKE ? ESCAPE '\')`, pattern, pattern, pattern))
+	}
+	if filter.Role != "" {
+		where = append(where, sq.Eq{"role": filter.Role})
+	}
+	switch filter.Status {
+	case models.UserStatusActive:
+		where = append(where, sq.Eq{"suspended_at": nil, "anonymized_at": nil})
+	case models.UserStatusSuspended:
+		where = append(where, sq.NotEq{"suspended_at": nil}, sq.Eq{"anonymized_at": nil})
+	case models.UserStatusAnonymized:
+		where = append(where, sq.NotEq{"anonymized_at": nil})
+	}
+
+	total, err := pagination.Count(ctx, r.db, r.sb.Select("COUNT(*)").From("users").Where(where))
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	queryBuilder := r.sb.
+		Select(userColumns...).
+		From("users").
+		Where(where)
+
+	sql, args, err := userKeyset.Apply(queryBuilder, filter.After, page, perPage).ToSql()
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: can't build sql: %w", op, err)
+	}
+
+	rows, err := r.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	users := make([]models.User, 0, perPage)
+	for rows.Next() {
+		var user models.User
+		if err := scanUser(rows, &user); err != nil {
+			return nil, 0, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	return users, total, nil
+}
+
+// ActiveAdminIDs возвращает незаблокированных администраторов. В транзакции строки
+// блокируются до ее конца, поэтому параллельные понижения не оставят систему без администратора
+func (r *UserRepo) ActiveAdminIDs(ctx context.Context) ([]uuid.UUID, error) {
+	const op = "repository.user_repository.ActiveAdminIDs"
+
+	sql, args, err := r.sb.Select("id").
+		From("users").
+		Where(sq.Eq{"role": models.RoleAdmin, "suspended_at": nil, "anonymized_at": nil}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: can't build sql: %w", op, err)
+	}
+
+	rows, err := r.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	defer rows.Close()
+
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("%s: failed to scan row: %w", op, err)
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: rows error: %w", op, err)
+	}
+
+	return ids, nil
+}
+
+// UpdateRole меняет роль пользователя от имени администратора actorID
+func (r *UserRepo) UpdateRole(ctx context.Context, userID uuid.UUID, role string, actorID uuid.UUID) error {
+	const op = "repository.user_repository.UpdateRole"
+
+	return r.update(ctx, op, userID, actorID, map[string]any{
+		"role":     role,
+		"is_admin": role == models.RoleAdmin,
+	})
+}
+
+// SetSuspended блокирует пользователя с причиной reason или снимает блокировку
+func (r *UserRepo) SetSuspended(ctx context.Context, userID uuid.UUID, suspended bool, reason string, actorID uuid.UUID) error {
+	const op = "repository.user_repository.SetSuspended"
+
+	fields := map[string]any{
+		"suspended_at":      nil,
+		"suspended_by":      nil,
+		"suspension_reason": nil,
+	}
+	if suspended {
+		fields = map[string]any{
+			"suspended_at":      time.Now().UTC(),
+			"suspended_by":      actorID,
+			"suspension_reason": reason,
+		}
+	}
+
+	return r.update(ctx, op, userID, actorID, fields)
+}
+
+// Anonymize заменяет персональные данные пользователя заглушками и делает вход невозможным.
+// Строка остается, чтобы не терять связанные комментарии и ссылки на автора
+func (r *UserRepo) Anonymize(ctx context.Context, userID uuid.UUID, actorID uuid.UUID) error {
+	const op = "repository.user_repository.Anonymize"
+
+	placeholder := "deleted-" + userID.String()
+
+	return r.update(ctx, op, userID, actorID, map[string]any{
+		"name":              "Deleted user",
+		"email":             placeholder + "@anonymized.invalid",
+		"phone":             placeholder,
+		"password":          "", // Пустой хеш не совпадает ни с одним паролем
+		"role":              models.RoleUser,
+		"is_admin":          false,
+		"anonymized_at":     time.Now().UTC(),
+		"suspended_at":      nil,
+		"suspended_by":      nil,
+		"suspension_reason": nil,
+		"totp_secret":       nil,
+		"totp_enabled_at":   nil,
+		"totp_last_step":    nil,
+	})
+}
+
+// DeleteUser удаляет пользователя. Его комментарии удаляются каскадно
+func (r *UserRepo) DeleteUser(ctx context.Context, userID uuid.UUID) error {
+	const op = "repository.user_repository.DeleteUser"
+
+	sql, args, err := r.sb.Delete("users").Where(sq.Eq{"id": userID}).ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: can't build sql: %w", op, err)
+	}
+
+	tag, err := r.db.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+
+	return nil
+}
+
+// update меняет поля пользователя и запоминает, кто и когда это сделал
+func (r *UserRepo) update(ctx context.Context, op string, userID, actorID uuid.UUID, fields map[string]any) error {
+	sql, args, err := r.sb.Update("users").
+		SetMap(fields).
+		Set("updated_at", time.Now().UTC()).
+		Set("updated_by", actorID).
+		Where(sq.Eq{"id": userID}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: can't build sql: %w", op, err)
+	}
+
+	tag, err := r.db.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+
+	return nil
+}
+
+// PasswordHash возвращает хеш пароля пользователя для проверки текущего пароля
+func (r *UserRepo) PasswordHash(ctx context.Context, userID uuid.UUID) ([]byte, error) {
+	const op = "repository.user_repository.PasswordHash"
+
+	sql, args, err := r.sb.Select("password").From("users").Where(sq.Eq{"id": userID}).ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: can't build sql: %w", op, err)
+	}
+
+	var hash []byte
+	if err := r.db.QueryRow(ctx, sql, args...).Scan(&hash); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return hash, nil
+}
+
+// UpdateProfile меняет имя и телефон пользователя. nil-поля не меняются
+func (r *UserRepo) UpdateProfile(ctx context.Context, userID uuid.UUID, name, phone *string) error {
+	const op = "repository.user_repository.UpdateProfile"
+
+	fields := map[string]any{}
+	if name != nil {
+		fields["name"] = *name
+	}
+	if phone != nil {
+		fields["phone"] = *phone
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+
+	if err := r.update(ctx, op, userID, userID, fields); err != nil {
+		return asConflict(err, storage.ErrUserExists)
+	}
+
+	return nil
+}
+
+// SetPendingEmail запоминает новый email и хеш кода его подтверждения.
+// Повторный запрос заменяет предыдущий код
+func (r *UserRepo) SetPendingEmail(ctx context.Context, userID uuid.UUID, email string, tokenHash []byte, expiresAt time.Time) error {
+	const op = "repository.user_repository.SetPendingEmail"
+
+	return r.update(ctx, op, userID, userID, map[string]any{
+		"pending_email":            email,
+		"pending_email_token":      tokenHash,
+		"pending_email_expires_at": expiresAt.UTC(),
+	})
+}
+
+// ConfirmEmail заменяет email пользователя подтвержденным. Если код не совпадает
+// или истек, возвращается storage.ErrInvalidVerificationToken
+func (r *UserRepo) ConfirmEmail(ctx context.Context, userID uuid.UUID, tokenHash []byte) error {
+	const op = "repository.user_repository.ConfirmEmail"
+
+	sql, args, err := r.sb.Update("users").
+		Set("email", sq.Expr("pending_email")).
+		Set("pending_email", nil).
+		Set("pending_email_token", nil).
+		Set("pending_email_expires_at", nil).
+		Set("updated_at", time.Now().UTC()).
+		Set("updated_by", userID).
+		Where(sq.Eq{"id": userID, "pending_email_token": tokenHash}).
+		Where(sq.Expr("pending_email_expires_at > NOW()")).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: can't build sql: %w", op, err)
+	}
+
+	tag, err := r.db.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, asConflict(err, storage.ErrUserExists))
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrInvalidVerificationToken)
+	}
+
+	return nil
+}
+
+// TouchLastLogin записывает время успешного входа
+func (r *UserRepo) TouchLastLogin(ctx context.Context, userID uuid.UUID) error {
+	const op = "repository.user_repository.TouchLastLogin"
+
+	sql, args, err := r.sb.Update("users").
+		Set("last_login", time.Now().UTC()).
+		Where(sq.Eq{"id": userID}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: can't build sql: %w", op, err)
+	}
+
+	if _, err := r.db.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// UpdatePassword заменяет хеш пароля пользователя
+func (r *UserRepo) UpdatePassword(ctx context.Context, userID uuid.UUID, passwordHash []byte) error {
+	const op = "repository.user_repository.UpdatePassword"
+
+	sql, args, err := r.sb.Update("users").
+		Set("password", passwordHash).
+		Where(sq.Eq{"id": userID}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: can't build sql: %w", op, err)
+	}
+
+	tag, err := r.db.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+
+	return nil
+}
+
+// TOTPSecret возвращает секрет TOTP пользователя, в том числе еще не подтвержденный.
+// nil, если настройка не начиналась
+func (r *UserRepo) TOTPSecret(ctx context.Context, userID uuid.UUID) ([]byte, error) {
+	const op = "repository.user_repository.TOTPSecret"
+
+	sql, args, err := r.sb.Select("totp_secret").
+		From("users").
+		Where(sq.Eq{"id": userID}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("%s: can't build sql: %w", op, err)
+	}
+
+	var secret []byte
+	if err := r.db.QueryRow(ctx, sql, args...).Scan(&secret); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return secret, nil
+}
+
+// SetTOTPSecret начинает настройку 2FA: сохраняет секрет, который заработает после EnableTOTP
+func (r *UserRepo) SetTOTPSecret(ctx context.Context, userID uuid.UUID, secret []byte) error {
+	const op = "repository.user_repository.SetTOTPSecret"
+
+	return r.setTOTP(ctx, op, userID, map[string]any{
+		"totp_secret":     secret,
+		"totp_enabled_at": nil,
+		"totp_last_step":  nil,
+	})
+}
+
+// EnableTOTP включает 2FA с сохраненным секретом. step - интервал кода, которым
+// пользователь подтвердил настройку
+func (r *UserRepo) EnableTOTP(ctx context.Context, userID uuid.UUID, step int64) error {
+	const op = "repository.user_repository.EnableTOTP"
+
+	return r.setTOTP(ctx, op, userID, map[string]any{
+		"totp_enabled_at": time.Now().UTC(),
+		"totp_last_step":  step,
+	})
+}
+
+// DisableTOTP выключает 2FA и удаляет секрет
+func (r *UserRepo) DisableTOTP(ctx context.Context, userID uuid.UUID) error {
+	const op = "repository.user_repository.DisableTOTP"
+
+	return r.setTOTP(ctx, op, userID, map[string]any{
+		"totp_secret":     nil,
+		"totp_enabled_at": nil,
+		"totp_last_step":  nil,
+	})
+}
+
+// setTOTP меняет поля 2FA. updated_by не трогается: это не правка учетной записи в админке
+func (r *UserRepo) setTOTP(ctx context.Context, op string, userID uuid.UUID, fields map[string]any) error {
+	sql, args, err := r.sb.Update("users").
+		SetMap(fields).
+		Where(sq.Eq{"id": userID}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: can't build sql: %w", op, err)
+	}
+
+	tag, err := r.db.Exec(ctx, sql, args...)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("%s: %w", op, storage.ErrUserNotFound)
+	}
+
+	return nil
+}
+
+// UseTOTPStep отмечает интервал принятого кода. false, если код этого или более
+// позднего интервала уже использовался: так один код нельзя предъявить дважды
+func (r *UserRepo) UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	const op = "repository.user_repository.UseTOTPStep"
+
+	sql, args, err := r.sb.Update("users").
+		Set("totp_last_step", step).
+		Where(sq.Eq{"id": userID}).
+		Where(sq.Or{sq.Eq{"totp_last_step": nil}, sq.Lt{"totp_last_step": step}}).
+		ToSql()
+	if err != nil {
+		return false, fmt.Errorf("%s: can't build sql: %w", op, err)
+	}
+
+	tag, err := r.db.Exec(ctx, sql, args...)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// ReplaceRecoveryCodes заменяет коды восстановления пользователя новыми. Пустой
+// список просто удаляет старые коды
+func (r *UserRepo) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes [][]byte) error {
+	const op = "repository.user_repository.ReplaceRecoveryCodes"
+
+	sql, args, err := r.sb.Delete("user_recovery_codes").
+		Where(sq.Eq{"user_id": userID}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: can't build sql: %w", op, err)
+	}
+
+	if _, err := r.db.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(codeHashes) == 0 {
+		return nil
+	}
+
+	insert := r.sb.Insert("user_recovery_codes").Columns("user_id", "code_hash")
+	for _, hash := range codeHashes {
+		insert = insert.Values(userID, hash)
+	}
+
+	sql, args, err = insert.ToSql()
+	if err != nil {
+		return fmt.Errorf("%s: can't build sql: %w", op, err)
+	}
+
+	if _, err := r.db.Exec(ctx, sql, args...); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// UseRecoveryCode погашает код восстановления. false, если кода нет или он уже использован
+func (r *UserRepo) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash []byte) (bool, error) {
+	const op = "repository.user_repository.UseRecoveryCode"
+
+	sql, args, err := r.sb.Update("user_recovery_codes").
+		Set("used_at", time.Now().UTC()).
+		Where(sq.Eq{"user_id": userID, "code_hash": codeHash, "used_at": nil}).
+		ToSql()
+	if err != nil {
+		return false, fmt.Errorf("%s: can't build sql: %w", op, err)
+	}
+
+	tag, err := r.db.Exec(ctx, sql, args...)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// CountRecoveryCodes количество неиспользованных кодов восстановления
+func (r *UserRepo) CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int, error) {
+	const op = "repository.user_repository.CountRecoveryCodes"
+
+	count, err := pagination.Count(ctx, r.db, r.sb.Select("COUNT(*)").
+		From("user_recovery_codes").
+		Where(sq.Eq{"user_id": userID, "used_at": nil}))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"premium_caste/internal/domain/apperr"
+	"premium_caste/internal/domain/models"
+	"premium_caste/internal/lib/logger/sl"
+	"premium_caste/internal/lib/principal"
+	"premium_caste/internal/repository"
+	"premium_caste/internal/storage"
+	"premium_caste/internal/transport/http/dto"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// keyPrefix начало каждого ключа. Помогает узнать ключ в конфигурации и сканерам утечек
+	keyPrefix = "pck_"
+	// displayPrefixLen длина начала ключа, которое хранится открыто для списка ключей
+	displayPrefixLen = 12
+
+	rateWindow = time.Minute
+)
+
+var (
+	ErrInvalidAPIKey     = apperr.Unauthorized("invalid_api_key", "api key is invalid, expired or revoked")
+	ErrAPIKeyRateLimited = apperr.RateLimited("api_key_rate_limited", "api key request limit exceeded, try again later")
+	ErrUnknownScope      = apperr.InvalidField("scopes", "oneof", "unknown api key scope")
+	ErrExpiresInPast     = apperr.InvalidField("expires_at", "future", "expires_at must be in the future")
+)
+
+// Auditor журнал аудита
+type Auditor interface {
+	Record(ctx context.Context, entry models.AuditEntry)
+}
+
+type APIKeyService struct {
+	log              *slog.Logger
+	keys             repository.APIKeyRepository
+	rates            repository.APIKeyRateRepository
+	users            repository.UserRepository
+	auditor          Auditor
+	defaultRateLimit atomic.Int64
+}
+
+// NewAPIKeyService создает сервис ключей API. defaultRateLimit лимит запросов в минуту
+// для ключей, созданных без своего лимита
+func NewAPIKeyService(log *slog.Logger, keys repository.APIKeyRepository, rates repository.APIKeyRateRepository, users repository.UserRepository, auditor Auditor, defaultRateLimit int) *APIKeyService {
+	s := &APIKeyService{
+		log:     log,
+		keys:    keys,
+		rates:   rates,
+		users:   users,
+		auditor: auditor,
+	}
+	s.SetDefaultRateLimit(defaultRateLimit)
+
+	return s
+}
+
+// SetDefaultRateLimit меняет лимит для новых ключей без перезапуска сервера.
+// Уже выданные ключи сохраняют свой лимит
+func (s *APIKeyService) SetDefaultRateLimit(limit int) {
+	s.defaultRateLimit.Store(int64(limit))
+}
+
+// CreateKey выпускает ключ пользователю userID. Ключ возвращается только здесь,
+// в базе остается его SHA-256
+func (s *APIKeyService) CreateKey(ctx context.Context, userID uuid.UUID, req dto.CreateAPIKeyRequest) (*dto.APIKeyCreatedResponse, error) {
+	const op = "apikey_service.CreateKey"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("user_id", userID.String()),
+	)
+
+	scopes := make([]string, 0, len(req.Scopes))
+	for _, scope := range req.Scopes {
+		if !models.ValidAPIKeyScope(scope) {
+			return nil, ErrUnknownScope.WithDetail("unknown scope %q, allowed: %s", scope, strings.Join(models.APIKeyScopes, ", "))
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	now := time.Now().UTC()
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(now) {
+		return nil, ErrExpiresInPast
+	}
+
+	rateLimit := req.RateLimit
+	if rateLimit <= 0 {
+		rateLimit = int(s.defaultRateLimit.Load())
+	}
+
+	raw, err := newKey()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	key := models.APIKey{
+		UserID:    userID,
+		Name:      strings.TrimSpace(req.Name),
+		Prefix:    raw[:displayPrefixLen],
+		Scopes:    scopes,
+		RateLimit: rateLimit,
+		CreatedAt: now,
+		ExpiresAt: req.ExpiresAt,
+	}
+
+	key.ID, err = s.keys.CreateAPIKey(ctx, key, hashKey(raw))
+	if err != nil {
+		log.Error("failed to save api key", sl.Err(err))
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("api key created", slog.String("key_id", key.ID.String()))
+	s.record(ctx, models.AuditAPIKeyCreate, key)
+
+	return &dto.APIKeyCreatedResponse{
+		APIKeyResponse: *mapToAPIKeyResponse(key),
+		Key:            raw,
+	}, nil
+}
+
+// ListKeys возвращает ключи пользователя ownerID или всех пользователей, если он nil
+func (s *APIKeyService) ListKeys(ctx context.Context, ownerID *uuid.UUID) ([]dto.APIKeyResponse, error) {
+	const op = "apikey_service.ListKeys"
+
+	keys, err := s.keys.ListAPIKeys(ctx, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	resp := make([]dto.APIKeyResponse, 0, len(keys))
+	for _, key := range keys {
+		resp = append(resp, *mapToAPIKeyResponse(key))
+	}
+
+	return resp, nil
+}
+
+// RevokeKey отзывает ключ. Если ownerID задан, отозвать можно только его ключ
+func (s *APIKeyService) RevokeKey(ctx context.Context, keyID uuid.UUID, ownerID *uuid.UUID) error {
+	const op = "apikey_service.RevokeKey"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("key_id", keyID.String()),
+	)
+
+	if err := s.keys.RevokeAPIKey(ctx, keyID, ownerID); err != nil {
+		log.Warn("failed to revoke api key", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("api key revoked")
+	s.record(ctx, models.AuditAPIKeyRevoke, models.APIKey{ID: keyID})
+
+	return nil
+}
+
+// Authenticate проверяет ключ из запроса и учитывает его в лимите запросов.
+// Запрос выполняется от имени владельца с его текущими ролями, поэтому ключ
+// заблокированного пользователя не принимается
+func (s *APIKeyService) Authenticate(ctx context.Context, rawKey string) (principal.Principal, error) {
+	const op = "apikey_service.Authenticate"
+
+	if !strings.HasPrefix(rawKey, keyPrefix) {
+		return principal.Principal{}, ErrInvalidAPIKey
+	}
+
+	key, err := s.keys.APIKeyByHash(ctx, hashKey(rawKey))
+	if err != nil {
+		if errors.Is(err, storage.ErrAPIKeyNotFound) {
+			return principal.Principal{}, ErrInvalidAPIKey
+		}
+		return principal.Principal{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if !key.Active(time.Now()) {
+		return principal.Principal{}, ErrInvalidAPIKey
+	}
+
+	count, err := s.rates.CountAPIKeyRequest(ctx, key.ID, rateWindow)
+	if err != nil {
+		return principal.Principal{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if count > int64(key.RateLimit) {
+		return principal.Principal{}, ErrAPIKeyRateLimited.WithDetail("limit is %d requests per minute", key.RateLimit)
+	}
+
+	user, err := s.users.GetUserById(ctx, key.UserID)
+	if err != nil {
+		if errors.Is(err, storage.ErrUserNotFound) {
+			return principal.Principal{}, ErrInvalidAPIKey
+		}
+		return principal.Principal{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if user.IsSuspended() {
+		return principal.Principal{}, ErrInvalidAPIKey
+	}
+
+	if err := s.keys.TouchAPIKey(ctx, key.ID); err != nil {
+		s.log.Warn("failed to record api key usage", slog.String("op", op), slog.String("key_id", key.ID.String()), sl.Err(err))
+	}
+
+	return principal.Principal{
+		UserID:   user.ID,
+		Roles:    user.Roles(),
+		APIKeyID: key.ID,
+		Scopes:   key.Scopes,
+	}, nil
+}
+
+func (s *APIKeyService) record(ctx context.Context, action string, key models.APIKey) {
+	entry := models.AuditEntry{
+		Action:     action,
+		TargetType: models.AuditTargetAPIKey,
+		TargetID:   key.ID.String(),
+	}
+	if action == models.AuditAPIKeyCreate {
+		entry.After = mapToAPIKeyResponse(key)
+	}
+
+	s.auditor.Record(ctx, entry)
+}
+
+func mapToAPIKeyResponse(key models.APIKey) *dto.APIKeyResponse {
+	return &dto.APIKeyResponse{
+		ID:         key.ID,
+		UserID:     key.UserID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		RateLimit:  key.RateLimit,
+		CreatedAt:  key.CreatedAt,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		RevokedAt:  key.RevokedAt,
+	}
+}
+
+// newKey возвращает новый ключ: префикс и 32 случайных байта
+func newKey() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	return keyPrefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashKey SHA-256 ключа. У ключа 256 бит энтропии, поэтому медленный хеш не нужен
+func hashKey(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
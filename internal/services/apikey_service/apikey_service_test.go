@@ -0,0 +1,313 @@
+package services
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"premium_caste/internal/domain/apperr"
+	"premium_caste/internal/domain/models"
+	"premium_caste/internal/repository"
+	"premium_caste/internal/storage"
+	"premium_caste/internal/transport/http/dto"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeUsers struct {
+	repository.UserRepository
+	users map[uuid.UUID]models.User
+}
+
+func (f *fakeUsers) GetUserById(ctx context.Context, userID uuid.UUID) (models.User, error) {
+	user, ok := f.users[userID]
+	if !ok {
+		return models.User{}, storage.ErrUserNotFound
+	}
+	return user, nil
+}
+
+// memoryKeys хранит ключи в памяти так же, как APIKeyRepo в базе
+type memoryKeys struct {
+	keys   map[uuid.UUID]models.APIKey
+	hashes map[string]uuid.UUID
+}
+
+func newMemoryKeys() *memoryKeys {
+	return &memoryKeys{keys: make(map[uuid.UUID]models.APIKey), hashes: make(map[string]uuid.UUID)}
+}
+
+func (m *memoryKeys) CreateAPIKey(ctx context.Context, key models.APIKey, keyHash string) (uuid.UUID, error) {
+	key.ID = uuid.New()
+	m.keys[key.ID] = key
+	m.hashes[keyHash] = key.ID
+	return key.ID, nil
+}
+
+func (m *memoryKeys) APIKeyByHash(ctx context.Context, keyHash string) (models.APIKey, error) {
+	id, ok := m.hashes[keyHash]
+	if !ok {
+		return models.APIKey{}, storage.ErrAPIKeyNotFound
+	}
+	return m.keys[id], nil
+}
+
+func (m *memoryKeys) ListAPIKeys(ctx context.Context, userID *uuid.UUID) ([]models.APIKey, error) {
+	keys := []models.APIKey{}
+	for _, key := range m.keys {
+		if userID == nil || key.UserID == *userID {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+func (m *memoryKeys) RevokeAPIKey(ctx context.Context, keyID uuid.UUID, userID *uuid.UUID) error {
+	key, ok := m.keys[keyID]
+	if !ok || key.RevokedAt != nil || (userID != nil && key.UserID != *userID) {
+		return storage.ErrAPIKeyNotFound
+	}
+	now := time.Now()
+	key.RevokedAt = &now
+	m.keys[keyID] = key
+	return nil
+}
+
+func (m *memoryKeys) TouchAPIKey(ctx context.Context, keyID uuid.UUID) error {
+	key := m.keys[keyID]
+	now := time.Now()
+	key.LastUsedAt = &now
+	m.keys[keyID] = key
+	return nil
+}
+
+// memoryRates считает запросы по ключам без учета окна
+type memoryRates map[uuid.UUID]int64
+
+func (m memoryRates) CountAPIKeyRequest(ctx context.Context, keyID uuid.UUID, window time.Duration) (int64, error) {
+	m[keyID]++
+	return m[keyID], nil
+}
+
+// recordingAuditor запоминает события, переданные в журнал аудита
+type recordingAuditor struct {
+	entries []models.AuditEntry
+}
+
+func (a *recordingAuditor) Record(ctx context.Context, entry models.AuditEntry) {
+	a.entries = append(a.entries, entry)
+}
+
+func (a *recordingAuditor) actions() []string {
+	var actions []string
+	for _, entry := range a.entries {
+		actions = append(actions, entry.Action)
+	}
+	return actions
+}
+
+const testDefaultRateLimit = 60
+
+type apiKeyFixture struct {
+	service *APIKeyService
+	keys    *memoryKeys
+	rates   memoryRates
+	users   *fakeUsers
+	auditor *recordingAuditor
+	owner   models.User
+}
+
+func newAPIKeyFixture() *apiKeyFixture {
+	owner := models.User{ID: uuid.New(), Email: "import@example.com", Role: models.RoleEditor}
+	f := &apiKeyFixture{
+		keys:    newMemoryKeys(),
+		rates:   memoryRates{},
+		users:   &fakeUsers{users: map[uuid.UUID]models.User{owner.ID: owner}},
+		auditor: &recordingAuditor{},
+		owner:   owner,
+	}
+	f.service = NewAPIKeyService(slog.New(slog.NewTextHandler(io.Discard, nil)), f.keys, f.rates, f.users, f.auditor, testDefaultRateLimit)
+
+	return f
+}
+
+func TestAPIKeyService_CreateKey(t *testing.T) {
+	ctx := context.Background()
+	past := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name       string
+		req        dto.CreateAPIKeyRequest
+		wantScopes []string
+		wantLimit  int
+		wantCode   string
+	}{
+		{
+			name:       "defaults",
+			req:        dto.CreateAPIKeyRequest{Name: " import ", Scopes: []string{models.ScopeMediaWrite, models.ScopePostsRead, models.ScopeMediaWrite}},
+			wantScopes: []string{models.ScopeMediaWrite, models.ScopePostsRead},
+			wantLimit:  testDefaultRateLimit,
+		},
+		{
+			name:       "custom rate limit",
+			req:        dto.CreateAPIKeyRequest{Name: "partner", Scopes: []string{models.ScopeGalleriesRead}, RateLimit: 600},
+			wantScopes: []string{models.ScopeGalleriesRead},
+			wantLimit:  600,
+		},
+		{
+			name:     "unknown scope",
+			req:      dto.CreateAPIKeyRequest{Name: "import", Scopes: []string{"users:write"}},
+			wantCode: "oneof",
+		},
+		{
+			name:     "expired on creation",
+			req:      dto.CreateAPIKeyRequest{Name: "import", Scopes: []string{models.ScopePostsRead}, ExpiresAt: &past},
+			wantCode: "future",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAPIKeyFixture()
+
+			created, err := f.service.CreateKey(ctx, f.owner.ID, tt.req)
+			if tt.wantCode != "" {
+				var appErr *apperr.Error
+				require.ErrorAs(t, err, &appErr)
+				assert.Equal(t, apperr.KindInvalid, appErr.Kind)
+				require.Len(t, appErr.Fields, 1)
+				assert.Equal(t, tt.wantCode, appErr.Fields[0].Code)
+				assert.Empty(t, f.keys.keys)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.True(t, strings.HasPrefix(created.Key, keyPrefix))
+			assert.Equal(t, created.Key[:displayPrefixLen], created.Prefix)
+			assert.Equal(t, strings.TrimSpace(tt.req.Name), created.Name)
+			assert.Equal(t, tt.wantScopes, created.Scopes)
+			assert.Equal(t, tt.wantLimit, created.RateLimit)
+
+			_, stored := f.keys.hashes[hashKey(created.Key)]
+			assert.True(t, stored, "key must be stored by hash")
+			_, plain := f.keys.hashes[created.Key]
+			assert.False(t, plain, "key must not be stored as is")
+
+			assert.Equal(t, []string{models.AuditAPIKeyCreate}, f.auditor.actions())
+			assert.IsType(t, &dto.APIKeyResponse{}, f.auditor.entries[0].After, "audit must not receive the key itself")
+		})
+	}
+}
+
+func TestAPIKeyService_Authenticate(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		prepare func(f *apiKeyFixture, key *dto.APIKeyCreatedResponse) string
+		wantErr error
+	}{
+		{
+			name:    "valid key",
+			prepare: func(f *apiKeyFixture, key *dto.APIKeyCreatedResponse) string { return key.Key },
+		},
+		{
+			name: "no prefix",
+			prepare: func(f *apiKeyFixture, key *dto.APIKeyCreatedResponse) string {
+				return strings.TrimPrefix(key.Key, keyPrefix)
+			},
+			wantErr: ErrInvalidAPIKey,
+		},
+		{
+			name:    "unknown key",
+			prepare: func(f *apiKeyFixture, key *dto.APIKeyCreatedResponse) string { return keyPrefix + "unknown" },
+			wantErr: ErrInvalidAPIKey,
+		},
+		{
+			name: "revoked",
+			prepare: func(f *apiKeyFixture, key *dto.APIKeyCreatedResponse) string {
+				require.NoError(t, f.service.RevokeKey(ctx, key.ID, nil))
+				return key.Key
+			},
+			wantErr: ErrInvalidAPIKey,
+		},
+		{
+			name: "expired",
+			prepare: func(f *apiKeyFixture, key *dto.APIKeyCreatedResponse) string {
+				stored := f.keys.keys[key.ID]
+				past := time.Now().Add(-time.Minute)
+				stored.ExpiresAt = &past
+				f.keys.keys[key.ID] = stored
+				return key.Key
+			},
+			wantErr: ErrInvalidAPIKey,
+		},
+		{
+			name: "owner suspended",
+			prepare: func(f *apiKeyFixture, key *dto.APIKeyCreatedResponse) string {
+				owner := f.users.users[f.owner.ID]
+				now := time.Now()
+				owner.SuspendedAt = &now
+				f.users.users[f.owner.ID] = owner
+				return key.Key
+			},
+			wantErr: ErrInvalidAPIKey,
+		},
+		{
+			name: "rate limit exceeded",
+			prepare: func(f *apiKeyFixture, key *dto.APIKeyCreatedResponse) string {
+				f.rates[key.ID] = int64(key.RateLimit)
+				return key.Key
+			},
+			wantErr: ErrAPIKeyRateLimited,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAPIKeyFixture()
+			key, err := f.service.CreateKey(ctx, f.owner.ID, dto.CreateAPIKeyRequest{Name: "import", Scopes: []string{models.ScopeMediaWrite}})
+			require.NoError(t, err)
+
+			p, err := f.service.Authenticate(ctx, tt.prepare(f, key))
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+
+			assert.Equal(t, f.owner.ID, p.UserID)
+			assert.Equal(t, key.ID, p.APIKeyID)
+			assert.Equal(t, []string{models.RoleEditor}, p.Roles)
+			assert.True(t, p.Allows(models.ScopeMediaWrite))
+			assert.False(t, p.Allows(models.ScopePostsWrite))
+			assert.NotNil(t, f.keys.keys[key.ID].LastUsedAt)
+		})
+	}
+}
+
+func TestAPIKeyService_RevokeKey(t *testing.T) {
+	ctx := context.Background()
+	f := newAPIKeyFixture()
+
+	key, err := f.service.CreateKey(ctx, f.owner.ID, dto.CreateAPIKeyRequest{Name: "import", Scopes: []string{models.ScopePostsWrite}})
+	require.NoError(t, err)
+
+	stranger := uuid.New()
+	assert.ErrorIs(t, f.service.RevokeKey(ctx, key.ID, &stranger), storage.ErrAPIKeyNotFound)
+
+	require.NoError(t, f.service.RevokeKey(ctx, key.ID, &f.owner.ID))
+	assert.ErrorIs(t, f.service.RevokeKey(ctx, key.ID, &f.owner.ID), storage.ErrAPIKeyNotFound)
+
+	keys, err := f.service.ListKeys(ctx, &f.owner.ID)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.NotNil(t, keys[0].RevokedAt)
+
+	assert.Equal(t, []string{models.AuditAPIKeyCreate, models.AuditAPIKeyRevoke}, f.auditor.actions())
+}
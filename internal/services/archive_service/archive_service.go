@@ -0,0 +1,291 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path"
+	"premium_caste/internal/storage"
+	filestorage "premium_caste/internal/storage/filestorage"
+	"premium_caste/internal/transport/http/dto"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Размеры изображений в архиве. Уменьшенные варианты лежат рядом с оригиналом
+// в подкаталоге с именем размера: uploads/<user>/medium/photo.jpg
+const (
+	SizeOriginal = "original"
+	SizeLarge    = "large"
+	SizeMedium   = "medium"
+	SizeSmall    = "small"
+)
+
+const (
+	// defaultMaxBuildsPerUser сколько архивов один пользователь может скачивать одновременно
+	defaultMaxBuildsPerUser = 2
+	// manifestName имя файла с описанием галереи внутри архива
+	manifestName = "manifest.json"
+
+	statusPublished = "published"
+)
+
+var renditions = map[string]bool{
+	SizeOriginal: true,
+	SizeLarge:    true,
+	SizeMedium:   true,
+	SizeSmall:    true,
+}
+
+// GalleryGetter источник галерей для архивов
+type GalleryGetter interface {
+	GetGalleryByID(ctx context.Context, id uuid.UUID) (*dto.GalleryResponse, error)
+}
+
+// ArchiveService собирает ZIP-архивы галерей на лету, читая файлы из хранилища
+type ArchiveService struct {
+	log       *slog.Logger
+	galleries GalleryGetter
+	files     filestorage.FileStorage
+	maxBuilds int
+
+	mu     sync.Mutex
+	active map[string]int
+}
+
+func NewArchiveService(log *slog.Logger, galleries GalleryGetter, files filestorage.FileStorage, maxBuildsPerUser int) *ArchiveService {
+	if maxBuildsPerUser < 1 {
+		maxBuildsPerUser = defaultMaxBuildsPerUser
+	}
+
+	return &ArchiveService{
+		log:       log,
+		galleries: galleries,
+		files:     files,
+		maxBuilds: maxBuildsPerUser,
+		active:    make(map[string]int),
+	}
+}
+
+// galleryArchive архив галереи, который пишется в ответ по мере чтения файлов
+type galleryArchive struct {
+	log     *slog.Logger
+	files   filestorage.FileStorage
+	gallery *dto.GalleryResponse
+	size    string
+}
+
+type archiveManifest struct {
+	ID          uuid.UUID             `json:"id"`
+	Title       string                `json:"title"`
+	Slug        string                `json:"slug"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	PublishedAt *time.Time            `json:"published_at,omitempty"`
+	Size        string                `json:"size"`
+	Items       []archiveManifestItem `json:"items"`
+}
+
+type archiveManifestItem struct {
+	Position int       `json:"position"`
+	MediaID  uuid.UUID `json:"media_id"`
+	File     string    `json:"file,omitempty"`
+	Size     string    `json:"size,omitempty"`
+	Caption  string    `json:"caption,omitempty"`
+	AltText  string    `json:"alt_text,omitempty"`
+	Missing  bool      `json:"missing,omitempty"` // Файл не найден в хранилище и в архив не попал
+}
+
+// StreamGalleryArchive пишет ZIP-архив опубликованной галереи с файлом manifest.json.
+// start вызывается после всех проверок перед первой записью: получает имя файла
+// и возвращает, куда писать архив. Ошибки до вызова start можно отдать обычным ответом.
+// Пока архив пишется, он занимает один из слотов пользователя userKey
+func (s *ArchiveService) StreamGalleryArchive(ctx context.Context, galleryID uuid.UUID, userKey, size string, start func(filename string) io.Writer) error {
+	const op = "archive_service.StreamGalleryArchive"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("gallery_id", galleryID.String()),
+		slog.String("user", userKey),
+		slog.String("size", size),
+	)
+
+	if size == "" {
+		size = SizeOriginal
+	}
+	if !renditions[size] {
+		return storage.ErrInvalidRendition.WithDetail("%s", size)
+	}
+
+	gallery, err := s.galleries.GetGalleryByID(ctx, galleryID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Черновики и архивные галереи не отдаются так же, как и в публичных списках
+	if gallery.Status != statusPublished {
+		return fmt.Errorf("%s: %w", op, storage.ErrGalleryNotFound)
+	}
+
+	release, ok := s.acquire(userKey)
+	if !ok {
+		log.Warn("archive limit exceeded")
+		return fmt.Errorf("%s: %w", op, storage.ErrArchiveLimitExceeded)
+	}
+	defer release()
+
+	filename := gallery.Slug
+	if filename == "" {
+		filename = gallery.ID.String()
+	}
+
+	archive := &galleryArchive{
+		log:     log,
+		files:   s.files,
+		gallery: gallery,
+		size:    size,
+	}
+
+	log.Info("streaming gallery archive")
+
+	if err := archive.writeTo(ctx, start(filename+".zip")); err != nil {
+		log.Error("failed to stream gallery archive", slog.Any("err", err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// writeTo пишет архив в w, копируя файлы из хранилища по одному.
+// Архив целиком в памяти не собирается
+func (a *galleryArchive) writeTo(ctx context.Context, w io.Writer) error {
+	zw := zip.NewWriter(w)
+
+	manifest := archiveManifest{
+		ID:          a.gallery.ID,
+		Title:       a.gallery.Title,
+		Slug:        a.gallery.Slug,
+		Description: a.gallery.Description,
+		Tags:        a.gallery.Tags,
+		PublishedAt: a.gallery.PublishedAt,
+		Size:        a.size,
+		Items:       make([]archiveManifestItem, 0, len(a.gallery.Items)),
+	}
+
+	for i, item := range a.gallery.Items {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		entry := archiveManifestItem{
+			Position: i + 1,
+			MediaID:  item.MediaID,
+			Caption:  item.Caption,
+			AltText:  item.AltText,
+		}
+
+		file, size, err := openRendition(ctx, a.files, item.StoragePath, a.size)
+		if errors.Is(err, os.ErrNotExist) {
+			a.log.Warn("gallery file not found", slog.String("path", item.StoragePath))
+			entry.Missing = true
+			manifest.Items = append(manifest.Items, entry)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		entry.File = fmt.Sprintf("%03d_%s", i+1, path.Base(item.StoragePath))
+		entry.Size = size
+
+		// Изображения уже сжаты, поэтому кладутся без компрессии
+		err = writeEntry(zw, entry.File, zip.Store, file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+
+		manifest.Items = append(manifest.Items, entry)
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal manifest: %w", err)
+	}
+
+	if err := writeEntry(zw, manifestName, zip.Deflate, bytes.NewReader(data)); err != nil {
+		return err
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to finish archive: %w", err)
+	}
+
+	return nil
+}
+
+// openRendition открывает нужный размер изображения. Если уменьшенного варианта нет,
+// отдается оригинал
+func openRendition(ctx context.Context, files filestorage.FileStorage, storagePath, size string) (io.ReadCloser, string, error) {
+	if size != SizeOriginal {
+		file, err := files.Open(ctx, path.Join(path.Dir(storagePath), size, path.Base(storagePath)))
+		if err == nil {
+			return file, size, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, "", err
+		}
+	}
+
+	file, err := files.Open(ctx, storagePath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return file, SizeOriginal, nil
+}
+
+// acquire занимает слот пользователя, если лимит одновременных архивов не исчерпан
+func (s *ArchiveService) acquire(key string) (func(), bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.active[key] >= s.maxBuilds {
+		return nil, false
+	}
+	s.active[key]++
+
+	return func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		s.active[key]--
+		if s.active[key] <= 0 {
+			delete(s.active, key)
+		}
+	}, true
+}
+
+func writeEntry(zw *zip.Writer, name string, method uint16, src io.Reader) error {
+	w, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   method,
+		Modified: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", name, err)
+	}
+
+	if _, err := io.Copy(w, src); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"os"
+	"premium_caste/internal/storage"
+	"premium_caste/internal/transport/http/dto"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockGalleryGetter struct {
+	mock.Mock
+}
+
+func (m *MockGalleryGetter) GetGalleryByID(ctx context.Context, id uuid.UUID) (*dto.GalleryResponse, error) {
+	args := m.Called(ctx, id)
+	gallery, _ := args.Get(0).(*dto.GalleryResponse)
+	return gallery, args.Error(1)
+}
+
+// memoryStorage хранилище в памяти, достаточное для чтения файлов архивом
+type memoryStorage struct {
+	files map[string]string
+}
+
+func (s *memoryStorage) Save(ctx context.Context, file *multipart.FileHeader, subPath string) (string, int64, error) {
+	return "", 0, fmt.Errorf("not implemented")
+}
+
+func (s *memoryStorage) SaveMultiple(ctx context.Context, files []*multipart.FileHeader, subPath string) ([]string, []int64, error) {
+	return nil, nil, fmt.Errorf("not implemented")
+}
+
+func (s *memoryStorage) Delete(ctx context.Context, filePath string) error {
+	delete(s.files, filePath)
+	return nil
+}
+
+func (s *memoryStorage) Open(ctx context.Context, filePath string) (io.ReadCloser, error) {
+	content, ok := s.files[filePath]
+	if !ok {
+		return nil, fmt.Errorf("failed to open file: %w", os.ErrNotExist)
+	}
+	return io.NopCloser(bytes.NewBufferString(content)), nil
+}
+
+func (s *memoryStorage) GetFullPath(relativePath string) string { return relativePath }
+func (s *memoryStorage) BaseURL() string                        { return "" }
+func (s *memoryStorage) GetBaseDir() string                     { return "" }
+
+func testGallery(status string) *dto.GalleryResponse {
+	return &dto.GalleryResponse{
+		ID:     uuid.MustParse("1221067c-cc35-4dae-b5f5-feee4bbb3e22"),
+		Title:  "Summer in Paris",
+		Slug:   "summer-in-paris",
+		Status: status,
+		Items: []dto.GalleryItemResponse{
+			{MediaID: uuid.New(), Position: 0, Caption: "Eiffel tower", StoragePath: "uploads/u1/eiffel.jpg"},
+			{MediaID: uuid.New(), Position: 1, Caption: "Louvre", AltText: "pyramid", StoragePath: "uploads/u1/louvre.jpg"},
+			{MediaID: uuid.New(), Position: 2, StoragePath: "uploads/u1/lost.jpg"},
+		},
+	}
+}
+
+func readArchive(t *testing.T, data []byte) (map[string]string, archiveManifest) {
+	t.Helper()
+
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	files := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		files[f.Name] = string(content)
+	}
+
+	var manifest archiveManifest
+	require.NoError(t, json.Unmarshal([]byte(files[manifestName]), &manifest))
+
+	return files, manifest
+}
+
+func TestArchiveService_ArchiveContents(t *testing.T) {
+	ctx := context.Background()
+	files := &memoryStorage{files: map[string]string{
+		"uploads/u1/eiffel.jpg":        "eiffel-original",
+		"uploads/u1/medium/eiffel.jpg": "eiffel-medium",
+		"uploads/u1/louvre.jpg":        "louvre-original",
+	}}
+
+	tests := []struct {
+		name      string
+		size      string
+		wantFiles map[string]string
+		wantSizes []string
+	}{
+		{
+			name: "originals by default",
+			wantFiles: map[string]string{
+				"001_eiffel.jpg": "eiffel-original",
+				"002_louvre.jpg": "louvre-original",
+			},
+			wantSizes: []string{SizeOriginal, SizeOriginal, ""},
+		},
+		{
+			name: "rendition with fallback to original",
+			size: SizeMedium,
+			wantFiles: map[string]string{
+				"001_eiffel.jpg": "eiffel-medium",
+				"002_louvre.jpg": "louvre-original",
+			},
+			wantSizes: []string{SizeMedium, SizeOriginal, ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			galleries := new(MockGalleryGetter)
+			gallery := testGallery("published")
+			galleries.On("GetGalleryByID", ctx, gallery.ID).Return(gallery, nil).Once()
+			service := NewArchiveService(slog.Default(), galleries, files, 1)
+
+			var buf bytes.Buffer
+			var filename string
+			err := service.StreamGalleryArchive(ctx, gallery.ID, "user", tt.size, func(name string) io.Writer {
+				filename = name
+				return &buf
+			})
+			require.NoError(t, err)
+			assert.Equal(t, "summer-in-paris.zip", filename)
+
+			got, manifest := readArchive(t, buf.Bytes())
+			for name, content := range tt.wantFiles {
+				assert.Equal(t, content, got[name])
+			}
+			assert.Len(t, got, len(tt.wantFiles)+1)
+
+			assert.Equal(t, "Summer in Paris", manifest.Title)
+			require.Len(t, manifest.Items, 3)
+			for i, item := range manifest.Items {
+				assert.Equal(t, i+1, item.Position)
+				assert.Equal(t, tt.wantSizes[i], item.Size)
+			}
+			assert.Equal(t, "Louvre", manifest.Items[1].Caption)
+			assert.Equal(t, "pyramid", manifest.Items[1].AltText)
+			assert.True(t, manifest.Items[2].Missing)
+			galleries.AssertExpectations(t)
+		})
+	}
+}
+
+func TestArchiveService_StreamGalleryArchive(t *testing.T) {
+	ctx := context.Background()
+	files := &memoryStorage{files: map[string]string{}}
+	notStarted := func(t *testing.T) func(string) io.Writer {
+		return func(string) io.Writer {
+			t.Fatal("archive must not be started")
+			return nil
+		}
+	}
+
+	t.Run("unknown size", func(t *testing.T) {
+		service := NewArchiveService(slog.Default(), new(MockGalleryGetter), files, 1)
+
+		err := service.StreamGalleryArchive(ctx, uuid.New(), "user", "huge", notStarted(t))
+
+		assert.ErrorIs(t, err, storage.ErrInvalidRendition)
+	})
+
+	t.Run("draft gallery is hidden", func(t *testing.T) {
+		galleries := new(MockGalleryGetter)
+		gallery := testGallery("draft")
+		galleries.On("GetGalleryByID", ctx, gallery.ID).Return(gallery, nil).Once()
+		service := NewArchiveService(slog.Default(), galleries, files, 1)
+
+		err := service.StreamGalleryArchive(ctx, gallery.ID, "user", "", notStarted(t))
+
+		assert.ErrorIs(t, err, storage.ErrGalleryNotFound)
+	})
+
+	t.Run("concurrent builds are capped per user", func(t *testing.T) {
+		galleries := new(MockGalleryGetter)
+		gallery := testGallery("published")
+		galleries.On("GetGalleryByID", ctx, gallery.ID).Return(gallery, nil)
+		service := NewArchiveService(slog.Default(), galleries, files, 1)
+
+		err := service.StreamGalleryArchive(ctx, gallery.ID, "user", "", func(string) io.Writer {
+			// Пока первый архив пишется, второй для того же пользователя не начинается
+			err := service.StreamGalleryArchive(ctx, gallery.ID, "user", "", notStarted(t))
+			assert.ErrorIs(t, err, storage.ErrArchiveLimitExceeded)
+
+			err = service.StreamGalleryArchive(ctx, gallery.ID, "another-user", "", func(string) io.Writer { return io.Discard })
+			assert.NoError(t, err)
+
+			return io.Discard
+		})
+		require.NoError(t, err)
+
+		// Слот освобождается после завершения архива
+		err = service.StreamGalleryArchive(ctx, gallery.ID, "user", "", func(string) io.Writer { return io.Discard })
+		assert.NoError(t, err)
+		assert.Empty(t, service.active)
+	})
+}
@@ -0,0 +1,191 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"premium_caste/internal/domain/models"
+	"premium_caste/internal/lib/actor"
+	"premium_caste/internal/lib/logger/sl"
+	"premium_caste/internal/lib/pagination"
+	"premium_caste/internal/lib/principal"
+	"premium_caste/internal/repository"
+	"premium_caste/internal/transport/http/dto"
+
+	"github.com/google/uuid"
+)
+
+// AuditService ведет журнал действий администраторов и событий безопасности
+type AuditService struct {
+	log       *slog.Logger
+	repo      repository.AuditRepository
+	retention time.Duration
+}
+
+// NewAuditService создает журнал. retention - срок хранения записей, 0 - бессрочно
+func NewAuditService(log *slog.Logger, repo repository.AuditRepository, retention time.Duration) *AuditService {
+	return &AuditService{
+		log:       log,
+		repo:      repo,
+		retention: retention,
+	}
+}
+
+// Record записывает событие от имени инициатора из ctx. Ошибка записи не должна
+// отменять уже выполненное действие, поэтому она только попадает в лог.
+// Вызывается после завершения транзакции действия: ошибка внутри транзакции прервала бы ее
+func (s *AuditService) Record(ctx context.Context, entry models.AuditEntry) {
+	const op = "audit_service.Record"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("action", entry.Action),
+		slog.String("target_id", entry.TargetID),
+	)
+
+	a := actor.FromContext(ctx)
+	event := models.AuditEvent{
+		Action:     entry.Action,
+		IP:         a.IP,
+		UserAgent:  a.UserAgent,
+		TargetType: entry.TargetType,
+		TargetID:   entry.TargetID,
+	}
+	if a.UserID != uuid.Nil {
+		event.ActorID = &a.UserID
+	}
+	// Действие по ключу API отличается от действия в сеансе пользователя
+	if p, ok := principal.FromContext(ctx); ok && p.ViaAPIKey() {
+		event.APIKeyID = &p.APIKeyID
+	}
+
+	var err error
+	if event.Before, err = marshalState(entry.Before); err != nil {
+		log.Error("failed to marshal audit state", sl.Err(err))
+	}
+	if event.After, err = marshalState(entry.After); err != nil {
+		log.Error("failed to marshal audit state", sl.Err(err))
+	}
+
+	// Запрос клиента мог уже завершиться, а запись в журнал должна состояться
+	if err := s.repo.SaveEvent(context.WithoutCancel(ctx), event); err != nil {
+		log.Error("failed to save audit event", sl.Err(err))
+	}
+}
+
+// ListEvents возвращает страницу журнала
+func (s *AuditService) ListEvents(ctx context.Context, filter dto.AuditEventFilter, page, perPage int) (*dto.AuditEventListResponse, error) {
+	const op = "audit_service.ListEvents"
+
+	page, perPage = pagination.Normalize(page, perPage)
+
+	repoFilter := models.AuditFilter{
+		Action:     filter.Action,
+		ActorID:    filter.ActorID,
+		APIKeyID:   filter.APIKeyID,
+		TargetType: filter.TargetType,
+		TargetID:   filter.TargetID,
+		From:       filter.From,
+		To:         filter.To,
+	}
+	if filter.Cursor != "" {
+		cursor, err := pagination.DecodeCursor(filter.Cursor, models.AuditSortOccurred)
+		if err != nil {
+			return nil, err
+		}
+		repoFilter.After = cursor
+	}
+
+	events, total, err := s.repo.ListEvents(ctx, repoFilter, page, perPage)
+	if err != nil {
+		s.log.Error("failed to list audit events", slog.String("op", op), sl.Err(err))
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	response := &dto.AuditEventListResponse{
+		Events: make([]dto.AuditEventResponse, 0, len(events)),
+		Meta:   pagination.NewMeta(total, page, perPage),
+	}
+	for _, event := range events {
+		response.Events = append(response.Events, dto.AuditEventResponse{
+			ID:         event.ID,
+			OccurredAt: event.OccurredAt,
+			Action:     event.Action,
+			ActorID:    event.ActorID,
+			APIKeyID:   event.APIKeyID,
+			IP:         event.IP,
+			UserAgent:  event.UserAgent,
+			TargetType: event.TargetType,
+			TargetID:   event.TargetID,
+			Before:     event.Before,
+			After:      event.After,
+		})
+	}
+
+	response.SetNextCursor(len(events), repoFilter.After != nil, func() pagination.Cursor {
+		last := events[len(events)-1]
+		return pagination.Cursor{Sort: models.AuditSortOccurred, ID: last.ID, Time: last.OccurredAt}
+	})
+
+	return response, nil
+}
+
+// Prune удаляет записи старше срока хранения и возвращает их количество
+func (s *AuditService) Prune(ctx context.Context) (int64, error) {
+	const op = "audit_service.Prune"
+
+	if s.retention <= 0 {
+		return 0, nil
+	}
+
+	deleted, err := s.repo.DeleteEventsBefore(ctx, time.Now().Add(-s.retention))
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return deleted, nil
+}
+
+// RunPruner периодически удаляет устаревшие записи, пока ctx не отменен
+func (s *AuditService) RunPruner(ctx context.Context, interval time.Duration) {
+	const op = "audit_service.RunPruner"
+
+	log := s.log.With(slog.String("op", op))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := s.Prune(ctx)
+		if err != nil {
+			log.Error("failed to prune audit events", sl.Err(err))
+		} else if deleted > 0 {
+			log.Info("audit events pruned", slog.Int64("deleted", deleted))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// marshalState сериализует состояние объекта. nil, в том числе типизированный,
+// остается пустым, чтобы в базе был NULL
+func marshalState(state any) (json.RawMessage, error) {
+	if state == nil {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil || string(data) == "null" {
+		return nil, err
+	}
+
+	return data, nil
+}
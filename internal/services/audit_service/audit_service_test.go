@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"premium_caste/internal/domain/models"
+	"premium_caste/internal/lib/actor"
+	"premium_caste/internal/lib/pagination"
+	"premium_caste/internal/lib/principal"
+	"premium_caste/internal/storage"
+	"premium_caste/internal/transport/http/dto"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockAuditRepository struct {
+	mock.Mock
+}
+
+func (m *MockAuditRepository) SaveEvent(ctx context.Context, event models.AuditEvent) error {
+	args := m.Called(ctx, event)
+	return args.Error(0)
+}
+
+func (m *MockAuditRepository) ListEvents(ctx context.Context, filter models.AuditFilter, page, perPage int) ([]models.AuditEvent, int, error) {
+	args := m.Called(ctx, filter, page, perPage)
+	events, _ := args.Get(0).([]models.AuditEvent)
+	return events, args.Int(1), args.Error(2)
+}
+
+func (m *MockAuditRepository) DeleteEventsBefore(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func TestAuditService_Record(t *testing.T) {
+	userID := uuid.New()
+	apiKeyID := uuid.New()
+	targetID := uuid.New().String()
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		entry     models.AuditEntry
+		saveErr   error
+		wantEvent models.AuditEvent
+	}{
+		{
+			name: "actor and states from request",
+			ctx:  actor.WithUser(actor.WithClient(context.Background(), "10.0.0.1", "curl/8.0"), userID),
+			entry: models.AuditEntry{
+				Action:     models.AuditPostUpdate,
+				TargetType: models.AuditTargetPost,
+				TargetID:   targetID,
+				Before:     map[string]string{"title": "old"},
+				After:      map[string]string{"title": "new"},
+			},
+			wantEvent: models.AuditEvent{
+				Action:     models.AuditPostUpdate,
+				ActorID:    &userID,
+				IP:         "10.0.0.1",
+				UserAgent:  "curl/8.0",
+				TargetType: models.AuditTargetPost,
+				TargetID:   targetID,
+				Before:     json.RawMessage(`{"title":"old"}`),
+				After:      json.RawMessage(`{"title":"new"}`),
+			},
+		},
+		{
+			name: "action with api key",
+			ctx: principal.WithPrincipal(actor.WithUser(context.Background(), userID),
+				principal.Principal{UserID: userID, APIKeyID: apiKeyID, Scopes: []string{models.ScopePostsWrite}}),
+			entry: models.AuditEntry{Action: models.AuditPostCreate, TargetType: models.AuditTargetPost, TargetID: targetID},
+			wantEvent: models.AuditEvent{
+				Action:     models.AuditPostCreate,
+				ActorID:    &userID,
+				APIKeyID:   &apiKeyID,
+				TargetType: models.AuditTargetPost,
+				TargetID:   targetID,
+			},
+		},
+		{
+			name: "action in user session",
+			ctx: principal.WithPrincipal(actor.WithUser(context.Background(), userID),
+				principal.Principal{UserID: userID, SessionID: "session-1"}),
+			entry: models.AuditEntry{Action: models.AuditPostCreate},
+			wantEvent: models.AuditEvent{
+				Action:  models.AuditPostCreate,
+				ActorID: &userID,
+			},
+		},
+		{
+			name: "anonymous actor and typed nil state",
+			ctx:  context.Background(),
+			entry: models.AuditEntry{
+				Action:     models.AuditUserLoginFailed,
+				TargetType: models.AuditTargetUser,
+				After:      (*dto.UserResponse)(nil),
+			},
+			wantEvent: models.AuditEvent{
+				Action:     models.AuditUserLoginFailed,
+				TargetType: models.AuditTargetUser,
+			},
+		},
+		{
+			name:    "save error is not returned",
+			ctx:     context.Background(),
+			entry:   models.AuditEntry{Action: models.AuditMediaDelete},
+			saveErr: errors.New("db error"),
+			wantEvent: models.AuditEvent{
+				Action: models.AuditMediaDelete,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockAuditRepository)
+			repo.On("SaveEvent", mock.Anything, tt.wantEvent).Return(tt.saveErr).Once()
+
+			NewAuditService(slog.Default(), repo, 0).Record(tt.ctx, tt.entry)
+
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAuditService_Record_CanceledContext(t *testing.T) {
+	repo := new(MockAuditRepository)
+	repo.On("SaveEvent", mock.MatchedBy(func(ctx context.Context) bool {
+		return ctx.Err() == nil
+	}), mock.Anything).Return(nil).Once()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	NewAuditService(slog.Default(), repo, 0).Record(ctx, models.AuditEntry{Action: models.AuditUserLogin})
+
+	repo.AssertExpectations(t)
+}
+
+func TestAuditService_ListEvents(t *testing.T) {
+	ctx := context.Background()
+	actorID := uuid.New()
+	event := models.AuditEvent{ID: uuid.New(), Action: models.AuditPostPublish, ActorID: &actorID}
+
+	repo := new(MockAuditRepository)
+	repo.On("ListEvents", ctx, models.AuditFilter{Action: models.AuditPostPublish}, 1, pagination.DefaultPerPage).
+		Return([]models.AuditEvent{event}, 1, nil).Once()
+
+	resp, err := NewAuditService(slog.Default(), repo, 0).ListEvents(ctx, dto.AuditEventFilter{Action: models.AuditPostPublish}, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, resp.Events, 1)
+	assert.Equal(t, event.ID, resp.Events[0].ID)
+	assert.Equal(t, &actorID, resp.Events[0].ActorID)
+	assert.Equal(t, 1, resp.Total)
+	assert.Empty(t, resp.NextCursor, "single page has no next cursor")
+
+	repo.AssertExpectations(t)
+}
+
+func TestAuditService_ListEventsByCursor(t *testing.T) {
+	ctx := context.Background()
+	events := []models.AuditEvent{
+		{ID: uuid.New(), Action: models.AuditPostPublish, OccurredAt: time.Now()},
+		{ID: uuid.New(), Action: models.AuditPostPublish, OccurredAt: time.Now().Add(-time.Minute)},
+	}
+	after := pagination.Cursor{Sort: models.AuditSortOccurred, ID: uuid.New(), Time: time.Now()}
+
+	repo := new(MockAuditRepository)
+	repo.On("ListEvents", ctx, mock.MatchedBy(func(f models.AuditFilter) bool {
+		return f.After != nil && f.After.ID == after.ID
+	}), 1, 2).Return(events, 10, nil).Once()
+
+	service := NewAuditService(slog.Default(), repo, 0)
+
+	resp, err := service.ListEvents(ctx, dto.AuditEventFilter{Cursor: after.Encode()}, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, pagination.Cursor{Sort: models.AuditSortOccurred, ID: events[1].ID, Time: events[1].OccurredAt}.Encode(), resp.NextCursor)
+
+	_, err = service.ListEvents(ctx, dto.AuditEventFilter{Cursor: "not-a-cursor"}, 1, 2)
+	assert.ErrorIs(t, err, storage.ErrInvalidCursor)
+
+	repo.AssertExpectations(t)
+}
+
+func TestAuditService_Prune(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("zero retention keeps events", func(t *testing.T) {
+		repo := new(MockAuditRepository)
+
+		deleted, err := NewAuditService(slog.Default(), repo, 0).Prune(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, deleted)
+
+		repo.AssertNotCalled(t, "DeleteEventsBefore", mock.Anything, mock.Anything)
+	})
+
+	t.Run("deletes events older than retention", func(t *testing.T) {
+		retention := 30 * 24 * time.Hour
+		repo := new(MockAuditRepository)
+		repo.On("DeleteEventsBefore", ctx, mock.MatchedBy(func(before time.Time) bool {
+			return time.Since(before.Add(retention)) < time.Minute
+		})).Return(int64(5), nil).Once()
+
+		deleted, err := NewAuditService(slog.Default(), repo, retention).Prune(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), deleted)
+
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("repository error", func(t *testing.T) {
+		repo := new(MockAuditRepository)
+		repo.On("DeleteEventsBefore", ctx, mock.Anything).Return(int64(0), errors.New("db error")).Once()
+
+		_, err := NewAuditService(slog.Default(), repo, time.Hour).Prune(ctx)
+		assert.ErrorContains(t, err, "db error")
+	})
+}
@@ -2,10 +2,16 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"premium_caste/internal/domain/apperr"
 	"premium_caste/internal/domain/models"
+	"premium_caste/internal/lib/markdown"
+	"premium_caste/internal/lib/pagination"
+	"premium_caste/internal/lib/slug"
 	"premium_caste/internal/repository"
+	"premium_caste/internal/storage"
 	"premium_caste/internal/transport/http/dto"
 	"strings"
 	"time"
@@ -13,15 +19,27 @@ import (
 	"github.com/google/uuid"
 )
 
+// excerptLength длина автоматически формируемого краткого описания
+const excerptLength = 200
+
+// Auditor журнал аудита
+type Auditor interface {
+	Record(ctx context.Context, entry models.AuditEntry)
+}
+
 type BlogService struct {
-	log  *slog.Logger
-	repo repository.BlogRepository
+	log     *slog.Logger
+	repo    repository.BlogRepository
+	tx      repository.Transactor
+	auditor Auditor
 }
 
-func NewBlogService(log *slog.Logger, repo repository.BlogRepository) *BlogService {
+func NewBlogService(log *slog.Logger, repo repository.BlogRepository, tx repository.Transactor, auditor Auditor) *BlogService {
 	return &BlogService{
-		log:  log,
-		repo: repo,
+		log:     log,
+		repo:    repo,
+		tx:      tx,
+		auditor: auditor,
 	}
 }
 
@@ -38,11 +56,11 @@ func (s *BlogService) CreatePost(ctx context.Context, req dto.CreateBlogPostRequ
 	// Валидация обязательных полей
 	if req.Title == "" {
 		log.Error("post title is required")
-		return nil, fmt.Errorf("post title is required")
+		return nil, apperr.InvalidField("title", "required", "post title is required")
 	}
 	if req.AuthorID == uuid.Nil {
 		log.Error("author ID is required")
-		return nil, fmt.Errorf("author ID is required")
+		return nil, apperr.InvalidField("author_id", "required", "author ID is required")
 	}
 
 	post := models.BlogPost{
@@ -50,11 +68,14 @@ func (s *BlogService) CreatePost(ctx context.Context, req dto.CreateBlogPostRequ
 		Slug:            req.Slug,
 		Excerpt:         req.Excerpt,
 		Content:         req.Content,
+		ContentFormat:   req.ContentFormat,
 		FeaturedImageID: req.FeaturedImageID,
 		AuthorID:        req.AuthorID,
+		CategoryID:      req.CategoryID,
 		Status:          req.Status,
 		PublishedAt:     req.PublishedAt,
 		Metadata:        req.Metadata,
+		CommentsClosed:  req.CommentsClosed,
 	}
 
 	// Генерация slug, если не указан
@@ -63,6 +84,21 @@ func (s *BlogService) CreatePost(ctx context.Context, req dto.CreateBlogPostRequ
 		log.Debug("generated slug", slog.String("slug", post.Slug))
 	}
 
+	// Рендеринг контента в безопасный HTML
+	if post.ContentFormat == "" {
+		post.ContentFormat = markdown.FormatHTML
+	}
+	contentHTML, err := markdown.Render(post.ContentFormat, post.Content)
+	if err != nil {
+		log.Error("failed to render content", slog.Any("err", err))
+		return nil, fmt.Errorf("failed to render content: %w", err)
+	}
+	post.ContentHTML = contentHTML
+
+	if post.Excerpt == "" {
+		post.Excerpt = markdown.Excerpt(contentHTML, excerptLength)
+	}
+
 	// Установка статуса по умолчанию
 	if post.Status == "" {
 		post.Status = "draft"
@@ -80,25 +116,82 @@ func (s *BlogService) CreatePost(ctx context.Context, req dto.CreateBlogPostRequ
 		log.Debug("set published_at", slog.Time("published_at", now))
 	}
 
-	// Сохранение в репозитории
-	id, err := s.repo.SaveBlogPost(ctx, post)
-	if err != nil {
-		if strings.Contains(err.Error(), "duplicate key value violates unique constraint") {
-			log.Warn("slug conflict detected, generating unique slug")
-			post.Slug = generateUniqueSlug(post.Slug)
-			id, err = s.repo.SaveBlogPost(ctx, post)
-			if err != nil {
-				log.Error("failed to create post after slug conflict", slog.Any("err", err))
-				return nil, fmt.Errorf("failed to create post after slug conflict: %w", err)
+	// Пост, его теги и медиа-группы сохраняются в одной транзакции
+	var id uuid.UUID
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		id, err = s.savePost(ctx, post, log)
+		if err != nil {
+			return err
+		}
+
+		// Привязка тегов
+		if len(req.Tags) > 0 {
+			if err := s.repo.SetPostTags(ctx, id, normalizePostTags(req.Tags)); err != nil {
+				log.Error("failed to set post tags", slog.Any("err", err))
+				return fmt.Errorf("failed to set post tags: %w", err)
 			}
-		} else {
-			log.Error("failed to create post", slog.Any("err", err))
-			return nil, fmt.Errorf("failed to create post: %w", err)
 		}
+
+		// Привязка медиа-групп
+		for _, group := range req.MediaGroups {
+			if err := s.repo.AddMediaGroupToPost(ctx, id, group.GroupID, group.RelationType); err != nil {
+				log.Error("failed to add media group", slog.Any("err", err))
+				return fmt.Errorf("failed to add media group: %w", err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	log.Info("post created successfully", slog.String("post_id", id.String()))
-	return s.toPostResponse(ctx, id)
+
+	response, err := s.toPostResponse(ctx, id)
+	s.auditor.Record(ctx, models.AuditEntry{
+		Action:     models.AuditPostCreate,
+		TargetType: models.AuditTargetPost,
+		TargetID:   id.String(),
+		After:      response,
+	})
+
+	return response, err
+}
+
+// savePost сохраняет пост, а при занятом slug повторяет попытку с уникальным.
+// Каждая попытка выполняется в своей точке сохранения: ошибка уникальности
+// прерывает транзакцию, и без отката к точке повтор в ней невозможен
+func (s *BlogService) savePost(ctx context.Context, post models.BlogPost, log *slog.Logger) (uuid.UUID, error) {
+	save := func() (uuid.UUID, error) {
+		var id uuid.UUID
+		err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+			var err error
+			id, err = s.repo.SaveBlogPost(ctx, post)
+			return err
+		})
+		return id, err
+	}
+
+	id, err := save()
+	if err == nil {
+		return id, nil
+	}
+	if !errors.Is(err, storage.ErrSlugTaken) {
+		log.Error("failed to create post", slog.Any("err", err))
+		return uuid.Nil, fmt.Errorf("failed to create post: %w", err)
+	}
+
+	log.Warn("slug conflict detected, generating unique slug")
+	post.Slug = generateUniqueSlug(post.Slug)
+	id, err = save()
+	if err != nil {
+		log.Error("failed to create post after slug conflict", slog.Any("err", err))
+		return uuid.Nil, fmt.Errorf("failed to create post after slug conflict: %w", err)
+	}
+
+	return id, nil
 }
 
 // UpdatePost обновляет пост с валидацией и обработкой slug
@@ -136,6 +229,13 @@ func (s *BlogService) UpdatePost(ctx context.Context, postID uuid.UUID, req dto.
 	if req.FeaturedImageID != nil {
 		updates["featured_image_id"] = *req.FeaturedImageID
 	}
+	if req.CategoryID != nil {
+		if *req.CategoryID == uuid.Nil {
+			updates["category_id"] = nil
+		} else {
+			updates["category_id"] = *req.CategoryID
+		}
+	}
 	if req.Status != nil {
 		updates["status"] = *req.Status
 	}
@@ -145,6 +245,37 @@ func (s *BlogService) UpdatePost(ctx context.Context, postID uuid.UUID, req dto.
 	if req.Metadata != nil {
 		updates["metadata"] = req.Metadata
 	}
+	if req.CommentsClosed != nil {
+		updates["comments_closed"] = *req.CommentsClosed
+	}
+
+	// Перерендеринг контента при изменении текста или формата
+	if req.Content != nil || req.ContentFormat != nil {
+		content, format := existingPost.Content, existingPost.ContentFormat
+		if req.Content != nil {
+			content = *req.Content
+		}
+		if req.ContentFormat != nil {
+			format = *req.ContentFormat
+			updates["content_format"] = format
+		}
+
+		contentHTML, err := markdown.Render(format, content)
+		if err != nil {
+			log.Error("failed to render content", slog.Any("err", err))
+			return nil, fmt.Errorf("failed to render content: %w", err)
+		}
+		updates["content_html"] = contentHTML
+
+		// Автоматическое описание, если его нет и не передано новое
+		excerpt := existingPost.Excerpt
+		if req.Excerpt != nil {
+			excerpt = *req.Excerpt
+		}
+		if excerpt == "" {
+			updates["excerpt"] = markdown.Excerpt(contentHTML, excerptLength)
+		}
+	}
 
 	// Обработка slug при обновлении
 	if slug, ok := updates["slug"].(string); ok && slug != existingPost.Slug {
@@ -169,16 +300,118 @@ func (s *BlogService) UpdatePost(ctx context.Context, postID uuid.UUID, req dto.
 		}
 	}
 
-	// Вызов репозитория
-	err = s.repo.UpdateBlogPostFields(ctx, postID, updates)
+	// Поля и теги обновляются в одной транзакции
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		// Вызов репозитория (запрос только с тегами не трогает поля поста)
+		if len(updates) > 0 || req.Tags == nil {
+			if err := s.repo.UpdateBlogPostFields(ctx, postID, updates); err != nil {
+				log.Error("failed to update post", slog.Any("err", err))
+				return fmt.Errorf("failed to update post: %w", err)
+			}
+		}
+
+		// Теги заменяются целиком, если переданы
+		if req.Tags != nil {
+			if err := s.repo.SetPostTags(ctx, postID, normalizePostTags(req.Tags)); err != nil {
+				log.Error("failed to set post tags", slog.Any("err", err))
+				return fmt.Errorf("failed to set post tags: %w", err)
+			}
+		}
+
+		return nil
+	})
 	if err != nil {
-		log.Error("failed to update post", slog.Any("err", err))
-		return nil, fmt.Errorf("failed to update post: %w", err)
+		return nil, err
 	}
 
 	log.Info("post updated successfully")
 
-	return s.toPostResponse(ctx, postID)
+	response, err := s.toPostResponse(ctx, postID)
+	s.auditor.Record(ctx, models.AuditEntry{
+		Action:     models.AuditPostUpdate,
+		TargetType: models.AuditTargetPost,
+		TargetID:   postID.String(),
+		Before:     s.mapToPostResponse(existingPost),
+		After:      response,
+	})
+
+	return response, err
+}
+
+// GetPostBySlug возвращает пост по slug. Если slug устарел после переименования поста,
+// возвращается пустой пост и актуальный slug для редиректа.
+// Без includeUnpublished неопубликованный пост не отдается и его slug не раскрывается
+// через редирект: ответ такой же, как для несуществующего поста
+func (s *BlogService) GetPostBySlug(ctx context.Context, postSlug string, includeUnpublished bool) (*dto.BlogPostResponse, string, error) {
+	const op = "blog_service.GetPostBySlug"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("slug", postSlug),
+	)
+
+	log.Info("getting blog post by slug")
+
+	post, err := s.repo.GetBlogPostBySlug(ctx, postSlug)
+	if err == nil {
+		if !includeUnpublished && post.Status != "published" {
+			log.Warn("post is not published", slog.String("status", post.Status))
+			return nil, "", fmt.Errorf("failed to get post: %w", storage.ErrPostNotFound)
+		}
+
+		log.Info("post retrieved successfully")
+		return s.mapToPostResponse(post), "", nil
+	}
+
+	if !errors.Is(err, storage.ErrPostNotFound) {
+		log.Error("failed to get post", slog.Any("err", err))
+		return nil, "", fmt.Errorf("failed to get post: %w", err)
+	}
+
+	// Ищем пост среди прежних slug'ов
+	currentSlug, err := s.repo.GetPostSlugRedirect(ctx, postSlug)
+	if err != nil {
+		log.Warn("post not found", slog.Any("err", err))
+		return nil, "", fmt.Errorf("failed to get post: %w", err)
+	}
+
+	if !includeUnpublished {
+		moved, err := s.repo.GetBlogPostBySlug(ctx, currentSlug)
+		if err != nil {
+			log.Warn("failed to get moved post", slog.Any("err", err))
+			return nil, "", fmt.Errorf("failed to get post: %w", err)
+		}
+		if moved.Status != "published" {
+			log.Warn("moved post is not published", slog.String("status", moved.Status))
+			return nil, "", fmt.Errorf("failed to get post: %w", storage.ErrPostNotFound)
+		}
+	}
+
+	log.Info("post slug moved", slog.String("current_slug", currentSlug))
+	return nil, currentSlug, nil
+}
+
+// CheckSlugAvailability проверяет, свободен ли slug для нового поста
+func (s *BlogService) CheckSlugAvailability(ctx context.Context, postSlug string) (*dto.SlugAvailabilityResponse, error) {
+	const op = "blog_service.CheckSlugAvailability"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("slug", postSlug),
+	)
+
+	log.Info("checking slug availability")
+
+	if !slug.Valid(postSlug) {
+		log.Warn("invalid slug format")
+		return nil, apperr.InvalidField("slug", "invalid", "invalid slug format")
+	}
+
+	taken, err := s.repo.IsPostSlugTaken(ctx, postSlug)
+	if err != nil {
+		log.Error("failed to check slug", slog.Any("err", err))
+		return nil, fmt.Errorf("failed to check slug: %w", err)
+	}
+
+	return &dto.SlugAvailabilityResponse{Available: !taken}, nil
 }
 
 // GetPostByID возвращает пост по ID
@@ -202,11 +435,11 @@ func (s *BlogService) GetPostByID(ctx context.Context, id uuid.UUID) (*dto.BlogP
 }
 
 // ListPosts возвращает список постов с пагинацией и фильтрацией
-func (s *BlogService) ListPosts(ctx context.Context, statusFilter string, page, perPage int) (*dto.BlogPostListResponse, error) {
+func (s *BlogService) ListPosts(ctx context.Context, filter dto.BlogPostFilter, page, perPage int) (*dto.BlogPostListResponse, error) {
 	const op = "blog_service.ListPosts"
 	log := s.log.With(
 		slog.String("op", op),
-		slog.String("status_filter", statusFilter),
+		slog.String("status_filter", filter.Status),
 		slog.Int("page", page),
 		slog.Int("per_page", perPage),
 	)
@@ -214,14 +447,35 @@ func (s *BlogService) ListPosts(ctx context.Context, statusFilter string, page,
 	log.Info("listing blog posts")
 
 	// Валидация параметров
-	if page < 1 {
-		page = 1
+	page, perPage = pagination.Normalize(page, perPage)
+
+	if filter.DateFrom != nil && filter.DateTo != nil && filter.DateFrom.After(*filter.DateTo) {
+		log.Error("invalid date range")
+		return nil, apperr.InvalidField("date_from", "range", "date_from must be before date_to")
+	}
+
+	repoFilter := models.BlogPostFilter{
+		Status:       filter.Status,
+		CategoryID:   filter.CategoryID,
+		CategorySlug: filter.CategorySlug,
+		AuthorID:     filter.AuthorID,
+		DateFrom:     filter.DateFrom,
+		DateTo:       filter.DateTo,
 	}
-	if perPage < 1 || perPage > 100 {
-		perPage = 10
+	for _, tag := range normalizePostTags(filter.Tags) {
+		repoFilter.Tags = append(repoFilter.Tags, tag.Slug)
 	}
 
-	posts, total, err := s.repo.GetBlogPosts(ctx, statusFilter, page, perPage)
+	if filter.Cursor != "" {
+		cursor, err := pagination.DecodeCursor(filter.Cursor, models.BlogPostSortCreated)
+		if err != nil {
+			log.Warn("invalid cursor", slog.Any("err", err))
+			return nil, err
+		}
+		repoFilter.After = cursor
+	}
+
+	posts, total, err := s.repo.GetBlogPosts(ctx, repoFilter, page, perPage)
 	if err != nil {
 		log.Error("failed to list posts", slog.Any("err", err))
 		return nil, fmt.Errorf("failed to list posts: %w", err)
@@ -229,19 +483,57 @@ func (s *BlogService) ListPosts(ctx context.Context, statusFilter string, page,
 
 	response := &dto.BlogPostListResponse{
 		Posts:      make([]dto.BlogPostResponse, 0, len(posts)),
+		Meta:       pagination.NewMeta(total, page, perPage),
 		TotalCount: total,
-		Page:       page,
-		PerPage:    perPage,
 	}
 
 	for _, post := range posts {
 		response.Posts = append(response.Posts, *s.mapToPostResponse(&post))
 	}
 
+	response.SetNextCursor(len(posts), repoFilter.After != nil, func() pagination.Cursor {
+		last := posts[len(posts)-1]
+		return pagination.Cursor{Sort: models.BlogPostSortCreated, ID: last.ID, Time: last.CreatedAt}
+	})
+
 	log.Info("posts listed successfully", slog.Int("count", len(posts)))
 	return response, nil
 }
 
+// GetTagCounts возвращает теги постов с количеством использований для облака тегов
+func (s *BlogService) GetTagCounts(ctx context.Context, statusFilter string, limit int) ([]dto.TagCountResponse, error) {
+	const op = "blog_service.GetTagCounts"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("status_filter", statusFilter),
+		slog.Int("limit", limit),
+	)
+
+	log.Info("getting tag counts")
+
+	if limit < 0 || limit > 500 {
+		limit = 100
+	}
+
+	counts, err := s.repo.GetTagCounts(ctx, statusFilter, limit)
+	if err != nil {
+		log.Error("failed to get tag counts", slog.Any("err", err))
+		return nil, fmt.Errorf("failed to get tag counts: %w", err)
+	}
+
+	response := make([]dto.TagCountResponse, 0, len(counts))
+	for _, tc := range counts {
+		response = append(response, dto.TagCountResponse{
+			Name:  tc.Name,
+			Slug:  tc.Slug,
+			Count: tc.Count,
+		})
+	}
+
+	log.Info("tag counts retrieved successfully", slog.Int("count", len(response)))
+	return response, nil
+}
+
 // PublishPost публикует пост (устанавливает статус published)
 func (s *BlogService) PublishPost(ctx context.Context, postID uuid.UUID) (*dto.BlogPostResponse, error) {
 	const op = "blog_service.PublishPost"
@@ -264,7 +556,16 @@ func (s *BlogService) PublishPost(ctx context.Context, postID uuid.UUID) (*dto.B
 	}
 
 	log.Info("post published successfully")
-	return s.toPostResponse(ctx, postID)
+
+	response, err := s.toPostResponse(ctx, postID)
+	s.auditor.Record(ctx, models.AuditEntry{
+		Action:     models.AuditPostPublish,
+		TargetType: models.AuditTargetPost,
+		TargetID:   postID.String(),
+		After:      response,
+	})
+
+	return response, err
 }
 
 // ArchivePost отправляет пост в архив
@@ -284,7 +585,16 @@ func (s *BlogService) ArchivePost(ctx context.Context, postID uuid.UUID) (*dto.B
 	}
 
 	log.Info("post archived successfully")
-	return s.toPostResponse(ctx, postID)
+
+	response, err := s.toPostResponse(ctx, postID)
+	s.auditor.Record(ctx, models.AuditEntry{
+		Action:     models.AuditPostArchive,
+		TargetType: models.AuditTargetPost,
+		TargetID:   postID.String(),
+		After:      response,
+	})
+
+	return response, err
 }
 
 // DeletePost удаляет пост (физическое удаление)
@@ -304,6 +614,13 @@ func (s *BlogService) DeletePost(ctx context.Context, postID uuid.UUID) error {
 	}
 
 	log.Info("post deleted successfully")
+
+	s.auditor.Record(ctx, models.AuditEntry{
+		Action:     models.AuditPostDelete,
+		TargetType: models.AuditTargetPost,
+		TargetID:   postID.String(),
+	})
+
 	return nil
 }
 
@@ -322,7 +639,7 @@ func (s *BlogService) AddMediaGroup(ctx context.Context, postID uuid.UUID, req d
 	// Валидация relationType
 	if req.RelationType != "content" && req.RelationType != "gallery" && req.RelationType != "attachment" {
 		log.Error("invalid relation type")
-		return nil, fmt.Errorf("invalid relation type")
+		return nil, apperr.InvalidField("relation_type", "oneof", "invalid relation type")
 	}
 
 	err := s.repo.AddMediaGroupToPost(ctx, postID, req.GroupID, req.RelationType)
@@ -372,11 +689,34 @@ func (s *BlogService) GetPostMediaGroups(ctx context.Context, postID uuid.UUID,
 
 // Вспомогательные функции
 func generateSlug(title string) string {
-	slug := strings.ToLower(title)
-	slug = strings.ReplaceAll(slug, " ", "-")
-	slug = strings.ReplaceAll(slug, "'", "")
-	slug = strings.ReplaceAll(slug, `"`, "")
-	return slug
+	if s := slug.Make(title); s != "" {
+		return s
+	}
+	// Заголовок без букв и цифр (например, из одних эмодзи)
+	return generateUniqueSlug("post")
+}
+
+// normalizePostTags очищает теги от пробелов и дубликатов и формирует для них slug
+func normalizePostTags(tags []string) []models.BlogTag {
+	seen := make(map[string]bool, len(tags))
+	result := make([]models.BlogTag, 0, len(tags))
+
+	for _, tag := range tags {
+		name := strings.Join(strings.Fields(tag), " ")
+		if name == "" {
+			continue
+		}
+
+		tagSlug := slug.Make(name)
+		if tagSlug == "" || seen[tagSlug] {
+			continue
+		}
+		seen[tagSlug] = true
+
+		result = append(result, models.BlogTag{Name: name, Slug: tagSlug})
+	}
+
+	return result
 }
 
 func generateUniqueSlug(base string) string {
@@ -402,14 +742,50 @@ func (s *BlogService) mapToPostResponse(post *models.BlogPost) *dto.BlogPostResp
 		Slug:              post.Slug,
 		Excerpt:           post.Excerpt,
 		Content:           post.Content,
+		ContentFormat:     post.ContentFormat,
+		ContentHTML:       post.ContentHTML,
 		FeaturedImageID:   post.FeaturedImageID,
 		FeaturedImagePath: post.FeaturedImagePath,
 		AuthorID:          post.AuthorID,
+		CategoryID:        post.CategoryID,
+		Tags:              make([]dto.BlogTagResponse, 0, len(post.Tags)),
 		Status:            post.Status,
 		PublishedAt:       post.PublishedAt,
 		CreatedAt:         post.CreatedAt,
 		UpdatedAt:         post.UpdatedAt,
 		Metadata:          post.Metadata,
+		CommentsClosed:    post.CommentsClosed,
+		CommentCount:      post.CommentCount,
+	}
+
+	// Посты, созданные до появления рендеринга, рендерятся на лету
+	if response.ContentHTML == "" && post.Content != "" {
+		format := post.ContentFormat
+		if format == "" {
+			format = markdown.FormatHTML
+		}
+		if contentHTML, err := markdown.Render(format, post.Content); err == nil {
+			response.ContentHTML = contentHTML
+		} else {
+			s.log.Warn("failed to render post content", slog.String("post_id", post.ID.String()), slog.Any("err", err))
+		}
+	}
+
+	response.ReadingTime = markdown.ReadingTime(response.ContentHTML)
+	response.TableOfContents = make([]dto.TOCEntry, 0)
+	for _, heading := range markdown.TableOfContents(response.ContentHTML) {
+		response.TableOfContents = append(response.TableOfContents, dto.TOCEntry{
+			Level: heading.Level,
+			ID:    heading.ID,
+			Text:  heading.Text,
+		})
+	}
+
+	for _, tag := range post.Tags {
+		response.Tags = append(response.Tags, dto.BlogTagResponse{
+			Name: tag.Name,
+			Slug: tag.Slug,
+		})
 	}
 
 	if post.MediaGroups != nil {
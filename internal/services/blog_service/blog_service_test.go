@@ -3,8 +3,11 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"premium_caste/internal/domain/models"
+	"premium_caste/internal/lib/pagination"
+	"premium_caste/internal/storage"
 	"premium_caste/internal/transport/http/dto"
 	"strings"
 	"testing"
@@ -13,6 +16,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 // MockBlogRepository реализация мок-репозитория
@@ -38,11 +42,42 @@ func (m *MockBlogRepository) UpdateBlogPostFields(ctx context.Context, id uuid.U
 	return args.Error(0)
 }
 
-func (m *MockBlogRepository) GetBlogPosts(ctx context.Context, statusFilter string, page, perPage int) ([]models.BlogPost, int, error) {
-	args := m.Called(ctx, statusFilter, page, perPage)
+func (m *MockBlogRepository) GetBlogPosts(ctx context.Context, filter models.BlogPostFilter, page, perPage int) ([]models.BlogPost, int, error) {
+	args := m.Called(ctx, filter, page, perPage)
 	return args.Get(0).([]models.BlogPost), args.Int(1), args.Error(2)
 }
 
+func (m *MockBlogRepository) SetPostTags(ctx context.Context, postID uuid.UUID, tags []models.BlogTag) error {
+	args := m.Called(ctx, postID, tags)
+	return args.Error(0)
+}
+
+func (m *MockBlogRepository) GetTagCounts(ctx context.Context, statusFilter string, limit int) ([]models.TagCount, error) {
+	args := m.Called(ctx, statusFilter, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.TagCount), args.Error(1)
+}
+
+func (m *MockBlogRepository) GetBlogPostBySlug(ctx context.Context, slug string) (*models.BlogPost, error) {
+	args := m.Called(ctx, slug)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.BlogPost), args.Error(1)
+}
+
+func (m *MockBlogRepository) GetPostSlugRedirect(ctx context.Context, oldSlug string) (string, error) {
+	args := m.Called(ctx, oldSlug)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockBlogRepository) IsPostSlugTaken(ctx context.Context, slug string) (bool, error) {
+	args := m.Called(ctx, slug)
+	return args.Bool(0), args.Error(1)
+}
+
 func (m *MockBlogRepository) SoftDeleteBlogPost(ctx context.Context, id uuid.UUID) error {
 	args := m.Called(ctx, id)
 	return args.Error(0)
@@ -63,14 +98,39 @@ func (m *MockBlogRepository) GetPostMediaGroups(ctx context.Context, postID uuid
 	return args.Get(0).([]uuid.UUID), args.Error(0)
 }
 
+// passthroughTx выполняет функцию с исходным контекстом, без транзакции
+type passthroughTx struct{}
+
+func (passthroughTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// recordingAuditor запоминает события, переданные в журнал аудита
+type recordingAuditor struct {
+	entries []models.AuditEntry
+}
+
+func (a *recordingAuditor) Record(ctx context.Context, entry models.AuditEntry) {
+	a.entries = append(a.entries, entry)
+}
+
+func (a *recordingAuditor) actions() []string {
+	var actions []string
+	for _, entry := range a.entries {
+		actions = append(actions, entry.Action)
+	}
+	return actions
+}
+
 func TestBlogService_CreatePost(t *testing.T) {
 	ctx := context.Background()
 	log := slog.Default()
 	mockRepo := new(MockBlogRepository)
-	service := NewBlogService(log, mockRepo)
+	service := NewBlogService(log, mockRepo, passthroughTx{}, &recordingAuditor{})
 
 	testUUID := uuid.MustParse("b3c87987-ba25-4c7b-8070-f74ef402fe7c")
 	authorID := uuid.New()
+	groupID := uuid.New()
 	mockPost := &models.BlogPost{
 		ID:        testUUID,
 		Title:     "Test Post",
@@ -106,6 +166,64 @@ func TestBlogService_CreatePost(t *testing.T) {
 			},
 			wantError: false,
 		},
+		{
+			name: "slug conflict is retried with unique slug",
+			req: dto.CreateBlogPostRequest{
+				Title:    "Test Post",
+				Slug:     "test-post",
+				AuthorID: authorID,
+			},
+			mockSetup: func() {
+				mockRepo.On("SaveBlogPost", ctx, mock.MatchedBy(func(p models.BlogPost) bool { return p.Slug == "test-post" })).
+					Return(uuid.Nil, fmt.Errorf("repository.blog_repository.SaveBlogPost: %w", storage.ErrSlugTaken)).Once()
+				mockRepo.On("SaveBlogPost", ctx, mock.MatchedBy(func(p models.BlogPost) bool { return p.Slug != "test-post" })).
+					Return(testUUID, nil).Once()
+
+				mockRepo.On("GetBlogPostByID", ctx, testUUID).
+					Return(mockPost, nil).
+					Once()
+			},
+			wantError: false,
+		},
+		{
+			name: "media groups attached with post",
+			req: dto.CreateBlogPostRequest{
+				Title:    "Test Post",
+				AuthorID: authorID,
+				MediaGroups: []dto.AddMediaGroupRequest{
+					{GroupID: groupID, RelationType: "gallery"},
+				},
+			},
+			mockSetup: func() {
+				mockRepo.On("SaveBlogPost", ctx, mock.AnythingOfType("models.BlogPost")).
+					Return(testUUID, nil).Once()
+				mockRepo.On("AddMediaGroupToPost", ctx, testUUID, groupID, "gallery").
+					Return(nil).Once()
+
+				mockRepo.On("GetBlogPostByID", ctx, testUUID).
+					Return(mockPost, nil).
+					Once()
+			},
+			wantError: false,
+		},
+		{
+			name: "media group failure aborts creation",
+			req: dto.CreateBlogPostRequest{
+				Title:    "Test Post",
+				AuthorID: authorID,
+				MediaGroups: []dto.AddMediaGroupRequest{
+					{GroupID: groupID, RelationType: "gallery"},
+				},
+			},
+			mockSetup: func() {
+				mockRepo.On("SaveBlogPost", ctx, mock.AnythingOfType("models.BlogPost")).
+					Return(testUUID, nil).Once()
+				mockRepo.On("AddMediaGroupToPost", ctx, testUUID, groupID, "gallery").
+					Return(errors.New("foreign key violation")).Once()
+			},
+			wantError:   true,
+			expectedErr: "failed to add media group",
+		},
 		{
 			name: "missing title",
 			req: dto.CreateBlogPostRequest{
@@ -155,7 +273,7 @@ func TestBlogService_UpdatePost(t *testing.T) {
 	ctx := context.Background()
 	log := slog.Default()
 	mockRepo := new(MockBlogRepository)
-	service := NewBlogService(log, mockRepo)
+	service := NewBlogService(log, mockRepo, passthroughTx{}, &recordingAuditor{})
 
 	postID := uuid.New()
 	existingPost := &models.BlogPost{
@@ -270,7 +388,7 @@ func TestBlogService_GetPostByID(t *testing.T) {
 	ctx := context.Background()
 	log := slog.Default()
 	mockRepo := new(MockBlogRepository)
-	service := NewBlogService(log, mockRepo)
+	service := NewBlogService(log, mockRepo, passthroughTx{}, &recordingAuditor{})
 
 	postID := uuid.New()
 	expectedPost := &models.BlogPost{
@@ -345,7 +463,7 @@ func TestBlogService_ListPosts(t *testing.T) {
 	ctx := context.Background()
 	log := slog.Default()
 	mockRepo := new(MockBlogRepository)
-	service := NewBlogService(log, mockRepo)
+	service := NewBlogService(log, mockRepo, passthroughTx{}, &recordingAuditor{})
 
 	now := time.Now()
 	posts := []models.BlogPost{
@@ -365,9 +483,12 @@ func TestBlogService_ListPosts(t *testing.T) {
 		},
 	}
 
+	categoryID := uuid.New()
+	dateFrom := now.Add(-24 * time.Hour)
+
 	tests := []struct {
 		name        string
-		status      string
+		filter      dto.BlogPostFilter
 		page        int
 		perPage     int
 		mockSetup   func()
@@ -376,33 +497,66 @@ func TestBlogService_ListPosts(t *testing.T) {
 	}{
 		{
 			name:    "successful list",
-			status:  "published",
+			filter:  dto.BlogPostFilter{Status: "published"},
 			page:    1,
 			perPage: 10,
 			mockSetup: func() {
-				mockRepo.On("GetBlogPosts", ctx, "published", 1, 10).
+				mockRepo.On("GetBlogPosts", ctx, models.BlogPostFilter{Status: "published"}, 1, 10).
 					Return(posts, 2, nil).Once()
 			},
 			wantError: false,
 		},
 		{
 			name:    "invalid page correction",
-			status:  "published",
+			filter:  dto.BlogPostFilter{Status: "published"},
 			page:    0,
 			perPage: 0,
 			mockSetup: func() {
-				mockRepo.On("GetBlogPosts", ctx, "published", 1, 10).
+				mockRepo.On("GetBlogPosts", ctx, models.BlogPostFilter{Status: "published"}, 1, 10).
+					Return(posts, 2, nil).Once()
+			},
+			wantError: false,
+		},
+		{
+			name: "taxonomy filters with tag normalization",
+			filter: dto.BlogPostFilter{
+				Status:     "published",
+				CategoryID: &categoryID,
+				Tags:       []string{" Go ", "go", "Web  Dev"},
+				DateFrom:   &dateFrom,
+			},
+			page:    1,
+			perPage: 10,
+			mockSetup: func() {
+				mockRepo.On("GetBlogPosts", ctx, models.BlogPostFilter{
+					Status:     "published",
+					CategoryID: &categoryID,
+					Tags:       []string{"go", "web-dev"},
+					DateFrom:   &dateFrom,
+				}, 1, 10).
 					Return(posts, 2, nil).Once()
 			},
 			wantError: false,
 		},
+		{
+			name: "invalid date range",
+			filter: dto.BlogPostFilter{
+				DateFrom: &now,
+				DateTo:   &dateFrom,
+			},
+			page:        1,
+			perPage:     10,
+			mockSetup:   func() {},
+			wantError:   true,
+			expectedErr: "date_from must be before date_to",
+		},
 		{
 			name:    "repository error",
-			status:  "published",
+			filter:  dto.BlogPostFilter{Status: "published"},
 			page:    1,
 			perPage: 10,
 			mockSetup: func() {
-				mockRepo.On("GetBlogPosts", ctx, "published", 1, 10).
+				mockRepo.On("GetBlogPosts", ctx, models.BlogPostFilter{Status: "published"}, 1, 10).
 					Return([]models.BlogPost{}, 0, errors.New("db error")).Once()
 			},
 			wantError:   true,
@@ -414,7 +568,7 @@ func TestBlogService_ListPosts(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mockSetup()
 
-			resp, err := service.ListPosts(ctx, tt.status, tt.page, tt.perPage)
+			resp, err := service.ListPosts(ctx, tt.filter, tt.page, tt.perPage)
 
 			if tt.wantError {
 				assert.Error(t, err)
@@ -425,6 +579,7 @@ func TestBlogService_ListPosts(t *testing.T) {
 				assert.NotNil(t, resp)
 				assert.Len(t, resp.Posts, len(posts))
 				assert.Equal(t, 2, resp.TotalCount)
+				assert.Equal(t, 2, resp.Total)
 			}
 
 			mockRepo.AssertExpectations(t)
@@ -432,11 +587,42 @@ func TestBlogService_ListPosts(t *testing.T) {
 	}
 }
 
+func TestBlogService_ListPostsCursor(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockBlogRepository)
+	service := NewBlogService(slog.Default(), mockRepo, passthroughTx{}, &recordingAuditor{})
+
+	last := models.BlogPost{ID: uuid.New(), Title: "Older", CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)}
+	filter := models.BlogPostFilter{Status: "published"}
+	mockRepo.On("GetBlogPosts", ctx, filter, 1, 2).
+		Return([]models.BlogPost{{ID: uuid.New(), Title: "Newer"}, last}, 3, nil).Once()
+
+	resp, err := service.ListPosts(ctx, dto.BlogPostFilter{Status: "published"}, 1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, resp.TotalPages)
+	require.NotEmpty(t, resp.NextCursor)
+
+	next := filter
+	next.After = &pagination.Cursor{Sort: models.BlogPostSortCreated, ID: last.ID, Time: last.CreatedAt}
+	mockRepo.On("GetBlogPosts", ctx, next, 1, 2).
+		Return([]models.BlogPost{{ID: uuid.New()}}, 3, nil).Once()
+
+	resp, err = service.ListPosts(ctx, dto.BlogPostFilter{Status: "published", Cursor: resp.NextCursor}, 1, 2)
+	require.NoError(t, err)
+	assert.Len(t, resp.Posts, 1)
+	assert.Empty(t, resp.NextCursor)
+
+	_, err = service.ListPosts(ctx, dto.BlogPostFilter{Cursor: "broken"}, 1, 2)
+	assert.ErrorIs(t, err, storage.ErrInvalidCursor)
+
+	mockRepo.AssertExpectations(t)
+}
+
 func TestBlogService_PublishPost(t *testing.T) {
 	ctx := context.Background()
 	log := slog.Default()
 	mockRepo := new(MockBlogRepository)
-	service := NewBlogService(log, mockRepo)
+	service := NewBlogService(log, mockRepo, passthroughTx{}, &recordingAuditor{})
 
 	testUUID := uuid.MustParse("b3c87987-ba25-4c7b-8070-f74ef402fe7c")
 	authorID := uuid.New()
@@ -509,7 +695,7 @@ func TestBlogService_ArchivePost(t *testing.T) {
 	ctx := context.Background()
 	log := slog.Default()
 	mockRepo := new(MockBlogRepository)
-	service := NewBlogService(log, mockRepo)
+	service := NewBlogService(log, mockRepo, passthroughTx{}, &recordingAuditor{})
 
 	testUUID := uuid.MustParse("b3c87987-ba25-4c7b-8070-f74ef402fe7c")
 	authorID := uuid.New()
@@ -581,7 +767,8 @@ func TestBlogService_DeletePost(t *testing.T) {
 	ctx := context.Background()
 	log := slog.Default()
 	mockRepo := new(MockBlogRepository)
-	service := NewBlogService(log, mockRepo)
+	auditor := &recordingAuditor{}
+	service := NewBlogService(log, mockRepo, passthroughTx{}, auditor)
 
 	postID := uuid.New()
 
@@ -591,6 +778,7 @@ func TestBlogService_DeletePost(t *testing.T) {
 		mockSetup   func()
 		wantError   bool
 		expectedErr string
+		wantAudit   []string
 	}{
 		{
 			name:   "successful delete",
@@ -600,6 +788,7 @@ func TestBlogService_DeletePost(t *testing.T) {
 					Return(nil).Once()
 			},
 			wantError: false,
+			wantAudit: []string{models.AuditPostDelete},
 		},
 		{
 			name:   "repository error",
@@ -615,6 +804,7 @@ func TestBlogService_DeletePost(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			auditor.entries = nil
 			tt.mockSetup()
 
 			err := service.DeletePost(ctx, tt.postID)
@@ -625,6 +815,7 @@ func TestBlogService_DeletePost(t *testing.T) {
 			} else {
 				assert.NoError(t, err)
 			}
+			assert.Equal(t, tt.wantAudit, auditor.actions())
 
 			mockRepo.AssertExpectations(t)
 		})
@@ -635,7 +826,7 @@ func TestBlogService_AddMediaGroup(t *testing.T) {
 	ctx := context.Background()
 	log := slog.Default()
 	mockRepo := new(MockBlogRepository)
-	service := NewBlogService(log, mockRepo)
+	service := NewBlogService(log, mockRepo, passthroughTx{}, &recordingAuditor{})
 
 	postID := uuid.New()
 	groupID := uuid.New()
@@ -706,6 +897,269 @@ func TestBlogService_AddMediaGroup(t *testing.T) {
 	}
 }
 
+func TestBlogService_GetPostBySlug(t *testing.T) {
+	ctx := context.Background()
+	log := slog.Default()
+	mockRepo := new(MockBlogRepository)
+	service := NewBlogService(log, mockRepo, passthroughTx{}, &recordingAuditor{})
+
+	post := &models.BlogPost{ID: uuid.New(), Title: "Новый пост", Slug: "novyy-post", Status: "published"}
+	draft := &models.BlogPost{ID: uuid.New(), Title: "Черновик", Slug: "chernovik", Status: "draft"}
+
+	tests := []struct {
+		name               string
+		slug               string
+		includeUnpublished bool
+		mockSetup          func()
+		wantPost           bool
+		wantRedirect       string
+		wantError          bool
+	}{
+		{
+			name: "current slug",
+			slug: "novyy-post",
+			mockSetup: func() {
+				mockRepo.On("GetBlogPostBySlug", ctx, "novyy-post").Return(post, nil).Once()
+			},
+			wantPost: true,
+		},
+		{
+			name: "old slug redirects",
+			slug: "staryy-post",
+			mockSetup: func() {
+				mockRepo.On("GetBlogPostBySlug", ctx, "staryy-post").
+					Return(nil, fmt.Errorf("repo: %w", storage.ErrPostNotFound)).Once()
+				mockRepo.On("GetPostSlugRedirect", ctx, "staryy-post").Return("novyy-post", nil).Once()
+				mockRepo.On("GetBlogPostBySlug", ctx, "novyy-post").Return(post, nil).Once()
+			},
+			wantRedirect: "novyy-post",
+		},
+		{
+			name: "draft is hidden",
+			slug: "chernovik",
+			mockSetup: func() {
+				mockRepo.On("GetBlogPostBySlug", ctx, "chernovik").Return(draft, nil).Once()
+			},
+			wantError: true,
+		},
+		{
+			name:               "draft for admin",
+			slug:               "chernovik",
+			includeUnpublished: true,
+			mockSetup: func() {
+				mockRepo.On("GetBlogPostBySlug", ctx, "chernovik").Return(draft, nil).Once()
+			},
+			wantPost: true,
+		},
+		{
+			name: "old slug of draft does not reveal current slug",
+			slug: "staryy-chernovik",
+			mockSetup: func() {
+				mockRepo.On("GetBlogPostBySlug", ctx, "staryy-chernovik").
+					Return(nil, fmt.Errorf("repo: %w", storage.ErrPostNotFound)).Once()
+				mockRepo.On("GetPostSlugRedirect", ctx, "staryy-chernovik").Return("chernovik", nil).Once()
+				mockRepo.On("GetBlogPostBySlug", ctx, "chernovik").Return(draft, nil).Once()
+			},
+			wantError: true,
+		},
+		{
+			name:               "old slug of draft redirects admin",
+			slug:               "staryy-chernovik",
+			includeUnpublished: true,
+			mockSetup: func() {
+				mockRepo.On("GetBlogPostBySlug", ctx, "staryy-chernovik").
+					Return(nil, fmt.Errorf("repo: %w", storage.ErrPostNotFound)).Once()
+				mockRepo.On("GetPostSlugRedirect", ctx, "staryy-chernovik").Return("chernovik", nil).Once()
+			},
+			wantRedirect: "chernovik",
+		},
+		{
+			name: "unknown slug",
+			slug: "missing",
+			mockSetup: func() {
+				mockRepo.On("GetBlogPostBySlug", ctx, "missing").
+					Return(nil, fmt.Errorf("repo: %w", storage.ErrPostNotFound)).Once()
+				mockRepo.On("GetPostSlugRedirect", ctx, "missing").
+					Return("", fmt.Errorf("repo: %w", storage.ErrPostNotFound)).Once()
+			},
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+
+			resp, redirect, err := service.GetPostBySlug(ctx, tt.slug, tt.includeUnpublished)
+
+			if tt.wantError {
+				assert.Error(t, err)
+				assert.ErrorIs(t, err, storage.ErrPostNotFound)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.wantPost, resp != nil)
+				assert.Equal(t, tt.wantRedirect, redirect)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBlogService_CheckSlugAvailability(t *testing.T) {
+	ctx := context.Background()
+	log := slog.Default()
+	mockRepo := new(MockBlogRepository)
+	service := NewBlogService(log, mockRepo, passthroughTx{}, &recordingAuditor{})
+
+	mockRepo.On("IsPostSlugTaken", ctx, "free-slug").Return(false, nil).Once()
+	mockRepo.On("IsPostSlugTaken", ctx, "taken-slug").Return(true, nil).Once()
+
+	resp, err := service.CheckSlugAvailability(ctx, "free-slug")
+	assert.NoError(t, err)
+	assert.True(t, resp.Available)
+
+	resp, err = service.CheckSlugAvailability(ctx, "taken-slug")
+	assert.NoError(t, err)
+	assert.False(t, resp.Available)
+
+	_, err = service.CheckSlugAvailability(ctx, "Not A Slug")
+	assert.Error(t, err)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestBlogService_ContentRendering(t *testing.T) {
+	ctx := context.Background()
+	log := slog.Default()
+	mockRepo := new(MockBlogRepository)
+	service := NewBlogService(log, mockRepo, passthroughTx{}, &recordingAuditor{})
+
+	postID := uuid.New()
+	authorID := uuid.New()
+
+	t.Run("create renders markdown and fills excerpt", func(t *testing.T) {
+		mockRepo.On("SaveBlogPost", ctx, mock.MatchedBy(func(p models.BlogPost) bool {
+			return p.ContentFormat == "markdown" &&
+				strings.Contains(p.ContentHTML, `<h2 id="plan">План</h2>`) &&
+				p.Excerpt == "План Первый шаг"
+		})).Return(postID, nil).Once()
+		mockRepo.On("GetBlogPostByID", ctx, postID).Return(&models.BlogPost{
+			ID:            postID,
+			Content:       "## План\n\nПервый шаг",
+			ContentFormat: "markdown",
+			ContentHTML:   `<h2 id="plan">План</h2><p>Первый шаг</p>`,
+		}, nil).Once()
+
+		resp, err := service.CreatePost(ctx, dto.CreateBlogPostRequest{
+			Title:         "Markdown post",
+			Content:       "## План\n\nПервый шаг",
+			ContentFormat: "markdown",
+			AuthorID:      authorID,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 1, resp.ReadingTime)
+		assert.Equal(t, []dto.TOCEntry{{Level: 2, ID: "plan", Text: "План"}}, resp.TableOfContents)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("update re-renders with existing format", func(t *testing.T) {
+		existing := &models.BlogPost{ID: postID, Content: "old", ContentFormat: "plain", Excerpt: "kept"}
+		mockRepo.On("GetBlogPostByID", ctx, postID).Return(existing, nil).Twice()
+		mockRepo.On("UpdateBlogPostFields", ctx, postID, map[string]interface{}{
+			"content":      "a < b",
+			"content_html": "<p>a &lt; b</p>\n",
+		}).Return(nil).Once()
+
+		_, err := service.UpdatePost(ctx, postID, dto.UpdateBlogPostRequest{Content: stringPtr("a < b")})
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("legacy post rendered on the fly", func(t *testing.T) {
+		resp := service.mapToPostResponse(&models.BlogPost{ID: postID, Content: `<p onclick="x()">hi</p>`})
+		assert.Equal(t, "<p>hi</p>", resp.ContentHTML)
+	})
+}
+
+func TestGenerateSlug(t *testing.T) {
+	assert.Equal(t, "kak-ya-provyol-leto", generateSlug("Как я провёл лето"))
+	assert.True(t, strings.HasPrefix(generateSlug("!!!"), "post-"))
+}
+
+func TestBlogService_GetTagCounts(t *testing.T) {
+	ctx := context.Background()
+	log := slog.Default()
+	mockRepo := new(MockBlogRepository)
+	service := NewBlogService(log, mockRepo, passthroughTx{}, &recordingAuditor{})
+
+	tests := []struct {
+		name        string
+		status      string
+		limit       int
+		mockSetup   func()
+		wantLen     int
+		wantError   bool
+		expectedErr string
+	}{
+		{
+			name:   "successful get",
+			status: "published",
+			limit:  10,
+			mockSetup: func() {
+				mockRepo.On("GetTagCounts", ctx, "published", 10).
+					Return([]models.TagCount{
+						{Name: "Go", Slug: "go", Count: 5},
+						{Name: "Web", Slug: "web", Count: 2},
+					}, nil).Once()
+			},
+			wantLen:   2,
+			wantError: false,
+		},
+		{
+			name:   "limit correction",
+			status: "published",
+			limit:  1000,
+			mockSetup: func() {
+				mockRepo.On("GetTagCounts", ctx, "published", 100).
+					Return([]models.TagCount{}, nil).Once()
+			},
+			wantLen:   0,
+			wantError: false,
+		},
+		{
+			name:   "repository error",
+			status: "published",
+			limit:  10,
+			mockSetup: func() {
+				mockRepo.On("GetTagCounts", ctx, "published", 10).
+					Return(nil, errors.New("db error")).Once()
+			},
+			wantError:   true,
+			expectedErr: "failed to get tag counts",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+
+			resp, err := service.GetTagCounts(ctx, tt.status, tt.limit)
+
+			if tt.wantError {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+				assert.Nil(t, resp)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, resp, tt.wantLen)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
 func stringPtr(s string) *string {
 	return &s
 }
@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"premium_caste/internal/domain/apperr"
+	"premium_caste/internal/domain/models"
+	"premium_caste/internal/lib/slug"
+	"premium_caste/internal/repository"
+	"premium_caste/internal/transport/http/dto"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// maxCategoryDepth ограничивает обход дерева категорий при проверке на циклы
+const maxCategoryDepth = 32
+
+type CategoryService struct {
+	log  *slog.Logger
+	repo repository.CategoryRepository
+}
+
+func NewCategoryService(log *slog.Logger, repo repository.CategoryRepository) *CategoryService {
+	return &CategoryService{
+		log:  log,
+		repo: repo,
+	}
+}
+
+// CreateCategory создает новую категорию блога
+func (s *CategoryService) CreateCategory(ctx context.Context, req dto.CreateCategoryRequest) (*dto.CategoryResponse, error) {
+	const op = "category_service.CreateCategory"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("name", req.Name),
+	)
+
+	log.Info("creating category")
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		log.Error("category name is required")
+		return nil, apperr.InvalidField("name", "required", "category name is required")
+	}
+
+	category := models.BlogCategory{
+		ParentID:    req.ParentID,
+		Name:        name,
+		Slug:        req.Slug,
+		Description: req.Description,
+		Position:    req.Position,
+	}
+
+	if category.Slug == "" {
+		category.Slug = slug.Make(name)
+		log.Debug("generated slug", slog.String("slug", category.Slug))
+	}
+	if !slug.Valid(category.Slug) {
+		log.Error("invalid category slug", slog.String("slug", category.Slug))
+		return nil, apperr.InvalidField("slug", "invalid", "invalid category slug")
+	}
+
+	if category.ParentID != nil && *category.ParentID == uuid.Nil {
+		category.ParentID = nil
+	}
+
+	if category.ParentID != nil {
+		if _, err := s.repo.GetCategoryByID(ctx, *category.ParentID); err != nil {
+			log.Error("failed to get parent category", slog.Any("err", err))
+			return nil, fmt.Errorf("failed to get parent category: %w", err)
+		}
+	}
+
+	id, err := s.repo.CreateCategory(ctx, category)
+	if err != nil {
+		log.Error("failed to create category", slog.Any("err", err))
+		return nil, fmt.Errorf("failed to create category: %w", err)
+	}
+
+	log.Info("category created successfully", slog.String("category_id", id.String()))
+	return s.toCategoryResponse(ctx, id)
+}
+
+// UpdateCategory обновляет категорию. Перенос категории внутрь собственного поддерева запрещен.
+func (s *CategoryService) UpdateCategory(ctx context.Context, id uuid.UUID, req dto.UpdateCategoryRequest) (*dto.CategoryResponse, error) {
+	const op = "category_service.UpdateCategory"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("category_id", id.String()),
+	)
+
+	log.Info("updating category")
+
+	category, err := s.repo.GetCategoryByID(ctx, id)
+	if err != nil {
+		log.Error("failed to get category", slog.Any("err", err))
+		return nil, fmt.Errorf("failed to get category: %w", err)
+	}
+
+	if req.Name != nil {
+		category.Name = strings.TrimSpace(*req.Name)
+		if category.Name == "" {
+			log.Error("category name is required")
+			return nil, apperr.InvalidField("name", "required", "category name is required")
+		}
+	}
+	if req.Slug != nil {
+		category.Slug = *req.Slug
+		if category.Slug == "" {
+			category.Slug = slug.Make(category.Name)
+			log.Debug("generated new slug", slog.String("slug", category.Slug))
+		}
+		if !slug.Valid(category.Slug) {
+			log.Error("invalid category slug", slog.String("slug", category.Slug))
+			return nil, apperr.InvalidField("slug", "invalid", "invalid category slug")
+		}
+	}
+	if req.Description != nil {
+		category.Description = *req.Description
+	}
+	if req.Position != nil {
+		category.Position = *req.Position
+	}
+	if req.ParentID != nil {
+		if *req.ParentID == uuid.Nil {
+			category.ParentID = nil
+		} else {
+			if err := s.checkParent(ctx, id, *req.ParentID); err != nil {
+				log.Error("invalid parent category", slog.Any("err", err))
+				return nil, err
+			}
+			category.ParentID = req.ParentID
+		}
+	}
+
+	if err := s.repo.UpdateCategory(ctx, category); err != nil {
+		log.Error("failed to update category", slog.Any("err", err))
+		return nil, fmt.Errorf("failed to update category: %w", err)
+	}
+
+	log.Info("category updated successfully")
+	return s.toCategoryResponse(ctx, id)
+}
+
+// DeleteCategory удаляет категорию
+func (s *CategoryService) DeleteCategory(ctx context.Context, id uuid.UUID) error {
+	const op = "category_service.DeleteCategory"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("category_id", id.String()),
+	)
+
+	log.Info("deleting category")
+
+	if err := s.repo.DeleteCategory(ctx, id); err != nil {
+		log.Error("failed to delete category", slog.Any("err", err))
+		return fmt.Errorf("failed to delete category: %w", err)
+	}
+
+	log.Info("category deleted successfully")
+	return nil
+}
+
+// GetCategoryByID возвращает категорию по ID
+func (s *CategoryService) GetCategoryByID(ctx context.Context, id uuid.UUID) (*dto.CategoryResponse, error) {
+	const op = "category_service.GetCategoryByID"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("category_id", id.String()),
+	)
+
+	log.Info("getting category")
+
+	response, err := s.toCategoryResponse(ctx, id)
+	if err != nil {
+		log.Error("failed to get category", slog.Any("err", err))
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// ListCategories возвращает все категории плоским списком, иерархия задается через parent_id
+func (s *CategoryService) ListCategories(ctx context.Context) ([]dto.CategoryResponse, error) {
+	const op = "category_service.ListCategories"
+	log := s.log.With(
+		slog.String("op", op),
+	)
+
+	log.Info("listing categories")
+
+	categories, err := s.repo.ListCategories(ctx)
+	if err != nil {
+		log.Error("failed to list categories", slog.Any("err", err))
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+
+	response := make([]dto.CategoryResponse, 0, len(categories))
+	for _, category := range categories {
+		response = append(response, mapToCategoryResponse(category))
+	}
+
+	log.Info("categories listed successfully", slog.Int("count", len(response)))
+	return response, nil
+}
+
+// checkParent проверяет, что новый родитель существует и не находится в поддереве категории
+func (s *CategoryService) checkParent(ctx context.Context, categoryID, parentID uuid.UUID) error {
+	current := parentID
+	for depth := 0; depth < maxCategoryDepth; depth++ {
+		if current == categoryID {
+			return apperr.InvalidField("parent_id", "cycle", "category cannot be moved into its own subtree")
+		}
+
+		parent, err := s.repo.GetCategoryByID(ctx, current)
+		if err != nil {
+			return fmt.Errorf("failed to get parent category: %w", err)
+		}
+
+		if parent.ParentID == nil {
+			return nil
+		}
+		current = *parent.ParentID
+	}
+
+	return apperr.InvalidField("parent_id", "depth", "category tree is too deep")
+}
+
+func (s *CategoryService) toCategoryResponse(ctx context.Context, id uuid.UUID) (*dto.CategoryResponse, error) {
+	category, err := s.repo.GetCategoryByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category: %w", err)
+	}
+
+	response := mapToCategoryResponse(category)
+	return &response, nil
+}
+
+func mapToCategoryResponse(category models.BlogCategory) dto.CategoryResponse {
+	return dto.CategoryResponse{
+		ID:          category.ID,
+		ParentID:    category.ParentID,
+		Name:        category.Name,
+		Slug:        category.Slug,
+		Description: category.Description,
+		Position:    category.Position,
+		CreatedAt:   category.CreatedAt,
+		UpdatedAt:   category.UpdatedAt,
+	}
+}
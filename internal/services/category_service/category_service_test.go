@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"premium_caste/internal/domain/models"
+	"premium_caste/internal/transport/http/dto"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockCategoryRepository реализация мок-репозитория
+type MockCategoryRepository struct {
+	mock.Mock
+}
+
+func (m *MockCategoryRepository) CreateCategory(ctx context.Context, category models.BlogCategory) (uuid.UUID, error) {
+	args := m.Called(ctx, category)
+	return args.Get(0).(uuid.UUID), args.Error(1)
+}
+
+func (m *MockCategoryRepository) UpdateCategory(ctx context.Context, category models.BlogCategory) error {
+	args := m.Called(ctx, category)
+	return args.Error(0)
+}
+
+func (m *MockCategoryRepository) DeleteCategory(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockCategoryRepository) GetCategoryByID(ctx context.Context, id uuid.UUID) (models.BlogCategory, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(models.BlogCategory), args.Error(1)
+}
+
+func (m *MockCategoryRepository) GetCategoryBySlug(ctx context.Context, slug string) (models.BlogCategory, error) {
+	args := m.Called(ctx, slug)
+	return args.Get(0).(models.BlogCategory), args.Error(1)
+}
+
+func (m *MockCategoryRepository) ListCategories(ctx context.Context) ([]models.BlogCategory, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]models.BlogCategory), args.Error(1)
+}
+
+func TestCategoryService_CreateCategory(t *testing.T) {
+	ctx := context.Background()
+	log := slog.Default()
+
+	categoryID := uuid.New()
+	parentID := uuid.New()
+
+	tests := []struct {
+		name        string
+		req         dto.CreateCategoryRequest
+		mockSetup   func(m *MockCategoryRepository)
+		wantError   bool
+		expectedErr string
+	}{
+		{
+			name: "successful create with generated slug",
+			req:  dto.CreateCategoryRequest{Name: "Travel Notes"},
+			mockSetup: func(m *MockCategoryRepository) {
+				m.On("CreateCategory", ctx, models.BlogCategory{Name: "Travel Notes", Slug: "travel-notes"}).
+					Return(categoryID, nil).Once()
+				m.On("GetCategoryByID", ctx, categoryID).
+					Return(models.BlogCategory{ID: categoryID, Name: "Travel Notes", Slug: "travel-notes"}, nil).Once()
+			},
+			wantError: false,
+		},
+		{
+			name:        "empty name",
+			req:         dto.CreateCategoryRequest{Name: "   "},
+			mockSetup:   func(m *MockCategoryRepository) {},
+			wantError:   true,
+			expectedErr: "category name is required",
+		},
+		{
+			name: "parent not found",
+			req:  dto.CreateCategoryRequest{Name: "Child", ParentID: &parentID},
+			mockSetup: func(m *MockCategoryRepository) {
+				m.On("GetCategoryByID", ctx, parentID).
+					Return(models.BlogCategory{}, errors.New("not found")).Once()
+			},
+			wantError:   true,
+			expectedErr: "failed to get parent category",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockCategoryRepository)
+			service := NewCategoryService(log, mockRepo)
+			tt.mockSetup(mockRepo)
+
+			resp, err := service.CreateCategory(ctx, tt.req)
+
+			if tt.wantError {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+				assert.Nil(t, resp)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, categoryID, resp.ID)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCategoryService_UpdateCategory(t *testing.T) {
+	ctx := context.Background()
+	log := slog.Default()
+
+	rootID := uuid.New()
+	childID := uuid.New()
+	grandchildID := uuid.New()
+	otherID := uuid.New()
+
+	root := models.BlogCategory{ID: rootID, Name: "Root", Slug: "root"}
+	child := models.BlogCategory{ID: childID, ParentID: &rootID, Name: "Child", Slug: "child"}
+	grandchild := models.BlogCategory{ID: grandchildID, ParentID: &childID, Name: "Grandchild", Slug: "grandchild"}
+	other := models.BlogCategory{ID: otherID, Name: "Other", Slug: "other"}
+
+	tests := []struct {
+		name        string
+		id          uuid.UUID
+		req         dto.UpdateCategoryRequest
+		mockSetup   func(m *MockCategoryRepository)
+		wantError   bool
+		expectedErr string
+	}{
+		{
+			name: "move under another root",
+			id:   childID,
+			req:  dto.UpdateCategoryRequest{ParentID: &otherID},
+			mockSetup: func(m *MockCategoryRepository) {
+				m.On("GetCategoryByID", ctx, childID).Return(child, nil)
+				m.On("GetCategoryByID", ctx, otherID).Return(other, nil).Once()
+				updated := child
+				updated.ParentID = &otherID
+				m.On("UpdateCategory", ctx, updated).Return(nil).Once()
+			},
+			wantError: false,
+		},
+		{
+			name: "move into own subtree",
+			id:   rootID,
+			req:  dto.UpdateCategoryRequest{ParentID: &grandchildID},
+			mockSetup: func(m *MockCategoryRepository) {
+				m.On("GetCategoryByID", ctx, rootID).Return(root, nil).Once()
+				m.On("GetCategoryByID", ctx, grandchildID).Return(grandchild, nil).Once()
+				m.On("GetCategoryByID", ctx, childID).Return(child, nil).Once()
+			},
+			wantError:   true,
+			expectedErr: "own subtree",
+		},
+		{
+			name: "make category root",
+			id:   childID,
+			req:  dto.UpdateCategoryRequest{ParentID: &uuid.Nil},
+			mockSetup: func(m *MockCategoryRepository) {
+				m.On("GetCategoryByID", ctx, childID).Return(child, nil)
+				updated := child
+				updated.ParentID = nil
+				m.On("UpdateCategory", ctx, updated).Return(nil).Once()
+			},
+			wantError: false,
+		},
+		{
+			name: "repository error",
+			id:   childID,
+			req:  dto.UpdateCategoryRequest{Name: stringPtr("Renamed")},
+			mockSetup: func(m *MockCategoryRepository) {
+				m.On("GetCategoryByID", ctx, childID).Return(child, nil).Once()
+				updated := child
+				updated.Name = "Renamed"
+				m.On("UpdateCategory", ctx, updated).Return(errors.New("db error")).Once()
+			},
+			wantError:   true,
+			expectedErr: "failed to update category",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockCategoryRepository)
+			service := NewCategoryService(log, mockRepo)
+			tt.mockSetup(mockRepo)
+
+			resp, err := service.UpdateCategory(ctx, tt.id, tt.req)
+
+			if tt.wantError {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.expectedErr)
+				assert.Nil(t, resp)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, resp)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,309 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"premium_caste/internal/domain/apperr"
+	"premium_caste/internal/domain/models"
+	"premium_caste/internal/lib/pagination"
+	"premium_caste/internal/repository"
+	"premium_caste/internal/storage"
+	"premium_caste/internal/transport/http/dto"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RateLimit не больше Limit комментариев пользователя за Window
+type RateLimit struct {
+	Limit  int
+	Window time.Duration
+}
+
+type CommentService struct {
+	log       *slog.Logger
+	repo      repository.CommentRepository
+	now       func() time.Time
+	rateLimit atomic.Pointer[RateLimit]
+}
+
+func NewCommentService(log *slog.Logger, repo repository.CommentRepository, rateLimit RateLimit) *CommentService {
+	s := &CommentService{
+		log:  log,
+		repo: repo,
+		now:  time.Now,
+	}
+	s.SetRateLimit(rateLimit)
+
+	return s
+}
+
+// SetRateLimit меняет лимит комментариев без перезапуска сервера
+func (s *CommentService) SetRateLimit(rateLimit RateLimit) {
+	s.rateLimit.Store(&rateLimit)
+}
+
+// CreateComment добавляет комментарий к опубликованному посту. Новый комментарий
+// попадает в очередь модерации.
+func (s *CommentService) CreateComment(ctx context.Context, postID, userID uuid.UUID, req dto.CreateCommentRequest) (*dto.CommentResponse, error) {
+	const op = "comment_service.CreateComment"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("post_id", postID.String()),
+		slog.String("user_id", userID.String()),
+	)
+
+	log.Info("creating comment")
+
+	content := strings.TrimSpace(req.Content)
+	if content == "" {
+		log.Error("comment content is required")
+		return nil, apperr.InvalidField("content", "required", "comment content is required")
+	}
+
+	settings, err := s.repo.GetPostCommentSettings(ctx, postID)
+	if err != nil {
+		log.Error("failed to get post", slog.Any("err", err))
+		return nil, fmt.Errorf("failed to get post: %w", err)
+	}
+
+	// Комментировать можно только опубликованные посты
+	if settings.Status != "published" {
+		log.Warn("post is not published", slog.String("status", settings.Status))
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrPostNotFound)
+	}
+	if settings.CommentsClosed {
+		log.Warn("comments are closed")
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrCommentsClosed)
+	}
+
+	parentID := req.ParentID
+	if parentID != nil && *parentID == uuid.Nil {
+		parentID = nil
+	}
+	if parentID != nil {
+		parent, err := s.repo.GetCommentByID(ctx, *parentID)
+		if errors.Is(err, storage.ErrCommentNotFound) {
+			log.Warn("parent comment not found", slog.String("parent_id", parentID.String()))
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrInvalidCommentParent.WithDetail("parent comment not found"))
+		}
+		if err != nil {
+			log.Error("failed to get parent comment", slog.Any("err", err))
+			return nil, fmt.Errorf("failed to get parent comment: %w", err)
+		}
+		if parent.PostID != postID {
+			log.Warn("parent comment belongs to another post", slog.String("parent_id", parent.ID.String()))
+			return nil, fmt.Errorf("%s: %w", op, storage.ErrInvalidCommentParent.WithDetail("parent comment belongs to another post"))
+		}
+	}
+
+	// Лимит проверяется в той же операции, что и вставка
+	rateLimit := s.rateLimit.Load()
+	id, err := s.repo.CreateCommentWithinLimit(ctx, models.Comment{
+		PostID:   postID,
+		UserID:   userID,
+		ParentID: parentID,
+		Content:  content,
+		Status:   models.CommentStatusPending,
+	}, s.now().Add(-rateLimit.Window), rateLimit.Limit)
+	if errors.Is(err, storage.ErrCommentRateLimited) {
+		log.Warn("comment rate limit exceeded", slog.Int("limit", rateLimit.Limit))
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrCommentRateLimited)
+	}
+	if err != nil {
+		log.Error("failed to create comment", slog.Any("err", err))
+		return nil, fmt.Errorf("failed to create comment: %w", err)
+	}
+
+	comment, err := s.repo.GetCommentByID(ctx, id)
+	if err != nil {
+		log.Error("failed to get created comment", slog.Any("err", err))
+		return nil, fmt.Errorf("failed to get created comment: %w", err)
+	}
+
+	log.Info("comment created", slog.String("comment_id", id.String()))
+
+	response := mapToCommentResponse(comment)
+	return &response, nil
+}
+
+// ListPostComments возвращает одобренные комментарии поста в виде дерева
+func (s *CommentService) ListPostComments(ctx context.Context, postID uuid.UUID) ([]dto.CommentResponse, error) {
+	const op = "comment_service.ListPostComments"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("post_id", postID.String()),
+	)
+
+	settings, err := s.repo.GetPostCommentSettings(ctx, postID)
+	if err != nil {
+		log.Error("failed to get post", slog.Any("err", err))
+		return nil, fmt.Errorf("failed to get post: %w", err)
+	}
+	if settings.Status != "published" {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrPostNotFound)
+	}
+
+	comments, err := s.repo.ListPostComments(ctx, postID)
+	if err != nil {
+		log.Error("failed to list comments", slog.Any("err", err))
+		return nil, fmt.Errorf("failed to list comments: %w", err)
+	}
+
+	return buildCommentTree(comments), nil
+}
+
+// ListComments возвращает плоский список комментариев для модерации
+func (s *CommentService) ListComments(ctx context.Context, filter dto.CommentFilter, page, perPage int) (*dto.CommentListResponse, error) {
+	const op = "comment_service.ListComments"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("status", filter.Status),
+	)
+
+	if filter.Status != "" && !validCommentStatus(filter.Status) {
+		log.Warn("invalid comment status")
+		return nil, apperr.InvalidField("status", "oneof", "invalid comment status").WithDetail("%s", filter.Status)
+	}
+
+	page, perPage = pagination.Normalize(page, perPage)
+
+	repoFilter := models.CommentFilter{Status: filter.Status}
+	if filter.Cursor != "" {
+		cursor, err := pagination.DecodeCursor(filter.Cursor, models.CommentSortCreated)
+		if err != nil {
+			log.Warn("invalid cursor", slog.Any("err", err))
+			return nil, err
+		}
+		repoFilter.After = cursor
+	}
+
+	comments, total, err := s.repo.ListComments(ctx, repoFilter, page, perPage)
+	if err != nil {
+		log.Error("failed to list comments", slog.Any("err", err))
+		return nil, fmt.Errorf("failed to list comments: %w", err)
+	}
+
+	response := &dto.CommentListResponse{
+		Comments:   make([]dto.CommentResponse, 0, len(comments)),
+		Meta:       pagination.NewMeta(total, page, perPage),
+		TotalCount: total,
+	}
+	for _, comment := range comments {
+		response.Comments = append(response.Comments, mapToCommentResponse(comment))
+	}
+
+	response.SetNextCursor(len(comments), repoFilter.After != nil, func() pagination.Cursor {
+		last := comments[len(comments)-1]
+		return pagination.Cursor{Sort: models.CommentSortCreated, ID: last.ID, Time: last.CreatedAt}
+	})
+
+	return response, nil
+}
+
+// ModerateComment меняет статус комментария от имени модератора
+func (s *CommentService) ModerateComment(ctx context.Context, id, moderatorID uuid.UUID, status string) (*dto.CommentResponse, error) {
+	const op = "comment_service.ModerateComment"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("comment_id", id.String()),
+		slog.String("status", status),
+	)
+
+	if !validCommentStatus(status) {
+		log.Warn("invalid comment status")
+		return nil, apperr.InvalidField("status", "oneof", "invalid comment status").WithDetail("%s", status)
+	}
+
+	if err := s.repo.UpdateCommentStatus(ctx, id, status, moderatorID); err != nil {
+		log.Error("failed to update comment status", slog.Any("err", err))
+		return nil, fmt.Errorf("failed to update comment status: %w", err)
+	}
+
+	comment, err := s.repo.GetCommentByID(ctx, id)
+	if err != nil {
+		log.Error("failed to get comment", slog.Any("err", err))
+		return nil, fmt.Errorf("failed to get comment: %w", err)
+	}
+
+	log.Info("comment moderated")
+
+	response := mapToCommentResponse(comment)
+	return &response, nil
+}
+
+// DeleteComment удаляет комментарий вместе с ответами
+func (s *CommentService) DeleteComment(ctx context.Context, id uuid.UUID) error {
+	const op = "comment_service.DeleteComment"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("comment_id", id.String()),
+	)
+
+	if err := s.repo.DeleteComment(ctx, id); err != nil {
+		log.Error("failed to delete comment", slog.Any("err", err))
+		return fmt.Errorf("failed to delete comment: %w", err)
+	}
+
+	log.Info("comment deleted")
+	return nil
+}
+
+func validCommentStatus(status string) bool {
+	switch status {
+	case models.CommentStatusPending, models.CommentStatusApproved,
+		models.CommentStatusSpam, models.CommentStatusRejected:
+		return true
+	}
+	return false
+}
+
+// buildCommentTree собирает дерево из упорядоченного по дате списка.
+// Ответы на скрытые комментарии в дерево не попадают.
+func buildCommentTree(comments []models.Comment) []dto.CommentResponse {
+	children := make(map[uuid.UUID][]models.Comment)
+	roots := make([]models.Comment, 0)
+	for _, comment := range comments {
+		if comment.ParentID == nil {
+			roots = append(roots, comment)
+			continue
+		}
+		children[*comment.ParentID] = append(children[*comment.ParentID], comment)
+	}
+
+	var build func(comment models.Comment) dto.CommentResponse
+	build = func(comment models.Comment) dto.CommentResponse {
+		response := mapToCommentResponse(comment)
+		for _, child := range children[comment.ID] {
+			response.Replies = append(response.Replies, build(child))
+		}
+		return response
+	}
+
+	tree := make([]dto.CommentResponse, 0, len(roots))
+	for _, root := range roots {
+		tree = append(tree, build(root))
+	}
+
+	return tree
+}
+
+func mapToCommentResponse(comment models.Comment) dto.CommentResponse {
+	return dto.CommentResponse{
+		ID:          comment.ID,
+		PostID:      comment.PostID,
+		UserID:      comment.UserID,
+		ParentID:    comment.ParentID,
+		AuthorName:  comment.AuthorName,
+		Content:     comment.Content,
+		Status:      comment.Status,
+		ModeratedBy: comment.ModeratedBy,
+		ModeratedAt: comment.ModeratedAt,
+		CreatedAt:   comment.CreatedAt,
+		UpdatedAt:   comment.UpdatedAt,
+	}
+}
@@ -0,0 +1,374 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"premium_caste/internal/domain/models"
+	"premium_caste/internal/lib/pagination"
+	"premium_caste/internal/storage"
+	"premium_caste/internal/transport/http/dto"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockCommentRepository реализация мок-репозитория
+type MockCommentRepository struct {
+	mock.Mock
+}
+
+func (m *MockCommentRepository) CreateComment(ctx context.Context, comment models.Comment) (uuid.UUID, error) {
+	args := m.Called(ctx, comment)
+	return args.Get(0).(uuid.UUID), args.Error(1)
+}
+
+func (m *MockCommentRepository) GetCommentByID(ctx context.Context, id uuid.UUID) (models.Comment, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(models.Comment), args.Error(1)
+}
+
+func (m *MockCommentRepository) ListPostComments(ctx context.Context, postID uuid.UUID) ([]models.Comment, error) {
+	args := m.Called(ctx, postID)
+	return args.Get(0).([]models.Comment), args.Error(1)
+}
+
+func (m *MockCommentRepository) ListComments(ctx context.Context, filter models.CommentFilter, page, perPage int) ([]models.Comment, int, error) {
+	args := m.Called(ctx, filter, page, perPage)
+	return args.Get(0).([]models.Comment), args.Int(1), args.Error(2)
+}
+
+func (m *MockCommentRepository) UpdateCommentStatus(ctx context.Context, id uuid.UUID, status string, moderatorID uuid.UUID) error {
+	args := m.Called(ctx, id, status, moderatorID)
+	return args.Error(0)
+}
+
+func (m *MockCommentRepository) DeleteComment(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockCommentRepository) CreateCommentWithinLimit(ctx context.Context, comment models.Comment, since time.Time, limit int) (uuid.UUID, error) {
+	args := m.Called(ctx, comment, since, limit)
+	return args.Get(0).(uuid.UUID), args.Error(1)
+}
+
+func (m *MockCommentRepository) GetPostCommentSettings(ctx context.Context, postID uuid.UUID) (models.PostCommentSettings, error) {
+	args := m.Called(ctx, postID)
+	return args.Get(0).(models.PostCommentSettings), args.Error(1)
+}
+
+var testRateLimit = RateLimit{Limit: 5, Window: 10 * time.Minute}
+
+func TestCommentService_CreateComment(t *testing.T) {
+	ctx := context.Background()
+	log := slog.Default()
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	since := now.Add(-testRateLimit.Window)
+	postID := uuid.New()
+	userID := uuid.New()
+	commentID := uuid.New()
+	parentID := uuid.New()
+	published := models.PostCommentSettings{Status: "published"}
+
+	tests := []struct {
+		name        string
+		req         dto.CreateCommentRequest
+		mockSetup   func(m *MockCommentRepository)
+		wantError   bool
+		expectedErr error
+	}{
+		{
+			name: "successful create goes to moderation",
+			req:  dto.CreateCommentRequest{Content: "  Great post!  "},
+			mockSetup: func(m *MockCommentRepository) {
+				m.On("GetPostCommentSettings", ctx, postID).Return(published, nil).Once()
+				m.On("CreateCommentWithinLimit", ctx, models.Comment{
+					PostID:  postID,
+					UserID:  userID,
+					Content: "Great post!",
+					Status:  models.CommentStatusPending,
+				}, since, testRateLimit.Limit).Return(commentID, nil).Once()
+				m.On("GetCommentByID", ctx, commentID).
+					Return(models.Comment{ID: commentID, PostID: postID, Status: models.CommentStatusPending}, nil).Once()
+			},
+			wantError: false,
+		},
+		{
+			name: "reply to comment of the same post",
+			req:  dto.CreateCommentRequest{ParentID: &parentID, Content: "Agree"},
+			mockSetup: func(m *MockCommentRepository) {
+				m.On("GetPostCommentSettings", ctx, postID).Return(published, nil).Once()
+				m.On("GetCommentByID", ctx, parentID).Return(models.Comment{ID: parentID, PostID: postID}, nil).Once()
+				m.On("CreateCommentWithinLimit", ctx, models.Comment{
+					PostID:   postID,
+					UserID:   userID,
+					ParentID: &parentID,
+					Content:  "Agree",
+					Status:   models.CommentStatusPending,
+				}, since, testRateLimit.Limit).Return(commentID, nil).Once()
+				m.On("GetCommentByID", ctx, commentID).
+					Return(models.Comment{ID: commentID, PostID: postID, ParentID: &parentID}, nil).Once()
+			},
+			wantError: false,
+		},
+		{
+			name: "parent from another post",
+			req:  dto.CreateCommentRequest{ParentID: &parentID, Content: "Agree"},
+			mockSetup: func(m *MockCommentRepository) {
+				m.On("GetPostCommentSettings", ctx, postID).Return(published, nil).Once()
+				m.On("GetCommentByID", ctx, parentID).Return(models.Comment{ID: parentID, PostID: uuid.New()}, nil).Once()
+			},
+			wantError:   true,
+			expectedErr: storage.ErrInvalidCommentParent,
+		},
+		{
+			name: "comments closed",
+			req:  dto.CreateCommentRequest{Content: "Hello"},
+			mockSetup: func(m *MockCommentRepository) {
+				m.On("GetPostCommentSettings", ctx, postID).
+					Return(models.PostCommentSettings{Status: "published", CommentsClosed: true}, nil).Once()
+			},
+			wantError:   true,
+			expectedErr: storage.ErrCommentsClosed,
+		},
+		{
+			name: "draft post",
+			req:  dto.CreateCommentRequest{Content: "Hello"},
+			mockSetup: func(m *MockCommentRepository) {
+				m.On("GetPostCommentSettings", ctx, postID).
+					Return(models.PostCommentSettings{Status: "draft"}, nil).Once()
+			},
+			wantError:   true,
+			expectedErr: storage.ErrPostNotFound,
+		},
+		{
+			name: "rate limited",
+			req:  dto.CreateCommentRequest{Content: "Hello"},
+			mockSetup: func(m *MockCommentRepository) {
+				m.On("GetPostCommentSettings", ctx, postID).Return(published, nil).Once()
+				m.On("CreateCommentWithinLimit", ctx, mock.AnythingOfType("models.Comment"), since, testRateLimit.Limit).
+					Return(uuid.Nil, fmt.Errorf("repository: %w", storage.ErrCommentRateLimited)).Once()
+			},
+			wantError:   true,
+			expectedErr: storage.ErrCommentRateLimited,
+		},
+		{
+			name:      "empty content",
+			req:       dto.CreateCommentRequest{Content: "   "},
+			mockSetup: func(m *MockCommentRepository) {},
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockCommentRepository)
+			tt.mockSetup(mockRepo)
+
+			service := NewCommentService(log, mockRepo, testRateLimit)
+			service.now = func() time.Time { return now }
+
+			result, err := service.CreateComment(ctx, postID, userID, tt.req)
+
+			if tt.wantError {
+				assert.Error(t, err)
+				if tt.expectedErr != nil {
+					assert.True(t, errors.Is(err, tt.expectedErr))
+				}
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.NotNil(t, result)
+				assert.Equal(t, commentID, result.ID)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCommentService_SetRateLimit(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	postID := uuid.New()
+	userID := uuid.New()
+
+	mockRepo := new(MockCommentRepository)
+	mockRepo.On("GetPostCommentSettings", ctx, postID).Return(models.PostCommentSettings{Status: "published"}, nil).Once()
+	mockRepo.On("CreateCommentWithinLimit", ctx, mock.AnythingOfType("models.Comment"), now.Add(-time.Hour), 2).
+		Return(uuid.Nil, storage.ErrCommentRateLimited).Once()
+
+	service := NewCommentService(slog.Default(), mockRepo, testRateLimit)
+	service.now = func() time.Time { return now }
+	service.SetRateLimit(RateLimit{Limit: 2, Window: time.Hour})
+
+	_, err := service.CreateComment(ctx, postID, userID, dto.CreateCommentRequest{Content: "Hello"})
+	assert.ErrorIs(t, err, storage.ErrCommentRateLimited)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCommentService_ListPostComments(t *testing.T) {
+	ctx := context.Background()
+	log := slog.Default()
+
+	postID := uuid.New()
+	rootID := uuid.New()
+	replyID := uuid.New()
+	hiddenParentID := uuid.New()
+
+	mockRepo := new(MockCommentRepository)
+	mockRepo.On("GetPostCommentSettings", ctx, postID).
+		Return(models.PostCommentSettings{Status: "published"}, nil).Once()
+	mockRepo.On("ListPostComments", ctx, postID).Return([]models.Comment{
+		{ID: rootID, PostID: postID, Content: "root"},
+		{ID: replyID, PostID: postID, ParentID: &rootID, Content: "reply"},
+		{ID: uuid.New(), PostID: postID, ParentID: &hiddenParentID, Content: "orphan"},
+	}, nil).Once()
+
+	service := NewCommentService(log, mockRepo, testRateLimit)
+	tree, err := service.ListPostComments(ctx, postID)
+
+	assert.NoError(t, err)
+	assert.Len(t, tree, 1)
+	assert.Equal(t, rootID, tree[0].ID)
+	assert.Len(t, tree[0].Replies, 1)
+	assert.Equal(t, replyID, tree[0].Replies[0].ID)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCommentService_ListComments(t *testing.T) {
+	ctx := context.Background()
+
+	now := time.Now()
+	comments := []models.Comment{
+		{ID: uuid.New(), Status: models.CommentStatusPending, CreatedAt: now},
+		{ID: uuid.New(), Status: models.CommentStatusPending, CreatedAt: now.Add(-time.Minute)},
+	}
+	last := pagination.Cursor{Sort: models.CommentSortCreated, ID: comments[1].ID, Time: comments[1].CreatedAt}
+
+	tests := []struct {
+		name       string
+		filter     dto.CommentFilter
+		mockSetup  func(m *MockCommentRepository)
+		wantTotal  int
+		wantCursor string
+		wantErr    error
+	}{
+		{
+			name:   "first page with next cursor",
+			filter: dto.CommentFilter{Status: models.CommentStatusPending},
+			mockSetup: func(m *MockCommentRepository) {
+				m.On("ListComments", ctx, models.CommentFilter{Status: models.CommentStatusPending}, 1, 2).
+					Return(comments, 5, nil).Once()
+			},
+			wantTotal:  5,
+			wantCursor: last.Encode(),
+		},
+		{
+			name:   "page by cursor",
+			filter: dto.CommentFilter{Cursor: last.Encode()},
+			mockSetup: func(m *MockCommentRepository) {
+				m.On("ListComments", ctx, mock.MatchedBy(func(f models.CommentFilter) bool {
+					return f.After != nil && f.After.ID == last.ID
+				}), 1, 2).Return(comments[:1], 5, nil).Once()
+			},
+			wantTotal: 5,
+		},
+		{
+			name:    "cursor of another list",
+			filter:  dto.CommentFilter{Cursor: pagination.Cursor{Sort: models.UserSortRegistered, ID: uuid.New()}.Encode()},
+			wantErr: storage.ErrInvalidCursor,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockCommentRepository)
+			if tt.mockSetup != nil {
+				tt.mockSetup(mockRepo)
+			}
+
+			service := NewCommentService(slog.Default(), mockRepo, testRateLimit)
+			resp, err := service.ListComments(ctx, tt.filter, 1, 2)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantTotal, resp.Total)
+			assert.Equal(t, tt.wantTotal, resp.TotalCount)
+			assert.Equal(t, 3, resp.TotalPages)
+			assert.Equal(t, tt.wantCursor, resp.NextCursor)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCommentService_ModerateComment(t *testing.T) {
+	ctx := context.Background()
+	log := slog.Default()
+
+	commentID := uuid.New()
+	moderatorID := uuid.New()
+
+	tests := []struct {
+		name      string
+		status    string
+		mockSetup func(m *MockCommentRepository)
+		wantError bool
+	}{
+		{
+			name:   "approve",
+			status: models.CommentStatusApproved,
+			mockSetup: func(m *MockCommentRepository) {
+				m.On("UpdateCommentStatus", ctx, commentID, models.CommentStatusApproved, moderatorID).Return(nil).Once()
+				m.On("GetCommentByID", ctx, commentID).
+					Return(models.Comment{ID: commentID, Status: models.CommentStatusApproved}, nil).Once()
+			},
+			wantError: false,
+		},
+		{
+			name:      "invalid status",
+			status:    "deleted",
+			mockSetup: func(m *MockCommentRepository) {},
+			wantError: true,
+		},
+		{
+			name:   "comment not found",
+			status: models.CommentStatusSpam,
+			mockSetup: func(m *MockCommentRepository) {
+				m.On("UpdateCommentStatus", ctx, commentID, models.CommentStatusSpam, moderatorID).
+					Return(storage.ErrCommentNotFound).Once()
+			},
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockCommentRepository)
+			tt.mockSetup(mockRepo)
+
+			service := NewCommentService(log, mockRepo, testRateLimit)
+			result, err := service.ModerateComment(ctx, commentID, moderatorID, tt.status)
+
+			if tt.wantError {
+				assert.Error(t, err)
+				assert.Nil(t, result)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.status, result.Status)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,358 @@
+package services
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"premium_caste/internal/repository"
+	"premium_caste/internal/storage"
+	"premium_caste/internal/transport/http/dto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// feedSize количество последних постов в RSS и Atom лентах
+	feedSize = 20
+	// sitemapPageSize количество URL в одном файле sitemap
+	sitemapPageSize = 1000
+	// listBatchSize максимальный размер страницы, который отдают сервисы списков
+	listBatchSize = 100
+
+	sitemapPosts     = "posts"
+	sitemapGalleries = "galleries"
+
+	statusPublished = "published"
+)
+
+// PostLister источник опубликованных постов
+type PostLister interface {
+	ListPosts(ctx context.Context, filter dto.BlogPostFilter, page, perPage int) (*dto.BlogPostListResponse, error)
+}
+
+// GalleryLister источник опубликованных галерей
+type GalleryLister interface {
+	ListGalleries(ctx context.Context, filter dto.GalleryFilter, page, perPage int) (*dto.GalleryListResponse, error)
+}
+
+// SiteInfo публичные данные сайта, используемые в лентах
+type SiteInfo struct {
+	BaseURL     string
+	Title       string
+	Description string
+}
+
+type FeedService struct {
+	log       *slog.Logger
+	posts     PostLister
+	galleries GalleryLister
+	cache     repository.FeedCacheRepository
+	site      SiteInfo
+	ttl       time.Duration
+}
+
+func NewFeedService(log *slog.Logger, posts PostLister, galleries GalleryLister, cache repository.FeedCacheRepository, site SiteInfo, ttl time.Duration) *FeedService {
+	site.BaseURL = strings.TrimRight(site.BaseURL, "/")
+
+	return &FeedService{
+		log:       log,
+		posts:     posts,
+		galleries: galleries,
+		cache:     cache,
+		site:      site,
+		ttl:       ttl,
+	}
+}
+
+// RSS возвращает ленту последних постов в формате RSS 2.0
+func (s *FeedService) RSS(ctx context.Context) ([]byte, error) {
+	const op = "feed_service.RSS"
+
+	return s.cached(ctx, op, "rss", func() ([]byte, error) {
+		posts, err := s.latestPosts(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		channel := rssChannel{
+			Title:         s.site.Title,
+			Link:          s.site.BaseURL + "/",
+			Description:   s.site.Description,
+			LastBuildDate: time.Now().UTC().Format(time.RFC1123Z),
+			AtomLink:      rssAtomLink{Href: s.site.BaseURL + "/feed.xml", Rel: "self", Type: "application/rss+xml"},
+		}
+		for _, post := range posts {
+			link := s.postURL(post.Slug)
+			channel.Items = append(channel.Items, rssItem{
+				Title:       post.Title,
+				Link:        link,
+				GUID:        rssGUID{Value: link, IsPermaLink: true},
+				PubDate:     postDate(post).UTC().Format(time.RFC1123Z),
+				Description: post.Excerpt,
+			})
+		}
+
+		return marshalXML(rssFeed{Version: "2.0", AtomNS: atomNS, Channel: channel})
+	})
+}
+
+// Atom возвращает ленту последних постов в формате Atom
+func (s *FeedService) Atom(ctx context.Context) ([]byte, error) {
+	const op = "feed_service.Atom"
+
+	return s.cached(ctx, op, "atom", func() ([]byte, error) {
+		posts, err := s.latestPosts(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		feed := atomFeed{
+			Title:    s.site.Title,
+			Subtitle: s.site.Description,
+			ID:       s.site.BaseURL + "/",
+			Updated:  time.Now().UTC().Format(time.RFC3339),
+			Author:   atomAuthor{Name: s.site.Title},
+			Links: []atomLink{
+				{Href: s.site.BaseURL + "/"},
+				{Href: s.site.BaseURL + "/atom.xml", Rel: "self"},
+			},
+		}
+		if len(posts) > 0 {
+			feed.Updated = posts[0].UpdatedAt.UTC().Format(time.RFC3339)
+		}
+		for _, post := range posts {
+			feed.Entries = append(feed.Entries, atomEntry{
+				Title:     post.Title,
+				ID:        "urn:uuid:" + post.ID.String(),
+				Link:      atomLink{Href: s.postURL(post.Slug)},
+				Published: postDate(post).UTC().Format(time.RFC3339),
+				Updated:   post.UpdatedAt.UTC().Format(time.RFC3339),
+				Summary:   post.Excerpt,
+			})
+		}
+
+		return marshalXML(feed)
+	})
+}
+
+// SitemapIndex возвращает индекс sitemap со ссылками на страницы постов и галерей
+func (s *FeedService) SitemapIndex(ctx context.Context) ([]byte, error) {
+	const op = "feed_service.SitemapIndex"
+
+	return s.cached(ctx, op, "sitemap", func() ([]byte, error) {
+		posts, err := s.posts.ListPosts(ctx, dto.BlogPostFilter{Status: statusPublished}, 1, 1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count posts: %w", err)
+		}
+
+		galleries, err := s.galleries.ListGalleries(ctx, dto.GalleryFilter{Status: statusPublished}, 1, 1)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count galleries: %w", err)
+		}
+
+		sections := []struct {
+			name  string
+			total int
+		}{
+			{sitemapPosts, posts.Total},
+			{sitemapGalleries, galleries.Total},
+		}
+
+		index := sitemapIndex{XMLNS: sitemapNS}
+		for _, section := range sections {
+			for page := 1; page <= pagesCount(section.total); page++ {
+				index.Sitemaps = append(index.Sitemaps, sitemapRef{
+					Loc: s.site.BaseURL + "/sitemaps/" + sitemapFileName(section.name, page),
+				})
+			}
+		}
+
+		return marshalXML(index)
+	})
+}
+
+// Sitemap возвращает страницу sitemap по имени файла из индекса, например posts-2.xml
+func (s *FeedService) Sitemap(ctx context.Context, name string) ([]byte, error) {
+	const op = "feed_service.Sitemap"
+
+	section, page, ok := parseSitemapFileName(name)
+	if !ok || (section != sitemapPosts && section != sitemapGalleries) || page < 1 {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrSitemapNotFound)
+	}
+
+	return s.cached(ctx, op, "sitemap:"+sitemapFileName(section, page), func() ([]byte, error) {
+		var (
+			urls []sitemapURL
+			err  error
+		)
+		if section == sitemapPosts {
+			urls, err = s.postURLs(ctx, page)
+		} else {
+			urls, err = s.galleryURLs(ctx, page)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if len(urls) == 0 && page > 1 {
+			return nil, storage.ErrSitemapNotFound
+		}
+
+		return marshalXML(urlSet{XMLNS: sitemapNS, URLs: urls})
+	})
+}
+
+// Invalidate сбрасывает кэш лент и sitemap. Вызывается при изменении постов и галерей
+func (s *FeedService) Invalidate(ctx context.Context) error {
+	const op = "feed_service.Invalidate"
+
+	if err := s.cache.Invalidate(ctx); err != nil {
+		s.log.Error("failed to invalidate feed cache", slog.String("op", op), slog.Any("err", err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// sitemapFileName формирует имя файла страницы sitemap, например posts-1.xml
+func sitemapFileName(section string, page int) string {
+	return section + "-" + strconv.Itoa(page) + ".xml"
+}
+
+// parseSitemapFileName разбирает имя файла страницы sitemap
+func parseSitemapFileName(name string) (string, int, bool) {
+	base, ok := strings.CutSuffix(name, ".xml")
+	if !ok {
+		return "", 0, false
+	}
+
+	idx := strings.LastIndex(base, "-")
+	if idx <= 0 {
+		return "", 0, false
+	}
+
+	page, err := strconv.Atoi(base[idx+1:])
+	if err != nil {
+		return "", 0, false
+	}
+
+	return base[:idx], page, true
+}
+
+func (s *FeedService) cached(ctx context.Context, op, key string, build func() ([]byte, error)) ([]byte, error) {
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("key", key),
+	)
+
+	// Недоступный кэш не должен ломать ленты, поэтому ошибки только логируются
+	data, found, err := s.cache.Get(ctx, key)
+	if err != nil {
+		log.Warn("failed to read feed cache", slog.Any("err", err))
+	}
+	if found {
+		return data, nil
+	}
+
+	data, err = build()
+	if err != nil {
+		log.Error("failed to build feed", slog.Any("err", err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := s.cache.Set(ctx, key, data, s.ttl); err != nil {
+		log.Warn("failed to write feed cache", slog.Any("err", err))
+	}
+
+	return data, nil
+}
+
+func (s *FeedService) latestPosts(ctx context.Context) ([]dto.BlogPostResponse, error) {
+	list, err := s.posts.ListPosts(ctx, dto.BlogPostFilter{Status: statusPublished}, 1, feedSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list posts: %w", err)
+	}
+
+	return list.Posts, nil
+}
+
+func (s *FeedService) postURLs(ctx context.Context, page int) ([]sitemapURL, error) {
+	var urls []sitemapURL
+
+	first := (page-1)*sitemapPageSize/listBatchSize + 1
+	for batch := first; batch < first+sitemapPageSize/listBatchSize; batch++ {
+		list, err := s.posts.ListPosts(ctx, dto.BlogPostFilter{Status: statusPublished}, batch, listBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list posts: %w", err)
+		}
+
+		for _, post := range list.Posts {
+			urls = append(urls, sitemapURL{
+				Loc:     s.postURL(post.Slug),
+				LastMod: post.UpdatedAt.UTC().Format(time.RFC3339),
+			})
+		}
+
+		if batch*listBatchSize >= list.Total {
+			break
+		}
+	}
+
+	return urls, nil
+}
+
+func (s *FeedService) galleryURLs(ctx context.Context, page int) ([]sitemapURL, error) {
+	var urls []sitemapURL
+
+	first := (page-1)*sitemapPageSize/listBatchSize + 1
+	for batch := first; batch < first+sitemapPageSize/listBatchSize; batch++ {
+		list, err := s.galleries.ListGalleries(ctx, dto.GalleryFilter{Status: statusPublished}, batch, listBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list galleries: %w", err)
+		}
+
+		for _, gallery := range list.Galleries {
+			urls = append(urls, sitemapURL{
+				Loc:     s.site.BaseURL + "/gallery/" + gallery.Slug,
+				LastMod: gallery.UpdatedAt.UTC().Format(time.RFC3339),
+			})
+		}
+
+		if batch*listBatchSize >= list.Total {
+			break
+		}
+	}
+
+	return urls, nil
+}
+
+func (s *FeedService) postURL(slug string) string {
+	return s.site.BaseURL + "/blog/" + slug
+}
+
+func postDate(post dto.BlogPostResponse) time.Time {
+	if post.PublishedAt != nil {
+		return *post.PublishedAt
+	}
+	return post.CreatedAt
+}
+
+func pagesCount(total int) int {
+	pages := (total + sitemapPageSize - 1) / sitemapPageSize
+	if pages == 0 {
+		// Пустой раздел все равно публикуется одной страницей
+		return 1
+	}
+	return pages
+}
+
+func marshalXML(v any) ([]byte, error) {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal xml: %w", err)
+	}
+
+	return append([]byte(xml.Header), data...), nil
+}
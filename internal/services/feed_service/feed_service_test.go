@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"premium_caste/internal/lib/pagination"
+	"premium_caste/internal/storage"
+	"premium_caste/internal/transport/http/dto"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockPostLister struct {
+	mock.Mock
+}
+
+func (m *MockPostLister) ListPosts(ctx context.Context, filter dto.BlogPostFilter, page, perPage int) (*dto.BlogPostListResponse, error) {
+	args := m.Called(ctx, filter, page, perPage)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.BlogPostListResponse), args.Error(1)
+}
+
+type MockGalleryLister struct {
+	mock.Mock
+}
+
+func (m *MockGalleryLister) ListGalleries(ctx context.Context, filter dto.GalleryFilter, page, perPage int) (*dto.GalleryListResponse, error) {
+	args := m.Called(ctx, filter, page, perPage)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*dto.GalleryListResponse), args.Error(1)
+}
+
+type MockFeedCache struct {
+	mock.Mock
+}
+
+func (m *MockFeedCache) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	args := m.Called(ctx, key)
+	data, _ := args.Get(0).([]byte)
+	return data, args.Bool(1), args.Error(2)
+}
+
+func (m *MockFeedCache) Set(ctx context.Context, key string, data []byte, ttl time.Duration) error {
+	args := m.Called(ctx, key, data, ttl)
+	return args.Error(0)
+}
+
+func (m *MockFeedCache) Invalidate(ctx context.Context) error {
+	args := m.Called(ctx)
+	return args.Error(0)
+}
+
+var testSite = SiteInfo{BaseURL: "https://example.com/", Title: "Example", Description: "Example blog"}
+
+func TestFeedService_RSS(t *testing.T) {
+	ctx := context.Background()
+	log := slog.Default()
+
+	publishedAt := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	published := dto.BlogPostFilter{Status: "published"}
+	list := &dto.BlogPostListResponse{
+		Posts: []dto.BlogPostResponse{
+			{ID: uuid.New(), Title: "Hello & welcome", Slug: "hello", Excerpt: "First post", PublishedAt: &publishedAt, UpdatedAt: publishedAt},
+		},
+		Meta: pagination.Meta{Total: 1},
+	}
+
+	t.Run("builds and caches feed", func(t *testing.T) {
+		posts := new(MockPostLister)
+		cache := new(MockFeedCache)
+		posts.On("ListPosts", ctx, published, 1, feedSize).Return(list, nil).Once()
+		cache.On("Get", ctx, "rss").Return(nil, false, nil).Once()
+		cache.On("Set", ctx, "rss", mock.Anything, time.Hour).Return(nil).Once()
+
+		service := NewFeedService(log, posts, new(MockGalleryLister), cache, testSite, time.Hour)
+		data, err := service.RSS(ctx)
+
+		require.NoError(t, err)
+		body := string(data)
+		assert.Contains(t, body, `<rss version="2.0"`)
+		assert.Contains(t, body, "<link>https://example.com/blog/hello</link>")
+		assert.Contains(t, body, "Hello &amp; welcome")
+		assert.Contains(t, body, "Fri, 01 Mar 2024 10:00:00 +0000")
+		posts.AssertExpectations(t)
+		cache.AssertExpectations(t)
+	})
+
+	t.Run("served from cache", func(t *testing.T) {
+		posts := new(MockPostLister)
+		cache := new(MockFeedCache)
+		cache.On("Get", ctx, "rss").Return([]byte("<rss/>"), true, nil).Once()
+
+		service := NewFeedService(log, posts, new(MockGalleryLister), cache, testSite, time.Hour)
+		data, err := service.RSS(ctx)
+
+		require.NoError(t, err)
+		assert.Equal(t, "<rss/>", string(data))
+		posts.AssertNotCalled(t, "ListPosts")
+	})
+
+	t.Run("cache failure does not break feed", func(t *testing.T) {
+		posts := new(MockPostLister)
+		cache := new(MockFeedCache)
+		posts.On("ListPosts", ctx, published, 1, feedSize).Return(list, nil).Once()
+		cache.On("Get", ctx, "atom").Return(nil, false, errors.New("redis down")).Once()
+		cache.On("Set", ctx, "atom", mock.Anything, time.Hour).Return(errors.New("redis down")).Once()
+
+		service := NewFeedService(log, posts, new(MockGalleryLister), cache, testSite, time.Hour)
+		data, err := service.Atom(ctx)
+
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `<feed xmlns="http://www.w3.org/2005/Atom">`)
+		assert.Contains(t, string(data), "<updated>2024-03-01T10:00:00Z</updated>")
+	})
+}
+
+func TestFeedService_Sitemap(t *testing.T) {
+	ctx := context.Background()
+	log := slog.Default()
+
+	published := dto.BlogPostFilter{Status: "published"}
+	updatedAt := time.Date(2024, 4, 2, 8, 30, 0, 0, time.UTC)
+
+	t.Run("index pages by section size", func(t *testing.T) {
+		posts := new(MockPostLister)
+		galleries := new(MockGalleryLister)
+		cache := new(MockFeedCache)
+		posts.On("ListPosts", ctx, published, 1, 1).
+			Return(&dto.BlogPostListResponse{Meta: pagination.Meta{Total: sitemapPageSize + 1}}, nil).Once()
+		galleries.On("ListGalleries", ctx, dto.GalleryFilter{Status: "published"}, 1, 1).Return(&dto.GalleryListResponse{}, nil).Once()
+		cache.On("Get", ctx, "sitemap").Return(nil, false, nil).Once()
+		cache.On("Set", ctx, "sitemap", mock.Anything, time.Hour).Return(nil).Once()
+
+		service := NewFeedService(log, posts, galleries, cache, testSite, time.Hour)
+		data, err := service.SitemapIndex(ctx)
+
+		require.NoError(t, err)
+		body := string(data)
+		assert.Contains(t, body, "<loc>https://example.com/sitemaps/posts-1.xml</loc>")
+		assert.Contains(t, body, "<loc>https://example.com/sitemaps/posts-2.xml</loc>")
+		assert.Contains(t, body, "<loc>https://example.com/sitemaps/galleries-1.xml</loc>")
+		assert.NotContains(t, body, "posts-3.xml")
+	})
+
+	t.Run("galleries page with lastmod", func(t *testing.T) {
+		galleries := new(MockGalleryLister)
+		cache := new(MockFeedCache)
+		galleries.On("ListGalleries", ctx, dto.GalleryFilter{Status: "published"}, 1, listBatchSize).
+			Return(&dto.GalleryListResponse{Galleries: []dto.GalleryResponse{{Slug: "summer", UpdatedAt: updatedAt}}, Meta: pagination.Meta{Total: 1}}, nil).Once()
+		cache.On("Get", ctx, "sitemap:galleries-1.xml").Return(nil, false, nil).Once()
+		cache.On("Set", ctx, "sitemap:galleries-1.xml", mock.Anything, time.Hour).Return(nil).Once()
+
+		service := NewFeedService(log, new(MockPostLister), galleries, cache, testSite, time.Hour)
+		data, err := service.Sitemap(ctx, "galleries-1.xml")
+
+		require.NoError(t, err)
+		assert.Contains(t, string(data), "<loc>https://example.com/gallery/summer</loc>")
+		assert.Contains(t, string(data), "<lastmod>2024-04-02T08:30:00Z</lastmod>")
+		galleries.AssertExpectations(t)
+	})
+
+	t.Run("unknown sitemap", func(t *testing.T) {
+		service := NewFeedService(log, new(MockPostLister), new(MockGalleryLister), new(MockFeedCache), testSite, time.Hour)
+
+		for _, name := range []string{"users-1.xml", "posts-0.xml", "posts.xml", "posts-1.txt"} {
+			_, err := service.Sitemap(ctx, name)
+			assert.ErrorIs(t, err, storage.ErrSitemapNotFound, name)
+		}
+	})
+}
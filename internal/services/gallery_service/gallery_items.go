@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"premium_caste/internal/domain/apperr"
+	"premium_caste/internal/domain/models"
+	"premium_caste/internal/transport/http/dto"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// AddGalleryItems добавляет изображения в конец галереи
+func (s *GalleryService) AddGalleryItems(ctx context.Context, galleryID uuid.UUID, req dto.AddGalleryItemsRequest) (*dto.GalleryResponse, error) {
+	const op = "service.GalleryService.AddGalleryItems"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("gallery_id", galleryID.String()),
+		slog.Int("count", len(req.Items)),
+	)
+
+	log.Info("adding gallery items")
+
+	if len(req.Items) == 0 {
+		return nil, apperr.InvalidField("items", "required", "items are required")
+	}
+
+	items, err := s.resolveGalleryItems(ctx, req.Items, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve gallery images: %w", err)
+	}
+
+	if err := s.repo.AddGalleryItems(ctx, galleryID, items); err != nil {
+		log.Error("failed to add gallery items", slog.Any("err", err))
+		return nil, fmt.Errorf("failed to add gallery items: %w", err)
+	}
+
+	return s.galleryAfterChange(ctx, galleryID)
+}
+
+// UpdateGalleryItem обновляет подпись и alt-текст изображения
+func (s *GalleryService) UpdateGalleryItem(ctx context.Context, galleryID, mediaID uuid.UUID, req dto.UpdateGalleryItemRequest) (*dto.GalleryResponse, error) {
+	const op = "service.GalleryService.UpdateGalleryItem"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("gallery_id", galleryID.String()),
+		slog.String("media_id", mediaID.String()),
+	)
+
+	log.Info("updating gallery item")
+
+	err := s.repo.UpdateGalleryItem(ctx, models.GalleryItem{
+		GalleryID: galleryID,
+		MediaID:   mediaID,
+		Caption:   strings.TrimSpace(req.Caption),
+		AltText:   strings.TrimSpace(req.AltText),
+	})
+	if err != nil {
+		log.Error("failed to update gallery item", slog.Any("err", err))
+		return nil, fmt.Errorf("failed to update gallery item: %w", err)
+	}
+
+	return s.galleryAfterChange(ctx, galleryID)
+}
+
+// RemoveGalleryItem убирает изображение из галереи. Сам медиафайл не удаляется
+func (s *GalleryService) RemoveGalleryItem(ctx context.Context, galleryID, mediaID uuid.UUID) (*dto.GalleryResponse, error) {
+	const op = "service.GalleryService.RemoveGalleryItem"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("gallery_id", galleryID.String()),
+		slog.String("media_id", mediaID.String()),
+	)
+
+	log.Info("removing gallery item")
+
+	if err := s.repo.RemoveGalleryItem(ctx, galleryID, mediaID); err != nil {
+		log.Error("failed to remove gallery item", slog.Any("err", err))
+		return nil, fmt.Errorf("failed to remove gallery item: %w", err)
+	}
+
+	return s.galleryAfterChange(ctx, galleryID)
+}
+
+// ReorderGalleryItems задает порядок изображений галереи
+func (s *GalleryService) ReorderGalleryItems(ctx context.Context, galleryID uuid.UUID, req dto.ReorderGalleryItemsRequest) (*dto.GalleryResponse, error) {
+	const op = "service.GalleryService.ReorderGalleryItems"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("gallery_id", galleryID.String()),
+	)
+
+	log.Info("reordering gallery items")
+
+	if err := s.repo.ReorderGalleryItems(ctx, galleryID, req.MediaIDs); err != nil {
+		log.Error("failed to reorder gallery items", slog.Any("err", err))
+		return nil, fmt.Errorf("failed to reorder gallery items: %w", err)
+	}
+
+	return s.galleryAfterChange(ctx, galleryID)
+}
+
+// SetGalleryCover делает обложкой одно из изображений галереи
+func (s *GalleryService) SetGalleryCover(ctx context.Context, galleryID uuid.UUID, req dto.SetGalleryCoverRequest) (*dto.GalleryResponse, error) {
+	const op = "service.GalleryService.SetGalleryCover"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("gallery_id", galleryID.String()),
+		slog.String("media_id", req.MediaID.String()),
+	)
+
+	log.Info("setting gallery cover")
+
+	if req.MediaID == uuid.Nil {
+		return nil, apperr.InvalidField("media_id", "required", "media_id is required")
+	}
+
+	if err := s.repo.SetGalleryCover(ctx, galleryID, req.MediaID); err != nil {
+		log.Error("failed to set gallery cover", slog.Any("err", err))
+		return nil, fmt.Errorf("failed to set gallery cover: %w", err)
+	}
+
+	return s.galleryAfterChange(ctx, galleryID)
+}
+
+// galleryAfterChange возвращает галерею после изменения ее изображений
+// и записывает новое состояние в журнал аудита
+func (s *GalleryService) galleryAfterChange(ctx context.Context, galleryID uuid.UUID) (*dto.GalleryResponse, error) {
+	response, err := s.GetGalleryByID(ctx, galleryID)
+	s.auditor.Record(ctx, models.AuditEntry{
+		Action:     models.AuditGalleryUpdate,
+		TargetType: models.AuditTargetGallery,
+		TargetID:   galleryID.String(),
+		After:      response,
+	})
+
+	return response, err
+}
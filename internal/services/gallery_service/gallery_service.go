@@ -5,23 +5,34 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"premium_caste/internal/domain/apperr"
 	"premium_caste/internal/domain/models"
+	"premium_caste/internal/lib/pagination"
+	"premium_caste/internal/lib/slug"
 	"premium_caste/internal/repository"
+	"premium_caste/internal/storage"
 	"premium_caste/internal/transport/http/dto"
 	"strings"
 
 	"github.com/google/uuid"
 )
 
+// Auditor журнал аудита
+type Auditor interface {
+	Record(ctx context.Context, entry models.AuditEntry)
+}
+
 type GalleryService struct {
-	log  *slog.Logger
-	repo repository.GalleryRepository
+	log     *slog.Logger
+	repo    repository.GalleryRepository
+	auditor Auditor
 }
 
-func NewGalleryService(log *slog.Logger, repo repository.GalleryRepository) *GalleryService {
+func NewGalleryService(log *slog.Logger, repo repository.GalleryRepository, auditor Auditor) *GalleryService {
 	return &GalleryService{
-		log:  log,
-		repo: repo,
+		log:     log,
+		repo:    repo,
+		auditor: auditor,
 	}
 }
 
@@ -38,27 +49,33 @@ func (s *GalleryService) CreateGallery(ctx context.Context, req dto.CreateGaller
 	// Валидация данных галереи
 	if req.Title == "" {
 		log.Error("title is required")
-		return uuid.Nil, fmt.Errorf("title is required")
+		return uuid.Nil, apperr.InvalidField("title", "required", "title is required")
 	}
 
-	if len(req.Images) == 0 {
-		return uuid.Nil, fmt.Errorf("images are required")
+	if len(req.Images) == 0 && len(req.Items) == 0 {
+		return uuid.Nil, apperr.InvalidField("images", "required", "images are required")
 	}
 
 	if req.AuthorID == uuid.Nil {
-		return uuid.Nil, fmt.Errorf("author_id is required")
+		return uuid.Nil, apperr.InvalidField("author_id", "required", "author_id is required")
+	}
+
+	items, err := s.resolveGalleryItems(ctx, req.Items, req.Images)
+	if err != nil {
+		log.Error("failed to resolve gallery images", slog.Any("err", err))
+		return uuid.Nil, fmt.Errorf("failed to resolve gallery images: %w", err)
 	}
 
 	gallery := models.Gallery{
-		Title:           req.Title,
-		Slug:            req.Slug,
-		Description:     req.Description,
-		Images:          req.Images,
-		CoverImageIndex: req.CoverImageIndex,
-		AuthorID:        req.AuthorID,
-		Status:          req.Status,
-		Tags:            req.Tags,
-		Metadata:        req.Metadata,
+		Title:        req.Title,
+		Slug:         gallerySlug(req.Slug, req.Title),
+		Description:  req.Description,
+		Items:        items,
+		CoverMediaID: coverMediaID(req.CoverMediaID, req.Items, req.CoverImageIndex, items),
+		AuthorID:     req.AuthorID,
+		Status:       req.Status,
+		Tags:         req.Tags,
+		Metadata:     req.Metadata,
 	}
 
 	id, err := s.repo.CreateGallery(ctx, gallery)
@@ -68,6 +85,15 @@ func (s *GalleryService) CreateGallery(ctx context.Context, req dto.CreateGaller
 	}
 
 	log.Info("gallery created successfully", slog.String("id", id.String()))
+
+	gallery.ID = id
+	s.auditor.Record(ctx, models.AuditEntry{
+		Action:     models.AuditGalleryCreate,
+		TargetType: models.AuditTargetGallery,
+		TargetID:   id.String(),
+		After:      s.mapToGalleryResponse(gallery),
+	})
+
 	return id, nil
 }
 
@@ -84,7 +110,7 @@ func (s *GalleryService) UpdateGallery(ctx context.Context, req dto.UpdateGaller
 	// Валидация данных галереи
 	if req.Title == "" {
 		log.Error("title is required")
-		return fmt.Errorf("title is required")
+		return apperr.InvalidField("title", "required", "title is required")
 	}
 
 	if req.Tags == nil {
@@ -95,28 +121,43 @@ func (s *GalleryService) UpdateGallery(ctx context.Context, req dto.UpdateGaller
 		req.Metadata = map[string]interface{}{}
 	}
 
-	if len(req.Images) == 0 {
-		req.Images = []string{}
+	// Изображения заменяются, только если переданы items или устаревший images
+	items, err := s.resolveGalleryItems(ctx, req.Items, req.Images)
+	if err != nil {
+		log.Error("failed to resolve gallery images", slog.Any("err", err))
+		return fmt.Errorf("failed to resolve gallery images: %w", err)
 	}
 
 	gallery := models.Gallery{
-		ID:          req.ID,
-		Title:       req.Title,
-		Slug:        req.Slug,
-		Images:      req.Images,
-		Description: req.Description,
-		Status:      req.Status,
-		Tags:        req.Tags,
-		Metadata:    req.Metadata,
+		ID:           req.ID,
+		Title:        req.Title,
+		Slug:         gallerySlug(req.Slug, req.Title),
+		Items:        items,
+		CoverMediaID: req.CoverMediaID,
+		Description:  req.Description,
+		Status:       req.Status,
+		Tags:         req.Tags,
+		Metadata:     req.Metadata,
+	}
+	if gallery.CoverMediaID == nil && req.Items == nil && items != nil {
+		gallery.CoverMediaID = coverMediaID(nil, nil, req.CoverImageIndex, items)
 	}
 
-	err := s.repo.UpdateGallery(ctx, gallery)
+	err = s.repo.UpdateGallery(ctx, gallery)
 	if err != nil {
 		log.Error("failed to update gallery", slog.Any("err", err))
 		return fmt.Errorf("failed to update gallery: %w", err)
 	}
 
 	log.Info("gallery updated successfully")
+
+	s.auditor.Record(ctx, models.AuditEntry{
+		Action:     models.AuditGalleryUpdate,
+		TargetType: models.AuditTargetGallery,
+		TargetID:   req.ID.String(),
+		After:      s.mapToGalleryResponse(gallery),
+	})
+
 	return nil
 }
 
@@ -134,7 +175,7 @@ func (s *GalleryService) UpdateGalleryStatus(ctx context.Context, id uuid.UUID,
 	// Валидация статуса
 	if status != "draft" && status != "published" && status != "archived" {
 		log.Error("invalid status", slog.String("status", status))
-		return fmt.Errorf("invalid status: %s", status)
+		return apperr.InvalidField("status", "oneof", "invalid status").WithDetail("%s", status)
 	}
 
 	err := s.repo.UpdateGalleryStatus(ctx, id, status)
@@ -144,6 +185,14 @@ func (s *GalleryService) UpdateGalleryStatus(ctx context.Context, id uuid.UUID,
 	}
 
 	log.Info("gallery status updated successfully")
+
+	s.auditor.Record(ctx, models.AuditEntry{
+		Action:     models.AuditGalleryStatus,
+		TargetType: models.AuditTargetGallery,
+		TargetID:   id.String(),
+		After:      map[string]string{"status": status},
+	})
+
 	return nil
 }
 
@@ -164,6 +213,13 @@ func (s *GalleryService) DeleteGallery(ctx context.Context, id uuid.UUID) error
 	}
 
 	log.Info("gallery deleted successfully")
+
+	s.auditor.Record(ctx, models.AuditEntry{
+		Action:     models.AuditGalleryDelete,
+		TargetType: models.AuditTargetGallery,
+		TargetID:   id.String(),
+	})
+
 	return nil
 }
 
@@ -187,69 +243,155 @@ func (s *GalleryService) GetGalleryByID(ctx context.Context, id uuid.UUID) (*dto
 	return s.mapToGalleryResponse(gallery), nil
 }
 
-// GetGalleries возвращает список галерей с пагинацией
-func (s *GalleryService) GetGalleries(
-	ctx context.Context,
-	statusFilter string,
-	page int,
-	perPage int,
-) ([]dto.GalleryResponse, int, error) {
-	const op = "service.GalleryService.GetGalleries"
+// GetGalleryBySlug возвращает галерею по slug. Если slug устарел после переименования галереи,
+// возвращается пустая галерея и актуальный slug для редиректа.
+// Без includeUnpublished неопубликованная галерея не отдается и ее slug не раскрывается
+// через редирект: ответ такой же, как для несуществующей галереи
+func (s *GalleryService) GetGalleryBySlug(ctx context.Context, gallerySlug string, includeUnpublished bool) (*dto.GalleryResponse, string, error) {
+	const op = "service.GalleryService.GetGalleryBySlug"
 	log := s.log.With(
 		slog.String("op", op),
-		slog.String("status_filter", statusFilter),
-		slog.Int("page", page),
-		slog.Int("per_page", perPage),
+		slog.String("slug", gallerySlug),
 	)
 
-	log.Info("getting galleries")
+	log.Info("getting gallery by slug")
 
-	galleries, total, err := s.repo.GetGalleries(ctx, statusFilter, page, perPage)
+	gallery, err := s.repo.GetGalleryBySlug(ctx, gallerySlug)
+	if err == nil {
+		if !includeUnpublished && gallery.Status != "published" {
+			log.Warn("gallery is not published", slog.String("status", gallery.Status))
+			return nil, "", fmt.Errorf("failed to get gallery: %w", storage.ErrGalleryNotFound)
+		}
+
+		log.Info("gallery retrieved successfully")
+		return s.mapToGalleryResponse(gallery), "", nil
+	}
+
+	if !errors.Is(err, storage.ErrGalleryNotFound) {
+		log.Error("failed to get gallery", slog.Any("err", err))
+		return nil, "", fmt.Errorf("failed to get gallery: %w", err)
+	}
+
+	// Ищем галерею среди прежних slug'ов
+	currentSlug, err := s.repo.GetGallerySlugRedirect(ctx, gallerySlug)
 	if err != nil {
-		log.Error("failed to get galleries", slog.Any("err", err))
-		return nil, 0, fmt.Errorf("failed to get galleries: %w", err)
+		log.Warn("gallery not found", slog.Any("err", err))
+		return nil, "", fmt.Errorf("failed to get gallery: %w", err)
 	}
 
-	// Преобразуем модели в DTO
-	var galleryResponses []dto.GalleryResponse
-	for _, gallery := range galleries {
-		galleryResponses = append(galleryResponses, *s.mapToGalleryResponse(gallery))
+	if !includeUnpublished {
+		moved, err := s.repo.GetGalleryBySlug(ctx, currentSlug)
+		if err != nil {
+			log.Warn("failed to get moved gallery", slog.Any("err", err))
+			return nil, "", fmt.Errorf("failed to get gallery: %w", err)
+		}
+		if moved.Status != "published" {
+			log.Warn("moved gallery is not published", slog.String("status", moved.Status))
+			return nil, "", fmt.Errorf("failed to get gallery: %w", storage.ErrGalleryNotFound)
+		}
 	}
 
-	log.Info("galleries retrieved successfully", slog.Int("total", total))
-	return galleryResponses, total, nil
+	log.Info("gallery slug moved", slog.String("current_slug", currentSlug))
+	return nil, currentSlug, nil
 }
 
-func (s *GalleryService) GetGalleriesByTags(ctx context.Context, tags []string, matchAll bool) ([]dto.GalleryResponse, error) {
-	const op = "service.TagService.GetGalleriesByTags"
+// CheckSlugAvailability проверяет, свободен ли slug для новой галереи
+func (s *GalleryService) CheckSlugAvailability(ctx context.Context, gallerySlug string) (*dto.SlugAvailabilityResponse, error) {
+	const op = "service.GalleryService.CheckSlugAvailability"
 	log := s.log.With(
 		slog.String("op", op),
-		slog.Any("tags", tags),
-		slog.Bool("match_all", matchAll),
+		slog.String("slug", gallerySlug),
 	)
 
-	log.Info("getting galleries by tags")
+	log.Info("checking slug availability")
+
+	if !slug.Valid(gallerySlug) {
+		log.Warn("invalid slug format")
+		return nil, apperr.InvalidField("slug", "invalid", "invalid slug format")
+	}
 
-	// 1. Получаем галереи из репозитория
-	galleries, err := s.repo.GetGalleriesByTags(ctx, tags, matchAll)
+	taken, err := s.repo.IsGallerySlugTaken(ctx, gallerySlug)
 	if err != nil {
-		log.Error("failed to get galleries by tags",
-			slog.Any("err", err),
-			slog.Any("input_tags", tags),
-		)
-		return nil, fmt.Errorf("%s: failed to get galleries: %w", op, err)
+		log.Error("failed to check slug", slog.Any("err", err))
+		return nil, fmt.Errorf("failed to check slug: %w", err)
 	}
 
-	// 2. Преобразуем модели в DTO
-	galleryResponses := make([]dto.GalleryResponse, 0, len(galleries))
+	return &dto.SlugAvailabilityResponse{Available: !taken}, nil
+}
+
+// ListGalleries возвращает страницу галерей с фильтрацией по статусу, тегам, автору,
+// дате и названию. Страница задается номером или курсором next_cursor предыдущего ответа
+func (s *GalleryService) ListGalleries(ctx context.Context, filter dto.GalleryFilter, page, perPage int) (*dto.GalleryListResponse, error) {
+	const op = "service.GalleryService.ListGalleries"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("status_filter", filter.Status),
+		slog.Int("page", page),
+		slog.Int("per_page", perPage),
+	)
+
+	log.Info("listing galleries")
+
+	page, perPage = pagination.Normalize(page, perPage)
+
+	if filter.DateFrom != nil && filter.DateTo != nil && filter.DateFrom.After(*filter.DateTo) {
+		log.Error("invalid date range")
+		return nil, apperr.InvalidField("date_from", "range", "date_from must be before date_to")
+	}
+
+	tags, err := normalizeTags(filter.Tags)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tags: %w", err)
+	}
+
+	repoFilter := models.GalleryFilter{
+		Status:       filter.Status,
+		Tags:         tags,
+		MatchAllTags: filter.MatchAllTags,
+		AuthorID:     filter.AuthorID,
+		DateFrom:     filter.DateFrom,
+		DateTo:       filter.DateTo,
+		Query:        strings.TrimSpace(filter.Query),
+		Sort:         filter.Sort,
+	}
+
+	switch repoFilter.Sort {
+	case "":
+		repoFilter.Sort = models.GallerySortCreated
+	case models.GallerySortCreated, models.GallerySortPublished, models.GallerySortTitle:
+	default:
+		return nil, apperr.InvalidField("sort", "oneof", "invalid sort").WithDetail("%s", filter.Sort)
+	}
+
+	if filter.Cursor != "" {
+		cursor, err := pagination.DecodeCursor(filter.Cursor, repoFilter.Sort)
+		if err != nil {
+			log.Warn("invalid cursor", slog.Any("err", err))
+			return nil, err
+		}
+		repoFilter.After = cursor
+	}
+
+	galleries, total, err := s.repo.ListGalleries(ctx, repoFilter, page, perPage)
+	if err != nil {
+		log.Error("failed to list galleries", slog.Any("err", err))
+		return nil, fmt.Errorf("failed to list galleries: %w", err)
+	}
+
+	response := &dto.GalleryListResponse{
+		Galleries: make([]dto.GalleryResponse, 0, len(galleries)),
+		Meta:      pagination.NewMeta(total, page, perPage),
+	}
 	for _, gallery := range galleries {
-		galleryResponses = append(galleryResponses, *s.mapToGalleryResponse(gallery))
+		response.Galleries = append(response.Galleries, *s.mapToGalleryResponse(gallery))
 	}
 
-	log.Info("galleries by tags retrieved successfully",
-		slog.Int("count", len(galleryResponses)),
-	)
-	return galleryResponses, nil
+	response.SetNextCursor(len(galleries), repoFilter.After != nil, func() pagination.Cursor {
+		return galleryCursor(galleries[len(galleries)-1], repoFilter.Sort)
+	})
+
+	log.Info("galleries listed successfully", slog.Int("total", total))
+	return response, nil
 }
 
 func (s *GalleryService) AddTags(ctx context.Context, galleryID string, tags []string) error {
@@ -284,6 +426,9 @@ func (s *GalleryService) AddTags(ctx context.Context, galleryID string, tags []s
 	}
 
 	log.Info("tags added successfully")
+
+	s.recordTagsChange(ctx, galleryID, map[string][]string{"added_tags": cleanedTags})
+
 	return nil
 }
 
@@ -313,6 +458,9 @@ func (s *GalleryService) RemoveTags(ctx context.Context, galleryID string, tagsT
 	}
 
 	log.Info("tags removed successfully")
+
+	s.recordTagsChange(ctx, galleryID, map[string][]string{"removed_tags": tagsToRemove})
+
 	return nil
 }
 
@@ -343,9 +491,22 @@ func (s *GalleryService) ReplaceTags(ctx context.Context, galleryID string, newT
 	}
 
 	log.Info("tags replaced successfully")
+
+	s.recordTagsChange(ctx, galleryID, map[string][]string{"tags": cleanedTags})
+
 	return nil
 }
 
+// recordTagsChange записывает изменение тегов галереи в журнал аудита
+func (s *GalleryService) recordTagsChange(ctx context.Context, galleryID string, change map[string][]string) {
+	s.auditor.Record(ctx, models.AuditEntry{
+		Action:     models.AuditGalleryUpdate,
+		TargetType: models.AuditTargetGallery,
+		TargetID:   galleryID,
+		After:      change,
+	})
+}
+
 func (s *GalleryService) GetTags(ctx context.Context, galleryID string) ([]string, error) {
 	const op = "service.GalleryService.GetTags"
 	log := s.log.With(
@@ -403,7 +564,7 @@ func (s *GalleryService) HasTags(ctx context.Context, galleryID string, tags []s
 // validateGalleryID проверяет корректность UUID галереи
 func validateGalleryID(id string) error {
 	if _, err := uuid.Parse(id); err != nil {
-		return errors.New("некорректный идентификатор галереи")
+		return apperr.InvalidField("id", "uuid", "некорректный идентификатор галереи")
 	}
 	return nil
 }
@@ -420,7 +581,7 @@ func normalizeTags(tags []string) ([]string, error) {
 		}
 
 		if len(trimmed) > 50 {
-			return nil, errors.New("тег не может быть длиннее 50 символов")
+			return nil, apperr.InvalidField("tags", "max", "тег не может быть длиннее 50 символов")
 		}
 
 		lower := strings.ToLower(trimmed)
@@ -433,15 +594,46 @@ func normalizeTags(tags []string) ([]string, error) {
 	return result, nil
 }
 
+// gallerySlug приводит переданный slug к допустимому виду или формирует его из названия
+func gallerySlug(requested, title string) string {
+	if slug.Valid(requested) {
+		return requested
+	}
+	if requested != "" {
+		if normalized := slug.Make(requested); normalized != "" {
+			return normalized
+		}
+	}
+	return slug.Make(title)
+}
+
 // mapToGalleryResponse преобразует модель галереи в DTO
 func (s *GalleryService) mapToGalleryResponse(gallery models.Gallery) *dto.GalleryResponse {
+	items := make([]dto.GalleryItemResponse, 0, len(gallery.Items))
+	for _, item := range gallery.Items {
+		items = append(items, dto.GalleryItemResponse{
+			MediaID:     item.MediaID,
+			Position:    item.Position,
+			Caption:     item.Caption,
+			AltText:     item.AltText,
+			StoragePath: item.StoragePath,
+		})
+	}
+
+	images := gallery.Images
+	if images == nil {
+		images = []string{}
+	}
+
 	return &dto.GalleryResponse{
 		ID:              gallery.ID,
 		Title:           gallery.Title,
 		Slug:            gallery.Slug,
 		Description:     gallery.Description,
-		Images:          gallery.Images,
+		Images:          images,
 		CoverImageIndex: gallery.CoverImageIndex,
+		CoverMediaID:    gallery.CoverMediaID,
+		Items:           items,
 		AuthorID:        gallery.AuthorID,
 		Status:          gallery.Status,
 		PublishedAt:     gallery.PublishedAt,
@@ -451,3 +643,70 @@ func (s *GalleryService) mapToGalleryResponse(gallery models.Gallery) *dto.Galle
 		Tags:            gallery.Tags,
 	}
 }
+
+// resolveGalleryItems собирает изображения галереи из items или, для старых клиентов,
+// из путей images. Возвращает nil, если не передано ни то, ни другое
+func (s *GalleryService) resolveGalleryItems(ctx context.Context, inputs []dto.GalleryItemInput, images []string) ([]models.GalleryItem, error) {
+	if inputs != nil {
+		items := make([]models.GalleryItem, 0, len(inputs))
+		for _, input := range inputs {
+			items = append(items, models.GalleryItem{
+				MediaID: input.MediaID,
+				Caption: strings.TrimSpace(input.Caption),
+				AltText: strings.TrimSpace(input.AltText),
+			})
+		}
+		return items, nil
+	}
+
+	if images == nil {
+		return nil, nil
+	}
+
+	mediaIDs, err := s.repo.GetMediaIDsByPaths(ctx, images)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]models.GalleryItem, 0, len(images))
+	for _, path := range images {
+		mediaID, ok := mediaIDs[path]
+		if !ok {
+			return nil, storage.ErrMediaNotFound.WithDetail("%s", path)
+		}
+		items = append(items, models.GalleryItem{MediaID: mediaID})
+	}
+
+	return items, nil
+}
+
+// coverMediaID выбирает обложку: явно указанную или, для старых клиентов, по индексу в images
+func coverMediaID(requested *uuid.UUID, inputs []dto.GalleryItemInput, legacyIndex int, items []models.GalleryItem) *uuid.UUID {
+	if requested != nil || inputs != nil {
+		return requested
+	}
+
+	if legacyIndex >= 0 && legacyIndex < len(items) {
+		return &items[legacyIndex].MediaID
+	}
+
+	return nil
+}
+
+// galleryCursor возвращает позицию галереи в списке с заданной сортировкой
+func galleryCursor(gallery models.Gallery, sort string) pagination.Cursor {
+	cursor := pagination.Cursor{Sort: sort, ID: gallery.ID}
+	switch sort {
+	case models.GallerySortTitle:
+		cursor.Text = gallery.Title
+	case models.GallerySortPublished:
+		cursor.Time = gallery.CreatedAt
+		if gallery.PublishedAt != nil {
+			cursor.Time = *gallery.PublishedAt
+		}
+	default:
+		cursor.Time = gallery.CreatedAt
+	}
+
+	return cursor
+}
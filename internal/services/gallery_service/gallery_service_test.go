@@ -3,11 +3,15 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"premium_caste/internal/domain/models"
+	"premium_caste/internal/lib/pagination"
+	"premium_caste/internal/storage"
 	"premium_caste/internal/transport/http/dto"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -43,14 +47,24 @@ func (m *MockGalleryRepository) GetGalleryByID(ctx context.Context, id uuid.UUID
 	return args.Get(0).(models.Gallery), args.Error(1)
 }
 
-func (m *MockGalleryRepository) GetGalleries(ctx context.Context, statusFilter string, page, perPage int) ([]models.Gallery, int, error) {
-	args := m.Called(ctx, statusFilter, page, perPage)
-	return args.Get(0).([]models.Gallery), args.Int(1), args.Error(2)
+func (m *MockGalleryRepository) GetGalleryBySlug(ctx context.Context, slug string) (models.Gallery, error) {
+	args := m.Called(ctx, slug)
+	return args.Get(0).(models.Gallery), args.Error(1)
+}
+
+func (m *MockGalleryRepository) GetGallerySlugRedirect(ctx context.Context, oldSlug string) (string, error) {
+	args := m.Called(ctx, oldSlug)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockGalleryRepository) IsGallerySlugTaken(ctx context.Context, slug string) (bool, error) {
+	args := m.Called(ctx, slug)
+	return args.Bool(0), args.Error(1)
 }
 
-func (m *MockGalleryRepository) GetGalleriesByTags(ctx context.Context, tags []string, matchAll bool) ([]models.Gallery, error) {
-	args := m.Called(ctx, tags, matchAll)
-	return args.Get(0).([]models.Gallery), args.Error(1)
+func (m *MockGalleryRepository) ListGalleries(ctx context.Context, filter models.GalleryFilter, page, perPage int) ([]models.Gallery, int, error) {
+	args := m.Called(ctx, filter, page, perPage)
+	return args.Get(0).([]models.Gallery), args.Int(1), args.Error(2)
 }
 
 func (m *MockGalleryRepository) AddTags(ctx context.Context, galleryID string, tags []string) error {
@@ -78,10 +92,58 @@ func (m *MockGalleryRepository) GetTags(ctx context.Context, galleryID string) (
 	return args.Get(0).([]string), args.Error(1)
 }
 
+func (m *MockGalleryRepository) AddGalleryItems(ctx context.Context, galleryID uuid.UUID, items []models.GalleryItem) error {
+	args := m.Called(ctx, galleryID, items)
+	return args.Error(0)
+}
+
+func (m *MockGalleryRepository) UpdateGalleryItem(ctx context.Context, item models.GalleryItem) error {
+	args := m.Called(ctx, item)
+	return args.Error(0)
+}
+
+func (m *MockGalleryRepository) RemoveGalleryItem(ctx context.Context, galleryID, mediaID uuid.UUID) error {
+	args := m.Called(ctx, galleryID, mediaID)
+	return args.Error(0)
+}
+
+func (m *MockGalleryRepository) ReorderGalleryItems(ctx context.Context, galleryID uuid.UUID, mediaIDs []uuid.UUID) error {
+	args := m.Called(ctx, galleryID, mediaIDs)
+	return args.Error(0)
+}
+
+func (m *MockGalleryRepository) SetGalleryCover(ctx context.Context, galleryID, mediaID uuid.UUID) error {
+	args := m.Called(ctx, galleryID, mediaID)
+	return args.Error(0)
+}
+
+func (m *MockGalleryRepository) GetMediaIDsByPaths(ctx context.Context, paths []string) (map[string]uuid.UUID, error) {
+	args := m.Called(ctx, paths)
+	ids, _ := args.Get(0).(map[string]uuid.UUID)
+	return ids, args.Error(1)
+}
+
+// recordingAuditor запоминает события, переданные в журнал аудита
+type recordingAuditor struct {
+	entries []models.AuditEntry
+}
+
+func (a *recordingAuditor) Record(ctx context.Context, entry models.AuditEntry) {
+	a.entries = append(a.entries, entry)
+}
+
+func (a *recordingAuditor) actions() []string {
+	var actions []string
+	for _, entry := range a.entries {
+		actions = append(actions, entry.Action)
+	}
+	return actions
+}
+
 func TestGalleryService_CreateGallery(t *testing.T) {
 	ctx := context.Background()
 	mockRepo := new(MockGalleryRepository)
-	service := NewGalleryService(slog.Default(), mockRepo)
+	service := NewGalleryService(slog.Default(), mockRepo, &recordingAuditor{})
 
 	testUUID := uuid.New()
 	gallery := dto.CreateGalleryRequest{
@@ -150,7 +212,7 @@ func TestGalleryService_CreateGallery(t *testing.T) {
 func TestGalleryService_UpdateGallery(t *testing.T) {
 	ctx := context.Background()
 	mockRepo := new(MockGalleryRepository)
-	service := NewGalleryService(slog.Default(), mockRepo)
+	service := NewGalleryService(slog.Default(), mockRepo, &recordingAuditor{})
 
 	gallery := dto.UpdateGalleryRequest{
 		ID:    uuid.New(),
@@ -215,7 +277,7 @@ func TestGalleryService_UpdateGallery(t *testing.T) {
 func TestGalleryService_UpdateGalleryStatus(t *testing.T) {
 	ctx := context.Background()
 	mockRepo := new(MockGalleryRepository)
-	service := NewGalleryService(slog.Default(), mockRepo)
+	service := NewGalleryService(slog.Default(), mockRepo, &recordingAuditor{})
 
 	id := uuid.New()
 	status := "published"
@@ -282,7 +344,7 @@ func TestGalleryService_UpdateGalleryStatus(t *testing.T) {
 func TestGalleryService_DeleteGallery(t *testing.T) {
 	ctx := context.Background()
 	mockRepo := new(MockGalleryRepository)
-	service := NewGalleryService(slog.Default(), mockRepo)
+	service := NewGalleryService(slog.Default(), mockRepo, &recordingAuditor{})
 
 	id := uuid.New()
 
@@ -335,7 +397,7 @@ func TestGalleryService_DeleteGallery(t *testing.T) {
 func TestGalleryService_GetGalleryByID(t *testing.T) {
 	ctx := context.Background()
 	mockRepo := new(MockGalleryRepository)
-	service := NewGalleryService(slog.Default(), mockRepo)
+	service := NewGalleryService(slog.Default(), mockRepo, &recordingAuditor{})
 
 	id := uuid.New()
 	gallery := models.Gallery{
@@ -393,10 +455,10 @@ func TestGalleryService_GetGalleryByID(t *testing.T) {
 	}
 }
 
-func TestGalleryService_GetGalleries(t *testing.T) {
+func TestGalleryService_ListGalleries(t *testing.T) {
 	ctx := context.Background()
 	mockRepo := new(MockGalleryRepository)
-	service := NewGalleryService(slog.Default(), mockRepo)
+	service := NewGalleryService(slog.Default(), mockRepo, &recordingAuditor{})
 
 	galleries := []models.Gallery{
 		{ID: uuid.New(), Title: "Gallery 1", Images: []string{"img2.jpg"}},
@@ -405,36 +467,69 @@ func TestGalleryService_GetGalleries(t *testing.T) {
 	total := 2
 
 	tests := []struct {
-		name         string
-		statusFilter string
-		page         int
-		perPage      int
-		mockSetup    func()
-		wantError    bool
-		expectedErr  string
+		name        string
+		filter      dto.GalleryFilter
+		page        int
+		perPage     int
+		mockSetup   func()
+		wantError   bool
+		expectedErr string
 	}{
 		{
-			name:         "successful retrieval",
-			statusFilter: "published",
-			page:         1,
-			perPage:      10,
+			name:    "successful retrieval",
+			filter:  dto.GalleryFilter{Status: "published"},
+			page:    1,
+			perPage: 10,
 			mockSetup: func() {
-				mockRepo.On("GetGalleries", ctx, "published", 1, 10).
+				mockRepo.On("ListGalleries", ctx, models.GalleryFilter{Status: "published", Sort: models.GallerySortCreated}, 1, 10).
 					Return(galleries, total, nil).Once()
 			},
 			wantError: false,
 		},
 		{
-			name:         "repository error",
-			statusFilter: "published",
-			page:         1,
-			perPage:      10,
+			name:    "tags normalized with AND logic",
+			filter:  dto.GalleryFilter{Status: "published", Tags: []string{" Nature", "landscape", "nature"}, MatchAllTags: true, Sort: "title"},
+			page:    1,
+			perPage: 10,
 			mockSetup: func() {
-				mockRepo.On("GetGalleries", ctx, "published", 1, 10).
-					Return(galleries, 0, errors.New("repository error")).Once()
+				mockRepo.On("ListGalleries", ctx, models.GalleryFilter{
+					Status:       "published",
+					Tags:         []string{"nature", "landscape"},
+					MatchAllTags: true,
+					Sort:         models.GallerySortTitle,
+				}, 1, 10).Return(galleries, total, nil).Once()
 			},
+			wantError: false,
+		},
+		{
+			name:        "invalid sort",
+			filter:      dto.GalleryFilter{Sort: "views"},
+			page:        1,
+			perPage:     10,
+			mockSetup:   func() {},
+			wantError:   true,
+			expectedErr: "invalid sort",
+		},
+		{
+			name:        "invalid cursor",
+			filter:      dto.GalleryFilter{Cursor: "not-a-cursor"},
+			page:        1,
+			perPage:     10,
+			mockSetup:   func() {},
 			wantError:   true,
-			expectedErr: "failed to get galleries",
+			expectedErr: storage.ErrInvalidCursor.Error(),
+		},
+		{
+			name:    "repository error",
+			filter:  dto.GalleryFilter{Status: "published"},
+			page:    1,
+			perPage: 10,
+			mockSetup: func() {
+				mockRepo.On("ListGalleries", ctx, models.GalleryFilter{Status: "published", Sort: models.GallerySortCreated}, 1, 10).
+					Return([]models.Gallery{}, 0, errors.New("repository error")).Once()
+			},
+			wantError:   true,
+			expectedErr: "failed to list galleries",
 		},
 	}
 
@@ -442,18 +537,18 @@ func TestGalleryService_GetGalleries(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.mockSetup()
 
-			resp, totalCount, err := service.GetGalleries(ctx, tt.statusFilter, tt.page, tt.perPage)
+			resp, err := service.ListGalleries(ctx, tt.filter, tt.page, tt.perPage)
 
 			if tt.wantError {
 				assert.Error(t, err)
 				assert.Contains(t, err.Error(), tt.expectedErr)
 				assert.Nil(t, resp)
-				assert.Equal(t, 0, totalCount)
 			} else {
 				assert.NoError(t, err)
 				assert.NotNil(t, resp)
-				assert.Equal(t, total, totalCount)
-				assert.Equal(t, len(galleries), len(resp))
+				assert.Equal(t, total, resp.Total)
+				assert.Equal(t, len(galleries), len(resp.Galleries))
+				assert.Empty(t, resp.NextCursor)
 			}
 
 			mockRepo.AssertExpectations(t)
@@ -461,102 +556,45 @@ func TestGalleryService_GetGalleries(t *testing.T) {
 	}
 }
 
-func TestTagService_GetGalleriesByTags(t *testing.T) {
+func TestGalleryService_ListGalleriesCursor(t *testing.T) {
 	ctx := context.Background()
 	mockRepo := new(MockGalleryRepository)
-	service := NewGalleryService(slog.Default(), mockRepo)
+	service := NewGalleryService(slog.Default(), mockRepo, &recordingAuditor{})
 
-	gallery1 := models.Gallery{
-		ID:    uuid.New(),
-		Title: "Gallery with tags",
-		Tags:  []string{"nature", "landscape"},
-	}
+	last := models.Gallery{ID: uuid.New(), Title: "Autumn", CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 123000, time.UTC)}
+	first := models.Gallery{ID: uuid.New(), Title: "Winter"}
 
-	gallery2 := models.Gallery{
-		ID:    uuid.New(),
-		Title: "Gallery with single tag",
-		Tags:  []string{"art"},
-	}
+	filter := models.GalleryFilter{Status: "published", Sort: models.GallerySortCreated}
+	mockRepo.On("ListGalleries", ctx, filter, 1, 2).
+		Return([]models.Gallery{first, last}, 3, nil).Once()
 
-	tests := []struct {
-		name      string
-		tags      []string
-		matchAll  bool
-		mockSetup func()
-		wantError bool
-		expected  []dto.GalleryResponse
-		errMsg    string
-	}{
-		{
-			name:     "successful retrieval with AND logic",
-			tags:     []string{"nature", "landscape"},
-			matchAll: true,
-			mockSetup: func() {
-				mockRepo.On("GetGalleriesByTags", ctx, []string{"nature", "landscape"}, true).
-					Return([]models.Gallery{gallery1}, nil).Once()
-			},
-			wantError: false,
-			expected:  []dto.GalleryResponse{*service.mapToGalleryResponse(gallery1)},
-		},
-		{
-			name:     "successful retrieval with OR logic",
-			tags:     []string{"nature", "art"},
-			matchAll: false,
-			mockSetup: func() {
-				mockRepo.On("GetGalleriesByTags", ctx, []string{"nature", "art"}, false).
-					Return([]models.Gallery{gallery1, gallery2}, nil).Once()
-			},
-			wantError: false,
-			expected:  []dto.GalleryResponse{*service.mapToGalleryResponse(gallery1), *service.mapToGalleryResponse(gallery2)},
-		},
-		{
-			name:     "repository error",
-			tags:     []string{"nature", "landscape"},
-			matchAll: true,
-			mockSetup: func() {
-				mockRepo.On("GetGalleriesByTags", ctx, []string{"nature", "landscape"}, true).
-					Return([]models.Gallery{}, errors.New("repository error")).Once()
-			},
-			wantError: true,
-			errMsg:    "failed to get galleries",
-		},
-		{
-			name:     "no matching tags",
-			tags:     []string{"unknown"},
-			matchAll: true,
-			mockSetup: func() {
-				mockRepo.On("GetGalleriesByTags", ctx, []string{"unknown"}, true).
-					Return([]models.Gallery{}, nil).Once()
-			},
-			wantError: false,
-			expected:  []dto.GalleryResponse{},
-		},
+	resp, err := service.ListGalleries(ctx, dto.GalleryFilter{Status: "published"}, 1, 2)
+	assert.NoError(t, err)
+	if !assert.NotEmpty(t, resp.NextCursor) {
+		return
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			tt.mockSetup()
+	next := filter
+	next.After = &pagination.Cursor{Sort: models.GallerySortCreated, ID: last.ID, Time: last.CreatedAt}
+	mockRepo.On("ListGalleries", ctx, next, 1, 2).
+		Return([]models.Gallery{{ID: uuid.New()}}, 3, nil).Once()
 
-			resp, err := service.GetGalleriesByTags(ctx, tt.tags, tt.matchAll)
+	resp, err = service.ListGalleries(ctx, dto.GalleryFilter{Status: "published", Cursor: resp.NextCursor}, 1, 2)
+	assert.NoError(t, err)
+	assert.Len(t, resp.Galleries, 1)
+	assert.Empty(t, resp.NextCursor)
 
-			if tt.wantError {
-				assert.Error(t, err)
-				assert.Contains(t, err.Error(), tt.errMsg)
-				assert.Nil(t, resp)
-			} else {
-				assert.NoError(t, err)
-				assert.Equal(t, tt.expected, resp)
-			}
+	// Курсор другой сортировки не принимается
+	_, err = service.ListGalleries(ctx, dto.GalleryFilter{Sort: "title", Cursor: galleryCursor(last, models.GallerySortCreated).Encode()}, 1, 2)
+	assert.ErrorIs(t, err, storage.ErrInvalidCursor)
 
-			mockRepo.AssertExpectations(t)
-		})
-	}
+	mockRepo.AssertExpectations(t)
 }
 
 func TestService_AddTags(t *testing.T) {
 	ctx := context.Background()
 	mockRepo := new(MockGalleryRepository)
-	service := NewGalleryService(slog.Default(), mockRepo)
+	service := NewGalleryService(slog.Default(), mockRepo, &recordingAuditor{})
 
 	testUUID := uuid.New().String()
 	validTags := []string{"art", "design"}
@@ -630,7 +668,7 @@ func TestService_AddTags(t *testing.T) {
 func TestService_RemoveTags(t *testing.T) {
 	ctx := context.Background()
 	mockRepo := new(MockGalleryRepository)
-	service := NewGalleryService(slog.Default(), mockRepo)
+	service := NewGalleryService(slog.Default(), mockRepo, &recordingAuditor{})
 
 	testUUID := uuid.New().String()
 	tagsToRemove := []string{"old", "tag"}
@@ -691,7 +729,7 @@ func TestService_RemoveTags(t *testing.T) {
 func TestService_ReplaceTags(t *testing.T) {
 	ctx := context.Background()
 	mockRepo := new(MockGalleryRepository)
-	service := NewGalleryService(slog.Default(), mockRepo)
+	service := NewGalleryService(slog.Default(), mockRepo, &recordingAuditor{})
 
 	testUUID := uuid.New().String()
 	newTags := []string{"new", "tags"}
@@ -745,7 +783,7 @@ func TestService_ReplaceTags(t *testing.T) {
 func TestService_GetTags(t *testing.T) {
 	ctx := context.Background()
 	mockRepo := new(MockGalleryRepository)
-	service := NewGalleryService(slog.Default(), mockRepo)
+	service := NewGalleryService(slog.Default(), mockRepo, &recordingAuditor{})
 
 	testUUID := uuid.New().String()
 	expectedTags := []string{"tag1", "tag2"}
@@ -810,7 +848,7 @@ func TestService_GetTags(t *testing.T) {
 func TestService_HasTags(t *testing.T) {
 	ctx := context.Background()
 	mockRepo := new(MockGalleryRepository)
-	service := NewGalleryService(slog.Default(), mockRepo)
+	service := NewGalleryService(slog.Default(), mockRepo, &recordingAuditor{})
 
 	testUUID := uuid.New().String()
 	tagsToCheck := []string{"required", "tag"}
@@ -871,3 +909,211 @@ func TestService_HasTags(t *testing.T) {
 		})
 	}
 }
+
+func TestGalleryService_GetGalleryBySlug(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockGalleryRepository)
+	service := NewGalleryService(slog.Default(), mockRepo, &recordingAuditor{})
+
+	gallery := models.Gallery{ID: uuid.New(), Title: "Лето", Slug: "leto", Status: "published"}
+	draft := models.Gallery{ID: uuid.New(), Title: "Осень", Slug: "osen", Status: "draft"}
+	moved := fmt.Errorf("repo: %w", storage.ErrGalleryNotFound)
+
+	mockRepo.On("GetGalleryBySlug", ctx, "leto").Return(gallery, nil).Twice()
+	mockRepo.On("GetGalleryBySlug", ctx, "old-leto").Return(models.Gallery{}, moved).Once()
+	mockRepo.On("GetGallerySlugRedirect", ctx, "old-leto").Return("leto", nil).Once()
+	mockRepo.On("GetGalleryBySlug", ctx, "osen").Return(draft, nil).Times(3)
+	mockRepo.On("GetGalleryBySlug", ctx, "old-osen").Return(models.Gallery{}, moved).Twice()
+	mockRepo.On("GetGallerySlugRedirect", ctx, "old-osen").Return("osen", nil).Twice()
+
+	resp, movedTo, err := service.GetGalleryBySlug(ctx, "leto", false)
+	assert.NoError(t, err)
+	assert.Equal(t, gallery.ID, resp.ID)
+	assert.Empty(t, movedTo)
+
+	resp, movedTo, err = service.GetGalleryBySlug(ctx, "old-leto", false)
+	assert.NoError(t, err)
+	assert.Nil(t, resp)
+	assert.Equal(t, "leto", movedTo)
+
+	// Черновик и его новый slug видит только администратор
+	_, _, err = service.GetGalleryBySlug(ctx, "osen", false)
+	assert.ErrorIs(t, err, storage.ErrGalleryNotFound)
+
+	_, movedTo, err = service.GetGalleryBySlug(ctx, "old-osen", false)
+	assert.ErrorIs(t, err, storage.ErrGalleryNotFound)
+	assert.Empty(t, movedTo)
+
+	resp, _, err = service.GetGalleryBySlug(ctx, "osen", true)
+	assert.NoError(t, err)
+	assert.Equal(t, draft.ID, resp.ID)
+
+	_, movedTo, err = service.GetGalleryBySlug(ctx, "old-osen", true)
+	assert.NoError(t, err)
+	assert.Equal(t, "osen", movedTo)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGallerySlug(t *testing.T) {
+	assert.Equal(t, "valid-slug", gallerySlug("valid-slug", "Title"))
+	assert.Equal(t, "test-slug-gallery", gallerySlug("test slug gallery", "Title"))
+	assert.Equal(t, "letniy-parizh", gallerySlug("", "Летний Париж"))
+}
+
+func TestGalleryService_CreateGalleryFromLegacyImages(t *testing.T) {
+	ctx := context.Background()
+	authorID := uuid.New()
+	firstID, secondID := uuid.New(), uuid.New()
+	images := []string{"uploads/a.jpg", "uploads/b.jpg"}
+
+	t.Run("paths resolved to media with cover by index", func(t *testing.T) {
+		mockRepo := new(MockGalleryRepository)
+		service := NewGalleryService(slog.Default(), mockRepo, &recordingAuditor{})
+
+		mockRepo.On("GetMediaIDsByPaths", ctx, images).
+			Return(map[string]uuid.UUID{"uploads/a.jpg": firstID, "uploads/b.jpg": secondID}, nil).Once()
+		mockRepo.On("IsGallerySlugTaken", ctx, mock.Anything).Return(false, nil).Maybe()
+		mockRepo.On("CreateGallery", ctx, mock.MatchedBy(func(g models.Gallery) bool {
+			return len(g.Items) == 2 &&
+				g.Items[0].MediaID == firstID &&
+				g.Items[1].MediaID == secondID &&
+				g.CoverMediaID != nil && *g.CoverMediaID == secondID
+		})).Return(uuid.New(), nil).Once()
+
+		_, err := service.CreateGallery(ctx, dto.CreateGalleryRequest{
+			Title:           "Legacy",
+			Images:          images,
+			CoverImageIndex: 1,
+			AuthorID:        authorID,
+		})
+
+		assert.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("unknown path", func(t *testing.T) {
+		mockRepo := new(MockGalleryRepository)
+		service := NewGalleryService(slog.Default(), mockRepo, &recordingAuditor{})
+
+		mockRepo.On("GetMediaIDsByPaths", ctx, images).
+			Return(map[string]uuid.UUID{"uploads/a.jpg": firstID}, nil).Once()
+
+		_, err := service.CreateGallery(ctx, dto.CreateGalleryRequest{
+			Title:    "Legacy",
+			Images:   images,
+			AuthorID: authorID,
+		})
+
+		assert.ErrorIs(t, err, storage.ErrMediaNotFound)
+		mockRepo.AssertNotCalled(t, "CreateGallery", mock.Anything, mock.Anything)
+	})
+}
+
+func TestGalleryService_GalleryItems(t *testing.T) {
+	ctx := context.Background()
+	galleryID := uuid.New()
+	mediaID := uuid.New()
+	gallery := models.Gallery{
+		ID:     galleryID,
+		Title:  "Test Gallery",
+		Images: []string{"uploads/a.jpg"},
+		Items:  []models.GalleryItem{{GalleryID: galleryID, MediaID: mediaID, Caption: "Sunset", StoragePath: "uploads/a.jpg"}},
+	}
+
+	tests := []struct {
+		name        string
+		mockSetup   func(m *MockGalleryRepository)
+		call        func(s *GalleryService) (*dto.GalleryResponse, error)
+		wantErr     error
+		expectedErr string
+	}{
+		{
+			name: "add items",
+			mockSetup: func(m *MockGalleryRepository) {
+				m.On("AddGalleryItems", ctx, galleryID, []models.GalleryItem{{MediaID: mediaID, Caption: "Sunset"}}).Return(nil).Once()
+				m.On("GetGalleryByID", ctx, galleryID).Return(gallery, nil).Once()
+			},
+			call: func(s *GalleryService) (*dto.GalleryResponse, error) {
+				return s.AddGalleryItems(ctx, galleryID, dto.AddGalleryItemsRequest{
+					Items: []dto.GalleryItemInput{{MediaID: mediaID, Caption: " Sunset "}},
+				})
+			},
+		},
+		{
+			name:      "add without items",
+			mockSetup: func(m *MockGalleryRepository) {},
+			call: func(s *GalleryService) (*dto.GalleryResponse, error) {
+				return s.AddGalleryItems(ctx, galleryID, dto.AddGalleryItemsRequest{})
+			},
+			expectedErr: "items are required",
+		},
+		{
+			name: "update missing item",
+			mockSetup: func(m *MockGalleryRepository) {
+				m.On("UpdateGalleryItem", ctx, models.GalleryItem{GalleryID: galleryID, MediaID: mediaID, Caption: "New"}).
+					Return(storage.ErrGalleryItemNotFound).Once()
+			},
+			call: func(s *GalleryService) (*dto.GalleryResponse, error) {
+				return s.UpdateGalleryItem(ctx, galleryID, mediaID, dto.UpdateGalleryItemRequest{Caption: "New"})
+			},
+			wantErr: storage.ErrGalleryItemNotFound,
+		},
+		{
+			name: "reorder with foreign media",
+			mockSetup: func(m *MockGalleryRepository) {
+				m.On("ReorderGalleryItems", ctx, galleryID, []uuid.UUID{mediaID}).
+					Return(storage.ErrInvalidGalleryOrder).Once()
+			},
+			call: func(s *GalleryService) (*dto.GalleryResponse, error) {
+				return s.ReorderGalleryItems(ctx, galleryID, dto.ReorderGalleryItemsRequest{MediaIDs: []uuid.UUID{mediaID}})
+			},
+			wantErr: storage.ErrInvalidGalleryOrder,
+		},
+		{
+			name: "remove item",
+			mockSetup: func(m *MockGalleryRepository) {
+				m.On("RemoveGalleryItem", ctx, galleryID, mediaID).Return(nil).Once()
+				m.On("GetGalleryByID", ctx, galleryID).Return(gallery, nil).Once()
+			},
+			call: func(s *GalleryService) (*dto.GalleryResponse, error) {
+				return s.RemoveGalleryItem(ctx, galleryID, mediaID)
+			},
+		},
+		{
+			name:      "cover without media",
+			mockSetup: func(m *MockGalleryRepository) {},
+			call: func(s *GalleryService) (*dto.GalleryResponse, error) {
+				return s.SetGalleryCover(ctx, galleryID, dto.SetGalleryCoverRequest{})
+			},
+			expectedErr: "media_id is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockGalleryRepository)
+			tt.mockSetup(mockRepo)
+			service := NewGalleryService(slog.Default(), mockRepo, &recordingAuditor{})
+
+			resp, err := tt.call(service)
+
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Nil(t, resp)
+			case tt.expectedErr != "":
+				assert.ErrorContains(t, err, tt.expectedErr)
+				assert.Nil(t, resp)
+			default:
+				assert.NoError(t, err)
+				if assert.NotNil(t, resp) && assert.Len(t, resp.Items, 1) {
+					assert.Equal(t, mediaID, resp.Items[0].MediaID)
+					assert.Equal(t, []string{"uploads/a.jpg"}, resp.Images)
+				}
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -2,10 +2,14 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
 	"mime/multipart"
+	"os"
+	"path"
 	"path/filepath"
+	"premium_caste/internal/domain/apperr"
 
 	"premium_caste/internal/domain/models"
 	"premium_caste/internal/lib/logger/sl"
@@ -21,18 +25,35 @@ import (
 	"github.com/patrickmn/go-cache"
 )
 
+const (
+	// gcBatchSize сколько медиа сборщик мусора проверяет за один запрос
+	gcBatchSize = 100
+)
+
+// gcRenditions каталоги уменьшенных копий рядом с оригиналом, как их читает архиватор галерей
+var gcRenditions = []string{"large", "medium", "small"}
+
+// Auditor журнал аудита
+type Auditor interface {
+	Record(ctx context.Context, entry models.AuditEntry)
+}
+
 type MediaService struct {
 	log         *slog.Logger
 	repo        repository.MediaRepository
+	tx          repository.Transactor
 	fileStorage storage.FileStorage
+	auditor     Auditor
 	cache       *cache.Cache
 }
 
-func NewMediaService(log *slog.Logger, repo repository.MediaRepository, fileStorage storage.FileStorage) *MediaService {
+func NewMediaService(log *slog.Logger, repo repository.MediaRepository, tx repository.Transactor, fileStorage storage.FileStorage, auditor Auditor) *MediaService {
 	return &MediaService{
 		log:         log,
 		repo:        repo,
+		tx:          tx,
 		fileStorage: fileStorage,
+		auditor:     auditor,
 		cache:       cache.New(5*time.Minute, 10*time.Minute), // Кеш с TTL 5 минут и очисткой каждые 10 минут
 	}
 }
@@ -46,6 +67,93 @@ func (s *MediaService) UploadMultipleMedia(ctx context.Context, inputs []dto.Med
 
 	log.Info("Upload multiple media", slog.Int("count", len(inputs)))
 
+	medias, paths, err := s.saveFiles(ctx, inputs, log)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Сохраняем в базу данных
+	createdMedias, err := s.repo.CreateMultipleMedia(ctx, medias)
+	if err != nil {
+		// Удаляем все сохраненные файлы при ошибке базы данных
+		if cleanupErr := s.cleanupFiles(ctx, paths, log); cleanupErr != nil {
+			log.Error("failed to cleanup files after db error",
+				sl.Err(err), sl.Err(cleanupErr))
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	s.recordUploads(ctx, createdMedias)
+
+	return createdMedias, nil
+}
+
+// UploadMediaGroup загружает файлы и объединяет их в новую медиа-группу.
+// Медиа, группа и ее состав сохраняются в одной транзакции: при ошибке
+// в базе не остается ни медиа, ни пустой группы, а файлы удаляются
+func (s *MediaService) UploadMediaGroup(ctx context.Context, inputs []dto.MediaUploadInput, description string) (*dto.MediaGroupUploadResponse, error) {
+	const op = "media_service.UploadMediaGroup"
+
+	log := s.log.With(
+		slog.String("op", op),
+	)
+
+	log.Info("Upload media group", slog.Int("count", len(inputs)))
+
+	medias, paths, err := s.saveFiles(ctx, inputs, log)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	resp := &dto.MediaGroupUploadResponse{}
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		createdMedias, err := s.repo.CreateMultipleMedia(ctx, medias)
+		if err != nil {
+			return fmt.Errorf("failed to save media: %w", err)
+		}
+
+		groupID, err := s.repo.AddMediaGroup(ctx, medias[0].UploaderID, description)
+		if err != nil {
+			return fmt.Errorf("failed to create group: %w", err)
+		}
+
+		mediaIDs := make([]uuid.UUID, 0, len(createdMedias))
+		for _, media := range createdMedias {
+			mediaIDs = append(mediaIDs, media.ID)
+		}
+		if err := s.repo.AddMediaGroupItems(ctx, groupID, mediaIDs); err != nil {
+			return fmt.Errorf("failed to attach media: %w", err)
+		}
+
+		resp.GroupID = groupID
+		resp.Media = createdMedias
+		return nil
+	})
+	if err != nil {
+		log.Error("failed to save media group", sl.Err(err))
+		if cleanupErr := s.cleanupFiles(ctx, paths, log); cleanupErr != nil {
+			log.Error("failed to cleanup files after db error",
+				sl.Err(err), sl.Err(cleanupErr))
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("media group uploaded",
+		slog.String("group_id", resp.GroupID.String()),
+		slog.Int("media_count", len(resp.Media)))
+
+	s.recordUploads(ctx, resp.Media)
+
+	return resp, nil
+}
+
+// saveFiles сохраняет файлы одного загрузчика в хранилище и готовит модели медиа.
+// При ошибке валидации сохраненные файлы удаляются
+func (s *MediaService) saveFiles(ctx context.Context, inputs []dto.MediaUploadInput, log *slog.Logger) ([]*models.Media, []string, error) {
+	if len(inputs) == 0 {
+		return nil, nil, apperr.InvalidField("files", "required", "at least one file is required")
+	}
+
 	// Подготовка данных для хранения
 	var (
 		fileHeaders = make([]*multipart.FileHeader, 0, len(inputs))
@@ -57,7 +165,7 @@ func (s *MediaService) UploadMultipleMedia(ctx context.Context, inputs []dto.Med
 		if uploaderID == uuid.Nil {
 			uploaderID = input.UploaderID
 		} else if uploaderID != input.UploaderID {
-			return nil, fmt.Errorf("%s: all files must have same uploader ID", op)
+			return nil, nil, apperr.InvalidField("uploader_id", "same", "all files must have same uploader ID")
 		}
 		fileHeaders = append(fileHeaders, input.File)
 	}
@@ -66,7 +174,7 @@ func (s *MediaService) UploadMultipleMedia(ctx context.Context, inputs []dto.Med
 	paths, sizes, err := s.fileStorage.SaveMultiple(ctx, fileHeaders, filepath.Join("uploads", uploaderID.String()))
 	if err != nil {
 		log.Error("failed to save files", sl.Err(err))
-		return nil, fmt.Errorf("%s: %w", op, err)
+		return nil, nil, err
 	}
 
 	// Создаем модели медиа
@@ -94,25 +202,13 @@ func (s *MediaService) UploadMultipleMedia(ctx context.Context, inputs []dto.Med
 				log.Error("failed to cleanup files after validation error",
 					sl.Err(err), sl.Err(cleanupErr))
 			}
-			return nil, fmt.Errorf("%s: %w", op, err)
+			return nil, nil, err
 		}
 
 		medias = append(medias, media)
 	}
 
-	// Сохраняем в базу данных
-
-	createdMedias, err := s.repo.CreateMultipleMedia(ctx, medias)
-	if err != nil {
-		// Удаляем все сохраненные файлы при ошибке базы данных
-		if cleanupErr := s.cleanupFiles(ctx, paths, log); cleanupErr != nil {
-			log.Error("failed to cleanup files after db error",
-				sl.Err(err), sl.Err(cleanupErr))
-		}
-		return nil, fmt.Errorf("%s: %w", op, err)
-	}
-
-	return createdMedias, nil
+	return medias, paths, nil
 }
 
 func (s *MediaService) UploadMedia(ctx context.Context, input dto.MediaUploadInput) (*models.Media, error) {
@@ -164,9 +260,23 @@ func (s *MediaService) UploadMedia(ctx context.Context, input dto.MediaUploadInp
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
+	s.recordUploads(ctx, []*models.Media{createdMedia})
+
 	return createdMedia, nil
 }
 
+// recordUploads записывает загруженные медиа в журнал аудита
+func (s *MediaService) recordUploads(ctx context.Context, medias []*models.Media) {
+	for _, media := range medias {
+		s.auditor.Record(ctx, models.AuditEntry{
+			Action:     models.AuditMediaUpload,
+			TargetType: models.AuditTargetMedia,
+			TargetID:   media.ID.String(),
+			After:      media,
+		})
+	}
+}
+
 func (s *MediaService) AttachMediaToGroup(ctx context.Context, groupID uuid.UUID, mediaIDs []uuid.UUID) error {
 	const op = "media_service.AttachMediaToGroupItems"
 
@@ -179,17 +289,17 @@ func (s *MediaService) AttachMediaToGroup(ctx context.Context, groupID uuid.UUID
 	// Валидация параметров
 	if groupID == uuid.Nil {
 		log.Info("groupID is required", "op", op)
-		return fmt.Errorf("%s: groupID is required", op)
+		return fmt.Errorf("%s: %w", op, apperr.InvalidField("group_id", "required", "groupID is required"))
 	}
 	if len(mediaIDs) == 0 {
 		log.Info("at least one mediaID is required", "op", op)
-		return fmt.Errorf("%s: at least one mediaID is required", op)
+		return fmt.Errorf("%s: %w", op, apperr.InvalidField("media_ids", "required", "at least one mediaID is required"))
 	}
 
 	// Проверка на нулевые UUID в массиве
 	if slices.Contains(mediaIDs, uuid.Nil) {
 		log.Info("mediaID cannot be nil", "op", op)
-		return fmt.Errorf("%s: mediaID cannot be nil", op)
+		return fmt.Errorf("%s: %w", op, apperr.InvalidField("media_ids", "required", "mediaID cannot be nil"))
 	}
 
 	// Вызов репозитория с массивом mediaIDs
@@ -218,7 +328,7 @@ func (s *MediaService) AttachMedia(ctx context.Context, ownerID uuid.UUID, descr
 
 	if ownerID == uuid.Nil {
 		log.Info("ownerID is required", "op", op)
-		return uuid.Nil, fmt.Errorf("%s: ownerID is required", op)
+		return uuid.Nil, fmt.Errorf("%s: %w", op, apperr.InvalidField("owner_id", "required", "ownerID is required"))
 	}
 
 	groupID, err := s.repo.AddMediaGroup(ctx, ownerID, description)
@@ -246,7 +356,7 @@ func (s *MediaService) ListGroupMedia(ctx context.Context, groupID uuid.UUID) ([
 
 	if groupID == uuid.Nil {
 		log.Info("groupID is required", "op", op)
-		return []models.Media{}, fmt.Errorf("%s: groupID is required", op)
+		return []models.Media{}, fmt.Errorf("%s: %w", op, apperr.InvalidField("group_id", "required", "groupID is required"))
 	}
 
 	media, err := s.repo.GetMediaByGroupID(ctx, groupID)
@@ -335,3 +445,89 @@ func (s *MediaService) GetImages(ctx context.Context) ([]models.Media, error) {
 
 	return media, nil
 }
+
+// CollectGarbage удаляет медиа, на которые ничего не ссылается и которые загружены
+// раньше чем minAge назад, вместе с файлами и уменьшенными копиями. Запись удаляется
+// раньше файла, поэтому при сбое остается лишний файл, а не ссылка на пустоту.
+// С dryRun только возвращает найденные медиа
+func (s *MediaService) CollectGarbage(ctx context.Context, minAge time.Duration, dryRun bool) (*dto.MediaGCResult, error) {
+	const op = "media_service.CollectGarbage"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Duration("min_age", minAge),
+		slog.Bool("dry_run", dryRun),
+	)
+
+	createdBefore := time.Now().Add(-minAge)
+	result := &dto.MediaGCResult{DryRun: dryRun}
+
+	for afterID := uuid.Nil; ; {
+		batch, err := s.repo.ListOrphanMedia(ctx, createdBefore, afterID, gcBatchSize)
+		if err != nil {
+			log.Error("failed to list orphan media", sl.Err(err))
+			return result, fmt.Errorf("%s: %w", op, err)
+		}
+
+		for _, media := range batch {
+			if dryRun {
+				result.Media = append(result.Media, media)
+				result.FreedBytes += media.FileSize
+				continue
+			}
+
+			deleted, err := s.repo.DeleteOrphanMedia(ctx, media.ID)
+			if err != nil {
+				log.Error("failed to delete media", slog.Any("media_id", media.ID), sl.Err(err))
+				return result, fmt.Errorf("%s: %w", op, err)
+			}
+			if !deleted {
+				log.Info("media is referenced again, skipped", slog.Any("media_id", media.ID))
+				continue
+			}
+
+			result.Media = append(result.Media, media)
+			result.FreedBytes += media.FileSize
+			result.FileErrors += s.deleteMediaFiles(ctx, media.StoragePath, log)
+
+			s.auditor.Record(ctx, models.AuditEntry{
+				Action:     models.AuditMediaDelete,
+				TargetType: models.AuditTargetMedia,
+				TargetID:   media.ID.String(),
+				Before:     media,
+			})
+		}
+
+		if len(batch) < gcBatchSize {
+			break
+		}
+		afterID = batch[len(batch)-1].ID
+	}
+
+	log.Info("media garbage collected",
+		slog.Int("count", len(result.Media)),
+		slog.Int64("freed_bytes", result.FreedBytes),
+		slog.Int("file_errors", result.FileErrors),
+	)
+
+	return result, nil
+}
+
+// deleteMediaFiles удаляет оригинал и уменьшенные копии. Отсутствующие файлы
+// не считаются ошибкой. Возвращает число файлов, которые удалить не удалось
+func (s *MediaService) deleteMediaFiles(ctx context.Context, storagePath string, log *slog.Logger) int {
+	paths := []string{storagePath}
+	for _, size := range gcRenditions {
+		paths = append(paths, path.Join(path.Dir(storagePath), size, path.Base(storagePath)))
+	}
+
+	failed := 0
+	for _, p := range paths {
+		if err := s.fileStorage.Delete(ctx, p); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Error("failed to delete file", slog.String("path", p), sl.Err(err))
+			failed++
+		}
+	}
+
+	return failed
+}
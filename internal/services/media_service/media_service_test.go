@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"mime/multipart"
 	"os"
@@ -11,7 +12,9 @@ import (
 	"testing"
 	"time"
 
+	"premium_caste/internal/domain/apperr"
 	"premium_caste/internal/domain/models"
+	"premium_caste/internal/transport/http/dto"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -64,7 +67,15 @@ func (m *MockMediaRepository) CreateMultipleMedia(ctx context.Context, medias []
 	return args.Get(0).([]*models.Media), args.Error(1)
 }
 
-func (m *MockMediaRepository) GetAllImages(ctx context.Context) ([]models.Media, error) {
+func (m *MockMediaRepository) GetAllImages(ctx context.Context, limit int) ([]models.Media, int, error) {
+	args := m.Called(ctx, limit)
+	if args.Get(0) == nil {
+		return nil, args.Int(1), args.Error(2)
+	}
+	return args.Get(0).([]models.Media), args.Int(1), args.Error(2)
+}
+
+func (m *MockMediaRepository) GetImages(ctx context.Context) ([]models.Media, error) {
 	args := m.Called(ctx)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -72,6 +83,43 @@ func (m *MockMediaRepository) GetAllImages(ctx context.Context) ([]models.Media,
 	return args.Get(0).([]models.Media), args.Error(1)
 }
 
+func (m *MockMediaRepository) ListOrphanMedia(ctx context.Context, createdBefore time.Time, afterID uuid.UUID, limit int) ([]models.Media, error) {
+	args := m.Called(ctx, createdBefore, afterID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Media), args.Error(1)
+}
+
+func (m *MockMediaRepository) DeleteOrphanMedia(ctx context.Context, id uuid.UUID) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+// passthroughTx выполняет функцию с исходным контекстом, без транзакции
+type passthroughTx struct{}
+
+func (passthroughTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// recordingAuditor запоминает события, переданные в журнал аудита
+type recordingAuditor struct {
+	entries []models.AuditEntry
+}
+
+func (a *recordingAuditor) Record(ctx context.Context, entry models.AuditEntry) {
+	a.entries = append(a.entries, entry)
+}
+
+func (a *recordingAuditor) actions() []string {
+	var actions []string
+	for _, entry := range a.entries {
+		actions = append(actions, entry.Action)
+	}
+	return actions
+}
+
 type MockFileStorage struct {
 	mock.Mock
 }
@@ -110,6 +158,12 @@ func (m *MockFileStorage) Delete(ctx context.Context, filePath string) error {
 	return args.Error(0)
 }
 
+func (m *MockFileStorage) Open(ctx context.Context, filePath string) (io.ReadCloser, error) {
+	args := m.Called(ctx, filePath)
+	file, _ := args.Get(0).(io.ReadCloser)
+	return file, args.Error(1)
+}
+
 func TestFileStorageMethods(t *testing.T) {
 	storageMock := new(MockFileStorage)
 
@@ -191,7 +245,7 @@ func TestMediaRepositoryMethods(t *testing.T) {
 	groupID := uuid.New()
 	mediaID := uuid.New()
 
-	repoMock.On("AddMediaGroupItems", mock.Anything, groupID, mediaID).Return(nil)
+	repoMock.On("AddMediaGroupItems", mock.Anything, groupID, []uuid.UUID{mediaID}).Return(nil)
 	repoMock.On("FindByID", mock.Anything, testMedia.ID).Return(testMedia, nil)
 	repoMock.On("UpdateMedia", mock.Anything, testMedia).Return(nil)
 
@@ -212,13 +266,13 @@ func TestAttachMediaToGroup(t *testing.T) {
 
 	log := slog.Default()
 
-	service := NewMediaService(log, mockRepo, storageMock)
+	service := NewMediaService(log, mockRepo, passthroughTx{}, storageMock, &recordingAuditor{})
 
 	validGroupID := uuid.New()
 	validMediaID := uuid.New()
 
 	t.Run("Succesfull add media", func(t *testing.T) {
-		mockRepo.On("AddMediaGroupItems", mock.Anything, validGroupID, validMediaID).
+		mockRepo.On("AddMediaGroupItems", mock.Anything, validGroupID, []uuid.UUID{validMediaID}).
 			Return(nil)
 
 		err := service.AttachMediaToGroup(context.Background(), validGroupID, []uuid.UUID{validMediaID})
@@ -237,8 +291,12 @@ func TestAttachMediaToGroup(t *testing.T) {
 	t.Run("Validation error, empty mediaID", func(t *testing.T) {
 		err := service.AttachMediaToGroup(context.Background(), validGroupID, []uuid.UUID{uuid.Nil})
 
-		assert.ErrorContains(t, err, "mediaID is required")
-		mockRepo.AssertNotCalled(t, "AddMediaToGroup")
+		var appErr *apperr.Error
+		require.ErrorAs(t, err, &appErr)
+		assert.Equal(t, apperr.KindInvalid, appErr.Kind)
+		require.Len(t, appErr.Fields, 1)
+		assert.Equal(t, "media_ids", appErr.Fields[0].Field)
+		mockRepo.AssertNotCalled(t, "AddMediaGroupItems", mock.Anything, validGroupID, []uuid.UUID{uuid.Nil})
 	})
 }
 
@@ -248,14 +306,14 @@ func TestAttachMedia(t *testing.T) {
 
 	log := slog.Default()
 
-	service := NewMediaService(log, mockRepo, storageMock)
+	service := NewMediaService(log, mockRepo, passthroughTx{}, storageMock, &recordingAuditor{})
 
 	validOwnerID := uuid.New()
 	description := "cats"
 
 	t.Run("Succesfull add media", func(t *testing.T) {
 		mockRepo.On("AddMediaGroup", mock.Anything, validOwnerID, description).
-			Return(nil)
+			Return(uuid.New(), nil)
 
 		_, err := service.AttachMedia(context.Background(), validOwnerID, description)
 
@@ -305,7 +363,7 @@ func TestMediaService_ListGroupMedia(t *testing.T) {
 
 	log := slog.Default()
 
-	service := NewMediaService(log, mockRepo, storageMock)
+	service := NewMediaService(log, mockRepo, passthroughTx{}, storageMock, &recordingAuditor{})
 
 	t.Run("Succesfull get media by group id", func(t *testing.T) {
 		mockRepo.On("GetMediaByGroupID", mock.Anything, testGroupID).Return(testMedia, nil)
@@ -325,3 +383,173 @@ func TestMediaService_ListGroupMedia(t *testing.T) {
 		mockRepo.AssertNotCalled(t, "GetMediaByGroupID")
 	})
 }
+
+func TestMediaService_UploadMediaGroup(t *testing.T) {
+	uploaderID := uuid.New()
+	groupID := uuid.New()
+	width, height := 800, 600
+
+	inputs := []dto.MediaUploadInput{
+		{UploaderID: uploaderID, File: &multipart.FileHeader{Filename: "a.jpg"}, MediaType: "photo", Width: &width, Height: &height},
+		{UploaderID: uploaderID, File: &multipart.FileHeader{Filename: "b.jpg"}, MediaType: "photo", Width: &width, Height: &height},
+	}
+	paths := []string{"uploads/a.jpg", "uploads/b.jpg"}
+	created := []*models.Media{{ID: uuid.New()}, {ID: uuid.New()}}
+
+	tests := []struct {
+		name      string
+		mockSetup func(repo *MockMediaRepository, storage *MockFileStorage)
+		wantErr   string
+	}{
+		{
+			name: "media and group saved together",
+			mockSetup: func(repo *MockMediaRepository, storage *MockFileStorage) {
+				storage.On("SaveMultiple", mock.Anything, mock.Anything, mock.Anything).
+					Return(paths, []int64{10, 20}, nil)
+				repo.On("CreateMultipleMedia", mock.Anything, mock.Anything).
+					Return(created, nil)
+				repo.On("AddMediaGroup", mock.Anything, uploaderID, "trip").Return(groupID, nil)
+				repo.On("AddMediaGroupItems", mock.Anything, groupID, []uuid.UUID{created[0].ID, created[1].ID}).
+					Return(nil)
+			},
+		},
+		{
+			name: "files removed when group items fail",
+			mockSetup: func(repo *MockMediaRepository, storage *MockFileStorage) {
+				storage.On("SaveMultiple", mock.Anything, mock.Anything, mock.Anything).
+					Return(paths, []int64{10, 20}, nil)
+				repo.On("CreateMultipleMedia", mock.Anything, mock.Anything).
+					Return(created, nil)
+				repo.On("AddMediaGroup", mock.Anything, uploaderID, "trip").Return(groupID, nil)
+				repo.On("AddMediaGroupItems", mock.Anything, groupID, mock.Anything).
+					Return(errors.New("db error"))
+				storage.On("Delete", mock.Anything, paths[0]).Return(nil).Once()
+				storage.On("Delete", mock.Anything, paths[1]).Return(nil).Once()
+			},
+			wantErr: "failed to attach media",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockMediaRepository)
+			storage := new(MockFileStorage)
+			tt.mockSetup(repo, storage)
+
+			service := NewMediaService(slog.Default(), repo, passthroughTx{}, storage, &recordingAuditor{})
+			resp, err := service.UploadMediaGroup(context.Background(), inputs, "trip")
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				assert.Nil(t, resp)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, groupID, resp.GroupID)
+				assert.Len(t, resp.Media, 2)
+			}
+
+			repo.AssertExpectations(t)
+			storage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMediaService_CollectGarbage(t *testing.T) {
+	orphan := models.Media{ID: uuid.New(), StoragePath: "uploads/u1/a.jpg", FileSize: 100}
+	reused := models.Media{ID: uuid.New(), StoragePath: "uploads/u1/b.jpg", FileSize: 200}
+	renditions := []string{"uploads/u1/large/a.jpg", "uploads/u1/medium/a.jpg", "uploads/u1/small/a.jpg"}
+
+	tests := []struct {
+		name           string
+		dryRun         bool
+		mockSetup      func(repo *MockMediaRepository, storage *MockFileStorage)
+		wantErr        string
+		wantMedia      []models.Media
+		wantFreed      int64
+		wantFileErrors int
+		wantAudit      []string
+	}{
+		{
+			name:   "dry run only lists media",
+			dryRun: true,
+			mockSetup: func(repo *MockMediaRepository, storage *MockFileStorage) {
+				repo.On("ListOrphanMedia", mock.Anything, mock.Anything, uuid.Nil, gcBatchSize).
+					Return([]models.Media{orphan, reused}, nil).Once()
+			},
+			wantMedia: []models.Media{orphan, reused},
+			wantFreed: 300,
+		},
+		{
+			name: "deletes rows, files and renditions",
+			mockSetup: func(repo *MockMediaRepository, storage *MockFileStorage) {
+				repo.On("ListOrphanMedia", mock.Anything, mock.Anything, uuid.Nil, gcBatchSize).
+					Return([]models.Media{orphan, reused}, nil).Once()
+				repo.On("DeleteOrphanMedia", mock.Anything, orphan.ID).Return(true, nil).Once()
+				repo.On("DeleteOrphanMedia", mock.Anything, reused.ID).Return(false, nil).Once()
+				storage.On("Delete", mock.Anything, orphan.StoragePath).Return(nil).Once()
+				storage.On("Delete", mock.Anything, renditions[0]).Return(os.ErrNotExist).Once()
+				storage.On("Delete", mock.Anything, renditions[1]).Return(os.ErrNotExist).Once()
+				storage.On("Delete", mock.Anything, renditions[2]).Return(errors.New("permission denied")).Once()
+			},
+			wantMedia:      []models.Media{orphan},
+			wantFreed:      100,
+			wantFileErrors: 1,
+			wantAudit:      []string{models.AuditMediaDelete},
+		},
+		{
+			name: "repository error stops collection",
+			mockSetup: func(repo *MockMediaRepository, storage *MockFileStorage) {
+				repo.On("ListOrphanMedia", mock.Anything, mock.Anything, uuid.Nil, gcBatchSize).
+					Return(nil, errors.New("db error")).Once()
+			},
+			wantErr: "db error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockMediaRepository)
+			storage := new(MockFileStorage)
+			tt.mockSetup(repo, storage)
+
+			auditor := &recordingAuditor{}
+			service := NewMediaService(slog.Default(), repo, passthroughTx{}, storage, auditor)
+			result, err := service.CollectGarbage(context.Background(), time.Hour, tt.dryRun)
+
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantMedia, result.Media)
+				assert.Equal(t, tt.wantFreed, result.FreedBytes)
+				assert.Equal(t, tt.wantFileErrors, result.FileErrors)
+				assert.Equal(t, tt.dryRun, result.DryRun)
+			}
+			assert.Equal(t, tt.wantAudit, auditor.actions())
+
+			repo.AssertExpectations(t)
+			storage.AssertExpectations(t)
+		})
+	}
+}
+
+func TestMediaService_CollectGarbage_Pages(t *testing.T) {
+	repo := new(MockMediaRepository)
+	storage := new(MockFileStorage)
+
+	firstPage := make([]models.Media, gcBatchSize)
+	for i := range firstPage {
+		firstPage[i] = models.Media{ID: uuid.New()}
+	}
+	last := firstPage[len(firstPage)-1].ID
+
+	repo.On("ListOrphanMedia", mock.Anything, mock.Anything, uuid.Nil, gcBatchSize).Return(firstPage, nil).Once()
+	repo.On("ListOrphanMedia", mock.Anything, mock.Anything, last, gcBatchSize).Return([]models.Media{}, nil).Once()
+
+	service := NewMediaService(slog.Default(), repo, passthroughTx{}, storage, &recordingAuditor{})
+	result, err := service.CollectGarbage(context.Background(), time.Hour, true)
+
+	require.NoError(t, err)
+	assert.Len(t, result.Media, gcBatchSize)
+	repo.AssertExpectations(t)
+}
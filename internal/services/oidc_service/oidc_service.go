@@ -0,0 +1,440 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"premium_caste/internal/domain/apperr"
+	"premium_caste/internal/domain/models"
+	"premium_caste/internal/lib/actor"
+	"premium_caste/internal/lib/logger/sl"
+	"premium_caste/internal/lib/oidc"
+	"premium_caste/internal/repository"
+	"premium_caste/internal/storage"
+	"premium_caste/internal/transport/http/dto"
+
+	"github.com/google/uuid"
+)
+
+// stateTTL время, за которое пользователь должен войти на странице провайдера и вернуться
+const stateTTL = 10 * time.Minute
+
+var (
+	ErrUnknownProvider     = apperr.NotFound("unknown_provider", "identity provider is not configured")
+	ErrProviderLoginFailed = apperr.Unauthorized("provider_login_failed", "identity provider did not confirm the sign-in")
+	ErrEmailRequired       = apperr.Invalid("identity_email_required", "identity provider did not share an email address")
+	ErrEmailNotVerified    = apperr.Forbidden("identity_email_not_verified", "identity provider has not verified the email address")
+	ErrLastLoginMethod     = apperr.Conflict("last_login_method", "cannot unlink the only way to sign in, set a password first")
+	ErrEmailTaken          = apperr.Conflict("identity_email_taken", "an account with this email already exists, sign in and link the provider from your profile")
+)
+
+// IdentityProvider провайдер OpenID Connect. Реализуется oidc.Provider
+type IdentityProvider interface {
+	AuthCodeURL(ctx context.Context, state, nonce, verifier string) (string, error)
+	Exchange(ctx context.Context, code, verifier, nonce string) (oidc.Identity, error)
+}
+
+// Sessions выдает токены пользователю, которого подтвердил провайдер. Реализуется UserService,
+// поэтому блокировка и второй фактор проверяются так же, как при входе по паролю
+type Sessions interface {
+	LoginExternal(ctx context.Context, userID uuid.UUID) (*models.LoginResult, error)
+}
+
+// Auditor журнал аудита
+type Auditor interface {
+	Record(ctx context.Context, entry models.AuditEntry)
+}
+
+type OIDCService struct {
+	log        *slog.Logger
+	providers  map[string]IdentityProvider
+	users      repository.UserRepository
+	identities repository.IdentityRepository
+	states     repository.OIDCStateRepository
+	tx         repository.Transactor
+	sessions   Sessions
+	auditor    Auditor
+}
+
+func NewOIDCService(log *slog.Logger, providers map[string]IdentityProvider, users repository.UserRepository, identities repository.IdentityRepository, states repository.OIDCStateRepository, tx repository.Transactor, sessions Sessions, auditor Auditor) *OIDCService {
+	return &OIDCService{
+		log:        log,
+		providers:  providers,
+		users:      users,
+		identities: identities,
+		states:     states,
+		tx:         tx,
+		sessions:   sessions,
+		auditor:    auditor,
+	}
+}
+
+// Providers возвращает имена настроенных провайдеров по алфавиту
+func (s *OIDCService) Providers() []string {
+	names := make([]string, 0, len(s.providers))
+	for name := range s.providers {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
+
+// BeginLogin начинает вход через провайдера и возвращает адрес его страницы входа
+func (s *OIDCService) BeginLogin(ctx context.Context, provider string) (*dto.OIDCStartResponse, error) {
+	const op = "oidc_service.BeginLogin"
+
+	authURL, err := s.begin(ctx, provider, nil)
+	if err != nil {
+		s.log.Error("failed to begin login", slog.String("op", op), slog.String("provider", provider), sl.Err(err))
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &dto.OIDCStartResponse{AuthorizationURL: authURL}, nil
+}
+
+// FinishLogin завершает вход кодом, с которым провайдер вернул пользователя.
+// Для учетной записи провайдера, которая еще не привязана, создается новый пользователь.
+// Если email уже занят, возвращается ErrEmailTaken: привязать провайдера можно только
+// из профиля после входа, через BeginLink
+func (s *OIDCService) FinishLogin(ctx context.Context, provider, code, state string) (*models.LoginResult, error) {
+	const op = "oidc_service.FinishLogin"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("provider", provider),
+	)
+
+	st, err := s.takeState(ctx, provider, state)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if st.LinkTo != nil {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrInvalidOIDCState)
+	}
+
+	identity, err := s.exchange(ctx, provider, code, st, log)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	userID, err := s.identities.IdentityUserID(ctx, provider, identity.Subject)
+	switch {
+	case err == nil:
+		if err := s.identities.TouchIdentity(ctx, provider, identity.Subject); err != nil {
+			log.Error("failed to update identity last login", sl.Err(err))
+		}
+	case errors.Is(err, storage.ErrIdentityNotFound):
+		userID, err = s.resolveUser(ctx, provider, identity, log)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	default:
+		log.Error("failed to find identity", sl.Err(err))
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	result, err := s.sessions.LoginExternal(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return result, nil
+}
+
+// BeginLink начинает привязку учетной записи провайдера к вошедшему пользователю
+func (s *OIDCService) BeginLink(ctx context.Context, userID uuid.UUID, provider string) (*dto.OIDCStartResponse, error) {
+	const op = "oidc_service.BeginLink"
+
+	authURL, err := s.begin(ctx, provider, &userID)
+	if err != nil {
+		s.log.Error("failed to begin link", slog.String("op", op), slog.String("provider", provider), sl.Err(err))
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &dto.OIDCStartResponse{AuthorizationURL: authURL}, nil
+}
+
+// FinishLink привязывает учетную запись провайдера к пользователю, начавшему привязку
+func (s *OIDCService) FinishLink(ctx context.Context, userID uuid.UUID, provider, code, state string) (*dto.IdentityResponse, error) {
+	const op = "oidc_service.FinishLink"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("provider", provider),
+		slog.String("user_id", userID.String()),
+	)
+
+	st, err := s.takeState(ctx, provider, state)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	// Привязку должен завершить тот же пользователь, иначе чужой код мог бы привязать
+	// учетную запись атакующего к жертве
+	if st.LinkTo == nil || *st.LinkTo != userID {
+		return nil, fmt.Errorf("%s: %w", op, storage.ErrInvalidOIDCState)
+	}
+
+	identity, err := s.exchange(ctx, provider, code, st, log)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	linked, err := s.link(ctx, userID, provider, identity)
+	if err != nil {
+		log.Warn("failed to link identity", sl.Err(err))
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("identity linked")
+
+	return mapToIdentityResponse(linked), nil
+}
+
+// ListIdentities возвращает учетные записи провайдеров пользователя
+func (s *OIDCService) ListIdentities(ctx context.Context, userID uuid.UUID) ([]dto.IdentityResponse, error) {
+	const op = "oidc_service.ListIdentities"
+
+	identities, err := s.identities.ListIdentities(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	resp := make([]dto.IdentityResponse, 0, len(identities))
+	for _, identity := range identities {
+		resp = append(resp, *mapToIdentityResponse(identity))
+	}
+
+	return resp, nil
+}
+
+// UnlinkIdentity отвязывает учетную запись провайдера. Последнюю запись пользователя
+// без пароля отвязать нельзя: он не сможет войти
+func (s *OIDCService) UnlinkIdentity(ctx context.Context, userID uuid.UUID, provider string) error {
+	const op = "oidc_service.UnlinkIdentity"
+
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("provider", provider),
+		slog.String("user_id", userID.String()),
+	)
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		identities, err := s.identities.ListIdentities(ctx, userID)
+		if err != nil {
+			return err
+		}
+
+		if len(identities) == 1 && identities[0].Provider == provider {
+			hash, err := s.users.PasswordHash(ctx, userID)
+			if err != nil {
+				return err
+			}
+			if len(hash) == 0 {
+				return ErrLastLoginMethod
+			}
+		}
+
+		return s.identities.UnlinkIdentity(ctx, userID, provider)
+	})
+	if err != nil {
+		log.Warn("failed to unlink identity", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("identity unlinked")
+	s.record(ctx, models.AuditUserIdentityUnlink, userID, provider)
+
+	return nil
+}
+
+// begin сохраняет незавершенный вход и возвращает адрес страницы входа провайдера
+func (s *OIDCService) begin(ctx context.Context, provider string, linkTo *uuid.UUID) (string, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", ErrUnknownProvider
+	}
+
+	state, err := oidc.NewNonce()
+	if err != nil {
+		return "", err
+	}
+	nonce, err := oidc.NewNonce()
+	if err != nil {
+		return "", err
+	}
+	verifier, err := oidc.NewVerifier()
+	if err != nil {
+		return "", err
+	}
+
+	authURL, err := p.AuthCodeURL(ctx, state, nonce, verifier)
+	if err != nil {
+		return "", err
+	}
+
+	err = s.states.SaveOIDCState(ctx, stateKey(state), models.OIDCState{
+		Provider: provider,
+		Verifier: verifier,
+		Nonce:    nonce,
+		LinkTo:   linkTo,
+	}, stateTTL)
+	if err != nil {
+		return "", err
+	}
+
+	return authURL, nil
+}
+
+// takeState погашает незавершенный вход. Вход, начатый для другого провайдера, не подходит
+func (s *OIDCService) takeState(ctx context.Context, provider, state string) (models.OIDCState, error) {
+	if _, ok := s.providers[provider]; !ok {
+		return models.OIDCState{}, ErrUnknownProvider
+	}
+
+	st, err := s.states.TakeOIDCState(ctx, stateKey(state))
+	if err != nil {
+		return models.OIDCState{}, err
+	}
+	if st.Provider != provider {
+		return models.OIDCState{}, storage.ErrInvalidOIDCState
+	}
+
+	return st, nil
+}
+
+// exchange обменивает код на подтвержденного пользователя. Подробности ошибки
+// провайдера остаются в логе, клиент получает ErrProviderLoginFailed
+func (s *OIDCService) exchange(ctx context.Context, provider, code string, st models.OIDCState, log *slog.Logger) (oidc.Identity, error) {
+	identity, err := s.providers[provider].Exchange(ctx, code, st.Verifier, st.Nonce)
+	if err != nil {
+		log.Warn("provider did not confirm sign-in", sl.Err(err))
+
+		return oidc.Identity{}, ErrProviderLoginFailed
+	}
+
+	return identity, nil
+}
+
+// resolveUser создает пользователя для новой учетной записи провайдера. Email должен быть
+// подтвержден провайдером. К существующему пользователю с тем же email запись не
+// привязывается: локальный email не подтверждается, и атакующий, заранее
+// зарегистрировавший чужой адрес с паролем, сохранил бы доступ после входа владельца
+func (s *OIDCService) resolveUser(ctx context.Context, provider string, identity oidc.Identity, log *slog.Logger) (uuid.UUID, error) {
+	email := strings.TrimSpace(identity.Email)
+	if email == "" {
+		return uuid.Nil, ErrEmailRequired
+	}
+	if !identity.EmailVerified {
+		log.Info("identity email is not verified")
+
+		return uuid.Nil, ErrEmailNotVerified
+	}
+
+	existing, err := s.users.UserByIdentifier(ctx, email)
+	switch {
+	case err == nil:
+		log.Warn("identity email belongs to existing user", slog.String("user_id", existing.ID.String()))
+
+		return uuid.Nil, ErrEmailTaken
+	case !errors.Is(err, storage.ErrUserNotFound):
+		return uuid.Nil, err
+	}
+
+	var userID uuid.UUID
+	err = s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		userID, err = s.users.SaveUser(ctx, models.User{
+			Name:     displayName(identity),
+			Email:    email,
+			Password: []byte{}, // Без пароля: пустой хеш не совпадает ни с одним паролем
+			Role:     models.RoleUser,
+		})
+		if err != nil {
+			return err
+		}
+
+		return s.identities.LinkIdentity(ctx, models.UserIdentity{
+			Provider: provider,
+			Subject:  identity.Subject,
+			UserID:   userID,
+			Email:    email,
+		})
+	})
+	if err != nil {
+		log.Error("failed to create user", sl.Err(err))
+
+		return uuid.Nil, err
+	}
+
+	log.Info("user registered via identity provider", slog.String("user_id", userID.String()))
+	s.record(actor.WithUser(ctx, userID), models.AuditUserRegister, userID, provider)
+
+	return userID, nil
+}
+
+func (s *OIDCService) link(ctx context.Context, userID uuid.UUID, provider string, identity oidc.Identity) (models.UserIdentity, error) {
+	linked := models.UserIdentity{
+		Provider:  provider,
+		Subject:   identity.Subject,
+		UserID:    userID,
+		Email:     identity.Email,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := s.identities.LinkIdentity(ctx, linked); err != nil {
+		return models.UserIdentity{}, err
+	}
+
+	s.record(actor.WithUser(ctx, userID), models.AuditUserIdentityLink, userID, provider)
+
+	return linked, nil
+}
+
+func (s *OIDCService) record(ctx context.Context, action string, userID uuid.UUID, provider string) {
+	s.auditor.Record(ctx, models.AuditEntry{
+		Action:     action,
+		TargetType: models.AuditTargetUser,
+		TargetID:   userID.String(),
+		After:      map[string]string{"provider": provider},
+	})
+}
+
+// displayName имя нового пользователя. Если провайдер его не передал, берется начало email
+func displayName(identity oidc.Identity) string {
+	name := strings.TrimSpace(identity.Name)
+	if len([]rune(name)) >= 2 {
+		return name
+	}
+
+	local, _, _ := strings.Cut(identity.Email, "@")
+	if len([]rune(local)) >= 2 {
+		return local
+	}
+
+	return "User"
+}
+
+func stateKey(state string) string {
+	sum := sha256.Sum256([]byte(state))
+	return hex.EncodeToString(sum[:])
+}
+
+func mapToIdentityResponse(identity models.UserIdentity) *dto.IdentityResponse {
+	return &dto.IdentityResponse{
+		Provider:    identity.Provider,
+		Email:       identity.Email,
+		CreatedAt:   identity.CreatedAt,
+		LastLoginAt: identity.LastLoginAt,
+	}
+}
@@ -0,0 +1,401 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"premium_caste/internal/domain/models"
+	"premium_caste/internal/lib/oidc"
+	"premium_caste/internal/lib/oidc/oidctest"
+	"premium_caste/internal/repository"
+	"premium_caste/internal/storage"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testProvider = "test"
+
+// fakeUsers хранит пользователей в памяти. Методы, которые сервис не вызывает,
+// остаются от nil-интерфейса и паникуют
+type fakeUsers struct {
+	repository.UserRepository
+	users map[uuid.UUID]models.User
+}
+
+func newFakeUsers(users ...models.User) *fakeUsers {
+	f := &fakeUsers{users: make(map[uuid.UUID]models.User)}
+	for _, user := range users {
+		f.users[user.ID] = user
+	}
+	return f
+}
+
+func (f *fakeUsers) UserByIdentifier(ctx context.Context, identifier string) (models.User, error) {
+	for _, user := range f.users {
+		if user.Email == identifier {
+			return user, nil
+		}
+	}
+	return models.User{}, storage.ErrUserNotFound
+}
+
+func (f *fakeUsers) SaveUser(ctx context.Context, user models.User) (uuid.UUID, error) {
+	user.ID = uuid.New()
+	f.users[user.ID] = user
+	return user.ID, nil
+}
+
+func (f *fakeUsers) PasswordHash(ctx context.Context, userID uuid.UUID) ([]byte, error) {
+	user, ok := f.users[userID]
+	if !ok {
+		return nil, storage.ErrUserNotFound
+	}
+	return user.Password, nil
+}
+
+// memoryIdentities хранит привязки провайдеров в памяти с теми же ограничениями уникальности, что и таблица
+type memoryIdentities struct {
+	identities []models.UserIdentity
+}
+
+func (m *memoryIdentities) IdentityUserID(ctx context.Context, provider, subject string) (uuid.UUID, error) {
+	for _, identity := range m.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return identity.UserID, nil
+		}
+	}
+	return uuid.Nil, storage.ErrIdentityNotFound
+}
+
+func (m *memoryIdentities) LinkIdentity(ctx context.Context, identity models.UserIdentity) error {
+	for _, existing := range m.identities {
+		if existing.Provider == identity.Provider && (existing.Subject == identity.Subject || existing.UserID == identity.UserID) {
+			return storage.ErrIdentityLinked
+		}
+	}
+	m.identities = append(m.identities, identity)
+	return nil
+}
+
+func (m *memoryIdentities) TouchIdentity(ctx context.Context, provider, subject string) error {
+	return nil
+}
+
+func (m *memoryIdentities) ListIdentities(ctx context.Context, userID uuid.UUID) ([]models.UserIdentity, error) {
+	var identities []models.UserIdentity
+	for _, identity := range m.identities {
+		if identity.UserID == userID {
+			identities = append(identities, identity)
+		}
+	}
+	return identities, nil
+}
+
+func (m *memoryIdentities) UnlinkIdentity(ctx context.Context, userID uuid.UUID, provider string) error {
+	for i, identity := range m.identities {
+		if identity.UserID == userID && identity.Provider == provider {
+			m.identities = append(m.identities[:i], m.identities[i+1:]...)
+			return nil
+		}
+	}
+	return storage.ErrIdentityNotFound
+}
+
+type memoryStates map[string]models.OIDCState
+
+func (m memoryStates) SaveOIDCState(ctx context.Context, key string, state models.OIDCState, ttl time.Duration) error {
+	m[key] = state
+	return nil
+}
+
+func (m memoryStates) TakeOIDCState(ctx context.Context, key string) (models.OIDCState, error) {
+	state, ok := m[key]
+	if !ok {
+		return models.OIDCState{}, storage.ErrInvalidOIDCState
+	}
+	delete(m, key)
+	return state, nil
+}
+
+// recordingSessions запоминает, кому выданы токены
+type recordingSessions struct {
+	logins []uuid.UUID
+}
+
+func (s *recordingSessions) LoginExternal(ctx context.Context, userID uuid.UUID) (*models.LoginResult, error) {
+	s.logins = append(s.logins, userID)
+	return &models.LoginResult{Tokens: &models.TokenPair{UserID: userID, AccessToken: "access"}}, nil
+}
+
+// passthroughTx выполняет функцию с исходным контекстом, без транзакции
+type passthroughTx struct{}
+
+func (passthroughTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// recordingAuditor запоминает события, переданные в журнал аудита
+type recordingAuditor struct {
+	entries []models.AuditEntry
+}
+
+func (a *recordingAuditor) Record(ctx context.Context, entry models.AuditEntry) {
+	a.entries = append(a.entries, entry)
+}
+
+func (a *recordingAuditor) actions() []string {
+	var actions []string
+	for _, entry := range a.entries {
+		actions = append(actions, entry.Action)
+	}
+	return actions
+}
+
+type oidcFixture struct {
+	service    *OIDCService
+	issuer     *oidctest.Issuer
+	users      *fakeUsers
+	identities *memoryIdentities
+	sessions   *recordingSessions
+	auditor    *recordingAuditor
+}
+
+func newOIDCFixture(t *testing.T, users ...models.User) *oidcFixture {
+	issuer := oidctest.NewIssuer(t)
+	f := &oidcFixture{
+		issuer:     issuer,
+		users:      newFakeUsers(users...),
+		identities: &memoryIdentities{},
+		sessions:   &recordingSessions{},
+		auditor:    &recordingAuditor{},
+	}
+
+	providers := map[string]IdentityProvider{
+		testProvider: oidc.NewProvider(issuer.Config("premium-caste", "http://localhost:3000/auth/test/callback"), nil),
+	}
+	f.service = NewOIDCService(slog.Default(), providers, f.users, f.identities, memoryStates{}, passthroughTx{}, f.sessions, f.auditor)
+
+	return f
+}
+
+// login проходит вход у провайдера и возвращает code и state для callback
+func (f *oidcFixture) login(t *testing.T, identity oidc.Identity) (string, string) {
+	start, err := f.service.BeginLogin(context.Background(), testProvider)
+	require.NoError(t, err)
+
+	return f.issuer.Authorize(t, start.AuthorizationURL, identity)
+}
+
+func TestOIDCService_FinishLogin(t *testing.T) {
+	ctx := context.Background()
+	existing := models.User{ID: uuid.New(), Name: "Existing", Email: "user@example.com", Password: []byte("hash")}
+
+	tests := []struct {
+		name       string
+		identity   oidc.Identity
+		linked     bool
+		wantErr    error
+		wantNew    bool
+		wantLinked bool
+		wantAudit  []string
+	}{
+		{
+			name:       "new user is registered",
+			identity:   oidc.Identity{Subject: "sub-new", Email: "new@example.com", EmailVerified: true, Name: "New User"},
+			wantNew:    true,
+			wantLinked: true,
+			wantAudit:  []string{models.AuditUserRegister},
+		},
+		{
+			name:     "verified email of existing user is not linked",
+			identity: oidc.Identity{Subject: "sub-existing", Email: existing.Email, EmailVerified: true},
+			wantErr:  ErrEmailTaken,
+		},
+		{
+			name:     "email of existing user with spaces is not linked",
+			identity: oidc.Identity{Subject: "sub-existing", Email: " user@example.com ", EmailVerified: true},
+			wantErr:  ErrEmailTaken,
+		},
+		{
+			name:     "unverified email is rejected",
+			identity: oidc.Identity{Subject: "sub-existing", Email: existing.Email},
+			wantErr:  ErrEmailNotVerified,
+		},
+		{
+			name:     "missing email is rejected",
+			identity: oidc.Identity{Subject: "sub-new", EmailVerified: true},
+			wantErr:  ErrEmailRequired,
+		},
+		{
+			name:     "linked identity logs in",
+			identity: oidc.Identity{Subject: "sub-linked", Email: "changed@example.com"},
+			linked:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newOIDCFixture(t, existing)
+			if tt.linked {
+				require.NoError(t, f.identities.LinkIdentity(ctx, models.UserIdentity{Provider: testProvider, Subject: tt.identity.Subject, UserID: existing.ID}))
+			}
+
+			code, state := f.login(t, tt.identity)
+			result, err := f.service.FinishLogin(ctx, testProvider, code, state)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, f.sessions.logins)
+				assert.Empty(t, f.identities.identities)
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, result.Tokens)
+
+			userID, err := f.identities.IdentityUserID(ctx, testProvider, tt.identity.Subject)
+			require.NoError(t, err)
+			assert.Equal(t, []uuid.UUID{userID}, f.sessions.logins)
+
+			if tt.wantNew {
+				created := f.users.users[userID]
+				assert.Equal(t, tt.identity.Name, created.Name)
+				assert.Equal(t, tt.identity.Email, created.Email)
+				assert.Empty(t, created.Password)
+				assert.Equal(t, models.RoleUser, created.Role)
+			} else {
+				assert.Equal(t, existing.ID, userID)
+			}
+			assert.Equal(t, tt.wantAudit, f.auditor.actions())
+		})
+	}
+}
+
+func TestOIDCService_FinishLogin_State(t *testing.T) {
+	ctx := context.Background()
+	identity := oidc.Identity{Subject: "sub", Email: "user@example.com", EmailVerified: true}
+
+	t.Run("state is single use", func(t *testing.T) {
+		f := newOIDCFixture(t)
+		code, state := f.login(t, identity)
+
+		_, err := f.service.FinishLogin(ctx, testProvider, code, state)
+		require.NoError(t, err)
+
+		_, err = f.service.FinishLogin(ctx, testProvider, code, state)
+		assert.ErrorIs(t, err, storage.ErrInvalidOIDCState)
+	})
+
+	t.Run("unknown state", func(t *testing.T) {
+		f := newOIDCFixture(t)
+		code, _ := f.login(t, identity)
+
+		_, err := f.service.FinishLogin(ctx, testProvider, code, "forged")
+		assert.ErrorIs(t, err, storage.ErrInvalidOIDCState)
+	})
+
+	t.Run("provider rejects code", func(t *testing.T) {
+		f := newOIDCFixture(t)
+		_, state := f.login(t, identity)
+
+		_, err := f.service.FinishLogin(ctx, testProvider, "forged", state)
+		assert.ErrorIs(t, err, ErrProviderLoginFailed)
+	})
+
+	t.Run("link state cannot be used to log in", func(t *testing.T) {
+		f := newOIDCFixture(t)
+		start, err := f.service.BeginLink(ctx, uuid.New(), testProvider)
+		require.NoError(t, err)
+		code, state := f.issuer.Authorize(t, start.AuthorizationURL, identity)
+
+		_, err = f.service.FinishLogin(ctx, testProvider, code, state)
+		assert.ErrorIs(t, err, storage.ErrInvalidOIDCState)
+	})
+
+	t.Run("unknown provider", func(t *testing.T) {
+		f := newOIDCFixture(t)
+
+		_, err := f.service.BeginLogin(ctx, "other")
+		assert.ErrorIs(t, err, ErrUnknownProvider)
+	})
+}
+
+func TestOIDCService_Link(t *testing.T) {
+	ctx := context.Background()
+	owner := models.User{ID: uuid.New(), Email: "owner@example.com", Password: []byte("hash")}
+	other := models.User{ID: uuid.New(), Email: "other@example.com", Password: []byte("hash")}
+	identity := oidc.Identity{Subject: "sub", Email: "someone@example.com"}
+
+	t.Run("linked to user who started", func(t *testing.T) {
+		f := newOIDCFixture(t, owner, other)
+		start, err := f.service.BeginLink(ctx, owner.ID, testProvider)
+		require.NoError(t, err)
+		code, state := f.issuer.Authorize(t, start.AuthorizationURL, identity)
+
+		linked, err := f.service.FinishLink(ctx, owner.ID, testProvider, code, state)
+		require.NoError(t, err)
+		assert.Equal(t, testProvider, linked.Provider)
+		assert.Equal(t, identity.Email, linked.Email)
+
+		identities, err := f.service.ListIdentities(ctx, owner.ID)
+		require.NoError(t, err)
+		require.Len(t, identities, 1)
+		assert.Equal(t, []string{models.AuditUserIdentityLink}, f.auditor.actions())
+	})
+
+	t.Run("other user cannot finish link", func(t *testing.T) {
+		f := newOIDCFixture(t, owner, other)
+		start, err := f.service.BeginLink(ctx, owner.ID, testProvider)
+		require.NoError(t, err)
+		code, state := f.issuer.Authorize(t, start.AuthorizationURL, identity)
+
+		_, err = f.service.FinishLink(ctx, other.ID, testProvider, code, state)
+		assert.ErrorIs(t, err, storage.ErrInvalidOIDCState)
+		assert.Empty(t, f.identities.identities)
+	})
+
+	t.Run("identity linked to another user", func(t *testing.T) {
+		f := newOIDCFixture(t, owner, other)
+		require.NoError(t, f.identities.LinkIdentity(ctx, models.UserIdentity{Provider: testProvider, Subject: identity.Subject, UserID: other.ID}))
+		start, err := f.service.BeginLink(ctx, owner.ID, testProvider)
+		require.NoError(t, err)
+		code, state := f.issuer.Authorize(t, start.AuthorizationURL, identity)
+
+		_, err = f.service.FinishLink(ctx, owner.ID, testProvider, code, state)
+		assert.ErrorIs(t, err, storage.ErrIdentityLinked)
+	})
+}
+
+func TestOIDCService_UnlinkIdentity(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		password  []byte
+		wantErr   error
+		wantAudit []string
+	}{
+		{name: "user with password", password: []byte("hash"), wantAudit: []string{models.AuditUserIdentityUnlink}},
+		{name: "last way to sign in", password: []byte{}, wantErr: ErrLastLoginMethod},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := models.User{ID: uuid.New(), Password: tt.password}
+			f := newOIDCFixture(t, user)
+			require.NoError(t, f.identities.LinkIdentity(ctx, models.UserIdentity{Provider: testProvider, Subject: "sub", UserID: user.ID}))
+
+			err := f.service.UnlinkIdentity(ctx, user.ID, testProvider)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Len(t, f.identities.identities, 1)
+			} else {
+				require.NoError(t, err)
+				assert.Empty(t, f.identities.identities)
+			}
+			assert.Equal(t, tt.wantAudit, f.auditor.actions())
+		})
+	}
+}
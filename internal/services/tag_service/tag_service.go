@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"premium_caste/internal/repository"
+	"premium_caste/internal/storage"
+	"premium_caste/internal/transport/http/dto"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	// maxTagLength максимальная длина тега галереи
+	maxTagLength = 50
+	// defaultTagsLimit размер справочника тегов по умолчанию
+	defaultTagsLimit = 100
+	// maxTagsLimit максимальный размер справочника тегов за один запрос
+	maxTagsLimit = 500
+)
+
+// TagService справочник тегов галерей
+type TagService struct {
+	log  *slog.Logger
+	repo repository.GalleryTagRepository
+}
+
+func NewTagService(log *slog.Logger, repo repository.GalleryTagRepository) *TagService {
+	return &TagService{
+		log:  log,
+		repo: repo,
+	}
+}
+
+// ListTags возвращает теги галерей с количеством использований.
+// prefix ограничивает выдачу тегами, начинающимися с него (для автодополнения)
+func (s *TagService) ListTags(ctx context.Context, status, prefix string, limit int) ([]dto.GalleryTagResponse, error) {
+	const op = "tag_service.ListTags"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("status", status),
+		slog.String("prefix", prefix),
+	)
+
+	if limit < 1 {
+		limit = defaultTagsLimit
+	}
+	if limit > maxTagsLimit {
+		limit = maxTagsLimit
+	}
+
+	tags, err := s.repo.ListTags(ctx, status, strings.ToLower(strings.TrimSpace(prefix)), limit)
+	if err != nil {
+		log.Error("failed to list tags", slog.Any("err", err))
+		return nil, fmt.Errorf("failed to list tags: %w", err)
+	}
+
+	response := make([]dto.GalleryTagResponse, 0, len(tags))
+	for _, tag := range tags {
+		response = append(response, dto.GalleryTagResponse{Name: tag.Name, Count: tag.Count})
+	}
+
+	return response, nil
+}
+
+// RenameTag переименовывает тег во всех галереях. Если новый тег уже используется,
+// теги объединяются
+func (s *TagService) RenameTag(ctx context.Context, req dto.RenameGalleryTagRequest) (*dto.GalleryTagOperationResponse, error) {
+	const op = "tag_service.RenameTag"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("from", req.From),
+		slog.String("to", req.To),
+	)
+
+	log.Info("renaming tag")
+
+	from, err := normalizeTag(req.From)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := normalizeTag(req.To)
+	if err != nil {
+		return nil, err
+	}
+
+	if from == to {
+		return nil, storage.ErrInvalidTag.WithDetail("new name must differ from the current one")
+	}
+
+	return s.merge(ctx, log, []string{from}, to, req.KeepAlias)
+}
+
+// MergeTags заменяет несколько тегов одним во всех галереях
+func (s *TagService) MergeTags(ctx context.Context, req dto.MergeGalleryTagsRequest) (*dto.GalleryTagOperationResponse, error) {
+	const op = "tag_service.MergeTags"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.Any("sources", req.Sources),
+		slog.String("target", req.Target),
+	)
+
+	log.Info("merging tags")
+
+	target, err := normalizeTag(req.Target)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{target: true}
+	sources := make([]string, 0, len(req.Sources))
+	for _, source := range req.Sources {
+		tag, err := normalizeTag(source)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			sources = append(sources, tag)
+		}
+	}
+
+	if len(sources) == 0 {
+		return nil, storage.ErrInvalidTag.WithDetail("at least one source tag different from target is required")
+	}
+
+	return s.merge(ctx, log, sources, target, req.KeepAliases)
+}
+
+// ListAliases возвращает алиасы тегов
+func (s *TagService) ListAliases(ctx context.Context) ([]dto.GalleryTagAliasResponse, error) {
+	const op = "tag_service.ListAliases"
+
+	aliases, err := s.repo.ListAliases(ctx)
+	if err != nil {
+		s.log.Error("failed to list tag aliases", slog.String("op", op), slog.Any("err", err))
+		return nil, fmt.Errorf("failed to list tag aliases: %w", err)
+	}
+
+	response := make([]dto.GalleryTagAliasResponse, 0, len(aliases))
+	for _, alias := range aliases {
+		response = append(response, dto.GalleryTagAliasResponse{
+			Alias:     alias.Alias,
+			Tag:       alias.Tag,
+			CreatedAt: alias.CreatedAt,
+		})
+	}
+
+	return response, nil
+}
+
+// DeleteAlias удаляет алиас, после чего старое имя тега перестает вести на актуальный
+func (s *TagService) DeleteAlias(ctx context.Context, alias string) error {
+	const op = "tag_service.DeleteAlias"
+	log := s.log.With(
+		slog.String("op", op),
+		slog.String("alias", alias),
+	)
+
+	tag, err := normalizeTag(alias)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.DeleteAlias(ctx, tag); err != nil {
+		log.Error("failed to delete tag alias", slog.Any("err", err))
+		return fmt.Errorf("failed to delete tag alias: %w", err)
+	}
+
+	log.Info("tag alias deleted")
+	return nil
+}
+
+func (s *TagService) merge(ctx context.Context, log *slog.Logger, sources []string, target string, keepAliases bool) (*dto.GalleryTagOperationResponse, error) {
+	affected, err := s.repo.MergeTags(ctx, sources, target, keepAliases)
+	if err != nil {
+		log.Error("failed to merge tags", slog.Any("err", err))
+		return nil, fmt.Errorf("failed to merge tags: %w", err)
+	}
+
+	log.Info("tags merged", slog.Int("galleries", affected))
+	return &dto.GalleryTagOperationResponse{Tag: target, Galleries: affected}, nil
+}
+
+// normalizeTag приводит тег к формату, в котором теги хранятся в галереях
+func normalizeTag(tag string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(tag))
+	if normalized == "" {
+		return "", storage.ErrInvalidTag.WithDetail("tag is empty")
+	}
+
+	if utf8.RuneCountInString(normalized) > maxTagLength {
+		return "", storage.ErrInvalidTag.WithDetail("tag %q is longer than %d characters", normalized, maxTagLength)
+	}
+
+	return normalized, nil
+}
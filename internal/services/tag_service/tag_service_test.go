@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"premium_caste/internal/domain/models"
+	"premium_caste/internal/storage"
+	"premium_caste/internal/transport/http/dto"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockGalleryTagRepository struct {
+	mock.Mock
+}
+
+func (m *MockGalleryTagRepository) ListTags(ctx context.Context, statusFilter, prefix string, limit int) ([]models.GalleryTag, error) {
+	args := m.Called(ctx, statusFilter, prefix, limit)
+	tags, _ := args.Get(0).([]models.GalleryTag)
+	return tags, args.Error(1)
+}
+
+func (m *MockGalleryTagRepository) MergeTags(ctx context.Context, sources []string, target string, keepAliases bool) (int, error) {
+	args := m.Called(ctx, sources, target, keepAliases)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockGalleryTagRepository) ListAliases(ctx context.Context) ([]models.GalleryTagAlias, error) {
+	args := m.Called(ctx)
+	aliases, _ := args.Get(0).([]models.GalleryTagAlias)
+	return aliases, args.Error(1)
+}
+
+func (m *MockGalleryTagRepository) DeleteAlias(ctx context.Context, alias string) error {
+	args := m.Called(ctx, alias)
+	return args.Error(0)
+}
+
+func TestTagService_ListTags(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		prefix    string
+		limit     int
+		mockSetup func(m *MockGalleryTagRepository)
+		want      []dto.GalleryTagResponse
+		wantErr   bool
+	}{
+		{
+			name:   "autocomplete by prefix",
+			prefix: " Nat",
+			limit:  10,
+			mockSetup: func(m *MockGalleryTagRepository) {
+				m.On("ListTags", ctx, "published", "nat", 10).
+					Return([]models.GalleryTag{{Name: "nature", Count: 3}}, nil).Once()
+			},
+			want: []dto.GalleryTagResponse{{Name: "nature", Count: 3}},
+		},
+		{
+			name:  "limit clamped",
+			limit: 10000,
+			mockSetup: func(m *MockGalleryTagRepository) {
+				m.On("ListTags", ctx, "published", "", maxTagsLimit).
+					Return([]models.GalleryTag{}, nil).Once()
+			},
+			want: []dto.GalleryTagResponse{},
+		},
+		{
+			name: "repository error",
+			mockSetup: func(m *MockGalleryTagRepository) {
+				m.On("ListTags", ctx, "published", "", defaultTagsLimit).
+					Return(nil, errors.New("db down")).Once()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := new(MockGalleryTagRepository)
+			tt.mockSetup(repo)
+			service := NewTagService(slog.Default(), repo)
+
+			tags, err := service.ListTags(ctx, "published", tt.prefix, tt.limit)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, tags)
+			}
+			repo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestTagService_RenameAndMerge(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("rename normalizes names", func(t *testing.T) {
+		repo := new(MockGalleryTagRepository)
+		repo.On("MergeTags", ctx, []string{"natrue"}, "nature", true).Return(4, nil).Once()
+		service := NewTagService(slog.Default(), repo)
+
+		resp, err := service.RenameTag(ctx, dto.RenameGalleryTagRequest{From: " Natrue", To: "NATURE ", KeepAlias: true})
+
+		assert.NoError(t, err)
+		assert.Equal(t, &dto.GalleryTagOperationResponse{Tag: "nature", Galleries: 4}, resp)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("rename to the same name", func(t *testing.T) {
+		service := NewTagService(slog.Default(), new(MockGalleryTagRepository))
+
+		_, err := service.RenameTag(ctx, dto.RenameGalleryTagRequest{From: "Art", To: "art"})
+
+		assert.ErrorIs(t, err, storage.ErrInvalidTag)
+	})
+
+	t.Run("merge skips target and duplicates", func(t *testing.T) {
+		repo := new(MockGalleryTagRepository)
+		repo.On("MergeTags", ctx, []string{"sea", "ocean"}, "water", false).Return(2, nil).Once()
+		service := NewTagService(slog.Default(), repo)
+
+		resp, err := service.MergeTags(ctx, dto.MergeGalleryTagsRequest{
+			Sources: []string{"Sea", "water", "sea", "ocean"},
+			Target:  "Water",
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, resp.Galleries)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("merge without sources", func(t *testing.T) {
+		service := NewTagService(slog.Default(), new(MockGalleryTagRepository))
+
+		_, err := service.MergeTags(ctx, dto.MergeGalleryTagsRequest{Sources: []string{"water"}, Target: "water"})
+
+		assert.ErrorIs(t, err, storage.ErrInvalidTag)
+	})
+
+	t.Run("tag too long", func(t *testing.T) {
+		service := NewTagService(slog.Default(), new(MockGalleryTagRepository))
+
+		_, err := service.MergeTags(ctx, dto.MergeGalleryTagsRequest{Sources: []string{strings.Repeat("я", 51)}, Target: "water"})
+
+		assert.ErrorIs(t, err, storage.ErrInvalidTag)
+	})
+}
+
+func TestTagService_DeleteAlias(t *testing.T) {
+	ctx := context.Background()
+
+	repo := new(MockGalleryTagRepository)
+	repo.On("DeleteAlias", ctx, "natrue").Return(storage.ErrTagAliasNotFound).Once()
+	service := NewTagService(slog.Default(), repo)
+
+	err := service.DeleteAlias(ctx, "Natrue")
+
+	assert.ErrorIs(t, err, storage.ErrTagAliasNotFound)
+	repo.AssertExpectations(t)
+}
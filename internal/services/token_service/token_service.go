@@ -2,8 +2,13 @@ package services
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
 	"errors"
+	"premium_caste/internal/domain/apperr"
 	"premium_caste/internal/domain/models"
+	"premium_caste/internal/lib/principal"
 	"premium_caste/internal/repository"
 	"time"
 
@@ -12,33 +17,45 @@ import (
 )
 
 var (
-	ErrInvalidToken       = errors.New("invalid token")
-	ErrInvalidTokenClaims = errors.New("invalid token claims")
-	ErrTokenExpired       = errors.New("token expired")
-	ErrTokenNotInStorage  = errors.New("token not found in storage")
+	ErrInvalidToken       = apperr.Unauthorized("invalid_token", "invalid token")
+	ErrInvalidTokenClaims = apperr.Unauthorized("invalid_token_claims", "invalid token claims")
+	ErrTokenExpired       = apperr.Unauthorized("token_expired", "token expired")
+	ErrTokenNotInStorage  = apperr.Unauthorized("token_revoked", "token not found in storage")
 )
 
 const (
 	AccessTokenExpire  = 15 * time.Minute
 	RefreshTokenExpire = 7 * 24 * time.Hour
-	SecretKey          = "test"
+)
+
+// Типы токенов в claim typ. Refresh-токен не принимается вместо access-токена
+const (
+	TokenTypeAccess  = "access"
+	TokenTypeRefresh = "refresh"
 )
 
 type TokenService struct {
-	repo repository.TokenRepository
+	repo   repository.TokenRepository
+	secret []byte
 }
 
-func NewTokenService(repo repository.TokenRepository) *TokenService {
-	return &TokenService{repo: repo}
+// NewTokenService создает сервис токенов. secret подписывает токены и токены CSRF
+func NewTokenService(repo repository.TokenRepository, secret string) *TokenService {
+	return &TokenService{repo: repo, secret: []byte(secret)}
 }
 
+// GenerateTokens выдает пару токенов нового сеанса
 func (s *TokenService) GenerateTokens(user models.User) (*models.TokenPair, error) {
-	accessToken, err := NewToken(user, AccessTokenExpire)
+	return s.generateTokens(user, uuid.NewString())
+}
+
+func (s *TokenService) generateTokens(user models.User, sessionID string) (*models.TokenPair, error) {
+	accessToken, err := s.NewToken(user, sessionID, TokenTypeAccess, AccessTokenExpire)
 	if err != nil {
 		return nil, err
 	}
 
-	refreshToken, err := NewToken(user, RefreshTokenExpire)
+	refreshToken, err := s.NewToken(user, sessionID, TokenTypeRefresh, RefreshTokenExpire)
 	if err != nil {
 		return nil, err
 	}
@@ -52,49 +69,162 @@ func (s *TokenService) GenerateTokens(user models.User) (*models.TokenPair, erro
 		UserID:       user.ID,
 		AccessToken:  accessToken,
 		RefreshToken: refreshToken,
+		CSRFToken:    s.CSRFToken(sessionID),
 	}, nil
 }
 
+// RefreshTokens выдает новую пару в том же сеансе. Роли переносятся из refresh-токена:
+// при изменении прав refresh-токены отзываются, и пользователь входит заново.
+// Предъявленный refresh-токен удаляется из хранилища и повторно не принимается
 func (s *TokenService) RefreshTokens(refreshToken string) (*models.TokenPair, error) {
-	token, _, err := new(jwt.Parser).ParseUnverified(refreshToken, jwt.MapClaims{})
+	claims, err := s.parseToken(refreshToken, TokenTypeRefresh)
 	if err != nil {
-		return nil, ErrInvalidToken
-	}
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
+		// Истекший refresh-токен не продлевается: клиент входит заново
 		return nil, ErrInvalidToken
 	}
 
-	userID, ok := claims["uid"].(string)
-	if !ok {
+	p := principalFromClaims(claims)
+	email, ok := claims["email"].(string)
+	if p.UserID == uuid.Nil || !ok {
 		return nil, ErrInvalidTokenClaims
 	}
+	userID := p.UserID.String()
 
 	exists, err := s.repo.GetRefreshToken(context.Background(), userID, refreshToken)
 	if err != nil || !exists {
 		return nil, ErrInvalidToken
 	}
 
-	// if err := s.repo.DeleteRefreshToken(context.Background(), userID, refreshToken); err != nil {
-	// 	return nil, err
-	// }
+	if err := s.repo.DeleteRefreshToken(context.Background(), userID, refreshToken); err != nil {
+		return nil, err
+	}
 
 	user := models.User{
-		ID:    uuid.MustParse(userID),
-		Email: claims["email"].(string),
+		ID:    p.UserID,
+		Email: email,
+	}
+	if len(p.Roles) > 0 {
+		user.Role = p.Roles[0]
+		user.IsAdmin = p.HasRole(models.RoleAdmin)
+	}
+
+	sessionID := p.SessionID
+	if sessionID == "" {
+		sessionID = uuid.NewString()
+	}
+
+	return s.generateTokens(user, sessionID)
+}
+
+// ParseAccessToken проверяет подпись и срок access-токена и возвращает его владельца
+func (s *TokenService) ParseAccessToken(accessToken string) (principal.Principal, error) {
+	claims, err := s.parseToken(accessToken, TokenTypeAccess)
+	if err != nil {
+		return principal.Principal{}, err
+	}
+
+	p := principalFromClaims(claims)
+	if p.UserID == uuid.Nil {
+		return principal.Principal{}, ErrInvalidTokenClaims
+	}
+
+	return p, nil
+}
+
+// ParseRefreshToken проверяет подпись и срок refresh-токена и возвращает его владельца.
+// Токен при этом не отзывается
+func (s *TokenService) ParseRefreshToken(refreshToken string) (principal.Principal, error) {
+	claims, err := s.parseToken(refreshToken, TokenTypeRefresh)
+	if err != nil {
+		return principal.Principal{}, ErrInvalidToken
+	}
+
+	p := principalFromClaims(claims)
+	if p.UserID == uuid.Nil {
+		return principal.Principal{}, ErrInvalidTokenClaims
 	}
 
-	return s.GenerateTokens(user)
+	return p, nil
 }
 
-func NewToken(user models.User, duration time.Duration) (string, error) {
+// parseToken проверяет подпись, алгоритм, срок и тип токена
+func (s *TokenService) parseToken(tokenString, tokenType string) (jwt.MapClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (any, error) {
+		return s.secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrTokenExpired
+		}
+		return nil, ErrInvalidToken
+	}
+
+	if typ, _ := claims["typ"].(string); typ != tokenType {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+// NewToken подписывает токен типа tokenType для пользователя user в сеансе sessionID
+func (s *TokenService) NewToken(user models.User, sessionID, tokenType string, duration time.Duration) (string, error) {
 	token := jwt.New(jwt.SigningMethodHS256)
 
 	claims := token.Claims.(jwt.MapClaims)
 	claims["uid"] = user.ID
 	claims["email"] = user.Email
+	claims["roles"] = user.Roles()
+	claims["sid"] = sessionID
+	claims["typ"] = tokenType
 	claims["iat"] = time.Now().Unix()
 	claims["exp"] = time.Now().Add(duration).Unix()
 
-	return token.SignedString([]byte(SecretKey))
+	return token.SignedString(s.secret)
+}
+
+// principalFromClaims читает пользователя из claims. Неразобранные поля остаются пустыми
+func principalFromClaims(claims jwt.MapClaims) principal.Principal {
+	var p principal.Principal
+
+	if uid, ok := claims["uid"].(string); ok {
+		p.UserID, _ = uuid.Parse(uid)
+	}
+	p.SessionID, _ = claims["sid"].(string)
+
+	roles, _ := claims["roles"].([]any)
+	for _, role := range roles {
+		if role, ok := role.(string); ok {
+			p.Roles = append(p.Roles, role)
+		}
+	}
+
+	return p
+}
+
+// CSRFToken токен защиты от CSRF для сеанса. Он вычисляется из ID сеанса и нигде
+// не хранится: сохраняется при обновлении токенов и меняется при новом входе
+func (s *TokenService) CSRFToken(sessionID string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte("csrf:" + sessionID))
+
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// ValidCSRFToken token выдан для сеанса sessionID
+func (s *TokenService) ValidCSRFToken(sessionID, token string) bool {
+	if sessionID == "" || token == "" {
+		return false
+	}
+
+	return hmac.Equal([]byte(token), []byte(s.CSRFToken(sessionID)))
+}
+
+// RevokeUserTokens отзывает все refresh-токены пользователя. Уже выданные
+// access-токены действуют до истечения срока
+func (s *TokenService) RevokeUserTokens(ctx context.Context, userID uuid.UUID) error {
+	return s.repo.DeleteAllUserTokens(ctx, userID.String())
 }
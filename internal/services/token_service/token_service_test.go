@@ -7,9 +7,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 type MockTokenRepository struct {
@@ -36,6 +38,8 @@ func (m *MockTokenRepository) DeleteAllUserTokens(ctx context.Context, userID st
 	return args.Error(0)
 }
 
+const testSecret = "0123456789abcdef0123456789abcdef"
+
 var (
 	testUser = models.User{
 		ID:    uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
@@ -46,7 +50,7 @@ var (
 
 func TestGenerateTokens_Success(t *testing.T) {
 	repo := new(MockTokenRepository)
-	service := NewTokenService(repo)
+	service := NewTokenService(repo, testSecret)
 
 	repo.On("SaveRefreshToken", testCtx, testUser.ID.String(), mock.Anything, mock.Anything).
 		Return(nil)
@@ -56,12 +60,16 @@ func TestGenerateTokens_Success(t *testing.T) {
 	assert.NoError(t, err)
 	assert.NotEmpty(t, tokens.AccessToken)
 	assert.NotEmpty(t, tokens.RefreshToken)
+
+	p, err := service.ParseRefreshToken(tokens.RefreshToken)
+	require.NoError(t, err)
+	assert.True(t, service.ValidCSRFToken(p.SessionID, tokens.CSRFToken))
 	repo.AssertExpectations(t)
 }
 
 func TestGenerateTokens_RepoError(t *testing.T) {
 	repo := new(MockTokenRepository)
-	service := NewTokenService(repo)
+	service := NewTokenService(repo, testSecret)
 
 	expectedErr := errors.New("storage error")
 	repo.On("SaveRefreshToken", testCtx, testUser.ID.String(), mock.Anything, mock.Anything).
@@ -76,10 +84,10 @@ func TestGenerateTokens_RepoError(t *testing.T) {
 
 func TestRefreshTokens_Success(t *testing.T) {
 	repo := new(MockTokenRepository)
-	service := NewTokenService(repo)
+	service := NewTokenService(repo, testSecret)
 
 	// Генерируем валидный refresh token
-	refreshToken, _ := NewToken(testUser, time.Hour)
+	refreshToken, _ := service.NewToken(testUser, "session-1", TokenTypeRefresh, time.Hour)
 
 	// Настраиваем ожидания мока
 	repo.On("GetRefreshToken", testCtx, testUser.ID.String(), refreshToken).
@@ -99,7 +107,7 @@ func TestRefreshTokens_Success(t *testing.T) {
 
 func TestRefreshTokens_InvalidToken(t *testing.T) {
 	repo := new(MockTokenRepository)
-	service := NewTokenService(repo)
+	service := NewTokenService(repo, testSecret)
 
 	_, err := service.RefreshTokens("invalid.token.string")
 
@@ -108,9 +116,9 @@ func TestRefreshTokens_InvalidToken(t *testing.T) {
 
 func TestRefreshTokens_TokenNotInStorage(t *testing.T) {
 	repo := new(MockTokenRepository)
-	service := NewTokenService(repo)
+	service := NewTokenService(repo, testSecret)
 
-	refreshToken, _ := NewToken(testUser, time.Hour)
+	refreshToken, _ := service.NewToken(testUser, "session-1", TokenTypeRefresh, time.Hour)
 
 	repo.On("GetRefreshToken", testCtx, testUser.ID.String(), refreshToken).
 		Return(false, nil)
@@ -123,9 +131,9 @@ func TestRefreshTokens_TokenNotInStorage(t *testing.T) {
 
 func TestRefreshTokens_StorageError(t *testing.T) {
 	repo := new(MockTokenRepository)
-	service := NewTokenService(repo)
+	service := NewTokenService(repo, testSecret)
 
-	refreshToken, _ := NewToken(testUser, time.Hour)
+	refreshToken, _ := service.NewToken(testUser, "session-1", TokenTypeRefresh, time.Hour)
 	expectedErr := errors.New("storage error")
 
 	repo.On("GetRefreshToken", testCtx, testUser.ID.String(), refreshToken).
@@ -139,9 +147,9 @@ func TestRefreshTokens_StorageError(t *testing.T) {
 
 func TestRefreshTokens_ExpiredToken(t *testing.T) {
 	repo := new(MockTokenRepository)
-	service := NewTokenService(repo)
+	service := NewTokenService(repo, testSecret)
 
-	expiredToken, _ := NewToken(testUser, -time.Hour)
+	expiredToken, _ := service.NewToken(testUser, "session-1", TokenTypeRefresh, -time.Hour)
 
 	repo.On("GetRefreshToken", mock.Anything, mock.Anything, mock.Anything).
 		Return(false, nil).Maybe()
@@ -154,9 +162,9 @@ func TestRefreshTokens_ExpiredToken(t *testing.T) {
 
 func TestRefreshTokens_DeleteTokenError(t *testing.T) {
 	repo := new(MockTokenRepository)
-	service := NewTokenService(repo)
+	service := NewTokenService(repo, testSecret)
 
-	refreshToken, _ := NewToken(testUser, time.Hour)
+	refreshToken, _ := service.NewToken(testUser, "session-1", TokenTypeRefresh, time.Hour)
 	expectedErr := errors.New("delete error")
 
 	repo.On("GetRefreshToken", testCtx, testUser.ID.String(), refreshToken).
@@ -169,3 +177,155 @@ func TestRefreshTokens_DeleteTokenError(t *testing.T) {
 	assert.ErrorIs(t, err, expectedErr)
 	repo.AssertExpectations(t)
 }
+
+func TestRefreshTokens_KeepsSessionAndRoles(t *testing.T) {
+	repo := new(MockTokenRepository)
+	service := NewTokenService(repo, testSecret)
+
+	editor := testUser
+	editor.Role = models.RoleEditor
+	refreshToken, _ := service.NewToken(editor, "session-1", TokenTypeRefresh, time.Hour)
+
+	repo.On("GetRefreshToken", testCtx, testUser.ID.String(), refreshToken).
+		Return(true, nil)
+	repo.On("DeleteRefreshToken", testCtx, testUser.ID.String(), refreshToken).
+		Return(nil)
+	repo.On("SaveRefreshToken", testCtx, testUser.ID.String(), mock.Anything, mock.Anything).
+		Return(nil)
+
+	tokens, err := service.RefreshTokens(refreshToken)
+	require.NoError(t, err)
+
+	p, err := service.ParseAccessToken(tokens.AccessToken)
+	require.NoError(t, err)
+	assert.Equal(t, "session-1", p.SessionID)
+	assert.Equal(t, []string{models.RoleEditor}, p.Roles)
+	assert.Equal(t, service.CSRFToken("session-1"), tokens.CSRFToken)
+}
+
+func TestRefreshTokens_Rejected(t *testing.T) {
+	service := NewTokenService(new(MockTokenRepository), testSecret)
+
+	sign := func(claims jwt.MapClaims, method jwt.SigningMethod) string {
+		token, err := jwt.NewWithClaims(method, claims).SignedString([]byte(testSecret))
+		require.NoError(t, err)
+		return token
+	}
+	exp := time.Now().Add(time.Hour).Unix()
+	access, _ := service.NewToken(testUser, "session-1", TokenTypeAccess, time.Hour)
+	foreign, _ := NewTokenService(nil, "another-secret-another-secret-00").NewToken(testUser, "session-1", TokenTypeRefresh, time.Hour)
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{name: "access token is not accepted", token: access, wantErr: ErrInvalidToken},
+		{name: "signed with another secret", token: foreign, wantErr: ErrInvalidToken},
+		{name: "unsigned", token: sign(jwt.MapClaims{"uid": testUser.ID.String(), "email": testUser.Email, "typ": TokenTypeRefresh, "exp": exp}, jwt.SigningMethodHS512), wantErr: ErrInvalidToken},
+		{name: "no expiration", token: sign(jwt.MapClaims{"uid": testUser.ID.String(), "email": testUser.Email, "typ": TokenTypeRefresh}, jwt.SigningMethodHS256), wantErr: ErrInvalidToken},
+		{name: "no type", token: sign(jwt.MapClaims{"uid": testUser.ID.String(), "email": testUser.Email, "exp": exp}, jwt.SigningMethodHS256), wantErr: ErrInvalidToken},
+		{name: "malformed user id", token: sign(jwt.MapClaims{"uid": "not-a-uuid", "email": testUser.Email, "typ": TokenTypeRefresh, "exp": exp}, jwt.SigningMethodHS256), wantErr: ErrInvalidTokenClaims},
+		{name: "no email", token: sign(jwt.MapClaims{"uid": testUser.ID.String(), "typ": TokenTypeRefresh, "exp": exp}, jwt.SigningMethodHS256), wantErr: ErrInvalidTokenClaims},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, err := service.RefreshTokens(tt.token)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Nil(t, tokens)
+		})
+	}
+}
+
+func TestParseRefreshToken(t *testing.T) {
+	service := NewTokenService(new(MockTokenRepository), testSecret)
+
+	refresh, _ := service.NewToken(testUser, "session-1", TokenTypeRefresh, time.Hour)
+	access, _ := service.NewToken(testUser, "session-1", TokenTypeAccess, time.Hour)
+	expired, _ := service.NewToken(testUser, "session-1", TokenTypeRefresh, -time.Hour)
+
+	p, err := service.ParseRefreshToken(refresh)
+	require.NoError(t, err)
+	assert.Equal(t, testUser.ID, p.UserID)
+	assert.Equal(t, "session-1", p.SessionID)
+
+	_, err = service.ParseRefreshToken(access)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	_, err = service.ParseRefreshToken(expired)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestParseAccessToken(t *testing.T) {
+	service := NewTokenService(new(MockTokenRepository), testSecret)
+
+	admin := testUser
+	admin.Role = models.RoleUser
+	admin.IsAdmin = true
+
+	sign := func(claims jwt.MapClaims, method jwt.SigningMethod) string {
+		token, err := jwt.NewWithClaims(method, claims).SignedString([]byte(testSecret))
+		require.NoError(t, err)
+		return token
+	}
+	access, _ := service.NewToken(admin, "session-1", TokenTypeAccess, time.Hour)
+	refresh, _ := service.NewToken(admin, "session-1", TokenTypeRefresh, time.Hour)
+	expired, _ := service.NewToken(admin, "session-1", TokenTypeAccess, -time.Hour)
+	foreign, _ := NewTokenService(nil, "another-secret-another-secret-00").NewToken(admin, "session-1", TokenTypeAccess, time.Hour)
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{name: "access token", token: access},
+		{name: "refresh token is not accepted", token: refresh, wantErr: ErrInvalidToken},
+		{name: "expired", token: expired, wantErr: ErrTokenExpired},
+		{name: "wrong signature", token: access[:len(access)-2] + "xx", wantErr: ErrInvalidToken},
+		{name: "signed with another secret", token: foreign, wantErr: ErrInvalidToken},
+		{name: "no expiration", token: sign(jwt.MapClaims{"uid": testUser.ID.String(), "typ": TokenTypeAccess}, jwt.SigningMethodHS256), wantErr: ErrInvalidToken},
+		{name: "unsigned", token: sign(jwt.MapClaims{"uid": testUser.ID.String(), "typ": TokenTypeAccess, "exp": time.Now().Add(time.Hour).Unix()}, jwt.SigningMethodHS512), wantErr: ErrInvalidToken},
+		{name: "no user", token: sign(jwt.MapClaims{"typ": TokenTypeAccess, "exp": time.Now().Add(time.Hour).Unix()}, jwt.SigningMethodHS256), wantErr: ErrInvalidTokenClaims},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := service.ParseAccessToken(tt.token)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, testUser.ID, p.UserID)
+			assert.Equal(t, "session-1", p.SessionID)
+			assert.Equal(t, []string{models.RoleUser, models.RoleAdmin}, p.Roles)
+			assert.True(t, p.HasRole(models.RoleAdmin))
+		})
+	}
+}
+
+func TestValidCSRFToken(t *testing.T) {
+	service := NewTokenService(new(MockTokenRepository), testSecret)
+	token := service.CSRFToken("session-1")
+
+	tests := []struct {
+		name      string
+		sessionID string
+		token     string
+		want      bool
+	}{
+		{name: "same session", sessionID: "session-1", token: token, want: true},
+		{name: "other session", sessionID: "session-2", token: token},
+		{name: "empty token", sessionID: "session-1"},
+		{name: "token without session", token: service.CSRFToken("")},
+		{name: "tampered", sessionID: "session-1", token: token[:len(token)-1] + "x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, service.ValidCSRFToken(tt.sessionID, tt.token))
+		})
+	}
+}
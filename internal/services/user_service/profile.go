@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"premium_caste/internal/domain/apperr"
+	"premium_caste/internal/domain/models"
+	"premium_caste/internal/lib/logger/sl"
+	"premium_caste/internal/storage"
+	"premium_caste/internal/transport/http/dto"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// emailVerificationTTL срок действия кода подтверждения нового email
+const emailVerificationTTL = 24 * time.Hour
+
+var ErrInvalidCurrentPassword = apperr.InvalidField("current_password", "invalid_password", "current password is incorrect")
+
+// EmailSender доставляет пользователю код подтверждения нового email
+type EmailSender interface {
+	SendEmailVerification(ctx context.Context, email, code string) error
+}
+
+// LogEmailSender записывает код подтверждения в лог вместо отправки письма.
+// Подходит для локальной разработки, пока почтовая рассылка не настроена
+type LogEmailSender struct {
+	log *slog.Logger
+}
+
+func NewLogEmailSender(log *slog.Logger) *LogEmailSender {
+	return &LogEmailSender{log: log}
+}
+
+func (s *LogEmailSender) SendEmailVerification(ctx context.Context, email, code string) error {
+	s.log.Info("email verification code", slog.String("email", email), slog.String("code", code))
+
+	return nil
+}
+
+// UpdateProfile меняет имя и телефон пользователя. Новый email начинает действовать
+// только после подтверждения кодом, который отправляется на этот адрес
+func (u *UserService) UpdateProfile(ctx context.Context, userID uuid.UUID, req dto.UpdateProfileRequest) (*dto.UserResponse, error) {
+	const op = "user_service.UpdateProfile"
+
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("user_id", userID.String()),
+	)
+
+	user, err := u.repo.GetUserById(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := u.repo.UpdateProfile(ctx, userID, req.Name, req.Phone); err != nil {
+		log.Warn("failed to update profile", sl.Err(err))
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if req.Email != nil && !strings.EqualFold(*req.Email, user.Email) {
+		if err := u.requestEmailChange(ctx, userID, *req.Email); err != nil {
+			log.Warn("failed to request email change", sl.Err(err))
+
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	log.Info("profile updated")
+
+	response, err := u.GetUser(ctx, userID)
+	u.auditor.Record(ctx, models.AuditEntry{
+		Action:     models.AuditUserProfileUpdate,
+		TargetType: models.AuditTargetUser,
+		TargetID:   userID.String(),
+		Before:     mapToUserResponse(user),
+		After:      response,
+	})
+
+	return response, err
+}
+
+// requestEmailChange сохраняет новый email как неподтвержденный и отправляет на него код
+func (u *UserService) requestEmailChange(ctx context.Context, userID uuid.UUID, email string) error {
+	// Занятость адреса проверяется сразу, чтобы не отправлять код, который нельзя применить.
+	// Уникальность при подтверждении все равно гарантирует ограничение базы
+	_, err := u.repo.UserByIdentifier(ctx, email)
+	switch {
+	case err == nil:
+		return storage.ErrUserExists
+	case !errors.Is(err, storage.ErrUserNotFound):
+		return err
+	}
+
+	code, hash, err := newVerificationCode()
+	if err != nil {
+		return err
+	}
+
+	if err := u.repo.SetPendingEmail(ctx, userID, email, hash, time.Now().Add(emailVerificationTTL)); err != nil {
+		return err
+	}
+
+	return u.emailSender.SendEmailVerification(ctx, email, code)
+}
+
+// ConfirmEmail применяет новый email по коду из письма
+func (u *UserService) ConfirmEmail(ctx context.Context, userID uuid.UUID, code string) (*dto.UserResponse, error) {
+	const op = "user_service.ConfirmEmail"
+
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("user_id", userID.String()),
+	)
+
+	if err := u.repo.ConfirmEmail(ctx, userID, hashVerificationCode(code)); err != nil {
+		log.Warn("failed to confirm email", sl.Err(err))
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("email confirmed")
+
+	response, err := u.GetUser(ctx, userID)
+	u.record(ctx, models.AuditUserEmailChange, userID, response)
+
+	return response, err
+}
+
+// ChangePassword меняет пароль после проверки текущего. Все refresh-токены пользователя
+// отзываются, для текущего сеанса выдается новая пара токенов
+func (u *UserService) ChangePassword(ctx context.Context, userID uuid.UUID, req dto.ChangePasswordRequest) (*models.TokenPair, error) {
+	const op = "user_service.ChangePassword"
+
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("user_id", userID.String()),
+	)
+
+	hash, err := u.repo.PasswordHash(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := bcrypt.CompareHashAndPassword(hash, []byte(req.CurrentPassword)); err != nil {
+		log.Info("invalid current password")
+
+		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCurrentPassword)
+	}
+
+	newHash, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		log.Error("failed to generate password hash", sl.Err(err))
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := u.repo.UpdatePassword(ctx, userID, newHash); err != nil {
+		log.Error("failed to update password", sl.Err(err))
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("password changed")
+	u.record(ctx, models.AuditUserPasswordChange, userID, nil)
+
+	if err := u.authService.RevokeUserTokens(ctx, userID); err != nil {
+		log.Error("failed to revoke user tokens", sl.Err(err))
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	user, err := u.repo.GetUserById(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	token, err := u.authService.GenerateTokens(user)
+	if err != nil {
+		log.Error("failed to generate token", sl.Err(err))
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return token, nil
+}
+
+// newVerificationCode возвращает случайный код и его хеш для хранения в базе
+func newVerificationCode() (string, []byte, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, fmt.Errorf("failed to generate verification code: %w", err)
+	}
+
+	code := base64.RawURLEncoding.EncodeToString(buf)
+
+	return code, hashVerificationCode(code), nil
+}
+
+func hashVerificationCode(code string) []byte {
+	sum := sha256.Sum256([]byte(code))
+	return sum[:]
+}
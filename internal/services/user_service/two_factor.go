@@ -0,0 +1,519 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"premium_caste/internal/domain/apperr"
+	"premium_caste/internal/domain/models"
+	"premium_caste/internal/lib/logger/sl"
+	"premium_caste/internal/lib/totp"
+	"premium_caste/internal/storage"
+	"premium_caste/internal/transport/http/dto"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// loginChallengeTTL время на ввод второго фактора после пароля
+	loginChallengeTTL = 5 * time.Minute
+	// maxChallengeAttempts попыток ввести код, после которых вход начинается заново
+	maxChallengeAttempts = 5
+	// totpSkew допустимое расхождение часов устройства в интервалах TOTP
+	totpSkew = 1
+	// recoveryCodeCount сколько кодов восстановления выдается за раз
+	recoveryCodeCount = 10
+)
+
+var (
+	ErrInvalidTwoFactorCode = apperr.InvalidField("code", "invalid_code", "two-factor code is invalid")
+	ErrTwoFactorEnabled     = apperr.Conflict("two_factor_enabled", "two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled  = apperr.Conflict("two_factor_not_enabled", "two-factor authentication is not enabled")
+	ErrTwoFactorNotEnrolled = apperr.Conflict("two_factor_not_enrolled", "two-factor enrollment has not been started")
+	ErrTwoFactorRequired    = apperr.Forbidden("two_factor_required", "two-factor authentication is required for this role")
+)
+
+// recoveryEncoding алфавит кодов восстановления: base32 в нижнем регистре, удобный для ручного ввода
+var recoveryEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// CompleteLogin завершает вход кодом из приложения-аутентификатора или кодом восстановления.
+// Если политика потребовала 2FA от пользователя без нее, код подтверждает настройку,
+// начатую StartLoginEnrollment, и в результате возвращаются коды восстановления
+func (u *UserService) CompleteLogin(ctx context.Context, challengeID, code string) (*models.LoginResult, error) {
+	const op = "user_service.CompleteLogin"
+
+	log := u.log.With(
+		slog.String("op", op),
+	)
+
+	key := challengeKey(challengeID)
+	challenge, err := u.loginChallenge(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.String("user_id", challenge.UserID.String()))
+
+	user, err := u.repo.GetUserById(ctx, challenge.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if user.IsSuspended() {
+		u.deleteLoginChallenge(ctx, key, log)
+		log.Warn("suspended user tried to login")
+
+		return nil, fmt.Errorf("%s: %w", op, ErrUserSuspended)
+	}
+
+	var recoveryCodes []string
+	if challenge.Enrollment && !user.TwoFactorEnabled {
+		recoveryCodes, err = u.enableTwoFactor(ctx, user.ID, code)
+	} else {
+		err = u.verifySecondFactor(ctx, user.ID, code)
+	}
+	if err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			log.Info("invalid second factor")
+			u.record(ctx, models.AuditUserTwoFactorFailed, user.ID, nil)
+		}
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	u.deleteLoginChallenge(ctx, key, log)
+	log.Info("user logged in with second factor")
+
+	token, err := u.issueTokens(ctx, user, log)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &models.LoginResult{Tokens: token, RecoveryCodes: recoveryCodes}, nil
+}
+
+// StartLoginEnrollment начинает настройку 2FA во время входа, когда политика
+// безопасности требует ее, а пользователь еще не настроил
+func (u *UserService) StartLoginEnrollment(ctx context.Context, challengeID string) (*dto.TwoFactorEnrollmentResponse, error) {
+	const op = "user_service.StartLoginEnrollment"
+
+	challenge, err := u.challenges.LoginChallenge(ctx, challengeKey(challengeID))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !challenge.Enrollment {
+		return nil, fmt.Errorf("%s: %w", op, ErrTwoFactorEnabled)
+	}
+
+	enrollment, err := u.EnrollTwoFactor(ctx, challenge.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return enrollment, nil
+}
+
+// EnrollTwoFactor создает новый секрет TOTP. 2FA включается после подтверждения
+// кодом из приложения в ConfirmTwoFactor
+func (u *UserService) EnrollTwoFactor(ctx context.Context, userID uuid.UUID) (*dto.TwoFactorEnrollmentResponse, error) {
+	const op = "user_service.EnrollTwoFactor"
+
+	user, err := u.repo.GetUserById(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if user.TwoFactorEnabled {
+		return nil, fmt.Errorf("%s: %w", op, ErrTwoFactorEnabled)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := u.repo.SetTOTPSecret(ctx, userID, secret); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	u.log.Info("two-factor enrollment started", slog.String("op", op), slog.String("user_id", userID.String()))
+
+	return &dto.TwoFactorEnrollmentResponse{
+		Secret:          totp.EncodeSecret(secret),
+		ProvisioningURI: totp.ProvisioningURI(u.issuer, user.Email, secret),
+	}, nil
+}
+
+// ConfirmTwoFactor включает 2FA, если код совпал с новым секретом, и возвращает коды восстановления
+func (u *UserService) ConfirmTwoFactor(ctx context.Context, userID uuid.UUID, code string) (*dto.RecoveryCodesResponse, error) {
+	const op = "user_service.ConfirmTwoFactor"
+
+	user, err := u.repo.GetUserById(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if user.TwoFactorEnabled {
+		return nil, fmt.Errorf("%s: %w", op, ErrTwoFactorEnabled)
+	}
+
+	codes, err := u.enableTwoFactor(ctx, userID, code)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &dto.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// enableTwoFactor проверяет код по секрету из EnrollTwoFactor, включает 2FA и выдает коды восстановления
+func (u *UserService) enableTwoFactor(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	secret, err := u.repo.TOTPSecret(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+
+	step, ok := totp.Match(secret, strings.TrimSpace(code), time.Now(), totpSkew)
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := u.repo.EnableTOTP(ctx, userID, step); err != nil {
+			return err
+		}
+
+		return u.repo.ReplaceRecoveryCodes(ctx, userID, hashes)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	u.log.Info("two-factor authentication enabled", slog.String("user_id", userID.String()))
+	u.record(ctx, models.AuditUserTwoFactorEnable, userID, nil)
+
+	return codes, nil
+}
+
+// DisableTwoFactor выключает 2FA после проверки пароля. Если политика требует 2FA
+// для роли пользователя, выключить ее нельзя
+func (u *UserService) DisableTwoFactor(ctx context.Context, userID uuid.UUID, password string) error {
+	const op = "user_service.DisableTwoFactor"
+
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("user_id", userID.String()),
+	)
+
+	hash, err := u.repo.PasswordHash(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if err := bcrypt.CompareHashAndPassword(hash, []byte(password)); err != nil {
+		log.Info("invalid current password")
+
+		return fmt.Errorf("%s: %w", op, ErrInvalidCurrentPassword)
+	}
+
+	user, err := u.repo.GetUserById(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !user.TwoFactorEnabled {
+		return fmt.Errorf("%s: %w", op, ErrTwoFactorNotEnabled)
+	}
+
+	policy, err := u.securityPolicy(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if policy.TwoFactorRequired(user.Role) {
+		return fmt.Errorf("%s: %w", op, ErrTwoFactorRequired)
+	}
+
+	if err := u.disableTwoFactor(ctx, userID); err != nil {
+		log.Error("failed to disable two-factor authentication", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("two-factor authentication disabled")
+	u.record(ctx, models.AuditUserTwoFactorDisable, userID, nil)
+
+	return nil
+}
+
+// RegenerateRecoveryCodes заменяет коды восстановления новыми. Старые перестают действовать
+func (u *UserService) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) (*dto.RecoveryCodesResponse, error) {
+	const op = "user_service.RegenerateRecoveryCodes"
+
+	user, err := u.repo.GetUserById(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if !user.TwoFactorEnabled {
+		return nil, fmt.Errorf("%s: %w", op, ErrTwoFactorNotEnabled)
+	}
+
+	if err := u.verifySecondFactor(ctx, userID, code); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := u.repo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	u.record(ctx, models.AuditUserRecoveryCodes, userID, nil)
+
+	return &dto.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// TwoFactorStatus возвращает состояние 2FA пользователя
+func (u *UserService) TwoFactorStatus(ctx context.Context, userID uuid.UUID) (*dto.TwoFactorStatusResponse, error) {
+	const op = "user_service.TwoFactorStatus"
+
+	user, err := u.repo.GetUserById(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	policy, err := u.securityPolicy(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	status := &dto.TwoFactorStatusResponse{
+		Enabled:  user.TwoFactorEnabled,
+		Required: policy.TwoFactorRequired(user.Role),
+	}
+	if user.TwoFactorEnabled {
+		if status.RecoveryCodesLeft, err = u.repo.CountRecoveryCodes(ctx, userID); err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return status, nil
+}
+
+// ResetTwoFactor выключает 2FA пользователя, потерявшего устройство и коды восстановления.
+// Если политика требует 2FA, при следующем входе пользователь настроит ее заново
+func (u *UserService) ResetTwoFactor(ctx context.Context, actorID, userID uuid.UUID) error {
+	const op = "user_service.ResetTwoFactor"
+
+	log := u.adminLog(op, actorID, userID)
+
+	err := u.adminAction(ctx, actorID, userID, false, func(ctx context.Context) error {
+		return u.disableTwoFactor(ctx, userID)
+	})
+	if err != nil {
+		log.Warn("failed to reset two-factor authentication", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("two-factor authentication reset")
+	u.record(ctx, models.AuditUserTwoFactorReset, userID, nil)
+
+	return nil
+}
+
+// SecurityPolicy возвращает настройки безопасности
+func (u *UserService) SecurityPolicy(ctx context.Context) (*models.SecurityPolicy, error) {
+	const op = "user_service.SecurityPolicy"
+
+	policy, err := u.securityPolicy(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &policy, nil
+}
+
+// UpdateSecurityPolicy меняет настройки безопасности от имени администратора actorID.
+// Требование 2FA действует со следующего входа: текущие сеансы не прерываются
+func (u *UserService) UpdateSecurityPolicy(ctx context.Context, actorID uuid.UUID, req dto.UpdateSecurityPolicyRequest) (*models.SecurityPolicy, error) {
+	const op = "user_service.UpdateSecurityPolicy"
+
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("actor_id", actorID.String()),
+	)
+
+	before, err := u.securityPolicy(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	policy := before
+	if req.RequireTwoFactor != nil {
+		policy.RequireTwoFactor = *req.RequireTwoFactor
+	}
+
+	if err := u.settings.SaveSetting(ctx, models.SettingSecurity, policy, actorID); err != nil {
+		log.Error("failed to save security policy", sl.Err(err))
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("security policy updated", slog.Bool("require_two_factor", policy.RequireTwoFactor))
+	u.auditor.Record(ctx, models.AuditEntry{
+		Action:     models.AuditSettingsUpdate,
+		TargetType: models.AuditTargetSetting,
+		TargetID:   models.SettingSecurity,
+		Before:     before,
+		After:      policy,
+	})
+
+	return &policy, nil
+}
+
+// verifySecondFactor принимает код TOTP или неиспользованный код восстановления.
+// Код TOTP нельзя предъявить повторно, код восстановления погашается
+func (u *UserService) verifySecondFactor(ctx context.Context, userID uuid.UUID, code string) error {
+	code = strings.TrimSpace(code)
+
+	secret, err := u.repo.TOTPSecret(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	// Без секрета код TOTP не проверяется: HMAC с пустым ключом может вычислить кто угодно
+	if step, ok := totp.Match(secret, code, time.Now(), totpSkew); ok && len(secret) > 0 {
+		fresh, err := u.repo.UseTOTPStep(ctx, userID, step)
+		if err != nil {
+			return err
+		}
+		if !fresh {
+			return ErrInvalidTwoFactorCode
+		}
+
+		return nil
+	}
+
+	used, err := u.repo.UseRecoveryCode(ctx, userID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidTwoFactorCode
+	}
+
+	u.record(ctx, models.AuditUserRecoveryCodeUsed, userID, nil)
+
+	return nil
+}
+
+func (u *UserService) disableTwoFactor(ctx context.Context, userID uuid.UUID) error {
+	return u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := u.repo.DisableTOTP(ctx, userID); err != nil {
+			return err
+		}
+
+		return u.repo.ReplaceRecoveryCodes(ctx, userID, nil)
+	})
+}
+
+// securityPolicy читает настройки безопасности. Пока администратор их не менял, 2FA не обязательна
+func (u *UserService) securityPolicy(ctx context.Context) (models.SecurityPolicy, error) {
+	var policy models.SecurityPolicy
+	if err := u.settings.GetSetting(ctx, models.SettingSecurity, &policy); err != nil {
+		return models.SecurityPolicy{}, err
+	}
+
+	return policy, nil
+}
+
+// newLoginChallenge сохраняет незавершенный вход. Клиент получает случайный идентификатор,
+// в Redis ключом служит его хеш
+func (u *UserService) newLoginChallenge(ctx context.Context, user models.User) (*models.LoginChallenge, error) {
+	id, _, err := newVerificationCode()
+	if err != nil {
+		return nil, err
+	}
+
+	challenge := models.LoginChallenge{
+		ID:         id,
+		UserID:     user.ID,
+		Enrollment: !user.TwoFactorEnabled,
+		ExpiresAt:  time.Now().Add(loginChallengeTTL),
+	}
+	if err := u.challenges.SaveLoginChallenge(ctx, challengeKey(id), challenge, loginChallengeTTL); err != nil {
+		return nil, err
+	}
+
+	return &challenge, nil
+}
+
+// loginChallenge возвращает незавершенный вход и учитывает попытку ввести код.
+// После maxChallengeAttempts попыток вход удаляется, чтобы код нельзя было подобрать
+func (u *UserService) loginChallenge(ctx context.Context, key string) (models.LoginChallenge, error) {
+	challenge, err := u.challenges.LoginChallenge(ctx, key)
+	if err != nil {
+		return models.LoginChallenge{}, err
+	}
+
+	attempts, err := u.challenges.CountLoginChallengeAttempt(ctx, key)
+	if err != nil {
+		return models.LoginChallenge{}, err
+	}
+	if attempts > maxChallengeAttempts {
+		u.deleteLoginChallenge(ctx, key, u.log)
+
+		return models.LoginChallenge{}, storage.ErrInvalidLoginChallenge
+	}
+
+	return challenge, nil
+}
+
+// deleteLoginChallenge удаляет использованный вход. Он все равно истечет, поэтому ошибка только в логе
+func (u *UserService) deleteLoginChallenge(ctx context.Context, key string, log *slog.Logger) {
+	if err := u.challenges.DeleteLoginChallenge(ctx, key); err != nil {
+		log.Error("failed to delete login challenge", sl.Err(err))
+	}
+}
+
+func challengeKey(challengeID string) string {
+	return hex.EncodeToString(hashVerificationCode(challengeID))
+}
+
+// newRecoveryCodes возвращает коды восстановления вида abcde-fghij и их хеши
+func newRecoveryCodes() ([]string, [][]byte, error) {
+	codes := make([]string, 0, recoveryCodeCount)
+	hashes := make([][]byte, 0, recoveryCodeCount)
+
+	buf := make([]byte, 7)
+	for range recoveryCodeCount {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+
+		raw := recoveryEncoding.EncodeToString(buf)[:10]
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, hashRecoveryCode(raw))
+	}
+
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode хеширует код восстановления без учета дефисов, пробелов и регистра
+func hashRecoveryCode(code string) []byte {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return hashVerificationCode(normalized)
+}
@@ -0,0 +1,305 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"premium_caste/internal/domain/apperr"
+	"premium_caste/internal/domain/models"
+	"premium_caste/internal/lib/logger/sl"
+	"premium_caste/internal/lib/pagination"
+	"premium_caste/internal/transport/http/dto"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrSelfAction = apperr.Forbidden("self_action_forbidden", "administrators cannot change their own role, suspend or delete themselves")
+	ErrLastAdmin  = apperr.Conflict("last_admin", "at least one active administrator must remain")
+)
+
+// ListUsers возвращает страницу пользователей для админки
+func (u *UserService) ListUsers(ctx context.Context, filter dto.UserFilter, page, perPage int) (*dto.UserListResponse, error) {
+	const op = "user_service.ListUsers"
+
+	log := u.log.With(
+		slog.String("op", op),
+		slog.Int("page", page),
+		slog.Int("per_page", perPage),
+	)
+
+	if filter.Role != "" && !validRole(filter.Role) {
+		return nil, apperr.InvalidField("role", "oneof", "invalid role").WithDetail("%s", filter.Role)
+	}
+	switch filter.Status {
+	case "", models.UserStatusActive, models.UserStatusSuspended, models.UserStatusAnonymized:
+	default:
+		return nil, apperr.InvalidField("status", "oneof", "invalid user status").WithDetail("%s", filter.Status)
+	}
+
+	page, perPage = pagination.Normalize(page, perPage)
+
+	repoFilter := models.UserFilter{
+		Query:  filter.Query,
+		Role:   filter.Role,
+		Status: filter.Status,
+	}
+	if filter.Cursor != "" {
+		cursor, err := pagination.DecodeCursor(filter.Cursor, models.UserSortRegistered)
+		if err != nil {
+			return nil, err
+		}
+		repoFilter.After = cursor
+	}
+
+	users, total, err := u.repo.ListUsers(ctx, repoFilter, page, perPage)
+	if err != nil {
+		log.Error("failed to list users", sl.Err(err))
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	response := &dto.UserListResponse{
+		Users: make([]dto.UserResponse, 0, len(users)),
+		Meta:  pagination.NewMeta(total, page, perPage),
+	}
+	for _, user := range users {
+		response.Users = append(response.Users, mapToUserResponse(user))
+	}
+
+	response.SetNextCursor(len(users), repoFilter.After != nil, func() pagination.Cursor {
+		last := users[len(users)-1]
+		return pagination.Cursor{Sort: models.UserSortRegistered, ID: last.ID, Time: last.RegistrationDate}
+	})
+
+	return response, nil
+}
+
+// GetUser возвращает пользователя без хеша пароля
+func (u *UserService) GetUser(ctx context.Context, userID uuid.UUID) (*dto.UserResponse, error) {
+	const op = "user_service.GetUser"
+
+	user, err := u.repo.GetUserById(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	response := mapToUserResponse(user)
+	return &response, nil
+}
+
+// ChangeRole назначает пользователю роль от имени администратора actorID
+func (u *UserService) ChangeRole(ctx context.Context, actorID, userID uuid.UUID, role string) (*dto.UserResponse, error) {
+	const op = "user_service.ChangeRole"
+
+	log := u.adminLog(op, actorID, userID).With(slog.String("role", role))
+
+	if !validRole(role) {
+		return nil, apperr.InvalidField("role", "oneof", "invalid role").WithDetail("%s", role)
+	}
+
+	var before models.User
+	err := u.adminAction(ctx, actorID, userID, role != models.RoleAdmin, func(ctx context.Context) error {
+		var err error
+		if before, err = u.repo.GetUserById(ctx, userID); err != nil {
+			return err
+		}
+
+		return u.repo.UpdateRole(ctx, userID, role, actorID)
+	})
+	if err != nil {
+		log.Warn("failed to change role", sl.Err(err))
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("user role changed")
+	u.revokeTokens(ctx, userID, log)
+
+	response, err := u.GetUser(ctx, userID)
+	u.auditor.Record(ctx, models.AuditEntry{
+		Action:     models.AuditUserRoleChange,
+		TargetType: models.AuditTargetUser,
+		TargetID:   userID.String(),
+		Before:     mapToUserResponse(before),
+		After:      response,
+	})
+
+	return response, err
+}
+
+// Suspend блокирует пользователя: он не может войти, а выданные refresh-токены отзываются
+func (u *UserService) Suspend(ctx context.Context, actorID, userID uuid.UUID, reason string) (*dto.UserResponse, error) {
+	const op = "user_service.Suspend"
+
+	log := u.adminLog(op, actorID, userID)
+
+	err := u.adminAction(ctx, actorID, userID, true, func(ctx context.Context) error {
+		return u.repo.SetSuspended(ctx, userID, true, reason, actorID)
+	})
+	if err != nil {
+		log.Warn("failed to suspend user", sl.Err(err))
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("user suspended", slog.String("reason", reason))
+	u.revokeTokens(ctx, userID, log)
+
+	response, err := u.GetUser(ctx, userID)
+	u.record(ctx, models.AuditUserSuspend, userID, response)
+
+	return response, err
+}
+
+// Unsuspend снимает блокировку пользователя
+func (u *UserService) Unsuspend(ctx context.Context, actorID, userID uuid.UUID) (*dto.UserResponse, error) {
+	const op = "user_service.Unsuspend"
+
+	log := u.adminLog(op, actorID, userID)
+
+	err := u.adminAction(ctx, actorID, userID, false, func(ctx context.Context) error {
+		return u.repo.SetSuspended(ctx, userID, false, "", actorID)
+	})
+	if err != nil {
+		log.Warn("failed to unsuspend user", sl.Err(err))
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("user unsuspended")
+
+	response, err := u.GetUser(ctx, userID)
+	u.record(ctx, models.AuditUserUnsuspend, userID, response)
+
+	return response, err
+}
+
+// Anonymize заменяет персональные данные пользователя заглушками, сохраняя его комментарии
+func (u *UserService) Anonymize(ctx context.Context, actorID, userID uuid.UUID) (*dto.UserResponse, error) {
+	const op = "user_service.Anonymize"
+
+	log := u.adminLog(op, actorID, userID)
+
+	err := u.adminAction(ctx, actorID, userID, true, func(ctx context.Context) error {
+		return u.repo.Anonymize(ctx, userID, actorID)
+	})
+	if err != nil {
+		log.Warn("failed to anonymize user", sl.Err(err))
+
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("user anonymized")
+	u.revokeTokens(ctx, userID, log)
+
+	response, err := u.GetUser(ctx, userID)
+	u.record(ctx, models.AuditUserAnonymize, userID, response)
+
+	return response, err
+}
+
+// DeleteUser удаляет пользователя вместе с его комментариями
+func (u *UserService) DeleteUser(ctx context.Context, actorID, userID uuid.UUID) error {
+	const op = "user_service.DeleteUser"
+
+	log := u.adminLog(op, actorID, userID)
+
+	err := u.adminAction(ctx, actorID, userID, true, func(ctx context.Context) error {
+		return u.repo.DeleteUser(ctx, userID)
+	})
+	if err != nil {
+		log.Warn("failed to delete user", sl.Err(err))
+
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("user deleted")
+	u.revokeTokens(ctx, userID, log)
+	u.record(ctx, models.AuditUserDelete, userID, nil)
+
+	return nil
+}
+
+// adminAction выполняет действие администратора actorID над пользователем userID в транзакции.
+// Над собой действия запрещены, чтобы администратор случайно не лишил себя доступа.
+// removesAdmin - действие лишает пользователя прав администратора, тогда проверяется,
+// что после него останется хотя бы один активный администратор
+func (u *UserService) adminAction(ctx context.Context, actorID, userID uuid.UUID, removesAdmin bool, fn func(ctx context.Context) error) error {
+	if actorID == userID {
+		return ErrSelfAction
+	}
+
+	return u.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if removesAdmin {
+			admins, err := u.repo.ActiveAdminIDs(ctx)
+			if err != nil {
+				return err
+			}
+			if slices.Contains(admins, userID) && len(admins) <= 1 {
+				return ErrLastAdmin
+			}
+		}
+
+		return fn(ctx)
+	})
+}
+
+// revokeTokens отзывает refresh-токены после изменения прав. Изменение уже сохранено,
+// поэтому ошибка только записывается в лог
+func (u *UserService) revokeTokens(ctx context.Context, userID uuid.UUID, log *slog.Logger) {
+	if err := u.authService.RevokeUserTokens(ctx, userID); err != nil {
+		log.Error("failed to revoke user tokens", sl.Err(err))
+	}
+}
+
+// adminLog логгер действия администратора. actor_id есть в каждой записи,
+// чтобы любое изменение можно было связать с тем, кто его сделал
+func (u *UserService) adminLog(op string, actorID, userID uuid.UUID) *slog.Logger {
+	return u.log.With(
+		slog.String("op", op),
+		slog.String("actor_id", actorID.String()),
+		slog.String("user_id", userID.String()),
+	)
+}
+
+func validRole(role string) bool {
+	switch role {
+	case models.RoleUser, models.RoleEditor, models.RoleAdmin:
+		return true
+	}
+
+	return false
+}
+
+func mapToUserResponse(user models.User) dto.UserResponse {
+	status := models.UserStatusActive
+	switch {
+	case user.AnonymizedAt != nil:
+		status = models.UserStatusAnonymized
+	case user.IsSuspended():
+		status = models.UserStatusSuspended
+	}
+
+	return dto.UserResponse{
+		ID:               user.ID,
+		Name:             user.Name,
+		Email:            user.Email,
+		Phone:            user.Phone,
+		Role:             user.Role,
+		IsAdmin:          user.IsAdmin,
+		Status:           status,
+		RegistrationDate: user.RegistrationDate,
+		LastLogin:        user.LastLogin,
+		SuspendedAt:      user.SuspendedAt,
+		SuspendedBy:      user.SuspendedBy,
+		SuspensionReason: user.SuspensionReason,
+		AnonymizedAt:     user.AnonymizedAt,
+		UpdatedAt:        user.UpdatedAt,
+		UpdatedBy:        user.UpdatedBy,
+		PendingEmail:     user.PendingEmail,
+		TwoFactorEnabled: user.TwoFactorEnabled,
+	}
+}
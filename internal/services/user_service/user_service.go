@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"premium_caste/internal/domain/apperr"
 
 	"premium_caste/internal/domain/models"
+	"premium_caste/internal/lib/actor"
 	"premium_caste/internal/lib/logger/sl"
 	"premium_caste/internal/repository"
 	"premium_caste/internal/storage"
@@ -17,31 +19,51 @@ import (
 )
 
 var (
-	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrUserExist          = errors.New("user already exist")
-	ErrUserNotFound       = errors.New("user not found")
+	ErrInvalidCredentials = apperr.Unauthorized("invalid_credentials", "invalid credentials")
+	ErrUserSuspended      = apperr.Forbidden("user_suspended", "user is suspended")
 )
 
 type TokenService interface {
 	GenerateTokens(user models.User) (*models.TokenPair, error)
 	RefreshTokens(refreshToken string) (*models.TokenPair, error)
+	RevokeUserTokens(ctx context.Context, userID uuid.UUID) error
+}
+
+// Auditor журнал аудита
+type Auditor interface {
+	Record(ctx context.Context, entry models.AuditEntry)
 }
 
 type UserService struct {
 	log         *slog.Logger
 	repo        repository.UserRepository
+	tx          repository.Transactor
 	authService TokenService
+	emailSender EmailSender
+	auditor     Auditor
+	settings    repository.SettingsRepository
+	challenges  repository.LoginChallengeRepository
+	issuer      string // Название сайта в приложении-аутентификатор
 }
 
-func NewUserService(log *slog.Logger, repo repository.UserRepository, authService TokenService) *UserService {
+func NewUserService(log *slog.Logger, repo repository.UserRepository, tx repository.Transactor, authService TokenService, emailSender EmailSender, auditor Auditor, settings repository.SettingsRepository, challenges repository.LoginChallengeRepository, issuer string) *UserService {
 	return &UserService{
 		log:         log,
 		repo:        repo,
+		tx:          tx,
 		authService: authService,
+		emailSender: emailSender,
+		auditor:     auditor,
+		settings:    settings,
+		challenges:  challenges,
+		issuer:      issuer,
 	}
 }
 
-func (u *UserService) Login(ctx context.Context, identifier, password string) (*models.TokenPair, error) {
+// Login проверяет пароль. Если у пользователя включена 2FA или политика безопасности
+// требует ее для его роли, вместо токенов возвращается запрос второго фактора,
+// который завершается через CompleteLogin
+func (u *UserService) Login(ctx context.Context, identifier, password string) (*models.LoginResult, error) {
 	const op = "user_service.Login"
 
 	log := u.log.With(
@@ -55,6 +77,11 @@ func (u *UserService) Login(ctx context.Context, identifier, password string) (*
 	if err != nil {
 		if errors.Is(err, storage.ErrUserNotFound) {
 			u.log.Warn("user not found", sl.Err(err))
+			u.auditor.Record(ctx, models.AuditEntry{
+				Action:     models.AuditUserLoginFailed,
+				TargetType: models.AuditTargetUser,
+				After:      map[string]string{"identifier": identifier},
+			})
 
 			return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
 		}
@@ -65,19 +92,76 @@ func (u *UserService) Login(ctx context.Context, identifier, password string) (*
 
 	if err := bcrypt.CompareHashAndPassword(user.Password, []byte(password)); err != nil {
 		u.log.Info("invalid credentials", sl.Err(err))
+		u.record(ctx, models.AuditUserLoginFailed, user.ID, nil)
 
 		return nil, fmt.Errorf("%s: %w", op, ErrInvalidCredentials)
 	}
 
-	log.Info("user logged in successfully")
+	// Блокировка проверяется после пароля, чтобы не раскрывать ее без верных учетных данных
+	result, err := u.startSession(ctx, user, log)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
 
-	token, err := u.authService.GenerateTokens(user)
+	return result, nil
+}
+
+// LoginExternal входит от имени пользователя, которого подтвердил внешний провайдер.
+// Блокировка и второй фактор проверяются так же, как при входе по паролю
+func (u *UserService) LoginExternal(ctx context.Context, userID uuid.UUID) (*models.LoginResult, error) {
+	const op = "user_service.LoginExternal"
+
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("user_id", userID.String()),
+	)
+
+	user, err := u.repo.GetUserById(ctx, userID)
 	if err != nil {
-		u.log.Error("failed to generate token", sl.Err(err))
+		log.Error("failed to get user", sl.Err(err))
 
 		return nil, fmt.Errorf("%s: %w", op, err)
 	}
 
+	result, err := u.startSession(ctx, user, log)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return result, nil
+}
+
+// startSession завершает вход подтвержденного пользователя: выдает токены или,
+// если нужен второй фактор, незавершенный вход
+func (u *UserService) startSession(ctx context.Context, user models.User, log *slog.Logger) (*models.LoginResult, error) {
+	if user.IsSuspended() {
+		log.Warn("suspended user tried to login")
+
+		return nil, ErrUserSuspended
+	}
+
+	policy, err := u.securityPolicy(ctx)
+	if err != nil {
+		log.Error("failed to get security policy", sl.Err(err))
+
+		return nil, err
+	}
+
+	if user.TwoFactorEnabled || policy.TwoFactorRequired(user.Role) {
+		challenge, err := u.newLoginChallenge(ctx, user)
+		if err != nil {
+			log.Error("failed to create login challenge", sl.Err(err))
+
+			return nil, err
+		}
+
+		log.Info("second factor required", slog.Bool("enrollment", challenge.Enrollment))
+
+		return &models.LoginResult{Challenge: challenge}, nil
+	}
+
+	log.Info("user logged in successfully")
+
 	// http.SetCookie(c.Response().Writer, &http.Cookie{
 	// 	Name:     "access_token",
 	// 	Value:    token.AccessToken,
@@ -98,6 +182,30 @@ func (u *UserService) Login(ctx context.Context, identifier, password string) (*
 	// 	Expires:  time.Now().Add(7 * 24 * time.Hour),
 	// })
 
+	token, err := u.issueTokens(ctx, user, log)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.LoginResult{Tokens: token}, nil
+}
+
+// issueTokens завершает вход: выдает токены, запоминает время входа и пишет его в журнал
+func (u *UserService) issueTokens(ctx context.Context, user models.User, log *slog.Logger) (*models.TokenPair, error) {
+	token, err := u.authService.GenerateTokens(user)
+	if err != nil {
+		log.Error("failed to generate token", sl.Err(err))
+
+		return nil, err
+	}
+
+	// Ошибка записи времени входа не должна мешать самому входу
+	if err := u.repo.TouchLastLogin(ctx, user.ID); err != nil {
+		log.Error("failed to update last login", sl.Err(err))
+	}
+
+	u.record(actor.WithUser(ctx, user.ID), models.AuditUserLogin, user.ID, nil)
+
 	token.UserID = user.ID
 
 	return token, nil
@@ -126,15 +234,19 @@ func (u *UserService) RegisterNewUser(ctx context.Context, input dto.UserRegiste
 		Phone:    input.Phone,
 		Password: passHash,
 		IsAdmin:  input.IsAdmin,
+		Role:     models.RoleUser,
 		BasketID: uuid.New(),
 	}
+	if input.IsAdmin {
+		user.Role = models.RoleAdmin
+	}
 
 	id, err := u.repo.SaveUser(ctx, user)
 	if err != nil {
-		if errors.Is(err, ErrUserExist) {
+		if errors.Is(err, storage.ErrUserExists) {
 			log.Warn("user already exist", sl.Err(err))
 
-			return uuid.Nil, fmt.Errorf("%s: %w", op, ErrUserExist)
+			return uuid.Nil, fmt.Errorf("%s: %w", op, err)
 		}
 
 		log.Error("failed to save user", sl.Err(err))
@@ -144,6 +256,8 @@ func (u *UserService) RegisterNewUser(ctx context.Context, input dto.UserRegiste
 
 	log.Info("user register")
 
+	u.record(ctx, models.AuditUserRegister, id, nil)
+
 	return id, nil
 }
 
@@ -188,3 +302,52 @@ func (u *UserService) GetUserById(ctx context.Context, userID uuid.UUID) (models
 
 	return user, nil
 }
+
+// ResetPassword задает пользователю новый пароль и возвращает его id.
+// Выданные ранее токены не отзываются, это делает вызывающий
+func (u *UserService) ResetPassword(ctx context.Context, identifier, password string) (uuid.UUID, error) {
+	const op = "user_service.ResetPassword"
+
+	log := u.log.With(
+		slog.String("op", op),
+		slog.String("identifier", identifier),
+	)
+
+	log.Info("reset user password")
+
+	user, err := u.repo.UserByIdentifier(ctx, identifier)
+	if err != nil {
+		log.Error("failed to get user", sl.Err(err))
+
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	passHash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		log.Error("failed to generate password hash", sl.Err(err))
+
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := u.repo.UpdatePassword(ctx, user.ID, passHash); err != nil {
+		log.Error("failed to update password", sl.Err(err))
+
+		return uuid.Nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("user password reset")
+
+	u.record(ctx, models.AuditUserPasswordReset, user.ID, nil)
+
+	return user.ID, nil
+}
+
+// record записывает в журнал аудита действие над пользователем userID
+func (u *UserService) record(ctx context.Context, action string, userID uuid.UUID, after any) {
+	u.auditor.Record(ctx, models.AuditEntry{
+		Action:     action,
+		TargetType: models.AuditTargetUser,
+		TargetID:   userID.String(),
+		After:      after,
+	})
+}
@@ -2,11 +2,15 @@ package services
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"log/slog"
 	"testing"
+	"time"
 
 	"premium_caste/internal/domain/models"
+	"premium_caste/internal/lib/pagination"
+	"premium_caste/internal/lib/totp"
 	"premium_caste/internal/storage"
 	"premium_caste/internal/transport/http/dto"
 
@@ -41,6 +45,107 @@ func (m *MockUserRepository) GetUserById(ctx context.Context, userID uuid.UUID)
 	return args.Get(0).(models.User), args.Error(1)
 }
 
+func (m *MockUserRepository) UpdatePassword(ctx context.Context, userID uuid.UUID, passwordHash []byte) error {
+	args := m.Called(ctx, userID, passwordHash)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) ListUsers(ctx context.Context, filter models.UserFilter, page, perPage int) ([]models.User, int, error) {
+	args := m.Called(ctx, filter, page, perPage)
+	return args.Get(0).([]models.User), args.Int(1), args.Error(2)
+}
+
+func (m *MockUserRepository) ActiveAdminIDs(ctx context.Context) ([]uuid.UUID, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]uuid.UUID), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdateRole(ctx context.Context, userID uuid.UUID, role string, actorID uuid.UUID) error {
+	args := m.Called(ctx, userID, role, actorID)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) SetSuspended(ctx context.Context, userID uuid.UUID, suspended bool, reason string, actorID uuid.UUID) error {
+	args := m.Called(ctx, userID, suspended, reason, actorID)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) Anonymize(ctx context.Context, userID uuid.UUID, actorID uuid.UUID) error {
+	args := m.Called(ctx, userID, actorID)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) DeleteUser(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) PasswordHash(ctx context.Context, userID uuid.UUID) ([]byte, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]byte), args.Error(1)
+}
+
+func (m *MockUserRepository) UpdateProfile(ctx context.Context, userID uuid.UUID, name, phone *string) error {
+	args := m.Called(ctx, userID, name, phone)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) SetPendingEmail(ctx context.Context, userID uuid.UUID, email string, tokenHash []byte, expiresAt time.Time) error {
+	args := m.Called(ctx, userID, email, tokenHash, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) ConfirmEmail(ctx context.Context, userID uuid.UUID, tokenHash []byte) error {
+	args := m.Called(ctx, userID, tokenHash)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) TouchLastLogin(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) TOTPSecret(ctx context.Context, userID uuid.UUID) ([]byte, error) {
+	args := m.Called(ctx, userID)
+	secret, _ := args.Get(0).([]byte)
+	return secret, args.Error(1)
+}
+
+func (m *MockUserRepository) SetTOTPSecret(ctx context.Context, userID uuid.UUID, secret []byte) error {
+	args := m.Called(ctx, userID, secret)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) EnableTOTP(ctx context.Context, userID uuid.UUID, step int64) error {
+	args := m.Called(ctx, userID, step)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) DisableTOTP(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	args := m.Called(ctx, userID, step)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes [][]byte) error {
+	args := m.Called(ctx, userID, codeHashes)
+	return args.Error(0)
+}
+
+func (m *MockUserRepository) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash []byte) (bool, error) {
+	args := m.Called(ctx, userID, codeHash)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockUserRepository) CountRecoveryCodes(ctx context.Context, userID uuid.UUID) (int, error) {
+	args := m.Called(ctx, userID)
+	return args.Int(0), args.Error(1)
+}
+
 type MockTokenService struct {
 	mock.Mock
 }
@@ -55,6 +160,111 @@ func (m *MockTokenService) RefreshTokens(refreshToken string) (*models.TokenPair
 	return args.Get(0).(*models.TokenPair), args.Error(1)
 }
 
+func (m *MockTokenService) RevokeUserTokens(ctx context.Context, userID uuid.UUID) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+type MockEmailSender struct {
+	mock.Mock
+}
+
+func (m *MockEmailSender) SendEmailVerification(ctx context.Context, email, code string) error {
+	args := m.Called(ctx, email, code)
+	return args.Error(0)
+}
+
+// memorySettings хранит настройки в памяти в том же JSON, что и репозиторий
+type memorySettings struct {
+	values map[string][]byte
+}
+
+func newMemorySettings() *memorySettings {
+	return &memorySettings{values: make(map[string][]byte)}
+}
+
+func (s *memorySettings) GetSetting(ctx context.Context, key string, dest any) error {
+	value, ok := s.values[key]
+	if !ok {
+		return nil
+	}
+	return json.Unmarshal(value, dest)
+}
+
+func (s *memorySettings) SaveSetting(ctx context.Context, key string, value any, actorID uuid.UUID) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	s.values[key] = data
+	return nil
+}
+
+// memoryChallenges хранит незавершенные входы в памяти без срока действия
+type memoryChallenges struct {
+	challenges map[string]models.LoginChallenge
+	attempts   map[string]int64
+}
+
+func newMemoryChallenges() *memoryChallenges {
+	return &memoryChallenges{
+		challenges: make(map[string]models.LoginChallenge),
+		attempts:   make(map[string]int64),
+	}
+}
+
+func (c *memoryChallenges) SaveLoginChallenge(ctx context.Context, key string, challenge models.LoginChallenge, ttl time.Duration) error {
+	challenge.ID = ""
+	c.challenges[key] = challenge
+	return nil
+}
+
+func (c *memoryChallenges) LoginChallenge(ctx context.Context, key string) (models.LoginChallenge, error) {
+	challenge, ok := c.challenges[key]
+	if !ok {
+		return models.LoginChallenge{}, storage.ErrInvalidLoginChallenge
+	}
+	return challenge, nil
+}
+
+func (c *memoryChallenges) CountLoginChallengeAttempt(ctx context.Context, key string) (int64, error) {
+	if _, ok := c.challenges[key]; !ok {
+		return 0, storage.ErrInvalidLoginChallenge
+	}
+	c.attempts[key]++
+	return c.attempts[key], nil
+}
+
+func (c *memoryChallenges) DeleteLoginChallenge(ctx context.Context, key string) error {
+	delete(c.challenges, key)
+	delete(c.attempts, key)
+	return nil
+}
+
+// passthroughTx выполняет функцию с исходным контекстом, без транзакции
+type passthroughTx struct{}
+
+func (passthroughTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// recordingAuditor запоминает события, переданные в журнал аудита
+type recordingAuditor struct {
+	entries []models.AuditEntry
+}
+
+func (a *recordingAuditor) Record(ctx context.Context, entry models.AuditEntry) {
+	a.entries = append(a.entries, entry)
+}
+
+func (a *recordingAuditor) actions() []string {
+	var actions []string
+	for _, entry := range a.entries {
+		actions = append(actions, entry.Action)
+	}
+	return actions
+}
+
 // func createTestContext() echo.Context {
 // 	e := echo.New()
 // 	req := httptest.NewRequest(http.MethodPost, "/login", nil)
@@ -68,7 +278,7 @@ func TestUserService_Login(t *testing.T) {
 	mockToken := new(MockTokenService)
 	log := slog.Default()
 
-	service := NewUserService(log, mockRepo, mockToken)
+	service := NewUserService(log, mockRepo, passthroughTx{}, mockToken, new(MockEmailSender), &recordingAuditor{}, newMemorySettings(), newMemoryChallenges(), "Premium Caste")
 
 	testEmail := "test@example.com"
 	testPassword := "password123"
@@ -129,7 +339,7 @@ func TestUserService_RegisterNewUser(t *testing.T) {
 	mockRepo := new(MockUserRepository)
 	mockToken := new(MockTokenService)
 	log := slog.Default()
-	service := NewUserService(log, mockRepo, mockToken)
+	service := NewUserService(log, mockRepo, passthroughTx{}, mockToken, new(MockEmailSender), &recordingAuditor{}, newMemorySettings(), newMemoryChallenges(), "Premium Caste")
 
 	// Тестовые данные
 	testInput := dto.UserRegisterInput{
@@ -181,7 +391,7 @@ func TestUserService_IsAdmin(t *testing.T) {
 	mockRepo := new(MockUserRepository)
 	mockToken := new(MockTokenService)
 	log := slog.Default()
-	service := NewUserService(log, mockRepo, mockToken)
+	service := NewUserService(log, mockRepo, passthroughTx{}, mockToken, new(MockEmailSender), &recordingAuditor{}, newMemorySettings(), newMemoryChallenges(), "Premium Caste")
 
 	testUserID := uuid.New()
 
@@ -209,3 +419,773 @@ func TestUserService_IsAdmin(t *testing.T) {
 		assert.ErrorContains(t, err, "db error")
 	})
 }
+
+func TestUserService_ResetPassword(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockUserRepository)
+	mockToken := new(MockTokenService)
+	log := slog.Default()
+	service := NewUserService(log, mockRepo, passthroughTx{}, mockToken, new(MockEmailSender), &recordingAuditor{}, newMemorySettings(), newMemoryChallenges(), "Premium Caste")
+
+	testEmail := "admin@example.com"
+	testPassword := "new-password"
+	testUser := models.User{ID: uuid.New(), Email: testEmail}
+
+	t.Run("successful reset", func(t *testing.T) {
+		var savedHash []byte
+		mockRepo.On("UserByIdentifier", ctx, testEmail).Return(testUser, nil).Once()
+		mockRepo.On("UpdatePassword", ctx, testUser.ID, mock.AnythingOfType("[]uint8")).
+			Run(func(args mock.Arguments) { savedHash = args.Get(2).([]byte) }).
+			Return(nil).Once()
+
+		id, err := service.ResetPassword(ctx, testEmail, testPassword)
+		require.NoError(t, err)
+		assert.Equal(t, testUser.ID, id)
+		assert.NoError(t, bcrypt.CompareHashAndPassword(savedHash, []byte(testPassword)))
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		mockRepo.On("UserByIdentifier", ctx, testEmail).Return(models.User{}, storage.ErrUserNotFound).Once()
+
+		_, err := service.ResetPassword(ctx, testEmail, testPassword)
+		assert.ErrorIs(t, err, storage.ErrUserNotFound)
+	})
+
+	t.Run("update error", func(t *testing.T) {
+		mockRepo.On("UserByIdentifier", ctx, testEmail).Return(testUser, nil).Once()
+		mockRepo.On("UpdatePassword", ctx, testUser.ID, mock.Anything).Return(errors.New("db error")).Once()
+
+		_, err := service.ResetPassword(ctx, testEmail, testPassword)
+		assert.ErrorContains(t, err, "db error")
+	})
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_LoginSuspended(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockUserRepository)
+	mockToken := new(MockTokenService)
+	service := NewUserService(slog.Default(), mockRepo, passthroughTx{}, mockToken, new(MockEmailSender), &recordingAuditor{}, newMemorySettings(), newMemoryChallenges(), "Premium Caste")
+
+	testPassword := "password123"
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.DefaultCost)
+	suspendedAt := time.Now()
+	testUser := models.User{
+		Email:       "test@example.com",
+		Password:    hashedPassword,
+		SuspendedAt: &suspendedAt,
+	}
+
+	t.Run("suspended user cannot login", func(t *testing.T) {
+		mockRepo.On("UserByIdentifier", ctx, testUser.Email).Return(testUser, nil).Once()
+
+		_, err := service.Login(ctx, testUser.Email, testPassword)
+		assert.ErrorIs(t, err, ErrUserSuspended)
+	})
+
+	t.Run("wrong password does not reveal suspension", func(t *testing.T) {
+		mockRepo.On("UserByIdentifier", ctx, testUser.Email).Return(testUser, nil).Once()
+
+		_, err := service.Login(ctx, testUser.Email, "wrong_password")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	})
+
+	mockRepo.AssertExpectations(t)
+	mockToken.AssertNotCalled(t, "GenerateTokens", mock.Anything)
+}
+
+func TestUserService_RegisterIgnoresAdminFlag(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(slog.Default(), mockRepo, passthroughTx{}, new(MockTokenService), new(MockEmailSender), &recordingAuditor{}, newMemorySettings(), newMemoryChallenges(), "Premium Caste")
+
+	input := dto.UserRegisterInput{
+		Name:     "Test User",
+		Email:    "test@example.com",
+		Phone:    "+1234567890",
+		Password: "password123",
+	}
+
+	// Флаг IsAdmin не читается из JSON, поэтому публичная регистрация всегда создает обычного пользователя
+	var body struct {
+		dto.UserRegisterInput
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"is_admin": true}`), &body))
+	assert.False(t, body.IsAdmin)
+
+	mockRepo.On("SaveUser", ctx, mock.MatchedBy(func(u models.User) bool {
+		return u.Role == models.RoleUser && !u.IsAdmin
+	})).Return(uuid.New(), nil).Once()
+
+	_, err := service.RegisterNewUser(ctx, input)
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_AdminActions(t *testing.T) {
+	ctx := context.Background()
+	actorID := uuid.New()
+	userID := uuid.New()
+	dbErr := errors.New("db error")
+
+	tests := []struct {
+		name      string
+		setup     func(repo *MockUserRepository, token *MockTokenService)
+		action    func(s *UserService) error
+		wantErr   error
+		errString string
+		wantAudit []string
+	}{
+		{
+			name: "suspend revokes tokens",
+			setup: func(repo *MockUserRepository, token *MockTokenService) {
+				repo.On("ActiveAdminIDs", ctx).Return([]uuid.UUID{actorID}, nil).Once()
+				repo.On("SetSuspended", ctx, userID, true, "spam", actorID).Return(nil).Once()
+				token.On("RevokeUserTokens", ctx, userID).Return(nil).Once()
+				repo.On("GetUserById", ctx, userID).Return(models.User{ID: userID}, nil).Once()
+			},
+			action: func(s *UserService) error {
+				_, err := s.Suspend(ctx, actorID, userID, "spam")
+				return err
+			},
+			wantAudit: []string{models.AuditUserSuspend},
+		},
+		{
+			name: "cannot suspend yourself",
+			action: func(s *UserService) error {
+				_, err := s.Suspend(ctx, actorID, actorID, "")
+				return err
+			},
+			wantErr: ErrSelfAction,
+		},
+		{
+			name: "cannot demote the last admin",
+			setup: func(repo *MockUserRepository, token *MockTokenService) {
+				repo.On("ActiveAdminIDs", ctx).Return([]uuid.UUID{userID}, nil).Once()
+			},
+			action: func(s *UserService) error {
+				_, err := s.ChangeRole(ctx, actorID, userID, models.RoleEditor)
+				return err
+			},
+			wantErr: ErrLastAdmin,
+		},
+		{
+			name: "demote one of several admins",
+			setup: func(repo *MockUserRepository, token *MockTokenService) {
+				repo.On("ActiveAdminIDs", ctx).Return([]uuid.UUID{actorID, userID}, nil).Once()
+				repo.On("GetUserById", ctx, userID).Return(models.User{ID: userID, Role: models.RoleAdmin}, nil).Once()
+				repo.On("UpdateRole", ctx, userID, models.RoleEditor, actorID).Return(nil).Once()
+				token.On("RevokeUserTokens", ctx, userID).Return(nil).Once()
+				repo.On("GetUserById", ctx, userID).Return(models.User{ID: userID, Role: models.RoleEditor}, nil).Once()
+			},
+			action: func(s *UserService) error {
+				_, err := s.ChangeRole(ctx, actorID, userID, models.RoleEditor)
+				return err
+			},
+			wantAudit: []string{models.AuditUserRoleChange},
+		},
+		{
+			name: "promote skips admin check",
+			setup: func(repo *MockUserRepository, token *MockTokenService) {
+				repo.On("GetUserById", ctx, userID).Return(models.User{ID: userID, Role: models.RoleEditor}, nil).Once()
+				repo.On("UpdateRole", ctx, userID, models.RoleAdmin, actorID).Return(nil).Once()
+				token.On("RevokeUserTokens", ctx, userID).Return(nil).Once()
+				repo.On("GetUserById", ctx, userID).Return(models.User{ID: userID, Role: models.RoleAdmin}, nil).Once()
+			},
+			action: func(s *UserService) error {
+				_, err := s.ChangeRole(ctx, actorID, userID, models.RoleAdmin)
+				return err
+			},
+			wantAudit: []string{models.AuditUserRoleChange},
+		},
+		{
+			name: "invalid role",
+			action: func(s *UserService) error {
+				_, err := s.ChangeRole(ctx, actorID, userID, "owner")
+				return err
+			},
+			errString: "invalid role",
+		},
+		{
+			name: "revoke error does not fail delete",
+			setup: func(repo *MockUserRepository, token *MockTokenService) {
+				repo.On("ActiveAdminIDs", ctx).Return([]uuid.UUID{actorID}, nil).Once()
+				repo.On("DeleteUser", ctx, userID).Return(nil).Once()
+				token.On("RevokeUserTokens", ctx, userID).Return(errors.New("redis error")).Once()
+			},
+			action: func(s *UserService) error {
+				return s.DeleteUser(ctx, actorID, userID)
+			},
+			wantAudit: []string{models.AuditUserDelete},
+		},
+		{
+			name: "anonymize user not found",
+			setup: func(repo *MockUserRepository, token *MockTokenService) {
+				repo.On("ActiveAdminIDs", ctx).Return([]uuid.UUID{actorID}, nil).Once()
+				repo.On("Anonymize", ctx, userID, actorID).Return(storage.ErrUserNotFound).Once()
+			},
+			action: func(s *UserService) error {
+				_, err := s.Anonymize(ctx, actorID, userID)
+				return err
+			},
+			wantErr: storage.ErrUserNotFound,
+		},
+		{
+			name: "unsuspend repository error",
+			setup: func(repo *MockUserRepository, token *MockTokenService) {
+				repo.On("SetSuspended", ctx, userID, false, "", actorID).Return(dbErr).Once()
+			},
+			action: func(s *UserService) error {
+				_, err := s.Unsuspend(ctx, actorID, userID)
+				return err
+			},
+			wantErr: dbErr,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			mockToken := new(MockTokenService)
+			if tt.setup != nil {
+				tt.setup(mockRepo, mockToken)
+			}
+			auditor := &recordingAuditor{}
+			service := NewUserService(slog.Default(), mockRepo, passthroughTx{}, mockToken, new(MockEmailSender), auditor, newMemorySettings(), newMemoryChallenges(), "Premium Caste")
+
+			err := tt.action(service)
+			switch {
+			case tt.wantErr != nil:
+				assert.ErrorIs(t, err, tt.wantErr)
+			case tt.errString != "":
+				assert.ErrorContains(t, err, tt.errString)
+			default:
+				require.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.wantAudit, auditor.actions())
+			mockRepo.AssertExpectations(t)
+			mockToken.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserService_ListUsers(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(slog.Default(), mockRepo, passthroughTx{}, new(MockTokenService), new(MockEmailSender), &recordingAuditor{}, newMemorySettings(), newMemoryChallenges(), "Premium Caste")
+
+	suspendedAt := time.Now()
+	users := []models.User{
+		{ID: uuid.New(), Role: models.RoleUser, Password: []byte("hash")},
+		{ID: uuid.New(), Role: models.RoleUser, SuspendedAt: &suspendedAt},
+	}
+
+	t.Run("maps status and pagination", func(t *testing.T) {
+		mockRepo.On("ListUsers", ctx, models.UserFilter{Query: "ann", Role: models.RoleUser}, 1, pagination.DefaultPerPage).
+			Return(users, 2, nil).Once()
+
+		resp, err := service.ListUsers(ctx, dto.UserFilter{Query: "ann", Role: models.RoleUser}, 0, 0)
+		require.NoError(t, err)
+		require.Len(t, resp.Users, 2)
+		assert.Equal(t, models.UserStatusActive, resp.Users[0].Status)
+		assert.Equal(t, models.UserStatusSuspended, resp.Users[1].Status)
+		assert.Equal(t, 2, resp.Total)
+	})
+
+	t.Run("invalid status", func(t *testing.T) {
+		_, err := service.ListUsers(ctx, dto.UserFilter{Status: "banned"}, 1, 20)
+		assert.ErrorContains(t, err, "invalid user status")
+	})
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_LoginUpdatesLastLogin(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockUserRepository)
+	mockToken := new(MockTokenService)
+	auditor := &recordingAuditor{}
+	service := NewUserService(slog.Default(), mockRepo, passthroughTx{}, mockToken, new(MockEmailSender), auditor, newMemorySettings(), newMemoryChallenges(), "Premium Caste")
+
+	testPassword := "password123"
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.DefaultCost)
+	testUser := models.User{ID: uuid.New(), Email: "test@example.com", Password: hashedPassword}
+	tokens := &models.TokenPair{AccessToken: "access", RefreshToken: "refresh"}
+
+	t.Run("successful login", func(t *testing.T) {
+		mockRepo.On("UserByIdentifier", ctx, testUser.Email).Return(testUser, nil).Once()
+		mockToken.On("GenerateTokens", testUser).Return(tokens, nil).Once()
+		mockRepo.On("TouchLastLogin", ctx, testUser.ID).Return(nil).Once()
+
+		result, err := service.Login(ctx, testUser.Email, testPassword)
+		require.NoError(t, err)
+		require.NotNil(t, result.Tokens)
+		assert.Equal(t, testUser.ID, result.Tokens.UserID)
+		assert.Equal(t, []string{models.AuditUserLogin}, auditor.actions())
+	})
+
+	t.Run("failed login is audited", func(t *testing.T) {
+		auditor.entries = nil
+		mockRepo.On("UserByIdentifier", ctx, testUser.Email).Return(testUser, nil).Once()
+
+		_, err := service.Login(ctx, testUser.Email, "wrong-password")
+		require.ErrorIs(t, err, ErrInvalidCredentials)
+		require.Len(t, auditor.entries, 1)
+		assert.Equal(t, models.AuditUserLoginFailed, auditor.entries[0].Action)
+		assert.Equal(t, testUser.ID.String(), auditor.entries[0].TargetID)
+	})
+
+	t.Run("last login error does not fail login", func(t *testing.T) {
+		mockRepo.On("UserByIdentifier", ctx, testUser.Email).Return(testUser, nil).Once()
+		mockToken.On("GenerateTokens", testUser).Return(tokens, nil).Once()
+		mockRepo.On("TouchLastLogin", ctx, testUser.ID).Return(errors.New("db error")).Once()
+
+		_, err := service.Login(ctx, testUser.Email, testPassword)
+		require.NoError(t, err)
+	})
+
+	mockRepo.AssertExpectations(t)
+	mockToken.AssertExpectations(t)
+}
+
+func TestUserService_UpdateProfile(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	current := models.User{ID: userID, Email: "old@example.com"}
+	name := "New Name"
+	newEmail := "new@example.com"
+	sameEmail := "OLD@example.com"
+
+	tests := []struct {
+		name    string
+		req     dto.UpdateProfileRequest
+		setup   func(repo *MockUserRepository, sender *MockEmailSender)
+		wantErr error
+	}{
+		{
+			name: "name only",
+			req:  dto.UpdateProfileRequest{Name: &name},
+			setup: func(repo *MockUserRepository, sender *MockEmailSender) {
+				repo.On("UpdateProfile", ctx, userID, &name, (*string)(nil)).Return(nil).Once()
+			},
+		},
+		{
+			name: "same email is not reverified",
+			req:  dto.UpdateProfileRequest{Email: &sameEmail},
+			setup: func(repo *MockUserRepository, sender *MockEmailSender) {
+				repo.On("UpdateProfile", ctx, userID, (*string)(nil), (*string)(nil)).Return(nil).Once()
+			},
+		},
+		{
+			name: "new email waits for confirmation",
+			req:  dto.UpdateProfileRequest{Email: &newEmail},
+			setup: func(repo *MockUserRepository, sender *MockEmailSender) {
+				var hash []byte
+				repo.On("UpdateProfile", ctx, userID, (*string)(nil), (*string)(nil)).Return(nil).Once()
+				repo.On("UserByIdentifier", ctx, newEmail).Return(models.User{}, storage.ErrUserNotFound).Once()
+				repo.On("SetPendingEmail", ctx, userID, newEmail, mock.AnythingOfType("[]uint8"), mock.AnythingOfType("time.Time")).
+					Run(func(args mock.Arguments) { hash = args.Get(3).([]byte) }).
+					Return(nil).Once()
+				sender.On("SendEmailVerification", ctx, newEmail, mock.MatchedBy(func(code string) bool {
+					return code != "" && string(hashVerificationCode(code)) == string(hash)
+				})).Return(nil).Once()
+			},
+		},
+		{
+			name: "email taken",
+			req:  dto.UpdateProfileRequest{Email: &newEmail},
+			setup: func(repo *MockUserRepository, sender *MockEmailSender) {
+				repo.On("UpdateProfile", ctx, userID, (*string)(nil), (*string)(nil)).Return(nil).Once()
+				repo.On("UserByIdentifier", ctx, newEmail).Return(models.User{ID: uuid.New()}, nil).Once()
+			},
+			wantErr: storage.ErrUserExists,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			mockSender := new(MockEmailSender)
+			mockRepo.On("GetUserById", ctx, userID).Return(current, nil)
+			tt.setup(mockRepo, mockSender)
+			service := NewUserService(slog.Default(), mockRepo, passthroughTx{}, new(MockTokenService), mockSender, &recordingAuditor{}, newMemorySettings(), newMemoryChallenges(), "Premium Caste")
+
+			_, err := service.UpdateProfile(ctx, userID, tt.req)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			mockRepo.AssertExpectations(t)
+			mockSender.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUserService_ConfirmEmail(t *testing.T) {
+	ctx := context.Background()
+	mockRepo := new(MockUserRepository)
+	service := NewUserService(slog.Default(), mockRepo, passthroughTx{}, new(MockTokenService), new(MockEmailSender), &recordingAuditor{}, newMemorySettings(), newMemoryChallenges(), "Premium Caste")
+
+	userID := uuid.New()
+
+	t.Run("valid code", func(t *testing.T) {
+		mockRepo.On("ConfirmEmail", ctx, userID, hashVerificationCode("code")).Return(nil).Once()
+		mockRepo.On("GetUserById", ctx, userID).Return(models.User{ID: userID, Email: "new@example.com"}, nil).Once()
+
+		user, err := service.ConfirmEmail(ctx, userID, "code")
+		require.NoError(t, err)
+		assert.Equal(t, "new@example.com", user.Email)
+	})
+
+	t.Run("invalid code", func(t *testing.T) {
+		mockRepo.On("ConfirmEmail", ctx, userID, hashVerificationCode("wrong")).
+			Return(storage.ErrInvalidVerificationToken).Once()
+
+		_, err := service.ConfirmEmail(ctx, userID, "wrong")
+		assert.ErrorIs(t, err, storage.ErrInvalidVerificationToken)
+	})
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUserService_ChangePassword(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	hash, _ := bcrypt.GenerateFromPassword([]byte("current-password"), bcrypt.DefaultCost)
+	user := models.User{ID: userID}
+	tokens := &models.TokenPair{UserID: userID, AccessToken: "access", RefreshToken: "refresh"}
+
+	t.Run("wrong current password", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockToken := new(MockTokenService)
+		service := NewUserService(slog.Default(), mockRepo, passthroughTx{}, mockToken, new(MockEmailSender), &recordingAuditor{}, newMemorySettings(), newMemoryChallenges(), "Premium Caste")
+
+		mockRepo.On("PasswordHash", ctx, userID).Return(hash, nil).Once()
+
+		_, err := service.ChangePassword(ctx, userID, dto.ChangePasswordRequest{CurrentPassword: "wrong", NewPassword: "new-password"})
+		assert.ErrorIs(t, err, ErrInvalidCurrentPassword)
+		mockRepo.AssertNotCalled(t, "UpdatePassword", mock.Anything, mock.Anything, mock.Anything)
+		mockToken.AssertNotCalled(t, "RevokeUserTokens", mock.Anything, mock.Anything)
+	})
+
+	t.Run("password changed and sessions revoked", func(t *testing.T) {
+		mockRepo := new(MockUserRepository)
+		mockToken := new(MockTokenService)
+		service := NewUserService(slog.Default(), mockRepo, passthroughTx{}, mockToken, new(MockEmailSender), &recordingAuditor{}, newMemorySettings(), newMemoryChallenges(), "Premium Caste")
+
+		var savedHash []byte
+		mockRepo.On("PasswordHash", ctx, userID).Return(hash, nil).Once()
+		mockRepo.On("UpdatePassword", ctx, userID, mock.AnythingOfType("[]uint8")).
+			Run(func(args mock.Arguments) { savedHash = args.Get(2).([]byte) }).
+			Return(nil).Once()
+		mockToken.On("RevokeUserTokens", ctx, userID).Return(nil).Once()
+		mockRepo.On("GetUserById", ctx, userID).Return(user, nil).Once()
+		mockToken.On("GenerateTokens", user).Return(tokens, nil).Once()
+
+		token, err := service.ChangePassword(ctx, userID, dto.ChangePasswordRequest{CurrentPassword: "current-password", NewPassword: "new-password"})
+		require.NoError(t, err)
+		assert.Equal(t, tokens, token)
+		assert.NoError(t, bcrypt.CompareHashAndPassword(savedHash, []byte("new-password")))
+
+		mockRepo.AssertExpectations(t)
+		mockToken.AssertExpectations(t)
+	})
+}
+
+func TestUserService_LoginTwoFactor(t *testing.T) {
+	ctx := context.Background()
+	testPassword := "password123"
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.DefaultCost)
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+
+	testUser := models.User{ID: uuid.New(), Email: "test@example.com", Password: hashedPassword, Role: models.RoleUser, TwoFactorEnabled: true}
+	tokens := &models.TokenPair{AccessToken: "access", RefreshToken: "refresh"}
+
+	setup := func() (*UserService, *MockUserRepository, *MockTokenService, *recordingAuditor) {
+		mockRepo := new(MockUserRepository)
+		mockToken := new(MockTokenService)
+		auditor := &recordingAuditor{}
+		service := NewUserService(slog.Default(), mockRepo, passthroughTx{}, mockToken, new(MockEmailSender), auditor, newMemorySettings(), newMemoryChallenges(), "Premium Caste")
+		return service, mockRepo, mockToken, auditor
+	}
+
+	login := func(t *testing.T, service *UserService, mockRepo *MockUserRepository) *models.LoginChallenge {
+		mockRepo.On("UserByIdentifier", ctx, testUser.Email).Return(testUser, nil).Once()
+
+		result, err := service.Login(ctx, testUser.Email, testPassword)
+		require.NoError(t, err)
+		require.Nil(t, result.Tokens)
+		require.NotNil(t, result.Challenge)
+		assert.False(t, result.Challenge.Enrollment)
+
+		return result.Challenge
+	}
+
+	t.Run("totp code completes login", func(t *testing.T) {
+		service, mockRepo, mockToken, auditor := setup()
+		challenge := login(t, service, mockRepo)
+
+		step := totp.Step(time.Now())
+		mockRepo.On("GetUserById", ctx, testUser.ID).Return(testUser, nil).Once()
+		mockRepo.On("TOTPSecret", ctx, testUser.ID).Return(secret, nil).Once()
+		mockRepo.On("UseTOTPStep", ctx, testUser.ID, step).Return(true, nil).Once()
+		mockToken.On("GenerateTokens", testUser).Return(tokens, nil).Once()
+		mockRepo.On("TouchLastLogin", ctx, testUser.ID).Return(nil).Once()
+
+		result, err := service.CompleteLogin(ctx, challenge.ID, totp.Code(secret, step))
+		require.NoError(t, err)
+		require.NotNil(t, result.Tokens)
+		assert.Equal(t, testUser.ID, result.Tokens.UserID)
+		assert.Nil(t, result.RecoveryCodes)
+		assert.Equal(t, []string{models.AuditUserLogin}, auditor.actions())
+
+		_, err = service.CompleteLogin(ctx, challenge.ID, totp.Code(secret, step))
+		assert.ErrorIs(t, err, storage.ErrInvalidLoginChallenge)
+
+		mockRepo.AssertExpectations(t)
+		mockToken.AssertExpectations(t)
+	})
+
+	t.Run("reused totp code is rejected", func(t *testing.T) {
+		service, mockRepo, _, auditor := setup()
+		challenge := login(t, service, mockRepo)
+
+		step := totp.Step(time.Now())
+		code := totp.Code(secret, step)
+		mockRepo.On("GetUserById", ctx, testUser.ID).Return(testUser, nil).Once()
+		mockRepo.On("TOTPSecret", ctx, testUser.ID).Return(secret, nil).Once()
+		mockRepo.On("UseTOTPStep", ctx, testUser.ID, step).Return(false, nil).Once()
+
+		_, err := service.CompleteLogin(ctx, challenge.ID, code)
+		assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+		assert.Equal(t, []string{models.AuditUserTwoFactorFailed}, auditor.actions())
+	})
+
+	t.Run("recovery code completes login", func(t *testing.T) {
+		service, mockRepo, mockToken, auditor := setup()
+		challenge := login(t, service, mockRepo)
+
+		mockRepo.On("GetUserById", ctx, testUser.ID).Return(testUser, nil).Once()
+		mockRepo.On("TOTPSecret", ctx, testUser.ID).Return(secret, nil).Once()
+		mockRepo.On("UseRecoveryCode", ctx, testUser.ID, hashRecoveryCode("abcdefghij")).Return(true, nil).Once()
+		mockToken.On("GenerateTokens", testUser).Return(tokens, nil).Once()
+		mockRepo.On("TouchLastLogin", ctx, testUser.ID).Return(nil).Once()
+
+		_, err := service.CompleteLogin(ctx, challenge.ID, " ABCDE-FGHIJ ")
+		require.NoError(t, err)
+		assert.Equal(t, []string{models.AuditUserRecoveryCodeUsed, models.AuditUserLogin}, auditor.actions())
+	})
+
+	t.Run("totp is not checked without secret", func(t *testing.T) {
+		service, mockRepo, _, _ := setup()
+		challenge := login(t, service, mockRepo)
+
+		code := totp.Code(nil, totp.Step(time.Now()))
+		mockRepo.On("GetUserById", ctx, testUser.ID).Return(testUser, nil).Once()
+		mockRepo.On("TOTPSecret", ctx, testUser.ID).Return(nil, nil).Once()
+		mockRepo.On("UseRecoveryCode", ctx, testUser.ID, hashRecoveryCode(code)).Return(false, nil).Once()
+
+		_, err := service.CompleteLogin(ctx, challenge.ID, code)
+		assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+		mockRepo.AssertNotCalled(t, "UseTOTPStep", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("attempts are limited", func(t *testing.T) {
+		service, mockRepo, _, _ := setup()
+		challenge := login(t, service, mockRepo)
+
+		mockRepo.On("GetUserById", ctx, testUser.ID).Return(testUser, nil).Times(maxChallengeAttempts)
+		mockRepo.On("TOTPSecret", ctx, testUser.ID).Return(secret, nil).Times(maxChallengeAttempts)
+		mockRepo.On("UseRecoveryCode", ctx, testUser.ID, mock.Anything).Return(false, nil).Times(maxChallengeAttempts)
+
+		for range maxChallengeAttempts {
+			_, err := service.CompleteLogin(ctx, challenge.ID, "wrong")
+			require.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+		}
+
+		_, err := service.CompleteLogin(ctx, challenge.ID, totp.Code(secret, totp.Step(time.Now())))
+		assert.ErrorIs(t, err, storage.ErrInvalidLoginChallenge)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("unknown challenge", func(t *testing.T) {
+		service, _, _, _ := setup()
+
+		_, err := service.CompleteLogin(ctx, "unknown", "123456")
+		assert.ErrorIs(t, err, storage.ErrInvalidLoginChallenge)
+	})
+}
+
+func TestUserService_LoginTwoFactorEnrollment(t *testing.T) {
+	ctx := context.Background()
+	testPassword := "password123"
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.DefaultCost)
+	testUser := models.User{ID: uuid.New(), Email: "editor@example.com", Password: hashedPassword, Role: models.RoleEditor}
+	tokens := &models.TokenPair{AccessToken: "access", RefreshToken: "refresh"}
+
+	mockRepo := new(MockUserRepository)
+	mockToken := new(MockTokenService)
+	auditor := &recordingAuditor{}
+	settings := newMemorySettings()
+	require.NoError(t, settings.SaveSetting(ctx, models.SettingSecurity, models.SecurityPolicy{RequireTwoFactor: true}, uuid.New()))
+	service := NewUserService(slog.Default(), mockRepo, passthroughTx{}, mockToken, new(MockEmailSender), auditor, settings, newMemoryChallenges(), "Premium Caste")
+
+	mockRepo.On("UserByIdentifier", ctx, testUser.Email).Return(testUser, nil).Once()
+
+	result, err := service.Login(ctx, testUser.Email, testPassword)
+	require.NoError(t, err)
+	require.Nil(t, result.Tokens)
+	require.NotNil(t, result.Challenge)
+	assert.True(t, result.Challenge.Enrollment)
+
+	var secret []byte
+	mockRepo.On("GetUserById", ctx, testUser.ID).Return(testUser, nil).Twice()
+	mockRepo.On("SetTOTPSecret", ctx, testUser.ID, mock.Anything).Run(func(args mock.Arguments) {
+		secret = args.Get(2).([]byte)
+	}).Return(nil).Once()
+
+	enrollment, err := service.StartLoginEnrollment(ctx, result.Challenge.ID)
+	require.NoError(t, err)
+	assert.Equal(t, totp.EncodeSecret(secret), enrollment.Secret)
+	assert.Contains(t, enrollment.ProvisioningURI, "otpauth://totp/")
+
+	step := totp.Step(time.Now())
+	mockRepo.On("TOTPSecret", ctx, testUser.ID).Return(secret, nil).Once()
+	mockRepo.On("EnableTOTP", ctx, testUser.ID, step).Return(nil).Once()
+	mockRepo.On("ReplaceRecoveryCodes", ctx, testUser.ID, mock.MatchedBy(func(hashes [][]byte) bool {
+		return len(hashes) == recoveryCodeCount
+	})).Return(nil).Once()
+	mockToken.On("GenerateTokens", testUser).Return(tokens, nil).Once()
+	mockRepo.On("TouchLastLogin", ctx, testUser.ID).Return(nil).Once()
+
+	result, err = service.CompleteLogin(ctx, result.Challenge.ID, totp.Code(secret, step))
+	require.NoError(t, err)
+	require.NotNil(t, result.Tokens)
+	assert.Len(t, result.RecoveryCodes, recoveryCodeCount)
+	assert.Equal(t, []string{models.AuditUserTwoFactorEnable, models.AuditUserLogin}, auditor.actions())
+
+	mockRepo.AssertExpectations(t)
+	mockToken.AssertExpectations(t)
+}
+
+func TestUserService_DisableTwoFactor(t *testing.T) {
+	ctx := context.Background()
+	testPassword := "password123"
+	hashedPassword, _ := bcrypt.GenerateFromPassword([]byte(testPassword), bcrypt.DefaultCost)
+
+	tests := []struct {
+		name      string
+		role      string
+		password  string
+		required  bool
+		wantErr   error
+		wantAudit []string
+	}{
+		{name: "disabled", role: models.RoleEditor, password: testPassword, wantAudit: []string{models.AuditUserTwoFactorDisable}},
+		{name: "policy does not cover role", role: models.RoleUser, password: testPassword, required: true, wantAudit: []string{models.AuditUserTwoFactorDisable}},
+		{name: "required by policy", role: models.RoleAdmin, password: testPassword, required: true, wantErr: ErrTwoFactorRequired},
+		{name: "wrong password", role: models.RoleUser, password: "wrong-password", wantErr: ErrInvalidCurrentPassword},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := models.User{ID: uuid.New(), Role: tt.role, TwoFactorEnabled: true}
+			mockRepo := new(MockUserRepository)
+			auditor := &recordingAuditor{}
+			settings := newMemorySettings()
+			require.NoError(t, settings.SaveSetting(ctx, models.SettingSecurity, models.SecurityPolicy{RequireTwoFactor: tt.required}, uuid.New()))
+			service := NewUserService(slog.Default(), mockRepo, passthroughTx{}, new(MockTokenService), new(MockEmailSender), auditor, settings, newMemoryChallenges(), "Premium Caste")
+
+			mockRepo.On("PasswordHash", ctx, user.ID).Return(hashedPassword, nil).Once()
+			mockRepo.On("GetUserById", ctx, user.ID).Return(user, nil).Maybe()
+			mockRepo.On("DisableTOTP", ctx, user.ID).Return(nil).Maybe()
+			mockRepo.On("ReplaceRecoveryCodes", ctx, user.ID, [][]byte(nil)).Return(nil).Maybe()
+
+			err := service.DisableTwoFactor(ctx, user.ID, tt.password)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				mockRepo.AssertNotCalled(t, "DisableTOTP", mock.Anything, mock.Anything)
+			} else {
+				require.NoError(t, err)
+				mockRepo.AssertCalled(t, "DisableTOTP", ctx, user.ID)
+			}
+			assert.Equal(t, tt.wantAudit, auditor.actions())
+		})
+	}
+}
+
+func TestUserService_UpdateSecurityPolicy(t *testing.T) {
+	ctx := context.Background()
+	actorID := uuid.New()
+	auditor := &recordingAuditor{}
+	service := NewUserService(slog.Default(), new(MockUserRepository), passthroughTx{}, new(MockTokenService), new(MockEmailSender), auditor, newMemorySettings(), newMemoryChallenges(), "Premium Caste")
+
+	policy, err := service.SecurityPolicy(ctx)
+	require.NoError(t, err)
+	assert.False(t, policy.RequireTwoFactor)
+
+	enable := true
+	policy, err = service.UpdateSecurityPolicy(ctx, actorID, dto.UpdateSecurityPolicyRequest{RequireTwoFactor: &enable})
+	require.NoError(t, err)
+	assert.True(t, policy.RequireTwoFactor)
+
+	policy, err = service.SecurityPolicy(ctx)
+	require.NoError(t, err)
+	assert.True(t, policy.RequireTwoFactor)
+
+	require.Len(t, auditor.entries, 1)
+	entry := auditor.entries[0]
+	assert.Equal(t, models.AuditSettingsUpdate, entry.Action)
+	assert.Equal(t, models.SettingSecurity, entry.TargetID)
+	assert.Equal(t, models.SecurityPolicy{}, entry.Before)
+	assert.Equal(t, models.SecurityPolicy{RequireTwoFactor: true}, entry.After)
+}
+
+func TestUserService_LoginExternal(t *testing.T) {
+	ctx := context.Background()
+	suspendedAt := time.Now()
+	tokens := &models.TokenPair{AccessToken: "access", RefreshToken: "refresh"}
+
+	tests := []struct {
+		name          string
+		user          models.User
+		wantTokens    bool
+		wantChallenge bool
+		wantErr       error
+	}{
+		{name: "tokens issued", user: models.User{ID: uuid.New(), Role: models.RoleUser}, wantTokens: true},
+		{name: "second factor required", user: models.User{ID: uuid.New(), Role: models.RoleUser, TwoFactorEnabled: true}, wantChallenge: true},
+		{name: "suspended user", user: models.User{ID: uuid.New(), Role: models.RoleUser, SuspendedAt: &suspendedAt}, wantErr: ErrUserSuspended},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(MockUserRepository)
+			mockToken := new(MockTokenService)
+			service := NewUserService(slog.Default(), mockRepo, passthroughTx{}, mockToken, new(MockEmailSender), &recordingAuditor{}, newMemorySettings(), newMemoryChallenges(), "Premium Caste")
+
+			mockRepo.On("GetUserById", ctx, tt.user.ID).Return(tt.user, nil).Once()
+			if tt.wantTokens {
+				mockToken.On("GenerateTokens", tt.user).Return(tokens, nil).Once()
+				mockRepo.On("TouchLastLogin", ctx, tt.user.ID).Return(nil).Once()
+			}
+
+			result, err := service.LoginExternal(ctx, tt.user.ID)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantTokens, result.Tokens != nil)
+			assert.Equal(t, tt.wantChallenge, result.Challenge != nil)
+
+			mockRepo.AssertExpectations(t)
+			mockToken.AssertExpectations(t)
+		})
+	}
+}
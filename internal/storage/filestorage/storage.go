@@ -7,6 +7,7 @@ import (
 	"mime/multipart"
 	"os"
 	"path/filepath"
+	"strings"
 )
 
 // FileStorage интерфейс для работы с файловым хранилищем
@@ -14,6 +15,7 @@ type FileStorage interface {
 	Save(ctx context.Context, file *multipart.FileHeader, subPath string) (filePath string, fileSize int64, err error)
 	SaveMultiple(ctx context.Context, files []*multipart.FileHeader, subPath string) ([]string, []int64, error)
 	Delete(ctx context.Context, filePath string) error
+	Open(ctx context.Context, filePath string) (io.ReadCloser, error)
 	GetFullPath(relativePath string) string
 	BaseURL() string
 	GetBaseDir() string
@@ -119,6 +121,25 @@ func (s *LocalFileStorage) Delete(ctx context.Context, filePath string) error {
 	return os.Remove(fullPath)
 }
 
+// Open открывает файл из хранилища на чтение. Путь не может выходить за пределы baseDir
+func (s *LocalFileStorage) Open(ctx context.Context, filePath string) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	cleanPath := filepath.Clean(filePath)
+	if filepath.IsAbs(cleanPath) || cleanPath == ".." || strings.HasPrefix(cleanPath, ".."+string(filepath.Separator)) {
+		return nil, fmt.Errorf("invalid file path %s: %w", filePath, os.ErrNotExist)
+	}
+
+	file, err := os.Open(filepath.Join(s.baseDir, cleanPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	return file, nil
+}
+
 // GetFullPath возвращает полный путь к файлу на диске
 func (s *LocalFileStorage) GetFullPath(relativePath string) string {
 	return filepath.Join(s.baseDir, relativePath)
@@ -3,6 +3,7 @@ package storage_test
 import (
 	"bytes"
 	"context"
+	"io"
 	"mime/multipart"
 	"net/http/httptest"
 	"os"
@@ -137,6 +138,35 @@ func TestLocalFileStorage_Delete(t *testing.T) {
 	})
 }
 
+func TestLocalFileStorage_Open(t *testing.T) {
+	fs, tempDir := setupFileStorage(t)
+	defer cleanupFileStorage(t, tempDir)
+
+	ctx := context.Background()
+	require.NoError(t, os.MkdirAll(filepath.Join(tempDir, "images"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(tempDir, "images", "photo.jpg"), []byte("image"), 0644))
+
+	t.Run("reads existing file", func(t *testing.T) {
+		file, err := fs.Open(ctx, "images/photo.jpg")
+		require.NoError(t, err)
+		defer file.Close()
+
+		content, err := io.ReadAll(file)
+		require.NoError(t, err)
+		assert.Equal(t, "image", string(content))
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		_, err := fs.Open(ctx, "images/missing.jpg")
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("path outside base dir", func(t *testing.T) {
+		_, err := fs.Open(ctx, "../etc/passwd")
+		assert.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
 func TestLocalFileStorage_GetFullPath(t *testing.T) {
 	fs, tempDir := setupFileStorage(t)
 	defer os.RemoveAll(tempDir)
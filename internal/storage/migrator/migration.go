@@ -0,0 +1,142 @@
+package migrator
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Migration одна миграция схемы из файла вида 002_create_user_table.sql
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+	NoTx    bool // Миграция помечена NO TRANSACTION, например CREATE INDEX CONCURRENTLY
+}
+
+var (
+	errNoUpSection = errors.New("missing -- +goose Up annotation")
+	fileNameRe     = regexp.MustCompile(`^(\d+)_(\w+)\.sql$`)
+)
+
+// Load читает миграции из корня fsys и сортирует их по версии.
+// Файлы, имя которых не начинается с номера версии, пропускаются
+func Load(fsys fs.FS) ([]Migration, error) {
+	const op = "migrator.Load"
+
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	migrations := make([]Migration, 0, len(entries))
+	seen := make(map[int64]string, len(entries))
+	for _, entry := range entries {
+		match := fileNameRe.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(match[1], 10, 64)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("%s: invalid version in %s", op, entry.Name())
+		}
+		if prev, ok := seen[version]; ok {
+			return nil, fmt.Errorf("%s: duplicate version %d in %s and %s", op, version, prev, entry.Name())
+		}
+		seen[version] = entry.Name()
+
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		migration, err := Parse(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s: %w", op, entry.Name(), err)
+		}
+		migration.Version = version
+		migration.Name = match[2]
+
+		migrations = append(migrations, migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Parse разбирает SQL-файл с аннотациями goose на секции Up и Down.
+// Секция выполняется одним запросом, поэтому StatementBegin/StatementEnd
+// допускаются, но не требуются
+func Parse(sql string) (Migration, error) {
+	var (
+		migration Migration
+		up, down  strings.Builder
+		current   *strings.Builder
+		hasUp     bool
+	)
+
+	scanner := bufio.NewScanner(strings.NewReader(sql))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if annotation, ok := strings.CutPrefix(strings.TrimSpace(line), "-- +goose "); ok {
+			switch strings.TrimSpace(annotation) {
+			case "Up":
+				current, hasUp = &up, true
+			case "Down":
+				current = &down
+			case "NO TRANSACTION":
+				migration.NoTx = true
+			case "StatementBegin", "StatementEnd":
+			default:
+				return Migration{}, fmt.Errorf("unknown annotation %q", annotation)
+			}
+			continue
+		}
+
+		if current == nil {
+			// До секции Up допускаются только комментарии и пустые строки
+			if isEmptySQL(line) {
+				continue
+			}
+			return Migration{}, errNoUpSection
+		}
+
+		current.WriteString(line)
+		current.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return Migration{}, err
+	}
+	if !hasUp {
+		return Migration{}, errNoUpSection
+	}
+
+	migration.Up = up.String()
+	migration.Down = down.String()
+
+	return migration, nil
+}
+
+// isEmptySQL проверяет, что в тексте нет ничего, кроме комментариев и пробелов
+func isEmptySQL(sql string) bool {
+	for _, line := range strings.Split(sql, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,109 @@
+package migrator
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"premium_caste/migrations"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		sql      string
+		wantUp   string
+		wantDown string
+		wantNoTx bool
+		wantErr  bool
+	}{
+		{
+			name:     "up and down",
+			sql:      "-- +goose Up\nCREATE TABLE t (id INT);\n\n-- +goose Down\nDROP TABLE t;\n",
+			wantUp:   "CREATE TABLE t (id INT);\n\n",
+			wantDown: "DROP TABLE t;\n",
+		},
+		{
+			name:     "leading comments and statement blocks",
+			sql:      "-- описание\n\n-- +goose Up\n-- +goose StatementBegin\nCREATE FUNCTION f() RETURNS INT AS $$ SELECT 1; $$ LANGUAGE sql;\n-- +goose StatementEnd\n-- +goose Down\nDROP FUNCTION f;\n",
+			wantUp:   "CREATE FUNCTION f() RETURNS INT AS $$ SELECT 1; $$ LANGUAGE sql;\n",
+			wantDown: "DROP FUNCTION f;\n",
+		},
+		{
+			name:     "no transaction",
+			sql:      "-- +goose NO TRANSACTION\n-- +goose Up\nCREATE INDEX CONCURRENTLY i ON t(id);\n-- +goose Down\nDROP INDEX CONCURRENTLY i;\n",
+			wantUp:   "CREATE INDEX CONCURRENTLY i ON t(id);\n",
+			wantDown: "DROP INDEX CONCURRENTLY i;\n",
+			wantNoTx: true,
+		},
+		{
+			name:    "missing up section",
+			sql:     "CREATE TABLE t (id INT);\n",
+			wantErr: true,
+		},
+		{
+			name:    "unknown annotation",
+			sql:     "-- +goose Up\n-- +goose Sideways\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migration, err := Parse(tt.sql)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantUp, migration.Up)
+			assert.Equal(t, tt.wantDown, migration.Down)
+			assert.Equal(t, tt.wantNoTx, migration.NoTx)
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("sorted by version, other files skipped", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"010_second.sql":  {Data: []byte("-- +goose Up\nSELECT 2;\n")},
+			"002_first.sql":   {Data: []byte("-- +goose Up\nSELECT 1;\n")},
+			"README.md":       {Data: []byte("docs")},
+			"init/initdb.sql": {Data: []byte("SELECT 0;")},
+			"migrations.go":   {Data: []byte("package migrations")},
+		}
+
+		loaded, err := Load(fsys)
+		require.NoError(t, err)
+		require.Len(t, loaded, 2)
+		assert.Equal(t, int64(2), loaded[0].Version)
+		assert.Equal(t, "first", loaded[0].Name)
+		assert.Equal(t, int64(10), loaded[1].Version)
+	})
+
+	t.Run("duplicate version", func(t *testing.T) {
+		fsys := fstest.MapFS{
+			"002_first.sql":  {Data: []byte("-- +goose Up\nSELECT 1;\n")},
+			"0002_again.sql": {Data: []byte("-- +goose Up\nSELECT 1;\n")},
+		}
+
+		_, err := Load(fsys)
+		assert.ErrorContains(t, err, "duplicate version")
+	})
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	loaded, err := Load(migrations.FS)
+	require.NoError(t, err)
+	require.NotEmpty(t, loaded)
+
+	for _, migration := range loaded {
+		// Пустая Up (например, закомментированные данные) допустима, но тогда и откатывать нечего
+		if !isEmptySQL(migration.Up) {
+			assert.Falsef(t, isEmptySQL(migration.Down), "migration %d_%s has no down section", migration.Version, migration.Name)
+		}
+	}
+}
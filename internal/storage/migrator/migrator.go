@@ -0,0 +1,380 @@
+// Package migrator применяет и откатывает встроенные SQL-миграции без внешнего goose.
+// Состояние хранится в таблице goose_db_version в том же формате, что и у goose,
+// поэтому базы, размеченные goose раньше, продолжают работать
+package migrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"time"
+
+	"premium_caste/internal/lib/logger/sl"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// lockKey ключ рекомендательной блокировки, под которой выполняются миграции.
+// Несколько экземпляров приложения, стартующих одновременно, применяют их по очереди
+const lockKey int64 = 7_355_608_451_220_375
+
+// ErrNoMigration в базе нет примененной миграции или миграции с указанной версией
+var ErrNoMigration = errors.New("no migration")
+
+// Status состояние одной миграции
+type Status struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt *time.Time
+}
+
+// Migrator применяет миграции к базе
+type Migrator struct {
+	log        *slog.Logger
+	db         *pgxpool.Pool
+	migrations []Migration
+}
+
+// New загружает миграции из fsys. Пул соединений не закрывается мигратором
+func New(log *slog.Logger, db *pgxpool.Pool, fsys fs.FS) (*Migrator, error) {
+	migrations, err := Load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		log:        log,
+		db:         db,
+		migrations: migrations,
+	}, nil
+}
+
+// execer методы соединения и транзакции, нужные для выполнения миграции
+type execer interface {
+	Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error)
+}
+
+// Up применяет все еще не примененные миграции
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.UpTo(ctx, m.latest())
+}
+
+// UpTo применяет не примененные миграции с версией не выше version
+func (m *Migrator) UpTo(ctx context.Context, version int64) error {
+	const op = "migrator.UpTo"
+
+	return m.withLock(ctx, op, func(conn *pgxpool.Conn, applied map[int64]time.Time) error {
+		return m.upTo(ctx, conn, applied, version)
+	})
+}
+
+// Down откатывает последнюю примененную миграцию
+func (m *Migrator) Down(ctx context.Context) error {
+	const op = "migrator.Down"
+
+	return m.withLock(ctx, op, func(conn *pgxpool.Conn, applied map[int64]time.Time) error {
+		migration, ok := m.current(applied)
+		if !ok {
+			return ErrNoMigration
+		}
+		return m.apply(ctx, conn, migration, false)
+	})
+}
+
+// DownTo откатывает примененные миграции с версией выше version.
+// DownTo(ctx, 0) возвращает базу в исходное состояние
+func (m *Migrator) DownTo(ctx context.Context, version int64) error {
+	const op = "migrator.DownTo"
+
+	return m.withLock(ctx, op, func(conn *pgxpool.Conn, applied map[int64]time.Time) error {
+		return m.downTo(ctx, conn, applied, version)
+	})
+}
+
+// To приводит базу к версии version, применяя или откатывая миграции
+func (m *Migrator) To(ctx context.Context, version int64) error {
+	const op = "migrator.To"
+
+	if version != 0 && m.find(version) == nil {
+		return fmt.Errorf("%s: %w: version %d", op, ErrNoMigration, version)
+	}
+
+	return m.withLock(ctx, op, func(conn *pgxpool.Conn, applied map[int64]time.Time) error {
+		if current, ok := m.current(applied); ok && version < current.Version {
+			return m.downTo(ctx, conn, applied, version)
+		}
+		return m.upTo(ctx, conn, applied, version)
+	})
+}
+
+// Redo откатывает и заново применяет последнюю миграцию
+func (m *Migrator) Redo(ctx context.Context) error {
+	const op = "migrator.Redo"
+
+	return m.withLock(ctx, op, func(conn *pgxpool.Conn, applied map[int64]time.Time) error {
+		migration, ok := m.current(applied)
+		if !ok {
+			return ErrNoMigration
+		}
+		if err := m.apply(ctx, conn, migration, false); err != nil {
+			return err
+		}
+		return m.apply(ctx, conn, migration, true)
+	})
+}
+
+// Version возвращает версию последней примененной миграции или 0
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	const op = "migrator.Version"
+
+	var version int64
+	err := m.withLock(ctx, op, func(_ *pgxpool.Conn, applied map[int64]time.Time) error {
+		if migration, ok := m.current(applied); ok {
+			version = migration.Version
+		}
+		return nil
+	})
+
+	return version, err
+}
+
+// Status возвращает состояние всех известных миграций по возрастанию версии
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	const op = "migrator.Status"
+
+	var statuses []Status
+	err := m.withLock(ctx, op, func(_ *pgxpool.Conn, applied map[int64]time.Time) error {
+		statuses = make([]Status, 0, len(m.migrations))
+		for _, migration := range m.migrations {
+			status := Status{Version: migration.Version, Name: migration.Name}
+			if at, ok := applied[migration.Version]; ok {
+				status.Applied = true
+				status.AppliedAt = &at
+			}
+			statuses = append(statuses, status)
+		}
+		return nil
+	})
+
+	return statuses, err
+}
+
+// withLock берет отдельное соединение, захватывает на нем рекомендательную блокировку,
+// создает таблицу версий и вызывает fn с примененными миграциями
+func (m *Migrator) withLock(ctx context.Context, op string, fn func(conn *pgxpool.Conn, applied map[int64]time.Time) error) error {
+	conn, err := m.db.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: failed to acquire connection: %w", op, err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, lockKey); err != nil {
+		return fmt.Errorf("%s: failed to acquire migration lock: %w", op, err)
+	}
+	defer func() {
+		// Блокировка сессионная, поэтому снимается на том же соединении
+		if _, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, lockKey); err != nil {
+			m.log.Error("failed to release migration lock", slog.String("op", op), sl.Err(err))
+		}
+	}()
+
+	if err := ensureVersionTable(ctx, conn); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	applied, err := appliedVersions(ctx, conn)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := fn(conn, applied); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// upTo применяет по возрастанию версии миграции не выше version
+func (m *Migrator) upTo(ctx context.Context, conn *pgxpool.Conn, applied map[int64]time.Time, version int64) error {
+	for _, migration := range m.migrations {
+		if migration.Version > version {
+			break
+		}
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		if err := m.apply(ctx, conn, migration, true); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// downTo откатывает по убыванию версии миграции выше version
+func (m *Migrator) downTo(ctx context.Context, conn *pgxpool.Conn, applied map[int64]time.Time, version int64) error {
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if migration.Version <= version {
+			break
+		}
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+		if err := m.apply(ctx, conn, migration, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// apply выполняет секцию Up или Down миграции и отмечает это в таблице версий.
+// Без NO TRANSACTION и то и другое происходит в одной транзакции
+func (m *Migrator) apply(ctx context.Context, conn *pgxpool.Conn, migration Migration, up bool) error {
+	direction, sql := "up", migration.Up
+	if !up {
+		direction, sql = "down", migration.Down
+	}
+
+	log := m.log.With(
+		slog.Int64("version", migration.Version),
+		slog.String("name", migration.Name),
+		slog.String("direction", direction),
+	)
+	start := time.Now()
+
+	run := func(db execer) error {
+		// Секция выполняется целиком по простому протоколу, поэтому
+		// в ней может быть несколько выражений и тела функций
+		if !isEmptySQL(sql) {
+			if _, err := db.Exec(ctx, sql); err != nil {
+				return err
+			}
+		}
+
+		if up {
+			_, err := db.Exec(ctx, `INSERT INTO goose_db_version (version_id, is_applied) VALUES ($1, TRUE)`, migration.Version)
+			return err
+		}
+		_, err := db.Exec(ctx, `DELETE FROM goose_db_version WHERE version_id = $1`, migration.Version)
+		return err
+	}
+
+	var err error
+	if migration.NoTx {
+		err = run(conn)
+	} else {
+		err = conn.BeginFunc(ctx, func(tx pgx.Tx) error {
+			return run(tx)
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("migration %d_%s %s failed: %w", migration.Version, migration.Name, direction, err)
+	}
+
+	log.Info("migration applied", slog.Duration("duration", time.Since(start)))
+	return nil
+}
+
+// latest возвращает версию последней известной миграции
+func (m *Migrator) latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// current возвращает последнюю примененную миграцию из известных
+func (m *Migrator) current(applied map[int64]time.Time) (Migration, bool) {
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		if _, ok := applied[m.migrations[i].Version]; ok {
+			return m.migrations[i], true
+		}
+	}
+
+	return Migration{}, false
+}
+
+func (m *Migrator) find(version int64) *Migration {
+	for i := range m.migrations {
+		if m.migrations[i].Version == version {
+			return &m.migrations[i]
+		}
+	}
+
+	return nil
+}
+
+// ensureVersionTable создает таблицу версий goose. Как и goose, при создании
+// записывает нулевую версию
+func ensureVersionTable(ctx context.Context, conn *pgxpool.Conn) error {
+	var exists bool
+	if err := conn.QueryRow(ctx, `SELECT to_regclass('goose_db_version') IS NOT NULL`).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check version table: %w", err)
+	}
+	if exists {
+		return nil
+	}
+
+	return conn.BeginFunc(ctx, func(tx pgx.Tx) error {
+		if _, err := tx.Exec(ctx, `
+			CREATE TABLE goose_db_version (
+				id SERIAL PRIMARY KEY,
+				version_id BIGINT NOT NULL,
+				is_applied BOOLEAN NOT NULL,
+				tstamp TIMESTAMP NULL DEFAULT NOW()
+			)`); err != nil {
+			return fmt.Errorf("failed to create version table: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, `INSERT INTO goose_db_version (version_id, is_applied) VALUES (0, TRUE)`); err != nil {
+			return fmt.Errorf("failed to init version table: %w", err)
+		}
+		return nil
+	})
+}
+
+// appliedVersions возвращает примененные версии и время применения.
+// Для каждой версии значима только последняя запись: старые версии goose
+// отмечали откат записью с is_applied = false
+func appliedVersions(ctx context.Context, conn *pgxpool.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.Query(ctx, `SELECT version_id, is_applied, COALESCE(tstamp, NOW()) FROM goose_db_version ORDER BY id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read versions: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	seen := make(map[int64]struct{})
+	for rows.Next() {
+		var (
+			version   int64
+			isApplied bool
+			at        time.Time
+		)
+		if err := rows.Scan(&version, &isApplied, &at); err != nil {
+			return nil, fmt.Errorf("failed to scan version: %w", err)
+		}
+
+		if _, ok := seen[version]; ok || version == 0 {
+			continue
+		}
+		seen[version] = struct{}{}
+
+		if isApplied {
+			applied[version] = at
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read versions: %w", err)
+	}
+
+	return applied, nil
+}
@@ -1,17 +1,52 @@
 package storage
 
-import "errors"
+import (
+	"errors"
+	"premium_caste/internal/domain/apperr"
+)
 
 var (
-	ErrUserExists   = errors.New("user already exists")
-	ErrUserNotFound = errors.New("user not found")
+	ErrUserExists   = apperr.Conflict("user_exists", "user already exists")
+	ErrUserNotFound = apperr.NotFound("user_not_found", "user not found")
 	ErrAppNotFound  = errors.New("app not found")
 	ErrAppList      = errors.New("no such apps")
 	ErrorNoSuchKey  = errors.New("no such key")
 )
 
 var (
-	ErrFileTooLarge    = errors.New("file size exceeds limit")
-	ErrInvalidFileType = errors.New("invalid file type")
-	ErrFileNotFound    = errors.New("file not found")
+	ErrPostNotFound        = apperr.NotFound("post_not_found", "post not found")
+	ErrCategoryNotFound    = apperr.NotFound("category_not_found", "category not found")
+	ErrGalleryNotFound     = apperr.NotFound("gallery_not_found", "gallery not found")
+	ErrCommentNotFound     = apperr.NotFound("comment_not_found", "comment not found")
+	ErrMediaNotFound       = apperr.NotFound("media_not_found", "media not found")
+	ErrGalleryItemNotFound = apperr.NotFound("gallery_item_not_found", "gallery item not found")
+	ErrTagAliasNotFound    = apperr.NotFound("tag_alias_not_found", "tag alias not found")
+	ErrIdentityNotFound    = apperr.NotFound("identity_not_found", "external account is not linked")
+	ErrAPIKeyNotFound      = apperr.NotFound("api_key_not_found", "api key not found")
+)
+
+var (
+	ErrSlugTaken      = apperr.Conflict("slug_taken", "slug is already taken")
+	ErrIdentityLinked = apperr.Conflict("identity_linked", "external account is already linked")
+)
+
+var (
+	ErrCommentsClosed           = apperr.Forbidden("comments_closed", "comments are closed for this post")
+	ErrCommentRateLimited       = apperr.RateLimited("comment_rate_limited", "too many comments, try again later")
+	ErrInvalidCommentParent     = apperr.Invalid("invalid_comment_parent", "invalid parent comment")
+	ErrInvalidGalleryOrder      = apperr.Invalid("invalid_gallery_order", "order must list every gallery item exactly once")
+	ErrInvalidCursor            = apperr.Invalid("invalid_cursor", "invalid cursor")
+	ErrInvalidTag               = apperr.Invalid("invalid_tag", "invalid tag")
+	ErrInvalidRendition         = apperr.Invalid("invalid_rendition", "unknown image size")
+	ErrArchiveLimitExceeded     = apperr.RateLimited("archive_limit_exceeded", "too many archive downloads in progress")
+	ErrInvalidVerificationToken = apperr.Invalid("invalid_verification_token", "verification code is invalid or expired")
+	ErrInvalidLoginChallenge    = apperr.Unauthorized("invalid_login_challenge", "login challenge is invalid or expired")
+	ErrInvalidOIDCState         = apperr.Unauthorized("invalid_oidc_state", "sign-in request is invalid or expired")
+)
+
+var (
+	ErrFileTooLarge    = apperr.TooLarge("file_too_large", "file size exceeds limit")
+	ErrInvalidFileType = apperr.Unsupported("invalid_file_type", "invalid file type")
+	ErrFileNotFound    = apperr.NotFound("file_not_found", "file not found")
+	ErrSitemapNotFound = apperr.NotFound("sitemap_not_found", "sitemap not found")
 )
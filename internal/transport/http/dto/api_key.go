@@ -0,0 +1,35 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateAPIKeyRequest параметры нового ключа API
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,required"`           // posts:read, posts:write, media:read, media:write, galleries:read, galleries:write
+	RateLimit int        `json:"rate_limit,omitempty" validate:"omitempty,min=1,max=6000"` // Запросов в минуту, по умолчанию rate_limits.api_key_default из конфига
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`                                     // Без срока ключ действует до отзыва
+}
+
+// APIKeyResponse ключ API без секретной части
+type APIKeyResponse struct {
+	ID         uuid.UUID  `json:"id"`
+	UserID     uuid.UUID  `json:"user_id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	RateLimit  int        `json:"rate_limit"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// APIKeyCreatedResponse созданный ключ. Key показывается только в этом ответе
+type APIKeyCreatedResponse struct {
+	APIKeyResponse
+	Key string `json:"key"`
+}
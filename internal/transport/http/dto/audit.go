@@ -0,0 +1,43 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"premium_caste/internal/lib/pagination"
+
+	"github.com/google/uuid"
+)
+
+// AuditEventFilter фильтр журнала аудита
+type AuditEventFilter struct {
+	Action     string
+	ActorID    *uuid.UUID
+	APIKeyID   *uuid.UUID
+	TargetType string
+	TargetID   string
+	From       *time.Time
+	To         *time.Time
+	Cursor     string // Значение next_cursor предыдущей страницы
+}
+
+// AuditEventResponse запись журнала аудита
+type AuditEventResponse struct {
+	ID         uuid.UUID       `json:"id" swaggertype:"string" format:"uuid"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Action     string          `json:"action" example:"post.publish"`
+	ActorID    *uuid.UUID      `json:"actor_id,omitempty" swaggertype:"string" format:"uuid"`   // Пусто для служебных команд и анонимных запросов
+	APIKeyID   *uuid.UUID      `json:"api_key_id,omitempty" swaggertype:"string" format:"uuid"` // Ключ API, с которым выполнено действие. Пусто для входа по токену
+	IP         string          `json:"ip,omitempty"`
+	UserAgent  string          `json:"user_agent,omitempty"`
+	TargetType string          `json:"target_type,omitempty" example:"post"`
+	TargetID   string          `json:"target_id,omitempty"`
+	Before     json.RawMessage `json:"before,omitempty" swaggertype:"object"` // Состояние объекта до изменения
+	After      json.RawMessage `json:"after,omitempty" swaggertype:"object"`  // Состояние объекта после изменения
+}
+
+// AuditEventListResponse страница журнала аудита
+type AuditEventListResponse struct {
+	Events []AuditEventResponse `json:"events"`
+	pagination.Meta
+}
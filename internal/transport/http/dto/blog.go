@@ -1,21 +1,27 @@
 package dto
 
 import (
+	"premium_caste/internal/lib/pagination"
 	"time"
 
 	"github.com/google/uuid"
 )
 
 type CreateBlogPostRequest struct {
-	Title           string         `json:"title" validate:"required,min=3,max=100"`
-	Slug            string         `json:"slug,omitempty" validate:"omitempty,slug"`
-	Excerpt         string         `json:"excerpt,omitempty" validate:"omitempty,max=255"`
-	Content         string         `json:"content" validate:"required"`
-	FeaturedImageID uuid.UUID      `json:"featured_image_id,omitempty" swaggertype:"string" format:"uuid"`
-	AuthorID        uuid.UUID      `json:"author_id" validate:"required" swaggertype:"string" format:"uuid"`
-	Status          string         `json:"status,omitempty" validate:"omitempty,oneof=draft published archived"`
-	PublishedAt     *time.Time     `json:"published_at,omitempty"`
-	Metadata        map[string]any `json:"metadata,omitempty"`
+	Title           string                 `json:"title" validate:"required,min=3,max=100"`
+	Slug            string                 `json:"slug,omitempty" validate:"omitempty,slug"`
+	Excerpt         string                 `json:"excerpt,omitempty" validate:"omitempty,max=255"`
+	Content         string                 `json:"content" validate:"required"`
+	ContentFormat   string                 `json:"content_format,omitempty" validate:"omitempty,oneof=markdown html plain"`
+	FeaturedImageID uuid.UUID              `json:"featured_image_id,omitempty" swaggertype:"string" format:"uuid"`
+	AuthorID        uuid.UUID              `json:"-" swaggerignore:"true"` // Текущий пользователь, из тела запроса не читается
+	CategoryID      *uuid.UUID             `json:"category_id,omitempty" swaggertype:"string" format:"uuid"`
+	Tags            []string               `json:"tags,omitempty" validate:"omitempty,max=20,dive,min=1,max=50"`
+	MediaGroups     []AddMediaGroupRequest `json:"media_groups,omitempty" validate:"omitempty,max=20,dive"` // Привязываются вместе с постом в одной транзакции
+	Status          string                 `json:"status,omitempty" validate:"omitempty,oneof=draft published archived"`
+	PublishedAt     *time.Time             `json:"published_at,omitempty"`
+	Metadata        map[string]any         `json:"metadata,omitempty"`
+	CommentsClosed  bool                   `json:"comments_closed,omitempty"`
 }
 
 type CreateBlogPostResponse struct {
@@ -32,10 +38,14 @@ type UpdateBlogPostRequest struct {
 	Slug            *string        `json:"slug,omitempty" validate:"omitempty,slug"`
 	Excerpt         *string        `json:"excerpt,omitempty" validate:"omitempty,max=255"`
 	Content         *string        `json:"content,omitempty"`
+	ContentFormat   *string        `json:"content_format,omitempty" validate:"omitempty,oneof=markdown html plain"`
 	FeaturedImageID *uuid.UUID     `json:"featured_image_id,omitempty" swaggertype:"string" format:"uuid"`
+	CategoryID      *uuid.UUID     `json:"category_id,omitempty" swaggertype:"string" format:"uuid"`     // Нулевой UUID убирает пост из категории
+	Tags            []string       `json:"tags,omitempty" validate:"omitempty,max=20,dive,min=1,max=50"` // nil - теги не меняются, пустой список - удалить все теги
 	Status          *string        `json:"status,omitempty" validate:"omitempty,oneof=draft published archived"`
 	PublishedAt     *time.Time     `json:"published_at,omitempty"`
 	Metadata        map[string]any `json:"metadata,omitempty"`
+	CommentsClosed  *bool          `json:"comments_closed,omitempty"`
 }
 
 type UpdateBlogPostResponse struct {
@@ -53,17 +63,37 @@ type BlogPostResponse struct {
 	Slug              string                         `json:"slug"`
 	Excerpt           string                         `json:"excerpt,omitempty"`
 	Content           string                         `json:"content"`
+	ContentFormat     string                         `json:"content_format"`
+	ContentHTML       string                         `json:"content_html"`
+	ReadingTime       int                            `json:"reading_time"` // Время чтения в минутах
+	TableOfContents   []TOCEntry                     `json:"toc"`
 	FeaturedImageID   uuid.UUID                      `json:"featured_image_id,omitempty" swaggertype:"string" format:"uuid"`
 	FeaturedImagePath *string                        `json:"featured_image_path"`
 	AuthorID          uuid.UUID                      `json:"author_id" swaggertype:"string" format:"uuid"`
+	CategoryID        *uuid.UUID                     `json:"category_id,omitempty" swaggertype:"string" format:"uuid"`
+	Tags              []BlogTagResponse              `json:"tags"`
 	Status            string                         `json:"status"`
 	PublishedAt       *time.Time                     `json:"published_at,omitempty"`
 	CreatedAt         time.Time                      `json:"created_at"`
 	UpdatedAt         time.Time                      `json:"updated_at"`
 	Metadata          map[string]any                 `json:"metadata,omitempty"`
+	CommentsClosed    bool                           `json:"comments_closed"`
+	CommentCount      int                            `json:"comment_count"` // Количество одобренных комментариев
 	MediaGroups       map[string][]MediaItemResponse `json:"media_groups"`
 }
 
+// TOCEntry элемент оглавления поста
+type TOCEntry struct {
+	Level int    `json:"level"`
+	ID    string `json:"id"`
+	Text  string `json:"text"`
+}
+
+type BlogTagResponse struct {
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
 type MediaItemResponse struct {
 	ID          uuid.UUID `json:"id"`
 	StoragePath string    `json:"storage_path"`
@@ -72,10 +102,54 @@ type MediaItemResponse struct {
 }
 
 type BlogPostListResponse struct {
-	Posts      []BlogPostResponse `json:"posts"`
-	TotalCount int                `json:"total_count"`
-	Page       int                `json:"page"`
-	PerPage    int                `json:"per_page"`
+	Posts []BlogPostResponse `json:"posts"`
+	pagination.Meta
+	TotalCount int `json:"total_count"` // Устарело: то же, что total
+}
+
+// BlogPostFilter параметры фильтрации списка постов
+type BlogPostFilter struct {
+	Status       string
+	CategoryID   *uuid.UUID
+	CategorySlug string
+	Tags         []string
+	AuthorID     *uuid.UUID
+	DateFrom     *time.Time
+	DateTo       *time.Time
+	Cursor       string // Значение next_cursor предыдущей страницы
+}
+
+type TagCountResponse struct {
+	Name  string `json:"name"`
+	Slug  string `json:"slug"`
+	Count int    `json:"count"`
+}
+
+type CreateCategoryRequest struct {
+	ParentID    *uuid.UUID `json:"parent_id,omitempty" swaggertype:"string" format:"uuid"`
+	Name        string     `json:"name" validate:"required,min=2,max=100"`
+	Slug        string     `json:"slug,omitempty" validate:"omitempty,max=100,slug"`
+	Description string     `json:"description,omitempty" validate:"omitempty,max=500"`
+	Position    int        `json:"position"`
+}
+
+type UpdateCategoryRequest struct {
+	ParentID    *uuid.UUID `json:"parent_id,omitempty" swaggertype:"string" format:"uuid"` // Нулевой UUID делает категорию корневой
+	Name        *string    `json:"name,omitempty" validate:"omitempty,min=2,max=100"`
+	Slug        *string    `json:"slug,omitempty" validate:"omitempty,max=100,slug"`
+	Description *string    `json:"description,omitempty" validate:"omitempty,max=500"`
+	Position    *int       `json:"position,omitempty"`
+}
+
+type CategoryResponse struct {
+	ID          uuid.UUID  `json:"id" swaggertype:"string" format:"uuid"`
+	ParentID    *uuid.UUID `json:"parent_id,omitempty" swaggertype:"string" format:"uuid"`
+	Name        string     `json:"name"`
+	Slug        string     `json:"slug"`
+	Description string     `json:"description,omitempty"`
+	Position    int        `json:"position"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
 }
 
 type AddMediaGroupRequest struct {
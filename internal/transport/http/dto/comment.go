@@ -0,0 +1,44 @@
+package dto
+
+import (
+	"premium_caste/internal/lib/pagination"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CreateCommentRequest struct {
+	ParentID *uuid.UUID `json:"parent_id,omitempty" swaggertype:"string" format:"uuid"`
+	Content  string     `json:"content" validate:"required,min=1,max=5000"`
+}
+
+type UpdateCommentStatusRequest struct {
+	Status string `json:"status" validate:"required,oneof=pending approved spam rejected"`
+}
+
+type CommentResponse struct {
+	ID          uuid.UUID         `json:"id" swaggertype:"string" format:"uuid"`
+	PostID      uuid.UUID         `json:"post_id" swaggertype:"string" format:"uuid"`
+	UserID      uuid.UUID         `json:"user_id" swaggertype:"string" format:"uuid"`
+	ParentID    *uuid.UUID        `json:"parent_id,omitempty" swaggertype:"string" format:"uuid"`
+	AuthorName  string            `json:"author_name"`
+	Content     string            `json:"content"`
+	Status      string            `json:"status"`
+	ModeratedBy *uuid.UUID        `json:"moderated_by,omitempty" swaggertype:"string" format:"uuid"`
+	ModeratedAt *time.Time        `json:"moderated_at,omitempty"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+	Replies     []CommentResponse `json:"replies,omitempty"` // Ответы, только в древовидном списке поста
+}
+
+// CommentFilter параметры очереди модерации
+type CommentFilter struct {
+	Status string // Пусто для всех статусов
+	Cursor string // Значение next_cursor предыдущей страницы
+}
+
+type CommentListResponse struct {
+	Comments []CommentResponse `json:"comments"`
+	pagination.Meta
+	TotalCount int `json:"total_count"` // Устарело: то же, что total
+}
@@ -1,6 +1,7 @@
 package dto
 
 import (
+	"premium_caste/internal/lib/pagination"
 	"time"
 
 	"github.com/google/uuid"
@@ -8,49 +9,135 @@ import (
 
 // GalleryResponse представляет собой DTO для ответа с данными о галерее
 type GalleryResponse struct {
-	ID              uuid.UUID   `json:"id"`                // Уникальный идентификатор галереи
-	Title           string      `json:"title"`             // Название галереи
-	Slug            string      `json:"slug"`              // Уникальный URL-идентификатор галереи
-	Description     string      `json:"description"`       // Описание галереи
-	Images          []string    `json:"images"`            // Список изображений в галерее
-	CoverImageIndex int         `json:"cover_image_index"` // Индекс изображения, используемого как обложка
-	AuthorID        uuid.UUID   `json:"author_id"`         // Идентификатор автора галереи
-	Status          string      `json:"status"`            // Статус галереи (например, "draft", "published", "archived")
-	PublishedAt     *time.Time  `json:"published_at"`      // Дата и время публикации (если галерея опубликована)
-	CreatedAt       time.Time   `json:"created_at"`        // Дата и время создания галереи
-	UpdatedAt       time.Time   `json:"updated_at"`        // Дата и время последнего обновления галереи
-	Metadata        interface{} `json:"metadata"`          // Дополнительные метаданные (может быть произвольной структурой)
-	Tags            []string    `json:"tags"`              // Список тегов, связанных с галереей
+	ID              uuid.UUID             `json:"id"`                // Уникальный идентификатор галереи
+	Title           string                `json:"title"`             // Название галереи
+	Slug            string                `json:"slug"`              // Уникальный URL-идентификатор галереи
+	Description     string                `json:"description"`       // Описание галереи
+	Images          []string              `json:"images"`            // Пути изображений в порядке items, оставлены для совместимости
+	CoverImageIndex int                   `json:"cover_image_index"` // Индекс изображения, используемого как обложка
+	CoverMediaID    *uuid.UUID            `json:"cover_media_id"`    // Медиа обложки
+	Items           []GalleryItemResponse `json:"items"`             // Изображения галереи с подписями
+	AuthorID        uuid.UUID             `json:"author_id"`         // Идентификатор автора галереи
+	Status          string                `json:"status"`            // Статус галереи (например, "draft", "published", "archived")
+	PublishedAt     *time.Time            `json:"published_at"`      // Дата и время публикации (если галерея опубликована)
+	CreatedAt       time.Time             `json:"created_at"`        // Дата и время создания галереи
+	UpdatedAt       time.Time             `json:"updated_at"`        // Дата и время последнего обновления галереи
+	Metadata        interface{}           `json:"metadata"`          // Дополнительные метаданные (может быть произвольной структурой)
+	Tags            []string              `json:"tags"`              // Список тегов, связанных с галереей
 }
 
 type CreateGalleryRequest struct {
-	Title           string                 `json:"title" validate:"required"`
-	Slug            string                 `json:"slug"`
+	Title           string                 `json:"title" validate:"required,max=255"`
+	Slug            string                 `json:"slug" validate:"omitempty,max=255"`
 	Description     string                 `json:"description"`
-	Images          []string               `json:"images"`            // Список изображений в галерее
-	CoverImageIndex int                    `json:"cover_image_index"` // Индекс изображения, используемого как обложка
-	AuthorID        uuid.UUID              `json:"author_id" validate:"required"`
-	Status          string                 `json:"status"`
-	Tags            []string               `json:"tags"`
+	Images          []string               `json:"images"`            // Устарело: пути изображений, сопоставляются с media по storage_path
+	CoverImageIndex int                    `json:"cover_image_index"` // Устарело: индекс обложки в images
+	Items           []GalleryItemInput     `json:"items" validate:"omitempty,dive"`
+	CoverMediaID    *uuid.UUID             `json:"cover_media_id,omitempty"` // По умолчанию первое изображение
+	AuthorID        uuid.UUID              `json:"-" swaggerignore:"true"`   // Текущий пользователь, из тела запроса не читается
+	Status          string                 `json:"status" validate:"omitempty,oneof=draft published archived"`
+	Tags            []string               `json:"tags" validate:"omitempty,dive,min=1,max=255"`
 	Metadata        map[string]interface{} `json:"metadata"`
 }
 
 type UpdateGalleryRequest struct {
 	ID              uuid.UUID              `json:"id" validate:"required"`
-	Title           string                 `json:"title" validate:"required"`
-	Slug            string                 `json:"slug"`
+	Title           string                 `json:"title" validate:"required,max=255"`
+	Slug            string                 `json:"slug" validate:"omitempty,max=255"`
 	Description     string                 `json:"description"`
-	Images          []string               `json:"images"`            // Список изображений в галерее
-	CoverImageIndex int                    `json:"cover_image_index"` // Индекс изображения, используемого как обложка
-	Status          string                 `json:"status"`
-	Tags            []string               `json:"tags"`
+	Images          []string               `json:"images"`                          // Устарело: пути изображений, сопоставляются с media по storage_path
+	CoverImageIndex int                    `json:"cover_image_index"`               // Устарело: индекс обложки в images
+	Items           []GalleryItemInput     `json:"items" validate:"omitempty,dive"` // nil - изображения не меняются
+	CoverMediaID    *uuid.UUID             `json:"cover_media_id,omitempty"`        // nil - обложка не меняется
+	Status          string                 `json:"status" validate:"omitempty,oneof=draft published archived"`
+	Tags            []string               `json:"tags" validate:"omitempty,dive,min=1,max=255"`
 	Metadata        map[string]interface{} `json:"metadata"`
 }
 
 type UpdateGalleryStatusRequest struct {
-	Status string `json:"status" validate:"required"`
+	Status string `json:"status" validate:"required,oneof=draft published archived"`
 }
 
 type GalleryTagsRequest struct {
 	Tags []string `json:"tags"`
 }
+
+// GalleryFilter параметры фильтрации и сортировки списка галерей
+type GalleryFilter struct {
+	Status       string
+	Tags         []string
+	MatchAllTags bool
+	AuthorID     *uuid.UUID
+	DateFrom     *time.Time
+	DateTo       *time.Time
+	Query        string
+	Sort         string // created, published или title
+	Cursor       string // Значение next_cursor предыдущей страницы
+}
+
+type GalleryListResponse struct {
+	Galleries []GalleryResponse `json:"galleries"`
+	pagination.Meta
+}
+
+// GalleryItemResponse изображение галереи
+type GalleryItemResponse struct {
+	MediaID     uuid.UUID `json:"media_id"`
+	Position    int       `json:"position"`
+	Caption     string    `json:"caption,omitempty"`
+	AltText     string    `json:"alt_text,omitempty"`
+	StoragePath string    `json:"storage_path"`
+}
+
+type GalleryItemInput struct {
+	MediaID uuid.UUID `json:"media_id" validate:"required"`
+	Caption string    `json:"caption,omitempty" validate:"omitempty,max=500"`
+	AltText string    `json:"alt_text,omitempty" validate:"omitempty,max=255"`
+}
+
+type AddGalleryItemsRequest struct {
+	Items []GalleryItemInput `json:"items" validate:"required,min=1,dive"`
+}
+
+type UpdateGalleryItemRequest struct {
+	Caption string `json:"caption" validate:"omitempty,max=500"`
+	AltText string `json:"alt_text" validate:"omitempty,max=255"`
+}
+
+type ReorderGalleryItemsRequest struct {
+	MediaIDs []uuid.UUID `json:"media_ids" validate:"required,min=1"`
+}
+
+type SetGalleryCoverRequest struct {
+	MediaID uuid.UUID `json:"media_id" validate:"required"`
+}
+
+// GalleryTagResponse тег галерей с количеством использований
+type GalleryTagResponse struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type RenameGalleryTagRequest struct {
+	From      string `json:"from" validate:"required,max=50"`
+	To        string `json:"to" validate:"required,max=50"`
+	KeepAlias bool   `json:"keep_alias"` // Сохранить старое имя как алиас, чтобы старые ссылки продолжали работать
+}
+
+type MergeGalleryTagsRequest struct {
+	Sources     []string `json:"sources" validate:"required,min=1,dive,required,max=50"`
+	Target      string   `json:"target" validate:"required,max=50"`
+	KeepAliases bool     `json:"keep_aliases"` // Сохранить исходные теги как алиасы target
+}
+
+// GalleryTagOperationResponse результат переименования или слияния тегов
+type GalleryTagOperationResponse struct {
+	Tag       string `json:"tag"`       // Итоговый тег
+	Galleries int    `json:"galleries"` // Количество измененных галерей
+}
+
+type GalleryTagAliasResponse struct {
+	Alias     string    `json:"alias"`
+	Tag       string    `json:"tag"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,27 @@
+package dto
+
+import "time"
+
+// IdentityProvidersResponse провайдеры, через которых можно войти
+type IdentityProvidersResponse struct {
+	Providers []string `json:"providers"`
+}
+
+// OIDCStartResponse адрес страницы входа провайдера
+type OIDCStartResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+// OIDCCallbackRequest параметры, с которыми провайдер вернул пользователя на redirect_url
+type OIDCCallbackRequest struct {
+	Code  string `json:"code" validate:"required"`
+	State string `json:"state" validate:"required"`
+}
+
+// IdentityResponse учетная запись провайдера, привязанная к пользователю
+type IdentityResponse struct {
+	Provider    string     `json:"provider"`
+	Email       string     `json:"email"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+}
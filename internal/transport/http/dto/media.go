@@ -9,7 +9,7 @@ import (
 )
 
 type MediaUploadInput struct {
-	UploaderID     uuid.UUID             `json:"uploader_id" validate:"required"`
+	UploaderID     uuid.UUID             `json:"-" validate:"required"` // Текущий пользователь, из формы не читается
 	File           *multipart.FileHeader `json:"-" form:"file" validate:"required"`
 	MediaType      string                `json:"media_type" validate:"required,oneof=photo video audio document"`
 	IsPublic       bool                  `json:"is_public"`
@@ -24,8 +24,23 @@ type MediaUploadInput struct {
 }
 
 type CreateMediaGroupRequest struct {
-	OwnerID     string `json:"owner_id" validate:"required,uuid"`
-	Description string `json:"description"`
+	OwnerID     uuid.UUID `json:"-" swaggerignore:"true"` // Текущий пользователь, из формы не читается
+	Description string    `json:"description"`
+}
+
+// MediaGroupUploadResponse результат загрузки файлов в новую медиа-группу
+type MediaGroupUploadResponse struct {
+	GroupID uuid.UUID       `json:"group_id" swaggertype:"string" format:"uuid"`
+	Media   []*models.Media `json:"media"`
+}
+
+// MediaGCResult итог сборки мусора медиа. В режиме dry-run Media - найденные,
+// но не удаленные медиа
+type MediaGCResult struct {
+	Media      []models.Media `json:"media"`
+	FreedBytes int64          `json:"freed_bytes"`
+	FileErrors int            `json:"file_errors"` // Файлы, которые не удалось удалить после удаления записи
+	DryRun     bool           `json:"dry_run"`
 }
 
 type AttachMediaRequest struct {
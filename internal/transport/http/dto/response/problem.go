@@ -0,0 +1,18 @@
+package response
+
+import "premium_caste/internal/domain/apperr"
+
+// ProblemContentType тип содержимого ответа с ошибкой (RFC 7807)
+const ProblemContentType = "application/problem+json"
+
+// Problem описание ошибки в формате RFC 7807. Code - стабильный машиночитаемый код,
+// на который могут опираться клиенты, в отличие от текста detail
+type Problem struct {
+	Type     string              `json:"type" example:"about:blank"`
+	Title    string              `json:"title" example:"Not Found"`
+	Status   int                 `json:"status" example:"404"`
+	Detail   string              `json:"detail,omitempty" example:"post not found"`
+	Instance string              `json:"instance,omitempty" example:"/api/v1/posts/1221067c-cc35-4dae-b5f5-feee4bbb3e22"`
+	Code     string              `json:"code" example:"post_not_found"`
+	Errors   []apperr.FieldError `json:"errors,omitempty"` // Ошибки по полям для code=validation_failed
+}
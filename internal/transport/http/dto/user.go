@@ -1,15 +1,20 @@
 package dto
 
 import (
+	"time"
+
+	"premium_caste/internal/lib/pagination"
+
 	"github.com/google/uuid"
 )
 
 type UserRegisterInput struct {
-	Name     string    `json:"name" validate:"required,min=2,max=100"`
-	Email    string    `json:"email" validate:"required,email"`
-	Phone    string    `json:"phone" validate:"required,e164"` // Формат +71234567890
-	Password string    `json:"password" validate:"required,min=8,max=64"`
-	IsAdmin  bool      `json:"is_admin"`
+	Name     string `json:"name" validate:"required,min=2,max=100"`
+	Email    string `json:"email" validate:"required,email"`
+	Phone    string `json:"phone" validate:"required,e164"` // Формат +71234567890
+	Password string `json:"password" validate:"required,min=8,max=64"`
+	// IsAdmin задается только командой create-admin, из тела публичной регистрации не читается
+	IsAdmin  bool      `json:"-" swaggerignore:"true"`
 	BasketID uuid.UUID `json:"-" swaggertype:"string" format:"uuid"`
 }
 
@@ -23,3 +28,121 @@ type UserRegisterInput struct {
 // 		BasketID: uuid.New(),
 // 	}
 // }
+
+// ResetPasswordInput новый пароль пользователя, которого ищут по email или телефону
+type ResetPasswordInput struct {
+	Identifier string `json:"identifier" validate:"required"`
+	Password   string `json:"password" validate:"required,min=8,max=64"`
+}
+
+// UserResponse пользователь в ответах API. Хеш пароля не передается
+type UserResponse struct {
+	ID               uuid.UUID  `json:"id" swaggertype:"string" format:"uuid"`
+	Name             string     `json:"name"`
+	Email            string     `json:"email"`
+	Phone            string     `json:"phone"`
+	Role             string     `json:"role" enums:"user,editor,admin"`
+	IsAdmin          bool       `json:"is_admin"`
+	Status           string     `json:"status" enums:"active,suspended,anonymized"`
+	RegistrationDate time.Time  `json:"registration_date"`
+	LastLogin        time.Time  `json:"last_login"`
+	SuspendedAt      *time.Time `json:"suspended_at,omitempty"`
+	SuspendedBy      *uuid.UUID `json:"suspended_by,omitempty" swaggertype:"string" format:"uuid"`
+	SuspensionReason string     `json:"suspension_reason,omitempty"`
+	AnonymizedAt     *time.Time `json:"anonymized_at,omitempty"`
+	UpdatedAt        *time.Time `json:"updated_at,omitempty"`
+	UpdatedBy        *uuid.UUID `json:"updated_by,omitempty" swaggertype:"string" format:"uuid"`
+	PendingEmail     string     `json:"pending_email,omitempty"` // Новый email, ожидающий подтверждения
+	TwoFactorEnabled bool       `json:"two_factor_enabled"`
+}
+
+// UserListResponse страница списка пользователей
+type UserListResponse struct {
+	Users []UserResponse `json:"users"`
+	pagination.Meta
+}
+
+// UserFilter параметры поиска пользователей в админке
+type UserFilter struct {
+	Query  string // Подстрока имени, email или телефона
+	Role   string
+	Status string
+	Cursor string // Значение next_cursor предыдущей страницы
+}
+
+// UpdateUserRoleRequest новая роль пользователя
+type UpdateUserRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=user editor admin"`
+}
+
+// SuspendUserRequest причина блокировки, ее видят администраторы
+type SuspendUserRequest struct {
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+// UpdateProfileRequest изменения профиля текущего пользователя. Отсутствующие поля не меняются
+type UpdateProfileRequest struct {
+	Name  *string `json:"name,omitempty" validate:"omitempty,min=2,max=100"`
+	Email *string `json:"email,omitempty" validate:"omitempty,email"` // Применяется после подтверждения кодом из письма
+	Phone *string `json:"phone,omitempty" validate:"omitempty,e164"`
+}
+
+// ConfirmEmailRequest код подтверждения нового email
+type ConfirmEmailRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// ChangePasswordRequest смена пароля текущим пользователем
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=8,max=64,nefield=CurrentPassword"`
+}
+
+// LoginTwoFactorRequest второй шаг входа
+type LoginTwoFactorRequest struct {
+	ChallengeID string `json:"challenge_id" validate:"required"`
+	Code        string `json:"code" validate:"required"` // Код из приложения-аутентификатора или код восстановления
+}
+
+// LoginChallengeRequest незавершенный вход, для которого нужно настроить 2FA
+type LoginChallengeRequest struct {
+	ChallengeID string `json:"challenge_id" validate:"required"`
+}
+
+// TwoFactorEnrollmentResponse данные для добавления учетной записи в приложение-аутентификатор
+type TwoFactorEnrollmentResponse struct {
+	Secret          string `json:"secret"`           // Секрет в base32 для ручного ввода
+	ProvisioningURI string `json:"provisioning_uri"` // Ссылка otpauth:// для QR-кода
+}
+
+// TwoFactorCodeRequest код из приложения-аутентификатора
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// DisableTwoFactorRequest отключение 2FA подтверждается паролем
+type DisableTwoFactorRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+}
+
+// RecoveryCodesResponse коды восстановления. Показываются один раз, сервер хранит только хеши
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// TwoFactorStatusResponse состояние 2FA текущего пользователя
+type TwoFactorStatusResponse struct {
+	Enabled           bool `json:"enabled"`
+	Required          bool `json:"required"` // Политика безопасности требует 2FA для роли пользователя
+	RecoveryCodesLeft int  `json:"recovery_codes_left"`
+}
+
+// UpdateSecurityPolicyRequest изменение настроек безопасности
+type UpdateSecurityPolicyRequest struct {
+	RequireTwoFactor *bool `json:"require_two_factor" validate:"required"` // Требовать 2FA от администраторов и редакторов
+}
+
+// CSRFTokenResponse токен для заголовка X-CSRF-Token. Действует, пока не начат новый сеанс
+type CSRFTokenResponse struct {
+	CSRFToken string `json:"csrf_token"`
+}
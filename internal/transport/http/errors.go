@@ -0,0 +1,197 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"premium_caste/internal/domain/apperr"
+	"premium_caste/internal/lib/logger/sl"
+	"premium_caste/internal/lib/validation"
+	"premium_caste/internal/transport/http/dto/response"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// Ошибки, общие для обработчиков
+var (
+	errInvalidBody  = apperr.Invalid("invalid_request_body", "invalid request body")
+	errAuthRequired = apperr.Unauthorized("authentication_required", "authentication required")
+	errForbidden    = apperr.Forbidden("forbidden", "access denied")
+	errCSRFToken    = apperr.Forbidden("csrf_token_invalid", "missing or invalid CSRF token")
+	errInternal     = apperr.New(apperr.KindInternal, "internal_error", "internal server error")
+	errBodyTooLarge = apperr.TooLarge("request_body_too_large", "request body too large")
+)
+
+// kindStatus HTTP-статусы видов ошибок предметной области
+var kindStatus = map[apperr.Kind]int{
+	apperr.KindInvalid:      http.StatusBadRequest,
+	apperr.KindUnauthorized: http.StatusUnauthorized,
+	apperr.KindForbidden:    http.StatusForbidden,
+	apperr.KindNotFound:     http.StatusNotFound,
+	apperr.KindConflict:     http.StatusConflict,
+	apperr.KindTooLarge:     http.StatusRequestEntityTooLarge,
+	apperr.KindUnsupported:  http.StatusUnsupportedMediaType,
+	apperr.KindRateLimited:  http.StatusTooManyRequests,
+	apperr.KindInternal:     http.StatusInternalServerError,
+}
+
+// ErrorHandler центральный обработчик ошибок Echo. Обработчики возвращают ошибки,
+// а он переводит их в ответ application/problem+json (RFC 7807):
+//   - ошибки apperr - по виду ошибки, с ее кодом и ошибками полей;
+//   - ошибки валидатора - как validation_failed с описанием полей на языке из Accept-Language;
+//   - *http.MaxBytesError - как 413, даже если обработчик обернул ее в ошибку чтения тела;
+//   - *echo.HTTPError - со статусом ошибки (маршрут не найден, неверный метод и т.д.);
+//   - прочие ошибки - как 500 без подробностей, сама ошибка только пишется в лог
+func ErrorHandler(log *slog.Logger) echo.HTTPErrorHandler {
+	return func(err error, c echo.Context) {
+		if c.Response().Committed {
+			return
+		}
+
+		problem := problemFromError(err, fieldsTranslator(c))
+		problem.Instance = c.Request().URL.Path
+
+		if problem.Status >= http.StatusInternalServerError {
+			log.Error("request failed",
+				slog.String("method", c.Request().Method),
+				slog.String("path", c.Request().URL.Path),
+				sl.Err(err),
+			)
+		}
+
+		var writeErr error
+		if c.Request().Method == http.MethodHead {
+			writeErr = c.NoContent(problem.Status)
+		} else {
+			c.Response().Header().Set(echo.HeaderContentType, response.ProblemContentType)
+			writeErr = c.JSON(problem.Status, problem)
+		}
+		if writeErr != nil {
+			log.Error("failed to write error response", sl.Err(writeErr))
+		}
+	}
+}
+
+// fieldTranslator валидатор, который умеет описывать ошибки полей на языке клиента
+type fieldTranslator interface {
+	Fields(errs validator.ValidationErrors, lang string) []apperr.FieldError
+}
+
+// fieldsTranslator возвращает функцию описания ошибок полей на языке из Accept-Language
+// или nil, если валидатор Echo переводов не поддерживает
+func fieldsTranslator(c echo.Context) func(validator.ValidationErrors) []apperr.FieldError {
+	translator, ok := c.Echo().Validator.(fieldTranslator)
+	if !ok {
+		return nil
+	}
+
+	lang := validation.Lang(c.Request().Header.Get("Accept-Language"))
+	return func(errs validator.ValidationErrors) []apperr.FieldError {
+		c.Response().Header().Set("Content-Language", lang)
+		return translator.Fields(errs, lang)
+	}
+}
+
+// problemFromError описывает ошибку для ответа клиенту. translate описывает ошибки
+// полей валидатора, при nil используются английские сообщения с именем правила
+func problemFromError(err error, translate func(validator.ValidationErrors) []apperr.FieldError) response.Problem {
+	var (
+		appErr    *apperr.Error
+		httpErr   *echo.HTTPError
+		validErrs validator.ValidationErrors
+		bodyErr   *http.MaxBytesError
+	)
+
+	switch {
+	case errors.As(err, &bodyErr):
+		appErr = errBodyTooLarge.WithDetail("limit is %d bytes", bodyErr.Limit)
+	case errors.As(err, &appErr):
+	case errors.As(err, &validErrs):
+		appErr = validationError(validErrs, translate)
+	case errors.As(err, &httpErr):
+		return httpProblem(httpErr)
+	default:
+		appErr = errInternal
+	}
+
+	status, ok := kindStatus[appErr.Kind]
+	if !ok {
+		status = http.StatusInternalServerError
+	}
+
+	detail := appErr.Message
+	if appErr.Detail != "" {
+		detail += ": " + appErr.Detail
+	}
+
+	return response.Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: detail,
+		Code:   appErr.Code,
+		Errors: appErr.Fields,
+	}
+}
+
+// httpProblem описывает ошибки самого Echo: отсутствующий маршрут, неверный метод и т.д.
+func httpProblem(httpErr *echo.HTTPError) response.Problem {
+	status := httpErr.Code
+	if status < http.StatusBadRequest {
+		status = http.StatusInternalServerError
+	}
+
+	problem := response.Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Code:   strings.ReplaceAll(strings.ToLower(http.StatusText(status)), " ", "_"),
+	}
+
+	// Текст внутренних ошибок клиенту не отдается
+	if status < http.StatusInternalServerError {
+		problem.Detail = fmt.Sprint(httpErr.Message)
+	}
+
+	return problem
+}
+
+// validationError переводит ошибки валидатора в ошибку с описанием полей.
+// Имена полей берутся из json-тегов, вложенные поля записываются через точку: items[0].media_id
+func validationError(errs validator.ValidationErrors, translate func(validator.ValidationErrors) []apperr.FieldError) *apperr.Error {
+	if translate != nil {
+		return apperr.Validation("request validation failed", translate(errs)...)
+	}
+
+	fields := make([]apperr.FieldError, 0, len(errs))
+	for _, fe := range errs {
+		field := apperr.Field(validation.FieldPath(fe), fe.Tag(), fmt.Sprintf("failed on the %q rule", fe.Tag()))
+		field.Param = fe.Param()
+		fields = append(fields, field)
+	}
+
+	return apperr.Validation("request validation failed", fields...)
+}
+
+// bindRequest читает тело запроса в req и проверяет его валидатором
+func bindRequest(c echo.Context, req any) error {
+	if err := c.Bind(req); err != nil {
+		return errInvalidBody.Wrap(err)
+	}
+
+	return c.Validate(req)
+}
+
+// uuidParam читает UUID из параметра пути name
+func uuidParam(c echo.Context, name string) (uuid.UUID, error) {
+	id, err := uuid.Parse(c.Param(name))
+	if err != nil {
+		return uuid.Nil, apperr.InvalidField(name, "uuid", fmt.Sprintf("invalid %s", name))
+	}
+
+	return id, nil
+}
@@ -0,0 +1,181 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"premium_caste/internal/domain/apperr"
+	"premium_caste/internal/lib/validation"
+	"premium_caste/internal/storage"
+	"premium_caste/internal/transport/http/dto/response"
+	"reflect"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorHandler(t *testing.T) {
+	type createRequest struct {
+		Title string `json:"title" validate:"required"`
+	}
+
+	validate := validator.New()
+	validate.RegisterTagNameFunc(func(field reflect.StructField) string {
+		return field.Tag.Get("json")
+	})
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+		wantDetail string
+		wantFields []apperr.FieldError
+	}{
+		{
+			name:       "not found wrapped by service",
+			err:        fmt.Errorf("blog_service.GetPostByID: %w", storage.ErrPostNotFound),
+			wantStatus: http.StatusNotFound,
+			wantCode:   "post_not_found",
+			wantDetail: "post not found",
+		},
+		{
+			name:       "conflict keeps database error out of detail",
+			err:        storage.ErrSlugTaken.Wrap(errors.New("duplicate key value violates unique constraint")),
+			wantStatus: http.StatusConflict,
+			wantCode:   "slug_taken",
+			wantDetail: "slug is already taken",
+		},
+		{
+			name:       "error with detail",
+			err:        storage.ErrInvalidTag.WithDetail("tag is empty"),
+			wantStatus: http.StatusBadRequest,
+			wantCode:   "invalid_tag",
+			wantDetail: "invalid tag: tag is empty",
+		},
+		{
+			name:       "rate limited",
+			err:        storage.ErrCommentRateLimited,
+			wantStatus: http.StatusTooManyRequests,
+			wantCode:   "comment_rate_limited",
+			wantDetail: "too many comments, try again later",
+		},
+		{
+			name:       "validator errors",
+			err:        validate.Struct(createRequest{}),
+			wantStatus: http.StatusBadRequest,
+			wantCode:   apperr.CodeValidation,
+			wantDetail: "request validation failed",
+			wantFields: []apperr.FieldError{{Field: "title", Code: "required", Message: `failed on the "required" rule`}},
+		},
+		{
+			name:       "body too large while binding",
+			err:        errInvalidBody.Wrap(echo.NewHTTPError(http.StatusBadRequest).SetInternal(&http.MaxBytesError{Limit: 1024})),
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantCode:   "request_body_too_large",
+			wantDetail: "request body too large: limit is 1024 bytes",
+		},
+		{
+			name:       "echo error",
+			err:        echo.ErrMethodNotAllowed,
+			wantStatus: http.StatusMethodNotAllowed,
+			wantCode:   "method_not_allowed",
+			wantDetail: "Method Not Allowed",
+		},
+		{
+			name:       "unknown error is hidden",
+			err:        errors.New("pq: connection refused"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   "internal_error",
+			wantDetail: "internal server error",
+		},
+	}
+
+	handler := ErrorHandler(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/posts/1", nil)
+			rec := httptest.NewRecorder()
+
+			handler(tt.err, e.NewContext(req, rec))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, response.ProblemContentType, rec.Header().Get(echo.HeaderContentType))
+
+			var problem response.Problem
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+			assert.Equal(t, tt.wantStatus, problem.Status)
+			assert.Equal(t, tt.wantCode, problem.Code)
+			assert.Equal(t, tt.wantDetail, problem.Detail)
+			assert.Equal(t, tt.wantFields, problem.Errors)
+			assert.Equal(t, http.StatusText(tt.wantStatus), problem.Title)
+			assert.Equal(t, "/api/v1/posts/1", problem.Instance)
+		})
+	}
+}
+
+func TestErrorHandler_LocalizedValidation(t *testing.T) {
+	type createRequest struct {
+		Title string `json:"title" validate:"required,max=5"`
+		Slug  string `json:"slug" validate:"omitempty,slug"`
+	}
+
+	tests := []struct {
+		name           string
+		acceptLanguage string
+		wantLanguage   string
+		wantFields     []apperr.FieldError
+	}{
+		{
+			name:           "russian",
+			acceptLanguage: "ru-RU,ru;q=0.9,en;q=0.8",
+			wantLanguage:   "ru",
+			wantFields: []apperr.FieldError{
+				{Field: "title", Code: "max", Param: "5", Message: "title должен содержать максимум 5 символов"},
+				{Field: "slug", Code: "slug", Message: "slug может содержать только строчные латинские буквы, цифры и дефисы"},
+			},
+		},
+		{
+			name:         "english by default",
+			wantLanguage: "en",
+			wantFields: []apperr.FieldError{
+				{Field: "title", Code: "max", Param: "5", Message: "title must be a maximum of 5 characters in length"},
+				{Field: "slug", Code: "slug", Message: "slug must contain only lowercase latin letters, digits and hyphens"},
+			},
+		},
+	}
+
+	handler := ErrorHandler(slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			e.Validator = validation.MustNew()
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/posts", nil)
+			if tt.acceptLanguage != "" {
+				req.Header.Set("Accept-Language", tt.acceptLanguage)
+			}
+			rec := httptest.NewRecorder()
+
+			handler(e.Validator.Validate(createRequest{Title: "too long", Slug: "Bad Slug"}), e.NewContext(req, rec))
+
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+			assert.Equal(t, tt.wantLanguage, rec.Header().Get("Content-Language"))
+
+			var problem response.Problem
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem))
+			assert.Equal(t, apperr.CodeValidation, problem.Code)
+			assert.Equal(t, tt.wantFields, problem.Errors)
+		})
+	}
+}
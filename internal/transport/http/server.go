@@ -5,33 +5,59 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
+	"premium_caste/internal/domain/apperr"
 	"premium_caste/internal/domain/models"
 	"premium_caste/internal/lib/logger/sl"
+	"premium_caste/internal/lib/pagination"
+	"premium_caste/internal/lib/principal"
+	"premium_caste/internal/storage"
 	"premium_caste/internal/transport/http/dto"
 	"premium_caste/internal/transport/http/dto/request"
 	"premium_caste/internal/transport/http/dto/response"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/labstack/echo-contrib/session"
 	"github.com/labstack/echo/v4"
 
 	_ "premium_caste/docs"
 )
 
 type UserService interface {
-	Login(ctx context.Context, email, password string) (*models.TokenPair, error)
+	Login(ctx context.Context, email, password string) (*models.LoginResult, error)
+	CompleteLogin(ctx context.Context, challengeID, code string) (*models.LoginResult, error)
+	StartLoginEnrollment(ctx context.Context, challengeID string) (*dto.TwoFactorEnrollmentResponse, error)
 	RegisterNewUser(ctx context.Context, input dto.UserRegisterInput) (uuid.UUID, error)
 	IsAdmin(ctx context.Context, userID uuid.UUID) (bool, error)
-	GetUserById(ctx context.Context, userID uuid.UUID) (models.User, error)
+	ListUsers(ctx context.Context, filter dto.UserFilter, page, perPage int) (*dto.UserListResponse, error)
+	GetUser(ctx context.Context, userID uuid.UUID) (*dto.UserResponse, error)
+	ChangeRole(ctx context.Context, actorID, userID uuid.UUID, role string) (*dto.UserResponse, error)
+	Suspend(ctx context.Context, actorID, userID uuid.UUID, reason string) (*dto.UserResponse, error)
+	Unsuspend(ctx context.Context, actorID, userID uuid.UUID) (*dto.UserResponse, error)
+	Anonymize(ctx context.Context, actorID, userID uuid.UUID) (*dto.UserResponse, error)
+	DeleteUser(ctx context.Context, actorID, userID uuid.UUID) error
+	UpdateProfile(ctx context.Context, userID uuid.UUID, req dto.UpdateProfileRequest) (*dto.UserResponse, error)
+	ConfirmEmail(ctx context.Context, userID uuid.UUID, code string) (*dto.UserResponse, error)
+	ChangePassword(ctx context.Context, userID uuid.UUID, req dto.ChangePasswordRequest) (*models.TokenPair, error)
+	TwoFactorStatus(ctx context.Context, userID uuid.UUID) (*dto.TwoFactorStatusResponse, error)
+	EnrollTwoFactor(ctx context.Context, userID uuid.UUID) (*dto.TwoFactorEnrollmentResponse, error)
+	ConfirmTwoFactor(ctx context.Context, userID uuid.UUID, code string) (*dto.RecoveryCodesResponse, error)
+	DisableTwoFactor(ctx context.Context, userID uuid.UUID, password string) error
+	RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) (*dto.RecoveryCodesResponse, error)
+	ResetTwoFactor(ctx context.Context, actorID, userID uuid.UUID) error
+	SecurityPolicy(ctx context.Context) (*models.SecurityPolicy, error)
+	UpdateSecurityPolicy(ctx context.Context, actorID uuid.UUID, req dto.UpdateSecurityPolicyRequest) (*models.SecurityPolicy, error)
 }
 
 type MediaService interface {
 	UploadMedia(ctx context.Context, input dto.MediaUploadInput) (*models.Media, error)
 	UploadMultipleMedia(ctx context.Context, inputs []dto.MediaUploadInput) ([]*models.Media, error)
+	UploadMediaGroup(ctx context.Context, inputs []dto.MediaUploadInput, description string) (*dto.MediaGroupUploadResponse, error)
 	AttachMediaToGroup(ctx context.Context, groupID uuid.UUID, mediaIDs []uuid.UUID) error
 	AttachMedia(ctx context.Context, ownerID uuid.UUID, description string) (uuid.UUID, error)
 	ListGroupMedia(ctx context.Context, groupID uuid.UUID) ([]models.Media, error)
@@ -42,18 +68,61 @@ type MediaService interface {
 type AuthService interface {
 	GenerateTokens(user models.User) (*models.TokenPair, error)
 	RefreshTokens(refreshToken string) (*models.TokenPair, error)
+	ParseAccessToken(accessToken string) (principal.Principal, error)
+	ParseRefreshToken(refreshToken string) (principal.Principal, error)
+	CSRFToken(sessionID string) string
+	ValidCSRFToken(sessionID, token string) bool
 }
 
 type BlogService interface {
 	CreatePost(ctx context.Context, req dto.CreateBlogPostRequest) (*dto.BlogPostResponse, error)
 	UpdatePost(ctx context.Context, postID uuid.UUID, req dto.UpdateBlogPostRequest) (*dto.BlogPostResponse, error)
 	GetPostByID(ctx context.Context, id uuid.UUID) (*dto.BlogPostResponse, error)
+	GetPostBySlug(ctx context.Context, slug string, includeUnpublished bool) (*dto.BlogPostResponse, string, error)
+	CheckSlugAvailability(ctx context.Context, slug string) (*dto.SlugAvailabilityResponse, error)
 	PublishPost(ctx context.Context, postID uuid.UUID) (*dto.BlogPostResponse, error)
 	ArchivePost(ctx context.Context, postID uuid.UUID) (*dto.BlogPostResponse, error)
 	DeletePost(ctx context.Context, postID uuid.UUID) error
 	AddMediaGroup(ctx context.Context, postID uuid.UUID, req dto.AddMediaGroupRequest) (*dto.PostMediaGroupsResponse, error)
-	ListPosts(ctx context.Context, statusFilter string, page, perPage int) (*dto.BlogPostListResponse, error)
+	ListPosts(ctx context.Context, filter dto.BlogPostFilter, page, perPage int) (*dto.BlogPostListResponse, error)
 	GetPostMediaGroups(ctx context.Context, postID uuid.UUID, relationType string) (*dto.PostMediaGroupsResponse, error)
+	GetTagCounts(ctx context.Context, statusFilter string, limit int) ([]dto.TagCountResponse, error)
+}
+
+type CategoryService interface {
+	CreateCategory(ctx context.Context, req dto.CreateCategoryRequest) (*dto.CategoryResponse, error)
+	UpdateCategory(ctx context.Context, id uuid.UUID, req dto.UpdateCategoryRequest) (*dto.CategoryResponse, error)
+	DeleteCategory(ctx context.Context, id uuid.UUID) error
+	GetCategoryByID(ctx context.Context, id uuid.UUID) (*dto.CategoryResponse, error)
+	ListCategories(ctx context.Context) ([]dto.CategoryResponse, error)
+}
+
+type CommentService interface {
+	CreateComment(ctx context.Context, postID, userID uuid.UUID, req dto.CreateCommentRequest) (*dto.CommentResponse, error)
+	ListPostComments(ctx context.Context, postID uuid.UUID) ([]dto.CommentResponse, error)
+	ListComments(ctx context.Context, filter dto.CommentFilter, page, perPage int) (*dto.CommentListResponse, error)
+	ModerateComment(ctx context.Context, id, moderatorID uuid.UUID, status string) (*dto.CommentResponse, error)
+	DeleteComment(ctx context.Context, id uuid.UUID) error
+}
+
+type FeedService interface {
+	RSS(ctx context.Context) ([]byte, error)
+	Atom(ctx context.Context) ([]byte, error)
+	SitemapIndex(ctx context.Context) ([]byte, error)
+	Sitemap(ctx context.Context, name string) ([]byte, error)
+	Invalidate(ctx context.Context) error
+}
+
+type TagService interface {
+	ListTags(ctx context.Context, status, prefix string, limit int) ([]dto.GalleryTagResponse, error)
+	RenameTag(ctx context.Context, req dto.RenameGalleryTagRequest) (*dto.GalleryTagOperationResponse, error)
+	MergeTags(ctx context.Context, req dto.MergeGalleryTagsRequest) (*dto.GalleryTagOperationResponse, error)
+	ListAliases(ctx context.Context) ([]dto.GalleryTagAliasResponse, error)
+	DeleteAlias(ctx context.Context, alias string) error
+}
+
+type ArchiveService interface {
+	StreamGalleryArchive(ctx context.Context, galleryID uuid.UUID, userKey, size string, start func(filename string) io.Writer) error
 }
 
 type GalleryService interface {
@@ -62,53 +131,102 @@ type GalleryService interface {
 	UpdateGalleryStatus(ctx context.Context, id uuid.UUID, status string) error
 	DeleteGallery(ctx context.Context, id uuid.UUID) error
 	GetGalleryByID(ctx context.Context, id uuid.UUID) (*dto.GalleryResponse, error)
-	GetGalleries(ctx context.Context, statusFilter string, page int, perPage int) ([]dto.GalleryResponse, int, error)
-	GetGalleriesByTags(ctx context.Context, tags []string, matchAll bool) ([]dto.GalleryResponse, error)
+	GetGalleryBySlug(ctx context.Context, slug string, includeUnpublished bool) (*dto.GalleryResponse, string, error)
+	CheckSlugAvailability(ctx context.Context, slug string) (*dto.SlugAvailabilityResponse, error)
+	ListGalleries(ctx context.Context, filter dto.GalleryFilter, page, perPage int) (*dto.GalleryListResponse, error)
 	AddTags(ctx context.Context, galleryID string, tags []string) error
 	RemoveTags(ctx context.Context, galleryID string, tagsToRemove []string) error
 	ReplaceTags(ctx context.Context, galleryID string, newTags []string) error
 	GetTags(ctx context.Context, galleryID string) ([]string, error)
 	HasTags(ctx context.Context, galleryID string, tags []string) (bool, error)
+	AddGalleryItems(ctx context.Context, galleryID uuid.UUID, req dto.AddGalleryItemsRequest) (*dto.GalleryResponse, error)
+	UpdateGalleryItem(ctx context.Context, galleryID, mediaID uuid.UUID, req dto.UpdateGalleryItemRequest) (*dto.GalleryResponse, error)
+	RemoveGalleryItem(ctx context.Context, galleryID, mediaID uuid.UUID) (*dto.GalleryResponse, error)
+	ReorderGalleryItems(ctx context.Context, galleryID uuid.UUID, req dto.ReorderGalleryItemsRequest) (*dto.GalleryResponse, error)
+	SetGalleryCover(ctx context.Context, galleryID uuid.UUID, req dto.SetGalleryCoverRequest) (*dto.GalleryResponse, error)
+}
+
+type AuditService interface {
+	ListEvents(ctx context.Context, filter dto.AuditEventFilter, page, perPage int) (*dto.AuditEventListResponse, error)
+}
+
+type OIDCService interface {
+	Providers() []string
+	BeginLogin(ctx context.Context, provider string) (*dto.OIDCStartResponse, error)
+	FinishLogin(ctx context.Context, provider, code, state string) (*models.LoginResult, error)
+	BeginLink(ctx context.Context, userID uuid.UUID, provider string) (*dto.OIDCStartResponse, error)
+	FinishLink(ctx context.Context, userID uuid.UUID, provider, code, state string) (*dto.IdentityResponse, error)
+	ListIdentities(ctx context.Context, userID uuid.UUID) ([]dto.IdentityResponse, error)
+	UnlinkIdentity(ctx context.Context, userID uuid.UUID, provider string) error
+}
+
+type APIKeyService interface {
+	CreateKey(ctx context.Context, userID uuid.UUID, req dto.CreateAPIKeyRequest) (*dto.APIKeyCreatedResponse, error)
+	ListKeys(ctx context.Context, ownerID *uuid.UUID) ([]dto.APIKeyResponse, error)
+	RevokeKey(ctx context.Context, keyID uuid.UUID, ownerID *uuid.UUID) error
+	Authenticate(ctx context.Context, rawKey string) (principal.Principal, error)
 }
 
 type Routers struct {
-	log            *slog.Logger
-	UserService    UserService
-	MediaService   MediaService
-	AuthService    AuthService
-	BlogService    BlogService
-	GalleryService GalleryService
+	log             *slog.Logger
+	UserService     UserService
+	MediaService    MediaService
+	AuthService     AuthService
+	BlogService     BlogService
+	CategoryService CategoryService
+	CommentService  CommentService
+	GalleryService  GalleryService
+	TagService      TagService
+	FeedService     FeedService
+	ArchiveService  ArchiveService
+	AuditService    AuditService
+	OIDCService     OIDCService
+	APIKeyService   APIKeyService
+	cookies         CookieConfig
 }
 
-func NewRouter(log *slog.Logger, userService UserService, mediaService MediaService, authService AuthService, blogService BlogService, galleryService GalleryService) *Routers {
+// CSRFHeader заголовок, в котором SPA передает токен из /api/v1/csrf-token
+const CSRFHeader = "X-CSRF-Token"
+
+// CookieConfig параметры cookie с токенами
+type CookieConfig struct {
+	Secure   bool
+	SameSite http.SameSite
+	Domain   string
+}
+
+func NewRouter(log *slog.Logger, userService UserService, mediaService MediaService, authService AuthService, blogService BlogService, categoryService CategoryService, commentService CommentService, galleryService GalleryService, feedService FeedService, tagService TagService, archiveService ArchiveService, auditService AuditService, oidcService OIDCService, apiKeyService APIKeyService, cookies CookieConfig) *Routers {
 	return &Routers{
-		log:            log,
-		UserService:    userService,
-		MediaService:   mediaService,
-		AuthService:    authService,
-		BlogService:    blogService,
-		GalleryService: galleryService,
+		log:             log,
+		UserService:     userService,
+		MediaService:    mediaService,
+		AuthService:     authService,
+		BlogService:     blogService,
+		CategoryService: categoryService,
+		CommentService:  commentService,
+		GalleryService:  galleryService,
+		FeedService:     feedService,
+		TagService:      tagService,
+		ArchiveService:  archiveService,
+		AuditService:    auditService,
+		OIDCService:     oidcService,
+		APIKeyService:   apiKeyService,
+		cookies:         cookies,
 	}
 }
 
-var (
-	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrUserExist          = errors.New("user already exist")
-	ErrUserNotFound       = errors.New("user not found")
-	ErrInvalidUUID        = errors.New("not valid UUID")
-	ErrGalleryNotFound    = errors.New("gallery not founc")
-)
-
 // Login godoc
 // @Summary Аутентификация пользователя
 // @Description Вход в систему по email и паролю. Возвращает JWT-токен.
+// @Description Если у пользователя включена двухфакторная аутентификация или политика безопасности требует ее,
+// @Description токены не выдаются: в ответе two_factor_required и challenge_id для POST /api/v1/login/2fa
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param request body request.LoginRequest true "Данные для входа"
-// @Success 200 {object} response.Response{data=map[string]string} "Успешный вход (токен)"
-// @Failure 400 {object} response.ErrorResponse "Неверный формат запроса"
-// @Failure 401 {object} response.ErrorResponse "Ошибка аутентификации"
+// @Success 200 {object} response.Response{data=map[string]string} "Успешный вход (токен) или запрос второго фактора"
+// @Failure 400 {object} response.Problem "Неверный формат запроса"
+// @Failure 401 {object} response.Problem "Ошибка аутентификации"
 // @Router /api/v1/login [post]
 func (r *Routers) Login(c echo.Context) error {
 	const op = "http.routers.Login"
@@ -119,57 +237,125 @@ func (r *Routers) Login(c echo.Context) error {
 
 	var req request.LoginRequest
 
-	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, response.ErrInvalidRequestFormat)
+	if err := bindRequest(c, &req); err != nil {
+		log.Warn("invalid format request", slog.String("identifier", req.Identifier))
+		return err
 	}
 
-	if err := c.Validate(req); err != nil {
-		response.ErrInvalidRequestFormat.Details = err.Error()
-		log.Warn("invalid format request", slog.String("identifier", req.Identifier))
-		return c.JSON(http.StatusBadRequest, response.ErrInvalidRequestFormat)
+	result, err := r.UserService.Login(c.Request().Context(), req.Identifier, req.Password)
+	if err != nil {
+		return err
+	}
+
+	return r.loginResponse(c, result)
+}
+
+// LoginTwoFactor godoc
+// @Summary Второй шаг входа
+// @Description Завершает вход кодом из приложения-аутентификатора или кодом восстановления.
+// @Description Если вход требовал настройки 2FA, код подтверждает ее и в ответе возвращаются коды восстановления
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body dto.LoginTwoFactorRequest true "Идентификатор входа и код"
+// @Success 200 {object} response.Response{data=map[string]string} "Успешный вход (токен)"
+// @Failure 400 {object} response.Problem "Неверный код"
+// @Failure 401 {object} response.Problem "Вход истек или превышено число попыток"
+// @Router /api/v1/login/2fa [post]
+func (r *Routers) LoginTwoFactor(c echo.Context) error {
+	const op = "http.routers.LoginTwoFactor"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	var req dto.LoginTwoFactorRequest
+	if err := bindRequest(c, &req); err != nil {
+		log.Warn("invalid request data", sl.Err(err))
+		return err
 	}
 
-	token, err := r.UserService.Login(c.Request().Context(), req.Identifier, req.Password)
+	result, err := r.UserService.CompleteLogin(c.Request().Context(), req.ChallengeID, req.Code)
 	if err != nil {
-		response.ErrAuthenticationFailed.Details = err.Error()
-		return c.JSON(http.StatusUnauthorized, response.ErrAuthenticationFailed)
+		return err
 	}
 
-	sess, _ := session.Get("session", c)
-	sess.Values["user_id"] = token.UserID
-	sess.Save(c.Request(), c.Response())
+	return r.loginSucceeded(c, result)
+}
 
-	http.SetCookie(c.Response().Writer, &http.Cookie{
-		Name:     "access_token",
-		Value:    token.AccessToken,
-		Path:     "/",
-		HttpOnly: true,
-		Secure:   false,
-		SameSite: http.SameSiteStrictMode,
-		Expires:  time.Now().Add(7 * 24 * time.Hour),
-	})
+// LoginTwoFactorEnroll godoc
+// @Summary Настройка 2FA при входе
+// @Description Создает секрет TOTP для пользователя, от которого политика безопасности требует 2FA.
+// @Description Настройка подтверждается кодом в POST /api/v1/login/2fa
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body dto.LoginChallengeRequest true "Идентификатор входа"
+// @Success 200 {object} dto.TwoFactorEnrollmentResponse
+// @Failure 401 {object} response.Problem "Вход истек"
+// @Failure 409 {object} response.Problem "2FA уже настроена"
+// @Router /api/v1/login/2fa/enroll [post]
+func (r *Routers) LoginTwoFactorEnroll(c echo.Context) error {
+	const op = "http.routers.LoginTwoFactorEnroll"
 
-	http.SetCookie(c.Response().Writer, &http.Cookie{
-		Name:     "refresh_token",
-		Value:    token.RefreshToken,
-		Path:     "/api/v1/refresh",
-		HttpOnly: true,
-		Secure:   false,
-		SameSite: http.SameSiteStrictMode,
-		Expires:  time.Now().Add(7 * 24 * time.Hour),
-	})
+	log := r.log.With(
+		slog.String("op", op),
+	)
 
-	return c.JSON(http.StatusOK, response.Response{
-		Status: "success",
-		Data: map[string]interface{}{
-			"user_id":       token.UserID.String(),
-			"access_token":  token.AccessToken,
-			"refresh_token": token.RefreshToken,
-			"session": map[string]interface{}{
-				"expires_in": 86400 * 7,
-				"expires_at": time.Now().Add(86400 * 7 * time.Second).Format(time.RFC3339),
+	var req dto.LoginChallengeRequest
+	if err := bindRequest(c, &req); err != nil {
+		log.Warn("invalid request data", sl.Err(err))
+		return err
+	}
+
+	enrollment, err := r.UserService.StartLoginEnrollment(c.Request().Context(), req.ChallengeID)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, enrollment)
+}
+
+// loginResponse отвечает на шаг входа: токенами или запросом второго фактора
+func (r *Routers) loginResponse(c echo.Context, result *models.LoginResult) error {
+	if result.Challenge != nil {
+		return c.JSON(http.StatusOK, response.Response{
+			Status: "success",
+			Data: map[string]interface{}{
+				"two_factor_required": true,
+				"challenge_id":        result.Challenge.ID,
+				"enrollment_required": result.Challenge.Enrollment,
+				"expires_at":          result.Challenge.ExpiresAt.Format(time.RFC3339),
 			},
+		})
+	}
+
+	return r.loginSucceeded(c, result)
+}
+
+// loginSucceeded открывает сеанс и отвечает токенами
+func (r *Routers) loginSucceeded(c echo.Context, result *models.LoginResult) error {
+	token := result.Tokens
+
+	r.setAuthCookies(c, token)
+
+	data := map[string]interface{}{
+		"user_id":       token.UserID.String(),
+		"access_token":  token.AccessToken,
+		"refresh_token": token.RefreshToken,
+		"csrf_token":    token.CSRFToken,
+		"session": map[string]interface{}{
+			"expires_in": 86400 * 7,
+			"expires_at": time.Now().Add(86400 * 7 * time.Second).Format(time.RFC3339),
 		},
+	}
+	if result.RecoveryCodes != nil {
+		data["recovery_codes"] = result.RecoveryCodes
+	}
+
+	return c.JSON(http.StatusOK, response.Response{
+		Status: "success",
+		Data:   data,
 	})
 }
 
@@ -181,9 +367,9 @@ func (r *Routers) Login(c echo.Context) error {
 // @Produce json
 // @Param request body dto.UserRegisterInput true "Данные для регистрации"
 // @Success 201 {object} response.Response{data=object{user_id=string}} "Успешная регистрация"
-// @Failure 400 {object} response.ErrorResponse "Неверный формат запроса"
-// @Failure 409 {object} response.ErrorResponse "Пользователь уже существует"
-// @Failure 500 {object} response.ErrorResponse "Внутренняя ошибка сервера"
+// @Failure 400 {object} response.Problem "Неверный формат запроса"
+// @Failure 409 {object} response.Problem "Пользователь уже существует"
+// @Failure 500 {object} response.Problem "Внутренняя ошибка сервера"
 // @Router /api/v1/register [post]
 func (r *Routers) Register(c echo.Context) error {
 	const op = "http.routers.Register"
@@ -194,30 +380,17 @@ func (r *Routers) Register(c echo.Context) error {
 
 	var req dto.UserRegisterInput
 
-	if err := c.Bind(&req); err != nil {
-		r.log.Error("failed to bind request", sl.Err(err))
-		return c.JSON(http.StatusBadRequest, response.ErrInvalidRegisterRequest)
-	}
-
-	if err := c.Validate(req); err != nil {
-		response.ErrInvalidRegisterRequest.Details = err.Error()
-		r.log.Error("validation failed", sl.Err(err))
-		return c.JSON(http.StatusBadRequest, response.ErrInvalidRegisterRequest)
+	if err := bindRequest(c, &req); err != nil {
+		log.Warn("invalid register request", sl.Err(err))
+		return err
 	}
 
 	userID, err := r.UserService.RegisterNewUser(c.Request().Context(), req)
 	if err != nil {
-		if errors.Is(err, ErrUserExist) {
+		if errors.Is(err, storage.ErrUserExists) {
 			log.Warn("user already exists", slog.String("email", req.Email))
-			return c.JSON(http.StatusConflict, response.ErrUserAlreadyExists)
 		}
-
-		log.Error("registration failed", sl.Err(err))
-		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
-			Status:  "error",
-			Error:   "internal_error",
-			Details: "Internal server error",
-		})
+		return err
 	}
 
 	log.Info("user registered successfully", slog.String("user_id", userID.String()))
@@ -243,36 +416,83 @@ func (r *Routers) Refresh(c echo.Context) error {
 
 	if err := c.Bind(&req); err != nil {
 		log.Error("validation bind", sl.Err(err))
-		return echo.NewHTTPError(http.StatusBadRequest, "invalid request")
+		return errInvalidBody.Wrap(err)
+	}
+
+	// Cookie браузер подставляет сам, поэтому обновление по ней требует токен CSRF сеанса
+	if req.RefreshToken == "" {
+		cookie, err := c.Cookie("refresh_token")
+		if err != nil || cookie.Value == "" {
+			return errAuthRequired
+		}
+
+		p, err := r.AuthService.ParseRefreshToken(cookie.Value)
+		if err != nil {
+			log.Warn("invalid refresh cookie", sl.Err(err))
+			return err
+		}
+		if !r.AuthService.ValidCSRFToken(p.SessionID, c.Request().Header.Get(CSRFHeader)) {
+			log.Warn("refresh without csrf token", slog.String("user_id", p.UserID.String()))
+			return errCSRFToken
+		}
+
+		req.RefreshToken = cookie.Value
 	}
 
 	newTokens, err := r.AuthService.RefreshTokens(req.RefreshToken)
 	if err != nil {
-		log.Error("error refresh tokens", sl.Err(err))
-		return echo.NewHTTPError(http.StatusUnauthorized, "invalid refresh token")
+		log.Warn("error refresh tokens", sl.Err(err))
+		return err
 	}
 
+	r.setAuthCookies(c, newTokens)
+
+	return c.JSON(http.StatusOK, newTokens)
+}
+
+// setAuthCookies сохраняет пару токенов в HttpOnly cookie
+func (r *Routers) setAuthCookies(c echo.Context, token *models.TokenPair) {
 	http.SetCookie(c.Response().Writer, &http.Cookie{
 		Name:     "access_token",
-		Value:    newTokens.AccessToken,
+		Value:    token.AccessToken,
 		Path:     "/",
+		Domain:   r.cookies.Domain,
 		HttpOnly: true,
-		Secure:   false,
-		SameSite: http.SameSiteStrictMode,
+		Secure:   r.cookies.Secure,
+		SameSite: r.cookies.SameSite,
 		Expires:  time.Now().Add(7 * 24 * time.Hour),
 	})
 
 	http.SetCookie(c.Response().Writer, &http.Cookie{
 		Name:     "refresh_token",
-		Value:    newTokens.RefreshToken,
+		Value:    token.RefreshToken,
 		Path:     "/api/v1/refresh",
+		Domain:   r.cookies.Domain,
 		HttpOnly: true,
-		Secure:   false,
-		SameSite: http.SameSiteStrictMode,
+		Secure:   r.cookies.Secure,
+		SameSite: r.cookies.SameSite,
 		Expires:  time.Now().Add(7 * 24 * time.Hour),
 	})
+}
 
-	return c.JSON(http.StatusOK, newTokens)
+// GetCSRFToken godoc
+// @Summary Токен CSRF
+// @Description Токен текущего сеанса для заголовка X-CSRF-Token. Он обязателен в запросах POST, PUT,
+// @Description PATCH и DELETE, авторизованных cookie, и в POST /api/v1/refresh с refresh-токеном из cookie.
+// @Description С заголовком Authorization: Bearer токен не нужен. Тот же токен возвращается в поле csrf_token
+// @Description при входе, подтверждении 2FA, входе через провайдера, обновлении токенов и смене пароля
+// @Tags users
+// @Produce json
+// @Success 200 {object} dto.CSRFTokenResponse
+// @Failure 401 {object} response.Problem
+// @Router /api/v1/csrf-token [get]
+func (r *Routers) GetCSRFToken(c echo.Context) error {
+	p, ok := principal.FromContext(c.Request().Context())
+	if !ok {
+		return errAuthRequired
+	}
+
+	return c.JSON(http.StatusOK, dto.CSRFTokenResponse{CSRFToken: r.AuthService.CSRFToken(p.SessionID)})
 }
 
 // IsAdminPermission
@@ -283,8 +503,8 @@ func (r *Routers) Refresh(c echo.Context) error {
 // @Produce  json
 // @Param user_id path string true "UUID пользователя" format(uuid)
 // @Success 200 {object} map[string]bool "Результат проверки" example({"is_admin": true})
-// @Failure 400 {object} map[string]string "Невалидный UUID" example({"error": "invalid user ID format"})
-// @Failure 500 {object} map[string]string "Ошибка сервера" example({"error": "failed to check admin status"})
+// @Failure 400 {object} response.Problem "Невалидный UUID"
+// @Failure 500 {object} response.Problem "Ошибка сервера"
 // @Security ApiKeyAuth
 // @Router /api/v1/users/{user_id}/is-admin [get]
 func (r *Routers) IsAdminPermission(c echo.Context) error {
@@ -294,14 +514,10 @@ func (r *Routers) IsAdminPermission(c echo.Context) error {
 		slog.String("op", op),
 	)
 
-	userIDStr := c.Param("user_id")
-
-	userID, err := uuid.Parse(userIDStr)
+	userID, err := uuidParam(c, "user_id")
 	if err != nil {
 		log.Error("error parse uuid", sl.Err(err))
-		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
-			Error: "invalid user ID format",
-		})
+		return err
 	}
 
 	isAdmin, err := r.UserService.IsAdmin(c.Request().Context(), userID)
@@ -310,15 +526,7 @@ func (r *Routers) IsAdminPermission(c echo.Context) error {
 			slog.String("error", err.Error()),
 			slog.Any("user_id", userID),
 		)
-		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
-			Error: "failed to check admin status",
-		})
-	}
-
-	sess, _ := session.Get("session", c)
-	sess.Values["user_id"] = userID.String()
-	if err := sess.Save(c.Request(), c.Response()); err != nil {
-		return c.JSON(http.StatusInternalServerError, "failed to save session")
+		return fmt.Errorf("failed to check admin status: %w", err)
 	}
 
 	return c.JSON(http.StatusOK, map[string]bool{
@@ -328,17 +536,18 @@ func (r *Routers) IsAdminPermission(c echo.Context) error {
 
 // GetUserById godoc
 // @Summary Получение информации о пользователе
-// @Description Возвращает полную информацию о пользователе по его UUID
+// @Description Возвращает профиль пользователя по его UUID. Чужие профили доступны только администраторам, свой профиль удобнее получать через /api/v1/me
 // @Tags Пользователи
 // @Accept json
 // @Produce json
 // @Param user_id body string true "UUID пользователя" format(uuid) example("a8a8a8a8-a8a8-a8a8-a8a8-a8a8a8a8a8a8")
-// @Success 200 {object} models.User "Успешно полученные данные пользователя"
-// @Failure 400 {object} response.ErrorResponse "Некорректный UUID пользователя"
-// @Failure 404 {object} response.ErrorResponse "Пользователь не найден"
-// @Failure 500 {object} response.ErrorResponse "Внутренняя ошибка сервера"
+// @Success 200 {object} dto.UserResponse "Успешно полученные данные пользователя"
+// @Failure 400 {object} response.Problem "Некорректный UUID пользователя"
+// @Failure 403 {object} response.Problem "Нет доступа к чужому профилю"
+// @Failure 404 {object} response.Problem "Пользователь не найден"
+// @Failure 500 {object} response.Problem "Внутренняя ошибка сервера"
 // @Security ApiKeyAuth
-// @Router /api/v1/users/users_id [post]
+// @Router /api/v1/users/user_id [post]
 func (r *Routers) GetUserById(c echo.Context) error {
 	const op = "http.routers.GetUserById"
 
@@ -346,887 +555,2700 @@ func (r *Routers) GetUserById(c echo.Context) error {
 		slog.String("op", op),
 	)
 
+	requesterID, ok := currentUserID(c)
+	if !ok {
+		return errAuthRequired
+	}
+
 	var req struct {
 		UserID uuid.UUID `json:"user_id"`
 	}
 
 	if err := c.Bind(&req); err != nil {
 		log.Error("validation bind", sl.Err(err))
-		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
-			Error: "validation bind",
-		})
+		return errInvalidBody.Wrap(err)
+	}
+
+	if req.UserID != requesterID {
+		isAdmin, err := r.UserService.IsAdmin(c.Request().Context(), requesterID)
+		if err != nil {
+			log.Error("error check admin permission", sl.Err(err))
+			return err
+		}
+		if !isAdmin {
+			return errForbidden
+		}
 	}
 
-	user, err := r.UserService.GetUserById(c.Request().Context(), req.UserID)
+	user, err := r.UserService.GetUser(c.Request().Context(), req.UserID)
 	if err != nil {
 		log.Error("error get user", sl.Err(err))
-		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
-			Error: "failed get user",
-		})
-
+		return err
 	}
 
 	return c.JSON(http.StatusOK, user)
 }
 
-// UploadMedia godoc
-// @Summary Загрузка медиафайла
-// @Description Загружает файл на сервер с возможностью указания метаданных
-// @Tags Медиа
-// @Accept multipart/form-data
+// GetMe godoc
+// @Summary Свой профиль
+// @Description Возвращает профиль текущего пользователя
+// @Tags Профиль
 // @Produce json
-// @Param file formData file true "Файл для загрузки (макс. 10MB)"
-// @Param uploader_id formData string true "UUID пользователя-загрузчика" format(uuid)
-// @Param media_type formData string true "Тип контента" Enums(photo, video, audio, document)
-// @Param is_public formData boolean false "Публичный доступ (по умолчанию false)"
-// @Param metadata formData string false "Дополнительные метаданные в JSON-формате"
-// @Param width formData integer false "Ширина в пикселях (для изображений/видео)"
-// @Param height formData integer false "Высота в пикселях (для изображений/видео)"
-// @Param duration formData integer false "Длительность в секундах (для видео/аудио)"
-// @Success 201 {object} models.Media "Успешно загруженный медиаобъект"
-// @Failure 400 {object} response.ErrorResponse "Некорректные входные данные"
-// @Failure 413 {object} response.ErrorResponse "Превышен максимальный размер файла"
-// @Failure 415 {object} response.ErrorResponse "Неподдерживаемый тип файла"
-// @Failure 500 {object} response.ErrorResponse "Внутренняя ошибка сервера"
+// @Success 200 {object} dto.UserResponse
+// @Failure 401 {object} response.Problem
 // @Security ApiKeyAuth
-// @Router /api/v1/media/upload [post]
-func (r *Routers) UploadMedia(c echo.Context) error {
-	const op = "http.routers.UploadMedia"
+// @Router /api/v1/me [get]
+func (r *Routers) GetMe(c echo.Context) error {
+	const op = "http.routers.GetMe"
 
 	log := r.log.With(
 		slog.String("op", op),
 	)
 
-	startTime := time.Now()
-	defer func() {
-		log.Info("Request completed",
-			"duration", time.Since(startTime))
-	}()
-
-	log.Info("Start uploading media",
-		"method", c.Request().Method,
-		"path", c.Path(),
-		"client_ip", c.RealIP())
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errAuthRequired
+	}
 
-	file, err := c.FormFile("file")
+	user, err := r.UserService.GetUser(c.Request().Context(), userID)
 	if err != nil {
-		log.Warn("Empty file in request",
-			"error", err.Error())
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "File is required"})
+		log.Error("failed get profile", sl.Err(err))
+		return err
 	}
 
-	log.Debug("Got file for upload",
-		"filename", file.Filename,
-		"size", file.Size,
-		"mime_type", file.Header.Get("Content-Type"))
+	return c.JSON(http.StatusOK, user)
+}
 
-	input, err := r.parseMediaUploadInput(c)
-	if err != nil {
-		log.Warn("Error parsing data",
-			"error", err.Error(),
-			"uploader_id", c.FormValue("uploader_id"))
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+// UpdateMe godoc
+// @Summary Изменить свой профиль
+// @Description Меняет имя и телефон. Новый email сохраняется как pending_email и применяется после подтверждения кодом, отправленным на этот адрес
+// @Tags Профиль
+// @Accept json
+// @Produce json
+// @Param request body dto.UpdateProfileRequest true "Изменяемые поля"
+// @Success 200 {object} dto.UserResponse
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 409 {object} response.Problem "Email или телефон уже заняты"
+// @Security ApiKeyAuth
+// @Router /api/v1/me [patch]
+func (r *Routers) UpdateMe(c echo.Context) error {
+	const op = "http.routers.UpdateMe"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errAuthRequired
 	}
-	input.File = file
 
-	log.Debug("Options for upload",
-		"uploader_id", input.UploaderID,
-		"media_type", input.MediaType,
-		"is_public", input.IsPublic,
-		"metadata", input.CustomMetadata)
+	var req dto.UpdateProfileRequest
+	if err := bindRequest(c, &req); err != nil {
+		log.Error("invalid request data", sl.Err(err))
+		return err
+	}
 
-	media, err := r.MediaService.UploadMedia(c.Request().Context(), *input)
+	user, err := r.UserService.UpdateProfile(c.Request().Context(), userID, req)
 	if err != nil {
-		log.Error("Error upload media",
-			"error", err.Error(),
-			"uploader_id", input.UploaderID,
-			"filename", file.Filename)
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		log.Error("failed update profile", sl.Err(err))
+		return err
 	}
 
-	log.Info("Upload successfull",
-		"media_id", media.ID,
-		"uploader_id", media.UploaderID,
-		"file_size", media.FileSize,
-		"duration", time.Since(startTime))
-
-	return c.JSON(http.StatusCreated, media)
+	return c.JSON(http.StatusOK, user)
 }
 
-// UploadMultipleMedia обрабатывает запрос на загрузку множества медиафайлов
-// UploadMultipleMedia godoc
-// @Summary Загрузка множества медиафайлов
-// @Description Загружает несколько медиафайлов на сервер. Поддерживает передачу файлов и общих параметров загрузки.
-// @Tags Медиа
-// @Accept multipart/form-data
+// ConfirmMyEmail godoc
+// @Summary Подтвердить новый email
+// @Description Применяет email из pending_email по коду из письма. Код действует 24 часа
+// @Tags Профиль
+// @Accept json
 // @Produce json
-// @Param files formData file true "Файлы для загрузки (поддерживается множественная загрузка)"
-// @Param uploader_id formData string true "ID пользователя, загружающего файлы"
-// @Param media_type formData string true "Тип медиа (например, image, video)"
-// @Param is_public formData boolean true "Флаг публичности файла"
-// @Param metadata formData string false "Дополнительные метаданные (опционально)"
-// @Success 201 {array} models.Media "Успешная загрузка, возвращает массив созданных медиа-объектов"
-// @Failure 400 {object} map[string]string "Ошибка валидации входных данных"
-// @Failure 500 {object} map[string]string "Ошибка сервера при загрузке файлов"
-// @Router /api/v1/media/multiple [post]
-func (r *Routers) UploadMultipleMedia(c echo.Context) error {
-	const op = "http.routers.UploadMultipleMedia"
+// @Param request body dto.ConfirmEmailRequest true "Код подтверждения"
+// @Success 200 {object} dto.UserResponse
+// @Failure 400 {object} response.Problem "Код неверный или истек"
+// @Failure 401 {object} response.Problem
+// @Failure 409 {object} response.Problem "Email уже занят"
+// @Security ApiKeyAuth
+// @Router /api/v1/me/email/confirm [post]
+func (r *Routers) ConfirmMyEmail(c echo.Context) error {
+	const op = "http.routers.ConfirmMyEmail"
 
 	log := r.log.With(
 		slog.String("op", op),
 	)
 
-	startTime := time.Now()
-	defer func() {
-		log.Info("Request completed",
-			"duration", time.Since(startTime))
-	}()
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errAuthRequired
+	}
 
-	log.Info("Start uploading multiple media",
-		"method", c.Request().Method,
-		"path", c.Path(),
-		"client_ip", c.RealIP())
+	var req dto.ConfirmEmailRequest
+	if err := bindRequest(c, &req); err != nil {
+		log.Error("invalid request data", sl.Err(err))
+		return err
+	}
 
-	// Получаем файлы из запроса
-	form, err := c.MultipartForm()
+	user, err := r.UserService.ConfirmEmail(c.Request().Context(), userID, req.Code)
 	if err != nil {
-		log.Warn("Failed to parse multipart form",
-			"error", err.Error())
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "Invalid form data"})
+		log.Error("failed confirm email", sl.Err(err))
+		return err
 	}
 
-	files := form.File["files"]
-	if len(files) == 0 {
-		log.Warn("No files in request")
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "At least one file is required"})
-	}
+	return c.JSON(http.StatusOK, user)
+}
 
-	log.Debug("Got files for upload",
-		"count", len(files))
+// ChangeMyPassword godoc
+// @Summary Сменить пароль
+// @Description Меняет пароль после проверки текущего. Все сеансы пользователя завершаются, для текущего выдаются новые токены
+// @Tags Профиль
+// @Accept json
+// @Produce json
+// @Param request body dto.ChangePasswordRequest true "Текущий и новый пароль"
+// @Success 200 {object} models.TokenPair
+// @Failure 400 {object} response.Problem "Текущий пароль неверный"
+// @Failure 401 {object} response.Problem
+// @Security ApiKeyAuth
+// @Router /api/v1/me/password [post]
+func (r *Routers) ChangeMyPassword(c echo.Context) error {
+	const op = "http.routers.ChangeMyPassword"
 
-	// Парсим общие параметры загрузки
-	baseInput, err := r.parseMediaUploadInput(c)
-	if err != nil {
-		log.Warn("Error parsing base data",
-			"error", err.Error(),
-			"uploader_id", c.FormValue("uploader_id"))
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errAuthRequired
 	}
 
-	// Подготавливаем входные данные для каждого файла
-	inputs := make([]dto.MediaUploadInput, 0, len(files))
-	for _, file := range files {
-		input := *baseInput
-		input.File = file
-		inputs = append(inputs, input)
+	var req dto.ChangePasswordRequest
+	if err := bindRequest(c, &req); err != nil {
+		log.Error("invalid request data", sl.Err(err))
+		return err
 	}
 
-	// Выполняем загрузку через сервисный слой
-	medias, err := r.MediaService.UploadMultipleMedia(c.Request().Context(), inputs)
+	token, err := r.UserService.ChangePassword(c.Request().Context(), userID, req)
 	if err != nil {
-		log.Error("Error uploading multiple media",
-			"error", err.Error(),
-			"uploader_id", baseInput.UploaderID,
-			"file_count", len(files))
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		log.Error("failed change password", sl.Err(err))
+		return err
 	}
 
-	log.Info("Upload successful",
-		"media_count", len(medias),
-		"uploader_id", baseInput.UploaderID,
-		"duration", time.Since(startTime))
+	r.setAuthCookies(c, token)
 
-	return c.JSON(http.StatusCreated, medias)
+	return c.JSON(http.StatusOK, token)
 }
 
-// AttachMediaToGroup godoc
-// @Summary Прикрепить медиа к группе
-// @Description Связывает один или несколько медиафайлов с существующей медиагруппой
+// GetMyTwoFactor godoc
+// @Summary Состояние 2FA
+// @Description Включена ли двухфакторная аутентификация, требует ли ее политика и сколько осталось кодов восстановления
+// @Tags Профиль
+// @Produce json
+// @Success 200 {object} dto.TwoFactorStatusResponse
+// @Failure 401 {object} response.Problem
+// @Security ApiKeyAuth
+// @Router /api/v1/me/2fa [get]
+func (r *Routers) GetMyTwoFactor(c echo.Context) error {
+	const op = "http.routers.GetMyTwoFactor"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errAuthRequired
+	}
+
+	status, err := r.UserService.TwoFactorStatus(c.Request().Context(), userID)
+	if err != nil {
+		log.Error("failed get two-factor status", sl.Err(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, status)
+}
+
+// EnrollMyTwoFactor godoc
+// @Summary Начать настройку 2FA
+// @Description Создает секрет TOTP. 2FA включается после подтверждения кодом из приложения
+// @Tags Профиль
+// @Produce json
+// @Success 200 {object} dto.TwoFactorEnrollmentResponse
+// @Failure 401 {object} response.Problem
+// @Failure 409 {object} response.Problem "2FA уже включена"
+// @Security ApiKeyAuth
+// @Router /api/v1/me/2fa/enroll [post]
+func (r *Routers) EnrollMyTwoFactor(c echo.Context) error {
+	const op = "http.routers.EnrollMyTwoFactor"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errAuthRequired
+	}
+
+	enrollment, err := r.UserService.EnrollTwoFactor(c.Request().Context(), userID)
+	if err != nil {
+		log.Error("failed start two-factor enrollment", sl.Err(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, enrollment)
+}
+
+// ConfirmMyTwoFactor godoc
+// @Summary Включить 2FA
+// @Description Подтверждает настройку кодом из приложения и возвращает коды восстановления. Они показываются один раз
+// @Tags Профиль
+// @Accept json
+// @Produce json
+// @Param request body dto.TwoFactorCodeRequest true "Код из приложения"
+// @Success 200 {object} dto.RecoveryCodesResponse
+// @Failure 400 {object} response.Problem "Неверный код"
+// @Failure 401 {object} response.Problem
+// @Failure 409 {object} response.Problem
+// @Security ApiKeyAuth
+// @Router /api/v1/me/2fa/confirm [post]
+func (r *Routers) ConfirmMyTwoFactor(c echo.Context) error {
+	const op = "http.routers.ConfirmMyTwoFactor"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errAuthRequired
+	}
+
+	var req dto.TwoFactorCodeRequest
+	if err := bindRequest(c, &req); err != nil {
+		log.Error("invalid request data", sl.Err(err))
+		return err
+	}
+
+	codes, err := r.UserService.ConfirmTwoFactor(c.Request().Context(), userID, req.Code)
+	if err != nil {
+		log.Error("failed confirm two-factor", sl.Err(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, codes)
+}
+
+// DisableMyTwoFactor godoc
+// @Summary Выключить 2FA
+// @Description Выключает двухфакторную аутентификацию после проверки пароля. Недоступно, если политика требует 2FA для роли
+// @Tags Профиль
+// @Accept json
+// @Param request body dto.DisableTwoFactorRequest true "Текущий пароль"
+// @Success 204
+// @Failure 400 {object} response.Problem "Пароль неверный"
+// @Failure 401 {object} response.Problem
+// @Failure 403 {object} response.Problem "2FA обязательна"
+// @Security ApiKeyAuth
+// @Router /api/v1/me/2fa [delete]
+func (r *Routers) DisableMyTwoFactor(c echo.Context) error {
+	const op = "http.routers.DisableMyTwoFactor"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errAuthRequired
+	}
+
+	var req dto.DisableTwoFactorRequest
+	if err := bindRequest(c, &req); err != nil {
+		log.Error("invalid request data", sl.Err(err))
+		return err
+	}
+
+	if err := r.UserService.DisableTwoFactor(c.Request().Context(), userID, req.CurrentPassword); err != nil {
+		log.Error("failed disable two-factor", sl.Err(err))
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// RegenerateMyRecoveryCodes godoc
+// @Summary Новые коды восстановления
+// @Description Заменяет коды восстановления новыми, старые перестают действовать
+// @Tags Профиль
+// @Accept json
+// @Produce json
+// @Param request body dto.TwoFactorCodeRequest true "Код из приложения"
+// @Success 200 {object} dto.RecoveryCodesResponse
+// @Failure 400 {object} response.Problem "Неверный код"
+// @Failure 401 {object} response.Problem
+// @Failure 409 {object} response.Problem "2FA не включена"
+// @Security ApiKeyAuth
+// @Router /api/v1/me/2fa/recovery-codes [post]
+func (r *Routers) RegenerateMyRecoveryCodes(c echo.Context) error {
+	const op = "http.routers.RegenerateMyRecoveryCodes"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errAuthRequired
+	}
+
+	var req dto.TwoFactorCodeRequest
+	if err := bindRequest(c, &req); err != nil {
+		log.Error("invalid request data", sl.Err(err))
+		return err
+	}
+
+	codes, err := r.UserService.RegenerateRecoveryCodes(c.Request().Context(), userID, req.Code)
+	if err != nil {
+		log.Error("failed regenerate recovery codes", sl.Err(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, codes)
+}
+
+// ListIdentityProviders godoc
+// @Summary Провайдеры входа
+// @Description Внешние провайдеры OpenID Connect, через которых можно войти
+// @Tags users
+// @Produce json
+// @Success 200 {object} dto.IdentityProvidersResponse
+// @Router /api/v1/auth/providers [get]
+func (r *Routers) ListIdentityProviders(c echo.Context) error {
+	return c.JSON(http.StatusOK, dto.IdentityProvidersResponse{Providers: r.OIDCService.Providers()})
+}
+
+// StartOIDCLogin godoc
+// @Summary Начать вход через провайдера
+// @Description Возвращает адрес страницы входа провайдера. После входа провайдер вернет пользователя
+// @Description на redirect_url сайта с параметрами code и state, которые нужно передать в callback
+// @Tags users
+// @Produce json
+// @Param provider path string true "Имя провайдера"
+// @Success 200 {object} dto.OIDCStartResponse
+// @Failure 404 {object} response.Problem "Провайдер не настроен"
+// @Router /api/v1/auth/oidc/{provider} [post]
+func (r *Routers) StartOIDCLogin(c echo.Context) error {
+	const op = "http.routers.StartOIDCLogin"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	start, err := r.OIDCService.BeginLogin(c.Request().Context(), c.Param("provider"))
+	if err != nil {
+		log.Warn("failed to start provider login", sl.Err(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, start)
+}
+
+// FinishOIDCLogin godoc
+// @Summary Завершить вход через провайдера
+// @Description Обменивает код провайдера на токены. Ответ такой же, как у POST /api/v1/login:
+// @Description если нужен второй фактор, вместо токенов возвращается challenge_id.
+// @Description Для новой учетной записи провайдера создается пользователь. Если email уже занят,
+// @Description возвращается 409: провайдера нужно привязать из профиля после входа
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param provider path string true "Имя провайдера"
+// @Param request body dto.OIDCCallbackRequest true "Параметры возврата от провайдера"
+// @Success 200 {object} response.Response{data=map[string]string} "Успешный вход (токен) или запрос второго фактора"
+// @Failure 401 {object} response.Problem "Вход истек или провайдер его не подтвердил"
+// @Failure 403 {object} response.Problem "Email не подтвержден провайдером"
+// @Failure 409 {object} response.Problem "Пользователь с таким email уже есть"
+// @Router /api/v1/auth/oidc/{provider}/callback [post]
+func (r *Routers) FinishOIDCLogin(c echo.Context) error {
+	const op = "http.routers.FinishOIDCLogin"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	var req dto.OIDCCallbackRequest
+	if err := bindRequest(c, &req); err != nil {
+		log.Warn("invalid request data", sl.Err(err))
+		return err
+	}
+
+	result, err := r.OIDCService.FinishLogin(c.Request().Context(), c.Param("provider"), req.Code, req.State)
+	if err != nil {
+		return err
+	}
+
+	return r.loginResponse(c, result)
+}
+
+// ListMyIdentities godoc
+// @Summary Привязанные провайдеры
+// @Tags Профиль
+// @Produce json
+// @Success 200 {array} dto.IdentityResponse
+// @Failure 401 {object} response.Problem
+// @Security ApiKeyAuth
+// @Router /api/v1/me/identities [get]
+func (r *Routers) ListMyIdentities(c echo.Context) error {
+	const op = "http.routers.ListMyIdentities"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errAuthRequired
+	}
+
+	identities, err := r.OIDCService.ListIdentities(c.Request().Context(), userID)
+	if err != nil {
+		log.Error("failed list identities", sl.Err(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, identities)
+}
+
+// StartLinkIdentity godoc
+// @Summary Начать привязку провайдера
+// @Description Возвращает адрес страницы входа провайдера. Привязка завершается в POST /api/v1/me/identities/{provider}/callback
+// @Tags Профиль
+// @Produce json
+// @Param provider path string true "Имя провайдера"
+// @Success 200 {object} dto.OIDCStartResponse
+// @Failure 401 {object} response.Problem
+// @Failure 404 {object} response.Problem "Провайдер не настроен"
+// @Security ApiKeyAuth
+// @Router /api/v1/me/identities/{provider} [post]
+func (r *Routers) StartLinkIdentity(c echo.Context) error {
+	const op = "http.routers.StartLinkIdentity"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errAuthRequired
+	}
+
+	start, err := r.OIDCService.BeginLink(c.Request().Context(), userID, c.Param("provider"))
+	if err != nil {
+		log.Warn("failed to start identity link", sl.Err(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, start)
+}
+
+// FinishLinkIdentity godoc
+// @Summary Привязать провайдера
+// @Tags Профиль
+// @Accept json
+// @Produce json
+// @Param provider path string true "Имя провайдера"
+// @Param request body dto.OIDCCallbackRequest true "Параметры возврата от провайдера"
+// @Success 200 {object} dto.IdentityResponse
+// @Failure 401 {object} response.Problem "Привязка истекла или провайдер ее не подтвердил"
+// @Failure 409 {object} response.Problem "Учетная запись провайдера уже привязана"
+// @Security ApiKeyAuth
+// @Router /api/v1/me/identities/{provider}/callback [post]
+func (r *Routers) FinishLinkIdentity(c echo.Context) error {
+	const op = "http.routers.FinishLinkIdentity"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errAuthRequired
+	}
+
+	var req dto.OIDCCallbackRequest
+	if err := bindRequest(c, &req); err != nil {
+		log.Warn("invalid request data", sl.Err(err))
+		return err
+	}
+
+	identity, err := r.OIDCService.FinishLink(c.Request().Context(), userID, c.Param("provider"), req.Code, req.State)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, identity)
+}
+
+// UnlinkMyIdentity godoc
+// @Summary Отвязать провайдера
+// @Description Последнего провайдера нельзя отвязать, если у пользователя нет пароля
+// @Tags Профиль
+// @Param provider path string true "Имя провайдера"
+// @Success 204
+// @Failure 401 {object} response.Problem
+// @Failure 404 {object} response.Problem "Провайдер не привязан"
+// @Failure 409 {object} response.Problem "Это единственный способ входа"
+// @Security ApiKeyAuth
+// @Router /api/v1/me/identities/{provider} [delete]
+func (r *Routers) UnlinkMyIdentity(c echo.Context) error {
+	const op = "http.routers.UnlinkMyIdentity"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errAuthRequired
+	}
+
+	if err := r.OIDCService.UnlinkIdentity(c.Request().Context(), userID, c.Param("provider")); err != nil {
+		log.Warn("failed to unlink identity", sl.Err(err))
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ListMyAPIKeys godoc
+// @Summary Мои ключи API
+// @Description Ключи API пользователя, включая отозванные. Секретная часть не возвращается
+// @Tags Профиль
+// @Produce json
+// @Success 200 {array} dto.APIKeyResponse
+// @Failure 401 {object} response.Problem
+// @Security ApiKeyAuth
+// @Router /api/v1/me/api-keys [get]
+func (r *Routers) ListMyAPIKeys(c echo.Context) error {
+	const op = "http.routers.ListMyAPIKeys"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errAuthRequired
+	}
+
+	keys, err := r.APIKeyService.ListKeys(c.Request().Context(), &userID)
+	if err != nil {
+		log.Error("failed list api keys", sl.Err(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, keys)
+}
+
+// CreateMyAPIKey godoc
+// @Summary Создать ключ API
+// @Description Выпускает ключ для интеграций. Ключ передается в заголовке X-API-Key и показывается только в этом ответе.
+// @Description Области доступа: posts:read, posts:write, media:read, media:write, galleries:read, galleries:write
+// @Tags Профиль
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateAPIKeyRequest true "Название, области доступа и лимит запросов"
+// @Success 201 {object} dto.APIKeyCreatedResponse
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Security ApiKeyAuth
+// @Router /api/v1/me/api-keys [post]
+func (r *Routers) CreateMyAPIKey(c echo.Context) error {
+	const op = "http.routers.CreateMyAPIKey"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errAuthRequired
+	}
+
+	var req dto.CreateAPIKeyRequest
+	if err := bindRequest(c, &req); err != nil {
+		log.Error("invalid request data", sl.Err(err))
+		return err
+	}
+
+	key, err := r.APIKeyService.CreateKey(c.Request().Context(), userID, req)
+	if err != nil {
+		log.Error("failed create api key", sl.Err(err))
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, key)
+}
+
+// RevokeMyAPIKey godoc
+// @Summary Отозвать ключ API
+// @Tags Профиль
+// @Param id path string true "UUID ключа" format(uuid)
+// @Success 204
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 404 {object} response.Problem "Ключ не найден или уже отозван"
+// @Security ApiKeyAuth
+// @Router /api/v1/me/api-keys/{id} [delete]
+func (r *Routers) RevokeMyAPIKey(c echo.Context) error {
+	const op = "http.routers.RevokeMyAPIKey"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errAuthRequired
+	}
+
+	keyID, err := uuidParam(c, "id")
+	if err != nil {
+		log.Error("invalid api key ID format", sl.Err(err))
+		return err
+	}
+
+	if err := r.APIKeyService.RevokeKey(c.Request().Context(), keyID, &userID); err != nil {
+		log.Warn("failed revoke api key", sl.Err(err))
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// UploadMedia godoc
+// @Summary Загрузка медиафайла
+// @Description Загружает файл на сервер с возможностью указания метаданных
+// @Tags Медиа
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Файл для загрузки (макс. 10MB)"
+// @Param media_type formData string true "Тип контента" Enums(photo, video, audio, document)
+// @Param is_public formData boolean false "Публичный доступ (по умолчанию false)"
+// @Param metadata formData string false "Дополнительные метаданные в JSON-формате"
+// @Param width formData integer false "Ширина в пикселях (для изображений/видео)"
+// @Param height formData integer false "Высота в пикселях (для изображений/видео)"
+// @Param duration formData integer false "Длительность в секундах (для видео/аудио)"
+// @Success 201 {object} models.Media "Успешно загруженный медиаобъект"
+// @Failure 400 {object} response.Problem "Некорректные входные данные"
+// @Failure 413 {object} response.Problem "Превышен максимальный размер файла"
+// @Failure 415 {object} response.Problem "Неподдерживаемый тип файла"
+// @Failure 500 {object} response.Problem "Внутренняя ошибка сервера"
+// @Security ApiKeyAuth
+// @Router /api/v1/media/upload [post]
+func (r *Routers) UploadMedia(c echo.Context) error {
+	const op = "http.routers.UploadMedia"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	startTime := time.Now()
+	defer func() {
+		log.Info("Request completed",
+			"duration", time.Since(startTime))
+	}()
+
+	log.Info("Start uploading media",
+		"method", c.Request().Method,
+		"path", c.Path(),
+		"client_ip", c.RealIP())
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		log.Warn("Empty file in request",
+			"error", err.Error())
+		return apperr.InvalidField("file", "required", "file is required")
+	}
+
+	log.Debug("Got file for upload",
+		"filename", file.Filename,
+		"size", file.Size,
+		"mime_type", file.Header.Get("Content-Type"))
+
+	input, err := r.parseMediaUploadInput(c)
+	if err != nil {
+		log.Warn("Error parsing data",
+			"error", err.Error())
+		return err
+	}
+	input.File = file
+
+	log.Debug("Options for upload",
+		"uploader_id", input.UploaderID,
+		"media_type", input.MediaType,
+		"is_public", input.IsPublic,
+		"metadata", input.CustomMetadata)
+
+	media, err := r.MediaService.UploadMedia(c.Request().Context(), *input)
+	if err != nil {
+		log.Error("Error upload media",
+			"error", err.Error(),
+			"uploader_id", input.UploaderID,
+			"filename", file.Filename)
+		return err
+	}
+
+	log.Info("Upload successfull",
+		"media_id", media.ID,
+		"uploader_id", media.UploaderID,
+		"file_size", media.FileSize,
+		"duration", time.Since(startTime))
+
+	return c.JSON(http.StatusCreated, media)
+}
+
+// UploadMultipleMedia обрабатывает запрос на загрузку множества медиафайлов
+// UploadMultipleMedia godoc
+// @Summary Загрузка множества медиафайлов
+// @Description Загружает несколько медиафайлов на сервер. Поддерживает передачу файлов и общих параметров загрузки.
+// @Tags Медиа
+// @Accept multipart/form-data
+// @Produce json
+// @Param files formData file true "Файлы для загрузки (поддерживается множественная загрузка)"
+// @Param media_type formData string true "Тип медиа (например, image, video)"
+// @Param is_public formData boolean true "Флаг публичности файла"
+// @Param metadata formData string false "Дополнительные метаданные (опционально)"
+// @Success 201 {array} models.Media "Успешная загрузка, возвращает массив созданных медиа-объектов"
+// @Failure 400 {object} response.Problem "Ошибка валидации входных данных"
+// @Failure 500 {object} response.Problem "Ошибка сервера при загрузке файлов"
+// @Router /api/v1/media/multiple [post]
+func (r *Routers) UploadMultipleMedia(c echo.Context) error {
+	const op = "http.routers.UploadMultipleMedia"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	startTime := time.Now()
+	defer func() {
+		log.Info("Request completed",
+			"duration", time.Since(startTime))
+	}()
+
+	log.Info("Start uploading multiple media",
+		"method", c.Request().Method,
+		"path", c.Path(),
+		"client_ip", c.RealIP())
+
+	inputs, err := r.parseMultipleUploadInputs(c)
+	if err != nil {
+		log.Warn("Invalid upload request",
+			"error", err.Error())
+		return err
+	}
+
+	// Выполняем загрузку через сервисный слой
+	medias, err := r.MediaService.UploadMultipleMedia(c.Request().Context(), inputs)
+	if err != nil {
+		log.Error("Error uploading multiple media",
+			"error", err.Error(),
+			"uploader_id", inputs[0].UploaderID,
+			"file_count", len(inputs))
+		return err
+	}
+
+	log.Info("Upload successful",
+		"media_count", len(medias),
+		"uploader_id", inputs[0].UploaderID,
+		"duration", time.Since(startTime))
+
+	return c.JSON(http.StatusCreated, medias)
+}
+
+// UploadMediaGroup godoc
+// @Summary Загрузка файлов в новую медиа-группу
+// @Description Загружает несколько медиафайлов и объединяет их в новую группу. Медиа и группа создаются в одной транзакции.
+// @Tags Медиа-группы
+// @Accept multipart/form-data
+// @Produce json
+// @Param files formData file true "Файлы для загрузки (поддерживается множественная загрузка)"
+// @Param media_type formData string true "Тип медиа (например, image, video)"
+// @Param is_public formData boolean true "Флаг публичности файла"
+// @Param metadata formData string false "Дополнительные метаданные (опционально)"
+// @Param description formData string false "Описание группы"
+// @Success 201 {object} dto.MediaGroupUploadResponse "Группа и созданные медиа"
+// @Failure 400 {object} response.Problem "Ошибка валидации входных данных"
+// @Failure 500 {object} response.Problem "Ошибка сервера при загрузке файлов"
+// @Security ApiKeyAuth
+// @Router /api/v1/media/groups/upload [post]
+func (r *Routers) UploadMediaGroup(c echo.Context) error {
+	const op = "http.routers.UploadMediaGroup"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	inputs, err := r.parseMultipleUploadInputs(c)
+	if err != nil {
+		log.Warn("Invalid upload request", sl.Err(err))
+		return err
+	}
+
+	group, err := r.MediaService.UploadMediaGroup(c.Request().Context(), inputs, c.FormValue("description"))
+	if err != nil {
+		log.Error("Error uploading media group", sl.Err(err))
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, group)
+}
+
+// AttachMediaToGroup godoc
+// @Summary Прикрепить медиа к группе
+// @Description Связывает один или несколько медиафайлов с существующей медиагруппой
+// @Tags Медиа-группы
+// @Accept json
+// @Produce json
+// @Param group_id path string true "UUID группы" format(uuid)
+// @Param request body dto.AttachMediaRequest true "Данные для прикрепления"
+//
+//	{
+//	  "mediaIDs": [
+//	    "3fa85f64-5717-4562-b3fc-2c963f66afa6",
+//	    "4fb85f64-5717-4562-b3fc-2c963f66afa7"
+//	  ]
+//	}
+//
+// @Success 200 "Успешное прикрепление (no content)"
+// @Failure 400 {object} response.Problem "Невалидные данные: пустой массив, неверный формат UUID"
+// @Failure 404 {object} response.Problem "Группа не найдена"
+// @Failure 413 {object} response.Problem "Превышен лимит количества медиафайлов"
+// @Failure 500 {object} response.Problem "Ошибка привязки медиа"
+// @Security ApiKeyAuth
+// @Router /api/v1/media/groups/{group_id}/attach [post]
+func (r *Routers) AttachMediaToGroup(c echo.Context) error {
+	const op = "http.routers.AttachMediaToGroup"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	// 1-2. Разбор и валидация запроса
+	req := new(dto.AttachMediaRequest)
+	if err := bindRequest(c, req); err != nil {
+		log.Error("invalid request", sl.Err(err))
+		return err
+	}
+
+	// 3. Парсинг groupID
+	groupID, err := uuid.Parse(req.GroupID)
+	if err != nil {
+		log.Error("invalid groupID format", sl.Err(err))
+		return apperr.InvalidField("group_id", "uuid", "invalid groupID format")
+	}
+
+	// 4. Парсинг массива mediaIDs
+	mediaIDs := make([]uuid.UUID, 0, len(req.MediaIDs))
+	for i, idStr := range req.MediaIDs {
+		mediaID, err := uuid.Parse(idStr)
+		if err != nil {
+			log.Error("invalid mediaID format", slog.String("mediaID", idStr), sl.Err(err))
+			return apperr.InvalidField(fmt.Sprintf("media_ids[%d]", i), "uuid", "invalid mediaID format")
+		}
+		mediaIDs = append(mediaIDs, mediaID)
+	}
+
+	// 5. Вызов сервиса с массивом mediaIDs
+	if err := r.MediaService.AttachMediaToGroup(
+		c.Request().Context(),
+		groupID,
+		mediaIDs,
+	); err != nil {
+		log.Error("failed to attach media", sl.Err(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, response.Response{
+		Status: "success",
+		Data: map[string]interface{}{
+			"attachedCount": len(mediaIDs),
+			"groupID":       groupID.String(),
+		},
+	})
+}
+
+// CreateMediaGroup godoc
+// @Summary Создать медиагруппу
+// @Description Создает новую группу для организации медиафайлов
+// @Tags Медиа-группы
+// @Accept multipart/form-data
+// @Produce json
+// @Param description formData string false "Описание группы"
+// @Success 201 "Группа создана (no content)"
+// @Failure 400 {object} response.Problem "Невалидный UUID владельца"
+// @Failure 500 {object} response.Problem "Ошибка создания группы"
+// @Security ApiKeyAuth
+// @Router /api/v1/media/groups [post]
+func (r *Routers) CreateMediaGroup(c echo.Context) error {
+	const op = "http.routers.CreateMediaGroup"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	req := new(dto.CreateMediaGroupRequest)
+
+	if err := bindRequest(c, req); err != nil {
+		log.Error("error invalid request data", sl.Err(err))
+		return err
+	}
+
+	ownerID, ok := currentUserID(c)
+	if !ok {
+		return errAuthRequired
+	}
+
+	groupID, err := r.MediaService.AttachMedia(c.Request().Context(), ownerID, req.Description)
+	if err != nil {
+		return err
+	}
+
+	response := map[string]interface{}{
+		"data": groupID,
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// ListGroupMedia godoc
+// @Summary Получить медиа группы
+// @Description Возвращает список всех медиафайлов в группе
 // @Tags Медиа-группы
+// @Produce json
+// @Param group_id path string true "UUID группы" format(uuid)
+// @Success 200 {array} models.Media "Список медиафайлов"
+// @Failure 400 {object} response.Problem "Невалидный UUID группы"
+// @Failure 500 {object} response.Problem "Ошибка получения списка"
+// @Security ApiKeyAuth
+// @Router /api/v1/media/groups/{group_id} [get]
+func (r *Routers) ListGroupMedia(c echo.Context) error {
+	const op = "http.routers.ListGroupMedia"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	req := new(dto.ListGroupMediaRequest)
+	if err := bindRequest(c, req); err != nil {
+		log.Error("invalid query parameters", sl.Err(err))
+		return err
+	}
+
+	groupID, err := uuid.Parse(req.GroupID)
+	if err != nil {
+		log.Error("failed parse uuid", sl.Err(err))
+		return apperr.InvalidField("group_id", "uuid", "invalid group_id format")
+	}
+
+	media, err := r.MediaService.ListGroupMedia(c.Request().Context(), groupID)
+	if err != nil {
+		log.Error("failed list group", sl.Err(err))
+		return err
+	}
+
+	response := map[string]interface{}{
+		"data": media,
+		"meta": map[string]interface{}{
+			"count":    len(media),
+			"group_id": groupID,
+		},
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// GetAllImages godoc
+// @Summary Получить все изображения
+// @Description Возвращает список всех загруженных изображений с метаданными
+// @Tags Медиа
+// @Accept  json
+// @Produce  json
+// @Success 200 {object} map[string]interface{} "Успешный ответ"
+// @SuccessExample {json} Успешный ответ:
+//
+//	{
+//	    "data": [
+//	        {
+//	            "id": "550e8400-e29b-41d4-a716-446655440000",
+//	            "uploader_id": "550e8400-e29b-41d4-a716-446655440000",
+//	            "created_at": "2025-06-06T11:08:12Z",
+//	            "original_filename": "nature.jpg",
+//	            "storage_path": "images/2025/06/550e8400-e29b-41d4-a716-446655440000.jpg",
+//	            "file_size": 102400,
+//	            "mime_type": "image/jpeg",
+//	            "width": 1920,
+//	            "height": 1080,
+//	            "is_public": true,
+//	            "metadata": {}
+//	        }
+//	    ],
+//	    "meta": {
+//	        "count": 1
+//	    }
+//	}
+//
+// @Failure 500 {object} response.Problem "Внутренняя ошибка сервера"
+// @Router /api/v1/media/images [get]
+func (r *Routers) GetAllImages(c echo.Context) error {
+	const op = "http.routers.GetAllImages"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	// Получаем параметр limit из query (по умолчанию 0 — без лимита)
+	limit := c.QueryParam("limit")
+	var limitInt int
+	if limit != "" {
+		var err error
+		limitInt, err = strconv.Atoi(limit)
+		if err != nil {
+			log.Error("invalid limit parameter", sl.Err(err))
+			return apperr.InvalidField("limit", "number", "limit must be a valid integer")
+		}
+	}
+
+	media, total, err := r.MediaService.GetAllImages(c.Request().Context(), limitInt)
+	if err != nil {
+		log.Error("failed get images", sl.Err(err))
+		return err
+	}
+
+	response := map[string]interface{}{
+		"data":  media,
+		"total": total,
+		"meta": map[string]interface{}{
+			"count": len(media),
+		},
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+func (r *Routers) GetImages(c echo.Context) error {
+	const op = "http.routers.GetImages"
+
+	log := r.log.With(
+
+		slog.String("op", op),
+	)
+
+	media, err := r.MediaService.GetImages(c.Request().Context())
+
+	if err != nil {
+		log.Error("failed get images", sl.Err(err))
+		return err
+	}
+
+	response := map[string]interface{}{
+		"data": media,
+		"meta": map[string]interface{}{
+			"count": len(media),
+		},
+	}
+
+	return c.JSON(http.StatusOK, response)
+}
+
+// parseMultipleUploadInputs читает файлы из поля files и общие параметры загрузки
+func (r *Routers) parseMultipleUploadInputs(c echo.Context) ([]dto.MediaUploadInput, error) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, errInvalidBody.Wrap(err)
+	}
+
+	files := form.File["files"]
+	if len(files) == 0 {
+		return nil, apperr.InvalidField("files", "required", "at least one file is required")
+	}
+
+	baseInput, err := r.parseMediaUploadInput(c)
+	if err != nil {
+		return nil, err
+	}
+
+	// Подготавливаем входные данные для каждого файла
+	inputs := make([]dto.MediaUploadInput, 0, len(files))
+	for _, file := range files {
+		input := *baseInput
+		input.File = file
+		inputs = append(inputs, input)
+	}
+
+	return inputs, nil
+}
+
+func (r *Routers) parseMediaUploadInput(c echo.Context) (*dto.MediaUploadInput, error) {
+	uploaderID, ok := currentUserID(c)
+	if !ok {
+		return nil, errAuthRequired
+	}
+
+	var metadata map[string]any
+	if metaStr := c.FormValue("metadata"); metaStr != "" {
+		if err := json.Unmarshal([]byte(metaStr), &metadata); err != nil {
+			return nil, apperr.InvalidField("metadata", "json", "metadata must be a JSON object")
+		}
+	}
+
+	input := &dto.MediaUploadInput{
+		UploaderID:     uploaderID,
+		MediaType:      c.FormValue("media_type"),
+		IsPublic:       c.FormValue("is_public") == "true",
+		CustomMetadata: metadata,
+	}
+
+	if widthStr := c.FormValue("width"); widthStr != "" {
+		if width, err := strconv.Atoi(widthStr); err == nil {
+			input.Width = &width
+		}
+	}
+	if heightStr := c.FormValue("height"); heightStr != "" {
+		if height, err := strconv.Atoi(heightStr); err == nil {
+			input.Height = &height
+		}
+	}
+	if durationStr := c.FormValue("duration"); durationStr != "" {
+		if duration, err := strconv.Atoi(durationStr); err == nil {
+			input.Duration = &duration
+		}
+	}
+
+	return input, nil
+}
+
+// CreatePost godoc
+// @Summary Создать новый пост
+// @Description Создает новый пост блога от имени текущего пользователя. Добавлять FeaturedImageID -> только существующую медиа
+// @Tags Посты
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateBlogPostRequest true "Данные поста"
+// @Success 201 {object} dto.BlogPostResponse
+// @Failure 400 {object} response.Problem
+// @Failure 500 {object} response.Problem
+// @Security ApiKeyAuth
+// @Router /api/v1/posts [post]
+// Добавлять FeaturedImageID -> только существующую медиа
+func (r *Routers) CreatePost(c echo.Context) error {
+	const op = "http.routers.CreatePost"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	authorID, ok := currentUserID(c)
+	if !ok {
+		return errAuthRequired
+	}
+
+	var req dto.CreateBlogPostRequest
+
+	if err := bindRequest(c, &req); err != nil {
+		log.Error("invalid request data", sl.Err(err))
+		return err
+	}
+	req.AuthorID = authorID
+
+	post, err := r.BlogService.CreatePost(c.Request().Context(), req)
+	if err != nil {
+		log.Error("error create post", sl.Err(err))
+		return err
+	}
+
+	r.invalidateFeeds(c)
+
+	return c.JSON(http.StatusCreated, post)
+}
+
+// GetPost godoc
+// @Summary Получить пост
+// @Description Возвращает пост по его ID. Неопубликованные посты видит только администратор, остальным возвращается 404
+// @Tags Посты
+// @Produce json
+// @Param id path string true "UUID поста" format(uuid)
+// @Success 200 {object} dto.BlogPostResponse
+// @Failure 404 {object} response.Problem
+// @Failure 500 {object} response.Problem
+// @Security ApiKeyAuth
+// @Router /api/v1/posts/{id} [get]
+func (r *Routers) GetPost(c echo.Context) error {
+	const op = "http.routers.GetPost"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	postID, err := uuidParam(c, "id")
+	if err != nil {
+		log.Error("invalid post id format", sl.Err(err))
+		return err
+	}
+
+	post, err := r.BlogService.GetPostByID(c.Request().Context(), postID)
+	if err != nil {
+		log.Error("error get post by id", sl.Err(err))
+		return err
+	}
+
+	// Как и по slug, неопубликованный пост гостю не показывается
+	if post.Status != "published" && !r.viewerIsAdmin(c) {
+		log.Warn("unpublished post requested", slog.String("post_id", postID.String()))
+		return storage.ErrPostNotFound
+	}
+
+	return c.JSON(http.StatusOK, post)
+}
+
+// GetPostBySlug godoc
+// @Summary Получить пост по slug
+// @Description Возвращает пост по его slug. Для прежнего slug переименованного поста возвращает 301 на актуальный адрес.
+// @Description Неопубликованные посты видит только администратор, остальным возвращается 404
+// @Tags Посты
+// @Produce json
+// @Param slug path string true "Slug поста"
+// @Success 200 {object} dto.BlogPostResponse
+// @Success 301 "Пост переименован, актуальный адрес в заголовке Location"
+// @Failure 404 {object} response.Problem
+// @Router /api/v1/posts/by-slug/{slug} [get]
+func (r *Routers) GetPostBySlug(c echo.Context) error {
+	const op = "http.routers.GetPostBySlug"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	post, movedTo, err := r.BlogService.GetPostBySlug(c.Request().Context(), c.Param("slug"), r.viewerIsAdmin(c))
+	if err != nil {
+		log.Error("error get post by slug", sl.Err(err))
+		return err
+	}
+
+	if movedTo != "" {
+		return c.Redirect(http.StatusMovedPermanently, "/api/v1/posts/by-slug/"+url.PathEscape(movedTo))
+	}
+
+	return c.JSON(http.StatusOK, post)
+}
+
+// CheckPostSlugAvailability godoc
+// @Summary Проверить доступность slug поста
+// @Description Проверяет, не занят ли slug другим постом, в том числе в истории переименований
+// @Tags Посты
+// @Accept json
+// @Produce json
+// @Param request body dto.SlugAvailabilityRequest true "Проверяемый slug"
+// @Success 200 {object} dto.SlugAvailabilityResponse
+// @Failure 400 {object} response.Problem
+// @Security ApiKeyAuth
+// @Router /api/v1/posts/slug-availability [post]
+func (r *Routers) CheckPostSlugAvailability(c echo.Context) error {
+	const op = "http.routers.CheckPostSlugAvailability"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	var req dto.SlugAvailabilityRequest
+	if err := c.Bind(&req); err != nil {
+		log.Error("invalid request data", sl.Err(err))
+		return errInvalidBody.Wrap(err)
+	}
+
+	if err := c.Validate(req); err != nil {
+		log.Error("validation failed", sl.Err(err))
+		return err
+	}
+
+	resp, err := r.BlogService.CheckSlugAvailability(c.Request().Context(), req.Slug)
+	if err != nil {
+		log.Error("failed check slug", sl.Err(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// UpdatePost godoc
+// @Summary Обновить пост
+// @Description Обновляет данные поста
+// @Tags Посты
+// @Accept json
+// @Produce json
+// @Param id path string true "UUID поста" format(uuid)
+// @Param request body dto.UpdateBlogPostRequest true "Данные для обновления"
+// @Success 200 {object} dto.BlogPostResponse
+// @Failure 400 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Failure 500 {object} response.Problem
+// @Security ApiKeyAuth
+// @Router /api/v1/posts/{id} [put]
+func (r *Routers) UpdatePost(c echo.Context) error {
+	const op = "http.routers.UpdatePost"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	postID, err := uuidParam(c, "id")
+	if err != nil {
+		log.Error("invalid post ID format", sl.Err(err))
+		return err
+	}
+
+	req := new(dto.UpdateBlogPostRequest)
+	if err := c.Bind(req); err != nil {
+		log.Error("invalid request data", sl.Err(err))
+		return errInvalidBody.Wrap(err)
+	}
+
+	if err := c.Validate(req); err != nil {
+		log.Error("failed validate data", sl.Err(err))
+		return err
+	}
+
+	post, err := r.BlogService.UpdatePost(c.Request().Context(), postID, *req)
+	if err != nil {
+		log.Error("failed update post", sl.Err(err))
+		return err
+	}
+
+	r.invalidateFeeds(c)
+
+	return c.JSON(http.StatusOK, post)
+}
+
+// DeletePost godoc
+// @Summary Удалить пост
+// @Description Удаляет пост (физическое удаление)
+// @Tags Посты
+// @Param id path string true "UUID поста" format(uuid)
+// @Success 204
+// @Failure 400 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Failure 500 {object} response.Problem
+// @Security ApiKeyAuth
+// @Router /api/v1/posts/{id} [delete]
+func (r *Routers) DeletePost(c echo.Context) error {
+	const op = "http.routers.DeletePost"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	postID, err := uuidParam(c, "id")
+	if err != nil {
+		log.Error("invalid post ID format", sl.Err(err))
+		return err
+	}
+
+	if err := r.BlogService.DeletePost(c.Request().Context(), postID); err != nil {
+		log.Error("failed delete post", sl.Err(err))
+		return err
+	}
+
+	r.invalidateFeeds(c)
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// PublishPost godoc
+// @Summary Опубликовать пост
+// @Description Устанавливает статус поста "published"
+// @Tags Посты
+// @Param id path string true "UUID поста" format(uuid)
+// @Success 200 {object} dto.BlogPostResponse
+// @Failure 400 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Failure 500 {object} response.Problem
+// @Security ApiKeyAuth
+// @Router /api/v1/posts/{id}/publish [patch]
+func (r *Routers) PublishPost(c echo.Context) error {
+	const op = "http.routers.PublishPost"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	postID, err := uuidParam(c, "id")
+	if err != nil {
+		log.Error("invalid post ID format", sl.Err(err))
+		return err
+	}
+
+	post, err := r.BlogService.PublishPost(c.Request().Context(), postID)
+	if err != nil {
+		log.Error("failed publish post", sl.Err(err))
+		return err
+	}
+
+	r.invalidateFeeds(c)
+
+	return c.JSON(http.StatusOK, post)
+}
+
+// ArchivePost godoc
+// @Summary Архивировать пост
+// @Description Архивирует пост (soft delete)
+// @Tags Посты
+// @Param id path string true "UUID поста" format(uuid)
+// @Success 200 {object} dto.BlogPostResponse
+// @Failure 400 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Failure 500 {object} response.Problem
+// @Security ApiKeyAuth
+// @Router /api/v1/posts/{id}/archive [patch]
+func (r *Routers) ArchivePost(c echo.Context) error {
+	const op = "http.routers.ArchivePost"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	postID, err := uuidParam(c, "id")
+	if err != nil {
+		log.Error("invalid post ID format", sl.Err(err))
+		return err
+	}
+
+	post, err := r.BlogService.ArchivePost(c.Request().Context(), postID)
+	if err != nil {
+		log.Error("failed archive post", sl.Err(err))
+		return err
+	}
+
+	r.invalidateFeeds(c)
+
+	return c.JSON(http.StatusOK, post)
+}
+
+// ListPosts godoc
+// @Summary Список постов
+// @Description Возвращает список постов с пагинацией и фильтрацией по статусу, категории, тегам, автору и дате. http://localhost:8080/api/v1/posts?status=published&category=travel&tags=go&tags=web&from=2024-01-01&page=1&per_page=10
+// @Tags Посты
+// @Produce json
+// @Param status query string false "Фильтр по статусу (draft, published, archived, all). Кроме published, только для администратора"
+// @Param category query string false "UUID или slug категории (включая подкатегории)"
+// @Param tags query []string false "Slug'и тегов, пост должен содержать любой из них" collectionFormat(multi)
+// @Param author_id query string false "UUID автора" format(uuid)
+// @Param from query string false "Начало периода (RFC3339 или YYYY-MM-DD)"
+// @Param to query string false "Конец периода (RFC3339 или YYYY-MM-DD)"
+// @Param page query int false "Номер страницы" default(1)
+// @Param per_page query int false "Количество элементов на странице" default(10)
+// @Param cursor query string false "Значение next_cursor предыдущей страницы. Если задан, page не учитывается"
+// @Success 200 {object} dto.BlogPostListResponse
+// @Header 200 {string} Link "Ссылки first, prev, next и last (RFC 8288)"
+// @Failure 400 {object} response.Problem
+// @Failure 500 {object} response.Problem
+// @Security ApiKeyAuth
+// @Router /api/v1/posts [get]
+func (r *Routers) ListPosts(c echo.Context) error {
+	const op = "http.routers.ListPosts"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	filter, err := parseBlogPostFilter(c)
+	if err != nil {
+		log.Warn("invalid post filter", sl.Err(err))
+		return err
+	}
+	filter.Status = r.visibleStatus(c, filter.Status)
+
+	page, perPage := pageParams(c)
+
+	posts, err := r.BlogService.ListPosts(c.Request().Context(), filter, page, perPage)
+	if err != nil {
+		log.Error("failed list post", sl.Err(err))
+		return err
+	}
+
+	setPaginationLinks(c, posts.Meta)
+
+	return c.JSON(http.StatusOK, posts)
+}
+
+// GetPostTags godoc
+// @Summary Облако тегов постов
+// @Description Возвращает теги постов с количеством использований, отсортированные по популярности
+// @Tags Посты
+// @Produce json
+// @Param status query string false "Статус учитываемых постов (draft, published, archived, all). Кроме published, только для администратора" default(published)
+// @Param limit query int false "Максимальное количество тегов" default(100)
+// @Success 200 {array} dto.TagCountResponse
+// @Failure 400 {object} response.Problem
+// @Router /api/v1/posts/tags [get]
+func (r *Routers) GetPostTags(c echo.Context) error {
+	const op = "http.routers.GetPostTags"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	status := c.QueryParam("status")
+	if status == "" {
+		status = "published"
+	}
+	status = r.visibleStatus(c, status)
+
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil || limit < 1 {
+		limit = 100
+	}
+
+	tags, err := r.BlogService.GetTagCounts(c.Request().Context(), status, limit)
+	if err != nil {
+		log.Error("failed get tag counts", sl.Err(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, tags)
+}
+
+// parseBlogPostFilter собирает фильтр списка постов из query-параметров
+func parseBlogPostFilter(c echo.Context) (dto.BlogPostFilter, error) {
+	filter := dto.BlogPostFilter{
+		Status: c.QueryParam("status"),
+		Cursor: c.QueryParam("cursor"),
+	}
+
+	if category := c.QueryParam("category"); category != "" {
+		if id, err := uuid.Parse(category); err == nil {
+			filter.CategoryID = &id
+		} else {
+			filter.CategorySlug = category
+		}
+	}
+
+	// Теги можно передавать как повторяющимся параметром, так и через запятую
+	for _, value := range c.QueryParams()["tags"] {
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				filter.Tags = append(filter.Tags, tag)
+			}
+		}
+	}
+
+	if authorID := c.QueryParam("author_id"); authorID != "" {
+		id, err := uuid.Parse(authorID)
+		if err != nil {
+			return filter, apperr.InvalidField("author_id", "uuid", "invalid author_id format")
+		}
+		filter.AuthorID = &id
+	}
+
+	if from := c.QueryParam("from"); from != "" {
+		date, _, err := parseDateParam(from)
+		if err != nil {
+			return filter, apperr.InvalidField("from", "date", "invalid from date format")
+		}
+		filter.DateFrom = &date
+	}
+
+	if to := c.QueryParam("to"); to != "" {
+		date, dateOnly, err := parseDateParam(to)
+		if err != nil {
+			return filter, apperr.InvalidField("to", "date", "invalid to date format")
+		}
+		// Дата без времени включает весь день целиком
+		if dateOnly {
+			date = date.Add(24*time.Hour - time.Nanosecond)
+		}
+		filter.DateTo = &date
+	}
+
+	return filter, nil
+}
+
+// pageParams читает номер и размер страницы из query-параметров page и per_page
+func pageParams(c echo.Context) (int, int) {
+	page, _ := strconv.Atoi(c.QueryParam("page"))
+	perPage, _ := strconv.Atoi(c.QueryParam("per_page"))
+
+	return pagination.Normalize(page, perPage)
+}
+
+// setPaginationLinks добавляет в ответ заголовок Link со ссылками на соседние страницы
+func setPaginationLinks(c echo.Context, meta pagination.Meta) {
+	if links := pagination.Links(c.Request().URL, meta); links != "" {
+		c.Response().Header().Set("Link", links)
+	}
+}
+
+// parseDateParam разбирает дату в формате RFC3339 или YYYY-MM-DD
+func parseDateParam(value string) (time.Time, bool, error) {
+	if date, err := time.Parse(time.RFC3339, value); err == nil {
+		return date, false, nil
+	}
+
+	date, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	return date, true, nil
+}
+
+// AddMediaGroup godoc
+// @Summary Добавить медиа-группу к посту
+// @Description Привязывает медиа-группу к посту с указанием типа связи
+// @Tags Посты
 // @Accept json
 // @Produce json
-// @Param group_id path string true "UUID группы" format(uuid)
-// @Param request body dto.AttachMediaRequest true "Данные для прикрепления"
-//
-//	{
-//	  "mediaIDs": [
-//	    "3fa85f64-5717-4562-b3fc-2c963f66afa6",
-//	    "4fb85f64-5717-4562-b3fc-2c963f66afa7"
-//	  ]
-//	}
-//
-// @Success 200 "Успешное прикрепление (no content)"
-// @Failure 400 {object} response.ErrorResponse "Невалидные данные: пустой массив, неверный формат UUID"
-// @Failure 404 {object} response.ErrorResponse "Группа не найдена"
-// @Failure 413 {object} response.ErrorResponse "Превышен лимит количества медиафайлов"
-// @Failure 500 {object} response.ErrorResponse "Ошибка привязки медиа"
+// @Param id path string true "UUID поста" format(uuid)
+// @Param request body dto.AddMediaGroupRequest true "Данные медиа-группы"
+// @Success 200 {object} dto.PostMediaGroupsResponse
+// @Failure 400 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Failure 500 {object} response.Problem
 // @Security ApiKeyAuth
-// @Router /api/v1/media/groups/{group_id}/attach [post]
-func (r *Routers) AttachMediaToGroup(c echo.Context) error {
-	const op = "http.routers.AttachMediaToGroup"
+// @Router /api/v1/posts/{id}/media-groups [post]
+func (r *Routers) AddMediaGroup(c echo.Context) error {
+	const op = "http.routers.AddMediaGroup"
 
 	log := r.log.With(
 		slog.String("op", op),
 	)
 
-	req := new(dto.AttachMediaRequest)
+	postID, err := uuidParam(c, "id")
+	if err != nil {
+		log.Error("invalid post format", sl.Err(err))
+		return err
+	}
+
+	req := new(dto.AddMediaGroupRequest)
 	if err := c.Bind(req); err != nil {
-		log.Error("invalid request data format", sl.Err(err))
-		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
-			Error: "Invalid request data format",
-		})
+		log.Error("invalid request data", sl.Err(err))
+		return errInvalidBody.Wrap(err)
+	}
+
+	if err := c.Validate(req); err != nil {
+		log.Error("invalid validate data", sl.Err(err))
+		return err
+	}
+
+	_, err = r.BlogService.AddMediaGroup(c.Request().Context(), postID, *req)
+	if err != nil {
+		log.Error("failed add media group", sl.Err(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, response.Response{Data: "succesfull"})
+}
+
+// GetPostMediaGroups godoc
+// @Summary Получить медиа-группы поста
+// @Description Возвращает список медиа-групп, привязанных к посту
+// @Tags Посты
+// @Produce json
+// @Param id path string true "UUID поста" format(uuid)
+// @Param relation_type query string false "Тип связи (content, gallery, attachment)"
+// @Success 200 {object} dto.PostMediaGroupsResponse
+// @Failure 400 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Failure 500 {object} response.Problem
+// @Security ApiKeyAuth
+// @Router /api/v1/posts/{id}/media-groups [get]
+func (r *Routers) GetPostMediaGroups(c echo.Context) error {
+	const op = "http.routers.GetPostMediaGroups"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	postID, err := uuidParam(c, "id")
+	if err != nil {
+		log.Error("failed parse uuid", sl.Err(err))
+		return err
+	}
+
+	relationType := c.QueryParam("relation_type")
+
+	resp, err := r.BlogService.GetPostMediaGroups(c.Request().Context(), postID, relationType)
+	if err != nil {
+		log.Error("failed get post media group", sl.Err(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// ListCategories godoc
+// @Summary Список категорий
+// @Description Возвращает все категории блога. Иерархия задается полем parent_id
+// @Tags Категории
+// @Produce json
+// @Success 200 {array} dto.CategoryResponse
+// @Failure 500 {object} response.Problem
+// @Router /api/v1/categories [get]
+func (r *Routers) ListCategories(c echo.Context) error {
+	const op = "http.routers.ListCategories"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	categories, err := r.CategoryService.ListCategories(c.Request().Context())
+	if err != nil {
+		log.Error("failed list categories", sl.Err(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, categories)
+}
+
+// GetCategory godoc
+// @Summary Получить категорию
+// @Description Возвращает категорию по её ID
+// @Tags Категории
+// @Produce json
+// @Param id path string true "UUID категории" format(uuid)
+// @Success 200 {object} dto.CategoryResponse
+// @Failure 400 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Router /api/v1/categories/{id} [get]
+func (r *Routers) GetCategory(c echo.Context) error {
+	const op = "http.routers.GetCategory"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	categoryID, err := uuidParam(c, "id")
+	if err != nil {
+		log.Error("invalid category ID format", sl.Err(err))
+		return err
+	}
+
+	category, err := r.CategoryService.GetCategoryByID(c.Request().Context(), categoryID)
+	if err != nil {
+		log.Error("failed get category", sl.Err(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, category)
+}
+
+// CreateCategory godoc
+// @Summary Создать категорию
+// @Description Создает категорию блога. Если slug не указан, он формируется из названия
+// @Tags Категории
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateCategoryRequest true "Данные категории"
+// @Success 201 {object} dto.CategoryResponse
+// @Failure 400 {object} response.Problem
+// @Security ApiKeyAuth
+// @Router /api/v1/categories [post]
+func (r *Routers) CreateCategory(c echo.Context) error {
+	const op = "http.routers.CreateCategory"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	var req dto.CreateCategoryRequest
+	if err := c.Bind(&req); err != nil {
+		log.Error("invalid request data", sl.Err(err))
+		return errInvalidBody.Wrap(err)
+	}
+
+	if err := c.Validate(req); err != nil {
+		log.Error("validation failed", sl.Err(err))
+		return err
+	}
+
+	category, err := r.CategoryService.CreateCategory(c.Request().Context(), req)
+	if err != nil {
+		log.Error("failed create category", sl.Err(err))
+		return err
+	}
+
+	return c.JSON(http.StatusCreated, category)
+}
+
+// UpdateCategory godoc
+// @Summary Обновить категорию
+// @Description Обновляет категорию. Нулевой UUID в parent_id делает категорию корневой
+// @Tags Категории
+// @Accept json
+// @Produce json
+// @Param id path string true "UUID категории" format(uuid)
+// @Param request body dto.UpdateCategoryRequest true "Данные для обновления"
+// @Success 200 {object} dto.CategoryResponse
+// @Failure 400 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Security ApiKeyAuth
+// @Router /api/v1/categories/{id} [put]
+func (r *Routers) UpdateCategory(c echo.Context) error {
+	const op = "http.routers.UpdateCategory"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	categoryID, err := uuidParam(c, "id")
+	if err != nil {
+		log.Error("invalid category ID format", sl.Err(err))
+		return err
+	}
+
+	var req dto.UpdateCategoryRequest
+	if err := c.Bind(&req); err != nil {
+		log.Error("invalid request data", sl.Err(err))
+		return errInvalidBody.Wrap(err)
+	}
+
+	if err := c.Validate(req); err != nil {
+		log.Error("validation failed", sl.Err(err))
+		return err
+	}
+
+	category, err := r.CategoryService.UpdateCategory(c.Request().Context(), categoryID, req)
+	if err != nil {
+		log.Error("failed update category", sl.Err(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, category)
+}
+
+// DeleteCategory godoc
+// @Summary Удалить категорию
+// @Description Удаляет категорию. Подкатегории переносятся к родителю, посты остаются без категории
+// @Tags Категории
+// @Param id path string true "UUID категории" format(uuid)
+// @Success 204
+// @Failure 400 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Security ApiKeyAuth
+// @Router /api/v1/categories/{id} [delete]
+func (r *Routers) DeleteCategory(c echo.Context) error {
+	const op = "http.routers.DeleteCategory"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	categoryID, err := uuidParam(c, "id")
+	if err != nil {
+		log.Error("invalid category ID format", sl.Err(err))
+		return err
+	}
+
+	if err := r.CategoryService.DeleteCategory(c.Request().Context(), categoryID); err != nil {
+		log.Error("failed delete category", sl.Err(err))
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// CreateComment godoc
+// @Summary Оставить комментарий
+// @Description Добавляет комментарий к опубликованному посту. Комментарий появится после модерации
+// @Tags Комментарии
+// @Accept json
+// @Produce json
+// @Param id path string true "UUID поста" format(uuid)
+// @Param request body dto.CreateCommentRequest true "Текст комментария"
+// @Success 201 {object} dto.CommentResponse
+// @Failure 400 {object} response.Problem
+// @Failure 401 {object} response.Problem
+// @Failure 403 {object} response.Problem "Комментарии к посту закрыты"
+// @Failure 404 {object} response.Problem
+// @Failure 429 {object} response.Problem "Слишком много комментариев"
+// @Security ApiKeyAuth
+// @Router /api/v1/posts/{id}/comments [post]
+func (r *Routers) CreateComment(c echo.Context) error {
+	const op = "http.routers.CreateComment"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errAuthRequired
+	}
+
+	postID, err := uuidParam(c, "id")
+	if err != nil {
+		log.Error("invalid post ID format", sl.Err(err))
+		return err
+	}
+
+	var req dto.CreateCommentRequest
+	if err := c.Bind(&req); err != nil {
+		log.Error("invalid request data", sl.Err(err))
+		return errInvalidBody.Wrap(err)
 	}
 
-	// 2. Валидация запроса
 	if err := c.Validate(req); err != nil {
 		log.Error("validation failed", sl.Err(err))
-		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
-			Error: err.Error(),
-		})
+		return err
 	}
 
-	// 3. Парсинг groupID
-	groupID, err := uuid.Parse(req.GroupID)
+	comment, err := r.CommentService.CreateComment(c.Request().Context(), postID, userID, req)
 	if err != nil {
-		log.Error("invalid groupID format", sl.Err(err))
-		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
-			Error: "Invalid groupID format",
-		})
+		log.Error("failed create comment", sl.Err(err))
+		return err
 	}
 
-	// 4. Парсинг массива mediaIDs
-	mediaIDs := make([]uuid.UUID, 0, len(req.MediaIDs))
-	for _, idStr := range req.MediaIDs {
-		mediaID, err := uuid.Parse(idStr)
-		if err != nil {
-			log.Error("invalid mediaID format", slog.String("mediaID", idStr), sl.Err(err))
-			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
-				Error: fmt.Sprintf("Invalid mediaID format: %s", idStr),
-			})
-		}
-		mediaIDs = append(mediaIDs, mediaID)
+	return c.JSON(http.StatusCreated, comment)
+}
+
+// ListPostComments godoc
+// @Summary Комментарии поста
+// @Description Возвращает одобренные комментарии опубликованного поста в виде дерева
+// @Tags Комментарии
+// @Produce json
+// @Param id path string true "UUID поста" format(uuid)
+// @Success 200 {array} dto.CommentResponse
+// @Failure 400 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Router /api/v1/posts/{id}/comments [get]
+func (r *Routers) ListPostComments(c echo.Context) error {
+	const op = "http.routers.ListPostComments"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	postID, err := uuidParam(c, "id")
+	if err != nil {
+		log.Error("invalid post ID format", sl.Err(err))
+		return err
 	}
 
-	// 5. Вызов сервиса с массивом mediaIDs
-	if err := r.MediaService.AttachMediaToGroup(
-		c.Request().Context(),
-		groupID,
-		mediaIDs,
-	); err != nil {
-		log.Error("failed to attach media", sl.Err(err))
-		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
-			Error: err.Error(),
-		})
+	comments, err := r.CommentService.ListPostComments(c.Request().Context(), postID)
+	if err != nil {
+		log.Error("failed list comments", sl.Err(err))
+		return err
 	}
 
-	return c.JSON(http.StatusOK, response.Response{
-		Status: "success",
-		Data: map[string]interface{}{
-			"attachedCount": len(mediaIDs),
-			"groupID":       groupID.String(),
-		},
-	})
+	return c.JSON(http.StatusOK, comments)
 }
 
-// CreateMediaGroup godoc
-// @Summary Создать медиагруппу
-// @Description Создает новую группу для организации медиафайлов
-// @Tags Медиа-группы
-// @Accept multipart/form-data
+// ListComments godoc
+// @Summary Очередь модерации
+// @Description Возвращает комментарии всех постов, новые первыми. По умолчанию показываются ожидающие модерации
+// @Tags Комментарии
 // @Produce json
-// @Param owner_id formData string true "UUID владельца группы" format(uuid)
-// @Param description formData string false "Описание группы"
-// @Success 201 "Группа создана (no content)"
-// @Failure 400 {object} response.ErrorResponse "Невалидный UUID владельца"
-// @Failure 500 {object} response.ErrorResponse "Ошибка создания группы"
+// @Param status query string false "Статус (pending, approved, spam, rejected, all)" default(pending)
+// @Param page query int false "Номер страницы" default(1)
+// @Param per_page query int false "Количество элементов на странице" default(10)
+// @Param cursor query string false "Значение next_cursor предыдущей страницы. Если задан, page не учитывается"
+// @Success 200 {object} dto.CommentListResponse
+// @Header 200 {string} Link "Ссылки first, prev, next и last (RFC 8288)"
+// @Failure 400 {object} response.Problem
 // @Security ApiKeyAuth
-// @Router /api/v1/media/groups [post]
-func (r *Routers) CreateMediaGroup(c echo.Context) error {
-	const op = "http.routers.CreateMediaGroup"
+// @Router /api/v1/comments [get]
+func (r *Routers) ListComments(c echo.Context) error {
+	const op = "http.routers.ListComments"
 
 	log := r.log.With(
 		slog.String("op", op),
 	)
 
-	req := new(dto.CreateMediaGroupRequest)
+	filter := dto.CommentFilter{
+		Status: c.QueryParam("status"),
+		Cursor: c.QueryParam("cursor"),
+	}
+	switch filter.Status {
+	case "":
+		filter.Status = models.CommentStatusPending
+	case "all":
+		filter.Status = ""
+	}
+	page, perPage := pageParams(c)
 
-	if err := c.Bind(req); err != nil {
-		log.Error("error invalid request data", sl.Err(err))
-		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
-			Error: "Invalid request data",
-		})
+	comments, err := r.CommentService.ListComments(c.Request().Context(), filter, page, perPage)
+	if err != nil {
+		log.Error("failed list comments", sl.Err(err))
+		return err
 	}
 
-	if err := c.Validate(req); err != nil {
-		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
-			Error: err.Error(),
-		})
+	setPaginationLinks(c, comments.Meta)
+
+	return c.JSON(http.StatusOK, comments)
+}
+
+// ModerateComment godoc
+// @Summary Модерация комментария
+// @Description Меняет статус комментария (pending, approved, spam, rejected)
+// @Tags Комментарии
+// @Accept json
+// @Produce json
+// @Param id path string true "UUID комментария" format(uuid)
+// @Param request body dto.UpdateCommentStatusRequest true "Новый статус"
+// @Success 200 {object} dto.CommentResponse
+// @Failure 400 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Security ApiKeyAuth
+// @Router /api/v1/comments/{id}/status [patch]
+func (r *Routers) ModerateComment(c echo.Context) error {
+	const op = "http.routers.ModerateComment"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	moderatorID, ok := currentUserID(c)
+	if !ok {
+		return errAuthRequired
 	}
 
-	ownerID, err := uuid.Parse(req.OwnerID)
+	commentID, err := uuidParam(c, "id")
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
-			Error: err.Error(),
-		})
+		log.Error("invalid comment ID format", sl.Err(err))
+		return err
 	}
 
-	groupID, err := r.MediaService.AttachMedia(c.Request().Context(), ownerID, req.Description)
-	if err != nil {
-		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
-			Error: err.Error(),
-		})
+	var req dto.UpdateCommentStatusRequest
+	if err := c.Bind(&req); err != nil {
+		log.Error("invalid request data", sl.Err(err))
+		return errInvalidBody.Wrap(err)
 	}
 
-	response := map[string]interface{}{
-		"data": groupID,
+	if err := c.Validate(req); err != nil {
+		log.Error("validation failed", sl.Err(err))
+		return err
 	}
 
-	return c.JSON(http.StatusOK, response)
+	comment, err := r.CommentService.ModerateComment(c.Request().Context(), commentID, moderatorID, req.Status)
+	if err != nil {
+		log.Error("failed moderate comment", sl.Err(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, comment)
 }
 
-// ListGroupMedia godoc
-// @Summary Получить медиа группы
-// @Description Возвращает список всех медиафайлов в группе
-// @Tags Медиа-группы
-// @Produce json
-// @Param group_id path string true "UUID группы" format(uuid)
-// @Success 200 {array} models.Media "Список медиафайлов"
-// @Failure 400 {object} response.ErrorResponse "Невалидный UUID группы"
-// @Failure 500 {object} response.ErrorResponse "Ошибка получения списка"
+// DeleteComment godoc
+// @Summary Удалить комментарий
+// @Description Удаляет комментарий вместе со всеми ответами на него
+// @Tags Комментарии
+// @Param id path string true "UUID комментария" format(uuid)
+// @Success 204
+// @Failure 400 {object} response.Problem
+// @Failure 404 {object} response.Problem
 // @Security ApiKeyAuth
-// @Router /api/v1/media/groups/{group_id} [get]
-func (r *Routers) ListGroupMedia(c echo.Context) error {
-	const op = "http.routers.ListGroupMedia"
+// @Router /api/v1/comments/{id} [delete]
+func (r *Routers) DeleteComment(c echo.Context) error {
+	const op = "http.routers.DeleteComment"
 
 	log := r.log.With(
 		slog.String("op", op),
 	)
 
-	req := new(dto.ListGroupMediaRequest)
-	if err := c.Bind(req); err != nil {
-		log.Error("invalid query parameters", sl.Err(err))
-		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
-			Error: "Invalid query parameters",
-		})
+	commentID, err := uuidParam(c, "id")
+	if err != nil {
+		log.Error("invalid comment ID format", sl.Err(err))
+		return err
 	}
 
-	if err := c.Validate(req); err != nil {
-		log.Error("validataion failed", sl.Err(err))
-		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
-			Error:   "Validation failed",
-			Details: err.Error(),
-		})
+	if err := r.CommentService.DeleteComment(c.Request().Context(), commentID); err != nil {
+		log.Error("failed delete comment", sl.Err(err))
+		return err
 	}
 
-	groupID, err := uuid.Parse(req.GroupID)
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ListAuditEvents godoc
+// @Summary Журнал аудита
+// @Description Действия администраторов и события безопасности, новые первыми
+// @Tags Журнал аудита
+// @Produce json
+// @Param action query string false "Действие, например post.publish или user.login_failed"
+// @Param actor_id query string false "UUID инициатора" format(uuid)
+// @Param api_key_id query string false "UUID ключа API, с которым выполнено действие" format(uuid)
+// @Param target_type query string false "Тип объекта (post, gallery, media, user)"
+// @Param target_id query string false "Идентификатор объекта"
+// @Param from query string false "Начало периода (RFC3339 или YYYY-MM-DD)"
+// @Param to query string false "Конец периода (RFC3339 или YYYY-MM-DD)"
+// @Param page query int false "Номер страницы" default(1)
+// @Param per_page query int false "Количество элементов на странице" default(10)
+// @Param cursor query string false "Значение next_cursor предыдущей страницы. Если задан, page не учитывается"
+// @Success 200 {object} dto.AuditEventListResponse
+// @Header 200 {string} Link "Ссылки first, prev, next и last (RFC 8288)"
+// @Failure 400 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Security ApiKeyAuth
+// @Router /api/v1/admin/audit [get]
+func (r *Routers) ListAuditEvents(c echo.Context) error {
+	const op = "http.routers.ListAuditEvents"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	filter, err := auditFilterFromQuery(c)
 	if err != nil {
-		log.Error("failed parse uuid", sl.Err(err))
-		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
-			Error:   "Faield parse",
-			Details: err.Error(),
-		})
+		return err
 	}
+	page, perPage := pageParams(c)
 
-	media, err := r.MediaService.ListGroupMedia(c.Request().Context(), groupID)
+	events, err := r.AuditService.ListEvents(c.Request().Context(), filter, page, perPage)
 	if err != nil {
-		log.Error("failed list group", sl.Err(err))
-		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
-			Error: err.Error(),
-		})
+		log.Error("failed list audit events", sl.Err(err))
+		return err
 	}
 
-	response := map[string]interface{}{
-		"data": media,
-		"meta": map[string]interface{}{
-			"count":    len(media),
-			"group_id": groupID,
-		},
-	}
+	setPaginationLinks(c, events.Meta)
 
-	return c.JSON(http.StatusOK, response)
+	return c.JSON(http.StatusOK, events)
 }
 
-// GetAllImages godoc
-// @Summary Получить все изображения
-// @Description Возвращает список всех загруженных изображений с метаданными
-// @Tags Медиа
-// @Accept  json
-// @Produce  json
-// @Success 200 {object} map[string]interface{} "Успешный ответ"
-// @SuccessExample {json} Успешный ответ:
-//
-//	{
-//	    "data": [
-//	        {
-//	            "id": "550e8400-e29b-41d4-a716-446655440000",
-//	            "uploader_id": "550e8400-e29b-41d4-a716-446655440000",
-//	            "created_at": "2025-06-06T11:08:12Z",
-//	            "original_filename": "nature.jpg",
-//	            "storage_path": "images/2025/06/550e8400-e29b-41d4-a716-446655440000.jpg",
-//	            "file_size": 102400,
-//	            "mime_type": "image/jpeg",
-//	            "width": 1920,
-//	            "height": 1080,
-//	            "is_public": true,
-//	            "metadata": {}
-//	        }
-//	    ],
-//	    "meta": {
-//	        "count": 1
-//	    }
-//	}
-//
-// @Failure 500 {object} response.ErrorResponse "Внутренняя ошибка сервера"
-// @Router /api/v1/media/images [get]
-func (r *Routers) GetAllImages(c echo.Context) error {
-	const op = "http.routers.GetAllImages"
+// ListAPIKeys godoc
+// @Summary Ключи API пользователей
+// @Description Все ключи API или ключи одного пользователя
+// @Tags Администрирование пользователей
+// @Produce json
+// @Param user_id query string false "UUID владельца" format(uuid)
+// @Success 200 {array} dto.APIKeyResponse
+// @Failure 400 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Security ApiKeyAuth
+// @Router /api/v1/admin/api-keys [get]
+func (r *Routers) ListAPIKeys(c echo.Context) error {
+	const op = "http.routers.ListAPIKeys"
 
 	log := r.log.With(
 		slog.String("op", op),
 	)
 
-	// Получаем параметр limit из query (по умолчанию 0 — без лимита)
-	limit := c.QueryParam("limit")
-	var limitInt int
-	if limit != "" {
-		var err error
-		limitInt, err = strconv.Atoi(limit)
+	var ownerID *uuid.UUID
+	if userID := c.QueryParam("user_id"); userID != "" {
+		id, err := uuid.Parse(userID)
 		if err != nil {
-			log.Error("invalid limit parameter", sl.Err(err))
-			return c.JSON(http.StatusBadRequest, response.ErrorResponse{
-				Error: "limit must be a valid integer",
-			})
+			return apperr.InvalidField("user_id", "uuid", "invalid user_id format")
 		}
+		ownerID = &id
 	}
 
-	media, total, err := r.MediaService.GetAllImages(c.Request().Context(), limitInt)
+	keys, err := r.APIKeyService.ListKeys(c.Request().Context(), ownerID)
 	if err != nil {
-		log.Error("failed get images", sl.Err(err))
-		return c.JSON(http.StatusInternalServerError, response.ErrorResponse{
-			Error: err.Error(),
-		})
+		log.Error("failed list api keys", sl.Err(err))
+		return err
 	}
 
-	response := map[string]interface{}{
-		"data":  media,
-		"total": total,
-		"meta": map[string]interface{}{
-			"count": len(media),
-		},
-	}
-	return c.JSON(http.StatusOK, response)
+	return c.JSON(http.StatusOK, keys)
 }
 
-func (r *Routers) GetImages(c echo.Context) error {
-	const op = "http.routers.GetImages"
+// RevokeAPIKey godoc
+// @Summary Отозвать ключ API пользователя
+// @Tags Администрирование пользователей
+// @Param id path string true "UUID ключа" format(uuid)
+// @Success 204
+// @Failure 400 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Failure 404 {object} response.Problem "Ключ не найден или уже отозван"
+// @Security ApiKeyAuth
+// @Router /api/v1/admin/api-keys/{id} [delete]
+func (r *Routers) RevokeAPIKey(c echo.Context) error {
+	const op = "http.routers.RevokeAPIKey"
 
 	log := r.log.With(
-
 		slog.String("op", op),
 	)
 
-	media, err := r.MediaService.GetImages(c.Request().Context())
-
+	keyID, err := uuidParam(c, "id")
 	if err != nil {
-		log.Error("invalid limit parameter", sl.Err(err))
-		return c.JSON(http.StatusBadRequest, response.ErrorResponse{
-			Error: "limit must be a valid integer",
-		})
-
+		log.Error("invalid api key ID format", sl.Err(err))
+		return err
 	}
 
-	response := map[string]interface{}{
-		"data": media,
-		"meta": map[string]interface{}{
-			"count": len(media),
-		},
+	if err := r.APIKeyService.RevokeKey(c.Request().Context(), keyID, nil); err != nil {
+		log.Warn("failed revoke api key", sl.Err(err))
+		return err
 	}
 
-	return c.JSON(http.StatusOK, response)
+	return c.NoContent(http.StatusNoContent)
 }
 
-func (r *Routers) parseMediaUploadInput(c echo.Context) (*dto.MediaUploadInput, error) {
-	uploaderID, err := uuid.Parse(c.FormValue("uploader_id"))
-	if err != nil {
-		return nil, err
+// auditFilterFromQuery разбирает фильтр журнала аудита из query-параметров
+func auditFilterFromQuery(c echo.Context) (dto.AuditEventFilter, error) {
+	filter := dto.AuditEventFilter{
+		Action:     c.QueryParam("action"),
+		TargetType: c.QueryParam("target_type"),
+		TargetID:   c.QueryParam("target_id"),
+		Cursor:     c.QueryParam("cursor"),
 	}
 
-	var metadata map[string]any
-	if metaStr := c.FormValue("metadata"); metaStr != "" {
-		if err := json.Unmarshal([]byte(metaStr), &metadata); err != nil {
-			return nil, err
+	if actorID := c.QueryParam("actor_id"); actorID != "" {
+		id, err := uuid.Parse(actorID)
+		if err != nil {
+			return filter, apperr.InvalidField("actor_id", "uuid", "invalid actor_id format")
 		}
+		filter.ActorID = &id
 	}
 
-	input := &dto.MediaUploadInput{
-		UploaderID:     uploaderID,
-		MediaType:      c.FormValue("media_type"),
-		IsPublic:       c.FormValue("is_public") == "true",
-		CustomMetadata: metadata,
+	if apiKeyID := c.QueryParam("api_key_id"); apiKeyID != "" {
+		id, err := uuid.Parse(apiKeyID)
+		if err != nil {
+			return filter, apperr.InvalidField("api_key_id", "uuid", "invalid api_key_id format")
+		}
+		filter.APIKeyID = &id
 	}
 
-	if widthStr := c.FormValue("width"); widthStr != "" {
-		if width, err := strconv.Atoi(widthStr); err == nil {
-			input.Width = &width
+	if from := c.QueryParam("from"); from != "" {
+		date, _, err := parseDateParam(from)
+		if err != nil {
+			return filter, apperr.InvalidField("from", "date", "invalid from date format")
 		}
+		filter.From = &date
 	}
-	if heightStr := c.FormValue("height"); heightStr != "" {
-		if height, err := strconv.Atoi(heightStr); err == nil {
-			input.Height = &height
+
+	if to := c.QueryParam("to"); to != "" {
+		date, dateOnly, err := parseDateParam(to)
+		if err != nil {
+			return filter, apperr.InvalidField("to", "date", "invalid to date format")
 		}
-	}
-	if durationStr := c.FormValue("duration"); durationStr != "" {
-		if duration, err := strconv.Atoi(durationStr); err == nil {
-			input.Duration = &duration
+		// Дата без времени включает весь день целиком
+		if dateOnly {
+			date = date.Add(24*time.Hour - time.Nanosecond)
 		}
+		filter.To = &date
 	}
 
-	return input, nil
+	return filter, nil
 }
 
-// CreatePost godoc
-// @Summary Создать новый пост
-// @Description Создает новый пост блога. Добавлять только authod_id -> существующий пользователь. Добавлять FeaturedImageID -> только существующую медиа
-// @Tags Посты
-// @Accept json
+// ListUsers godoc
+// @Summary Список пользователей
+// @Description Поиск пользователей по имени, email или телефону с фильтрами по роли и статусу
+// @Tags Администрирование пользователей
 // @Produce json
-// @Param request body dto.CreateBlogPostRequest true "Данные поста"
-// @Success 201 {object} dto.BlogPostResponse
-// @Failure 400 {object} response.ErrorResponse
-// @Failure 500 {object} response.ErrorResponse
+// @Param q query string false "Подстрока имени, email или телефона"
+// @Param role query string false "Роль (user, editor, admin)"
+// @Param status query string false "Статус (active, suspended, anonymized)"
+// @Param page query int false "Номер страницы" default(1)
+// @Param per_page query int false "Количество элементов на странице" default(10)
+// @Param cursor query string false "Значение next_cursor предыдущей страницы. Если задан, page не учитывается"
+// @Success 200 {object} dto.UserListResponse
+// @Header 200 {string} Link "Ссылки first, prev, next и last (RFC 8288)"
+// @Failure 400 {object} response.Problem
+// @Failure 403 {object} response.Problem
 // @Security ApiKeyAuth
-// @Router /api/v1/posts [post]
-// Добавлять только authod_id -> существующий пользователь
-// Добавлять FeaturedImageID -> только существующую медиа
-func (r *Routers) CreatePost(c echo.Context) error {
-	const op = "http.routers.CreatePost"
+// @Router /api/v1/admin/users [get]
+func (r *Routers) ListUsers(c echo.Context) error {
+	const op = "http.routers.ListUsers"
 
 	log := r.log.With(
 		slog.String("op", op),
 	)
 
-	var req dto.CreateBlogPostRequest
-
-	if err := c.Bind(&req); err != nil {
-		log.Error("invalid request data", sl.Err(err))
-		return c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid request data"})
+	filter := dto.UserFilter{
+		Query:  c.QueryParam("q"),
+		Role:   c.QueryParam("role"),
+		Status: c.QueryParam("status"),
+		Cursor: c.QueryParam("cursor"),
 	}
+	page, perPage := pageParams(c)
 
-	// if err := c.Validate(req); err != nil {
-	// 	log.Error("validation failed", sl.Err(err))
-	// 	return c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
-	// }
-
-	post, err := r.BlogService.CreatePost(c.Request().Context(), req)
+	users, err := r.UserService.ListUsers(c.Request().Context(), filter, page, perPage)
 	if err != nil {
-		log.Error("error create post", sl.Err(err))
-		return c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+		log.Error("failed list users", sl.Err(err))
+		return err
 	}
 
-	return c.JSON(http.StatusCreated, post)
+	setPaginationLinks(c, users.Meta)
+
+	return c.JSON(http.StatusOK, users)
 }
 
-// GetPost godoc
-// @Summary Получить пост
-// @Description Возвращает пост по его ID
-// @Tags Посты
-// @Produce json
-// @Param id path string true "UUID поста" format(uuid)
-// @Success 200 {object} dto.BlogPostResponse
-// @Failure 404 {object} response.ErrorResponse
-// @Failure 500 {object} response.ErrorResponse
+// GetUser godoc
+// @Summary Пользователь
+// @Description Возвращает профиль пользователя со статусом блокировки. Хеш пароля не возвращается
+// @Tags Администрирование пользователей
+// @Produce json
+// @Param id path string true "UUID пользователя" format(uuid)
+// @Success 200 {object} dto.UserResponse
+// @Failure 400 {object} response.Problem
+// @Failure 404 {object} response.Problem
 // @Security ApiKeyAuth
-// @Router /api/v1/posts/{id} [get]
-func (r *Routers) GetPost(c echo.Context) error {
-	const op = "http.routers.GetPost"
+// @Router /api/v1/admin/users/{id} [get]
+func (r *Routers) GetUser(c echo.Context) error {
+	const op = "http.routers.GetUser"
 
 	log := r.log.With(
 		slog.String("op", op),
 	)
 
-	postID, err := uuid.Parse(c.Param("id"))
+	userID, err := uuidParam(c, "id")
 	if err != nil {
-		log.Error("invalid post id format", sl.Err(err))
-		return c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid post ID format"})
+		log.Error("invalid user ID format", sl.Err(err))
+		return err
 	}
 
-	post, err := r.BlogService.GetPostByID(c.Request().Context(), postID)
+	user, err := r.UserService.GetUser(c.Request().Context(), userID)
 	if err != nil {
-		log.Error("error get post by id", sl.Err(err))
-		return c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "error get post by id"})
+		log.Error("failed get user", sl.Err(err))
+		return err
 	}
 
-	return c.JSON(http.StatusOK, post)
+	return c.JSON(http.StatusOK, user)
 }
 
-// UpdatePost godoc
-// @Summary Обновить пост
-// @Description Обновляет данные поста
-// @Tags Посты
+// ChangeUserRole godoc
+// @Summary Изменить роль пользователя
+// @Description Назначает роль user, editor или admin. Свою роль менять нельзя, последнего администратора понизить нельзя
+// @Tags Администрирование пользователей
 // @Accept json
 // @Produce json
-// @Param id path string true "UUID поста" format(uuid)
-// @Param request body dto.UpdateBlogPostRequest true "Данные для обновления"
-// @Success 200 {object} dto.BlogPostResponse
-// @Failure 400 {object} response.ErrorResponse
-// @Failure 404 {object} response.ErrorResponse
-// @Failure 500 {object} response.ErrorResponse
+// @Param id path string true "UUID пользователя" format(uuid)
+// @Param request body dto.UpdateUserRoleRequest true "Новая роль"
+// @Success 200 {object} dto.UserResponse
+// @Failure 400 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Failure 409 {object} response.Problem
 // @Security ApiKeyAuth
-// @Router /api/v1/posts/{id} [put]
-func (r *Routers) UpdatePost(c echo.Context) error {
-	const op = "http.routers.UpdatePost"
+// @Router /api/v1/admin/users/{id}/role [put]
+func (r *Routers) ChangeUserRole(c echo.Context) error {
+	const op = "http.routers.ChangeUserRole"
 
 	log := r.log.With(
 		slog.String("op", op),
 	)
 
-	postID, err := uuid.Parse(c.Param("id"))
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return errAuthRequired
+	}
+
+	userID, err := uuidParam(c, "id")
 	if err != nil {
-		log.Error("invalid post ID format", sl.Err(err))
-		return c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid post ID format"})
+		log.Error("invalid user ID format", sl.Err(err))
+		return err
 	}
 
-	req := new(dto.UpdateBlogPostRequest)
-	if err := c.Bind(req); err != nil {
+	var req dto.UpdateUserRoleRequest
+	if err := bindRequest(c, &req); err != nil {
 		log.Error("invalid request data", sl.Err(err))
-		return c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid request data"})
+		return err
 	}
 
-	// if err := c.Validate(req); err != nil {
-	// 	log.Error("failed validate data", sl.Err(err))
-	// 	return c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
-	// }
-
-	post, err := r.BlogService.UpdatePost(c.Request().Context(), postID, *req)
+	user, err := r.UserService.ChangeRole(c.Request().Context(), actorID, userID, req.Role)
 	if err != nil {
-		log.Error("failed update post", sl.Err(err))
-		return c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "Error update post"})
+		log.Error("failed change user role", sl.Err(err))
+		return err
 	}
 
-	return c.JSON(http.StatusOK, post)
+	return c.JSON(http.StatusOK, user)
 }
 
-// DeletePost godoc
-// @Summary Удалить пост
-// @Description Удаляет пост (физическое удаление)
-// @Tags Посты
-// @Param id path string true "UUID поста" format(uuid)
-// @Success 204
-// @Failure 400 {object} response.ErrorResponse
-// @Failure 404 {object} response.ErrorResponse
-// @Failure 500 {object} response.ErrorResponse
+// SuspendUser godoc
+// @Summary Заблокировать пользователя
+// @Description Запрещает вход и отзывает refresh-токены пользователя
+// @Tags Администрирование пользователей
+// @Accept json
+// @Produce json
+// @Param id path string true "UUID пользователя" format(uuid)
+// @Param request body dto.SuspendUserRequest false "Причина блокировки"
+// @Success 200 {object} dto.UserResponse
+// @Failure 400 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Failure 409 {object} response.Problem
 // @Security ApiKeyAuth
-// @Router /api/v1/posts/{id} [delete]
-func (r *Routers) DeletePost(c echo.Context) error {
-	const op = "http.routers.DeletePost"
+// @Router /api/v1/admin/users/{id}/suspend [post]
+func (r *Routers) SuspendUser(c echo.Context) error {
+	const op = "http.routers.SuspendUser"
 
 	log := r.log.With(
 		slog.String("op", op),
 	)
 
-	postID, err := uuid.Parse(c.Param("id"))
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return errAuthRequired
+	}
+
+	userID, err := uuidParam(c, "id")
 	if err != nil {
-		log.Error("invalid post ID format", sl.Err(err))
-		return c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid post ID format"})
+		log.Error("invalid user ID format", sl.Err(err))
+		return err
 	}
 
-	if err := r.BlogService.DeletePost(c.Request().Context(), postID); err != nil {
-		log.Error("failed delete post", sl.Err(err))
-		return c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "failed delete post"})
+	var req dto.SuspendUserRequest
+	if err := bindRequest(c, &req); err != nil {
+		log.Error("invalid request data", sl.Err(err))
+		return err
 	}
 
-	return c.NoContent(http.StatusNoContent)
+	user, err := r.UserService.Suspend(c.Request().Context(), actorID, userID, req.Reason)
+	if err != nil {
+		log.Error("failed suspend user", sl.Err(err))
+		return err
+	}
+
+	return c.JSON(http.StatusOK, user)
 }
 
-// PublishPost godoc
-// @Summary Опубликовать пост
-// @Description Устанавливает статус поста "published"
-// @Tags Посты
-// @Param id path string true "UUID поста" format(uuid)
-// @Success 200 {object} dto.BlogPostResponse
-// @Failure 400 {object} response.ErrorResponse
-// @Failure 404 {object} response.ErrorResponse
-// @Failure 500 {object} response.ErrorResponse
+// UnsuspendUser godoc
+// @Summary Разблокировать пользователя
+// @Tags Администрирование пользователей
+// @Produce json
+// @Param id path string true "UUID пользователя" format(uuid)
+// @Success 200 {object} dto.UserResponse
+// @Failure 400 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Failure 404 {object} response.Problem
 // @Security ApiKeyAuth
-// @Router /api/v1/posts/{id}/publish [patch]
-func (r *Routers) PublishPost(c echo.Context) error {
-	const op = "http.routers.PublishPost"
+// @Router /api/v1/admin/users/{id}/suspend [delete]
+func (r *Routers) UnsuspendUser(c echo.Context) error {
+	const op = "http.routers.UnsuspendUser"
 
 	log := r.log.With(
 		slog.String("op", op),
 	)
 
-	postID, err := uuid.Parse(c.Param("id"))
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return errAuthRequired
+	}
+
+	userID, err := uuidParam(c, "id")
 	if err != nil {
-		log.Error("invalid post ID format", sl.Err(err))
-		return c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid post ID format"})
+		log.Error("invalid user ID format", sl.Err(err))
+		return err
 	}
 
-	post, err := r.BlogService.PublishPost(c.Request().Context(), postID)
+	user, err := r.UserService.Unsuspend(c.Request().Context(), actorID, userID)
 	if err != nil {
-		log.Error("failed publish post", sl.Err(err))
-		return c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "failed publish post"})
+		log.Error("failed unsuspend user", sl.Err(err))
+		return err
 	}
 
-	return c.JSON(http.StatusOK, post)
+	return c.JSON(http.StatusOK, user)
 }
 
-// ArchivePost godoc
-// @Summary Архивировать пост
-// @Description Архивирует пост (soft delete)
-// @Tags Посты
-// @Param id path string true "UUID поста" format(uuid)
-// @Success 200 {object} dto.BlogPostResponse
-// @Failure 400 {object} response.ErrorResponse
-// @Failure 404 {object} response.ErrorResponse
-// @Failure 500 {object} response.ErrorResponse
+// AnonymizeUser godoc
+// @Summary Анонимизировать пользователя
+// @Description Заменяет имя, email и телефон заглушками и удаляет пароль. Комментарии пользователя сохраняются
+// @Tags Администрирование пользователей
+// @Produce json
+// @Param id path string true "UUID пользователя" format(uuid)
+// @Success 200 {object} dto.UserResponse
+// @Failure 400 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Failure 409 {object} response.Problem
 // @Security ApiKeyAuth
-// @Router /api/v1/posts/{id}/archive [patch]
-func (r *Routers) ArchivePost(c echo.Context) error {
-	const op = "http.routers.ArchivePost"
+// @Router /api/v1/admin/users/{id}/anonymize [post]
+func (r *Routers) AnonymizeUser(c echo.Context) error {
+	const op = "http.routers.AnonymizeUser"
 
 	log := r.log.With(
 		slog.String("op", op),
 	)
 
-	postID, err := uuid.Parse(c.Param("id"))
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return errAuthRequired
+	}
+
+	userID, err := uuidParam(c, "id")
 	if err != nil {
-		log.Error("invalid post ID format", sl.Err(err))
-		return c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid post ID format"})
+		log.Error("invalid user ID format", sl.Err(err))
+		return err
 	}
 
-	post, err := r.BlogService.ArchivePost(c.Request().Context(), postID)
+	user, err := r.UserService.Anonymize(c.Request().Context(), actorID, userID)
 	if err != nil {
-		log.Error("failed archive post", sl.Err(err))
-		return c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "failed archive post"})
+		log.Error("failed anonymize user", sl.Err(err))
+		return err
 	}
 
-	return c.JSON(http.StatusOK, post)
+	return c.JSON(http.StatusOK, user)
 }
 
-// ListPosts godoc
-// @Summary Список постов
-// @Description Возвращает список постов с пагинацией и фильтрацией по статусу. http://localhost:8080/api/v1/posts?status=archived&page=1&per_page=1
-// @Tags Посты
-// @Produce json
-// @Param status query string false "Фильтр по статусу (draft, published, archived)"
-// @Param page query int false "Номер страницы" default(1)
-// @Param per_page query int false "Количество элементов на странице" default(10)
-// @Success 200 {object} dto.BlogPostListResponse
-// @Failure 400 {object} response.ErrorResponse
-// @Failure 500 {object} response.ErrorResponse
+// DeleteUser godoc
+// @Summary Удалить пользователя
+// @Description Удаляет пользователя вместе с его комментариями
+// @Tags Администрирование пользователей
+// @Param id path string true "UUID пользователя" format(uuid)
+// @Success 204
+// @Failure 400 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Failure 404 {object} response.Problem
+// @Failure 409 {object} response.Problem
 // @Security ApiKeyAuth
-// @Router /api/v1/posts [get]
-func (r *Routers) ListPosts(c echo.Context) error {
-	const op = "http.routers.ListPosts"
+// @Router /api/v1/admin/users/{id} [delete]
+func (r *Routers) DeleteUser(c echo.Context) error {
+	const op = "http.routers.DeleteUser"
 
 	log := r.log.With(
 		slog.String("op", op),
 	)
 
-	status := c.QueryParam("status")
-
-	page, err := strconv.Atoi(c.QueryParam("page"))
-	if err != nil || page < 1 {
-		page = 1
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return errAuthRequired
 	}
 
-	perPage, err := strconv.Atoi(c.QueryParam("per_page"))
-	if err != nil || perPage < 1 || perPage > 100 {
-		perPage = 10
+	userID, err := uuidParam(c, "id")
+	if err != nil {
+		log.Error("invalid user ID format", sl.Err(err))
+		return err
 	}
 
-	posts, err := r.BlogService.ListPosts(c.Request().Context(), status, page, perPage)
-	if err != nil {
-		log.Error("failed list post", sl.Err(err))
-		return c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "failed list post"})
+	if err := r.UserService.DeleteUser(c.Request().Context(), actorID, userID); err != nil {
+		log.Error("failed delete user", sl.Err(err))
+		return err
 	}
 
-	return c.JSON(http.StatusOK, posts)
+	return c.NoContent(http.StatusNoContent)
 }
 
-// AddMediaGroup godoc
-// @Summary Добавить медиа-группу к посту
-// @Description Привязывает медиа-группу к посту с указанием типа связи
-// @Tags Посты
-// @Accept json
-// @Produce json
-// @Param id path string true "UUID поста" format(uuid)
-// @Param request body dto.AddMediaGroupRequest true "Данные медиа-группы"
-// @Success 200 {object} dto.PostMediaGroupsResponse
-// @Failure 400 {object} response.ErrorResponse
-// @Failure 404 {object} response.ErrorResponse
-// @Failure 500 {object} response.ErrorResponse
+// ResetUserTwoFactor godoc
+// @Summary Сбросить 2FA пользователя
+// @Description Выключает двухфакторную аутентификацию пользователя, потерявшего устройство и коды восстановления
+// @Tags Администрирование пользователей
+// @Param id path string true "UUID пользователя" format(uuid)
+// @Success 204
+// @Failure 400 {object} response.Problem
+// @Failure 403 {object} response.Problem
+// @Failure 404 {object} response.Problem
 // @Security ApiKeyAuth
-// @Router /api/v1/posts/{id}/media-groups [post]
-func (r *Routers) AddMediaGroup(c echo.Context) error {
-	const op = "http.routers.AddMediaGroup"
+// @Router /api/v1/admin/users/{id}/2fa [delete]
+func (r *Routers) ResetUserTwoFactor(c echo.Context) error {
+	const op = "http.routers.ResetUserTwoFactor"
 
 	log := r.log.With(
 		slog.String("op", op),
 	)
 
-	postID, err := uuid.Parse(c.Param("id"))
-	if err != nil {
-		log.Error("invalid post format", sl.Err(err))
-		return c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid post ID format"})
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return errAuthRequired
 	}
 
-	req := new(dto.AddMediaGroupRequest)
-	if err := c.Bind(req); err != nil {
-		log.Error("invalid request data", sl.Err(err))
-		return c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid request data"})
+	userID, err := uuidParam(c, "id")
+	if err != nil {
+		log.Error("invalid user ID format", sl.Err(err))
+		return err
 	}
 
-	if err := c.Validate(req); err != nil {
-		log.Error("invalid validate data", sl.Err(err))
-		return c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: err.Error()})
+	if err := r.UserService.ResetTwoFactor(c.Request().Context(), actorID, userID); err != nil {
+		log.Error("failed reset two-factor", sl.Err(err))
+		return err
 	}
 
-	_, err = r.BlogService.AddMediaGroup(c.Request().Context(), postID, *req)
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetSecurityPolicy godoc
+// @Summary Настройки безопасности
+// @Tags Администрирование пользователей
+// @Produce json
+// @Success 200 {object} models.SecurityPolicy
+// @Failure 403 {object} response.Problem
+// @Security ApiKeyAuth
+// @Router /api/v1/admin/security [get]
+func (r *Routers) GetSecurityPolicy(c echo.Context) error {
+	const op = "http.routers.GetSecurityPolicy"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	policy, err := r.UserService.SecurityPolicy(c.Request().Context())
 	if err != nil {
-		log.Error("failed add media group", sl.Err(err))
-		return c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid request data"})
+		log.Error("failed get security policy", sl.Err(err))
+		return err
 	}
 
-	return c.JSON(http.StatusOK, response.Response{Data: "succesfull"})
+	return c.JSON(http.StatusOK, policy)
 }
 
-// GetPostMediaGroups godoc
-// @Summary Получить медиа-группы поста
-// @Description Возвращает список медиа-групп, привязанных к посту
-// @Tags Посты
+// UpdateSecurityPolicy godoc
+// @Summary Изменить настройки безопасности
+// @Description Требование 2FA для администраторов и редакторов действует со следующего входа
+// @Tags Администрирование пользователей
+// @Accept json
 // @Produce json
-// @Param id path string true "UUID поста" format(uuid)
-// @Param relation_type query string false "Тип связи (content, gallery, attachment)"
-// @Success 200 {object} dto.PostMediaGroupsResponse
-// @Failure 400 {object} response.ErrorResponse
-// @Failure 404 {object} response.ErrorResponse
-// @Failure 500 {object} response.ErrorResponse
+// @Param request body dto.UpdateSecurityPolicyRequest true "Настройки"
+// @Success 200 {object} models.SecurityPolicy
+// @Failure 400 {object} response.Problem
+// @Failure 403 {object} response.Problem
 // @Security ApiKeyAuth
-// @Router /api/v1/posts/{id}/media-groups [get]
-func (r *Routers) GetPostMediaGroups(c echo.Context) error {
-	const op = "http.routers.GetPostMediaGroups"
+// @Router /api/v1/admin/security [put]
+func (r *Routers) UpdateSecurityPolicy(c echo.Context) error {
+	const op = "http.routers.UpdateSecurityPolicy"
 
 	log := r.log.With(
 		slog.String("op", op),
 	)
 
-	postID, err := uuid.Parse(c.Param("id"))
+	actorID, ok := currentUserID(c)
+	if !ok {
+		return errAuthRequired
+	}
+
+	var req dto.UpdateSecurityPolicyRequest
+	if err := bindRequest(c, &req); err != nil {
+		log.Error("invalid request data", sl.Err(err))
+		return err
+	}
+
+	policy, err := r.UserService.UpdateSecurityPolicy(c.Request().Context(), actorID, req)
 	if err != nil {
-		log.Error("failed parse uuid", sl.Err(err))
-		return c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid uuid format"})
+		log.Error("failed update security policy", sl.Err(err))
+		return err
 	}
 
-	relationType := c.QueryParam("relation_type")
+	return c.JSON(http.StatusOK, policy)
+}
 
-	resp, err := r.BlogService.GetPostMediaGroups(c.Request().Context(), postID, relationType)
+// currentUserID возвращает ID пользователя, которого подтвердил middleware аутентификации
+func currentUserID(c echo.Context) (uuid.UUID, bool) {
+	p, ok := c.Get(principal.EchoKey).(principal.Principal)
+	return p.UserID, ok && p.UserID != uuid.Nil
+}
+
+// visibleStatus фильтр по статусу для публичного списка: любой статус администратору,
+// остальным только опубликованное
+func (r *Routers) visibleStatus(c echo.Context, status string) string {
+	if status == "published" || r.viewerIsAdmin(c) {
+		return status
+	}
+
+	return "published"
+}
+
+// viewerIsAdmin сообщает, что публичный маршрут вызвал администратор. Как и
+// adminOnlyMiddleware, сверяет роль из токена с базой
+func (r *Routers) viewerIsAdmin(c echo.Context) bool {
+	p, ok := c.Get(principal.EchoKey).(principal.Principal)
+	if !ok || !p.HasRole(models.RoleAdmin) {
+		return false
+	}
+
+	isAdmin, err := r.UserService.IsAdmin(c.Request().Context(), p.UserID)
 	if err != nil {
-		log.Error("failed get post media group", sl.Err(err))
-		return c.JSON(http.StatusBadRequest, response.ErrorResponse{Error: "invalid request data"})
+		r.log.Warn("failed to check admin permission", slog.String("user_id", p.UserID.String()), sl.Err(err))
+		return false
 	}
 
-	return c.JSON(http.StatusOK, resp)
+	return isAdmin
 }
 
 // CreateGalleryHandler создает новую галерею.
@@ -1246,15 +3268,14 @@ func (r *Routers) GetPostMediaGroups(c echo.Context) error {
 //	        "uploads/1221067c-cc35-4dae-b5f5-feee4bbb3e22/test3.png"
 //	    ],
 //	    "cover_image_index": 1,
-//	    "author_id": "1221067c-cc35-4dae-b5f5-feee4bbb3e22",
 //	    "status": "draft",
 //	    "tags": ["test tags", "test tags"],
 //	    "metadata": {}
 //	}
 //
 // @Success 201 {object} map[string]string "Галерея успешно создана, возвращает ID созданной галереи"
-// @Failure 400 {object} map[string]string "Некорректные данные запроса"
-// @Failure 500 {object} map[string]string "Ошибка при создании галереи"
+// @Failure 400 {object} response.Problem "Некорректные данные запроса"
+// @Failure 500 {object} response.Problem "Ошибка при создании галереи"
 // @Router /galleries [post]
 func (r *Routers) CreateGalleryHandler(c echo.Context) error {
 	const op = "http.routers.CreateGalleryHandler"
@@ -1263,19 +3284,27 @@ func (r *Routers) CreateGalleryHandler(c echo.Context) error {
 		slog.String("op", op),
 	)
 
+	authorID, ok := currentUserID(c)
+	if !ok {
+		return errAuthRequired
+	}
+
 	var req dto.CreateGalleryRequest
-	if err := c.Bind(&req); err != nil {
-		log.Error("invalid request data", sl.Err(err))
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+	if err := bindRequest(c, &req); err != nil {
+		log.Warn("invalid request data", sl.Err(err))
+		return err
 	}
+	req.AuthorID = authorID
 
 	// Вызываем сервис
 	id, err := r.GalleryService.CreateGallery(c.Request().Context(), req)
 	if err != nil {
 		log.Error("error create gallery", sl.Err(err))
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return err
 	}
 
+	r.invalidateFeeds(c)
+
 	return c.JSON(http.StatusCreated, map[string]string{"id": id.String()})
 }
 
@@ -1298,170 +3327,449 @@ func (r *Routers) CreateGalleryHandler(c echo.Context) error {
 //	        "uploads/1221067c-cc35-4dae-b5f5-feee4bbb3e22/updated-test3.png"
 //	    ],
 //	    "cover_image_index": 1,
-//	    "author_id": "1221067c-cc35-4dae-b5f5-feee4bbb3e22",
 //	    "status": "published",
 //	    "tags": ["updated test tags", "updated test tags"],
 //	    "metadata": {}
 //	}
 //
 // @Success 200 {object} map[string]string "Галерея успешно обновлена"
-// @Failure 400 {object} map[string]string "Некорректные данные запроса"
-// @Failure 500 {object} map[string]string "Ошибка при обновлении галереи"
+// @Failure 400 {object} response.Problem "Некорректные данные запроса"
+// @Failure 500 {object} response.Problem "Ошибка при обновлении галереи"
 // @Router /galleries/{id} [put]
 func (r *Routers) UpdateGalleryHandler(c echo.Context) error {
 	var req dto.UpdateGalleryRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+
+	// Вызываем сервис
+	err := r.GalleryService.UpdateGallery(c.Request().Context(), req)
+	if err != nil {
+		return err
+	}
+
+	r.invalidateFeeds(c)
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "gallery updated successfully"})
+}
+
+// UpdateGalleryStatusHandler обновляет статус галереи.
+// @Summary Обновление статуса галереи
+// @Description Обновляет статус существующей галереи на основе переданных данных.
+// @Tags Галереи
+// @Accept json
+// @Produce json
+// @Param id path string true "ID галереи"
+// @Param request body dto.UpdateGalleryStatusRequest true "Данные для обновления статуса галереи"
+//
+//	{
+//	    "status": "published"
+//	}
+//
+// @Success 200 {object} map[string]string "Статус галереи успешно обновлен"
+// @Failure 400 {object} response.Problem "Некорректные данные запроса"
+// @Failure 500 {object} response.Problem "Ошибка при обновлении статуса галереи"
+// @Router /galleries/{id}/status [put]
+// UpdateGalleryStatusHandler обрабатывает запрос на обновление статуса галереи
+func (r *Routers) UpdateGalleryStatusHandler(c echo.Context) error {
+	galleryID, err := uuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	var req dto.UpdateGalleryStatusRequest
+	if err := bindRequest(c, &req); err != nil {
+		return err
+	}
+
+	// Вызываем сервис
+	err = r.GalleryService.UpdateGalleryStatus(c.Request().Context(), galleryID, req.Status)
+	if err != nil {
+		return err
+	}
+
+	r.invalidateFeeds(c)
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "gallery status updated successfully"})
+}
+
+// DeleteGalleryHandler удаляет галерею.
+// @Summary Удаление галереи
+// @Description Удаляет существующую галерею по её ID.
+// @Tags Галереи
+// @Accept json
+// @Produce json
+// @Param id path string true "ID галереи"
+// @Success 200 {object} map[string]string "Галерея успешно удалена"
+// @Failure 400 {object} response.Problem "Некорректный ID галереи"
+// @Failure 500 {object} response.Problem "Ошибка при удалении галереи"
+// @Router /galleries/{id} [delete]
+// DeleteGalleryHandler обрабатывает запрос на удаление галереи
+func (r *Routers) DeleteGalleryHandler(c echo.Context) error {
+	galleryID, err := uuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	// Вызываем сервис
+	err = r.GalleryService.DeleteGallery(c.Request().Context(), galleryID)
+	if err != nil {
+		return err
+	}
+
+	r.invalidateFeeds(c)
+
+	return c.JSON(http.StatusOK, map[string]string{"message": "gallery deleted successfully"})
+}
+
+// AddGalleryItemsHandler добавляет изображения в галерею.
+// @Summary Добавление изображений в галерею
+// @Description Добавляет медиафайлы в конец галереи с подписями и alt-текстом.
+// @Tags Галереи
+// @Accept json
+// @Produce json
+// @Param id path string true "ID галереи"
+// @Param request body dto.AddGalleryItemsRequest true "Добавляемые изображения"
+// @Success 200 {object} dto.GalleryResponse "Обновленная галерея"
+// @Failure 400 {object} response.Problem "Некорректные данные запроса или медиафайл не найден"
+// @Failure 404 {object} response.Problem "Галерея не найдена"
+// @Failure 500 {object} response.Problem "Внутренняя ошибка сервера"
+// @Router /galleries/{id}/items [post]
+func (r *Routers) AddGalleryItemsHandler(c echo.Context) error {
+	galleryID, err := uuidParam(c, "id")
+	if err != nil {
+		return err
+	}
+
+	var req dto.AddGalleryItemsRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody.Wrap(err)
+	}
+
+	gallery, err := r.GalleryService.AddGalleryItems(c.Request().Context(), galleryID, req)
+	if err != nil {
+		return err
+	}
+
+	r.invalidateFeeds(c)
+
+	return c.JSON(http.StatusOK, gallery)
+}
+
+// UpdateGalleryItemHandler обновляет подпись и alt-текст изображения галереи.
+// @Summary Обновление изображения галереи
+// @Tags Галереи
+// @Accept json
+// @Produce json
+// @Param id path string true "ID галереи"
+// @Param media_id path string true "ID медиафайла"
+// @Param request body dto.UpdateGalleryItemRequest true "Подпись и alt-текст"
+// @Success 200 {object} dto.GalleryResponse "Обновленная галерея"
+// @Failure 400 {object} response.Problem "Некорректные данные запроса"
+// @Failure 404 {object} response.Problem "Изображение не найдено в галерее"
+// @Failure 500 {object} response.Problem "Внутренняя ошибка сервера"
+// @Router /galleries/{id}/items/{media_id} [patch]
+func (r *Routers) UpdateGalleryItemHandler(c echo.Context) error {
+	galleryID, mediaID, err := galleryItemParams(c)
+	if err != nil {
+		return err
+	}
+
+	var req dto.UpdateGalleryItemRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return errInvalidBody.Wrap(err)
 	}
 
-	// Вызываем сервис
-	err := r.GalleryService.UpdateGallery(c.Request().Context(), req)
+	gallery, err := r.GalleryService.UpdateGalleryItem(c.Request().Context(), galleryID, mediaID, req)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return err
 	}
 
-	return c.JSON(http.StatusOK, map[string]string{"message": "gallery updated successfully"})
+	return c.JSON(http.StatusOK, gallery)
 }
 
-// UpdateGalleryStatusHandler обновляет статус галереи.
-// @Summary Обновление статуса галереи
-// @Description Обновляет статус существующей галереи на основе переданных данных.
+// RemoveGalleryItemHandler убирает изображение из галереи.
+// @Summary Удаление изображения из галереи
+// @Description Убирает изображение из галереи, сам медиафайл не удаляется.
+// @Tags Галереи
+// @Produce json
+// @Param id path string true "ID галереи"
+// @Param media_id path string true "ID медиафайла"
+// @Success 200 {object} dto.GalleryResponse "Обновленная галерея"
+// @Failure 400 {object} response.Problem "Некорректный ID"
+// @Failure 404 {object} response.Problem "Изображение не найдено в галерее"
+// @Failure 500 {object} response.Problem "Внутренняя ошибка сервера"
+// @Router /galleries/{id}/items/{media_id} [delete]
+func (r *Routers) RemoveGalleryItemHandler(c echo.Context) error {
+	galleryID, mediaID, err := galleryItemParams(c)
+	if err != nil {
+		return err
+	}
+
+	gallery, err := r.GalleryService.RemoveGalleryItem(c.Request().Context(), galleryID, mediaID)
+	if err != nil {
+		return err
+	}
+
+	r.invalidateFeeds(c)
+
+	return c.JSON(http.StatusOK, gallery)
+}
+
+// ReorderGalleryItemsHandler задает порядок изображений галереи.
+// @Summary Изменение порядка изображений галереи
+// @Description Принимает полный список ID медиафайлов галереи в новом порядке.
 // @Tags Галереи
 // @Accept json
 // @Produce json
 // @Param id path string true "ID галереи"
-// @Param request body dto.UpdateGalleryStatusRequest true "Данные для обновления статуса галереи"
-//
-//	{
-//	    "status": "published"
-//	}
-//
-// @Success 200 {object} map[string]string "Статус галереи успешно обновлен"
-// @Failure 400 {object} map[string]string "Некорректные данные запроса"
-// @Failure 500 {object} map[string]string "Ошибка при обновлении статуса галереи"
-// @Router /galleries/{id}/status [put]
-// UpdateGalleryStatusHandler обрабатывает запрос на обновление статуса галереи
-func (r *Routers) UpdateGalleryStatusHandler(c echo.Context) error {
-	galleryID, err := uuid.Parse(c.Param("id"))
+// @Param request body dto.ReorderGalleryItemsRequest true "Новый порядок изображений"
+// @Success 200 {object} dto.GalleryResponse "Обновленная галерея"
+// @Failure 400 {object} response.Problem "Список не совпадает с изображениями галереи"
+// @Failure 404 {object} response.Problem "Галерея не найдена"
+// @Failure 500 {object} response.Problem "Внутренняя ошибка сервера"
+// @Router /galleries/{id}/items/order [put]
+func (r *Routers) ReorderGalleryItemsHandler(c echo.Context) error {
+	galleryID, err := uuidParam(c, "id")
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid gallery ID"})
+		return err
 	}
 
-	var req dto.UpdateGalleryStatusRequest
+	var req dto.ReorderGalleryItemsRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return errInvalidBody.Wrap(err)
 	}
 
-	// Вызываем сервис
-	err = r.GalleryService.UpdateGalleryStatus(c.Request().Context(), galleryID, req.Status)
+	gallery, err := r.GalleryService.ReorderGalleryItems(c.Request().Context(), galleryID, req)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return err
 	}
 
-	return c.JSON(http.StatusOK, map[string]string{"message": "gallery status updated successfully"})
+	r.invalidateFeeds(c)
+
+	return c.JSON(http.StatusOK, gallery)
 }
 
-// DeleteGalleryHandler удаляет галерею.
-// @Summary Удаление галереи
-// @Description Удаляет существующую галерею по её ID.
+// SetGalleryCoverHandler задает обложку галереи.
+// @Summary Выбор обложки галереи
 // @Tags Галереи
 // @Accept json
 // @Produce json
 // @Param id path string true "ID галереи"
-// @Success 200 {object} map[string]string "Галерея успешно удалена"
-// @Failure 400 {object} map[string]string "Некорректный ID галереи"
-// @Failure 500 {object} map[string]string "Ошибка при удалении галереи"
-// @Router /galleries/{id} [delete]
-// DeleteGalleryHandler обрабатывает запрос на удаление галереи
-func (r *Routers) DeleteGalleryHandler(c echo.Context) error {
-	galleryID, err := uuid.Parse(c.Param("id"))
+// @Param request body dto.SetGalleryCoverRequest true "Медиафайл обложки"
+// @Success 200 {object} dto.GalleryResponse "Обновленная галерея"
+// @Failure 400 {object} response.Problem "Некорректные данные запроса"
+// @Failure 404 {object} response.Problem "Изображение не найдено в галерее"
+// @Failure 500 {object} response.Problem "Внутренняя ошибка сервера"
+// @Router /galleries/{id}/cover [put]
+func (r *Routers) SetGalleryCoverHandler(c echo.Context) error {
+	galleryID, err := uuidParam(c, "id")
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid gallery ID"})
+		return err
 	}
 
-	// Вызываем сервис
-	err = r.GalleryService.DeleteGallery(c.Request().Context(), galleryID)
+	var req dto.SetGalleryCoverRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody.Wrap(err)
+	}
+
+	gallery, err := r.GalleryService.SetGalleryCover(c.Request().Context(), galleryID, req)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return err
 	}
 
-	return c.JSON(http.StatusOK, map[string]string{"message": "gallery deleted successfully"})
+	r.invalidateFeeds(c)
+
+	return c.JSON(http.StatusOK, gallery)
+}
+
+func galleryItemParams(c echo.Context) (uuid.UUID, uuid.UUID, error) {
+	galleryID, err := uuidParam(c, "id")
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	mediaID, err := uuidParam(c, "media_id")
+	if err != nil {
+		return uuid.Nil, uuid.Nil, err
+	}
+
+	return galleryID, mediaID, nil
 }
 
 // GetGalleryByIDHandler обрабатывает запрос на получение галереи по ID
 // GetGalleryByIDHandler возвращает галерею по её ID.
 // @Summary Получение галереи по ID
 // @Description Возвращает полную информацию о галерее по её уникальному идентификатору.
+// @Description Неопубликованные галереи видит только администратор, остальным возвращается 404.
 // @Tags Галереи
 // @Accept json
 // @Produce json
 // @Param id path string true "UUID галереи" example("1221067c-cc35-4dae-b5f5-feee4bbb3e22")
 // @Success 200 {object} map[string]string "Успешный ответ с данными галереи"
-// @Failure 400 {object} map[string]string "Некорректный формат UUID"
-// @Failure 404 {object} map[string]string "Галерея не найдена"
-// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Failure 400 {object} response.Problem "Некорректный формат UUID"
+// @Failure 404 {object} response.Problem "Галерея не найдена"
+// @Failure 500 {object} response.Problem "Внутренняя ошибка сервера"
 // @Router /galleries/{id} [get]
 func (r *Routers) GetGalleryByIDHandler(c echo.Context) error {
-	galleryID, err := uuid.Parse(c.Param("id"))
+	galleryID, err := uuidParam(c, "id")
 	if err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid gallery ID"})
+		return err
 	}
 
 	// Вызываем сервис
 	gallery, err := r.GalleryService.GetGalleryByID(c.Request().Context(), galleryID)
 	if err != nil {
-		if errors.Is(err, ErrGalleryNotFound) {
-			return c.JSON(http.StatusNotFound, map[string]string{"error": "gallery not found"})
-		}
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return err
+	}
+
+	// Как и по slug, неопубликованная галерея гостю не показывается
+	if gallery.Status != "published" && !r.viewerIsAdmin(c) {
+		return storage.ErrGalleryNotFound
 	}
 
 	return c.JSON(http.StatusOK, gallery)
 }
 
-// GetGalleriesHandler обрабатывает запрос на получение списка галерей
-// GetGalleriesHandler возвращает список галерей с фильтрацией и пагинацией.
-// @Summary Получение списка галерей
-// @Description Возвращает список галерей с возможностью фильтрации по статусу и пагинацией.
+// GetGalleryBySlugHandler возвращает галерею по её slug.
+// @Summary Получение галереи по slug
+// @Description Возвращает галерею по slug. Для прежнего slug переименованной галереи возвращает 301 на актуальный адрес.
+// @Description Неопубликованные галереи видит только администратор, остальным возвращается 404.
 // @Tags Галереи
-// @Accept json
 // @Produce json
-// @Param status query string false "Фильтр по статусу галереи" example("published")
-// @Param page query int false "Номер страницы (по умолчанию: 1)" example(1)
-// @Param per_page query int false "Количество элементов на странице (по умолчанию: 10, максимум: 100)" example(10)
-// @Success 200 {object} map[string]interface{} "Успешный ответ с данными галерей и общим количеством"
-// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
-// @Router /galleries [get]
-func (r *Routers) GetGalleriesHandler(c echo.Context) error {
-	statusFilter := c.QueryParam("status")
+// @Param slug path string true "Slug галереи" example("summer-in-paris")
+// @Success 200 {object} dto.GalleryResponse "Успешный ответ с данными галереи"
+// @Success 301 "Галерея переименована, актуальный адрес в заголовке Location"
+// @Failure 404 {object} response.Problem "Галерея не найдена"
+// @Failure 500 {object} response.Problem "Внутренняя ошибка сервера"
+// @Router /galleries/by-slug/{slug} [get]
+func (r *Routers) GetGalleryBySlugHandler(c echo.Context) error {
+	gallery, movedTo, err := r.GalleryService.GetGalleryBySlug(c.Request().Context(), c.Param("slug"), r.viewerIsAdmin(c))
+	if err != nil {
+		return err
+	}
 
-	// Устанавливаем значения по умолчанию
-	page, err := strconv.Atoi(c.QueryParam("page"))
-	if err != nil || page < 1 {
-		page = 1
+	if movedTo != "" {
+		return c.Redirect(http.StatusMovedPermanently, "/api/v1/gallery/galleries/by-slug/"+url.PathEscape(movedTo))
 	}
 
-	perPage, err := strconv.Atoi(c.QueryParam("per_page"))
-	if err != nil || perPage < 1 || perPage > 100 {
-		perPage = 10
+	return c.JSON(http.StatusOK, gallery)
+}
+
+// DownloadGalleryArchiveHandler отдает изображения галереи одним ZIP-архивом.
+// @Summary Скачивание галереи архивом
+// @Description Потоково отдает ZIP-архив опубликованной галереи с файлом manifest.json (названия, подписи, alt-тексты). Размер изображений выбирается параметром size; если уменьшенного варианта нет, в архив попадает оригинал. Число одновременных скачиваний на пользователя ограничено.
+// @Tags Галереи
+// @Produce application/zip
+// @Param id path string true "UUID галереи" example("1221067c-cc35-4dae-b5f5-feee4bbb3e22")
+// @Param size query string false "Размер изображений" Enums(original, large, medium, small) default(original)
+// @Success 200 {file} binary "ZIP-архив галереи"
+// @Failure 400 {object} response.Problem "Некорректный ID или размер"
+// @Failure 401 {object} response.Problem "Требуется авторизация"
+// @Failure 404 {object} response.Problem "Галерея не найдена"
+// @Failure 429 {object} response.Problem "Слишком много одновременных скачиваний"
+// @Failure 500 {object} response.Problem "Внутренняя ошибка сервера"
+// @Router /galleries/{id}/archive [get]
+func (r *Routers) DownloadGalleryArchiveHandler(c echo.Context) error {
+	const op = "http.Routers.DownloadGalleryArchiveHandler"
+	log := r.log.With(slog.String("op", op))
+
+	userID, ok := currentUserID(c)
+	if !ok {
+		return errAuthRequired
 	}
 
-	// Вызываем сервис
-	galleries, total, err := r.GalleryService.GetGalleries(c.Request().Context(), statusFilter, page, perPage)
+	galleryID, err := uuidParam(c, "id")
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return err
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"galleries": galleries,
-		"total":     total,
+	started := false
+	err = r.ArchiveService.StreamGalleryArchive(c.Request().Context(), galleryID, userID.String(), c.QueryParam("size"), func(filename string) io.Writer {
+		started = true
+		// Большой архив отдается дольше write_timeout сервера, запрос прерывается только отменой контекста
+		_ = http.NewResponseController(c.Response()).SetWriteDeadline(time.Time{})
+		c.Response().Header().Set(echo.HeaderContentType, "application/zip")
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+		c.Response().WriteHeader(http.StatusOK)
+		return c.Response()
 	})
+	if err == nil {
+		return nil
+	}
+
+	// Заголовки уже отправлены, клиент получит оборванный архив
+	if started {
+		log.Error("gallery archive interrupted", sl.Err(err))
+		return nil
+	}
+
+	return err
+}
+
+// CheckGallerySlugAvailabilityHandler проверяет, свободен ли slug для галереи.
+// @Summary Проверка доступности slug галереи
+// @Description Проверяет, не занят ли slug другой галереей, в том числе в истории переименований.
+// @Tags Галереи
+// @Accept json
+// @Produce json
+// @Param request body dto.SlugAvailabilityRequest true "Проверяемый slug"
+// @Success 200 {object} dto.SlugAvailabilityResponse
+// @Failure 400 {object} response.Problem "Некорректный slug"
+// @Router /galleries/slug-availability [post]
+func (r *Routers) CheckGallerySlugAvailabilityHandler(c echo.Context) error {
+	var req dto.SlugAvailabilityRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody.Wrap(err)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	resp, err := r.GalleryService.CheckSlugAvailability(c.Request().Context(), req.Slug)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// GetGalleriesHandler возвращает список галерей с фильтрацией, сортировкой и пагинацией.
+// @Summary Получение списка галерей
+// @Description Возвращает список галерей с фильтрацией по статусу, тегам, автору, дате и названию. Страница задается номером (page) или курсором (cursor) из next_cursor предыдущего ответа. http://localhost:8080/api/v1/gallery/galleries?tags=nature&tags=art&match_all=true&sort=title&per_page=20
+// @Tags Галереи
+// @Accept json
+// @Produce json
+// @Param status query string false "Фильтр по статусу галереи (draft, published, archived, all). Кроме published, только для администратора" default(published)
+// @Param tags query []string false "Теги галереи" collectionFormat(multi)
+// @Param match_all query bool false "true — галерея содержит все теги, false — любой из них" default(false)
+// @Param author_id query string false "UUID автора" format(uuid)
+// @Param from query string false "Начало периода (RFC3339 или YYYY-MM-DD)"
+// @Param to query string false "Конец периода (RFC3339 или YYYY-MM-DD)"
+// @Param q query string false "Подстрока в названии"
+// @Param sort query string false "Сортировка (created, published, title)" default(created)
+// @Param cursor query string false "Курсор следующей страницы"
+// @Param page query int false "Номер страницы (по умолчанию: 1)" example(1)
+// @Param per_page query int false "Количество элементов на странице (по умолчанию: 10, максимум: 100)" example(10)
+// @Success 200 {object} dto.GalleryListResponse "Галереи и общее количество с учетом фильтров"
+// @Header 200 {string} Link "Ссылки first, prev, next и last (RFC 8288)"
+// @Failure 400 {object} response.Problem "Некорректные параметры запроса"
+// @Failure 500 {object} response.Problem "Внутренняя ошибка сервера"
+// @Router /galleries [get]
+func (r *Routers) GetGalleriesHandler(c echo.Context) error {
+	filter, err := parseGalleryFilter(c)
+	if err != nil {
+		return err
+	}
+
+	return r.listGalleries(c, filter)
 }
 
-// GetGalleriesByTagsHandler обрабатывает запрос на получение списка галерей по тегам
 // GetGalleriesByTagsHandler возвращает список галерей, отфильтрованных по тегам.
+// Оставлен для совместимости, принимает те же параметры, что и GetGalleriesHandler.
 // @Summary Получение списка галерей по тегам
-// @Description Возвращает список галерей, отфильтрованных по указанным тегам, с возможностью выбора логики фильтрации (AND/OR).
+// @Description Возвращает список галерей, отфильтрованных по указанным тегам, с возможностью выбора логики фильтрации (AND/OR). По умолчанию только опубликованные.
 // @Tags Галереи
 // @Accept json
 // @Produce json
@@ -1470,32 +3778,118 @@ func (r *Routers) GetGalleriesHandler(c echo.Context) error {
 //	GET /galleries/by-tags?tags=nature&tags=art&match_all=true
 //
 // @Param match_all query bool false "Режим фильтрации: true — AND, false — OR (по умолчанию: false)" example(false)
-// @Success 200 {object} map[string]interface{} "Успешный ответ с данными галерей"
-// @Failure 400 {object} map[string]string "Некорректный запрос"
-// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Param status query string false "Фильтр по статусу галереи. Кроме published, только для администратора" default(published)
+// @Param page query int false "Номер страницы" default(1)
+// @Param per_page query int false "Количество элементов на странице" default(10)
+// @Success 200 {object} dto.GalleryListResponse "Успешный ответ с данными галерей"
+// @Header 200 {string} Link "Ссылки first, prev, next и last (RFC 8288)"
+// @Failure 400 {object} response.Problem "Некорректный запрос"
+// @Failure 500 {object} response.Problem "Внутренняя ошибка сервера"
 // @Router /galleries/by-tags [get]
 func (r *Routers) GetGalleriesByTagsHandler(c echo.Context) error {
-	// Получаем список тегов из запроса
-	tags := c.QueryParams()["tags"]
-	if len(tags) == 0 {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "tags parameter is required"})
+	filter, err := parseGalleryFilter(c)
+	if err != nil {
+		return err
 	}
 
-	// Получаем режим фильтрации (AND/OR)
-	matchAll, err := strconv.ParseBool(c.QueryParam("match_all"))
-	if err != nil {
-		matchAll = false
+	if len(filter.Tags) == 0 {
+		return apperr.InvalidField("tags", "required", "tags parameter is required")
 	}
 
+	return r.listGalleries(c, filter)
+}
+
+func (r *Routers) listGalleries(c echo.Context, filter dto.GalleryFilter) error {
+	filter.Status = r.visibleStatus(c, filter.Status)
+
+	page, perPage := pageParams(c)
+
 	// Вызываем сервис
-	galleries, err := r.GalleryService.GetGalleriesByTags(c.Request().Context(), tags, matchAll)
+	galleries, err := r.GalleryService.ListGalleries(c.Request().Context(), filter, page, perPage)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return err
 	}
 
-	return c.JSON(http.StatusOK, map[string]interface{}{
-		"galleries": galleries,
-	})
+	setPaginationLinks(c, galleries.Meta)
+
+	return c.JSON(http.StatusOK, galleries)
+}
+
+// parseGalleryFilter собирает фильтр списка галерей из query-параметров.
+// Без параметра status возвращаются только опубликованные галереи, другие
+// статусы listGalleries оставляет только администратору
+func parseGalleryFilter(c echo.Context) (dto.GalleryFilter, error) {
+	filter := dto.GalleryFilter{
+		Status: c.QueryParam("status"),
+		Query:  c.QueryParam("q"),
+		Sort:   c.QueryParam("sort"),
+		Cursor: c.QueryParam("cursor"),
+	}
+
+	switch filter.Status {
+	case "":
+		filter.Status = "published"
+	case "all", "draft", "published", "archived":
+	default:
+		return filter, apperr.InvalidField("status", "oneof", "invalid status filter")
+	}
+
+	switch filter.Sort {
+	case "", "created", "published", "title":
+	default:
+		return filter, apperr.InvalidField("sort", "oneof", "invalid sort, expected created, published or title")
+	}
+
+	// Теги можно передавать как повторяющимся параметром, так и через запятую
+	for _, value := range c.QueryParams()["tags"] {
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				filter.Tags = append(filter.Tags, tag)
+			}
+		}
+	}
+
+	if matchAll := c.QueryParam("match_all"); matchAll != "" {
+		value, err := strconv.ParseBool(matchAll)
+		if err != nil {
+			return filter, apperr.InvalidField("match_all", "boolean", "invalid match_all value")
+		}
+		filter.MatchAllTags = value
+	}
+
+	if authorID := c.QueryParam("author_id"); authorID != "" {
+		id, err := uuid.Parse(authorID)
+		if err != nil {
+			return filter, apperr.InvalidField("author_id", "uuid", "invalid author_id format")
+		}
+		filter.AuthorID = &id
+	}
+
+	if from := c.QueryParam("from"); from != "" {
+		date, _, err := parseDateParam(from)
+		if err != nil {
+			return filter, apperr.InvalidField("from", "date", "invalid from date format")
+		}
+		filter.DateFrom = &date
+	}
+
+	if to := c.QueryParam("to"); to != "" {
+		date, dateOnly, err := parseDateParam(to)
+		if err != nil {
+			return filter, apperr.InvalidField("to", "date", "invalid to date format")
+		}
+		// Дата без времени включает весь день целиком
+		if dateOnly {
+			date = date.Add(24*time.Hour - time.Nanosecond)
+		}
+		filter.DateTo = &date
+	}
+
+	if filter.DateFrom != nil && filter.DateTo != nil && filter.DateFrom.After(*filter.DateTo) {
+		return filter, apperr.InvalidField("from", "range", "from must be before to")
+	}
+
+	return filter, nil
 }
 
 // AddTagsHandler обрабатывает запрос на добавление тегов к галерее
@@ -1507,19 +3901,19 @@ func (r *Routers) GetGalleriesByTagsHandler(c echo.Context) error {
 // @Param gallery_id path string true "Идентификатор галереи" example("a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11")
 // @Param tags body []string true "Список тегов для добавления" example(["art", "design"])
 // @Success 204 "Теги успешно добавлены"
-// @Failure 400 {object} map[string]string "Некорректный запрос"
-// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Failure 400 {object} response.Problem "Некорректный запрос"
+// @Failure 500 {object} response.Problem "Внутренняя ошибка сервера"
 // @Router /galleries/{gallery_id}/tags [post]
 func (r *Routers) AddTagsHandler(c echo.Context) error {
 	galleryID := c.Param("gallery_id")
 
 	var tags dto.GalleryTagsRequest
 	if err := c.Bind(&tags); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return errInvalidBody.Wrap(err)
 	}
 
 	if err := r.GalleryService.AddTags(c.Request().Context(), galleryID, tags.Tags); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	return c.NoContent(http.StatusNoContent)
@@ -1534,19 +3928,19 @@ func (r *Routers) AddTagsHandler(c echo.Context) error {
 // @Param gallery_id path string true "Идентификатор галереи" example("a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11")
 // @Param tags body []string true "Список тегов для удаления" example(["old", "tag"])
 // @Success 204 "Теги успешно удалены"
-// @Failure 400 {object} map[string]string "Некорректный запрос"
-// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Failure 400 {object} response.Problem "Некорректный запрос"
+// @Failure 500 {object} response.Problem "Внутренняя ошибка сервера"
 // @Router /galleries/{gallery_id}/tags [delete]
 func (r *Routers) RemoveTagsHandler(c echo.Context) error {
 	galleryID := c.Param("gallery_id")
 
 	var tags dto.GalleryTagsRequest
 	if err := c.Bind(&tags); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return errInvalidBody.Wrap(err)
 	}
 
 	if err := r.GalleryService.RemoveTags(c.Request().Context(), galleryID, tags.Tags); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	return c.NoContent(http.StatusNoContent)
@@ -1561,19 +3955,19 @@ func (r *Routers) RemoveTagsHandler(c echo.Context) error {
 // @Param gallery_id path string true "Идентификатор галереи" example("a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11")
 // @Param tags body []string true "Новый список тегов" example(["new", "tags"])
 // @Success 204 "Теги успешно заменены"
-// @Failure 400 {object} map[string]string "Некорректный запрос"
-// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Failure 400 {object} response.Problem "Некорректный запрос"
+// @Failure 500 {object} response.Problem "Внутренняя ошибка сервера"
 // @Router /galleries/{gallery_id}/tags [put]
 func (r *Routers) ReplaceTagsHandler(c echo.Context) error {
 	galleryID := c.Param("gallery_id")
 
 	var tags dto.GalleryTagsRequest
 	if err := c.Bind(&tags); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "invalid request body"})
+		return errInvalidBody.Wrap(err)
 	}
 
 	if err := r.GalleryService.ReplaceTags(c.Request().Context(), galleryID, tags.Tags); err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	return c.NoContent(http.StatusNoContent)
@@ -1587,15 +3981,15 @@ func (r *Routers) ReplaceTagsHandler(c echo.Context) error {
 // @Produce json
 // @Param gallery_id path string true "Идентификатор галереи" example("a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11")
 // @Success 200 {object} []string "Список тегов галереи"
-// @Failure 400 {object} map[string]string "Некорректный запрос"
-// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Failure 400 {object} response.Problem "Некорректный запрос"
+// @Failure 500 {object} response.Problem "Внутренняя ошибка сервера"
 // @Router /galleries/{gallery_id}/tags [get]
 func (r *Routers) GetTagsHandler(c echo.Context) error {
 	galleryID := c.Param("gallery_id")
 
 	tags, err := r.GalleryService.GetTags(c.Request().Context(), galleryID)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	return c.JSON(http.StatusOK, tags)
@@ -1610,21 +4004,249 @@ func (r *Routers) GetTagsHandler(c echo.Context) error {
 // @Param gallery_id path string true "Идентификатор галереи" example("a0eebc99-9c0b-4ef8-bb6d-6bb9bd380a11")
 // @Param tags query []string true "Список тегов для проверки" example(["art", "design"])
 // @Success 200 {object} bool "Результат проверки"
-// @Failure 400 {object} map[string]string "Некорректный запрос"
-// @Failure 500 {object} map[string]string "Внутренняя ошибка сервера"
+// @Failure 400 {object} response.Problem "Некорректный запрос"
+// @Failure 500 {object} response.Problem "Внутренняя ошибка сервера"
 // @Router /galleries/{gallery_id}/has-tags [get]
 func (r *Routers) HasTagsHandler(c echo.Context) error {
 	galleryID := c.Param("gallery_id")
 	tags := c.QueryParams()["tags"]
 
 	if len(tags) == 0 {
-		return c.JSON(http.StatusBadRequest, map[string]string{"error": "tags parameter is required"})
+		return apperr.InvalidField("tags", "required", "tags parameter is required")
 	}
 
 	hasTags, err := r.GalleryService.HasTags(c.Request().Context(), galleryID, tags)
 	if err != nil {
-		return c.JSON(http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return err
 	}
 
 	return c.JSON(http.StatusOK, hasTags)
 }
+
+// ListGalleryTagsHandler возвращает справочник тегов галерей.
+// @Summary Справочник тегов галерей
+// @Description Возвращает теги галерей с количеством использований, отсортированные по популярности. Параметр prefix используется для автодополнения.
+// @Tags Галереи
+// @Produce json
+// @Param prefix query string false "Начало тега"
+// @Param status query string false "Статус учитываемых галерей (draft, published, archived, all)" default(published)
+// @Param limit query int false "Максимальное количество тегов" default(100)
+// @Success 200 {array} dto.GalleryTagResponse
+// @Failure 500 {object} response.Problem "Внутренняя ошибка сервера"
+// @Router /gallery/tags [get]
+func (r *Routers) ListGalleryTagsHandler(c echo.Context) error {
+	status := c.QueryParam("status")
+	if status == "" {
+		status = "published"
+	}
+
+	limit, err := strconv.Atoi(c.QueryParam("limit"))
+	if err != nil || limit < 1 {
+		limit = 100
+	}
+
+	tags, err := r.TagService.ListTags(c.Request().Context(), status, c.QueryParam("prefix"), limit)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, tags)
+}
+
+// RenameGalleryTagHandler переименовывает тег во всех галереях.
+// @Summary Переименование тега галерей
+// @Description Переименовывает тег во всех галереях одной транзакцией. С keep_alias старое имя продолжает находить галереи.
+// @Tags Галереи
+// @Accept json
+// @Produce json
+// @Param request body dto.RenameGalleryTagRequest true "Старое и новое имя тега"
+// @Success 200 {object} dto.GalleryTagOperationResponse
+// @Failure 400 {object} response.Problem "Некорректные данные запроса"
+// @Failure 500 {object} response.Problem "Внутренняя ошибка сервера"
+// @Router /gallery/tags/rename [post]
+func (r *Routers) RenameGalleryTagHandler(c echo.Context) error {
+	var req dto.RenameGalleryTagRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody.Wrap(err)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	resp, err := r.TagService.RenameTag(c.Request().Context(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// MergeGalleryTagsHandler объединяет несколько тегов в один во всех галереях.
+// @Summary Слияние тегов галерей
+// @Description Заменяет исходные теги целевым во всех галереях одной транзакцией. С keep_aliases исходные теги продолжают находить галереи.
+// @Tags Галереи
+// @Accept json
+// @Produce json
+// @Param request body dto.MergeGalleryTagsRequest true "Исходные теги и целевой тег"
+// @Success 200 {object} dto.GalleryTagOperationResponse
+// @Failure 400 {object} response.Problem "Некорректные данные запроса"
+// @Failure 500 {object} response.Problem "Внутренняя ошибка сервера"
+// @Router /gallery/tags/merge [post]
+func (r *Routers) MergeGalleryTagsHandler(c echo.Context) error {
+	var req dto.MergeGalleryTagsRequest
+	if err := c.Bind(&req); err != nil {
+		return errInvalidBody.Wrap(err)
+	}
+
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	resp, err := r.TagService.MergeTags(c.Request().Context(), req)
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, resp)
+}
+
+// ListGalleryTagAliasesHandler возвращает алиасы тегов галерей.
+// @Summary Алиасы тегов галерей
+// @Tags Галереи
+// @Produce json
+// @Success 200 {array} dto.GalleryTagAliasResponse
+// @Failure 500 {object} response.Problem "Внутренняя ошибка сервера"
+// @Router /gallery/tags/aliases [get]
+func (r *Routers) ListGalleryTagAliasesHandler(c echo.Context) error {
+	aliases, err := r.TagService.ListAliases(c.Request().Context())
+	if err != nil {
+		return err
+	}
+
+	return c.JSON(http.StatusOK, aliases)
+}
+
+// DeleteGalleryTagAliasHandler удаляет алиас тега галерей.
+// @Summary Удаление алиаса тега
+// @Tags Галереи
+// @Produce json
+// @Param alias path string true "Алиас"
+// @Success 204 "Алиас удален"
+// @Failure 400 {object} response.Problem "Некорректный алиас"
+// @Failure 404 {object} response.Problem "Алиас не найден"
+// @Failure 500 {object} response.Problem "Внутренняя ошибка сервера"
+// @Router /gallery/tags/aliases/{alias} [delete]
+func (r *Routers) DeleteGalleryTagAliasHandler(c echo.Context) error {
+	if err := r.TagService.DeleteAlias(c.Request().Context(), c.Param("alias")); err != nil {
+		return err
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetRSSFeed godoc
+// @Summary RSS лента
+// @Description Последние опубликованные посты в формате RSS 2.0
+// @Tags Ленты
+// @Produce xml
+// @Success 200 {string} string "RSS 2.0"
+// @Failure 500 {object} response.Problem
+// @Router /feed.xml [get]
+func (r *Routers) GetRSSFeed(c echo.Context) error {
+	const op = "http.routers.GetRSSFeed"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	data, err := r.FeedService.RSS(c.Request().Context())
+	if err != nil {
+		log.Error("failed build rss feed", sl.Err(err))
+		return err
+	}
+
+	return c.Blob(http.StatusOK, "application/rss+xml; charset=utf-8", data)
+}
+
+// GetAtomFeed godoc
+// @Summary Atom лента
+// @Description Последние опубликованные посты в формате Atom
+// @Tags Ленты
+// @Produce xml
+// @Success 200 {string} string "Atom"
+// @Failure 500 {object} response.Problem
+// @Router /atom.xml [get]
+func (r *Routers) GetAtomFeed(c echo.Context) error {
+	const op = "http.routers.GetAtomFeed"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	data, err := r.FeedService.Atom(c.Request().Context())
+	if err != nil {
+		log.Error("failed build atom feed", sl.Err(err))
+		return err
+	}
+
+	return c.Blob(http.StatusOK, "application/atom+xml; charset=utf-8", data)
+}
+
+// GetSitemapIndex godoc
+// @Summary Индекс sitemap
+// @Description Индекс sitemap со ссылками на страницы опубликованных постов и галерей
+// @Tags Ленты
+// @Produce xml
+// @Success 200 {string} string "Sitemap index"
+// @Failure 500 {object} response.Problem
+// @Router /sitemap.xml [get]
+func (r *Routers) GetSitemapIndex(c echo.Context) error {
+	const op = "http.routers.GetSitemapIndex"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	data, err := r.FeedService.SitemapIndex(c.Request().Context())
+	if err != nil {
+		log.Error("failed build sitemap index", sl.Err(err))
+		return err
+	}
+
+	return c.Blob(http.StatusOK, echo.MIMEApplicationXMLCharsetUTF8, data)
+}
+
+// GetSitemap godoc
+// @Summary Страница sitemap
+// @Description Страница sitemap раздела постов или галерей, например posts-1.xml
+// @Tags Ленты
+// @Produce xml
+// @Param name path string true "Имя файла из индекса sitemap"
+// @Success 200 {string} string "Sitemap"
+// @Failure 404 {object} response.Problem
+// @Failure 500 {object} response.Problem
+// @Router /sitemaps/{name} [get]
+func (r *Routers) GetSitemap(c echo.Context) error {
+	const op = "http.routers.GetSitemap"
+
+	log := r.log.With(
+		slog.String("op", op),
+	)
+
+	data, err := r.FeedService.Sitemap(c.Request().Context(), c.Param("name"))
+	if err != nil {
+		log.Error("failed build sitemap", sl.Err(err))
+		return err
+	}
+
+	return c.Blob(http.StatusOK, echo.MIMEApplicationXMLCharsetUTF8, data)
+}
+
+// invalidateFeeds сбрасывает кэш лент после изменения постов или галерей.
+// Ошибка не влияет на ответ: кэш все равно истечет по TTL
+func (r *Routers) invalidateFeeds(c echo.Context) {
+	if err := r.FeedService.Invalidate(c.Request().Context()); err != nil {
+		r.log.Warn("failed invalidate feeds", sl.Err(err))
+	}
+}
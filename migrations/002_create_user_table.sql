@@ -19,5 +19,4 @@ ALTER TABLE "public"."users" ADD CONSTRAINT "phone" UNIQUE ("phone");
 CREATE INDEX idx_users_phone ON users(phone);
 
 -- +goose Down
-DROP TABLE users;
-DROP TABLE permissions;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,41 @@
+-- +goose Up
+
+-- Иерархические категории постов блога
+CREATE TABLE blog_categories (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    parent_id UUID REFERENCES blog_categories(id) ON DELETE SET NULL, -- Родительская категория
+    name VARCHAR(100) NOT NULL,                  -- Название категории
+    slug VARCHAR(120) UNIQUE NOT NULL,           -- URL-дружественный идентификатор
+    description TEXT,                            -- Описание категории
+    position INT NOT NULL DEFAULT 0,             -- Порядок сортировки среди соседних категорий
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Общий справочник тегов постов
+CREATE TABLE blog_tags (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name VARCHAR(50) NOT NULL,                   -- Отображаемое название тега
+    slug VARCHAR(60) UNIQUE NOT NULL,            -- URL-дружественный идентификатор
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Связь постов с тегами (многие ко многим)
+CREATE TABLE post_tags (
+    post_id UUID NOT NULL REFERENCES blog_posts(id) ON DELETE CASCADE,
+    tag_id UUID NOT NULL REFERENCES blog_tags(id) ON DELETE CASCADE,
+    PRIMARY KEY (post_id, tag_id)
+);
+
+-- Категория поста
+ALTER TABLE blog_posts ADD COLUMN category_id UUID REFERENCES blog_categories(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_blog_categories_parent ON blog_categories(parent_id);
+CREATE INDEX idx_post_tags_tag ON post_tags(tag_id);
+CREATE INDEX idx_blog_posts_category ON blog_posts(category_id);
+
+-- +goose Down
+ALTER TABLE blog_posts DROP COLUMN IF EXISTS category_id;
+DROP TABLE IF EXISTS post_tags;
+DROP TABLE IF EXISTS blog_tags;
+DROP TABLE IF EXISTS blog_categories;
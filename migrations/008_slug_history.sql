@@ -0,0 +1,22 @@
+-- +goose Up
+
+-- Прежние slug'и постов, чтобы старые ссылки отдавали 301 на актуальный адрес
+CREATE TABLE post_slug_history (
+    slug VARCHAR(255) PRIMARY KEY,               -- Прежний slug
+    post_id UUID NOT NULL REFERENCES blog_posts(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Прежние slug'и галерей
+CREATE TABLE gallery_slug_history (
+    slug VARCHAR(255) PRIMARY KEY,               -- Прежний slug
+    gallery_id UUID NOT NULL REFERENCES galleries(id) ON DELETE CASCADE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_post_slug_history_post ON post_slug_history(post_id);
+CREATE INDEX idx_gallery_slug_history_gallery ON gallery_slug_history(gallery_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS gallery_slug_history;
+DROP TABLE IF EXISTS post_slug_history;
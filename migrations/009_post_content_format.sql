@@ -0,0 +1,12 @@
+-- +goose Up
+
+-- Формат исходного текста поста и отрендеренный безопасный HTML
+ALTER TABLE blog_posts
+    ADD COLUMN content_format VARCHAR(20) NOT NULL DEFAULT 'html'
+        CHECK (content_format IN ('markdown', 'html', 'plain')),
+    ADD COLUMN content_html TEXT; -- NULL, пока пост не был перерендерен
+
+-- +goose Down
+ALTER TABLE blog_posts
+    DROP COLUMN IF EXISTS content_html,
+    DROP COLUMN IF EXISTS content_format;
@@ -0,0 +1,28 @@
+-- +goose Up
+
+-- Возможность закрыть комментарии для отдельного поста
+ALTER TABLE blog_posts ADD COLUMN comments_closed BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Древовидные комментарии к постам с модерацией
+CREATE TABLE comments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    post_id UUID NOT NULL REFERENCES blog_posts(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    parent_id UUID REFERENCES comments(id) ON DELETE CASCADE, -- Ответ на комментарий
+    content TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'approved', 'spam', 'rejected')),
+    moderated_by UUID REFERENCES users(id) ON DELETE SET NULL, -- Кто последним менял статус
+    moderated_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_comments_post_status ON comments(post_id, status);
+CREATE INDEX idx_comments_status_created ON comments(status, created_at);
+CREATE INDEX idx_comments_user_created ON comments(user_id, created_at);
+CREATE INDEX idx_comments_parent ON comments(parent_id);
+
+-- +goose Down
+DROP TABLE IF EXISTS comments;
+ALTER TABLE blog_posts DROP COLUMN IF EXISTS comments_closed;
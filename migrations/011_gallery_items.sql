@@ -0,0 +1,55 @@
+-- +goose Up
+
+-- Изображения галереи как ссылки на записи media с подписью, alt-текстом и порядком
+CREATE TABLE gallery_items (
+    gallery_id UUID NOT NULL REFERENCES galleries(id) ON DELETE CASCADE,
+    media_id UUID NOT NULL REFERENCES media(id) ON DELETE CASCADE, -- Удаление медиа убирает его из галерей
+    position INT NOT NULL DEFAULT 0,
+    caption TEXT,
+    alt_text TEXT,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (gallery_id, media_id)
+);
+
+CREATE INDEX idx_gallery_items_media ON gallery_items(media_id);
+CREATE INDEX idx_gallery_items_position ON gallery_items(gallery_id, position);
+
+ALTER TABLE galleries ADD COLUMN cover_media_id UUID REFERENCES media(id) ON DELETE SET NULL;
+
+-- Переносим существующие массивы images, сопоставляя пути с media.storage_path.
+-- Пути без записи в media пропускаются.
+INSERT INTO gallery_items (gallery_id, media_id, position)
+SELECT g.id, m.id, img.ord - 1
+FROM galleries g
+CROSS JOIN LATERAL unnest(g.images) WITH ORDINALITY AS img(path, ord)
+JOIN LATERAL (
+    SELECT id FROM media
+    WHERE ltrim(storage_path, '/') = ltrim(img.path, '/')
+    ORDER BY created_at
+    LIMIT 1
+) m ON TRUE
+ON CONFLICT (gallery_id, media_id) DO NOTHING;
+
+UPDATE galleries g
+SET cover_media_id = gi.media_id
+FROM gallery_items gi
+WHERE gi.gallery_id = g.id AND gi.position = COALESCE(g.cover_image_index, 0);
+
+-- Колонки images и cover_image_index больше не заполняются и оставлены для отката
+
+-- +goose Down
+UPDATE galleries g
+SET images = COALESCE((
+        SELECT array_agg(m.storage_path ORDER BY gi.position)
+        FROM gallery_items gi
+        JOIN media m ON m.id = gi.media_id
+        WHERE gi.gallery_id = g.id
+    ), '{}'),
+    cover_image_index = COALESCE((
+        SELECT COUNT(*) FROM gallery_items gi
+        WHERE gi.gallery_id = g.id
+          AND gi.position < (SELECT position FROM gallery_items c WHERE c.gallery_id = g.id AND c.media_id = g.cover_media_id)
+    ), 0);
+
+ALTER TABLE galleries DROP COLUMN IF EXISTS cover_media_id;
+DROP TABLE IF EXISTS gallery_items;
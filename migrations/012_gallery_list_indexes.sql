@@ -0,0 +1,11 @@
+-- +goose Up
+
+-- Индексы под сортировки списка галерей и постраничный вывод по курсору
+CREATE INDEX idx_galleries_status_created ON galleries(status, created_at DESC, id DESC);
+CREATE INDEX idx_galleries_status_published ON galleries(status, (COALESCE(published_at, created_at)) DESC, id DESC);
+CREATE INDEX idx_galleries_status_title ON galleries(status, title, id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_galleries_status_title;
+DROP INDEX IF EXISTS idx_galleries_status_published;
+DROP INDEX IF EXISTS idx_galleries_status_created;
@@ -0,0 +1,15 @@
+-- +goose Up
+
+-- Прежние имена тегов галерей после переименования или слияния.
+-- Фильтры и запись тегов подменяют алиас на актуальный тег
+CREATE TABLE gallery_tag_aliases (
+    alias VARCHAR(255) PRIMARY KEY,              -- Прежнее имя тега
+    tag VARCHAR(255) NOT NULL,                   -- Актуальное имя тега
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CHECK (alias <> tag)
+);
+
+CREATE INDEX idx_gallery_tag_aliases_tag ON gallery_tag_aliases(tag);
+
+-- +goose Down
+DROP TABLE IF EXISTS gallery_tag_aliases;
@@ -0,0 +1,9 @@
+-- +goose Up
+
+-- Индексы под постраничный вывод постов по курсору и подсчет картинок
+CREATE INDEX idx_blog_posts_status_created ON blog_posts(status, created_at DESC, id DESC);
+CREATE INDEX idx_media_type_created ON media(media_type, created_at DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_media_type_created;
+DROP INDEX IF EXISTS idx_blog_posts_status_created;
@@ -0,0 +1,35 @@
+-- +goose Up
+
+-- Роль пользователя. is_admin остается для совместимости и всегда совпадает с role = 'admin'
+ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'user'
+    CHECK (role IN ('user', 'editor', 'admin'));
+UPDATE users SET role = 'admin' WHERE is_admin;
+UPDATE users SET is_admin = FALSE WHERE is_admin IS NULL;
+ALTER TABLE users ALTER COLUMN is_admin SET NOT NULL;
+ALTER TABLE users ADD CONSTRAINT users_is_admin_role CHECK (is_admin = (role = 'admin'));
+
+-- Блокировка и обезличивание учетной записи администратором
+ALTER TABLE users ADD COLUMN suspended_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN suspended_by UUID REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE users ADD COLUMN suspension_reason TEXT;
+ALTER TABLE users ADD COLUMN anonymized_at TIMESTAMPTZ;
+
+-- Кто и когда последним менял учетную запись через админку
+ALTER TABLE users ADD COLUMN updated_at TIMESTAMPTZ;
+ALTER TABLE users ADD COLUMN updated_by UUID REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX idx_users_role ON users(role);
+CREATE INDEX idx_users_registration ON users(registration_date DESC, id DESC);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_users_registration;
+DROP INDEX IF EXISTS idx_users_role;
+ALTER TABLE users DROP COLUMN IF EXISTS updated_by;
+ALTER TABLE users DROP COLUMN IF EXISTS updated_at;
+ALTER TABLE users DROP COLUMN IF EXISTS anonymized_at;
+ALTER TABLE users DROP COLUMN IF EXISTS suspension_reason;
+ALTER TABLE users DROP COLUMN IF EXISTS suspended_by;
+ALTER TABLE users DROP COLUMN IF EXISTS suspended_at;
+ALTER TABLE users DROP CONSTRAINT IF EXISTS users_is_admin_role;
+ALTER TABLE users ALTER COLUMN is_admin DROP NOT NULL;
+ALTER TABLE users DROP COLUMN IF EXISTS role;
@@ -0,0 +1,12 @@
+-- +goose Up
+
+-- Новый email пользователя ждет подтверждения и до него не используется для входа.
+-- Хранится только хеш кода подтверждения
+ALTER TABLE users ADD COLUMN pending_email VARCHAR(255);
+ALTER TABLE users ADD COLUMN pending_email_token BYTEA;
+ALTER TABLE users ADD COLUMN pending_email_expires_at TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE users DROP COLUMN IF EXISTS pending_email_expires_at;
+ALTER TABLE users DROP COLUMN IF EXISTS pending_email_token;
+ALTER TABLE users DROP COLUMN IF EXISTS pending_email;
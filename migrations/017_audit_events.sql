@@ -0,0 +1,24 @@
+-- +goose Up
+
+-- Журнал действий администраторов и событий безопасности. actor_id без внешнего
+-- ключа: записи должны переживать удаление пользователя
+CREATE TABLE audit_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    action VARCHAR(64) NOT NULL,
+    actor_id UUID,
+    ip TEXT,
+    user_agent TEXT,
+    target_type VARCHAR(32),
+    target_id TEXT,
+    before JSONB,
+    after JSONB
+);
+
+CREATE INDEX idx_audit_events_occurred ON audit_events(occurred_at DESC, id DESC);
+CREATE INDEX idx_audit_events_actor ON audit_events(actor_id, occurred_at DESC);
+CREATE INDEX idx_audit_events_target ON audit_events(target_type, target_id, occurred_at DESC);
+CREATE INDEX idx_audit_events_action ON audit_events(action, occurred_at DESC);
+
+-- +goose Down
+DROP TABLE IF EXISTS audit_events;
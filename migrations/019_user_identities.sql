@@ -0,0 +1,22 @@
+-- +goose Up
+
+-- Учетные записи внешних провайдеров OpenID Connect. subject уникален только
+-- в пределах провайдера, у пользователя не больше одной записи каждого провайдера
+CREATE TABLE user_identities (
+    provider VARCHAR(64) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_login_at TIMESTAMPTZ,
+    PRIMARY KEY (provider, subject),
+    UNIQUE (user_id, provider)
+);
+
+-- Пользователи, пришедшие через провайдера, телефон не указывают
+ALTER TABLE users ALTER COLUMN phone DROP NOT NULL;
+
+-- +goose Down
+UPDATE users SET phone = 'oidc-' || id WHERE phone IS NULL;
+ALTER TABLE users ALTER COLUMN phone SET NOT NULL;
+DROP TABLE IF EXISTS user_identities;
@@ -0,0 +1,23 @@
+-- +goose Up
+
+-- Ключи API для интеграций. Сам ключ показывается один раз при создании,
+-- в базе хранится только его SHA-256. prefix - начало ключа, по которому
+-- владелец узнает ключ в списке
+CREATE TABLE api_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL UNIQUE,
+    scopes TEXT[] NOT NULL DEFAULT '{}',
+    rate_limit INTEGER NOT NULL CHECK (rate_limit > 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX idx_api_keys_user_id ON api_keys (user_id, created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS api_keys;
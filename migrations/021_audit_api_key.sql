@@ -0,0 +1,11 @@
+-- +goose Up
+
+-- Ключ API, с которым выполнено действие. NULL для входа по токену и служебных команд.
+-- Без внешнего ключа, как и actor_id: запись переживает удаление ключа
+ALTER TABLE audit_events ADD COLUMN api_key_id UUID;
+
+CREATE INDEX idx_audit_events_api_key ON audit_events(api_key_id, occurred_at DESC) WHERE api_key_id IS NOT NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_audit_events_api_key;
+ALTER TABLE audit_events DROP COLUMN IF EXISTS api_key_id;
@@ -0,0 +1,9 @@
+// Package migrations содержит SQL-миграции схемы в формате goose, встроенные в бинарник
+package migrations
+
+import "embed"
+
+// FS файлы миграций вида 002_create_user_table.sql
+//
+//go:embed *.sql
+var FS embed.FS